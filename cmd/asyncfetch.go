@@ -38,13 +38,20 @@ func asyncFetchCmdBuilder(cfg *iqshell.Config) *cobra.Command {
 	_ = cmd.Flags().MarkDeprecated("storage-type", "use --file-type instead") // 废弃 storage-type
 
 	cmd.Flags().BoolVarP(&info.Overwrite, "overwrite", "", false, "overwrite the file of same key in bucket")
+	cmd.Flags().BoolVarP(&info.SkipExisting, "skip-existing", "", false, "check whether the file exists in bucket before fetch, skip it if it is the same, a different one is still submitted but not overwritten by the fetch api; the hash (or the size when no hash) in input file is compared. stdin input is not checked in advance, existing files are skipped by the fetch api. ignored when --overwrite is set")
 	cmd.Flags().StringVarP(&info.BatchInfo.InputFile, "input-file", "i", "", "input file with urls")
+	setBatchCmdInputFormatFlags(cmd, &info.BatchInfo)
 	cmd.Flags().IntVarP(&info.BatchInfo.WorkerCount, "thread-count", "c", 20, "thread count")
 	cmd.Flags().BoolVarP(&info.BatchInfo.EnableRecord, "enable-record", "", false, "record work progress, and do from last progress while retry")
 	cmd.Flags().BoolVarP(&info.BatchInfo.RecordRedoWhileError, "record-redo-while-error", "", false, "when re-executing the command and checking the command task progress record, if a task has already been done and failed, the task will be re-executed. The default is false, and the task will not be re-executed when it detects that the task fails")
 	cmd.Flags().BoolVarP(&info.DisableCheckFetchResult, "disable-check-fetch-result", "", false, "not check async result after fetch")
+	cmd.Flags().BoolVarP(&info.Wait, "wait", "", false, "poll the status of fetch jobs until done or timeout, the jobs timeout will be exported to failure list with job id")
+	cmd.Flags().IntVarP(&info.WaitInterval, "wait-interval", "", 3, "interval of polling the fetch job status in seconds, depends on --wait")
+	cmd.Flags().IntVarP(&info.WaitTimeout, "wait-timeout", "", 0, "timeout of waiting for each fetch job in seconds, depends on --wait; the default 0 means depending on the file size")
+	cmd.Flags().StringSliceVarP(&info.MirrorHosts, "mirror-hosts", "", nil, "mirror hosts of the source, split by comma; when the source is temporarily unavailable(5xx or timeout), the host of url will be replaced by these hosts in turn and fetch again")
 	cmd.Flags().StringVarP(&info.BatchInfo.SuccessExportFilePath, "success-list", "s", "", "success fetch list")
 	cmd.Flags().StringVarP(&info.BatchInfo.FailExportFilePath, "failure-list", "e", "", "error fetch list")
+	setDeadletterFlags(cmd, &info.BatchInfo.FileExporterConfig)
 
 	return cmd
 }
@@ -104,6 +104,8 @@ var listBucketCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	}
 	cmd.Flags().StringVarP(&info.Prefix, "prefix", "p", "", "list by prefix")
 	cmd.Flags().StringVarP(&info.SaveToFile, "out", "o", "", "output file")
+	setListFilterFlags(cmd, &info)
+	setListColumnsFlags(cmd, &info)
 	return cmd
 }
 
@@ -144,6 +146,7 @@ var listBucketCmd2Builder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().StringVarP(&info.MimeTypes, "mimetypes", "", "", "Specify mimetype, separated by comma, all files will be listed according to the prefix and then filtered.")
 	cmd.Flags().StringVarP(&info.MinFileSize, "min-file-size", "", "", "Specify min file size, all files will be listed according to the prefix and then filtered.")
 	cmd.Flags().StringVarP(&info.MaxFileSize, "max-file-size", "", "", "Specify max file size, all files will be listed according to the prefix and then filtered.")
+	setListFilterFlags(cmd, &info)
 
 	cmd.Flags().BoolVarP(&info.AppendMode, "append", "a", false, "result append to file instead of overwriting")
 	cmd.Flags().BoolVarP(&info.Readable, "readable", "r", false, "present file size with human readable format")
@@ -154,11 +157,325 @@ var listBucketCmd2Builder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().BoolVarP(&info.EnableRecord, "enable-record", "", false, "record the execution status of the listbucket2 command. When the listbucket2 command is executed next time, the marker will be automatically filled and the listbucket2 will continue. Enabling this option will automatically enable append (see the --append option for details). The id of the record is related to the bucket where the file is located, the prefix listed, and the path where the file is saved.")
 
 	cmd.Flags().StringVarP(&info.OutputFieldsSep, "output-fields-sep", "", data.DefaultLineSeparate, "Each line needs to display the delimiter of the file information.")
-	cmd.Flags().StringVarP(&info.ShowFields, "show-fields", "", "", "The file attributes to be displayed on each line, separated by commas. Optional range: Key, Hash, FileSize, PutTime, MimeType, FileType, EndUser.")
+	cmd.Flags().StringVarP(&info.ShowFields, "show-fields", "", "", "The file attributes to be displayed on each line, separated by commas. Optional range: Key, Hash, FileSize, PutTime, MimeType, FileType, EndUser, RestoreStatus, RestoreExpiry. RestoreStatus and RestoreExpiry are not displayed by default and are n/a for objects that are not archived.")
+	setListColumnsFlags(cmd, &info)
 
+	cmd.Flags().StringVarP(&info.ShardPrefixes, "shard-prefixes", "", "", "list the bucket in parallel by shard prefixes, which are appended to --prefix and separated by commas, or hex:N for all hex prefixes of length N, like hex:2 for 00~ff. Keys that do not match any shard prefix are listed too, and nothing is listed twice. It can't be used with --marker and --enable-record.")
+	cmd.Flags().IntVarP(&info.ShardWorkerCount, "shard-worker-count", "", 10, "the max number of shards listed concurrently when --shard-prefixes is set")
+
+	return cmd
+}
+
+var bucketUsageCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.UsageInfo{}
+	var cmd = &cobra.Command{
+		Use:   "bucketusage <Bucket>",
+		Short: "Count the files and total size of the bucket grouped by key prefix and storage type",
+		Long:  "List the bucket and count the files and total size grouped by key prefix and storage type, like du. Each line is displayed in the following order:\n Prefix\tStorageType\tCount\tSize",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.BucketUsageType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.Usage(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.Prefix, "prefix", "p", "", "only count the files with the prefix")
+	cmd.Flags().IntVarP(&info.Depth, "group-by-prefix-depth", "", 0, "group the files by the key prefix up to this depth, the prefix is separated by /. 0 means only the total is output")
+	cmd.Flags().IntVarP(&info.MaxRetry, "max-retry", "x", 20, "max retries when error occurred while listing, -1 means retry forever")
+	cmd.Flags().BoolVarP(&info.Readable, "readable", "r", false, "present file size with human readable format")
+	return cmd
+}
+
+var findDuplicatesCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.FindDuplicatesInfo{}
+	var cmd = &cobra.Command{
+		Use:   "findduplicates <Bucket>",
+		Short: "Find the files with the same content but different keys in the bucket",
+		Long:  "List the bucket and group the files by hash, the groups with more than one file are output. Each group is displayed in the following order:\n Hash\tCount\tSize\tWastedSize\n followed by one line for each key, the first key is the one to keep.",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.FindDuplicatesType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.FindDuplicates(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.Prefix, "prefix", "p", "", "only find the files with the prefix")
+	cmd.Flags().IntVarP(&info.MaxRetry, "max-retry", "x", 20, "max retries when error occurred while listing, -1 means retry forever")
+	cmd.Flags().BoolVarP(&info.Readable, "readable", "r", false, "present file size with human readable format")
+	cmd.Flags().StringVarP(&info.DeleteListFile, "delete-list", "", "", "save the keys of the duplicate files to this file, one copy of each group is kept; the file can be used as the input of batchdelete")
+	return cmd
+}
+
+var lifecycleCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "lifecycle",
+		Short: "Manage the lifecycle rules of buckets",
+		Args:  cobra.MaximumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.LifecycleType
+			if !iqshell.ShowDocumentIfNeeded(cfg) {
+				_ = cmd.Help()
+			}
+		},
+	}
+	return cmd
+}
+
+var lifecycleListCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.LifecycleListInfo{}
+	var cmd = &cobra.Command{
+		Use:     "list <Bucket>",
+		Short:   "List the lifecycle rules of the bucket",
+		Example: `qshell lifecycle list <Bucket>`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.LifecycleType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.LifecycleList(cfg, info)
+		},
+	}
+	return cmd
+}
+
+var lifecycleAddCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.LifecycleAddInfo{}
+	var cmd = &cobra.Command{
+		Use:     "add <Bucket> --name <RuleName> [--prefix <Prefix>] [--to-ia <Days>] [--to-archive <Days>] [--delete <Days>]",
+		Short:   "Add a lifecycle rule to the bucket",
+		Example: `qshell lifecycle add <Bucket> --name logs --prefix logs/ --to-ia 30 --to-archive 90 --delete 365`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.LifecycleType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.LifecycleAdd(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.Name, "name", "", "", "name of the rule, unique in the bucket, consists of letters, numbers and underscores")
+	cmd.Flags().StringVarP(&info.Prefix, "prefix", "", "", "the rule applies to the files with the prefix, empty means all files")
+	cmd.Flags().IntVarP(&info.ToIAAfterDays, "to-ia", "", 0, "days after upload to change the storage type to IA, 0 means never")
+	cmd.Flags().IntVarP(&info.ToArchiveIRAfterDays, "to-archive-ir", "", 0, "days after upload to change the storage type to ARCHIVE_IR, 0 means never")
+	cmd.Flags().IntVarP(&info.ToArchiveAfterDays, "to-archive", "", 0, "days after upload to change the storage type to ARCHIVE, 0 means never")
+	cmd.Flags().IntVarP(&info.ToDeepArchiveAfterDays, "to-deep-archive", "", 0, "days after upload to change the storage type to DEEP_ARCHIVE, 0 means never")
+	cmd.Flags().IntVarP(&info.DeleteAfterDays, "delete", "", 0, "days after upload to delete the files, 0 means never")
+	return cmd
+}
+
+var lifecycleDeleteCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.LifecycleDeleteInfo{}
+	var cmd = &cobra.Command{
+		Use:     "delete <Bucket> <RuleName>",
+		Short:   "Delete a lifecycle rule of the bucket",
+		Example: `qshell lifecycle delete <Bucket> <RuleName>`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.LifecycleType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			if len(args) > 1 {
+				info.Name = args[1]
+			}
+			operations.LifecycleDelete(cfg, info)
+		},
+	}
+	return cmd
+}
+
+var eventsCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "events",
+		Short: "Manage the event notification rules of buckets",
+		Args:  cobra.MaximumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.EventsType
+			if !iqshell.ShowDocumentIfNeeded(cfg) {
+				_ = cmd.Help()
+			}
+		},
+	}
+	return cmd
+}
+
+var eventsListCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.EventListInfo{}
+	var cmd = &cobra.Command{
+		Use:     "list <Bucket>",
+		Short:   "List the event notification rules of the bucket",
+		Example: `qshell events list <Bucket>`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.EventsType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.EventList(cfg, info)
+		},
+	}
+	return cmd
+}
+
+var eventsAddCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.EventAddInfo{}
+	var cmd = &cobra.Command{
+		Use:     "add <Bucket> --name <RuleName> --event <Events> --callback-url <Urls> [--prefix <Prefix>] [--suffix <Suffix>]",
+		Short:   "Add an event notification rule to the bucket, the rule is updated if the name exists",
+		Example: `qshell events add <Bucket> --name images --event put,delete --prefix images/ --suffix .jpg --callback-url https://example.com/callback`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.EventsType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.EventAdd(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.Name, "name", "", "", "name of the rule, unique in the bucket")
+	cmd.Flags().StringSliceVarP(&info.Events, "event", "", nil, "events to notify, split by comma, one of put, mkfile, delete, copy, move, append, disable, enable and deleteMarkerCreate")
+	cmd.Flags().StringVarP(&info.Prefix, "prefix", "", "", "the rule applies to the files with the prefix")
+	cmd.Flags().StringVarP(&info.Suffix, "suffix", "", "", "the rule applies to the files with the suffix")
+	cmd.Flags().StringSliceVarP(&info.CallbackUrls, "callback-url", "", nil, "urls to receive the notification, split by comma")
+	cmd.Flags().StringVarP(&info.Host, "callback-host", "", "", "host header of the notification request")
+	return cmd
+}
+
+var eventsDeleteCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.EventDeleteInfo{}
+	var cmd = &cobra.Command{
+		Use:     "delete <Bucket> <RuleName>",
+		Short:   "Delete an event notification rule of the bucket",
+		Example: `qshell events delete <Bucket> <RuleName>`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.EventsType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			if len(args) > 1 {
+				info.Name = args[1]
+			}
+			operations.EventDelete(cfg, info)
+		},
+	}
 	return cmd
 }
 
+var corsCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "cors",
+		Short: "Manage the cross-origin rules of buckets",
+		Args:  cobra.MaximumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.CorsType
+			if !iqshell.ShowDocumentIfNeeded(cfg) {
+				_ = cmd.Help()
+			}
+		},
+	}
+	return cmd
+}
+
+var corsGetCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.CorsGetInfo{}
+	var cmd = &cobra.Command{
+		Use:     "get <Bucket>",
+		Short:   "Get the cross-origin rules of the bucket",
+		Example: `qshell cors get <Bucket>`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.CorsType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.CorsGet(cfg, info)
+		},
+	}
+	return cmd
+}
+
+var corsSetCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.CorsSetInfo{}
+	var cmd = &cobra.Command{
+		Use:     "set <Bucket> <RulesJsonFile>",
+		Short:   "Set the cross-origin rules of the bucket, the existing rules are replaced",
+		Example: `qshell cors set <Bucket> rules.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.CorsType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			if len(args) > 1 {
+				info.RulesFile = args[1]
+			}
+			operations.CorsSet(cfg, info)
+		},
+	}
+	return cmd
+}
+
+var refererCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "referer",
+		Short: "Manage the referer anti-leech config of buckets",
+		Args:  cobra.MaximumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.RefererType
+			if !iqshell.ShowDocumentIfNeeded(cfg) {
+				_ = cmd.Help()
+			}
+		},
+	}
+	return cmd
+}
+
+var refererGetCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.RefererGetInfo{}
+	var cmd = &cobra.Command{
+		Use:     "get <Bucket>",
+		Short:   "Get the referer anti-leech config of the bucket",
+		Example: `qshell referer get <Bucket>`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.RefererType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.RefererGet(cfg, info)
+		},
+	}
+	return cmd
+}
+
+var refererSetCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.RefererSetInfo{}
+	var cmd = &cobra.Command{
+		Use:     "set <Bucket> --mode <whitelist|blacklist|off> [--patterns <Patterns>] [--allow-empty]",
+		Short:   "Set the referer anti-leech config of the bucket",
+		Example: `qshell referer set <Bucket> --mode whitelist --patterns example.com,*.foo.com --allow-empty`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.RefererType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.RefererSet(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.Mode, "mode", "", "", "referer mode, one of whitelist, blacklist and off; off turns off the referer anti-leech")
+	cmd.Flags().StringSliceVarP(&info.Patterns, "patterns", "", nil, "referer patterns split by comma, each is a domain like foo.com, a wildcard domain like *.foo.com or *")
+	cmd.Flags().BoolVarP(&info.AllowEmptyReferer, "allow-empty", "", false, "allow the requests without referer")
+	cmd.Flags().BoolVarP(&info.SourceEnabled, "source-enabled", "", false, "enable the referer anti-leech of the origin too, only cdn is affected by default")
+	return cmd
+}
+
+// setListFilterFlags listbucket 及 listbucket2 共用的过滤选项
+func setListFilterFlags(cmd *cobra.Command, info *operations.ListInfo) {
+	cmd.Flags().StringVarP(&info.ExcludeSuffixes, "without-suffix", "", "", "only list the files whose key doesn't have any of the suffixes, separated by comma, all files will be listed according to the prefix and then filtered.")
+	cmd.Flags().StringVarP(&info.ExcludeKeyRegex, "key-regex-not", "", "", "only list the files whose key doesn't match the regular expression, all files will be listed according to the prefix and then filtered.")
+	cmd.Flags().StringVarP(&info.Filter, "filter", "", "", `filter expression evaluated against each listed file, like: 'size > 1m && mime == "image/jpeg" && putTime < 2023-01-01'. Fields: key, hash, mime, endUser, size, putTime, storageType; operators: == != > >= < <= =~ && || ! and parentheses.`)
+}
+
+func setListColumnsFlags(cmd *cobra.Command, info *operations.ListInfo) {
+	cmd.Flags().StringVarP(&info.Columns, "columns", "", "", "the columns to output, separated by commas, same as --show-fields and supports short names. Optional range: key, size, hash, putTime, mime, type, endUser, restoreStatus, restoreExpiry.")
+	cmd.Flags().StringVarP(&info.TimeFormat, "time-format", "", "", "format PutTime and RestoreExpiry with the Go time layout, like: \"2006-01-02 15:04:05\". PutTime is output in units of 100ns and RestoreExpiry in seconds if not set.")
+}
+
 func init() {
 	registerLoader(bucketCmdLoader)
 }
@@ -169,6 +486,38 @@ func bucketCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 		mkBucketCmdBuilder(cfg),
 		listBucketCmdBuilder(cfg),
 		listBucketCmd2Builder(cfg),
+		bucketUsageCmdBuilder(cfg),
+		findDuplicatesCmdBuilder(cfg),
 		domainsCmdBuilder(cfg),
 	)
+
+	lifecycleCmd := lifecycleCmdBuilder(cfg)
+	lifecycleCmd.AddCommand(
+		lifecycleListCmdBuilder(cfg),
+		lifecycleAddCmdBuilder(cfg),
+		lifecycleDeleteCmdBuilder(cfg),
+	)
+	superCmd.AddCommand(lifecycleCmd)
+
+	eventsCmd := eventsCmdBuilder(cfg)
+	eventsCmd.AddCommand(
+		eventsListCmdBuilder(cfg),
+		eventsAddCmdBuilder(cfg),
+		eventsDeleteCmdBuilder(cfg),
+	)
+	superCmd.AddCommand(eventsCmd)
+
+	corsCmd := corsCmdBuilder(cfg)
+	corsCmd.AddCommand(
+		corsGetCmdBuilder(cfg),
+		corsSetCmdBuilder(cfg),
+	)
+	superCmd.AddCommand(corsCmd)
+
+	refererCmd := refererCmdBuilder(cfg)
+	refererCmd.AddCommand(
+		refererGetCmdBuilder(cfg),
+		refererSetCmdBuilder(cfg),
+	)
+	superCmd.AddCommand(refererCmd)
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/qiniu/qshell/v2/docs"
 	"github.com/qiniu/qshell/v2/iqshell"
 	"github.com/qiniu/qshell/v2/iqshell/cdn/operations"
@@ -23,6 +25,9 @@ var cdnPrefetchCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().StringVarP(&info.UrlListFile, "input-file", "i", "", "input file")
 	cmd.Flags().IntVar(&info.QpsLimit, "qps", 0, "qps limit for http call, default no limit")
 	cmd.Flags().IntVarP(&info.SizeLimit, "size", "s", 50, "max item-size pre commit, max is 50, default 50")
+	cmd.Flags().BoolVar(&info.Wait, "wait", false, "poll the prefetch status after submitting until all urls are prefetched")
+	cmd.Flags().IntVar(&info.WaitInterval, "wait-interval", 10, "interval in seconds of polling the prefetch status")
+	cmd.Flags().IntVar(&info.WaitTimeout, "wait-timeout", 3600, "max time in seconds of polling the prefetch status, polling stops with an error after it")
 
 	return cmd
 }
@@ -30,7 +35,7 @@ var cdnPrefetchCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 var cdnRefreshCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.RefreshInfo{}
 	var cmd = &cobra.Command{
-		Use:   "cdnrefresh [-i <ItemListFile>]",
+		Use:   "cdnrefresh [-i <ItemListFile>] [--urls-file <UrlListFile>] [--dirs-file <DirListFile>]",
 		Short: "Batch refresh the cdn cache by the url list file",
 		Long:  "Batch refresh the cdn cache by the url list file or from stdin if ItemListFile not specified",
 		Args:  cobra.ExactArgs(0),
@@ -48,6 +53,132 @@ var cdnRefreshCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	return cmd
 }
 
+var cdnLogCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.LogInfo{}
+	var cmd = &cobra.Command{
+		Use:   "cdnlog <Domain> [<Domain>...] [--day <Day>] [--out <OutDir>]",
+		Short: "Download the cdn access logs of domains",
+		Long:  "Download the cdn access logs of domains for a specified day, and optionally summarize requests, traffic and status codes of the logs",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.CdnLogType
+			info.Domains = args
+			operations.Log(cfg, info)
+		},
+	}
+
+	cmd.Flags().StringVar(&info.Day, "day", "", "day of the logs, format: 2006-01-02, default is yesterday")
+	cmd.Flags().StringVarP(&info.OutDir, "out", "o", ".", "directory to save the logs")
+	cmd.Flags().IntVarP(&info.WorkerCount, "thread-count", "c", 5, "num of threads to download logs")
+	cmd.Flags().BoolVar(&info.Parse, "parse", false, "summarize requests, traffic and status codes of the logs by domain")
+
+	return cmd
+}
+
+var cdnFluxCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.FluxInfo{}
+	var cmd = &cobra.Command{
+		Use:   "cdnflux <Domain[,Domain...]> --from <StartDate> [--to <EndDate>] [--granularity <Granularity>]",
+		Short: "Query the cdn flux or bandwidth of domains",
+		Long:  "Query the cdn flux or bandwidth of domains, and print the time series with ISO 8601 timestamps",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.CdnFluxType
+			for _, arg := range args {
+				for _, domain := range strings.Split(arg, ",") {
+					if domain = strings.TrimSpace(domain); len(domain) > 0 {
+						info.Domains = append(info.Domains, domain)
+					}
+				}
+			}
+			operations.Flux(cfg, info)
+		},
+	}
+
+	cmd.Flags().StringVar(&info.From, "from", "", "start date, format: 2006-01-02")
+	cmd.Flags().StringVar(&info.To, "to", "", "end date, format: 2006-01-02, default is the same as start date")
+	cmd.Flags().StringVar(&info.Granularity, "granularity", "day", "granularity of the time series, 5min, hour or day")
+	cmd.Flags().BoolVar(&info.Bandwidth, "bandwidth", false, "query bandwidth (bps) instead of flux (bytes)")
+
+	return cmd
+}
+
+var domainCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "domain",
+		Short: "Manage the cdn domains of buckets",
+		Args:  cobra.MaximumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.DomainType
+			if !iqshell.ShowDocumentIfNeeded(cfg) {
+				_ = cmd.Help()
+			}
+		},
+	}
+	return cmd
+}
+
+var domainListCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.DomainListInfo{}
+	var cmd = &cobra.Command{
+		Use:     "list <Bucket>",
+		Short:   "List the cdn domains of the bucket with bind status and https status",
+		Example: `qshell domain list <Bucket>`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.DomainType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.DomainList(cfg, info)
+		},
+	}
+	return cmd
+}
+
+var domainBindCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.DomainBindInfo{}
+	var cmd = &cobra.Command{
+		Use:     "bind <Bucket> <Domain>",
+		Short:   "Bind a cdn domain to the bucket",
+		Example: `qshell domain bind <Bucket> <Domain>`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.DomainType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			if len(args) > 1 {
+				info.Domain = args[1]
+			}
+			operations.DomainBind(cfg, info)
+		},
+	}
+	cmd.Flags().StringVar(&info.Platform, "platform", "web", "platform of the domain, web, download or vod")
+	cmd.Flags().StringVar(&info.GeoCover, "geo-cover", "china", "geo cover of the domain, china, foreign or global")
+	cmd.Flags().StringVar(&info.CertId, "cert-id", "", "id of the certificate, the domain will use https when set")
+	return cmd
+}
+
+var domainUnbindCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.DomainUnbindInfo{}
+	var cmd = &cobra.Command{
+		Use:     "unbind <Bucket> <Domain>",
+		Short:   "Offline and delete the cdn domain of the bucket",
+		Example: `qshell domain unbind <Bucket> <Domain>`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.DomainType
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			if len(args) > 1 {
+				info.Domain = args[1]
+			}
+			operations.DomainUnbind(cfg, info)
+		},
+	}
+	cmd.Flags().BoolVarP(&info.Force, "force", "y", false, "force mode, don't need to confirm")
+	cmd.Flags().IntVar(&info.WaitInterval, "wait-interval", 10, "interval in seconds of polling the domain state after offline")
+	cmd.Flags().IntVar(&info.WaitTimeout, "wait-timeout", 600, "max time in seconds of waiting for the domain to be offlined, the domain is not deleted after it")
+	return cmd
+}
+
 func init() {
 	registerLoader(cdnCmdLoader)
 }
@@ -56,5 +187,15 @@ func cdnCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 	superCmd.AddCommand(
 		cdnPrefetchCmdBuilder(cfg),
 		cdnRefreshCmdBuilder(cfg),
+		cdnLogCmdBuilder(cfg),
+		cdnFluxCmdBuilder(cfg),
+	)
+
+	domainCmd := domainCmdBuilder(cfg)
+	domainCmd.AddCommand(
+		domainListCmdBuilder(cfg),
+		domainBindCmdBuilder(cfg),
+		domainUnbindCmdBuilder(cfg),
 	)
+	superCmd.AddCommand(domainCmd)
 }
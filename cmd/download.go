@@ -30,10 +30,27 @@ have already in local disk and need to skip download or not.`,
 	}
 	cmd.Flags().StringVarP(&info.SuccessExportFilePath, "success-list", "s", "", "specifies the file path where the successful file list is saved")
 	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list", "e", "", "specifies the file path where the failure file list is saved")
+	setDeadletterFlags(cmd, &info.FileExporterConfig)
 
 	cmd.Flags().IntVarP(&info.WorkerCount, "thread-count", "c", 5, "num of threads to download files")
+	cmd.Flags().IntVarP(&info.MaxWorkerCount, "max-thread-count", "", 0, "max num of threads to download files. when set, qshell will dynamically adjust the thread count between 1 and max-thread-count according to the observed download latency, 0 means the thread count is fixed")
 	cmd.Flags().IntVarP(&info.WorkerCount, "thread", "", 5, "num of threads to download files")
 	_ = cmd.Flags().MarkDeprecated("thread", "use --thread-count instead") // 废弃 thread-count
+	setFlowMaxErrorFlags(cmd, &info.Info)
+	setFlowKeyFilterFlags(cmd, &info.Info)
+	setFlowResumeFlags(cmd, &info.Info)
+	setFlowSizeFilterFlags(cmd, &info.Info)
+	setFlowRetryFlags(cmd, &info.Info)
+	setFlowProgressFlags(cmd, &info.Info)
+	cmd.Flags().StringVarP(&info.RateLimit, "rate-limit", "", "", "the total bandwidth limit of all threads, like 512k, 5m, unit:B/s. same to rate_limit of download config, empty means no limit")
+	cmd.Flags().BoolVarP(&info.NoVerify, "no-verify", "", false, "do not verify the hash of the file after downloading. use it when downloading processed content whose hash will not match the hash of the object in bucket. same to no_verify of download config")
+	cmd.Flags().StringVarP(&info.KeyFile, "key-file", "", "", "a file which specifies the keys to be downloaded, one key per line, the bucket will not be listed and the prefix is ignored. same to key_file of download config")
+	cmd.Flags().StringVarP(&info.StripPrefix, "strip-prefix", "", "", "drop the prefix from the key when computing the local path of the file. same to strip_prefix of download config")
+	cmd.Flags().BoolVarP(&info.Flatten, "flatten", "", false, "save all the files into the dest dir without sub directories, the slashes in the key are replaced with flatten separator. same to flatten of download config")
+	cmd.Flags().StringVarP(&info.FlattenSeparator, "flatten-sep", "", "", "the separator to replace the slashes in the key when --flatten is set, default is _. same to flatten_separator of download config")
+	cmd.Flags().BoolVarP(&info.Decompress, "decompress", "", false, "decompress the downloaded files according to the Content-Encoding(gzip or zstd) returned by the server, the hash is verified before decompressing. same to decompress of download config")
+	cmd.Flags().BoolVarP(&info.ForceDownload, "force", "", false, "always download the files, even if they exist locally and have not been changed. same to force_download of download config")
+	cmd.Flags().StringVarP(&info.Domains, "domains", "", "", "multiple download domains separated by comma, the files are downloaded from the domains in turn and the failing domain is deprioritized. same to domains of download config")
 
 	return cmd
 }
@@ -66,24 +83,40 @@ have already in local disk and need to skip download or not.`,
 	}
 	cmd.Flags().StringVarP(&info.SuccessExportFilePath, "success-list", "s", "", "specifies the file path where the successful file list is saved")
 	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list", "e", "", "specifies the file path where the failure file list is saved")
+	setDeadletterFlags(cmd, &info.FileExporterConfig)
 
 	cmd.Flags().IntVarP(&info.WorkerCount, "thread-count", "c", 5, "num of threads to download files")
+	cmd.Flags().IntVarP(&info.MaxWorkerCount, "max-thread-count", "", 0, "max num of threads to download files. when set, qshell will dynamically adjust the thread count between 1 and max-thread-count according to the observed download latency, 0 means the thread count is fixed")
 	cmd.Flags().IntVarP(&info.WorkerCount, "thread", "", 5, "num of threads to download files")
 	_ = cmd.Flags().MarkDeprecated("thread", "use --thread-count instead") // 废弃 thread-count
+	setFlowMaxErrorFlags(cmd, &info.Info)
+	setFlowKeyFilterFlags(cmd, &info.Info)
+	setFlowResumeFlags(cmd, &info.Info)
+	setFlowSizeFilterFlags(cmd, &info.Info)
+	setFlowRetryFlags(cmd, &info.Info)
+	setFlowProgressFlags(cmd, &info.Info)
 
 	cmd.Flags().StringVarP(&info.DownloadCfg.DestDir, "dest-dir", "", "", "local storage path, full path. default current dir")
 	cmd.Flags().BoolVarP(&info.DownloadCfg.GetFileApi, "get-file-api", "", false, "public storage cloud not support, private storage cloud support when has getfile api.")
 	cmd.Flags().StringVarP(&info.DownloadCfg.Bucket, "bucket", "", "", "storage bucket")
 	cmd.Flags().StringVarP(&info.DownloadCfg.Prefix, "prefix", "", "", "only download files with the specified prefix")
 	cmd.Flags().StringVarP(&info.DownloadCfg.Suffixes, "suffixes", "", "", "only download files with the specified suffixes")
-	cmd.Flags().StringVarP(&info.DownloadCfg.KeyFile, "key-file", "", "", "configure a file and specify the keys to be downloaded; if not configured, download all the files in the bucket")
+	cmd.Flags().StringVarP(&info.DownloadCfg.KeyFile, "key-file", "", "", "configure a file and specify the keys to be downloaded, one key per line, the bucket will not be listed and --prefix is ignored; if not configured, download all the files in the bucket")
 	cmd.Flags().StringVarP(&info.DownloadCfg.SavePathHandler, "save-path-handler", "", "", "specify a callback function; when constructing the save path of the file, this option is preferred for construction. If not configured, $dest_dir + $ file separator + $Key will be used for construction. This function is implemented through the template of the Go language. The func command is used for function verification. For the specific syntax, please refer to the description of the func command.")
 	cmd.Flags().BoolVarP(&info.DownloadCfg.CheckHash, "check-hash", "", false, "whether to verify the hash, if it is enabled, it may take a long time")
 	cmd.Flags().BoolVarP(&info.DownloadCfg.CheckSize, "check-size", "", false, "check the consistency of the file size between the local file and the server file. the download fails while the file is inconsistent.")
+	cmd.Flags().StringVarP(&info.DownloadCfg.RateLimit, "rate-limit", "", "", "the total bandwidth limit of all threads, like 512k, 5m, unit:B/s. empty means no limit")
+	cmd.Flags().BoolVarP(&info.DownloadCfg.NoVerify, "no-verify", "", false, "do not verify the hash of the file after downloading. use it when downloading processed content whose hash will not match the hash of the object in bucket")
+	cmd.Flags().StringVarP(&info.DownloadCfg.StripPrefix, "strip-prefix", "", "", "drop the prefix from the key when computing the local path of the file, the keys without the prefix are not affected")
+	cmd.Flags().BoolVarP(&info.DownloadCfg.Flatten, "flatten", "", false, "save all the files into the dest dir without sub directories, the slashes in the key are replaced with flatten separator. the keys which are mapped to the same local file are reported as failure")
+	cmd.Flags().StringVarP(&info.DownloadCfg.FlattenSeparator, "flatten-sep", "", "_", "the separator to replace the slashes in the key when --flatten is set")
+	cmd.Flags().BoolVarP(&info.DownloadCfg.Decompress, "decompress", "", false, "decompress the downloaded files according to the Content-Encoding(gzip or zstd) returned by the server, the files without Content-Encoding are kept as they are. the hash is verified before decompressing")
+	cmd.Flags().BoolVarP(&info.DownloadCfg.ForceDownload, "force", "", false, "always download the files, even if they exist locally and have not been changed")
 	cmd.Flags().StringVarP(&info.IoHost, "io-host", "", "", "io host of request")
 
 	cmd.Flags().StringVarP(&info.DownloadCfg.Domain, "cdn-domain", "", "", "same to --domain, deprecated")
 	cmd.Flags().StringVarP(&info.DownloadCfg.Domain, "domain", "", "", "domain of the download request, the default is empty, which means downloading from the storage source site")
+	cmd.Flags().StringVarP(&info.DownloadCfg.Domains, "domains", "", "", "multiple download domains separated by comma, the files are downloaded from the domains in turn and the failing domain is deprioritized; the domain of each file is appended to the success list. takes precedence over --domain")
 	_ = cmd.Flags().MarkDeprecated("cdn-domain", "use --domain instead")
 
 	cmd.Flags().StringVarP(&info.DownloadCfg.Referer, "referer", "", "", "if the CDN domain name is configured with domain name whitelist anti-leech, you need to specify a referer address that allows access")
@@ -138,9 +171,9 @@ func init() {
 }
 
 func downloadCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
-	superCmd.AddCommand(
+	superCmd.AddCommand(withRegionFlags(cfg,
 		getCmdBuilder(cfg),
 		downloadCmdBuilder(cfg),
 		download2CmdBuilder(cfg),
-	)
+	)...)
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/qiniu/qshell/v2/docs"
 	"github.com/qiniu/qshell/v2/iqshell"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/operations"
@@ -28,23 +30,40 @@ var preFopStatusCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 
 var pfopCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.PfopInfo{}
+	var fops = ""
 	var cmd = &cobra.Command{
-		Use:   "pfop <Bucket> <Key> <fopCommand>",
+		Use:   "pfop <Bucket> <Key> <fopCommand> | pfop <Bucket:Key> --fops <fopCommand>",
 		Short: "Issue a request to process file in bucket",
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.PFopType
-			if len(args) > 0 {
-				info.Bucket = args[0]
+			// 空间名不包含 :，第一个参数包含 : 时为 <Bucket:Key>
+			if len(args) > 0 && strings.Contains(args[0], ":") {
+				index := strings.Index(args[0], ":")
+				info.Bucket, info.Key = args[0][:index], args[0][index+1:]
+				args = args[1:]
+			} else {
+				if len(args) > 0 {
+					info.Bucket = args[0]
+				}
+				if len(args) > 1 {
+					info.Key = args[1]
+				}
+				if len(args) > 2 {
+					args = args[2:]
+				} else {
+					args = nil
+				}
 			}
-			if len(args) > 1 {
-				info.Key = args[1]
+			if len(args) > 0 {
+				info.Fops = args[0]
 			}
-			if len(args) > 2 {
-				info.Fops = args[2]
+			if len(fops) > 0 {
+				info.Fops = fops
 			}
 			operations.Pfop(cfg, info)
 		},
 	}
+	cmd.Flags().StringVarP(&fops, "fops", "", "", "fop commands separated by ;, such as avthumb/mp4;vframe/jpg/offset/1")
 	cmd.Flags().StringVarP(&info.Pipeline, "pipeline", "p", "", "task pipeline")
 	cmd.Flags().StringVarP(&info.NotifyURL, "notify-url", "u", "", "notfiy url")
 	cmd.Flags().StringVarP(&info.WorkflowTemplateID, "workflow-template-id", "", "", "Workflow template ID")
@@ -58,6 +77,159 @@ var pfopCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	return cmd
 }
 
+var imageInfoCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.ImageInfoInfo{}
+	var cmd = &cobra.Command{
+		Use:   "imageinfo <Bucket:Key | Url>",
+		Short: "Get the width, height, format and color model of an image",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.ImageInfoType
+			if len(args) > 0 {
+				info.Source = args[0]
+			}
+			operations.ImageInfo(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.Domain, "domain", "", "", "domain used to build the url of <Bucket:Key>, default is the first domain of the bucket")
+	cmd.Flags().BoolVarP(&info.Sign, "sign", "", false, "sign the url, required when the url is of a private bucket")
+	return cmd
+}
+
+var batchImageInfoCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.BatchImageInfoInfo{}
+	var cmd = &cobra.Command{
+		Use:   "batchimageinfo [-i <ItemListFile>] [--bucket <Bucket> | --domain <Domain>]",
+		Short: "Batch get the width, height, format and color model of images from the url list file or the key list file",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.BatchImageInfoType
+			info.BatchInfo.EnableStdin = true
+			info.BatchInfo.Force = true
+			operations.BatchImageInfo(cfg, info)
+		},
+	}
+	setBatchCmdInputFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
+	setDeadletterFlags(cmd, &info.BatchInfo.FileExporterConfig)
+	setBatchCmdResultExportFileFlags(cmd, &info.BatchInfo)
+	cmd.Flags().StringVarP(&info.Bucket, "bucket", "", "", "bucket of the keys, the first domain of the bucket is used to build the url, each line of the input file is a key when set")
+	cmd.Flags().StringVarP(&info.Domain, "domain", "", "", "domain used to build the url, each line of the input file is a key when set")
+	cmd.Flags().BoolVarP(&info.Sign, "sign", "", false, "sign the urls of the input file, required when the urls are of a private bucket")
+	cmd.Flags().IntVarP(&info.BatchInfo.WorkerCount, "worker", "c", 4, "worker count of getting image info")
+	return cmd
+}
+
+var thumbnailCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.ThumbnailInfo{}
+	var cmd = &cobra.Command{
+		Use:   "thumbnail <Bucket:Key> [--mode <Mode>] [--width <Width>] [--height <Height>] [--save-as <SaveAs>]",
+		Short: "Generate the imageView2 thumbnail url of an image, and optionally save the thumbnail to bucket",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.ThumbnailType
+			if len(args) > 0 {
+				info.Source = args[0]
+			}
+			operations.Thumbnail(cfg, info)
+		},
+	}
+	cmd.Flags().IntVarP(&info.Mode, "mode", "m", 2, "imageView2 mode, 0 ~ 5")
+	cmd.Flags().IntVarP(&info.Width, "width", "", 0, "width of the thumbnail, 1 ~ 9999, 0 means no limit")
+	cmd.Flags().IntVarP(&info.Height, "height", "", 0, "height of the thumbnail, 1 ~ 9999, 0 means no limit")
+	cmd.Flags().StringVarP(&info.Format, "image-format", "", "", "format of the thumbnail, such as jpg, png, webp, default is the same as the image")
+	cmd.Flags().IntVarP(&info.Quality, "quality", "q", 0, "quality of the thumbnail, 1 ~ 100, 0 means default quality")
+	cmd.Flags().StringVarP(&info.Domain, "domain", "", "", "domain used to build the url, default is the first domain of the bucket")
+	cmd.Flags().StringVarP(&info.SaveAs, "save-as", "", "", "save the thumbnail to <Bucket>:<Key> or <Key> of the same bucket by pfop")
+	cmd.Flags().StringVarP(&info.Pipeline, "pipeline", "p", "", "pipeline of the pfop, used with --save-as")
+	return cmd
+}
+
+var batchWatermarkCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.BatchWatermarkInfo{}
+	var cmd = &cobra.Command{
+		Use:   "batchwatermark <Bucket> [-i <KeyListFile>] (--image <ImageUrl> | --text <Text>) [--dest-prefix <Prefix>] [--dest-suffix <Suffix>]",
+		Short: "Batch submit watermark pfop for the files in the key list file and save the results to derived keys",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.BatchWatermarkType
+			info.BatchInfo.EnableStdin = true
+			info.BatchInfo.Force = true
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.BatchWatermark(cfg, info)
+		},
+	}
+	setBatchCmdInputFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
+	setDeadletterFlags(cmd, &info.BatchInfo.FileExporterConfig)
+	setBatchCmdResultExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdItemSeparateFlags(cmd, &info.BatchInfo)
+	cmd.Flags().IntVarP(&info.BatchInfo.WorkerCount, "worker", "c", 4, "worker count of submitting pfop")
+	cmd.Flags().StringVarP(&info.Image, "image", "", "", "url of the watermark image")
+	cmd.Flags().StringVarP(&info.Text, "text", "", "", "text of the watermark")
+	cmd.Flags().StringVarP(&info.Font, "font", "", "", "font of the text watermark")
+	cmd.Flags().IntVarP(&info.FontSize, "font-size", "", 0, "font size of the text watermark, unit: twip, 0 means default size")
+	cmd.Flags().StringVarP(&info.Fill, "fill", "", "", "color of the text watermark, such as #FFFFFF")
+	cmd.Flags().IntVarP(&info.Dissolve, "dissolve", "", 100, "dissolve of the watermark, 1 ~ 100")
+	cmd.Flags().StringVarP(&info.Gravity, "gravity", "", "SouthEast", "position of the watermark, NorthWest, North, NorthEast, West, Center, East, SouthWest, South or SouthEast")
+	cmd.Flags().IntVarP(&info.Dx, "dx", "", 10, "horizontal margin of the watermark, unit: pixel")
+	cmd.Flags().IntVarP(&info.Dy, "dy", "", 10, "vertical margin of the watermark, unit: pixel")
+	cmd.Flags().StringVarP(&info.DestBucket, "dest-bucket", "", "", "bucket to save the watermarked files, default is the source bucket")
+	cmd.Flags().StringVarP(&info.DestPrefix, "dest-prefix", "", "", "prefix added to the key of the watermarked file")
+	cmd.Flags().StringVarP(&info.DestSuffix, "dest-suffix", "", "", "suffix added to the key of the watermarked file before the extension")
+	cmd.Flags().StringVarP(&info.Pipeline, "pipeline", "p", "", "pipeline of the pfop")
+	cmd.Flags().StringVarP(&info.NotifyURL, "notify-url", "u", "", "notify url of the pfop")
+	cmd.Flags().BoolVarP(&info.Wait, "wait", "", false, "wait until each pfop job is done, the failed jobs are exported to --job-failure-list")
+	cmd.Flags().IntVarP(&info.WaitInterval, "wait-interval", "", 5, "interval in seconds of querying the pfop status when --wait is set")
+	cmd.Flags().IntVarP(&info.WaitTimeout, "wait-timeout", "", 600, "max time in seconds of waiting for each pfop job when --wait is set, the job is treated as failed after it")
+	cmd.Flags().StringVarP(&info.JobFailExportFilePath, "job-failure-list", "", "", "specifies the file path where the input lines of failed pfop jobs are saved, used with --wait")
+	return cmd
+}
+
+var avInfoCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.AvInfoInfo{}
+	var cmd = &cobra.Command{
+		Use:   "avinfo <Bucket:Key | Url>",
+		Short: "Get the duration, bitrate, codec, resolution and sample rate of an audio or video file",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.AvInfoType
+			if len(args) > 0 {
+				info.Source = args[0]
+			}
+			operations.AvInfo(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.Domain, "domain", "", "", "domain used to build the url of <Bucket:Key>, default is the first domain of the bucket")
+	cmd.Flags().BoolVarP(&info.Sign, "sign", "", false, "sign the url, required when the url is of a private bucket")
+	cmd.Flags().BoolVarP(&info.Raw, "raw", "", false, "print the full payload of avinfo")
+	return cmd
+}
+
+var batchAvInfoCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.BatchAvInfoInfo{}
+	var cmd = &cobra.Command{
+		Use:   "batchavinfo [-i <ItemListFile>] [--bucket <Bucket> | --domain <Domain>]",
+		Short: "Batch get the duration, bitrate, codec, resolution and sample rate of audio or video files from the url list file or the key list file",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.BatchAvInfoType
+			info.BatchInfo.EnableStdin = true
+			info.BatchInfo.Force = true
+			operations.BatchAvInfo(cfg, info)
+		},
+	}
+	setBatchCmdInputFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
+	setDeadletterFlags(cmd, &info.BatchInfo.FileExporterConfig)
+	setBatchCmdResultExportFileFlags(cmd, &info.BatchInfo)
+	cmd.Flags().StringVarP(&info.Bucket, "bucket", "", "", "bucket of the keys, the first domain of the bucket is used to build the url, each line of the input file is a key when set")
+	cmd.Flags().StringVarP(&info.Domain, "domain", "", "", "domain used to build the url, each line of the input file is a key when set")
+	cmd.Flags().BoolVarP(&info.Sign, "sign", "", false, "sign the urls of the input file, required when the urls are of a private bucket")
+	cmd.Flags().BoolVarP(&info.Raw, "raw", "", false, "print the full payload of avinfo for each file")
+	cmd.Flags().IntVarP(&info.BatchInfo.WorkerCount, "worker", "c", 4, "worker count of getting av info")
+	return cmd
+}
+
 func init() {
 	registerLoader(fopCmdLoader)
 }
@@ -66,5 +238,11 @@ func fopCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 	superCmd.AddCommand(
 		pfopCmdBuilder(cfg),
 		preFopStatusCmdBuilder(cfg),
+		imageInfoCmdBuilder(cfg),
+		batchImageInfoCmdBuilder(cfg),
+		avInfoCmdBuilder(cfg),
+		batchAvInfoCmdBuilder(cfg),
+		thumbnailCmdBuilder(cfg),
+		batchWatermarkCmdBuilder(cfg),
 	)
 }
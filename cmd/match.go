@@ -48,12 +48,43 @@ var batchMatchCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVarP(&info.BatchInfo.InputFile, "input-file", "i", "", "input file, read from stdin if not set")
+	setBatchCmdInputFormatFlags(cmd, &info.BatchInfo)
 	cmd.Flags().IntVarP(&info.BatchInfo.WorkerCount, "worker", "c", 1, "worker count")
 	cmd.Flags().StringVarP(&info.BatchInfo.ItemSeparate, "sep", "F", "\t", "Separator used for split line fields, default is \\t (tab)")
 	cmd.Flags().BoolVarP(&info.BatchInfo.EnableRecord, "enable-record", "", false, "record work progress, and do from last progress while retry")
 	cmd.Flags().BoolVarP(&info.BatchInfo.RecordRedoWhileError, "record-redo-while-error", "", false, "when re-executing the command and checking the command task progress record, if a task has already been done and failed, the task will be re-executed. The default is false, and the task will not be re-executed when it detects that the task fails")
 	cmd.Flags().StringVarP(&info.BatchInfo.SuccessExportFilePath, "success-list", "s", "", "rename success list")
 	cmd.Flags().StringVarP(&info.BatchInfo.FailExportFilePath, "failure-list", "e", "", "rename failure list")
+	setDeadletterFlags(cmd, &info.BatchInfo.FileExporterConfig)
+	return cmd
+}
+
+var bucketDiffCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.DiffInfo{}
+	var cmd = &cobra.Command{
+		Use:   "bucketdiff <LocalDir> <Bucket[:Prefix]>",
+		Short: "Compare the files in local directory with the files under the prefix of bucket",
+		Long: "Compare the files in local directory with the files under the prefix of bucket, the key of local file is <Prefix><RelativePath>. each line of the output is:\n" +
+			"+\t<Key>\t<LocalSize>               the file only exists in local directory\n" +
+			"-\t<Key>\t<RemoteSize>              the file only exists in bucket\n" +
+			"M\t<Key>\t<LocalSize>\t<RemoteSize> the file exists in both, but the size or hash is different",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.BucketDiffType
+			if len(args) > 0 {
+				info.LocalDir = args[0]
+			}
+			if len(args) > 1 {
+				info.Bucket, info.Prefix = operations.ParseBucketPrefix(args[1])
+			}
+			operations.Diff(cfg, info)
+		},
+	}
+	cmd.Flags().BoolVarP(&info.FollowSymlinks, "follow-symlinks", "", false, "follow the symlinks, the symlinks are skipped by default")
+	cmd.Flags().BoolVarP(&info.SizeOnly, "size-only", "", false, "only compare the file size, don't calculate the hash of local files")
+	cmd.Flags().IntVarP(&info.WorkerCount, "worker", "c", 0, "worker count of calculating hash, default is the number of CPU cores")
+	cmd.Flags().IntVarP(&info.MaxRetry, "max-retry", "x", 20, "max retries when listing bucket error occurred, -1 means retry forever")
+	cmd.Flags().StringVarP(&info.ResultExportFilePath, "outfile", "o", "", "specifies the file path where the differences is saved, print to stdout if not set")
+	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list", "e", "", "specifies the file path where the files failed to compare is saved")
 	return cmd
 }
 
@@ -65,5 +96,6 @@ func matchCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 	superCmd.AddCommand(
 		matchCmdBuilder(cfg),
 		batchMatchCmdBuilder(cfg),
+		bucketDiffCmdBuilder(cfg),
 	)
 }
@@ -60,12 +60,42 @@ var rootCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.PersistentFlags().BoolVarP(&cfg.DebugEnable, "debug", "d", false, "debug mode")
 	// ddebug 开启 client debug
 	cmd.PersistentFlags().BoolVarP(&cfg.DDebugEnable, "ddebug", "D", false, "deep debug mode")
+	cmd.PersistentFlags().StringVarP(&cfg.LogLevel, "log-level", "", "", "log level of console and log file, debug, info, warn or error. overrides --debug and --silence")
+	cmd.PersistentFlags().StringVarP(&cfg.LogFile, "log-file", "", "", "also write the logs to this file, the level is the same as console")
+	cmd.PersistentFlags().BoolVarP(&cfg.LogJson, "log-json", "", false, "write the log file in JSON Lines format, each line is like {\"time\":\"\",\"level\":\"info\",\"msg\":\"\"}")
+	cmd.PersistentFlags().StringVarP(&cfg.LogMaxSize, "log-max-size", "", "", "rotate the log file when its size exceeds this value, like 100m, default 256m")
 	cmd.PersistentFlags().StringVarP(&cfg.ConfigFilePath, "config", "C", "", "set config file (default is $HOME/.qshell.json)")
 	cmd.PersistentFlags().BoolVarP(&cfg.Local, "local", "L", false, "use current directory qshell workspace (default is $HOME/.qshell)")
 	cmd.PersistentFlags().BoolVarP(&cfg.Document, "doc", "", false, "document of command")
+	cmd.PersistentFlags().StringVarP(&cfg.Profile, "profile", "", "", "use the account with this name for the current command only, the current account is not changed")
+	cmd.PersistentFlags().StringVarP(&cfg.RsHost, "rs-host", "", "", "rs host, such as rs.example.com or https://rs.example.com, overrides the rs host of config and region")
+	cmd.PersistentFlags().StringVarP(&cfg.RsfHost, "rsf-host", "", "", "rsf host, overrides the rsf host of config and region")
+	cmd.PersistentFlags().StringVarP(&cfg.ApiHost, "api-host", "", "", "api host, overrides the api host of config and region")
+	cmd.PersistentFlags().StringVarP(&cfg.UcHost, "uc-host", "", "", "uc host which is used to query the region of bucket, overrides the uc host of config")
+	cmd.PersistentFlags().StringVarP(&cfg.HostsFile, "hosts-file", "", "", "json file of hosts, the format is the same as hosts of config, such as {\"rs\":\"rs.example.com\",\"ups\":[\"up.example.com\"]}")
+	cmd.PersistentFlags().StringVarP(&cfg.Proxy, "proxy", "", "", "proxy of all requests, such as http://127.0.0.1:8080 or socks5://127.0.0.1:1080, overrides the proxy of config and HTTP_PROXY/HTTPS_PROXY, hosts in NO_PROXY are not proxied")
+	cmd.PersistentFlags().StringVarP(&cfg.CAFile, "ca-file", "", "", "PEM file of custom root CA which is added to the system root CAs, such as the CA of private cloud")
+	cmd.PersistentFlags().BoolVarP(&cfg.InsecureSkipVerify, "insecure-skip-verify", "", false, "do not verify the TLS certificates of servers, INSECURE, only for testing")
+	cmd.PersistentFlags().IntVarP(&cfg.ConnectTimeout, "connect-timeout", "", 0, "timeout of connecting to server in seconds, 0 means the default 20s")
+	cmd.PersistentFlags().IntVarP(&cfg.ReadTimeout, "read-timeout", "", 0, "timeout of waiting for response and reading response data in seconds, the request fails when no data is read in this time; 0 means waiting for response 60s and reading without timeout")
+	cmd.PersistentFlags().IntVarP(&cfg.OverallTimeout, "overall-timeout", "", 0, "overall timeout of uploading, downloading or fetching a file in seconds, the file is marked failed and the next one is processed when timeout; 0 means no timeout")
+	cmd.PersistentFlags().StringVarP(&cfg.MetricsEndpoint, "metrics-endpoint", "", "", "push metrics of batch operations to this endpoint, statsd://host:port for StatsD or http(s)://host:port for Prometheus pushgateway")
+	cmd.PersistentFlags().StringVarP(&cfg.MetricsPrefix, "metrics-prefix", "", "", "prefix of metric names, also the job name of pushgateway, default qshell")
+	cmd.PersistentFlags().IntVarP(&cfg.MetricsInterval, "metrics-interval", "", 0, "interval of pushing metrics in seconds, default 10")
+	cmd.PersistentFlags().StringVarP(&cfg.OutputFormat, "format", "", data.OutputFormatText, "output format of batch operation results, text, json (one json object per line) or csv (only for listbucket, listbucket2 and cdnflux, other commands reject it). logs are written to stderr when format is json or csv")
+	cmd.PersistentFlags().BoolVarP(&cfg.NoSummary, "no-summary", "", false, "do not print the summary (total, success, failure, skipped, size, duration, throughput and limit hits) at the end of batch operations")
 	return cmd
 }
 
+// withRegionFlags 为命令添加 --region 选项及其别名 --zone，指定空间所在的区域
+func withRegionFlags(cfg *iqshell.Config, cmds ...*cobra.Command) []*cobra.Command {
+	for _, cmd := range cmds {
+		cmd.Flags().StringVarP(&cfg.Region, "region", "", "", "region id of bucket, such as z0, z1, z2, na0 and as0. by default, the region of bucket is queried and cached")
+		cmd.Flags().StringVarP(&cfg.Region, "zone", "", "", "same as --region")
+	}
+	return cmds
+}
+
 func Execute() {
 	var cfg = &iqshell.Config{
 		Document:       false,
@@ -74,7 +104,8 @@ func Execute() {
 		ConfigFilePath: "",
 		Local:          false,
 		CmdCfg: config.Config{
-			Log: &config.LogSetting{},
+			Log:   &config.LogSetting{},
+			Hosts: &config.Hosts{},
 		},
 	}
 
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	"github.com/qiniu/qshell/v2/docs"
@@ -8,6 +10,22 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/operations"
 )
 
+var objectExpireCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.ObjectExpireInfo{}
+	var cmd = &cobra.Command{
+		Use:   "objexpire <Bucket:Key>",
+		Short: "Show the delete-after-days and the expiry time of a remote file",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.ObjectExpireType
+			if len(args) > 0 {
+				info.Source = args[0]
+			}
+			operations.ObjectExpire(cfg, info)
+		},
+	}
+	return cmd
+}
+
 var statCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.StatusInfo{}
 	var cmd = &cobra.Command{
@@ -51,8 +69,8 @@ var forbiddenCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 var deleteCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.DeleteInfo{}
 	var cmd = &cobra.Command{
-		Use:   "delete <Bucket> <Key>",
-		Short: "Delete a remote file in the bucket",
+		Use:   "delete <Bucket> [<Key>] [--prefix <Prefix>]",
+		Short: "Delete a remote file in the bucket, or all the files with a prefix",
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.DeleteType
 			if len(args) > 0 {
@@ -64,6 +82,14 @@ var deleteCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 			operations.Delete(cfg, info)
 		},
 	}
+	cmd.Flags().StringVarP(&info.Prefix, "prefix", "p", "", "delete all the files with this prefix, the files are listed and deleted in batches at the same time")
+	cmd.Flags().Int64VarP(&info.Limit, "limit", "", 0, "the max number of files to delete when --prefix is specified, 0 means no limit")
+	setBatchCmdForceFlags(cmd, &info.BatchInfo)
+	setBatchCmdWorkerCountFlags(cmd, &info.BatchInfo)
+	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdDryRunFlags(cmd, &info.BatchInfo)
+	setFlowKeyFilterFlags(cmd, &info.BatchInfo.Info)
 	return cmd
 }
 
@@ -147,6 +173,39 @@ var moveCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	return cmd
 }
 
+var movePrefixCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.MovePrefixInfo{}
+	var cmd = &cobra.Command{
+		Use:   "moveprefix <Bucket> --from <FromPrefix> --to <ToPrefix> [--to-bucket <DestBucket>]",
+		Short: "Move/Rename all the files with a prefix to another prefix, like renaming a folder",
+		Example: `rename folder old/ to new/ in bucketA:
+	qshell moveprefix bucketA --from old/ --to new/
+preview the files to be moved:
+	qshell moveprefix bucketA --from old/ --to new/ --dry-run`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.MovePrefixType
+			if len(args) > 0 {
+				info.SourceBucket = args[0]
+			}
+			operations.MovePrefix(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.FromPrefix, "from", "", "", "the prefix of the files to move, only the leading prefix of the key is replaced")
+	cmd.Flags().StringVarP(&info.ToPrefix, "to", "", "", "the prefix of the dest files")
+	cmd.Flags().StringVarP(&info.DestBucket, "to-bucket", "", "", "the bucket of the dest files, default is the source bucket")
+	cmd.Flags().Int64VarP(&info.Limit, "limit", "", 0, "the max number of files to move, 0 means no limit")
+	cmd.Flags().BoolVarP(&info.Copy, "copy", "", false, "copy the files instead of moving them")
+	setBatchCmdForceFlags(cmd, &info.BatchInfo)
+	setBatchCmdOverwriteFlags(cmd, &info.BatchInfo)
+	setBatchCmdWorkerCountFlags(cmd, &info.BatchInfo)
+	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdDryRunFlags(cmd, &info.BatchInfo)
+	setFlowKeyFilterFlags(cmd, &info.BatchInfo.Info)
+	cmd.Flags().StringVarP(&info.BatchInfo.UndoExportFilePath, "undo-log", "", "", "specifies the file path where the undo log of successful moves is saved, which can be replayed by batchmove --undo")
+	return cmd
+}
+
 var renameCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.RenameInfo{}
 	var cmd = &cobra.Command{
@@ -155,7 +214,7 @@ var renameCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		Example: `rename A.png(bucket:bucketA key:A.png) to B.png(bucket:bucketA key:B.png):
 	qshell rename bucketA A.png B.png
 you can check if B.png has exists by:
-	qshell stat bucketB B.png
+	qshell stat bucketA B.png
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.RenameType
@@ -206,32 +265,72 @@ you can check if B.png has exists by:
 	_ = cmd.Flags().MarkShorthandDeprecated("overwrite", "deprecated and use --overwrite instead")
 
 	cmd.Flags().StringVarP(&info.DestKey, "key", "k", "", "filename saved in bucket, use <SrcKey> while omitted")
+	setCopyPreserveFlags(cmd, &info.Preserve)
 	return cmd
 }
 
+func setCopyPreserveFlags(cmd *cobra.Command, info *operations.CopyPreserveInfo) {
+	cmd.Flags().BoolVarP(&info.Meta, "preserve-meta", "", false, "after copying, re-apply the custom metadata(x-qn-meta-*) of the source file which is missing or different in the dest file")
+	cmd.Flags().BoolVarP(&info.Type, "preserve-type", "", false, "after copying, change the storage type of the dest file to the source file's if they are different")
+}
+
 var changeMimeCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.ChangeMimeInfo{}
 	var cmd = &cobra.Command{
-		Use:   "chgm <Bucket> <Key> <NewMimeType>",
+		Use:   "chgm <Bucket> <Key> <NewMimeType> | <Bucket:Key> <NewMimeType>",
 		Short: "Change the mime type of a file",
 		Example: `change mimetype of A.png(bucket:bucketA key:A.png) to image/jpeg
 	qshell chgm bucketA A.png image/jpeg
+	qshell chgm bucketA:A.png image/jpeg
+change mimetype of A.png to the mime type inferred from the extension of key
+	qshell chgm bucketA:A.png --from-extension
 and you can check result by command:
 	qshell stat bucketA A.png`,
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.ChangeMimeType
-			if len(args) > 0 {
+			// 空间名中不能包含 :，包含 : 时第一个参数为 <Bucket:Key>
+			if len(args) > 0 && strings.Contains(args[0], ":") {
+				info.Bucket, info.Key, _ = strings.Cut(args[0], ":")
+				args = args[1:]
+			} else if len(args) > 0 {
 				info.Bucket = args[0]
+				args = args[1:]
+				if len(args) > 0 {
+					info.Key = args[0]
+					args = args[1:]
+				}
 			}
-			if len(args) > 1 {
-				info.Key = args[1]
-			}
-			if len(args) > 2 {
-				info.Mime = args[2]
+			if len(args) > 0 {
+				info.Mime = args[0]
 			}
 			operations.ChangeMime(cfg, info)
 		},
 	}
+	cmd.Flags().BoolVarP(&info.FromExtension, "from-extension", "", false, "set the mime type inferred from the extension of key, can't be used with <NewMimeType>")
+	return cmd
+}
+
+var setMetaCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.SetMetaInfo{}
+	var cmd = &cobra.Command{
+		Use:   "setmeta <Bucket:Key>",
+		Short: "Set or remove the custom metadata(x-qn-meta-*) of a file without re-uploading",
+		Example: `set metadata x-qn-meta-author and x-qn-meta-version of A.png(bucket:bucketA key:A.png)
+	qshell setmeta bucketA:A.png --meta author=qiniu,version=2
+remove metadata x-qn-meta-version of A.png
+	qshell setmeta bucketA:A.png --remove version
+and you can check result by command:
+	qshell stat bucketA A.png`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.SetMetaType
+			if len(args) > 0 {
+				info.Source = args[0]
+			}
+			operations.SetMeta(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.Meta, "meta", "", "", "metadata to set, format: key1=value1,key2=value2, the x-qn-meta- prefix of key is optional, an empty value means removing the metadata")
+	cmd.Flags().StringSliceVarP(&info.Remove, "remove", "", nil, "names of metadata to remove, can be set multiple times or separated by comma")
 	return cmd
 }
 
@@ -245,7 +344,8 @@ And 0 means STANDARD storage,
 while 1 means IA storage,
 while 2 means ARCHIVE storage.
 while 3 means DEEP_ARCHIVE storage.
-while 4 means ARCHIVE_IR storage.`,
+while 4 means ARCHIVE_IR storage.
+File type can also be one of standard, line, archive, deep_archive, archive_ir.`,
 		Example: `change storage type of A.png(bucket:bucketA key:A.png) to ARCHIVE storage
 	qshell chtype bucketA A.png 2
 and you can check result by command:
@@ -372,7 +472,7 @@ var prefetchCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 var fetchCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info operations.FetchInfo
 	var cmd = &cobra.Command{
-		Use:   "fetch <RemoteResourceUrl> <Bucket> [-k <Key>]",
+		Use:   "fetch <RemoteResourceUrl> <Bucket> [-k <Key>] [--callback-url <CallbackUrl> --callback-body <CallbackBody>] [--persistent-ops <Fops>]",
 		Short: "Fetch a remote resource by url and save in bucket",
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.FetchType
@@ -387,6 +487,14 @@ var fetchCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&info.Key, "key", "k", "", "filename saved in bucket")
+	cmd.Flags().StringVarP(&info.CallbackUrl, "callback-url", "", "", "callback url, the fetch will be asynchronous when set")
+	cmd.Flags().StringVarP(&info.CallbackBody, "callback-body", "", "", "callback body, support magic variables like key=$(key)&hash=$(etag)")
+	cmd.Flags().StringVarP(&info.CallbackBodyType, "callback-body-type", "", "", "content type of callback body, default is application/x-www-form-urlencoded")
+	cmd.Flags().StringVarP(&info.CallbackHost, "callback-host", "", "", "host header of callback request")
+	cmd.Flags().StringVarP(&info.PersistentOps, "persistent-ops", "", "", "persistent fops triggered after fetched, like avthumb/mp4, the fetch will be asynchronous when set")
+	cmd.Flags().StringVarP(&info.PersistentNotifyUrl, "persistent-notify-url", "", "", "notify url of persistent fops result")
+	cmd.Flags().StringVarP(&info.PersistentPipeline, "persistent-pipeline", "", "", "pipeline of persistent fops")
+	cmd.Flags().BoolVarP(&info.NoWait, "no-wait", "", false, "do not wait for the asynchronous fetch to complete, only print the id of fetch job")
 
 	return cmd
 }
@@ -398,14 +506,17 @@ func init() {
 func rsCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 	superCmd.AddCommand(
 		statCmdBuilder(cfg),
+		objectExpireCmdBuilder(cfg),
 		forbiddenCmdBuilder(cfg),
 		changeLifecycleCmdBuilder(cfg),
 		deleteCmdBuilder(cfg),
 		deleteAfterCmdBuilder(cfg),
 		moveCmdBuilder(cfg),
+		movePrefixCmdBuilder(cfg),
 		renameCmdBuilder(cfg),
 		copyCmdBuilder(cfg),
 		changeMimeCmdBuilder(cfg),
+		setMetaCmdBuilder(cfg),
 		changeTypeCmdBuilder(cfg),
 		restoreArCmdBuilder(cfg),
 		privateUrlCmdBuilder(cfg),
@@ -1,14 +1,46 @@
 package cmd
 
 import (
+	"fmt"
+	"runtime"
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	"github.com/qiniu/qshell/v2/docs"
 	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/operations"
 )
 
+var batchObjectExpireCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.BatchObjectExpireInfo{}
+	var cmd = &cobra.Command{
+		Use:   "batchobjexpire <Bucket> [-i <KeyListFile>]",
+		Short: "Batch show the delete-after-days and the expiry time of files in bucket",
+		Long:  "Batch show the delete-after-days and the expiry time of files in bucket. Each line is displayed in the following order:\n Key\tPutTime\tDeleteAfterDays\tExpireAt",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.BatchObjectExpireType
+			info.BatchInfo.EnableStdin = true
+			info.BatchInfo.Force = true
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.BatchObjectExpire(cfg, info)
+		},
+	}
+	setBatchCmdInputFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
+	setDeadletterFlags(cmd, &info.BatchInfo.FileExporterConfig)
+	setBatchCmdResultExportFileFlags(cmd, &info.BatchInfo)
+	cmd.Flags().IntVarP(&info.BatchInfo.WorkerCount, "worker", "c", 4, "worker count of querying the files")
+	cmd.Flags().BoolVarP(&info.OnlyExpiring, "only-expiring", "", false, "only output the files scheduled for deletion")
+	return cmd
+}
+
 var batchStatCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.BatchStatusInfo{}
 	var cmd = &cobra.Command{
@@ -29,10 +61,12 @@ var batchStatCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	setBatchCmdWorkerCountIncreasePeriodFlags(cmd, &info.BatchInfo)
 	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
+	setDeadletterFlags(cmd, &info.BatchInfo.FileExporterConfig)
 	setBatchCmdResultExportFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdForceFlags(cmd, &info.BatchInfo)
 	setBatchCmdEnableRecordFlags(cmd, &info.BatchInfo)
 	setBatchCmdRecordRedoWhileErrorFlags(cmd, &info.BatchInfo)
+	setBatchCmdValidateFlags(cmd, &info.BatchInfo)
 	return cmd
 }
 
@@ -56,10 +90,13 @@ var batchForbiddenCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	setBatchCmdWorkerCountIncreasePeriodFlags(cmd, &info.BatchInfo)
 	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
+	setDeadletterFlags(cmd, &info.BatchInfo.FileExporterConfig)
 	setBatchCmdForceFlags(cmd, &info.BatchInfo)
 	setBatchCmdEnableRecordFlags(cmd, &info.BatchInfo)
 	setBatchCmdRecordRedoWhileErrorFlags(cmd, &info.BatchInfo)
+	setBatchCmdDryRunFlags(cmd, &info.BatchInfo)
 	cmd.Flags().BoolVarP(&info.UnForbidden, "reverse", "r", false, "unforbidden object in qiniu bucket")
+	setBatchCmdValidateFlags(cmd, &info.BatchInfo)
 	return cmd
 }
 
@@ -78,6 +115,8 @@ var batchDeleteCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		},
 	}
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
+	cmd.Flags().Int64VarP(&info.BatchInfo.ConfirmThreshold, "confirm-threshold", "", 10000, "when not forced, if the number of lines in the input file exceeds this value or is unknown (read from stdin), you need to input the bucket name again to confirm the deletion; 0 means no need")
+	setBatchCmdValidateFlags(cmd, &info.BatchInfo)
 	return cmd
 }
 
@@ -96,6 +135,30 @@ var batchChangeMimeCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		},
 	}
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
+	setBatchCmdColumnsFlags(cmd, &info.BatchInfo, "key", "mimeType")
+	cmd.Flags().BoolVarP(&info.FromExtension, "from-extension", "", false, "when the mime type is not set in the input line, set the mime type inferred from the extension of key")
+	setBatchCmdValidateFlags(cmd, &info.BatchInfo)
+	return cmd
+}
+
+var batchSetMetaCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.BatchSetMetaInfo{}
+	var cmd = &cobra.Command{
+		Use:   "batchsetmeta <Bucket> [-i <KeyMetaMapFile>]",
+		Short: "Batch set or remove the custom metadata(x-qn-meta-*) of files in bucket",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.BatchSetMetaType
+			info.BatchInfo.EnableStdin = true
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			operations.BatchSetMeta(cfg, info)
+		},
+	}
+	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
+	cmd.Flags().StringVarP(&info.Meta, "meta", "", "", "metadata to set for every file, format: key1=value1,key2=value2, the metadata in the input line takes precedence")
+	cmd.Flags().StringSliceVarP(&info.Remove, "remove", "", nil, "names of metadata to remove for every file, can be set multiple times or separated by comma")
+	setBatchCmdValidateFlags(cmd, &info.BatchInfo)
 	return cmd
 }
 
@@ -103,7 +166,7 @@ var batchChangeTypeCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.BatchChangeTypeInfo{}
 	var cmd = &cobra.Command{
 		Use:   "batchchtype <Bucket> [-i <KeyFileTypeMapFile>]",
-		Short: "Batch change the file type of files in bucket",
+		Short: "Batch change the file type of files in bucket, file type can be 0 ~ 4 or standard, line, archive, deep_archive, archive_ir",
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.BatchChangeType
 			info.BatchInfo.EnableStdin = true
@@ -114,6 +177,8 @@ var batchChangeTypeCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		},
 	}
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
+	setBatchCmdColumnsFlags(cmd, &info.BatchInfo, "key", "type")
+	setBatchCmdValidateFlags(cmd, &info.BatchInfo)
 	return cmd
 }
 
@@ -135,6 +200,32 @@ var batchRestoreArCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		},
 	}
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
+	setBatchCmdValidateFlags(cmd, &info.BatchInfo)
+	return cmd
+}
+
+var batchRestoreCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.BatchRestoreArchiveInfo{
+		DaysColumnEnable: true,
+		SkipRestored:     true,
+	}
+	var cmd = &cobra.Command{
+		Use:   "batchrestore <Bucket> [<FreezeAfterDays>]",
+		Short: `Batch unfreeze archive and deep archive files, each line of the input is <Key>[<Sep><FreezeAfterDays>], files already restored are skipped`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.BatchRestoreType
+			info.BatchInfo.EnableStdin = true
+			if len(args) > 0 {
+				info.Bucket = args[0]
+			}
+			if len(args) > 1 {
+				info.FreezeAfterDays = args[1]
+			}
+			operations.BatchRestoreArchive(cfg, info)
+		},
+	}
+	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
+	setBatchCmdValidateFlags(cmd, &info.BatchInfo)
 	return cmd
 }
 
@@ -153,6 +244,7 @@ var batchDeleteAfterCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		},
 	}
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
+	setBatchCmdValidateFlags(cmd, &info.BatchInfo)
 	return cmd
 }
 
@@ -181,16 +273,25 @@ Lifecycle value must great than or equal to -1, unit: day.
 	cmd.Flags().IntVarP(&info.ToDeepArchiveAfterDays, "to-deep-archive-after-days", "", 0, "to DEEP_ARCHIVE storage after some days. the range is -1 or bigger than 0. -1 means cancel to DEEP_ARCHIVE storage")
 	cmd.Flags().IntVarP(&info.DeleteAfterDays, "delete-after-days", "", 0, "delete after some days. the range is -1 or bigger than 0. -1 means cancel to delete")
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
+	setBatchCmdValidateFlags(cmd, &info.BatchInfo)
 	return cmd
 }
 
 var batchMoveCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.BatchMoveInfo{}
+	var undoLogFile = ""
 	var cmd = &cobra.Command{
 		Use:   "batchmove <SrcBucket> <DestBucket> [-i <SrcDestKeyMapFile>]",
 		Short: "Batch move files from bucket to bucket",
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.BatchMoveType
+			if len(undoLogFile) > 0 {
+				operations.BatchMoveUndo(cfg, operations.BatchMoveUndoInfo{
+					BatchInfo:   info.BatchInfo,
+					UndoLogFile: undoLogFile,
+				})
+				return
+			}
 			info.BatchInfo.EnableStdin = true
 			if len(args) > 0 {
 				info.SourceBucket = args[0]
@@ -202,17 +303,28 @@ var batchMoveCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		},
 	}
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
+	setBatchCmdColumnsFlags(cmd, &info.BatchInfo, "key", "dstKey")
 	setBatchCmdOverwriteFlags(cmd, &info.BatchInfo)
+	setBatchCmdUndoFlags(cmd, &info.BatchInfo, &undoLogFile)
+	setBatchCmdValidateFlags(cmd, &info.BatchInfo)
 	return cmd
 }
 
 var batchRenameCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.BatchRenameInfo{}
+	var undoLogFile = ""
 	var cmd = &cobra.Command{
 		Use:   "batchrename <Bucket> [-i <OldNewKeyMapFile>]",
 		Short: "Batch rename files in the bucket",
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.BatchRenameType
+			if len(undoLogFile) > 0 {
+				operations.BatchMoveUndo(cfg, operations.BatchMoveUndoInfo{
+					BatchInfo:   info.BatchInfo,
+					UndoLogFile: undoLogFile,
+				})
+				return
+			}
 			info.BatchInfo.EnableStdin = true
 			if len(args) > 0 {
 				info.Bucket = args[0]
@@ -221,7 +333,10 @@ var batchRenameCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		},
 	}
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
+	setBatchCmdColumnsFlags(cmd, &info.BatchInfo, "key", "dstKey")
 	setBatchCmdOverwriteFlags(cmd, &info.BatchInfo)
+	setBatchCmdUndoFlags(cmd, &info.BatchInfo, &undoLogFile)
+	setBatchCmdValidateFlags(cmd, &info.BatchInfo)
 	return cmd
 }
 
@@ -243,15 +358,51 @@ var batchCopyCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 		},
 	}
 	setBatchCmdDefaultFlags(cmd, &info.BatchInfo)
+	setBatchCmdColumnsFlags(cmd, &info.BatchInfo, "key", "dstKey")
 	setBatchCmdOverwriteFlags(cmd, &info.BatchInfo)
+	setCopyPreserveFlags(cmd, &info.Preserve)
+	setBatchCmdValidateFlags(cmd, &info.BatchInfo)
+	return cmd
+}
+
+var xCopyCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.XCopyInfo{}
+	var cmd = &cobra.Command{
+		Use:   "xcopy <SrcBucket> <DestBucket> [--src-user <SrcUserName>] [--dest-user <DestUserName>]",
+		Short: "Copy files from bucket to bucket of another account",
+		Example: `copy all files in bucketA of user userA to bucketB of user userB, the users are added by: qshell user add
+	qshell xcopy bucketA bucketB --src-user userA --dest-user userB
+copy files with prefix a/ in bucketA of user userA to bucketB of current user
+	qshell xcopy bucketA bucketB --src-user userA --prefix a/`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.XCopyType
+			if len(args) > 0 {
+				info.SrcBucket = args[0]
+			}
+			if len(args) > 1 {
+				info.DestBucket = args[1]
+			}
+			operations.XCopy(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.SrcUser, "src-user", "", "", "name of the user who owns the source bucket, use current user if not set")
+	cmd.Flags().StringVarP(&info.DestUser, "dest-user", "", "", "name of the user who owns the dest bucket, use current user if not set")
+	cmd.Flags().StringVarP(&info.Prefix, "prefix", "p", "", "only copy files with this prefix")
+	cmd.Flags().StringVarP(&info.SrcDomain, "src-domain", "", "", "domain to download files of the source bucket, use the first domain of the source bucket if not set")
+	cmd.Flags().IntVarP(&info.BatchInfo.WorkerCount, "worker", "c", 10, "worker count")
+	setBatchCmdForceFlags(cmd, &info.BatchInfo)
+	setBatchCmdEnableRecordFlags(cmd, &info.BatchInfo)
+	setBatchCmdRecordRedoWhileErrorFlags(cmd, &info.BatchInfo)
+	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
+	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
 	return cmd
 }
 
 var batchSignCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.BatchPrivateUrlInfo{}
 	var cmd = &cobra.Command{
-		Use:   "batchsign [-i <ItemListFile>] [-e <Deadline>]",
-		Short: "Batch create the private url from the public url list file",
+		Use:   "batchsign [-i <ItemListFile>] [-e <Deadline>] [--bucket <Bucket> | --domain <Domain>]",
+		Short: "Batch create the private url from the public url list file or the key list file",
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg.CmdCfg.CmdId = docs.BatchSignType
 			info.BatchInfo.EnableStdin = true
@@ -263,7 +414,11 @@ var batchSignCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	setBatchCmdResultExportFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdEnableRecordFlags(cmd, &info.BatchInfo)
 	setBatchCmdRecordRedoWhileErrorFlags(cmd, &info.BatchInfo)
-	cmd.Flags().StringVarP(&info.Deadline, "deadline", "e", "3600", "deadline in seconds, default 3600")
+	setDeadletterFlags(cmd, &info.BatchInfo.FileExporterConfig)
+	cmd.Flags().StringVarP(&info.Deadline, "deadline", "e", "3600", "deadline of the private url, unix timestamp in seconds or ttl like 3600, +3600, 30m, 2h, 7d; a number less than 1000000000 is treated as ttl in seconds")
+	cmd.Flags().StringVarP(&info.Bucket, "bucket", "", "", "bucket of the keys, the first domain of the bucket is used to build the url, each line of the input file is a key when set")
+	cmd.Flags().StringVarP(&info.Domain, "domain", "", "", "domain used to build the url, each line of the input file is a key when set")
+	cmd.Flags().IntVarP(&info.BatchInfo.WorkerCount, "worker", "c", runtime.NumCPU(), "worker count of signing, default is the number of CPUs")
 	return cmd
 }
 
@@ -291,8 +446,10 @@ var batchFetchCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	setBatchCmdRecordRedoWhileErrorFlags(cmd, &info.BatchInfo)
 	setBatchCmdSuccessExportFileFlags(cmd, &info.BatchInfo)
 	setBatchCmdFailExportFileFlags(cmd, &info.BatchInfo)
+	setDeadletterFlags(cmd, &info.BatchInfo.FileExporterConfig)
 	setBatchCmdItemSeparateFlags(cmd, &info.BatchInfo)
 	setBatchCmdForceFlags(cmd, &info.BatchInfo)
+	setBatchCmdMaxErrorFlags(cmd, &info.BatchInfo)
 	cmd.Flags().IntVarP(&info.BatchInfo.WorkerCount, "worker", "c", 1, "worker count")
 	cmd.Flags().StringVarP(&upHost, "up-host", "u", "", "fetch uphost")
 	return cmd
@@ -302,16 +459,34 @@ func setBatchCmdDefaultFlags(cmd *cobra.Command, info *batch.Info) {
 	setBatchCmdInputFileFlags(cmd, info)
 	setBatchCmdWorkerCountFlags(cmd, info)
 	setBatchCmdMinWorkerCountFlags(cmd, info)
+	setBatchCmdMaxWorkerCountFlags(cmd, info)
 	setBatchCmdWorkerCountIncreasePeriodFlags(cmd, info)
 	setBatchCmdEnableRecordFlags(cmd, info)
 	setBatchCmdRecordRedoWhileErrorFlags(cmd, info)
 	setBatchCmdSuccessExportFileFlags(cmd, info)
 	setBatchCmdFailExportFileFlags(cmd, info)
+	setDeadletterFlags(cmd, &info.FileExporterConfig)
 	setBatchCmdItemSeparateFlags(cmd, info)
 	setBatchCmdForceFlags(cmd, info)
+	setBatchCmdMaxErrorFlags(cmd, info)
+	setBatchCmdDryRunFlags(cmd, info)
+	setFlowKeyFilterFlags(cmd, &info.Info)
+	setFlowProgressFlags(cmd, &info.Info)
+	setFlowRetryFlags(cmd, &info.Info)
 }
 func setBatchCmdInputFileFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().StringVarP(&info.InputFile, "input-file", "i", "", "input file, read from stdin if not set")
+	setBatchCmdInputFormatFlags(cmd, info)
+}
+func setBatchCmdInputFormatFlags(cmd *cobra.Command, info *batch.Info) {
+	cmd.Flags().StringVarP(&info.InputFormat, "input-format", "", flow.InputFormatAuto, "the format of the input file: auto, tsv, csv or json. auto detects the format from the first lines, csv is only detected when there are quoted fields or a header line, and it is treated as tsv when --sep is specified")
+	cmd.Flags().BoolVarP(&info.InputHasHeader, "has-header", "", false, "the first line of the input file is a header line, it is skipped. the columns are mapped by the names in it if the command supports --columns and --columns is not specified")
+}
+
+// setBatchCmdColumnsFlags fields 为命令支持的列名，按默认的列顺序排列
+func setBatchCmdColumnsFlags(cmd *cobra.Command, info *batch.Info, fields ...string) {
+	cmd.Flags().StringSliceVarP(&info.InputColumns, "columns", "", nil, fmt.Sprintf("the names of the columns in the input file in order, separated by comma, the name should be one of %s, or %s to ignore the column. default is %s",
+		strings.Join(fields, ", "), flow.IgnoredColumn, strings.Join(fields, ",")))
 }
 func setBatchCmdForceFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().BoolVarP(&info.Force, "force", "y", false, "force mode, default false")
@@ -322,6 +497,9 @@ func setBatchCmdWorkerCountFlags(cmd *cobra.Command, info *batch.Info) {
 func setBatchCmdMinWorkerCountFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().IntVarP(&info.MinWorkerCount, "min-worker", "", 1, "min worker count. 1 means the number of objects in one operation is 1000 and if configured as 3 , the number of objects in one operation is 3000. for more, please refer to worker")
 }
+func setBatchCmdMaxWorkerCountFlags(cmd *cobra.Command, info *batch.Info) {
+	cmd.Flags().IntVarP(&info.MaxWorkerCount, "max-worker", "", 0, "max worker count. when set, qshell will dynamically adjust the worker count between min-worker and max-worker according to the observed latency and overrun errors, the adjustment period is worker-count-increase-period. 0 means the worker count is fixed")
+}
 func setBatchCmdWorkerCountIncreasePeriodFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().IntVarP(&info.WorkerCountIncreasePeriod, "worker-count-increase-period", "", 60, "worker count increase period. when the worker count is too big, an overrun error will be triggered. In order to alleviate this problem, qshell will automatically reduce the worker count. In order to complete the operation as quickly as possible, qshell will periodically increase the worker count. unit: second")
 }
@@ -336,6 +514,7 @@ func setBatchCmdRecordRedoWhileErrorFlags(cmd *cobra.Command, info *batch.Info)
 }
 func setBatchCmdSuccessExportFileFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().StringVarP(&info.SuccessExportFilePath, "success-list", "s", "", "specifies the file path where the successful file list is saved")
+	setFlowResumeFlags(cmd, &info.Info)
 }
 func setBatchCmdFailExportFileFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list", "e", "", "specifies the file path where the failure file list is saved")
@@ -344,17 +523,67 @@ func setBatchCmdOverwriteFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().BoolVarP(&info.Overwrite, "overwrite", "w", false, "overwrite mode")
 	_ = cmd.Flags().MarkShorthandDeprecated("overwrite", "deprecated and use --overwrite instead")
 }
+
+func setBatchCmdUndoFlags(cmd *cobra.Command, info *batch.Info, undoLogFile *string) {
+	cmd.Flags().StringVarP(&info.UndoExportFilePath, "undo-log", "", "", "specifies the file path where the undo log of successful moves is saved, which can be replayed by --undo")
+	cmd.Flags().StringVarP(undoLogFile, "undo", "", "", "undo the moves recorded in the undo log file in reverse order, the moved file which no longer exists is skipped; <SrcBucket> <DestBucket> and --input-file are ignored")
+}
 func setBatchCmdResultExportFileFlags(cmd *cobra.Command, info *batch.Info) {
 	cmd.Flags().StringVarP(&info.ResultExportFilePath, "outfile", "o", "", "specifies the file path where the results is saved")
 }
+func setBatchCmdDryRunFlags(cmd *cobra.Command, info *batch.Info) {
+	cmd.Flags().BoolVarP(&info.DryRun, "dry-run", "", false, "only print the operations that would be executed without executing them; the work record is consulted but not modified")
+}
+
+// setBatchCmdValidateFlags 由 validate 命令设置，仅校验输入文件
+func setBatchCmdValidateFlags(cmd *cobra.Command, info *batch.Info) {
+	cmd.Flags().BoolVarP(&info.Validate, validateFlagName, "", false, "only validate the input file without executing, use the validate command instead")
+	_ = cmd.Flags().MarkHidden(validateFlagName)
+}
+func setBatchCmdMaxErrorFlags(cmd *cobra.Command, info *batch.Info) {
+	setFlowMaxErrorFlags(cmd, &info.Info)
+}
+
+func setFlowMaxErrorFlags(cmd *cobra.Command, info *flow.Info) {
+	cmd.Flags().Int64VarP(&info.MaxErrorCount, "max-error-count", "", 0, "stop the task when the number of failed items reaches this value, 0 means no limit")
+	cmd.Flags().Float64VarP(&info.MaxErrorRate, "max-error-rate", "", 0, "stop the task when the ratio of failed items exceeds this value, between 0 and 1, 0 means no limit. It is only checked after at least 100 items have been processed")
+}
+
+func setFlowProgressFlags(cmd *cobra.Command, info *flow.Info) {
+	cmd.Flags().BoolVarP(&info.ShowProgress, "show-progress", "", false, "show an aggregate progress bar of the whole task with ETA instead of the progress of each item")
+}
+
+func setFlowRetryFlags(cmd *cobra.Command, info *flow.Info) {
+	cmd.Flags().IntVarP(&info.RetryCount, "retry", "", 0, "the max retry times of an item when it fails with a transient error, such as timeout, connection reset and 5xx, 0 means no retry")
+	cmd.Flags().IntVarP(&info.RetryMaxDelay, "retry-max-delay", "", 10, "the max delay before retrying, the delay increases exponentially with jitter. unit: second")
+}
+
+func setFlowSizeFilterFlags(cmd *cobra.Command, info *flow.Info) {
+	cmd.Flags().StringVarP(&info.MinSize, "min-size", "", "", "skip the files whose size is less than this value, like 512k, 10m, empty means no limit")
+	cmd.Flags().StringVarP(&info.MaxSize, "max-size", "", "", "skip the files whose size is greater than this value, like 512k, 10m, empty means no limit")
+}
+
+func setFlowResumeFlags(cmd *cobra.Command, info *flow.Info) {
+	cmd.Flags().StringVarP(&info.ResumeFile, "resume", "", "", "the success list of a previous run, the items in it are skipped. it's a lightweight way to resume without the local work record")
+}
+
+func setDeadletterFlags(cmd *cobra.Command, config *export.FileExporterConfig) {
+	cmd.Flags().StringVarP(&config.DeadletterExportFilePath, "deadletter", "", "", "specifies the file path where the input lines of failed items are saved without error messages, it can be used as the input file to re-run the failed items directly")
+}
+
+func setFlowKeyFilterFlags(cmd *cobra.Command, info *flow.Info) {
+	cmd.Flags().StringArrayVarP(&info.IncludeKeyRegexes, "include", "", nil, "only process the items whose key matches one of the regular expressions, can be specified multiple times")
+	cmd.Flags().StringArrayVarP(&info.ExcludeKeyRegexes, "exclude", "", nil, "skip the items whose key matches one of the regular expressions, can be specified multiple times")
+}
 
 func init() {
 	registerLoader(rsBatchCmdLoader)
 }
 
 func rsBatchCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
-	superCmd.AddCommand(
+	superCmd.AddCommand(withRegionFlags(cfg,
 		batchStatCmdBuilder(cfg),
+		batchObjectExpireCmdBuilder(cfg),
 		batchForbiddenCmdBuilder(cfg),
 		batchCopyCmdBuilder(cfg),
 		batchMoveCmdBuilder(cfg),
@@ -363,9 +592,14 @@ func rsBatchCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 		batchChangeLifecycleCmdBuilder(cfg),
 		batchDeleteAfterCmdBuilder(cfg),
 		batchChangeMimeCmdBuilder(cfg),
+		batchSetMetaCmdBuilder(cfg),
 		batchChangeTypeCmdBuilder(cfg),
 		batchRestoreArCmdBuilder(cfg),
-		batchSignCmdBuilder(cfg),
+		batchRestoreCmdBuilder(cfg),
 		batchFetchCmdBuilder(cfg),
+	)...)
+	superCmd.AddCommand(
+		xCopyCmdBuilder(cfg),
+		batchSignCmdBuilder(cfg),
 	)
 }
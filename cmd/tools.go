@@ -185,6 +185,32 @@ var etagCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 			operations.CreateEtag(cfg, info)
 		},
 	}
+	cmd.Flags().StringVarP(&info.BlockSize, "blocksize", "", "", "the part size of resumable upload v2, like 8m. the etag is calculated by the v2 algorithm with the part size, empty means the v1 algorithm (4M blocks)")
+	cmd.Flags().BoolVarP(&info.ShowDetail, "json", "", false, "print the etag and the sha1 of each block in json, for debugging hash mismatches")
+	cmd.Flags().StringVarP(&info.Verify, "verify", "", "", "the expected etag, exit with non-zero status when the etag of the file doesn't match")
+	return cmd
+}
+
+var batchEtagCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.BatchEtagInfo{}
+	var cmd = &cobra.Command{
+		Use:   "batchqetag <LocalDir>",
+		Short: "Calculate the hash of all files in local directory using the algorithm of qiniu qetag",
+		Long:  "Walk through the local directory and calculate the hash of all files in parallel, each line of the output is: <RelativePath>\t<Hash>\t<FileSize>",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.BatchQEtagType
+			if len(args) > 0 {
+				info.Dir = args[0]
+			}
+			operations.BatchEtag(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.BlockSize, "blocksize", "", "", "the part size of resumable upload v2, like 8m. the etag is calculated by the v2 algorithm with the part size, empty means the v1 algorithm (4M blocks)")
+	cmd.Flags().BoolVarP(&info.FollowSymlinks, "follow-symlinks", "", false, "follow the symlinks, the symlinks are skipped by default")
+	cmd.Flags().BoolVarP(&info.Sort, "sort", "", false, "sort the output by relative path after all files are calculated, for diffing with other lists")
+	cmd.Flags().IntVarP(&info.WorkerCount, "worker", "c", 0, "worker count, default is the number of CPU cores")
+	cmd.Flags().StringVarP(&info.ResultExportFilePath, "outfile", "o", "", "specifies the file path where the results is saved, print to stdout if not set")
+	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list", "e", "", "specifies the file path where the unreadable file list is saved")
 	return cmd
 }
 
@@ -226,6 +252,75 @@ var reqIdCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	return cmd
 }
 
+var upTokenCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "uptoken",
+		Short: "Create upload token from put policy or decode upload token",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.UpTokenType
+			operations.UpToken(cfg)
+		},
+	}
+
+	cmd.AddCommand(
+		upTokenCreateCmdBuilder(cfg),
+		upTokenDecodeCmdBuilder(cfg),
+	)
+	return cmd
+}
+
+var upTokenCreateCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.UpTokenCreateInfo{}
+	var cmd = &cobra.Command{
+		Use:   "create <PutPolicyJsonFile>",
+		Short: "Create upload token from put policy json file",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.UpTokenType
+			if len(args) > 0 {
+				info.PutPolicyFilePath = args[0]
+			}
+			operations.CreateUpToken(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.AccessKey, "access-key", "a", "", "access key")
+	cmd.Flags().StringVarP(&info.SecretKey, "secret-key", "s", "", "secret key")
+	return cmd
+}
+
+var upTokenDecodeCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.UpTokenDecodeInfo{}
+	var cmd = &cobra.Command{
+		Use:   "decode <UploadToken>",
+		Short: "Decode upload token and print the put policy json",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.UpTokenType
+			if len(args) > 0 {
+				info.Token = args[0]
+			}
+			operations.DecodeUpToken(cfg, info)
+		},
+	}
+	return cmd
+}
+
+var checkSignCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.CheckSignInfo{}
+	var cmd = &cobra.Command{
+		Use:   "checksign <PrivateUrlOrUploadToken>",
+		Short: "Verify the signature of private url or upload token with current account",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.CheckSignType
+			if len(args) > 0 {
+				info.Sign = args[0]
+			}
+			operations.CheckSign(cfg, info)
+		},
+	}
+	cmd.Flags().StringVarP(&info.AccessKey, "access-key", "a", "", "access key")
+	cmd.Flags().StringVarP(&info.SecretKey, "secret-key", "s", "", "secret key")
+	return cmd
+}
+
 var IpCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var info = operations.IpQueryInfo{}
 	var cmd = &cobra.Command{
@@ -378,10 +473,13 @@ func toolsCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 		urlEncodeCmdBuilder(cfg),
 		urlDecodeCmdBuilder(cfg),
 		etagCmdBuilder(cfg),
+		batchEtagCmdBuilder(cfg),
 		unzipCmdBuilder(cfg),
 		reqIdCmdBuilder(cfg),
 		IpCmdBuilder(cfg),
 		TokenCmdBuilder(cfg),
+		upTokenCmdBuilder(cfg),
+		checkSignCmdBuilder(cfg),
 		dirCacheCmdBuilder(cfg),
 		funcCmdBuilder(cfg),
 	)
@@ -7,6 +7,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell"
 	"github.com/qiniu/qshell/v2/iqshell/common/config"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	objectOperations "github.com/qiniu/qshell/v2/iqshell/storage/object/operations"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/upload/operations"
 )
 
@@ -32,11 +33,28 @@ var uploadCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list", "e", "", "specifies the file path where the failure file list is saved")
 	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list-old", "f", "", "specifies the file path where the failure file list is saved, deprecated")
 	_ = cmd.Flags().MarkDeprecated("failure-list-old", "use --failure-list instead")
+	setDeadletterFlags(cmd, &info.FileExporterConfig)
 
 	cmd.Flags().StringVarP(&info.OverwriteExportFilePath, "overwrite-list", "w", "", "specifies the file path where the overwrite file list is saved")
 	cmd.Flags().IntVarP(&info.Info.WorkerCount, "worker", "c", 1, "worker count")
+	cmd.Flags().IntVarP(&info.Info.MaxWorkerCount, "max-worker", "", 0, "max worker count. when set, qshell will dynamically adjust the worker count between 1 and max-worker according to the observed upload latency, 0 means the worker count is fixed")
 	cmd.Flags().StringVarP(&info.CallbackUrl, "callback-urls", "l", "", "upload callback urls, separated by comma")
 	cmd.Flags().StringVarP(&info.CallbackHost, "callback-host", "T", "", "upload callback host")
+	cmd.Flags().BoolVarP(&info.MimeTypeFromExtension, "mimetype-from-extension", "", false, "set the mime type of the file according to its extension, same to mimetype_from_extension of upload config")
+	cmd.Flags().StringVarP(&info.RateLimit, "rate-limit", "", "", "the total bandwidth limit of all threads, like 512k, 5m, unit:B/s. same to rate_limit of upload config, empty means no limit")
+	cmd.Flags().BoolVarP(&info.ForceRehash, "force-rehash", "", false, "recompute the hash of local files to compare with the last upload even if their size and modify time are unchanged, same to force_rehash of upload config")
+	cmd.Flags().BoolVarP(&info.NoHashCache, "no-hash-cache", "", false, "don't use the cached etag of local files, same to no_hash_cache of upload config")
+	cmd.Flags().StringVarP(&info.ResumeRecordDir, "resume-record-dir", "", "", "a dir shared by machines to save the resume records of resumable upload, any machine uploading the same file to the same key can resume from the record, same to resume_record_dir of upload config")
+	cmd.Flags().StringVarP(&info.OnCollision, "on-collision", "", "", "check whether multiple local files are uploaded to the same key before uploading, one of error, skip and overwrite. error: report the collisions and upload nothing; skip: only upload the first file of each key; overwrite: only report the collisions. same to on_collision of upload config, empty means no check")
+	cmd.Flags().IntVarP(&info.PartConcurrency, "part-concurrency", "", 0, "the number of concurrently uploaded parts of a single file in resumable upload, same to part_concurrency of upload config, 0 means using the upload config")
+	cmd.Flags().StringVarP(&info.PartSize, "part-size", "", "", "the part size of resumable upload, like 4m, 16m, between 1m and 1g. resumable upload v2 is used when set. same to part_size of upload config")
+	cmd.Flags().StringVarP(&info.MultipartThreshold, "multipart-threshold", "", "", "files whose size is not less than the threshold are uploaded by resumable upload, like 8m, 32m. same to multipart_threshold of upload config")
+	setFlowMaxErrorFlags(cmd, &info.Info)
+	setFlowKeyFilterFlags(cmd, &info.Info)
+	setFlowResumeFlags(cmd, &info.Info)
+	setFlowSizeFilterFlags(cmd, &info.Info)
+	setFlowRetryFlags(cmd, &info.Info)
+	setFlowProgressFlags(cmd, &info.Info)
 	return cmd
 }
 
@@ -66,9 +84,18 @@ var upload2CmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	}
 	cmd.Flags().StringVarP(&info.SuccessExportFilePath, "success-list", "s", "", "upload success file list")
 	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list", "e", "", "upload failure file list")
+	setDeadletterFlags(cmd, &info.FileExporterConfig)
 	cmd.Flags().StringVarP(&info.OverwriteExportFilePath, "overwrite-list", "w", "", "upload success (overwrite) file list")
 	cmd.Flags().IntVar(&info.Info.WorkerCount, "thread-count", 1, "multiple thread count")
-	cmd.Flags().IntVar(&info.UploadConfig.WorkerCount, "worker-count", 3, "the number of concurrently uploaded parts of a single file in resumable upload")
+	cmd.Flags().IntVar(&info.Info.MaxWorkerCount, "max-thread-count", 0, "max thread count. when set, qshell will dynamically adjust the thread count between 1 and max-thread-count according to the observed upload latency, 0 means the thread count is fixed")
+	cmd.Flags().StringVar(&info.UploadConfig.RateLimit, "rate-limit", "", "the total bandwidth limit of all threads, like 512k, 5m, unit:B/s. empty means no limit")
+	cmd.Flags().BoolVar(&info.UploadConfig.ForceRehash, "force-rehash", false, "recompute the hash of local files to compare with the last upload even if their size and modify time are unchanged")
+	cmd.Flags().BoolVar(&info.UploadConfig.NoHashCache, "no-hash-cache", false, "don't use the cached etag of local files. by default the etag of local files is cached in the workspace and reused when their size and modify time are unchanged")
+	cmd.Flags().StringVar(&info.UploadConfig.ResumeRecordDir, "resume-record-dir", "", "a dir shared by machines to save the resume records of resumable upload, any machine uploading the same file to the same key can resume from the record")
+	cmd.Flags().StringVar(&info.UploadConfig.OnCollision, "on-collision", "", "check whether multiple local files are uploaded to the same key before uploading, one of error, skip and overwrite. error: report the collisions and upload nothing; skip: only upload the first file of each key; overwrite: only report the collisions. empty means no check")
+	cmd.Flags().IntVar(&info.UploadConfig.WorkerCount, "part-concurrency", 3, "the number of concurrently uploaded parts of a single file in resumable upload. all threads share a pool of part-concurrency * thread-count part uploaders")
+	cmd.Flags().IntVar(&info.UploadConfig.WorkerCount, "worker-count", 3, "the number of concurrently uploaded parts of a single file in resumable upload, same to --part-concurrency")
+	_ = cmd.Flags().MarkDeprecated("worker-count", "use --part-concurrency instead")
 	cmd.Flags().BoolVar(&info.UploadConfig.SequentialReadFile, "sequential-read-file", false, "File reading is sequential and does not involve skipping; when enabled, the uploading fragment data will be loaded into the memory. This option may increase file upload speed for mounted network filesystems.")
 
 	cmd.Flags().BoolVarP(&info.ResumableAPIV2, "resumable-api-v2", "", false, "use resumable upload v2 APIs to upload")
@@ -81,9 +108,13 @@ var upload2CmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().BoolVar(&info.RescanLocal, "rescan-local", false, "rescan local dir to upload newly add files")
 
 	cmd.Flags().StringVar(&info.SrcDir, "src-dir", "", "src dir to upload")
+	cmd.Flags().StringVar(&info.SrcArchive, "src-archive", "", "upload the files in the archive without extraction, support .tar, .tar.gz, .tgz and .zip. can't be used with --src-dir")
+	cmd.Flags().StringArrayVar(&info.SrcGlobs, "src-glob", nil, "only upload the files in src dir matched by the glob pattern, like logs/2024-*/*.gz, ** matches any levels of directories. can be specified multiple times")
 	cmd.Flags().StringVar(&info.FileList, "file-list", "", "file list to upload")
 	cmd.Flags().StringVar(&info.Bucket, "bucket", "", "bucket")
 	cmd.Flags().Int64Var(&info.PutThreshold, "put-threshold", 8*1024*1024, "chunk upload threshold, unit: B")
+	cmd.Flags().StringVar(&info.PartSize, "part-size", "", "the part size of resumable upload, like 4m, 16m, between 1m and 1g. resumable upload v2 is used when set, it takes precedence over --resumable-api-v2-part-size")
+	cmd.Flags().StringVar(&info.MultipartThreshold, "multipart-threshold", "", "files whose size is not less than the threshold are uploaded by resumable upload, like 8m, 32m. it takes precedence over --put-threshold")
 	cmd.Flags().StringVar(&info.KeyPrefix, "key-prefix", "", "key prefix prepended to dest file key")
 	cmd.Flags().StringVar(&info.SkipFilePrefixes, "skip-file-prefixes", "", "skip files with these file prefixes")
 	cmd.Flags().StringVar(&info.SkipPathPrefixes, "skip-path-prefixes", "", "skip files with these relative path prefixes")
@@ -92,6 +123,12 @@ var upload2CmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().StringVar(&info.UpHost, "up-host", "", "upload host")
 	cmd.Flags().BoolVarP(&info.Accelerate, "accelerate", "", false, "enable uploading acceleration")
 	cmd.Flags().StringVar(&info.RecordRoot, "record-root", "", "record root dir, and will save record info to the dir(db and log), default <UserRoot>/.qshell")
+	setFlowMaxErrorFlags(cmd, &info.Info)
+	setFlowKeyFilterFlags(cmd, &info.Info)
+	setFlowResumeFlags(cmd, &info.Info)
+	setFlowSizeFilterFlags(cmd, &info.Info)
+	setFlowRetryFlags(cmd, &info.Info)
+	setFlowProgressFlags(cmd, &info.Info)
 	cmd.Flags().StringVar(&LogFile, "log-file", "", "log file")
 	cmd.Flags().StringVar(&LogLevel, "log-level", "debug", "log level")
 	cmd.Flags().IntVar(&LogRotate, "log-rotate", 7, "log rotate days")
@@ -104,7 +141,7 @@ var upload2CmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	//cmd.Flags().StringVar(&cfg.CmdCfg.Up.BindRsIp, "bind-rs-ip", "", "rs host ip to bind")
 	//cmd.Flags().StringVar(&cfg.CmdCfg.Up.BindNicIp, "bind-nic-ip", "", "local network interface card to bind")
 
-	cmd.Flags().StringVarP(&info.EndUser, "end-user", "", "", "Owner identification")
+	cmd.Flags().StringVarP(&info.EndUser, "end-user", "", "", "Owner identification, {dirN} is replaced with the Nth dir of the file relative path, like {dir1}")
 	cmd.Flags().StringVarP(&info.CallbackURL, "callback-urls", "l", "", "upload callback urls, separated by comma")
 	cmd.Flags().StringVarP(&info.CallbackHost, "callback-host", "T", "", "upload callback host")
 	cmd.Flags().StringVarP(&info.CallbackBody, "callback-body", "", "", "upload callback body")
@@ -122,6 +159,9 @@ The default value is set to 0.If the uploader specifies MimeType (except applica
 	2. Check the Key extension;
 	3. Detect content.
 Set to a value of -1 and use this value regardless of what value is specified on the uploader.`)
+	cmd.Flags().BoolVarP(&info.MimeTypeFromExtension, "mimetype-from-extension", "", false, "set the mime type of the file according to its extension, files with an explicitly specified mime type are not affected")
+	cmd.Flags().StringArrayVar(&info.MetadataItems, "metadata", nil, "metadata of the uploaded files, like Content-Type:text/html, Cache-Control:max-age=3600. Content-Type is used as the mime type, others are saved as custom metadata x-qn-meta-<Name>. can be specified multiple times")
+	cmd.Flags().StringVarP(&info.MimeTypeTableFile, "mimetype-table-file", "", "", "a json file which maps file extension to mime type, like {\".md\": \"text/markdown\"}, it takes precedence over the system mapping. used with --mimetype-from-extension")
 	cmd.Flags().Uint64VarP(&info.TrafficLimit, "traffic-limit", "", 0, "Upload request single link speed limit to control client bandwidth usage. The speed limit value range is 819200 ~ 838860800, and the unit is bit/s.")
 	return cmd
 }
@@ -146,8 +186,11 @@ var syncCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().StringVarP(&info.SaveKey, "key", "k", "", "save as <key> in bucket")
 	cmd.Flags().BoolVarP(&info.UseResumeV2, "resumable-api-v2", "", false, "use resumable upload v2 APIs to upload")
 	cmd.Flags().Int64VarP(&info.ChunkSize, "resumable-api-v2-part-size", "", data.BLOCK_SIZE, "the part size when use resumable upload v2 APIs to upload, default 4M")
+	cmd.Flags().StringVarP(&info.PartSize, "part-size", "", "", "the part size of resumable upload, like 4m, 16m, between 1m and 1g. resumable upload v2 is used when set, it takes precedence over --resumable-api-v2-part-size")
 	cmd.Flags().StringVarP(&info.UpHost, "up-host", "u", "", "upload host")
 	cmd.Flags().BoolVarP(&info.Accelerate, "accelerate", "", false, "enable uploading acceleration")
+	cmd.Flags().IntVarP(&info.ResumeWorkerCount, "part-concurrency", "", 3, "the count of blocks fetched and uploaded concurrently, only works when the source supports range requests")
+	cmd.Flags().IntVarP(&info.MaxRedirects, "max-redirects", "", 10, "the max count of redirects to follow when fetching the source, 0 means redirects are not followed")
 
 	cmd.Flags().IntVarP(&info.FileType, "file-type", "", 0, "set storage type of file, 0:STANDARD storage, 1:IA storage, 2:ARCHIVE storage, 3:DEEP_ARCHIVE storage, 4:ARCHIVE_IR storage")
 	cmd.Flags().IntVarP(&info.FileType, "storage", "s", 0, "set storage type of file, same to --file-type")
@@ -256,6 +299,7 @@ var resumeUploadCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().BoolVar(&info.SequentialReadFile, "sequential-read-file", false, "File reading is sequential and does not involve skipping; when enabled, the uploading fragment data will be loaded into the memory. This option may increase file upload speed for mounted network filesystems.")
 
 	cmd.Flags().Int64VarP(&info.ChunkSize, "resumable-api-v2-part-size", "", data.BLOCK_SIZE, "the part size when use resumable upload v2 APIs to upload, default 4M")
+	cmd.Flags().StringVarP(&info.PartSize, "part-size", "", "", "the part size of resumable upload, like 4m, 16m, between 1m and 1g. resumable upload v2 is used when set, it takes precedence over --resumable-api-v2-part-size")
 	cmd.Flags().Int64VarP(&info.ChunkSize, "v2-part-size", "", data.BLOCK_SIZE, "the part size when use resumable upload v2 APIs to upload, same to --resumable-api-v2-part-size")
 	_ = cmd.Flags().MarkDeprecated("v2-part-size", "use --resumable-api-v2-part-size instead")
 
@@ -263,7 +307,9 @@ var resumeUploadCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().IntVarP(&info.FileType, "storage", "s", 0, "set storage type of file, same to --file-type")
 	_ = cmd.Flags().MarkDeprecated("storage", "use --file-type instead") // 废弃 storage
 
-	cmd.Flags().IntVarP(&info.ResumeWorkerCount, "worker", "c", 3, "worker count")
+	cmd.Flags().IntVarP(&info.ResumeWorkerCount, "part-concurrency", "", 3, "the number of concurrently uploaded parts in resumable upload")
+	cmd.Flags().IntVarP(&info.ResumeWorkerCount, "worker", "c", 3, "the number of concurrently uploaded parts in resumable upload, same to --part-concurrency")
+	_ = cmd.Flags().MarkDeprecated("worker", "use --part-concurrency instead")
 	cmd.Flags().StringVarP(&info.UpHost, "up-host", "u", "", "uphost")
 	cmd.Flags().BoolVarP(&info.Accelerate, "accelerate", "", false, "enable uploading acceleration")
 
@@ -289,16 +335,46 @@ Set to a value of -1 and use this value regardless of what value is specified on
 	return cmd
 }
 
+var dirSyncCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.DirSyncInfo{}
+	var cmd = &cobra.Command{
+		Use:   "dirsync <LocalDir> <Bucket[:Prefix]>",
+		Short: "Synchronize the local directory to the prefix of bucket",
+		Long:  "Synchronize the local directory to the prefix of bucket, upload the new and changed files, and delete the files which only exist in bucket when --delete is set. the key of local file is <Prefix><RelativePath>",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.DirSyncType
+			if len(args) > 0 {
+				info.LocalDir = args[0]
+			}
+			if len(args) > 1 {
+				info.Bucket, info.Prefix = objectOperations.ParseBucketPrefix(args[1])
+			}
+			operations.DirSync(cfg, info)
+		},
+	}
+	cmd.Flags().BoolVarP(&info.Delete, "delete", "", false, "delete the files which only exist in bucket, you need to confirm by entering the bucket name unless --force is set")
+	cmd.Flags().BoolVarP(&info.DryRun, "dry-run", "", false, "only print the operations that would be executed without executing them; the work record is consulted but not modified")
+	cmd.Flags().BoolVarP(&info.Force, "force", "y", false, "force mode, don't need to confirm when --delete is set")
+	cmd.Flags().BoolVarP(&info.FollowSymlinks, "follow-symlinks", "", false, "follow the symlinks, the symlinks are skipped by default")
+	cmd.Flags().IntVarP(&info.WorkerCount, "worker", "c", 4, "worker count")
+	cmd.Flags().IntVarP(&info.MaxRetry, "max-retry", "x", 20, "max retries when listing bucket error occurred, -1 means retry forever")
+	cmd.Flags().BoolVarP(&info.EnableRecord, "enable-record", "", false, "record work progress, and skip the operations which have been done successfully while retry")
+	cmd.Flags().StringVarP(&info.SuccessExportFilePath, "success-list", "s", "", "specifies the file path where the successful operations is saved")
+	cmd.Flags().StringVarP(&info.FailExportFilePath, "failure-list", "e", "", "specifies the file path where the failure operations is saved")
+	return cmd
+}
+
 func init() {
 	registerLoader(uploadCmdLoader)
 }
 
 func uploadCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
-	superCmd.AddCommand(
+	superCmd.AddCommand(withRegionFlags(cfg,
 		upload2CmdBuilder(cfg),
 		uploadCmdBuilder(cfg),
 		syncCmdBuilder(cfg),
 		formUploadCmdBuilder(cfg),
 		resumeUploadCmdBuilder(cfg),
-	)
+		dirSyncCmdBuilder(cfg),
+	)...)
 }
@@ -41,6 +41,89 @@ var accountCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	return cmd
 }
 
+// 添加账户并切换为当前账户
+var accountAddCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.AddInfo{}
+	var cmd = &cobra.Command{
+		Use:     "add <Name> <AccessKey> <SecretKey>",
+		Short:   "Add an account profile to local and use it as the current account",
+		Example: `qshell account add <Name> <AK> <SK>`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.Account
+			if len(args) > 0 {
+				info.Name = args[0]
+			}
+			if len(args) > 1 {
+				info.AccessKey = args[1]
+			}
+			if len(args) > 2 {
+				info.SecretKey = args[2]
+			}
+			operations.Add(cfg, info)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&info.Over, "overwrite", "w", false, "overwrite account or not when account exists in local db, by default not overwrite")
+	cmd.Flags().BoolVarP(&info.EnablePassphrase, "passphrase", "", false, "encrypt the secret key with a passphrase, which is read from env QSHELL_ACCOUNT_PASSPHRASE or the terminal")
+
+	return cmd
+}
+
+// 切换当前账户
+var accountUseCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.ChangeInfo{}
+	var cmd = &cobra.Command{
+		Use:     "use <Name>",
+		Short:   "Use the account profile with name as the current account",
+		Example: `qshell account use <Name>`,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.Account
+			info.Name = args[0]
+			operations.Change(cfg, info)
+		},
+	}
+	return cmd
+}
+
+// 列举本地的账户
+var accountListCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.ListInfo{}
+	var cmd = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all account profiles of local",
+		Example: `qshell account list`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.Account
+			operations.List(cfg, info)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&info.OnlyListName, "name", "n", false, "only list account names")
+
+	return cmd
+}
+
+// 删除本地的账户
+var accountRmCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var info = operations.RemoveInfo{}
+	var cmd = &cobra.Command{
+		Use:     "rm <Name>",
+		Aliases: []string{"remove"},
+		Short:   "Remove the account profile with name from local, the current account is not changed",
+		Example: `qshell account rm <Name>`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.Account
+			if len(args) > 0 {
+				info.Name = args[0]
+			}
+			operations.Remove(cfg, info)
+		},
+	}
+	return cmd
+}
+
 var userCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	var cmd = &cobra.Command{
 		Use:   "user",
@@ -128,6 +211,7 @@ var userAddCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
 	cmd.Flags().StringVarP(&info.SecretKey, "sk", "", "", "user's secret key of Qiniu")
 	cmd.Flags().StringVarP(&info.Name, "name", "", "", "user id of local")
 	cmd.Flags().BoolVarP(&info.Over, "overwrite", "w", false, "overwrite user or not when account exists in local db, by default not overwrite")
+	cmd.Flags().BoolVarP(&info.EnablePassphrase, "passphrase", "", false, "encrypt the secret key with a passphrase, which is read from env QSHELL_ACCOUNT_PASSPHRASE or the terminal")
 
 	return cmd
 }
@@ -199,8 +283,16 @@ func userCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
 		userCurrentCmdBuilder(cfg), // 查看当前用户信息
 	)
 
+	accountCmd := accountCmdBuilder(cfg)
+	accountCmd.AddCommand(
+		accountAddCmdBuilder(cfg),  // 添加账户
+		accountUseCmdBuilder(cfg),  // 切换当前账户
+		accountListCmdBuilder(cfg), // 列举所有账户
+		accountRmCmdBuilder(cfg),   // 删除某个账户
+	)
+
 	superCmd.AddCommand(
-		accountCmdBuilder(cfg),
+		accountCmd,
 		userCmd,
 	)
 }
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/qiniu/qshell/v2/docs"
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// validateFlagName 支持校验输入文件的批量命令的隐藏选项，由 validate 命令设置
+const validateFlagName = "validate"
+
+var validateCmdBuilder = func(cfg *iqshell.Config) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "validate <BatchCommand> [<Args>...] -i <InputFile>",
+		Short: "Validate the input file of a batch command without executing it",
+		// 参数及选项由批量命令解析
+		DisableFlagParsing: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg.CmdCfg.CmdId = docs.ValidateType
+			if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "--doc" {
+				docs.ShowCmdDocument(docs.ValidateType)
+				return
+			}
+			if err := runValidate(cfg, cmd.Root(), args); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "validate error: %v\n", err)
+				data.SetCmdStatusError()
+			}
+		},
+	}
+	return cmd
+}
+
+// runValidate 查找批量命令，由批量命令解析参数及选项并开启校验模式后执行；校验不发送请求，无需加载账户
+func runValidate(cfg *iqshell.Config, root *cobra.Command, args []string) error {
+	target, targetArgs, err := root.Find(args)
+	if err != nil {
+		return err
+	}
+	if target == root || target.Run == nil || target.Flags().Lookup(validateFlagName) == nil {
+		return fmt.Errorf("command %s doesn't support validation, should be one of: %s",
+			args[0], strings.Join(validatableCommands(root), ", "))
+	}
+
+	target.InitDefaultHelpFlag()
+	if err = target.ParseFlags(targetArgs); err != nil {
+		return err
+	}
+	if help, _ := target.Flags().GetBool("help"); help {
+		return target.Help()
+	}
+	if err = target.Flags().Set(validateFlagName, "true"); err != nil {
+		return err
+	}
+	cfg.Validate = true
+
+	targetArgs = target.Flags().Args()
+	if err = target.ValidateArgs(targetArgs); err != nil {
+		return err
+	}
+	target.Run(target, targetArgs)
+	return nil
+}
+
+func validatableCommands(root *cobra.Command) []string {
+	var names []string
+	for _, c := range root.Commands() {
+		if c.Flags().Lookup(validateFlagName) != nil {
+			names = append(names, c.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	registerLoader(validateCmdLoader)
+}
+
+func validateCmdLoader(superCmd *cobra.Command, cfg *iqshell.Config) {
+	superCmd.AddCommand(validateCmdBuilder(cfg))
+}
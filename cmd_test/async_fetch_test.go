@@ -61,6 +61,13 @@ func TestAsyncFetchNoBucket(t *testing.T) {
 	}
 }
 
+func TestAsyncFetchWaitWithDisableCheck(t *testing.T) {
+	_, err := test.RunCmdWithError("abfetch", test.Bucket, "--wait", "--disable-check-fetch-result")
+	if !strings.Contains(err, "--wait is not supported") {
+		t.Fail()
+	}
+}
+
 func TestAsyncFetchDocument(t *testing.T) {
 	test.TestDocument("abfetch", t)
 }
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed avinfo.md
+var avInfoDocument string
+
+const AvInfoType = "avinfo"
+
+func init() {
+	addCmdDocumentInfo(AvInfoType, avInfoDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed batchavinfo.md
+var batchAvInfoDocument string
+
+const BatchAvInfoType = "batchavinfo"
+
+func init() {
+	addCmdDocumentInfo(BatchAvInfoType, batchAvInfoDocument)
+}
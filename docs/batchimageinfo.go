@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed batchimageinfo.md
+var batchImageInfoDocument string
+
+const BatchImageInfoType = "batchimageinfo"
+
+func init() {
+	addCmdDocumentInfo(BatchImageInfoType, batchImageInfoDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed batchobjexpire.md
+var batchObjectExpireDocument string
+
+const BatchObjectExpireType = "batchobjexpire"
+
+func init() {
+	addCmdDocumentInfo(BatchObjectExpireType, batchObjectExpireDocument)
+}
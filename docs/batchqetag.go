@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed batchqetag.md
+var batchQEtagDocument string
+
+const BatchQEtagType = "batchqetag"
+
+func init() {
+	addCmdDocumentInfo(BatchQEtagType, batchQEtagDocument)
+}
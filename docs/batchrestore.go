@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed batchrestore.md
+var batchRestoreDocument string
+
+const BatchRestoreType = "batchrestore"
+
+func init() {
+	addCmdDocumentInfo(BatchRestoreType, batchRestoreDocument)
+}
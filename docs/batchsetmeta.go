@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed batchsetmeta.md
+var batchSetMetaDocument string
+
+const BatchSetMetaType = "batchsetmeta"
+
+func init() {
+	addCmdDocumentInfo(BatchSetMetaType, batchSetMetaDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed batchwatermark.md
+var batchWatermarkDocument string
+
+const BatchWatermarkType = "batchwatermark"
+
+func init() {
+	addCmdDocumentInfo(BatchWatermarkType, batchWatermarkDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed bucketdiff.md
+var bucketDiffDocument string
+
+const BucketDiffType = "bucketdiff"
+
+func init() {
+	addCmdDocumentInfo(BucketDiffType, bucketDiffDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed bucketusage.md
+var bucketUsageDocument string
+
+const BucketUsageType = "bucketusage"
+
+func init() {
+	addCmdDocumentInfo(BucketUsageType, bucketUsageDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed cdnlog.md
+var cdnLogDocument string
+
+const CdnLogType = "cdnlog"
+
+func init() {
+	addCmdDocumentInfo(CdnLogType, cdnLogDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed checksign.md
+var checkSignDocument string
+
+const CheckSignType = "checksign"
+
+func init() {
+	addCmdDocumentInfo(CheckSignType, checkSignDocument)
+}
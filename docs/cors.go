@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed cors.md
+var corsDocument string
+
+const CorsType = "cors"
+
+func init() {
+	addCmdDocumentInfo(CorsType, corsDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed dirsync.md
+var dirSyncDocument string
+
+const DirSyncType = "dirsync"
+
+func init() {
+	addCmdDocumentInfo(DirSyncType, dirSyncDocument)
+}
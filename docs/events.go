@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed events.md
+var eventsDocument string
+
+const EventsType = "events"
+
+func init() {
+	addCmdDocumentInfo(EventsType, eventsDocument)
+}
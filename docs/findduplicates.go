@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed findduplicates.md
+var findDuplicatesDocument string
+
+const FindDuplicatesType = "findduplicates"
+
+func init() {
+	addCmdDocumentInfo(FindDuplicatesType, findDuplicatesDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed imageinfo.md
+var imageInfoDocument string
+
+const ImageInfoType = "imageinfo"
+
+func init() {
+	addCmdDocumentInfo(ImageInfoType, imageInfoDocument)
+}
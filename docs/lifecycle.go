@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed lifecycle.md
+var lifecycleDocument string
+
+const LifecycleType = "lifecycle"
+
+func init() {
+	addCmdDocumentInfo(LifecycleType, lifecycleDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed moveprefix.md
+var movePrefixDocument string
+
+const MovePrefixType = "moveprefix"
+
+func init() {
+	addCmdDocumentInfo(MovePrefixType, movePrefixDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed objexpire.md
+var objectExpireDocument string
+
+const ObjectExpireType = "objexpire"
+
+func init() {
+	addCmdDocumentInfo(ObjectExpireType, objectExpireDocument)
+}
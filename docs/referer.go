@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed referer.md
+var refererDocument string
+
+const RefererType = "referer"
+
+func init() {
+	addCmdDocumentInfo(RefererType, refererDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed setmeta.md
+var setMetaDocument string
+
+const SetMetaType = "setmeta"
+
+func init() {
+	addCmdDocumentInfo(SetMetaType, setMetaDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed thumbnail.md
+var thumbnailDocument string
+
+const ThumbnailType = "thumbnail"
+
+func init() {
+	addCmdDocumentInfo(ThumbnailType, thumbnailDocument)
+}
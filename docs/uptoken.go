@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed uptoken.md
+var upTokenDocument string
+
+const UpTokenType = "uptoken"
+
+func init() {
+	addCmdDocumentInfo(UpTokenType, upTokenDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed validate.md
+var validateDocument string
+
+const ValidateType = "validate"
+
+func init() {
+	addCmdDocumentInfo(ValidateType, validateDocument)
+}
@@ -0,0 +1,12 @@
+package docs
+
+import _ "embed"
+
+//go:embed xcopy.md
+var xCopyDocument string
+
+const XCopyType = "xcopy"
+
+func init() {
+	addCmdDocumentInfo(XCopyType, xCopyDocument)
+}
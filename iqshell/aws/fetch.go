@@ -171,7 +171,7 @@ func Fetch(cfg *iqshell.Config, info FetchInfo) {
 			}
 
 		}).
-		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result) {
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
 			metric.AddCurrentCount(1)
 			metric.AddSuccessCount(1)
 			metric.PrintProgress("Batching:" + workInfo.Data)
@@ -180,7 +180,7 @@ func Fetch(cfg *iqshell.Config, info FetchInfo) {
 			exporter.Success().ExportF("%s\t%s", in.FromUrl, in.Bucket)
 			log.InfoF("AWS Fetch Success, '%s' => [%s:%s]", in.FromUrl, in.Bucket, in.Key)
 		}).
-		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
 			metric.AddCurrentCount(1)
 			metric.AddFailureCount(1)
 			metric.PrintProgress("AWS Batching:" + workInfo.Data)
@@ -206,17 +206,4 @@ func Fetch(cfg *iqshell.Config, info FetchInfo) {
 	} else {
 		log.InfoF("save aws batch fetch result to path:%s", resultPath)
 	}
-
-	// 输出结果
-	log.Info("------------- AWS Batch Result --------------")
-	log.InfoF("%20s%10d", "Total:", metric.TotalCount)
-	log.InfoF("%20s%10d", "Success:", metric.SuccessCount)
-	log.InfoF("%20s%10d", "Failure:", metric.FailureCount)
-	log.InfoF("%20s%10d", "Skipped:", metric.SkippedCount)
-	log.InfoF("%20s%10ds", "Duration:", metric.Duration)
-	log.InfoF("--------------------------------------------")
-
-	if metric.FailureCount > 0 {
-		data.SetCmdStatusError()
-	}
 }
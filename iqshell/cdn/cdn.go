@@ -1,9 +1,13 @@
 package cdn
 
 import (
+	"fmt"
+
+	"github.com/qiniu/go-sdk/v7/auth"
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
 	"github.com/qiniu/go-sdk/v7/cdn"
 
+	"github.com/qiniu/qshell/v2/iqshell/common/client"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
@@ -28,35 +32,179 @@ func getCdnManager() (cdnManager *cdn.CdnManager, err *data.CodeError) {
 	return
 }
 
-func Prefetch(urls []string) *data.CodeError {
+// PrefetchQuotaExceededCode 预取的 url 数超过了每日的额度
+const PrefetchQuotaExceededCode = 400005
+
+// PrefetchResult 提交预取的结果
+type PrefetchResult struct {
+	RequestId  string // 预取请求的 id，可用于查询预取进度
+	QuotaDay   int    // 每日的预取额度
+	SurplusDay int    // 当日剩余的预取额度
+}
+
+// IsQuotaExhausted 提交后当日的预取额度是否已用完
+func (r *PrefetchResult) IsQuotaExhausted() bool {
+	return r != nil && r.QuotaDay > 0 && r.SurplusDay <= 0
+}
+
+// Prefetch 提交预取，超出每日额度时错误码为 PrefetchQuotaExceededCode
+func Prefetch(urls []string) (*PrefetchResult, *data.CodeError) {
 	cdnManager, err := getCdnManager()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if resp, e := cdnManager.PrefetchUrls(urls); e != nil {
-		return data.NewEmptyError().AppendDescF("CDN prefetch error:%v", e)
+	resp, e := cdnManager.PrefetchUrls(urls)
+	if e != nil {
+		return nil, data.NewEmptyError().AppendDescF("CDN prefetch error:%v", e)
 	} else if resp.Code != 200 {
-		return data.NewEmptyError().AppendDescF("CDN prefetch Code: %d, Error: %s", resp.Code, resp.Error)
-	} else {
-		log.InfoF("CDN prefetch Code: %d, FlowInfo: %s", resp.Code, resp.Error)
+		return nil, data.NewError(resp.Code, fmt.Sprintf("CDN prefetch Code: %d, Error: %s", resp.Code, resp.Error))
+	}
+	log.InfoF("CDN prefetch Code: %d, RequestId: %s, SurplusDay: %d", resp.Code, resp.RequestID, resp.SurplusDay)
+	return &PrefetchResult{
+		RequestId:  resp.RequestID,
+		QuotaDay:   resp.QuotaDay,
+		SurplusDay: resp.SurplusDay,
+	}, nil
+}
+
+const (
+	PrefetchStateSuccess    = "success"
+	PrefetchStateProcessing = "processing"
+	PrefetchStateFailure    = "failure"
+)
+
+// PrefetchItem 一个 url 的预取状态
+type PrefetchItem struct {
+	Url      string `json:"url"`
+	State    string `json:"state"`
+	StateMsg string `json:"stateMsg"`
+}
+
+// IsDone 预取是否已结束，成功或者失败
+func (i *PrefetchItem) IsDone() bool {
+	return i.State == PrefetchStateSuccess || i.State == PrefetchStateFailure
+}
+
+type prefetchListReq struct {
+	RequestId string `json:"requestId"`
+	PageNo    int    `json:"pageNo"`
+	PageSize  int    `json:"pageSize"`
+}
+
+type prefetchListResp struct {
+	Code  int            `json:"code"`
+	Error string         `json:"error"`
+	Items []PrefetchItem `json:"items"`
+	Total int            `json:"total"`
+}
+
+// PrefetchStatus 查询一次预取请求中所有 url 的预取状态
+func PrefetchStatus(requestId string) ([]PrefetchItem, *data.CodeError) {
+	mac, err := workspace.GetMac()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]PrefetchItem, 0)
+	for pageNo := 0; ; pageNo++ {
+		resp := &prefetchListResp{}
+		e := client.DefaultStorageClient().CredentialedCallWithJson(workspace.GetContext(), mac, auth.TokenQBox, resp,
+			"POST", cdn.FusionHost+"/v2/tune/prefetch/list", nil, &prefetchListReq{
+				RequestId: requestId,
+				PageNo:    pageNo,
+				PageSize:  100,
+			})
+		if e != nil {
+			return nil, data.NewEmptyError().AppendDescF("CDN prefetch status of request:%s error:%v", requestId, e)
+		} else if resp.Code != 200 {
+			return nil, data.NewError(resp.Code, fmt.Sprintf("CDN prefetch status of request:%s Code: %d, Error: %s", requestId, resp.Code, resp.Error))
+		}
+
+		items = append(items, resp.Items...)
+		if len(resp.Items) == 0 || len(items) >= resp.Total {
+			return items, nil
+		}
 	}
-	return nil
 }
 
-func Refresh(urls []string, dirs []string) *data.CodeError {
+const (
+	RefreshUrlQuotaExceededCode = 400003 // 刷新的 url 数超过了每日的额度
+	RefreshDirQuotaExceededCode = 400004 // 刷新的目录数超过了每日的额度
+)
+
+// RefreshResult 提交刷新的结果，额度为 0 表示接口未返回
+type RefreshResult struct {
+	RequestId     string
+	UrlQuotaDay   int // 每日的 url 刷新额度
+	UrlSurplusDay int // 当日剩余的 url 刷新额度
+	DirQuotaDay   int // 每日的目录刷新额度
+	DirSurplusDay int // 当日剩余的目录刷新额度
+}
+
+// Refresh 提交刷新，超出每日额度时错误码为 RefreshUrlQuotaExceededCode 或 RefreshDirQuotaExceededCode
+func Refresh(urls []string, dirs []string) (*RefreshResult, *data.CodeError) {
 	cdnManager, err := getCdnManager()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	log.DebugF("cdnRefresh, url size: %d, dir size: %d", len(urls), len(dirs))
-	if resp, e := cdnManager.RefreshUrlsAndDirs(urls, dirs); e != nil {
-		return data.NewEmptyError().AppendDescF("CDN refresh error:%v", err)
+	resp, e := cdnManager.RefreshUrlsAndDirs(urls, dirs)
+	if e != nil {
+		return nil, data.NewEmptyError().AppendDescF("CDN refresh error:%v", e)
 	} else if resp.Code != 200 {
-		return data.NewEmptyError().AppendDescF("CDN refresh Code: %d, Error: %s", resp.Code, resp.Error)
+		return nil, data.NewError(resp.Code, fmt.Sprintf("CDN refresh Code: %d, Error: %s", resp.Code, resp.Error))
+	}
+	log.InfoF("CDN refresh Code: %d, RequestId: %s", resp.Code, resp.RequestID)
+	return &RefreshResult{
+		RequestId:     resp.RequestID,
+		UrlQuotaDay:   resp.URLQuotaDay,
+		UrlSurplusDay: resp.URLSurplusDay,
+		DirQuotaDay:   resp.DirQuotaDay,
+		DirSurplusDay: resp.DirSurplusDay,
+	}, nil
+}
+
+// LogFile CDN 日志文件的信息
+type LogFile = cdn.LogDomainInfo
+
+// LogList 获取域名某一天的 CDN 日志文件列表，key 为域名；没有日志的域名不在结果中
+func LogList(day string, domains []string) (map[string][]LogFile, *data.CodeError) {
+	cdnManager, err := getCdnManager()
+	if err != nil {
+		return nil, err
+	}
+
+	result, e := cdnManager.GetCdnLogList(day, domains)
+	if e != nil {
+		return nil, data.NewEmptyError().AppendDescF("CDN get log list error:%v", e)
+	}
+	log.DebugF("CDN get log list Code: %d, domain count: %d", result.Code, len(result.Data))
+	return result.Data, nil
+}
+
+// TrafficData 域名国内及海外的带宽/流量数据，与 Traffic 返回的时间点一一对应
+type TrafficData = cdn.TrafficData
+
+// Traffic 获取域名的流量数据，bandwidth 为 true 时获取带宽数据；返回的时间为东八区时间，格式如：2006-01-02 15:04:05
+func Traffic(startDate, endDate, granularity string, domains []string, bandwidth bool) ([]string, map[string]TrafficData, *data.CodeError) {
+	cdnManager, err := getCdnManager()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var resp cdn.TrafficResp
+	var e error
+	if bandwidth {
+		resp, e = cdnManager.GetBandwidthData(startDate, endDate, granularity, domains)
 	} else {
-		log.InfoF("CDN refresh Code: %d, FlowInfo: %s", resp.Code, resp.Error)
+		resp, e = cdnManager.GetFluxData(startDate, endDate, granularity, domains)
+	}
+	if e != nil {
+		return nil, nil, data.NewEmptyError().AppendDescF("CDN get traffic error:%v", e)
+	} else if resp.Code != 200 {
+		return nil, nil, data.NewError(resp.Code, fmt.Sprintf("CDN get traffic Code: %d, Error: %s", resp.Code, resp.Error))
 	}
-	return nil
+	return resp.Time, resp.Data, nil
 }
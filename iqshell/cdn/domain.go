@@ -0,0 +1,214 @@
+package cdn
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/auth"
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/client"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// defaultDomainApiHost 域名管理接口默认的服务地址，域名管理接口不区分区域
+const defaultDomainApiHost = "https://api.qiniu.com"
+
+const (
+	DomainProtocolHttp  = "http"
+	DomainProtocolHttps = "https"
+
+	DomainSourceTypeBucket = "qiniuBucket"
+
+	DomainStateOfflined = "offlined"
+)
+
+// Domain 域名的信息
+type Domain struct {
+	Name               string `json:"name"`
+	Type               string `json:"type"`
+	CName              string `json:"cname"`
+	Platform           string `json:"platform"`
+	GeoCover           string `json:"geoCover"`
+	Protocol           string `json:"protocol"`           // http / https
+	OperatingState     string `json:"operatingState"`     // 绑定状态，如：processing / success / failed / frozen / offlined
+	OperatingStateDesc string `json:"operatingStateDesc"` // 绑定状态的说明
+}
+
+// IsHttps 是否已配置 https
+func (d *Domain) IsHttps() bool {
+	return d.Protocol == DomainProtocolHttps
+}
+
+// DomainDetail 域名的详细信息，包括回源的空间
+type DomainDetail struct {
+	Domain
+	Source struct {
+		SourceType        string `json:"sourceType"`
+		SourceQiniuBucket string `json:"sourceQiniuBucket"`
+	} `json:"source"`
+}
+
+// BindDomainInfo 绑定域名的参数
+type BindDomainInfo struct {
+	Bucket   string
+	Domain   string
+	Platform string // 使用场景：web / download / vod
+	GeoCover string // 覆盖范围：china / foreign / global
+	CertId   string // 证书 id，不为空时配置 https
+}
+
+type domainListResp struct {
+	Marker  string   `json:"marker"`
+	Domains []Domain `json:"domains"`
+}
+
+type domainSource struct {
+	SourceType        string `json:"sourceType"`
+	SourceQiniuBucket string `json:"sourceQiniuBucket"`
+}
+
+type domainHttps struct {
+	CertId     string `json:"certId"`
+	ForceHttps bool   `json:"forceHttps"`
+}
+
+type bindDomainReq struct {
+	Type     string       `json:"type"`
+	Platform string       `json:"platform"`
+	GeoCover string       `json:"geoCover"`
+	Protocol string       `json:"protocol"`
+	Source   domainSource `json:"source"`
+	Https    *domainHttps `json:"https,omitempty"`
+}
+
+// domainApiHost 域名管理接口的服务地址，配置了 api 服务地址（如：--api-host、配置文件中的 hosts）时使用配置的地址
+func domainApiHost() string {
+	cfg := workspace.GetStorageConfig()
+	if len(cfg.ApiHost) > 0 {
+		return utils.Endpoint(cfg.UseHTTPS, cfg.ApiHost)
+	}
+	return defaultDomainApiHost
+}
+
+// callDomainApi 调用域名管理接口，接口返回的错误信息（如：域名未验证归属权）原样返回给用户
+func callDomainApi(ret interface{}, method string, path string, body interface{}) *data.CodeError {
+	mac, err := workspace.GetMac()
+	if err != nil {
+		return err
+	}
+
+	reqUrl := domainApiHost() + path
+	storageClient := client.DefaultStorageClient()
+	var e error
+	if body == nil {
+		e = storageClient.CredentialedCall(workspace.GetContext(), mac, auth.TokenQiniu, ret, method, reqUrl, nil)
+	} else {
+		e = storageClient.CredentialedCallWithJson(workspace.GetContext(), mac, auth.TokenQiniu, ret, method, reqUrl, nil, body)
+	}
+	if e == nil {
+		return nil
+	}
+	if info, ok := e.(*storage.ErrorInfo); ok {
+		return data.NewError(info.Code, info.Err)
+	}
+	return data.ConvertError(e)
+}
+
+// DomainsOfBucket 列举回源到空间的所有 CDN 域名
+func DomainsOfBucket(bucket string) ([]Domain, *data.CodeError) {
+	domains := make([]Domain, 0)
+	marker := ""
+	for {
+		query := url.Values{}
+		query.Set("sourceTypes", DomainSourceTypeBucket)
+		query.Set("sourceQiniuBucket", bucket)
+		query.Set("limit", "1000")
+		if len(marker) > 0 {
+			query.Set("marker", marker)
+		}
+
+		resp := &domainListResp{}
+		if err := callDomainApi(resp, "GET", "/domain?"+query.Encode(), nil); err != nil {
+			return nil, data.NewEmptyError().AppendDescF("list domains of bucket:%s error:%v", bucket, err)
+		}
+		domains = append(domains, resp.Domains...)
+		if len(resp.Marker) == 0 || len(resp.Domains) == 0 {
+			return domains, nil
+		}
+		marker = resp.Marker
+	}
+}
+
+// GetDomain 获取域名的详细信息
+func GetDomain(domain string) (*DomainDetail, *data.CodeError) {
+	detail := &DomainDetail{}
+	if err := callDomainApi(detail, "GET", "/domain/"+url.PathEscape(domain), nil); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// BindDomain 创建回源到空间的 CDN 域名
+func BindDomain(info BindDomainInfo) *data.CodeError {
+	req := &bindDomainReq{
+		Type:     "normal",
+		Platform: info.Platform,
+		GeoCover: info.GeoCover,
+		Protocol: DomainProtocolHttp,
+		Source: domainSource{
+			SourceType:        DomainSourceTypeBucket,
+			SourceQiniuBucket: info.Bucket,
+		},
+	}
+	if len(info.CertId) > 0 {
+		req.Protocol = DomainProtocolHttps
+		req.Https = &domainHttps{CertId: info.CertId}
+	}
+	return callDomainApi(nil, "POST", "/domain/"+url.PathEscape(info.Domain), req)
+}
+
+// UnbindDomainInfo 下线并删除域名的参数
+type UnbindDomainInfo struct {
+	WaitInterval time.Duration // 下线后轮询域名状态的间隔
+	WaitTimeout  time.Duration // 下线后轮询域名状态的最长时间，超时后不删除域名
+}
+
+// UnbindDomain 下线并删除域名，已下线的域名直接删除；下线是异步的，下线后轮询域名状态，状态为已下线后再删除
+func UnbindDomain(detail *DomainDetail, info UnbindDomainInfo) *data.CodeError {
+	domain := detail.Name
+	if detail.OperatingState != DomainStateOfflined {
+		if err := callDomainApi(nil, "POST", fmt.Sprintf("/domain/%s/offline", url.PathEscape(domain)), nil); err != nil {
+			return data.NewEmptyError().AppendDescF("offline domain:%s error:%v", domain, err)
+		}
+		if err := waitDomainOfflined(domain, info.WaitInterval, info.WaitTimeout); err != nil {
+			return err
+		}
+	}
+	return callDomainApi(nil, "DELETE", "/domain/"+url.PathEscape(domain), nil)
+}
+
+// waitDomainOfflined 轮询域名的状态直到已下线，超过 timeout 后返回错误，域名仍在下线中
+func waitDomainOfflined(domain string, interval time.Duration, timeout time.Duration) *data.CodeError {
+	deadline := time.Now().Add(timeout)
+	for {
+		if time.Now().Add(interval).After(deadline) {
+			return data.NewEmptyError().AppendDescF("domain:%s is still going offline after %s, it is not deleted, please unbind it again later", domain, timeout)
+		}
+		time.Sleep(interval)
+
+		detail, err := GetDomain(domain)
+		if err != nil {
+			log.WarningF("get state of domain:%s error:%v, retry later", domain, err)
+			continue
+		}
+		if detail.OperatingState == DomainStateOfflined {
+			return nil
+		}
+		log.InfoF("domain:%s is going offline, state:%s", domain, detail.OperatingState)
+	}
+}
@@ -0,0 +1,188 @@
+package cdn
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// testDomainServer 域名管理接口，GET 域名时依次返回 states 中的状态，最后一个状态保持不变
+type testDomainServer struct {
+	*httptest.Server
+	lock     sync.Mutex
+	states   []string
+	requests []string
+	bodies   []string
+}
+
+func newTestDomainServer(states ...string) *testDomainServer {
+	s := &testDomainServer{states: states}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Reqid", "reqid")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/domain":
+			// 每页一个域名，marker 为下一个域名的序号
+			domains := []string{"a.example.com", "b.example.com"}
+			index := 0
+			if r.URL.Query().Get("marker") == "1" {
+				index = 1
+			}
+			marker := ""
+			if index == 0 {
+				marker = "1"
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"marker":  marker,
+				"domains": []Domain{{Name: domains[index], OperatingState: "success"}},
+			})
+		case r.Method == http.MethodGet:
+			state := s.states[0]
+			if len(s.states) > 1 {
+				s.states = s.states[1:]
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"name":           strings.TrimPrefix(r.URL.Path, "/domain/"),
+				"operatingState": state,
+			})
+		default:
+			body, _ := io.ReadAll(r.Body)
+			s.bodies = append(s.bodies, string(body))
+			_, _ = w.Write([]byte("{}"))
+		}
+	}))
+	return s
+}
+
+func (s *testDomainServer) getRequests() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+// loadTestWorkspace 使用环境变量中的账户及临时的工作区，api 服务地址为 apiHost，为空时不配置
+func loadTestWorkspace(t *testing.T, apiHost string) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(account.AccessKeyEnvKey, "ak")
+	t.Setenv(account.SecretKeyEnvKey, "sk")
+	cfg := &config.Config{CmdId: "domain"}
+	if len(apiHost) > 0 {
+		cfg.Hosts = &config.Hosts{Api: []string{apiHost}}
+	}
+	if err := workspace.Load(workspace.LoadInfo{CmdConfig: cfg}); err != nil {
+		t.Fatal("load workspace error:", err)
+	}
+}
+
+func TestDomainApiHost(t *testing.T) {
+	loadTestWorkspace(t, "")
+	if host := domainApiHost(); host != defaultDomainApiHost {
+		t.Fatal("api host should be the default host, but:", host)
+	}
+
+	// 同其他接口，配置的地址按 use_https 选择协议
+	loadTestWorkspace(t, "api.example.com")
+	if host := domainApiHost(); host != "http://api.example.com" {
+		t.Fatal("api host should be the configured host, but:", host)
+	}
+}
+
+func TestDomainsOfBucket(t *testing.T) {
+	server := newTestDomainServer("success")
+	defer server.Close()
+	loadTestWorkspace(t, server.URL)
+
+	domains, err := DomainsOfBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(domains) != 2 || domains[0].Name != "a.example.com" || domains[1].Name != "b.example.com" {
+		t.Fatal("domains should be listed by marker, but:", domains)
+	}
+}
+
+func TestBindDomain(t *testing.T) {
+	server := newTestDomainServer("success")
+	defer server.Close()
+	loadTestWorkspace(t, server.URL)
+
+	if err := BindDomain(BindDomainInfo{
+		Bucket:   "bucket",
+		Domain:   "a.example.com",
+		Platform: "web",
+		GeoCover: "china",
+		CertId:   "cert",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if requests := server.getRequests(); len(requests) != 1 || requests[0] != "POST /domain/a.example.com" {
+		t.Fatal("bind request error:", requests)
+	}
+	req := &bindDomainReq{}
+	if err := json.Unmarshal([]byte(server.bodies[0]), req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Protocol != DomainProtocolHttps || req.Https == nil || req.Https.CertId != "cert" ||
+		req.Source.SourceQiniuBucket != "bucket" {
+		t.Fatal("bind request body error:", server.bodies[0])
+	}
+}
+
+func TestUnbindDomain(t *testing.T) {
+	info := UnbindDomainInfo{
+		WaitInterval: 10 * time.Millisecond,
+		WaitTimeout:  time.Second,
+	}
+
+	// 下线后等待状态变为已下线再删除
+	server := newTestDomainServer("processing", "processing", DomainStateOfflined)
+	defer server.Close()
+	loadTestWorkspace(t, server.URL)
+	if err := UnbindDomain(&DomainDetail{Domain: Domain{Name: "a.example.com", OperatingState: "success"}}, info); err != nil {
+		t.Fatal(err)
+	}
+	requests := server.getRequests()
+	if len(requests) != 5 || requests[0] != "POST /domain/a.example.com/offline" ||
+		requests[3] != "GET /domain/a.example.com" || requests[4] != "DELETE /domain/a.example.com" {
+		t.Fatal("unbind requests error:", requests)
+	}
+
+	// 已下线的域名直接删除
+	offlinedServer := newTestDomainServer(DomainStateOfflined)
+	defer offlinedServer.Close()
+	loadTestWorkspace(t, offlinedServer.URL)
+	if err := UnbindDomain(&DomainDetail{Domain: Domain{Name: "a.example.com", OperatingState: DomainStateOfflined}}, info); err != nil {
+		t.Fatal(err)
+	}
+	if requests = offlinedServer.getRequests(); len(requests) != 1 || requests[0] != "DELETE /domain/a.example.com" {
+		t.Fatal("unbind offlined domain requests error:", requests)
+	}
+
+	// 超时仍在下线中，不删除
+	processingServer := newTestDomainServer("processing")
+	defer processingServer.Close()
+	loadTestWorkspace(t, processingServer.URL)
+	info.WaitTimeout = 100 * time.Millisecond
+	err := UnbindDomain(&DomainDetail{Domain: Domain{Name: "a.example.com", OperatingState: "success"}}, info)
+	if err == nil || !strings.Contains(err.Error(), "still going offline") {
+		t.Fatal("unbind should fail because the domain is still going offline, but:", err)
+	}
+	for _, request := range processingServer.getRequests() {
+		if strings.HasPrefix(request, http.MethodDelete) {
+			t.Fatal("domain going offline should not be deleted, but:", processingServer.getRequests())
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package operations
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/cdn"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+type DomainListInfo struct {
+	Bucket string // 空间名 【必选】
+}
+
+func (info *DomainListInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	return nil
+}
+
+// DomainList 列举回源到空间的 CDN 域名，输出绑定状态及是否配置了 https
+func DomainList(cfg *iqshell.Config, info DomainListInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	domains, err := cdn.DomainsOfBucket(info.Bucket)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+	if len(domains) == 0 {
+		log.WarningF("No CDN domains found for bucket `%s`", info.Bucket)
+		return
+	}
+
+	for _, domain := range domains {
+		if data.IsOutputFormatJson() {
+			if bytes, mErr := json.Marshal(domain); mErr != nil {
+				log.ErrorF("marshal domain:%s error:%v", domain.Name, mErr)
+			} else {
+				log.Alert(string(bytes))
+			}
+			continue
+		}
+
+		https := "no"
+		if domain.IsHttps() {
+			https = "yes"
+		}
+		log.AlertF("%s\t%s\thttps:%s\t%s", domain.Name, domain.OperatingState, https, domain.CName)
+	}
+}
+
+type DomainBindInfo struct {
+	Bucket   string // 空间名 【必选】
+	Domain   string // 域名 【必选】
+	Platform string // 使用场景：web / download / vod 【可选】
+	GeoCover string // 覆盖范围：china / foreign / global 【可选】
+	CertId   string // 证书 id，指定时配置 https 【可选】
+}
+
+func (info *DomainBindInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	if len(info.Domain) == 0 {
+		return alert.CannotEmptyError("Domain", "")
+	}
+	if len(info.Platform) == 0 {
+		info.Platform = "web"
+	}
+	if info.Platform != "web" && info.Platform != "download" && info.Platform != "vod" {
+		return alert.Error("invalid platform: "+info.Platform, "platform should be one of web, download and vod")
+	}
+	if len(info.GeoCover) == 0 {
+		info.GeoCover = "china"
+	}
+	if info.GeoCover != "china" && info.GeoCover != "foreign" && info.GeoCover != "global" {
+		return alert.Error("invalid geo cover: "+info.GeoCover, "geo cover should be one of china, foreign and global")
+	}
+	return nil
+}
+
+// DomainBind 绑定 CDN 域名到空间，域名未验证归属权等失败时输出接口返回的错误信息
+func DomainBind(cfg *iqshell.Config, info DomainBindInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if err := cdn.BindDomain(cdn.BindDomainInfo{
+		Bucket:   info.Bucket,
+		Domain:   info.Domain,
+		Platform: info.Platform,
+		GeoCover: info.GeoCover,
+		CertId:   info.CertId,
+	}); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Bind domain:%s to bucket:%s Failed, Code: %d, Error: %s", info.Domain, info.Bucket, err.Code, err.Desc)
+		return
+	}
+	log.InfoF("Bind domain:%s to bucket:%s success, it may take a few minutes to take effect, use `qshell domain list %s` to check the status", info.Domain, info.Bucket, info.Bucket)
+}
+
+type DomainUnbindInfo struct {
+	Bucket       string // 空间名，用于确认域名回源到此空间 【必选】
+	Domain       string // 域名 【必选】
+	Force        bool   // 不需要用户确认 【可选】
+	WaitInterval int    // 下线后轮询域名状态的间隔，单位：秒 【可选】
+	WaitTimeout  int    // 下线后轮询域名状态的最长时间，超时后不删除域名，单位：秒 【可选】
+}
+
+func (info *DomainUnbindInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	if len(info.Domain) == 0 {
+		return alert.CannotEmptyError("Domain", "")
+	}
+	if info.WaitInterval <= 0 {
+		info.WaitInterval = 10
+	}
+	if info.WaitTimeout <= 0 {
+		info.WaitTimeout = 600
+	}
+	return nil
+}
+
+// DomainUnbind 下线并删除回源到空间的 CDN 域名
+func DomainUnbind(cfg *iqshell.Config, info DomainUnbindInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	detail, err := cdn.GetDomain(info.Domain)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Get domain:%s Failed, Code: %d, Error: %s", info.Domain, err.Code, err.Desc)
+		return
+	}
+	if detail.Source.SourceQiniuBucket != info.Bucket {
+		data.SetCmdStatusError()
+		log.ErrorF("Domain:%s is not bound to bucket:%s", info.Domain, info.Bucket)
+		return
+	}
+
+	if !info.Force {
+		log.WarningF("Domain:%s will be offline and deleted, it can't be recovered", info.Domain)
+		if !flow.UserCodeVerification() {
+			return
+		}
+	}
+
+	if err = cdn.UnbindDomain(detail, cdn.UnbindDomainInfo{
+		WaitInterval: time.Duration(info.WaitInterval) * time.Second,
+		WaitTimeout:  time.Duration(info.WaitTimeout) * time.Second,
+	}); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Unbind domain:%s from bucket:%s Failed, Code: %d, Error: %s", info.Domain, info.Bucket, err.Code, err.Desc)
+		return
+	}
+	log.InfoF("Unbind domain:%s from bucket:%s success", info.Domain, info.Bucket)
+}
@@ -0,0 +1,163 @@
+package operations
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/cdn"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+// FluxAllDomains 指定多个域名时，所有域名合计的数据点使用的域名
+const FluxAllDomains = "ALL"
+
+// 流量/带宽接口的时间为东八区时间
+var fluxTimeLocation = time.FixedZone("UTC+8", 8*60*60)
+
+type FluxInfo struct {
+	Domains     []string // 域名 【必选】
+	From        string   // 开始日期，格式：2006-01-02 【必选】
+	To          string   // 结束日期，格式：2006-01-02，默认和开始日期相同 【可选】
+	Granularity string   // 粒度，取值：5min / hour / day，默认为 day 【可选】
+	Bandwidth   bool     // 查询带宽而不是流量 【可选】
+}
+
+func (info *FluxInfo) Check() *data.CodeError {
+	if len(info.Domains) == 0 {
+		return alert.CannotEmptyError("Domain", "")
+	}
+	if len(info.From) == 0 {
+		return alert.CannotEmptyError("from (--from)", "")
+	}
+	if len(info.To) == 0 {
+		info.To = info.From
+	}
+	from, err := time.Parse("2006-01-02", info.From)
+	if err != nil {
+		return alert.Error("invalid from: "+info.From, "from should be like 2024-06-01")
+	}
+	to, err := time.Parse("2006-01-02", info.To)
+	if err != nil {
+		return alert.Error("invalid to: "+info.To, "to should be like 2024-06-01")
+	}
+	if to.Before(from) {
+		return alert.Error("to can't be earlier than from", "")
+	}
+	if len(info.Granularity) == 0 {
+		info.Granularity = "day"
+	}
+	if info.Granularity != "5min" && info.Granularity != "hour" && info.Granularity != "day" {
+		return alert.Error("invalid granularity: "+info.Granularity, "granularity should be one of 5min, hour and day")
+	}
+	return nil
+}
+
+// FluxPoint 一个域名在一个时间点的流量(字节)或带宽(bps)
+type FluxPoint struct {
+	Time    string `json:"time"` // ISO 8601 格式
+	Domain  string `json:"domain"`
+	China   int64  `json:"china"`
+	Oversea int64  `json:"oversea"`
+	Total   int64  `json:"total"`
+}
+
+// fluxTime 将接口返回的东八区时间转为 ISO 8601 格式，无法解析时原样返回
+func fluxTime(apiTime string) string {
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", apiTime, fluxTimeLocation)
+	if err != nil {
+		return apiTime
+	}
+	return t.Format(time.RFC3339)
+}
+
+// buildFluxSeries 按时间点展开各个域名的数据，多个域名时每个时间点追加一个所有域名合计的数据点
+func buildFluxSeries(times []string, traffic map[string]cdn.TrafficData, domains []string) []*FluxPoint {
+	valueAt := func(values []int, index int) int64 {
+		if index < len(values) {
+			return int64(values[index])
+		}
+		return 0
+	}
+
+	points := make([]*FluxPoint, 0, len(times)*(len(domains)+1))
+	for index, apiTime := range times {
+		t := fluxTime(apiTime)
+		all := &FluxPoint{Time: t, Domain: FluxAllDomains}
+		for _, domain := range domains {
+			domainData := traffic[domain]
+			point := &FluxPoint{
+				Time:    t,
+				Domain:  domain,
+				China:   valueAt(domainData.DomainChina, index),
+				Oversea: valueAt(domainData.DomainOversea, index),
+			}
+			point.Total = point.China + point.Oversea
+			points = append(points, point)
+
+			all.China += point.China
+			all.Oversea += point.Oversea
+			all.Total += point.Total
+		}
+		if len(domains) > 1 {
+			points = append(points, all)
+		}
+	}
+	return points
+}
+
+// Flux 查询域名的流量或带宽，按时间点输出，支持 text、json 及 csv 格式
+func Flux(cfg *iqshell.Config, info FluxInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker:          &info,
+		SupportCSVOutput: true,
+	}); !shouldContinue {
+		return
+	}
+
+	times, traffic, err := cdn.Traffic(info.From, info.To, info.Granularity, info.Domains, info.Bandwidth)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	points := buildFluxSeries(times, traffic, info.Domains)
+	if data.IsOutputFormatCSV() {
+		outputFluxCSV(points)
+		return
+	}
+	for _, point := range points {
+		if data.IsOutputFormatJson() {
+			if bytes, mErr := json.Marshal(point); mErr != nil {
+				log.ErrorF("marshal flux of domain:%s error:%v", point.Domain, mErr)
+			} else {
+				log.Alert(string(bytes))
+			}
+			continue
+		}
+		log.AlertF("%s\t%s\t%d\t%d\t%d", point.Time, point.Domain, point.China, point.Oversea, point.Total)
+	}
+}
+
+func outputFluxCSV(points []*FluxPoint) {
+	builder := &strings.Builder{}
+	writer := csv.NewWriter(builder)
+	_ = writer.Write([]string{"time", "domain", "china", "oversea", "total"})
+	for _, point := range points {
+		_ = writer.Write([]string{
+			point.Time,
+			point.Domain,
+			strconv.FormatInt(point.China, 10),
+			strconv.FormatInt(point.Oversea, 10),
+			strconv.FormatInt(point.Total, 10),
+		})
+	}
+	writer.Flush()
+	log.Alert(strings.TrimSuffix(builder.String(), "\n"))
+}
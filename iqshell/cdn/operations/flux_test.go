@@ -0,0 +1,34 @@
+package operations
+
+import (
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/cdn"
+)
+
+func TestBuildFluxSeries(t *testing.T) {
+	times := []string{"2024-06-01 00:00:00", "2024-06-02 00:00:00"}
+	traffic := map[string]cdn.TrafficData{
+		"a.com": {DomainChina: []int{10, 20}, DomainOversea: []int{1, 2}},
+		"b.com": {DomainChina: []int{5}},
+	}
+
+	points := buildFluxSeries(times, traffic, []string{"a.com", "b.com", "c.com"})
+	if len(points) != 8 {
+		t.Fatalf("point count error, count:%d", len(points))
+	}
+	if points[0].Time != "2024-06-01T00:00:00+08:00" {
+		t.Fatalf("time error, time:%s", points[0].Time)
+	}
+	all := points[3]
+	if all.Domain != FluxAllDomains || all.China != 15 || all.Oversea != 1 || all.Total != 16 {
+		t.Fatalf("all domains error, point:%+v", all)
+	}
+	if b := points[5]; b.Domain != "b.com" || b.Total != 0 {
+		t.Fatalf("missing value should be 0, point:%+v", b)
+	}
+
+	if points := buildFluxSeries(times, traffic, []string{"a.com"}); len(points) != 2 || points[1].Total != 22 {
+		t.Fatalf("single domain error, points:%+v", points)
+	}
+}
@@ -0,0 +1,242 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/cdn"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/client"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+type LogInfo struct {
+	flow.Info
+
+	Domains []string // 域名 【必选】
+	Day     string   // 日志的日期，格式：2006-01-02，默认为昨天 【可选】
+	OutDir  string   // 日志保存的目录，默认为当前目录 【可选】
+	Parse   bool     // 下载后统计日志，按域名输出请求数、流量及状态码分布 【可选】
+}
+
+func (info *LogInfo) Check() *data.CodeError {
+	if len(info.Domains) == 0 {
+		return alert.CannotEmptyError("Domain", "")
+	}
+	if len(info.Day) == 0 {
+		info.Day = time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", info.Day); err != nil {
+		return alert.Error("invalid day: "+info.Day, "day should be like 2024-06-01")
+	}
+	if len(info.OutDir) == 0 {
+		info.OutDir = "."
+	}
+	if info.WorkerCount <= 0 {
+		info.WorkerCount = 5
+	}
+	// 仅下载日志，无需用户确认
+	info.Force = true
+	return info.Info.Check()
+}
+
+type logWork struct {
+	Domain string
+	File   cdn.LogFile
+}
+
+func (w *logWork) WorkId() string {
+	return w.File.Name
+}
+
+type logResult struct {
+	FilePath string
+	IsExist  bool
+	Stat     *LogStat
+}
+
+func (r *logResult) IsValid() bool {
+	return len(r.FilePath) > 0
+}
+
+// Log 下载域名某一天的 CDN 访问日志，可选统计日志中的请求数、流量及状态码分布
+func Log(cfg *iqshell.Config, info LogInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if err := os.MkdirAll(info.OutDir, 0755); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("create log dir:%s error:%v", info.OutDir, err)
+		return
+	}
+
+	logFiles, err := cdn.LogList(info.Day, info.Domains)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	// 没有日志的域名仅提示，不视为失败
+	works := make([]*logWork, 0)
+	for _, domain := range info.Domains {
+		files := logFiles[domain]
+		if len(files) == 0 {
+			log.WarningF("no CDN log found for domain:%s on day:%s", domain, info.Day)
+			continue
+		}
+		for _, file := range files {
+			works = append(works, &logWork{Domain: domain, File: file})
+		}
+	}
+	if len(works) == 0 {
+		return
+	}
+
+	workChan := make(chan flow.Work)
+	go func() {
+		defer close(workChan)
+		for _, work := range works {
+			workChan <- work
+		}
+	}()
+
+	var statsLock sync.Mutex
+	stats := make(map[string]*LogStat)
+	var failureCount int64
+	flow.New(info.Info).
+		WorkProviderWithChan(workChan).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
+				work := workInfo.Work.(*logWork)
+				result, dErr := downloadLogFile(work, info.OutDir)
+				if dErr != nil {
+					return nil, dErr
+				}
+				if !info.Parse {
+					return result, nil
+				}
+				if result.Stat, dErr = parseLogFile(work.Domain, result.FilePath); dErr != nil {
+					return nil, dErr
+				}
+				return result, nil
+			}), nil
+		})).
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
+			r, _ := result.(*logResult)
+			if r == nil {
+				return
+			}
+			if r.IsExist {
+				log.InfoF("CDN log:%s exists, skip downloading", r.FilePath)
+			} else {
+				log.InfoF("Download CDN log success, %s", r.FilePath)
+			}
+			if r.Stat == nil {
+				return
+			}
+			statsLock.Lock()
+			if domainStat := stats[r.Stat.Domain]; domainStat == nil {
+				stats[r.Stat.Domain] = r.Stat
+			} else {
+				domainStat.merge(r.Stat)
+			}
+			statsLock.Unlock()
+		}).
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
+			statsLock.Lock()
+			failureCount++
+			statsLock.Unlock()
+			name := workInfo.Data
+			if work, ok := workInfo.Work.(*logWork); ok {
+				name = work.File.Name
+			}
+			log.ErrorF("Download CDN log Failed, %s, Error: %v", name, err)
+		}).Build().Start()
+
+	if info.Parse {
+		domains := make([]string, 0, len(stats))
+		for domain := range stats {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+		for _, domain := range domains {
+			outputLogStat(stats[domain])
+		}
+	}
+
+	if failureCount > 0 {
+		log.ErrorF("%d CDN log file(s) failed", failureCount)
+	}
+}
+
+// downloadLogFile 下载日志文件，本地已存在大小相同的文件时不再下载
+func downloadLogFile(work *logWork, outDir string) (*logResult, *data.CodeError) {
+	filePath := filepath.Join(outDir, filepath.Base(work.File.Name))
+	result := &logResult{FilePath: filePath}
+	if stat, err := os.Stat(filePath); err == nil && stat.Size() == work.File.Size {
+		result.IsExist = true
+		return result, nil
+	}
+
+	resp, rErr := client.DefaultStorageClient().DoRequest(workspace.GetContext(), "GET", work.File.URL, nil)
+	if rErr != nil {
+		return nil, data.NewEmptyError().AppendDescF("request log file:%s error:%v", work.File.Name, rErr)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, data.NewError(resp.StatusCode, fmt.Sprintf("request log file:%s status:%s", work.File.Name, resp.Status))
+	}
+
+	tempFile := filePath + ".part"
+	file, cErr := os.Create(tempFile)
+	if cErr != nil {
+		return nil, data.NewEmptyError().AppendDescF("create file:%s error:%v", tempFile, cErr)
+	}
+	_, wErr := io.Copy(file, resp.Body)
+	if closeErr := file.Close(); wErr == nil {
+		wErr = closeErr
+	}
+	if wErr != nil {
+		_ = os.Remove(tempFile)
+		return nil, data.NewEmptyError().AppendDescF("download log file:%s error:%v", work.File.Name, wErr)
+	}
+	if err := os.Rename(tempFile, filePath); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("rename %s to %s error:%v", tempFile, filePath, err)
+	}
+	return result, nil
+}
+
+func outputLogStat(stat *LogStat) {
+	if data.IsOutputFormatJson() {
+		if bytes, err := json.Marshal(stat); err != nil {
+			log.ErrorF("marshal log stat of domain:%s error:%v", stat.Domain, err)
+		} else {
+			log.Alert(string(bytes))
+		}
+		return
+	}
+
+	codes := make([]string, 0, len(stat.StatusCodes))
+	for _, code := range stat.sortedStatusCodes() {
+		codes = append(codes, fmt.Sprintf("%s:%d", code, stat.StatusCodes[code]))
+	}
+	log.AlertF("%s\t%d\t%d\t%s", stat.Domain, stat.Requests, stat.Bytes, strings.Join(codes, ","))
+	if stat.InvalidLines > 0 {
+		log.WarningF("domain:%s, %d line(s) of the log can't be parsed", stat.Domain, stat.InvalidLines)
+	}
+}
@@ -0,0 +1,116 @@
+package operations
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// LogStat 一个域名的访问日志统计
+type LogStat struct {
+	Domain       string           `json:"domain"`
+	Requests     int64            `json:"requests"`
+	Bytes        int64            `json:"bytes"`
+	StatusCodes  map[string]int64 `json:"status_codes"`  // 状态码 -> 请求数
+	InvalidLines int64            `json:"invalid_lines"` // 无法解析的行数
+}
+
+func newLogStat(domain string) *LogStat {
+	return &LogStat{
+		Domain:      domain,
+		StatusCodes: make(map[string]int64),
+	}
+}
+
+func (s *LogStat) merge(other *LogStat) {
+	s.Requests += other.Requests
+	s.Bytes += other.Bytes
+	s.InvalidLines += other.InvalidLines
+	for code, count := range other.StatusCodes {
+		s.StatusCodes[code] += count
+	}
+}
+
+// sortedStatusCodes 状态码从小到大排序
+func (s *LogStat) sortedStatusCodes() []string {
+	codes := make([]string, 0, len(s.StatusCodes))
+	for code := range s.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// parseLogLine 解析一行访问日志，返回状态码及响应的字节数；日志格式如：
+// 1.2.3.4 HIT 10 [01/Jun/2024:00:00:00 +0800] "GET http://a.com/b.jpg HTTP/1.1" 200 1024 "-" "UA"
+func parseLogLine(line string) (statusCode string, bytes int64, ok bool) {
+	requestStart := strings.Index(line, "\"")
+	if requestStart < 0 {
+		return "", 0, false
+	}
+	requestEnd := strings.Index(line[requestStart+1:], "\"")
+	if requestEnd < 0 {
+		return "", 0, false
+	}
+
+	fields := strings.Fields(line[requestStart+1+requestEnd+1:])
+	if len(fields) < 2 {
+		return "", 0, false
+	}
+	if _, err := strconv.Atoi(fields[0]); err != nil {
+		return "", 0, false
+	}
+	// 响应大小可能为 -
+	size, _ := strconv.ParseInt(fields[1], 10, 64)
+	return fields[0], size, true
+}
+
+// parseLogFile 统计日志文件，文件以 .gz 结尾时按 gzip 解压读取
+func parseLogFile(domain string, filePath string) (*LogStat, *data.CodeError) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("open log file:%s error:%v", filePath, err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(filePath, ".gz") {
+		gzipReader, gErr := gzip.NewReader(file)
+		if gErr != nil {
+			return nil, data.NewEmptyError().AppendDescF("read gzip log file:%s error:%v", filePath, gErr)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+	return parseLog(domain, reader)
+}
+
+func parseLog(domain string, reader io.Reader) (*LogStat, *data.CodeError) {
+	stat := newLogStat(domain)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		statusCode, bytes, ok := parseLogLine(line)
+		if !ok {
+			stat.InvalidLines++
+			continue
+		}
+		stat.Requests++
+		stat.Bytes += bytes
+		stat.StatusCodes[statusCode]++
+	}
+	if err := scanner.Err(); err != nil {
+		return stat, data.NewEmptyError().AppendDescF("read log error:%v", err)
+	}
+	return stat, nil
+}
@@ -0,0 +1,27 @@
+package operations
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLog(t *testing.T) {
+	logContent := strings.Join([]string{
+		`1.2.3.4 HIT 10 [01/Jun/2024:00:00:00 +0800] "GET http://a.com/b.jpg HTTP/1.1" 200 1024 "-" "Mozilla/5.0"`,
+		`1.2.3.5 MISS 20 [01/Jun/2024:00:00:01 +0800] "GET http://a.com/c.jpg HTTP/1.1" 404 100 "-" "curl/7.0"`,
+		`1.2.3.6 HIT 5 [01/Jun/2024:00:00:02 +0800] "HEAD http://a.com/b.jpg HTTP/1.1" 200 - "-" "-"`,
+		``,
+		`invalid line`,
+	}, "\n")
+
+	stat, err := parseLog("a.com", strings.NewReader(logContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Requests != 3 || stat.Bytes != 1124 || stat.InvalidLines != 1 {
+		t.Fatalf("log stat error:%+v", stat)
+	}
+	if stat.StatusCodes["200"] != 2 || stat.StatusCodes["404"] != 1 {
+		t.Fatalf("status code stat error:%+v", stat.StatusCodes)
+	}
+}
@@ -8,15 +8,28 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
 	"strings"
+	"time"
 )
 
 type PrefetchInfo struct {
-	UrlListFile string // url 信息文件
-	SizeLimit   int    // 每次刷新最大 size 限制
-	QpsLimit    int    // qps 限制
+	UrlListFile  string // url 信息文件
+	SizeLimit    int    // 每次刷新最大 size 限制
+	QpsLimit     int    // qps 限制
+	Wait         bool   // 提交后轮询预取状态直到所有 url 预取结束
+	WaitInterval int    // 轮询预取状态的间隔，单位：秒
+	WaitTimeout  int    // 轮询预取状态的最长时间，超时后停止轮询，单位：秒
 }
 
 func (info *PrefetchInfo) Check() *data.CodeError {
+	if info.SizeLimit <= 0 || info.SizeLimit > cdn.BatchPrefetchAllowMax {
+		info.SizeLimit = cdn.BatchPrefetchAllowMax
+	}
+	if info.WaitInterval <= 0 {
+		info.WaitInterval = 10
+	}
+	if info.WaitTimeout <= 0 {
+		info.WaitTimeout = 3600
+	}
 	return nil
 }
 
@@ -29,7 +42,15 @@ func Prefetch(cfg *iqshell.Config, info PrefetchInfo) {
 
 	log.DebugF("qps limit: %d, max item-size: %d", info.QpsLimit, info.SizeLimit)
 
-	workProvider, err := flow.NewWorkProviderOfFile(info.UrlListFile,
+	createQpsLimitIfNeeded(info.QpsLimit)
+
+	var requestIds []string
+	quotaExhausted := false
+	// 只有一个 worker 串行提交；超出当日额度时 worker 返回错误，flow 不再提交剩余的 url
+	flow.New(flow.Info{
+		Force:       true,
+		WorkerCount: 1,
+	}).WorkProviderWithFile(info.UrlListFile,
 		true,
 		flow.NewItemsWorkCreator(flow.DefaultLineItemSeparate,
 			1,
@@ -41,54 +62,121 @@ func Prefetch(cfg *iqshell.Config, info PrefetchInfo) {
 				return &prefetchWork{
 					Url: item,
 				}, nil
-			}))
-	if err != nil {
-		log.Error(err)
-		data.SetCmdStatusError()
-		return
-	}
-
-	createQpsLimitIfNeeded(info.QpsLimit)
-
-	urlsToPrefetch := make([]string, 0, 50)
-	for {
-		hasMore, workInfo, pErr := workProvider.Provide()
-		if pErr != nil {
-			data.SetCmdStatusError()
-			log.ErrorF("read work error:%v", pErr)
-			continue
-		}
-		if !hasMore {
-			break
-		}
+			})).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewWorker(func(workInfos []*flow.WorkInfo) ([]*flow.WorkRecord, *data.CodeError) {
+				if quotaExhausted {
+					return nil, data.NewEmptyError().AppendDesc("CDN prefetch quota of today is used up, not submitted")
+				}
 
-		w, _ := workInfo.Work.(*prefetchWork)
-		urlsToPrefetch = append(urlsToPrefetch, w.Url)
+				urls := make([]string, 0, len(workInfos))
+				for _, workInfo := range workInfos {
+					urls = append(urls, workInfo.Work.(*prefetchWork).Url)
+				}
+				result, sErr := prefetchWithQps(urls)
+				if sErr != nil {
+					if sErr.Code == cdn.PrefetchQuotaExceededCode {
+						log.ErrorF("CDN prefetch quota of today is exceeded, stop submitting, Error: %v", sErr)
+						return nil, sErr
+					}
+					return prefetchWorkRecords(workInfos, nil, sErr), nil
+				}
 
-		if len(urlsToPrefetch) == cdn.BatchPrefetchAllowMax ||
-			(info.SizeLimit > 0 && len(urlsToPrefetch) >= info.SizeLimit) {
-			prefetchWithQps(urlsToPrefetch)
-			urlsToPrefetch = make([]string, 0, 50)
-		}
+				if len(result.RequestId) > 0 {
+					requestIds = append(requestIds, result.RequestId)
+				}
+				if result.IsQuotaExhausted() {
+					quotaExhausted = true
+					log.WarningF("CDN prefetch quota of today(%d) is used up, stop submitting", result.QuotaDay)
+				}
+				return prefetchWorkRecords(workInfos, &prefetchResult{RequestId: result.RequestId}, nil), nil
+			}), nil
+		})).
+		DoWorkListMaxCount(info.SizeLimit).
+		OnWorkSkip(func(workInfo *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			log.InfoF("Skip line:%s because:%v", workInfo.Data, err)
+		}).
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
+			log.ErrorF("CDN prefetch Failed, %s, Error: %v", workInfo.Data, err)
+		}).Build().Start()
+
+	if info.Wait {
+		waitPrefetchDone(requestIds, time.Duration(info.WaitInterval)*time.Second, time.Duration(info.WaitTimeout)*time.Second)
 	}
+}
 
-	if len(urlsToPrefetch) > 0 {
-		prefetchWithQps(urlsToPrefetch)
+func prefetchWorkRecords(workInfos []*flow.WorkInfo, result *prefetchResult, err *data.CodeError) []*flow.WorkRecord {
+	records := make([]*flow.WorkRecord, 0, len(workInfos))
+	for _, workInfo := range workInfos {
+		records = append(records, &flow.WorkRecord{
+			WorkInfo: workInfo,
+			Result:   result,
+			Err:      err,
+		})
 	}
+	return records
 }
 
-func prefetchWithQps(urlsToPrefetch []string) {
+func prefetchWithQps(urlsToPrefetch []string) (*cdn.PrefetchResult, *data.CodeError) {
 
 	waiterIfNeeded()
 
 	log.DebugF("cdnPrefetch, url size: %d", len(urlsToPrefetch))
-	if len(urlsToPrefetch) > 0 {
-		err := cdn.Prefetch(urlsToPrefetch)
-		if err != nil {
-			log.Error(err)
+	return cdn.Prefetch(urlsToPrefetch)
+}
+
+// waitPrefetchDone 轮询预取请求的状态，直到所有 url 预取成功或失败；超过 timeout 后停止轮询
+func waitPrefetchDone(requestIds []string, interval time.Duration, timeout time.Duration) {
+	var successCount, failureCount int
+	pending := requestIds
+	deadline := time.Now().Add(timeout)
+	for len(pending) > 0 {
+		if time.Now().Add(interval).After(deadline) {
 			data.SetCmdStatusError()
+			log.ErrorF("CDN prefetch, wait timeout, %d request(s) are not done:%s", len(pending), strings.Join(pending, ","))
+			break
+		}
+		time.Sleep(interval)
+
+		stillPending := make([]string, 0, len(pending))
+		processingCount := 0
+		for _, requestId := range pending {
+			items, err := cdn.PrefetchStatus(requestId)
+			if err != nil {
+				log.WarningF("get prefetch status of request:%s error:%v, retry later", requestId, err)
+				stillPending = append(stillPending, requestId)
+				continue
+			}
+
+			done := true
+			for _, item := range items {
+				if !item.IsDone() {
+					done = false
+					processingCount++
+				}
+			}
+			if !done {
+				stillPending = append(stillPending, requestId)
+				continue
+			}
+
+			for _, item := range items {
+				if item.State == cdn.PrefetchStateSuccess {
+					successCount++
+				} else {
+					failureCount++
+					log.ErrorF("CDN prefetch Failed, %s, Error: %s", item.Url, item.StateMsg)
+				}
+			}
+		}
+		pending = stillPending
+		if len(pending) > 0 {
+			log.InfoF("CDN prefetch, %d request(s) are processing, %d url(s) not done", len(pending), processingCount)
 		}
 	}
+
+	log.AlertF("CDN prefetch done, success: %d, failure: %d", successCount, failureCount)
+	data.SetCmdStatusByWorkCount(int64(successCount+failureCount), int64(failureCount))
 }
 
 type prefetchWork struct {
@@ -98,3 +186,11 @@ type prefetchWork struct {
 func (w *prefetchWork) WorkId() string {
 	return w.Url
 }
+
+type prefetchResult struct {
+	RequestId string
+}
+
+func (r *prefetchResult) IsValid() bool {
+	return r != nil
+}
@@ -13,14 +13,43 @@ import (
 type RefreshInfo struct {
 	ItemListFile string
 	IsDir        bool
+	UrlListFile  string // 文件外链列表文件，可与 DirListFile 同时使用
+	DirListFile  string // 目录外链列表文件，可与 UrlListFile 同时使用
 	SizeLimit    int
 	QpsLimit     int
 }
 
 func (info *RefreshInfo) Check() *data.CodeError {
+	if (len(info.UrlListFile) > 0 || len(info.DirListFile) > 0) && (len(info.ItemListFile) > 0 || info.IsDir) {
+		return alert.Error("--input-file and --dirs can't be used with --urls-file or --dirs-file", "")
+	}
 	return nil
 }
 
+// refreshTypeStat 一种刷新类型(文件或目录)的提交统计，两种类型的每日额度是分开计算的
+type refreshTypeStat struct {
+	Name          string
+	IsDir         bool
+	Submitted     int
+	QuotaDay      int
+	SurplusDay    int
+	QuotaExceeded bool
+}
+
+func (s *refreshTypeStat) batchMax() int {
+	if s.IsDir {
+		return cdn.BatchRefreshDirsAllowMax
+	}
+	return cdn.BatchRefreshUrlsAllowMax
+}
+
+func (s *refreshTypeStat) quotaExceededCode() int {
+	if s.IsDir {
+		return cdn.RefreshDirQuotaExceededCode
+	}
+	return cdn.RefreshUrlQuotaExceededCode
+}
+
 // Refresh 【cdnrefresh】刷新所有CDN节点
 func Refresh(cfg *iqshell.Config, info RefreshInfo) {
 
@@ -32,7 +61,37 @@ func Refresh(cfg *iqshell.Config, info RefreshInfo) {
 
 	log.DebugF("qps limit: %d, max item-size: %d", info.QpsLimit, info.SizeLimit)
 
-	workProvider, err := flow.NewWorkProviderOfFile(info.ItemListFile,
+	createQpsLimitIfNeeded(info.QpsLimit)
+
+	if len(info.UrlListFile) == 0 && len(info.DirListFile) == 0 {
+		stat := &refreshTypeStat{Name: "url", IsDir: info.IsDir}
+		if info.IsDir {
+			stat.Name = "dir"
+		}
+		refreshItemsOfFile(info, info.ItemListFile, stat)
+		outputRefreshTypeStat(stat)
+		return
+	}
+
+	// 一种类型超出额度后，另一种类型继续提交
+	stats := make([]*refreshTypeStat, 0, 2)
+	if len(info.UrlListFile) > 0 {
+		stat := &refreshTypeStat{Name: "url"}
+		refreshItemsOfFile(info, info.UrlListFile, stat)
+		stats = append(stats, stat)
+	}
+	if len(info.DirListFile) > 0 {
+		stat := &refreshTypeStat{Name: "dir", IsDir: true}
+		refreshItemsOfFile(info, info.DirListFile, stat)
+		stats = append(stats, stat)
+	}
+	for _, stat := range stats {
+		outputRefreshTypeStat(stat)
+	}
+}
+
+func refreshItemsOfFile(info RefreshInfo, itemListFile string, stat *refreshTypeStat) {
+	workProvider, err := flow.NewWorkProviderOfFile(itemListFile,
 		true,
 		flow.NewItemsWorkCreator(flow.DefaultLineItemSeparate,
 			1,
@@ -51,10 +110,8 @@ func Refresh(cfg *iqshell.Config, info RefreshInfo) {
 		return
 	}
 
-	createQpsLimitIfNeeded(info.QpsLimit)
-
 	itemsToRefresh := make([]string, 0, 50)
-	for {
+	for !stat.QuotaExceeded {
 		hasMore, workInfo, pErr := workProvider.Provide()
 		if pErr != nil {
 			data.SetCmdStatusError()
@@ -67,41 +124,58 @@ func Refresh(cfg *iqshell.Config, info RefreshInfo) {
 
 		w, _ := workInfo.Work.(*refreshWork)
 		itemsToRefresh = append(itemsToRefresh, w.Url)
-		if refreshWithQps(info, itemsToRefresh, false) {
+		if len(itemsToRefresh) == stat.batchMax() ||
+			(info.SizeLimit > 0 && len(itemsToRefresh) >= info.SizeLimit) {
+			refreshWithQps(stat, itemsToRefresh)
 			itemsToRefresh = make([]string, 0, 50)
 		}
 	}
 
 	//check final items
-	if len(itemsToRefresh) > 0 {
-		refreshWithQps(info, itemsToRefresh, true)
+	if !stat.QuotaExceeded && len(itemsToRefresh) > 0 {
+		refreshWithQps(stat, itemsToRefresh)
 	}
 }
 
-func refreshWithQps(info RefreshInfo, items []string, force bool) (isRefresh bool) {
-	var err *data.CodeError
+func refreshWithQps(stat *refreshTypeStat, items []string) {
+	waiterIfNeeded()
 
-	if info.IsDir {
-		if force || len(items) == cdn.BatchRefreshDirsAllowMax ||
-			(info.SizeLimit > 0 && len(items) >= info.SizeLimit) {
-			waiterIfNeeded()
-			err = cdn.Refresh(nil, items)
-			isRefresh = true
-		}
+	var result *cdn.RefreshResult
+	var err *data.CodeError
+	if stat.IsDir {
+		result, err = cdn.Refresh(nil, items)
 	} else {
-		if force || len(items) == cdn.BatchRefreshUrlsAllowMax ||
-			(info.SizeLimit > 0 && len(items) >= info.SizeLimit) {
-			waiterIfNeeded()
-			err = cdn.Refresh(items, nil)
-			isRefresh = true
-		}
+		result, err = cdn.Refresh(items, nil)
 	}
-
 	if err != nil {
-		log.Error(err)
 		data.SetCmdStatusError()
+		if err.Code == stat.quotaExceededCode() {
+			stat.QuotaExceeded = true
+			log.ErrorF("CDN refresh %s quota of today is exceeded, stop submitting %s, Error: %v", stat.Name, stat.Name, err)
+		} else {
+			log.Error(err)
+		}
+		return
+	}
+
+	stat.Submitted += len(items)
+	if stat.IsDir {
+		stat.QuotaDay, stat.SurplusDay = result.DirQuotaDay, result.DirSurplusDay
+	} else {
+		stat.QuotaDay, stat.SurplusDay = result.UrlQuotaDay, result.UrlSurplusDay
+	}
+	if stat.QuotaDay > 0 && stat.SurplusDay <= 0 {
+		stat.QuotaExceeded = true
+		log.WarningF("CDN refresh %s quota of today(%d) is used up, stop submitting %s", stat.Name, stat.QuotaDay, stat.Name)
+	}
+}
+
+func outputRefreshTypeStat(stat *refreshTypeStat) {
+	if stat.QuotaDay > 0 {
+		log.AlertF("CDN refresh %s, submitted: %d, quota of today: %d, surplus: %d", stat.Name, stat.Submitted, stat.QuotaDay, stat.SurplusDay)
+	} else {
+		log.AlertF("CDN refresh %s, submitted: %d", stat.Name, stat.Submitted)
 	}
-	return
 }
 
 type refreshWork struct {
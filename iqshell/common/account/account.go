@@ -4,15 +4,37 @@ import (
 	"fmt"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"strings"
+	"time"
 
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
 )
 
 // Account - 用户自定义的账户名称
 type Account struct {
-	Name      string
-	AccessKey string
-	SecretKey string
+	Name       string
+	AccessKey  string
+	SecretKey  string
+	Passphrase string    // 保存时使用口令加密 SecretKey，口令本身不保存
+	Source     string    // 账户的来源，不保存
+	Expiration time.Time // 临时凭证的过期时间，为空表示不过期，不保存
+	// 临时凭证的 session token，为空表示不是临时凭证，不保存
+	SessionToken string
+
+	encryptedSecretKey string // 使用口令加密的 SecretKey，Unlock 之前 SecretKey 为空
+}
+
+// CheckExpiration 临时凭证过期时返回 ErrorCodeCredentialsExpired 错误
+func (acc *Account) CheckExpiration() *data.CodeError {
+	if acc.Expiration.IsZero() || time.Now().Before(acc.Expiration) {
+		return nil
+	}
+	return data.NewError(data.ErrorCodeCredentialsExpired,
+		fmt.Sprintf("temporary credentials of %s expired at %s, please refresh them", acc.Source, acc.Expiration.Format(time.RFC3339)))
+}
+
+// IsPassphraseProtected SecretKey 是否使用口令加密
+func (acc *Account) IsPassphraseProtected() bool {
+	return len(acc.Passphrase) > 0 || len(acc.encryptedSecretKey) > 0
 }
 
 // 获取qbox.Mac
@@ -33,6 +55,20 @@ func (acc *Account) encrypt() (s string, err *data.CodeError) {
 
 // 对SecretKey加密， 形成最后的数据格式
 func (acc *Account) value() (v string, err *data.CodeError) {
+	if len(acc.Passphrase) > 0 {
+		encryptedKey, eErr := encryptSecretKeyWithPassphrase(acc.SecretKey, acc.Passphrase)
+		if eErr != nil {
+			err = eErr
+			return
+		}
+		v = strings.Join([]string{acc.Name, acc.AccessKey, encryptedKey, passphraseTag}, ":")
+		return
+	}
+	if len(acc.encryptedSecretKey) > 0 {
+		v = strings.Join([]string{acc.Name, acc.AccessKey, acc.encryptedSecretKey, passphraseTag}, ":")
+		return
+	}
+
 	encryptedKey, eErr := encryptSecretKey(acc.AccessKey, acc.SecretKey)
 	if eErr != nil {
 		err = eErr
@@ -43,5 +79,8 @@ func (acc *Account) value() (v string, err *data.CodeError) {
 }
 
 func (acc *Account) String() string {
+	if acc.IsPassphraseProtected() && len(acc.SecretKey) == 0 {
+		return fmt.Sprintf("Name: %s\nAccessKey: %s\nSecretKey: (protected by passphrase)", acc.Name, acc.AccessKey)
+	}
 	return fmt.Sprintf("Name: %s\nAccessKey: %s\nSecretKey: %s", acc.Name, acc.AccessKey, acc.SecretKey)
 }
@@ -56,6 +56,7 @@ func SetAccountToLocalFile(acc Account) (err *data.CodeError) {
 		err = data.NewEmptyError().AppendDescF("Write account info error, %s", wErr)
 		return
 	}
+	restrictPermission()
 	return
 }
 
@@ -93,6 +94,7 @@ func SaveToDB(acc Account, accountOver bool) (err *data.CodeError) {
 		err = data.NewEmptyError().AppendDescF("leveldb Put: %v", putErr)
 		return
 	}
+	restrictPermission()
 	return
 }
 
@@ -166,16 +168,35 @@ func GetOldAccount() (account Account, err *data.CodeError) {
 	return getAccount(info.OldAccountPath)
 }
 
-// 返回Account
+// GetAccount 返回Account，优先级：Profile 指定的账户 > 环境变量及临时凭证文件 > 凭证提供方 > 配置文件 > 当前账户；
+// 使用口令加密的账户需要 Unlock 后才能获取 SecretKey
 func GetAccount() (account Account, err *data.CodeError) {
+	if len(info.Profile) > 0 {
+		account, err = getUser(info.Profile)
+		account.Source = SourceProfile
+		return
+	}
+
+	if acc, ok, eErr := getEnvAccount(); ok {
+		return acc, eErr
+	}
+
+	if acc, ok, pErr := getProviderAccount(); ok {
+		return acc, pErr
+	}
+
 	credentials := config.GetCredentials(config.ConfigTypeDefault)
-	if credentials.AccessKey != "" && credentials.SecretKey != nil {
+	if credentials.AccessKey != "" && len(credentials.SecretKey) > 0 {
 		return Account{
 			AccessKey: credentials.AccessKey,
 			SecretKey: string(credentials.SecretKey),
+			Source:    SourceConfig,
 		}, nil
 	}
-	return getAccount(info.AccountPath)
+
+	account, err = getAccount(info.AccountPath)
+	account.Source = SourceAccountFile
+	return
 }
 
 // 获取Mac
@@ -184,27 +205,51 @@ func GetMac() (mac *qbox.Mac, err *data.CodeError) {
 	if err != nil {
 		return nil, err
 	}
+	if err = account.CheckExpiration(); err != nil {
+		return nil, err
+	}
+	if err = account.Unlock(); err != nil {
+		return nil, err
+	}
 	return account.mac(), nil
 }
 
+// GetUser 从本地数据库获取名称为 userName 的账户，名称需完全匹配；使用口令加密的账户会解密 SecretKey
+func GetUser(userName string) (user Account, err *data.CodeError) {
+	if user, err = getUser(userName); err != nil {
+		return
+	}
+	err = user.Unlock()
+	return
+}
+
+func getUser(userName string) (user Account, err *data.CodeError) {
+	db, oErr := leveldb.OpenFile(info.AccountDBPath, nil)
+	if oErr != nil {
+		err = data.NewEmptyError().AppendDescF("open db: %v", oErr)
+		return
+	}
+	defer db.Close()
+
+	value, gErr := db.Get([]byte(userName), nil)
+	if gErr != nil {
+		err = data.NewEmptyError().AppendDescF("can't find user by name:%s , error:%v", userName, gErr)
+		return
+	}
+	user, dErr := decrypt(string(value))
+	if dErr != nil {
+		err = data.NewEmptyError().AppendDescF("Decrypt account bytes: %v", dErr)
+		return
+	}
+	return user, nil
+}
+
 // 切换账户
 func ChUser(userName string) (name string, err *data.CodeError) {
 	if userName != "" {
-		db, oErr := leveldb.OpenFile(info.AccountDBPath, nil)
-		if oErr != nil {
-			err = data.NewEmptyError().AppendDescF("open db: %v", oErr)
-			return
-		}
-		defer db.Close()
-
-		value, gErr := db.Get([]byte(userName), nil)
+		user, gErr := getUser(userName)
 		if gErr != nil {
-			err = data.NewEmptyError().AppendDescF("can't find user by name:%s , error:%v", userName, gErr)
-			return
-		}
-		user, dErr := decrypt(string(value))
-		if dErr != nil {
-			err = data.NewEmptyError().AppendDescF("Decrypt account bytes: %v", dErr)
+			err = gErr
 			return
 		}
 
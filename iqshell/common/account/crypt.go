@@ -20,6 +20,17 @@ func decrypt(joinStr string) (acc Account, err *data.CodeError) {
 		acc.Name = ss[0]
 	}
 
+	// 使用口令加密的账户，解密需要口令，由 Unlock 完成
+	if len(ss) == 4 && ss[3] == passphraseTag {
+		if ss[0] == "" || ss[1] == "" || ss[2] == "" {
+			err = data.NewEmptyError().AppendDescF("name, accessKey and encryptedKey should not be empty")
+			return
+		}
+		acc.AccessKey = ss[1]
+		acc.encryptedSecretKey = ss[2]
+		return
+	}
+
 	if len(ss) != 3 {
 		err = data.NewEmptyError().AppendDescF("account json style format error")
 		return
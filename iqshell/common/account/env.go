@@ -0,0 +1,120 @@
+package account
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+const (
+	// AccessKeyEnvKey 和 SecretKeyEnvKey 同时设置时使用环境变量中的凭证，优先于本地保存的账户
+	AccessKeyEnvKey = "QINIU_ACCESS_KEY"
+	SecretKeyEnvKey = "QINIU_SECRET_KEY"
+	// ExpirationEnvKey 环境变量中临时凭证的过期时间，格式为 RFC3339 或 Unix 时间戳（秒）
+	ExpirationEnvKey = "QINIU_CREDENTIALS_EXPIRATION"
+	// SessionTokenEnvKey 环境变量中临时凭证的 session token
+	SessionTokenEnvKey = "QINIU_SESSION_TOKEN"
+	// CredentialsFileEnvKey 临时凭证文件的路径，文件内容为凭证 JSON：{"access_key":"","secret_key":"","session_token":"","expiration":""}
+	CredentialsFileEnvKey = "QINIU_CREDENTIALS_FILE"
+)
+
+// 账户的来源
+const (
+	SourceProfile         = "profile"
+	SourceEnv             = "environment variables"
+	SourceCredentialsFile = "credentials file"
+	SourceProvider        = "credential provider"
+	SourceConfig          = "config file"
+	SourceAccountFile     = "current account"
+)
+
+// credentialsFile 临时凭证文件及凭证提供方返回的凭证 JSON
+type credentialsFile struct {
+	AccessKey    string `json:"access_key"`
+	SecretKey    string `json:"secret_key"`
+	SessionToken string `json:"session_token"`
+	Token        string `json:"token"` // 同 session_token，兼容凭证提供方常用的字段名
+	Expiration   string `json:"expiration"`
+}
+
+// HasEnvCredentials 是否通过环境变量配置了凭证或临时凭证文件
+func HasEnvCredentials() bool {
+	return len(os.Getenv(AccessKeyEnvKey)) > 0 || len(os.Getenv(SecretKeyEnvKey)) > 0 ||
+		len(os.Getenv(CredentialsFileEnvKey)) > 0
+}
+
+// getEnvAccount 从环境变量或临时凭证文件获取账户，均未配置时 ok 为 false
+func getEnvAccount() (acc Account, ok bool, err *data.CodeError) {
+	accessKey, secretKey := os.Getenv(AccessKeyEnvKey), os.Getenv(SecretKeyEnvKey)
+	if len(accessKey) > 0 || len(secretKey) > 0 {
+		if len(accessKey) == 0 || len(secretKey) == 0 {
+			return acc, true, data.NewEmptyError().AppendDescF("%s and %s should be set together", AccessKeyEnvKey, SecretKeyEnvKey)
+		}
+		acc = Account{
+			AccessKey:    accessKey,
+			SecretKey:    secretKey,
+			SessionToken: os.Getenv(SessionTokenEnvKey),
+			Source:       SourceEnv,
+		}
+		if acc.Expiration, err = parseExpiration(os.Getenv(ExpirationEnvKey)); err != nil {
+			return acc, true, data.NewEmptyError().AppendDescF("invalid %s, %v", ExpirationEnvKey, err)
+		}
+		return acc, true, acc.CheckExpiration()
+	}
+
+	path := os.Getenv(CredentialsFileEnvKey)
+	if len(path) == 0 {
+		return acc, false, nil
+	}
+	content, rErr := os.ReadFile(path)
+	if rErr != nil {
+		return acc, true, data.NewEmptyError().AppendDescF("read credentials file:%s error:%v", path, rErr)
+	}
+	file := &credentialsFile{}
+	if uErr := json.Unmarshal(content, file); uErr != nil {
+		return acc, true, data.NewEmptyError().AppendDescF("parse credentials file:%s error:%v", path, uErr)
+	}
+	if acc, err = file.toAccount(SourceCredentialsFile); err != nil {
+		return acc, true, data.NewEmptyError().AppendDescF("credentials file:%s, %v", path, err)
+	}
+	return acc, true, acc.CheckExpiration()
+}
+
+func (f *credentialsFile) toAccount(source string) (acc Account, err *data.CodeError) {
+	if len(f.AccessKey) == 0 || len(f.SecretKey) == 0 {
+		return acc, data.NewEmptyError().AppendDesc("access_key and secret_key can't be empty")
+	}
+	acc = Account{
+		AccessKey:    f.AccessKey,
+		SecretKey:    f.SecretKey,
+		SessionToken: f.SessionToken,
+		Source:       source,
+	}
+	if len(acc.SessionToken) == 0 {
+		acc.SessionToken = f.Token
+	}
+	if acc.Expiration, err = parseExpiration(f.Expiration); err != nil {
+		return acc, data.NewEmptyError().AppendDescF("invalid expiration, %v", err)
+	}
+	return acc, nil
+}
+
+// parseExpiration 解析过期时间，支持 RFC3339 及 Unix 时间戳（秒），为空表示不过期
+func parseExpiration(expiration string) (time.Time, *data.CodeError) {
+	expiration = strings.TrimSpace(expiration)
+	if len(expiration) == 0 {
+		return time.Time{}, nil
+	}
+	if timestamp, err := strconv.ParseInt(expiration, 10, 64); err == nil {
+		return time.Unix(timestamp, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, expiration)
+	if err != nil {
+		return time.Time{}, data.NewEmptyError().AppendDescF("expiration should be RFC3339 or unix timestamp, but is:%s", expiration)
+	}
+	return t, nil
+}
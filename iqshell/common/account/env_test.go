@@ -0,0 +1,41 @@
+package account
+
+import (
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+func TestGetEnvAccount(t *testing.T) {
+	t.Setenv(AccessKeyEnvKey, "ak_env")
+	t.Setenv(SecretKeyEnvKey, "sk_env")
+	t.Setenv(SessionTokenEnvKey, "token_env")
+	t.Setenv(ExpirationEnvKey, "")
+	acc, ok, err := getEnvAccount()
+	if !ok || err != nil || acc.AccessKey != "ak_env" || acc.SecretKey != "sk_env" || acc.Source != SourceEnv {
+		t.Fatal("get env account error:", ok, err, acc)
+	}
+	if acc.SessionToken != "token_env" {
+		t.Fatal("session token should be read from env, but:", acc.SessionToken)
+	}
+
+	t.Setenv(ExpirationEnvKey, "1600000000")
+	if _, _, err = getEnvAccount(); err == nil || err.Code != data.ErrorCodeCredentialsExpired {
+		t.Fatal("expired credentials should return expired error, but:", err)
+	}
+
+	t.Setenv(ExpirationEnvKey, "2100-01-01T00:00:00Z")
+	if _, _, err = getEnvAccount(); err != nil {
+		t.Fatal("credentials should not be expired:", err)
+	}
+
+	t.Setenv(ExpirationEnvKey, "tomorrow")
+	if _, _, err = getEnvAccount(); err == nil {
+		t.Fatal("invalid expiration should return error")
+	}
+
+	t.Setenv(SecretKeyEnvKey, "")
+	if _, ok, err = getEnvAccount(); !ok || err == nil {
+		t.Fatal("access key without secret key should return error")
+	}
+}
@@ -10,6 +10,7 @@ type LoadInfo struct {
 	AccountPath    string
 	OldAccountPath string
 	AccountDBPath  string
+	Profile        string // 指定使用的账户名称，优先于配置文件中的账户及当前账户
 }
 
 var info LoadInfo
@@ -33,5 +34,8 @@ func Load(i LoadInfo) *data.CodeError {
 	log.Debug("account db path:" + info.AccountDBPath)
 	log.Debug("account path:" + info.AccountPath)
 	log.Debug("account old path:" + info.OldAccountPath)
+	if len(info.Profile) > 0 {
+		log.Debug("account profile:" + info.Profile)
+	}
 	return nil
 }
@@ -13,6 +13,8 @@ type AddInfo struct {
 	AccessKey string
 	SecretKey string
 	Over      bool
+	// 是否使用口令加密 SecretKey，口令通过环境变量 QSHELL_ACCOUNT_PASSPHRASE 或终端输入
+	EnablePassphrase bool
 }
 
 func (info *AddInfo) Check() *data.CodeError {
@@ -41,6 +43,15 @@ func Add(cfg *iqshell.Config, info AddInfo) {
 		AccessKey: info.AccessKey,
 		SecretKey: info.SecretKey,
 	}
+	if info.EnablePassphrase {
+		passphrase, err := account.ReadPassphrase(info.Name, true)
+		if err != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("user add: get passphrase error:%v", err)
+			return
+		}
+		acc.Passphrase = passphrase
+	}
 
 	if err := account.SaveToDB(acc, info.Over); err != nil {
 		data.SetCmdStatusError()
@@ -0,0 +1,180 @@
+package account
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+const (
+	// PassphraseEnvKey 账户口令的环境变量，设置后不再交互式输入口令
+	PassphraseEnvKey = "QSHELL_ACCOUNT_PASSPHRASE"
+
+	// passphraseTag 使用口令加密的账户，保存的数据格式为：<Name>:<AccessKey>:<EncryptedSecretKey>:passphrase
+	// EncryptedSecretKey 为 base64(salt + nonce + AES-256-GCM 密文)
+	passphraseTag = "passphrase"
+
+	passphraseSaltSize = 16
+	// scrypt 参数，推导一次密钥约需 100ms 及 32M 内存，增加暴力破解口令的代价
+	passphraseScryptN = 1 << 15
+	passphraseScryptR = 8
+	passphraseScryptP = 1
+	passphraseKeySize = 32
+)
+
+var (
+	passphraseLock  sync.Mutex
+	passphraseCache = make(map[string]string)
+)
+
+func passphraseGcm(passphrase string, salt []byte) (cipher.AEAD, *data.CodeError) {
+	key, err := scrypt.Key([]byte(passphrase), salt, passphraseScryptN, passphraseScryptR, passphraseScryptP, passphraseKeySize)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("derive key from passphrase error:%v", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, data.ConvertError(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, data.ConvertError(err)
+	}
+	return gcm, nil
+}
+
+// 使用口令对 SecretKey 加密，每次加密使用随机的 salt 及 nonce
+func encryptSecretKeyWithPassphrase(secretKey, passphrase string) (string, *data.CodeError) {
+	salt := make([]byte, passphraseSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", data.NewEmptyError().AppendDescF("generate salt error:%v", err)
+	}
+	gcm, err := passphraseGcm(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, rErr := io.ReadFull(rand.Reader, nonce); rErr != nil {
+		return "", data.NewEmptyError().AppendDescF("generate nonce error:%v", rErr)
+	}
+
+	encrypted := append(salt, nonce...)
+	encrypted = gcm.Seal(encrypted, nonce, []byte(secretKey), nil)
+	return base64.URLEncoding.EncodeToString(encrypted), nil
+}
+
+// 使用口令对 SecretKey 解密，口令错误或数据被篡改时返回错误
+func decryptSecretKeyWithPassphrase(encryptedKey, passphrase string) (string, *data.CodeError) {
+	encrypted, dErr := base64.URLEncoding.DecodeString(encryptedKey)
+	if dErr != nil {
+		return "", data.ConvertError(dErr)
+	}
+	if len(encrypted) < passphraseSaltSize {
+		return "", data.NewEmptyError().AppendDesc("encrypted secret key is invalid")
+	}
+	gcm, err := passphraseGcm(passphrase, encrypted[:passphraseSaltSize])
+	if err != nil {
+		return "", err
+	}
+	encrypted = encrypted[passphraseSaltSize:]
+	if len(encrypted) < gcm.NonceSize() {
+		return "", data.NewEmptyError().AppendDesc("encrypted secret key is invalid")
+	}
+	secretKey, oErr := gcm.Open(nil, encrypted[:gcm.NonceSize()], encrypted[gcm.NonceSize():], nil)
+	if oErr != nil {
+		return "", data.NewEmptyError().AppendDesc("passphrase is incorrect")
+	}
+	return string(secretKey), nil
+}
+
+// ReadPassphrase 获取账户的口令：优先使用环境变量 QSHELL_ACCOUNT_PASSPHRASE，否则在终端中输入；confirm 为 true 时需要输入两次
+func ReadPassphrase(name string, confirm bool) (string, *data.CodeError) {
+	if passphrase := os.Getenv(PassphraseEnvKey); len(passphrase) > 0 {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", data.NewEmptyError().AppendDescF("stdin is not a terminal, please set the passphrase of user:%s by environment variable %s", name, PassphraseEnvKey)
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Passphrase of user %s: ", name)
+	passphrase, rErr := term.ReadPassword(fd)
+	_, _ = fmt.Fprintln(os.Stderr)
+	if rErr != nil {
+		return "", data.NewEmptyError().AppendDescF("read passphrase error:%v", rErr)
+	}
+	if len(passphrase) == 0 {
+		return "", data.NewEmptyError().AppendDesc("passphrase can't be empty")
+	}
+	if !confirm {
+		return string(passphrase), nil
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "Confirm passphrase of user %s: ", name)
+	confirmed, rErr := term.ReadPassword(fd)
+	_, _ = fmt.Fprintln(os.Stderr)
+	if rErr != nil {
+		return "", data.NewEmptyError().AppendDescF("read passphrase error:%v", rErr)
+	}
+	if string(confirmed) != string(passphrase) {
+		return "", data.NewEmptyError().AppendDesc("passphrases don't match")
+	}
+	return string(passphrase), nil
+}
+
+// Unlock 解密使用口令加密的 SecretKey，同一账户在一次命令中只需输入一次口令
+func (acc *Account) Unlock() *data.CodeError {
+	if !acc.IsPassphraseProtected() || len(acc.SecretKey) > 0 {
+		return nil
+	}
+
+	passphraseLock.Lock()
+	defer passphraseLock.Unlock()
+
+	cacheKey := acc.Name + ":" + acc.AccessKey
+	passphrase, ok := passphraseCache[cacheKey]
+	if !ok {
+		var err *data.CodeError
+		if passphrase, err = ReadPassphrase(acc.Name, false); err != nil {
+			return err
+		}
+	}
+
+	secretKey, err := decryptSecretKeyWithPassphrase(acc.encryptedSecretKey, passphrase)
+	if err != nil {
+		return data.NewEmptyError().AppendDescF("decrypt secret key of user:%s error:%v", acc.Name, err)
+	}
+	passphraseCache[cacheKey] = passphrase
+	acc.SecretKey = secretKey
+	return nil
+}
+
+// restrictPermission 账户文件只允许当前用户读写
+func restrictPermission() {
+	for _, path := range []string{info.AccountPath, info.OldAccountPath} {
+		if _, err := os.Stat(path); err == nil {
+			_ = os.Chmod(path, 0600)
+		}
+	}
+	if _, err := os.Stat(info.AccountDBPath); err != nil {
+		return
+	}
+	_ = os.Chmod(info.AccountDBPath, 0700)
+	_ = filepath.Walk(info.AccountDBPath, func(path string, fileInfo os.FileInfo, err error) error {
+		if err == nil && !fileInfo.IsDir() {
+			_ = os.Chmod(path, 0600)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,41 @@
+package account
+
+import "testing"
+
+func TestPassphraseValue(t *testing.T) {
+	acc := Account{
+		Name:       "test",
+		AccessKey:  "ak_test",
+		SecretKey:  "sk_test",
+		Passphrase: "passphrase_test",
+	}
+	value, err := acc.value()
+	if err != nil {
+		t.Fatal("value error:", err)
+	}
+
+	stored, err := decrypt(value)
+	if err != nil {
+		t.Fatal("decrypt error:", err)
+	}
+	if !stored.IsPassphraseProtected() || len(stored.SecretKey) > 0 {
+		t.Fatal("secret key should be protected by passphrase")
+	}
+	if _, err = decryptSecretKeyWithPassphrase(stored.encryptedSecretKey, "wrong"); err == nil {
+		t.Fatal("decrypt with wrong passphrase should fail")
+	}
+	secretKey, err := decryptSecretKeyWithPassphrase(stored.encryptedSecretKey, acc.Passphrase)
+	if err != nil || secretKey != acc.SecretKey {
+		t.Fatal("decrypt secret key error:", err, secretKey)
+	}
+
+	// 相同的口令每次加密的结果不同
+	if v, _ := acc.value(); v == value {
+		t.Fatal("encrypted secret key should use random salt and nonce")
+	}
+
+	// 未解密的账户保存时保持原有的加密数据
+	if v, _ := stored.value(); v != value {
+		t.Fatal("value of stored account changed:", v)
+	}
+}
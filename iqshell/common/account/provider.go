@@ -0,0 +1,192 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+const (
+	CredentialProviderTypeCommand = "command"
+	CredentialProviderTypeHttp    = "http"
+
+	credentialProviderTimeout = 30 * time.Second
+	// credentialRefreshAhead 凭证过期前提前获取新的凭证，避免请求过程中凭证过期
+	credentialRefreshAhead = time.Minute
+)
+
+// CredentialProvider 从外部获取短期凭证，长期密钥无需保存在本地
+type CredentialProvider interface {
+	// Name 凭证提供方的描述，用于输出日志
+	Name() string
+	// Retrieve 获取凭证，Expiration 为空表示不过期
+	Retrieve() (Account, *data.CodeError)
+}
+
+var (
+	credentialProviderLock sync.Mutex
+	credentialProvider     CredentialProvider
+)
+
+// SetCredentialProvider 设置凭证提供方，获取账户时优先于配置文件及本地保存的账户；为 nil 表示不使用
+func SetCredentialProvider(provider CredentialProvider) {
+	credentialProviderLock.Lock()
+	defer credentialProviderLock.Unlock()
+
+	if provider == nil {
+		credentialProvider = nil
+	} else {
+		credentialProvider = &cachedCredentialProvider{provider: provider}
+	}
+}
+
+// HasCredentialProvider 是否设置了凭证提供方
+func HasCredentialProvider() bool {
+	credentialProviderLock.Lock()
+	defer credentialProviderLock.Unlock()
+
+	return credentialProvider != nil
+}
+
+func getProviderAccount() (acc Account, ok bool, err *data.CodeError) {
+	credentialProviderLock.Lock()
+	provider := credentialProvider
+	credentialProviderLock.Unlock()
+
+	if provider == nil {
+		return acc, false, nil
+	}
+	acc, err = provider.Retrieve()
+	if err != nil {
+		return acc, true, data.NewEmptyError().AppendDescF("get credentials from %s error:%v", provider.Name(), err)
+	}
+	return acc, true, acc.CheckExpiration()
+}
+
+// NewCredentialProvider 根据配置创建凭证提供方，未配置时返回 nil
+func NewCredentialProvider(cfg config.CredentialProvider) (CredentialProvider, *data.CodeError) {
+	switch cfg.Type {
+	case "":
+		return nil, nil
+	case CredentialProviderTypeCommand:
+		if len(cfg.Command) == 0 {
+			return nil, data.NewEmptyError().AppendDesc("credential_provider.command can't be empty when credential_provider.type is command")
+		}
+		return &commandCredentialProvider{command: cfg.Command}, nil
+	case CredentialProviderTypeHttp:
+		if len(cfg.Url) == 0 {
+			return nil, data.NewEmptyError().AppendDesc("credential_provider.url can't be empty when credential_provider.type is http")
+		}
+		return &httpCredentialProvider{url: cfg.Url}, nil
+	default:
+		return nil, data.NewEmptyError().AppendDescF("credential_provider.type should be %s or %s, but is:%s",
+			CredentialProviderTypeCommand, CredentialProviderTypeHttp, cfg.Type)
+	}
+}
+
+// cachedCredentialProvider 缓存获取的凭证，在凭证过期前不再重复获取
+type cachedCredentialProvider struct {
+	provider CredentialProvider
+	lock     sync.Mutex
+	account  *Account
+}
+
+func (p *cachedCredentialProvider) Name() string {
+	return p.provider.Name()
+}
+
+func (p *cachedCredentialProvider) Retrieve() (Account, *data.CodeError) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.account != nil && (p.account.Expiration.IsZero() || time.Now().Add(credentialRefreshAhead).Before(p.account.Expiration)) {
+		return *p.account, nil
+	}
+
+	acc, err := p.provider.Retrieve()
+	if err != nil {
+		return acc, err
+	}
+	acc.Source = SourceProvider
+	p.account = &acc
+	if acc.Expiration.IsZero() {
+		log.DebugF("get credentials from %s, AccessKey:%s", p.provider.Name(), acc.AccessKey)
+	} else {
+		log.DebugF("get credentials from %s, AccessKey:%s, expiration:%s", p.provider.Name(), acc.AccessKey, acc.Expiration.Format(time.RFC3339))
+	}
+	return acc, nil
+}
+
+func parseProviderCredentials(content []byte) (Account, *data.CodeError) {
+	credentials := &credentialsFile{}
+	if err := json.Unmarshal(content, credentials); err != nil {
+		return Account{}, data.NewEmptyError().AppendDescF("parse credentials error:%v", err)
+	}
+	return credentials.toAccount(SourceProvider)
+}
+
+// commandCredentialProvider 执行命令获取凭证，命令的标准输出为凭证 JSON
+type commandCredentialProvider struct {
+	command string
+}
+
+func (p *commandCredentialProvider) Name() string {
+	return "command:" + p.command
+}
+
+func (p *commandCredentialProvider) Retrieve() (Account, *data.CodeError) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialProviderTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", p.command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", p.command)
+	}
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return Account{}, data.NewEmptyError().AppendDescF("run command error:%v, stderr:%s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseProviderCredentials(output)
+}
+
+// httpCredentialProvider 请求 HTTP 地址获取凭证，响应的 body 为凭证 JSON
+type httpCredentialProvider struct {
+	url string
+}
+
+func (p *httpCredentialProvider) Name() string {
+	return "http:" + p.url
+}
+
+func (p *httpCredentialProvider) Retrieve() (Account, *data.CodeError) {
+	client := &http.Client{Timeout: credentialProviderTimeout}
+	resp, err := client.Get(p.url)
+	if err != nil {
+		return Account{}, data.ConvertError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return Account{}, data.ConvertError(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Account{}, data.NewError(resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return parseProviderCredentials(body)
+}
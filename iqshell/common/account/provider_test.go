@@ -0,0 +1,49 @@
+package account
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+)
+
+func TestHttpCredentialProvider(t *testing.T) {
+	requestCount := 0
+	expiration := time.Now().Add(time.Hour).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		_, _ = fmt.Fprintf(w, `{"access_key":"ak_%d","secret_key":"sk","token":"token_%d","expiration":"%d"}`, requestCount, requestCount, expiration)
+	}))
+	defer server.Close()
+
+	provider, err := NewCredentialProvider(config.CredentialProvider{
+		Type: CredentialProviderTypeHttp,
+		Url:  server.URL,
+	})
+	if err != nil {
+		t.Fatal("new provider error:", err)
+	}
+	SetCredentialProvider(provider)
+	defer SetCredentialProvider(nil)
+
+	for i := 0; i < 3; i++ {
+		acc, ok, gErr := getProviderAccount()
+		if !ok || gErr != nil || acc.AccessKey != "ak_1" || acc.SessionToken != "token_1" || acc.Source != SourceProvider {
+			t.Fatal("get provider account error:", ok, gErr, acc)
+		}
+	}
+	if requestCount != 1 {
+		t.Fatal("credentials should be cached before expiration, but request count:", requestCount)
+	}
+
+	// 即将过期的凭证需要重新获取
+	expiration = time.Now().Add(time.Second).Unix()
+	SetCredentialProvider(provider)
+	_, _, _ = getProviderAccount()
+	if acc, _, _ := getProviderAccount(); acc.AccessKey != "ak_3" || acc.SessionToken != "token_3" {
+		t.Fatal("credentials should be refreshed before expiration, but:", acc.AccessKey, acc.SessionToken)
+	}
+}
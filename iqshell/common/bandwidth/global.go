@@ -0,0 +1,49 @@
+package bandwidth
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+var globalLimiter atomic.Pointer[Limiter]
+
+// SetGlobalLimit 设置全局限速，所有 worker 共享，单位：B/s；bytesPerSecond <= 0 时不限速
+func SetGlobalLimit(bytesPerSecond int64) {
+	if bytesPerSecond <= 0 {
+		globalLimiter.Store(nil)
+	} else {
+		globalLimiter.Store(NewLimiter(bytesPerSecond))
+	}
+}
+
+// GlobalLimiter 全局限速器，未限速时返回 nil
+func GlobalLimiter() *Limiter {
+	return globalLimiter.Load()
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport 对请求 body 和响应 body 使用全局限速器限速，未设置全局限速时不做处理
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiter := GlobalLimiter()
+	if limiter == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+		req.Body = limiter.ReadCloser(req.Body)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if resp != nil && resp.Body != nil {
+		resp.Body = limiter.ReadCloser(resp.Body)
+	}
+	return resp, err
+}
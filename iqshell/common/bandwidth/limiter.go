@@ -0,0 +1,140 @@
+package bandwidth
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Limiter 令牌桶限速器，每秒产生 bytesPerSecond 个令牌，每传输 1 字节消耗 1 个令牌，桶容量为 1s 的令牌数
+// 多个 goroutine 共享同一个 Limiter 时，总的传输速度不超过限制
+type Limiter struct {
+	mu             sync.Mutex
+	bytesPerSecond int64
+	tokens         float64
+	last           time.Time
+}
+
+func NewLimiter(bytesPerSecond int64) *Limiter {
+	return &Limiter{
+		bytesPerSecond: bytesPerSecond,
+		tokens:         float64(bytesPerSecond),
+		last:           time.Now(),
+	}
+}
+
+// BytesPerSecond 限制的速度，单位：B/s
+func (l *Limiter) BytesPerSecond() int64 {
+	if l == nil {
+		return 0
+	}
+	return l.bytesPerSecond
+}
+
+// WaitN 消耗 n 个令牌，令牌不足时等待至令牌补足；nil 不限速
+func (l *Limiter) WaitN(n int) {
+	if l == nil || l.bytesPerSecond <= 0 || n <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.bytesPerSecond)
+	if l.tokens > float64(l.bytesPerSecond) {
+		l.tokens = float64(l.bytesPerSecond)
+	}
+	l.last = now
+	// 令牌可以预支，预支的部分由当前调用者等待偿还，后续调用者会在此基础上继续等待
+	l.tokens -= float64(n)
+	wait := time.Duration(0)
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / float64(l.bytesPerSecond) * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// maxChunkSize 单次读写的最大字节数，避免单次预支过多令牌
+func (l *Limiter) maxChunkSize() int {
+	size := l.bytesPerSecond / 10
+	if size < 1 {
+		size = 1
+	}
+	return int(size)
+}
+
+// Reader 返回限速的 Reader；l 为 nil 时返回 r 本身
+func (l *Limiter) Reader(r io.Reader) io.Reader {
+	if l == nil || r == nil {
+		return r
+	}
+	return &reader{limiter: l, r: r}
+}
+
+// ReadCloser 返回限速的 ReadCloser；l 为 nil 时返回 r 本身
+func (l *Limiter) ReadCloser(r io.ReadCloser) io.ReadCloser {
+	if l == nil || r == nil {
+		return r
+	}
+	return &readCloser{
+		reader: reader{limiter: l, r: r},
+		c:      r,
+	}
+}
+
+// Writer 返回限速的 Writer；l 为 nil 时返回 w 本身
+func (l *Limiter) Writer(w io.Writer) io.Writer {
+	if l == nil || w == nil {
+		return w
+	}
+	return &writer{limiter: l, w: w}
+}
+
+type reader struct {
+	limiter *Limiter
+	r       io.Reader
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if size := r.limiter.maxChunkSize(); len(p) > size {
+		p = p[:size]
+	}
+	n, err := r.r.Read(p)
+	r.limiter.WaitN(n)
+	return n, err
+}
+
+type readCloser struct {
+	reader
+	c io.Closer
+}
+
+func (r *readCloser) Close() error {
+	return r.c.Close()
+}
+
+type writer struct {
+	limiter *Limiter
+	w       io.Writer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	written := 0
+	size := w.limiter.maxChunkSize()
+	for written < len(p) {
+		end := written + size
+		if end > len(p) {
+			end = len(p)
+		}
+		w.limiter.WaitN(end - written)
+		n, err := w.w.Write(p[written:end])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
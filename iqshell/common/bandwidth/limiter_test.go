@@ -0,0 +1,41 @@
+package bandwidth
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiterShared(t *testing.T) {
+	// 桶中初始有 1s 的令牌，4 个 reader 共读取 2000B，限速 1000B/s 时约需 1s
+	limiter := NewLimiter(1000)
+	start := time.Now()
+	wait := &sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			n, err := io.Copy(ioutil.Discard, limiter.Reader(bytes.NewReader(make([]byte, 500))))
+			if err != nil || n != 500 {
+				t.Errorf("copy error:%v size:%d", err, n)
+			}
+		}()
+	}
+	wait.Wait()
+
+	if duration := time.Since(start); duration < 800*time.Millisecond || duration > 3*time.Second {
+		t.Fatalf("limiter duration:%s not match", duration)
+	}
+}
+
+func TestLimiterNil(t *testing.T) {
+	var limiter *Limiter
+	r := bytes.NewReader(nil)
+	if limiter.Reader(r) != io.Reader(r) {
+		t.Fatal("nil limiter should not wrap reader")
+	}
+	limiter.WaitN(100)
+}
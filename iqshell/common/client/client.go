@@ -5,28 +5,60 @@ import (
 	"net/http"
 	"time"
 
+	sdkclient "github.com/qiniu/go-sdk/v7/client"
 	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/bandwidth"
 )
 
+var defaultTransport = &http.Transport{
+	Proxy: proxyFromConfig,
+	DialContext: (&net.Dialer{
+		Timeout:   20 * time.Second,
+		KeepAlive: 20 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          2000,
+	MaxIdleConnsPerHost:   1000,
+	ResponseHeaderTimeout: 60 * time.Second,
+	IdleConnTimeout:       15 * time.Second,
+	TLSHandshakeTimeout:   15 * time.Second,
+	ExpectContinueTimeout: 1 * time.Second,
+}
+
 var defaultClient = storage.Client{
 	Client: &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			DialContext: (&net.Dialer{
-				Timeout:   20 * time.Second,
-				KeepAlive: 20 * time.Second,
-			}).DialContext,
-			ForceAttemptHTTP2:     true,
-			MaxIdleConns:          2000,
-			MaxIdleConnsPerHost:   1000,
-			ResponseHeaderTimeout: 60 * time.Second,
-			IdleConnTimeout:       15 * time.Second,
-			TLSHandshakeTimeout:   15 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-		},
+		// 上传、下载的限速、读取超时及临时凭证的 session token 在 Transport 层统一处理
+		Transport: newTimeoutTransport(newSessionTokenTransport(bandwidth.NewTransport(defaultTransport))),
 	},
 }
 
 func DefaultStorageClient() storage.Client {
 	return defaultClient
 }
+
+// NewProbeClient 用于探测服务是否可用的 client，建立连接、TLS 握手及等待响应头的超时均为 timeout；
+// 代理及 TLS 配置和默认 client 相同，需在代理及 TLS 配置设置后创建
+func NewProbeClient(timeout time.Duration) *http.Client {
+	transport := defaultTransport.Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 20 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = timeout
+	transport.ResponseHeaderTimeout = timeout
+	return &http.Client{
+		Transport: transport,
+	}
+}
+
+// sharedTransports qshell、SDK 及 http 包默认 client 使用的 Transport，代理及 TLS 配置需要对所有请求生效
+func sharedTransports() []*http.Transport {
+	transports := []*http.Transport{defaultTransport}
+	for _, transport := range []http.RoundTripper{http.DefaultTransport, sdkclient.DefaultTransport} {
+		if t, ok := transport.(*http.Transport); ok {
+			transports = append(transports, t)
+		}
+	}
+	return transports
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+var (
+	proxyLock sync.RWMutex
+	// proxyFunc 为 nil 时使用环境变量 HTTP_PROXY、HTTPS_PROXY 及 NO_PROXY
+	proxyFunc func(*url.URL) (*url.URL, error)
+)
+
+// ParseProxy 解析代理地址，支持 http、https、socks5 及 socks5h，未指定 scheme 时为 http
+func ParseProxy(proxy string) (*url.URL, *data.CodeError) {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("invalid proxy:%s, %v", proxy, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, data.NewEmptyError().AppendDescF("invalid proxy:%s, scheme should be http, https, socks5 or socks5h", proxy)
+	}
+	if len(u.Hostname()) == 0 {
+		return nil, data.NewEmptyError().AppendDescF("invalid proxy:%s, host is empty", proxy)
+	}
+	return u, nil
+}
+
+// SetProxy 设置所有请求使用的代理，优先级高于环境变量 HTTP_PROXY 及 HTTPS_PROXY；
+// 环境变量 NO_PROXY 中的地址依然不使用代理；proxy 为空时仅使用环境变量中的代理
+func SetProxy(proxy string) *data.CodeError {
+	proxyLock.Lock()
+	defer proxyLock.Unlock()
+
+	if len(proxy) == 0 {
+		proxyFunc = nil
+		return nil
+	}
+
+	u, err := ParseProxy(proxy)
+	if err != nil {
+		return err
+	}
+	proxyCfg := httpproxy.FromEnvironment()
+	proxyCfg.HTTPProxy = u.String()
+	proxyCfg.HTTPSProxy = u.String()
+	proxyFunc = proxyCfg.ProxyFunc()
+
+	for _, t := range sharedTransports() {
+		t.Proxy = proxyFromConfig
+	}
+	return nil
+}
+
+func proxyFromConfig(req *http.Request) (*url.URL, error) {
+	proxyLock.RLock()
+	f := proxyFunc
+	proxyLock.RUnlock()
+
+	if f == nil {
+		return http.ProxyFromEnvironment(req)
+	}
+	return f(req.URL)
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetProxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.Host
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	t.Setenv("NO_PROXY", "no-proxy.example.com")
+	if err := SetProxy(proxy.URL); err != nil {
+		t.Fatal(err)
+	}
+	defer SetProxy("")
+
+	resp, err := DefaultStorageClient().Get("http://qshell-proxy.example.com/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent || proxiedHost != "qshell-proxy.example.com" {
+		t.Fatalf("request should be proxied, status:%d host:%s", resp.StatusCode, proxiedHost)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://no-proxy.example.com/a", nil)
+	if u, pErr := proxyFromConfig(req); pErr != nil || u != nil {
+		t.Fatalf("host in NO_PROXY should not be proxied, proxy:%v error:%v", u, pErr)
+	}
+}
+
+func TestParseProxy(t *testing.T) {
+	for _, proxy := range []string{"127.0.0.1:8080", "https://proxy.example.com", "socks5://127.0.0.1:1080"} {
+		if _, err := ParseProxy(proxy); err != nil {
+			t.Fatalf("proxy:%s should be valid, but:%v", proxy, err)
+		}
+	}
+	for _, proxy := range []string{"ftp://127.0.0.1", "http://"} {
+		if _, err := ParseProxy(proxy); err == nil {
+			t.Fatalf("proxy:%s should be invalid", proxy)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// SessionTokenHeader 临时凭证的 session token 通过此请求头发送
+const SessionTokenHeader = "X-Session-Token"
+
+var sessionToken atomic.Value
+
+// SetSessionToken 设置当前临时凭证的 session token，为空时不发送
+func SetSessionToken(token string) {
+	sessionToken.Store(token)
+}
+
+func getSessionToken() string {
+	token, _ := sessionToken.Load().(string)
+	return token
+}
+
+type sessionTokenTransport struct {
+	base http.RoundTripper
+}
+
+// newSessionTokenTransport 为携带签名的请求添加 session token；
+// 未签名的请求（如下载外部链接）不添加，避免 session token 泄露给第三方
+func newSessionTokenTransport(base http.RoundTripper) http.RoundTripper {
+	return &sessionTokenTransport{base: base}
+}
+
+func (t *sessionTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := getSessionToken()
+	if len(token) == 0 || len(req.Header.Get("Authorization")) == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	// RoundTripper 不能修改传入的请求
+	req = req.Clone(req.Context())
+	req.Header.Set(SessionTokenHeader, token)
+	return t.base.RoundTrip(req)
+}
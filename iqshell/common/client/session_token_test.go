@@ -0,0 +1,46 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionToken(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(SessionTokenHeader)
+	}))
+	defer server.Close()
+
+	SetSessionToken("token_test")
+	defer SetSessionToken("")
+
+	do := func(authorization string) {
+		req, err := http.NewRequest("GET", server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(authorization) > 0 {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := DefaultStorageClient().Client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if len(req.Header.Get(SessionTokenHeader)) > 0 {
+			t.Fatal("request passed to client should not be modified")
+		}
+	}
+
+	do("Qiniu ak:sign")
+	if received != "token_test" {
+		t.Fatal("signed request should carry session token, but:", received)
+	}
+
+	do("")
+	if len(received) > 0 {
+		t.Fatal("unsigned request should not carry session token, but:", received)
+	}
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+var readTimeout atomic.Int64
+
+// SetTimeout 设置 qshell client 的超时时间，为 0 时使用默认值：
+// connectTimeout 为建立连接的超时时间，默认 20s；readTimeout 为等待响应及读取响应 body 时两次读到数据的最大间隔，默认等待响应 60s，读取 body 不超时
+func SetTimeout(connectTimeout, readTimeout time.Duration) {
+	if connectTimeout > 0 {
+		defaultTransport.DialContext = (&net.Dialer{
+			Timeout:   connectTimeout,
+			KeepAlive: 20 * time.Second,
+		}).DialContext
+	}
+	if readTimeout > 0 {
+		defaultTransport.ResponseHeaderTimeout = readTimeout
+	}
+	setReadTimeout(readTimeout)
+}
+
+func setReadTimeout(timeout time.Duration) {
+	readTimeout.Store(int64(timeout))
+}
+
+type timeoutTransport struct {
+	base http.RoundTripper
+}
+
+// newTimeoutTransport 读取响应 body 时超过 readTimeout 未读到数据则取消请求，避免下载卡住；未设置 readTimeout 时不做处理
+func newTimeoutTransport(base http.RoundTripper) http.RoundTripper {
+	return &timeoutTransport{base: base}
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timeout := time.Duration(readTimeout.Load())
+	if timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil || resp == nil || resp.Body == nil {
+		cancel()
+		return resp, err
+	}
+	body := &timeoutReadCloser{
+		ReadCloser: resp.Body,
+		timeout:    timeout,
+		cancel:     cancel,
+	}
+	body.timer = time.AfterFunc(timeout, func() {
+		body.timedOut.Store(true)
+		cancel()
+	})
+	resp.Body = body
+	return resp, nil
+}
+
+type timeoutReadCloser struct {
+	io.ReadCloser
+	timer    *time.Timer
+	timeout  time.Duration
+	timedOut atomic.Bool
+	cancel   context.CancelFunc
+}
+
+func (r *timeoutReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	if err != nil && err != io.EOF && r.timedOut.Load() {
+		// 超时取消的请求按超时处理，而不是用户取消
+		err = &readTimeoutError{timeout: r.timeout}
+	}
+	return n, err
+}
+
+func (r *timeoutReadCloser) Close() error {
+	r.timer.Stop()
+	err := r.ReadCloser.Close()
+	r.cancel()
+	return err
+}
+
+type readTimeoutError struct {
+	timeout time.Duration
+}
+
+func (e *readTimeoutError) Error() string {
+	return "no data was read from response body in " + e.timeout.String()
+}
+
+func (e *readTimeoutError) Timeout() bool {
+	return true
+}
+
+func (e *readTimeoutError) Temporary() bool {
+	return true
+}
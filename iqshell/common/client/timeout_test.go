@@ -0,0 +1,43 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+func TestReadTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("part"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	setReadTimeout(50 * time.Millisecond)
+	defer setReadTimeout(0)
+
+	resp, err := DefaultStorageClient().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	start := time.Now()
+	_, rErr := io.ReadAll(resp.Body)
+	if rErr == nil || !data.ConvertError(rErr).IsTimeout() {
+		t.Fatalf("read stalled body should timeout, but:%v", rErr)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("read stalled body should not block")
+	}
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// SetTLS 设置所有请求的 TLS 配置：caFile 为自定义根证书文件（PEM 格式），在系统根证书的基础上添加；
+// insecureSkipVerify 为 true 时不校验服务端证书，仅用于测试
+func SetTLS(caFile string, insecureSkipVerify bool) *data.CodeError {
+	if len(caFile) == 0 && !insecureSkipVerify {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+	if len(caFile) > 0 {
+		pool, err := loadCAFile(caFile)
+		if err != nil {
+			return err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	for _, t := range sharedTransports() {
+		t.TLSClientConfig = tlsConfig.Clone()
+	}
+	return nil
+}
+
+func loadCAFile(caFile string) (*x509.CertPool, *data.CodeError) {
+	content, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("read ca file:%s error:%v", caFile, err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, data.NewEmptyError().AppendDescF("ca file:%s has no valid PEM certificate", caFile)
+	}
+	return pool, nil
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	defer func() {
+		for _, transport := range sharedTransports() {
+			transport.TLSClientConfig = nil
+		}
+	}()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caContent := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caContent, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetTLS(caFile, false); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := DefaultStorageClient().Get(server.URL)
+	if err != nil {
+		t.Fatalf("request with custom ca error:%v", err)
+	}
+	_ = resp.Body.Close()
+
+	invalidCAFile := filepath.Join(t.TempDir(), "invalid.pem")
+	if err := os.WriteFile(invalidCAFile, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetTLS(invalidCAFile, false); err == nil {
+		t.Fatal("invalid ca file should return error")
+	}
+}
@@ -15,6 +15,19 @@ type Config struct {
 	UseHttps    *data.Bool        `json:"use_https,omitempty"`
 	Hosts       *Hosts            `json:"hosts,omitempty"`
 	Log         *LogSetting       `json:"log"`
+	Metrics     *Metrics          `json:"metrics,omitempty"`
+
+	Region         *data.String `json:"region,omitempty"`           // 指定的区域 Id，不再查询空间所在的区域
+	DefaultRegion  *data.String `json:"default_region,omitempty"`   // 查询空间所在的区域失败时使用的区域 Id
+	RegionCacheTTL *data.Int    `json:"region_cache_ttl,omitempty"` // 空间所在区域的缓存时间，单位：秒
+
+	Proxy              *data.String `json:"proxy,omitempty"`                // 代理地址，优先级高于环境变量 HTTP_PROXY 及 HTTPS_PROXY
+	CAFile             *data.String `json:"ca_file,omitempty"`              // 自定义根证书文件
+	InsecureSkipVerify *data.Bool   `json:"insecure_skip_verify,omitempty"` // 不校验服务端证书，仅用于测试
+
+	ConnectTimeout *data.Int `json:"connect_timeout,omitempty"` // 建立连接的超时时间，单位：秒
+	ReadTimeout    *data.Int `json:"read_timeout,omitempty"`    // 等待响应及读取响应数据的超时时间，单位：秒
+	OverallTimeout *data.Int `json:"overall_timeout,omitempty"` // 单个文件上传、下载、抓取的总超时时间，单位：秒
 }
 
 func (c *Config) IsUseHttps() bool {
@@ -28,6 +41,13 @@ func (c *Config) HasCredentials() bool {
 	return c.Credentials != nil && len(c.Credentials.AccessKey) > 0 && c.Credentials.SecretKey != nil
 }
 
+func (c *Config) IsInsecureSkipVerify() bool {
+	if c.InsecureSkipVerify == nil {
+		return false
+	}
+	return c.InsecureSkipVerify.Value()
+}
+
 func (c *Config) GetPortalHost() string {
 	var portalHost string
 	if hosts := c.Hosts; hosts != nil {
@@ -40,8 +60,15 @@ func (c *Config) GetPortalHost() string {
 }
 
 func (c *Config) GetRegion() *storage.Region {
-	if len(c.Hosts.Api) == 0 && len(c.Hosts.Rs) == 0 && len(c.Hosts.Rsf) == 0 &&
-		len(c.Hosts.Io) == 0 && len(c.Hosts.Up) == 0 {
+	if regionId := c.Region.Value(); len(regionId) > 0 {
+		if region, ok := storage.GetRegionByID(storage.RegionID(regionId)); ok {
+			return &region
+		}
+		return nil
+	}
+
+	// 仅配置了部分服务地址时，空间所在区域的其他服务地址需要查询
+	if c.Hosts == nil || !c.Hosts.IsRegionComplete() {
 		return nil
 	}
 
@@ -66,6 +93,16 @@ func (c *Config) Merge(from *Config) {
 		c.Credentials = from.Credentials
 	}
 
+	c.Region = data.GetNotEmptyStringIfExist(c.Region, from.Region)
+	c.DefaultRegion = data.GetNotEmptyStringIfExist(c.DefaultRegion, from.DefaultRegion)
+	c.RegionCacheTTL = data.GetNotEmptyIntIfExist(c.RegionCacheTTL, from.RegionCacheTTL)
+	c.Proxy = data.GetNotEmptyStringIfExist(c.Proxy, from.Proxy)
+	c.CAFile = data.GetNotEmptyStringIfExist(c.CAFile, from.CAFile)
+	c.InsecureSkipVerify = data.GetNotEmptyBoolIfExist(c.InsecureSkipVerify, from.InsecureSkipVerify)
+	c.ConnectTimeout = data.GetNotEmptyIntIfExist(c.ConnectTimeout, from.ConnectTimeout)
+	c.ReadTimeout = data.GetNotEmptyIntIfExist(c.ReadTimeout, from.ReadTimeout)
+	c.OverallTimeout = data.GetNotEmptyIntIfExist(c.OverallTimeout, from.OverallTimeout)
+
 	if from.Hosts != nil {
 		if c.Hosts == nil {
 			c.Hosts = &Hosts{}
@@ -79,6 +116,13 @@ func (c *Config) Merge(from *Config) {
 		}
 		c.Log.merge(from.Log)
 	}
+
+	if from.Metrics != nil {
+		if c.Metrics == nil {
+			c.Metrics = &Metrics{}
+		}
+		c.Metrics.merge(from.Metrics)
+	}
 }
 
 func (c *Config) String() string {
@@ -10,7 +10,15 @@ func GetGlobal() *Config {
 			AccessKey: getAccessKey(ConfigTypeGlobal),
 			SecretKey: []byte(getSecretKey(ConfigTypeGlobal)),
 		},
-		UseHttps: getIsUseHttps(ConfigTypeGlobal),
+		UseHttps:           getIsUseHttps(ConfigTypeGlobal),
+		DefaultRegion:      getDefaultRegion(ConfigTypeGlobal),
+		RegionCacheTTL:     getRegionCacheTTL(ConfigTypeGlobal),
+		Proxy:              getProxy(ConfigTypeGlobal),
+		CAFile:             getCAFile(ConfigTypeGlobal),
+		InsecureSkipVerify: getInsecureSkipVerify(ConfigTypeGlobal),
+		ConnectTimeout:     getTimeout(ConfigTypeGlobal, localKeyConnectTimeout),
+		ReadTimeout:        getTimeout(ConfigTypeGlobal, localKeyReadTimeout),
+		OverallTimeout:     getTimeout(ConfigTypeGlobal, localKeyOverallTimeout),
 		Hosts: &Hosts{
 			UC:  GetUcHosts(ConfigTypeGlobal),
 			Api: GetApiHosts(ConfigTypeGlobal),
@@ -19,5 +27,6 @@ func GetGlobal() *Config {
 			Io:  GetIoHosts(ConfigTypeGlobal),
 			Up:  GetUpHosts(ConfigTypeGlobal),
 		},
+		Metrics: getMetrics(ConfigTypeGlobal),
 	}
 }
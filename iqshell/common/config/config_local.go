@@ -49,9 +49,35 @@ var (
 	// USE HTTPS
 	localKeyIsUseHttps = []string{"use_https"}
 
+	// 区域
+	localKeyDefaultRegion  = []string{"default_region"}
+	localKeyRegionCacheTTL = []string{"region_cache_ttl"}
+
+	// 代理
+	localKeyProxy = []string{"proxy"}
+
+	// TLS
+	localKeyCAFile             = []string{"ca_file"}
+	localKeyInsecureSkipVerify = []string{"insecure_skip_verify"}
+
+	// 超时
+	localKeyConnectTimeout = []string{"connect_timeout"}
+	localKeyReadTimeout    = []string{"read_timeout"}
+	localKeyOverallTimeout = []string{"overall_timeout"}
+
 	// 账户密钥信息
 	localKeyAccessKey = []string{"access_key"}
 	localKeySecretKey = []string{"secret_key"}
+
+	// 凭证提供方
+	localKeyCredentialProviderType    = []string{"credential_provider.type"}
+	localKeyCredentialProviderCommand = []string{"credential_provider.command"}
+	localKeyCredentialProviderUrl     = []string{"credential_provider.url"}
+
+	// 指标上报
+	localKeyMetricsEndpoint = []string{"metrics.endpoint"}
+	localKeyMetricsPrefix   = []string{"metrics.prefix"}
+	localKeyMetricsInterval = []string{"metrics.interval"}
 )
 
 var (
@@ -98,6 +124,29 @@ func GetCredentials(configType ConfigType) auth.Credentials {
 	}
 }
 
+// CredentialProvider 从外部获取短期凭证的配置
+type CredentialProvider struct {
+	Type    string // 类型：command 或 http，为空表示不使用
+	Command string // type 为 command 时执行的命令，命令的标准输出为凭证 JSON
+	Url     string // type 为 http 时请求的地址，响应的 body 为凭证 JSON
+}
+
+func GetCredentialProvider(configType ConfigType) CredentialProvider {
+	return CredentialProvider{
+		Type:    getStringValue(configType, localKeyCredentialProviderType).Value(),
+		Command: getStringValue(configType, localKeyCredentialProviderCommand).Value(),
+		Url:     getStringValue(configType, localKeyCredentialProviderUrl).Value(),
+	}
+}
+
+func getMetrics(configType ConfigType) *Metrics {
+	return &Metrics{
+		Endpoint: getStringValue(configType, localKeyMetricsEndpoint),
+		Prefix:   getStringValue(configType, localKeyMetricsPrefix),
+		Interval: getIntValueFromLocal(getVipersWithConfigType(configType), localKeyMetricsInterval),
+	}
+}
+
 func getAccessKey(configType ConfigType) string {
 	return getStringValue(configType, localKeyAccessKey).Value()
 }
@@ -110,6 +159,30 @@ func getIsUseHttps(configType ConfigType) *data.Bool {
 	return getBoolValue(configType, localKeyIsUseHttps)
 }
 
+func getDefaultRegion(configType ConfigType) *data.String {
+	return getStringValue(configType, localKeyDefaultRegion)
+}
+
+func getRegionCacheTTL(configType ConfigType) *data.Int {
+	return getIntValueFromLocal(getVipersWithConfigType(configType), localKeyRegionCacheTTL)
+}
+
+func getProxy(configType ConfigType) *data.String {
+	return getStringValue(configType, localKeyProxy)
+}
+
+func getCAFile(configType ConfigType) *data.String {
+	return getStringValue(configType, localKeyCAFile)
+}
+
+func getInsecureSkipVerify(configType ConfigType) *data.Bool {
+	return getBoolValue(configType, localKeyInsecureSkipVerify)
+}
+
+func getTimeout(configType ConfigType, localKey []string) *data.Int {
+	return getIntValueFromLocal(getVipersWithConfigType(configType), localKey)
+}
+
 func getStringValue(configType ConfigType, localKey []string) *data.String {
 	return getStringValueFromLocal(getVipersWithConfigType(configType), localKey)
 }
@@ -119,8 +192,11 @@ func getBoolValue(configType ConfigType, localKey []string) *data.Bool {
 }
 
 func getHostsFromLocal(configType ConfigType, hostKey []string, hostsKey []string) []string {
+	return getHostsFromVipers(getVipersWithConfigType(configType), hostKey, hostsKey)
+}
+
+func getHostsFromVipers(vipers []*viper.Viper, hostKey []string, hostsKey []string) []string {
 	var hosts []string
-	vipers := getVipersWithConfigType(configType)
 	hosts = getStringArrayValueFromLocal(vipers, hostsKey)
 	if hosts == nil {
 		host := getStringValueFromLocal(vipers, hostKey)
@@ -8,7 +8,15 @@ func GetUser() *Config {
 			AccessKey: getAccessKey(ConfigTypeUser),
 			SecretKey: []byte(getSecretKey(ConfigTypeUser)),
 		},
-		UseHttps: getIsUseHttps(ConfigTypeUser),
+		UseHttps:           getIsUseHttps(ConfigTypeUser),
+		DefaultRegion:      getDefaultRegion(ConfigTypeUser),
+		RegionCacheTTL:     getRegionCacheTTL(ConfigTypeUser),
+		Proxy:              getProxy(ConfigTypeUser),
+		CAFile:             getCAFile(ConfigTypeUser),
+		InsecureSkipVerify: getInsecureSkipVerify(ConfigTypeUser),
+		ConnectTimeout:     getTimeout(ConfigTypeUser, localKeyConnectTimeout),
+		ReadTimeout:        getTimeout(ConfigTypeUser, localKeyReadTimeout),
+		OverallTimeout:     getTimeout(ConfigTypeUser, localKeyOverallTimeout),
 		Hosts: &Hosts{
 			UC:  GetUcHosts(ConfigTypeUser),
 			Api: GetApiHosts(ConfigTypeUser),
@@ -17,5 +25,6 @@ func GetUser() *Config {
 			Io:  GetIoHosts(ConfigTypeUser),
 			Up:  GetUpHosts(ConfigTypeUser),
 		},
+		Metrics: getMetrics(ConfigTypeUser),
 	}
 }
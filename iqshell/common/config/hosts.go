@@ -1,6 +1,15 @@
 package config
 
-import "github.com/qiniu/qshell/v2/iqshell/common/utils"
+import (
+	"net/url"
+	"strings"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/spf13/viper"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
 
 type Hosts struct {
 	UC     []string `json:"uc,omitempty"`
@@ -40,6 +49,37 @@ func (h *Hosts) GetOnePortal() string {
 	return h.Portal
 }
 
+// IsRegionComplete api/rs/rsf/io/up 是否均已配置
+func (h *Hosts) IsRegionComplete() bool {
+	return len(h.Api) > 0 && len(h.Rs) > 0 && len(h.Rsf) > 0 && len(h.Io) > 0 && len(h.Up) > 0
+}
+
+// OverrideRegion 使用配置的服务地址替换 region 中对应的服务地址，不修改 region 本身
+func (h *Hosts) OverrideRegion(region *storage.Region) *storage.Region {
+	if h == nil || region == nil {
+		return region
+	}
+
+	r := *region
+	if len(h.Up) > 0 {
+		r.SrcUpHosts = getRealHosts(h.Up)
+		r.CdnUpHosts = getRealHosts(h.Up)
+	}
+	if rs := h.GetOneRs(); len(rs) > 0 {
+		r.RsHost = rs
+	}
+	if rsf := h.GetOneRsf(); len(rsf) > 0 {
+		r.RsfHost = rsf
+	}
+	if api := h.GetOneApi(); len(api) > 0 {
+		r.ApiHost = api
+	}
+	if io := h.GetOneIo(); len(io) > 0 {
+		r.IovipHost = io
+	}
+	return &r
+}
+
 func getOneHostFromStringArray(hosts []string) string {
 	hosts = getRealHosts(hosts)
 	if len(hosts) > 0 {
@@ -94,3 +134,78 @@ func (h *Hosts) merge(from *Hosts) {
 		h.Portal = from.Portal
 	}
 }
+
+// Check 检查所有的服务地址，地址格式为 host[:port]，可以包含 http:// 或 https://，但不能包含路径及参数
+func (h *Hosts) Check() *data.CodeError {
+	for name, hosts := range map[string][]string{
+		"uc":  h.UC,
+		"api": h.Api,
+		"rs":  h.Rs,
+		"rsf": h.Rsf,
+		"io":  h.Io,
+		"up":  h.Up,
+	} {
+		for _, host := range hosts {
+			if err := checkHost(host); err != nil {
+				return data.NewEmptyError().AppendDescF("%s host:%s is invalid, %v", name, host, err)
+			}
+		}
+	}
+	return nil
+}
+
+func checkHost(host string) *data.CodeError {
+	hostUrl := host
+	if !strings.Contains(hostUrl, "://") {
+		hostUrl = "http://" + hostUrl
+	}
+	u, err := url.Parse(hostUrl)
+	if err != nil {
+		return data.ConvertError(err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return data.NewEmptyError().AppendDesc("scheme should be http or https")
+	}
+	if len(u.Hostname()) == 0 {
+		return data.NewEmptyError().AppendDesc("host is empty")
+	}
+	if (len(u.Path) > 0 && u.Path != "/") || len(u.RawQuery) > 0 || len(u.Fragment) > 0 {
+		return data.NewEmptyError().AppendDesc("path and query are not allowed")
+	}
+	return nil
+}
+
+// LoadHostsFile 加载服务地址文件，文件为 JSON，格式同配置文件中的 hosts，如：{"rs": "rs.example.com", "ucs": ["uc.example.com"]}
+func LoadHostsFile(path string) (*Hosts, *data.CodeError) {
+	vip := viper.New()
+	vip.SetConfigFile(path)
+	vip.SetConfigType("json")
+	if err := vip.ReadInConfig(); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("read hosts file:%s error:%v", path, err)
+	}
+
+	vipers := []*viper.Viper{vip}
+	getHosts := func(hostKey []string, hostsKey []string) []string {
+		return getHostsFromVipers(vipers, trimHostsKeyPrefix(hostKey), trimHostsKeyPrefix(hostsKey))
+	}
+	hosts := &Hosts{
+		UC:  getRealHosts(getHosts(localKeyHostUc, localKeyHostUcs)),
+		Api: getRealHosts(getHosts(localKeyHostApi, localKeyHostApis)),
+		Rs:  getRealHosts(getHosts(localKeyHostRs, localKeyHostRses)),
+		Rsf: getRealHosts(getHosts(localKeyHostRsf, localKeyHostRsfs)),
+		Io:  getRealHosts(getHosts(localKeyHostIo, localKeyHostIos)),
+		Up:  getRealHosts(getHosts(localKeyHostUp, localKeyHostUps)),
+	}
+	if err := hosts.Check(); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("hosts file:%s, %v", path, err)
+	}
+	return hosts, nil
+}
+
+func trimHostsKeyPrefix(keys []string) []string {
+	ret := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ret = append(ret, strings.TrimPrefix(key, "hosts."))
+	}
+	return ret
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+func TestHostsCheck(t *testing.T) {
+	for _, host := range []string{"rs.example.com", "rs.example.com:8080", "https://rs.example.com", "http://10.0.0.1/"} {
+		if err := (&Hosts{Rs: []string{host}}).Check(); err != nil {
+			t.Fatalf("host:%s should be valid, but:%v", host, err)
+		}
+	}
+	for _, host := range []string{"ftp://rs.example.com", "http://", "rs.example.com/path", "rs.example.com?a=b"} {
+		if err := (&Hosts{Rs: []string{host}}).Check(); err == nil {
+			t.Fatalf("host:%s should be invalid", host)
+		}
+	}
+}
+
+func TestLoadHostsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts.json")
+	content := `{"rs":"https://rs.example.com","ups":["up1.example.com","up2.example.com"],"uc_host":"uc.example.com"}`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hosts, err := LoadHostsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hosts.GetOneRs() != "rs.example.com" || hosts.GetOneUc() != "uc.example.com" || len(hosts.Up) != 2 {
+		t.Fatalf("load hosts file error, hosts:%+v", hosts)
+	}
+
+	region := hosts.OverrideRegion(&storage.Region{RsHost: "rs.qiniu.com", RsfHost: "rsf.qiniu.com"})
+	if region.RsHost != "rs.example.com" || region.RsfHost != "rsf.qiniu.com" || region.SrcUpHosts[1] != "up2.example.com" {
+		t.Fatalf("override region error, region:%+v", region)
+	}
+}
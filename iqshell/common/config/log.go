@@ -3,19 +3,25 @@ package config
 import (
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 )
 
 type LogSetting struct {
-	LogLevel  *data.String `json:"log_level,omitempty"`
-	LogFile   *data.String `json:"log_file,omitempty"`
-	LogRotate *data.Int    `json:"log_rotate,omitempty"`
-	LogStdout *data.Bool   `json:"log_stdout,omitempty"`
+	LogLevel   *data.String `json:"log_level,omitempty"`
+	LogFile    *data.String `json:"log_file,omitempty"`
+	LogRotate  *data.Int    `json:"log_rotate,omitempty"`
+	LogStdout  *data.Bool   `json:"log_stdout,omitempty"`
+	LogJson    *data.Bool   `json:"log_json,omitempty"`     // 日志文件每行为一个 JSON 对象
+	LogMaxSize *data.String `json:"log_max_size,omitempty"` // 日志文件超过此大小时轮转，如：100m，为空不限制
 }
 
 func (l *LogSetting) Check() *data.CodeError {
 	if l.LogRotate == nil {
 		l.LogRotate = data.NewInt(7)
 	}
+	if _, err := l.GetLogMaxSize(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -39,6 +45,27 @@ func (l *LogSetting) merge(from *LogSetting) {
 	l.LogFile = data.GetNotEmptyStringIfExist(l.LogFile, from.LogFile)
 	l.LogRotate = data.GetNotEmptyIntIfExist(l.LogRotate, from.LogRotate)
 	l.LogStdout = data.GetNotEmptyBoolIfExist(l.LogStdout, from.LogStdout)
+	l.LogJson = data.GetNotEmptyBoolIfExist(l.LogJson, from.LogJson)
+	l.LogMaxSize = data.GetNotEmptyStringIfExist(l.LogMaxSize, from.LogMaxSize)
+}
+
+func (l *LogSetting) IsLogJson() bool {
+	if l.LogJson == nil {
+		return false
+	}
+	return l.LogJson.Value()
+}
+
+// GetLogMaxSize 日志文件轮转的大小，单位：B，0 表示不按大小轮转
+func (l *LogSetting) GetLogMaxSize() (int64, *data.CodeError) {
+	if data.Empty(l.LogMaxSize) {
+		return 0, nil
+	}
+	size, err := utils.ParseFileSize(l.LogMaxSize.Value())
+	if err != nil {
+		return 0, data.NewEmptyError().AppendDescF("invalid log_max_size, %v", err)
+	}
+	return size, nil
 }
 
 const (
@@ -54,17 +81,24 @@ func (l *LogSetting) GetLogLevel() (logLevel int) {
 		return log.LevelNone
 	}
 
-	switch l.LogLevel.Value() {
+	if logLevel, ok := ParseLogLevel(l.LogLevel.Value()); ok {
+		return logLevel
+	}
+	return log.LevelNone
+}
+
+// ParseLogLevel 解析日志级别：debug、info、warn、error，其他值 ok 为 false
+func ParseLogLevel(level string) (logLevel int, ok bool) {
+	switch level {
 	case DebugKey:
-		logLevel = log.LevelDebug
+		return log.LevelDebug, true
 	case InfoKey:
-		logLevel = log.LevelInfo
+		return log.LevelInfo, true
 	case WarnKey:
-		logLevel = log.LevelWarning
+		return log.LevelWarning, true
 	case ErrorKey:
-		logLevel = log.LevelError
+		return log.LevelError, true
 	default:
-		logLevel = log.LevelNone
+		return log.LevelNone, false
 	}
-	return
 }
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+const (
+	MetricsSchemeStatsD = "statsd"
+	MetricsSchemeUdp    = "udp"
+	MetricsSchemeHttp   = "http"
+	MetricsSchemeHttps  = "https"
+)
+
+// Metrics 批量操作的指标上报配置
+type Metrics struct {
+	Endpoint *data.String `json:"endpoint,omitempty"` // 上报地址，statsd://host:port 或 Prometheus pushgateway 地址 http(s)://host:port，为空不上报
+	Prefix   *data.String `json:"prefix,omitempty"`   // 指标名前缀，同时作为 pushgateway 的 job 名
+	Interval *data.Int    `json:"interval,omitempty"` // 上报间隔，单位：秒
+}
+
+func (m *Metrics) merge(from *Metrics) {
+	if from == nil {
+		return
+	}
+
+	m.Endpoint = data.GetNotEmptyStringIfExist(m.Endpoint, from.Endpoint)
+	m.Prefix = data.GetNotEmptyStringIfExist(m.Prefix, from.Prefix)
+	m.Interval = data.GetNotEmptyIntIfExist(m.Interval, from.Interval)
+}
+
+func (m *Metrics) Enable() bool {
+	return m != nil && data.NotEmpty(m.Endpoint)
+}
+
+func (m *Metrics) Check() *data.CodeError {
+	if !m.Enable() {
+		return nil
+	}
+
+	if _, err := m.GetEndpointURL(); err != nil {
+		return err
+	}
+	if m.Interval.Value() < 0 {
+		return data.NewEmptyError().AppendDescF("metrics interval can't be negative, but is:%d", m.Interval.Value())
+	}
+	return nil
+}
+
+// GetEndpointURL 解析上报地址，scheme 仅支持 statsd、udp、http 及 https
+func (m *Metrics) GetEndpointURL() (*url.URL, *data.CodeError) {
+	endpoint := m.Endpoint.Value()
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("invalid metrics endpoint:%s, %v", endpoint, err)
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case MetricsSchemeStatsD, MetricsSchemeUdp, MetricsSchemeHttp, MetricsSchemeHttps:
+	default:
+		return nil, data.NewEmptyError().AppendDescF("invalid metrics endpoint:%s, should be like statsd://127.0.0.1:8125 or http://127.0.0.1:9091", endpoint)
+	}
+	if len(u.Host) == 0 {
+		return nil, data.NewEmptyError().AppendDescF("invalid metrics endpoint:%s, host is empty", endpoint)
+	}
+	return u, nil
+}
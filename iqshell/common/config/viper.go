@@ -30,6 +30,18 @@ func getStringArrayValueFromLocal(vipers []*viper.Viper, localKey []string) []st
 	return value
 }
 
+func getIntValueFromLocal(vipers []*viper.Viper, localKey []string) *data.Int {
+	var value *data.Int
+	getValueFromLocal(vipers, localKey, func(v interface{}) (stop bool) {
+		if v == nil {
+			return false
+		}
+		value = data.NewInt(cast.ToInt(v))
+		return true
+	})
+	return value
+}
+
 func getBoolValueFromLocal(vipers []*viper.Viper, localKey []string) *data.Bool {
 	var value *data.Bool
 	getValueFromLocal(vipers, localKey, func(v interface{}) (stop bool) {
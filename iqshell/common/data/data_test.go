@@ -36,3 +36,65 @@ func TestData(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSetCmdStatusByWorkCount(t *testing.T) {
+	defer SetCmdStatus(StatusOK)
+
+	cases := []struct {
+		workCount  int64
+		errorCount int64
+		status     int
+	}{
+		{workCount: 10, errorCount: 0, status: StatusOK},
+		{workCount: 10, errorCount: 3, status: StatusPartError},
+		{workCount: 10, errorCount: 10, status: StatusError},
+	}
+	for _, c := range cases {
+		SetCmdStatus(StatusOK)
+		SetCmdStatusByWorkCount(c.workCount, c.errorCount)
+		if s := GetCmdStatus(); s != c.status {
+			t.Fatalf("work:%d error:%d, status should be %d but %d", c.workCount, c.errorCount, c.status, s)
+		}
+	}
+
+	// 状态只升级不降级
+	SetCmdStatusError()
+	SetCmdStatusByWorkCount(10, 3)
+	if s := GetCmdStatus(); s != StatusError {
+		t.Fatalf("status should keep %d but %d", StatusError, s)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestErrorType(t *testing.T) {
+	if !NewErrorWithCode(612).IsNotFound() || !NewErrorWithCode(631).IsNotFound() {
+		t.Fatal("612/631 should be not found")
+	}
+	if !NewErrorWithCode(573).IsRateLimited() || NewErrorWithCode(573).IsRetryable() {
+		t.Fatal("573 should be rate limited and not retryable")
+	}
+	if !NewErrorWithCode(401).IsAuthFailed() || NewErrorWithCode(401).IsRetryable() {
+		t.Fatal("401 should be auth failed and not retryable")
+	}
+	if !NewErrorWithCode(630).IsQuotaExceeded() {
+		t.Fatal("630 should be quota exceeded")
+	}
+	if !NewErrorWithCode(502).IsRetryable() || NewErrorWithCode(579).IsRetryable() {
+		t.Fatal("502 should be retryable, 579 should not")
+	}
+	if !NewError(0, "read: connection reset by peer").IsRetryable() {
+		t.Fatal("connection reset should be retryable")
+	}
+	if err := ConvertError(fmt.Errorf("get: %w", timeoutError{})); !err.IsTimeout() || err.Code != ErrorCodeTimeout {
+		t.Fatal("net timeout should be converted to timeout error")
+	}
+	var nilErr *CodeError
+	if nilErr.IsNotFound() || nilErr.IsRetryable() {
+		t.Fatal("nil error should not match any type")
+	}
+}
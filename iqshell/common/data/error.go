@@ -7,12 +7,19 @@ import (
 )
 
 var (
-	ErrorCodeUnknown       = -10000
-	ErrorCodeCancel        = -10001
-	ErrorCodeParamNotExist = -11000
-	ErrorCodeParamMissing  = -11001
-	ErrorCodeLineHeader    = -11002
-	ErrorCodeAlreadyDone   = -15000
+	ErrorCodeUnknown            = -10000
+	ErrorCodeCancel             = -10001
+	ErrorCodeParamNotExist      = -11000
+	ErrorCodeParamMissing       = -11001
+	ErrorCodeLineHeader         = -11002
+	ErrorCodeLineInvalid        = -11003 // 输入行格式错误，如：CSV 引号不匹配、JSON 无法解析
+	ErrorCodeColumnMissing      = -11004 // 输入文件的标题行中缺少必须的列，所有的行都无法处理
+	ErrorCodeCredentialsExpired = -12000 // 临时凭证已过期
+	ErrorCodeAlreadyDone        = -15000
+	ErrorCodeSkipByFilter       = -15001
+	ErrorCodeSkipByWorker       = -15002 // worker 执行时根据资源状态跳过，如：归档文件已解冻
+	ErrorCodeSkipByExist        = -15003 // 目标文件已存在
+	ErrorCodeSkipByResume       = -15004 // 上次执行已成功，参考 --resume
 )
 
 var (
@@ -29,6 +36,8 @@ func ConvertError(err error) *CodeError {
 		rErr.Code = ErrorCodeCancel
 	} else if hErr, ok := err.(interface{ HttpCode() int }); ok {
 		rErr.Code = hErr.HttpCode()
+	} else if code := networkErrorCode(err); code != 0 {
+		rErr.Code = code
 	}
 	return rErr
 }
@@ -126,6 +135,15 @@ func (c *CodeError) Error() string {
 	return fmt.Sprintf("【%d】%s", c.Code, c.Desc)
 }
 
+// IsSkipped 是否为主动跳过，如：不满足过滤条件、目标已存在、上次已执行成功等，不属于失败
+func (c *CodeError) IsSkipped() bool {
+	if c == nil {
+		return false
+	}
+	return c.Code == ErrorCodeSkipByFilter || c.Code == ErrorCodeSkipByWorker ||
+		c.Code == ErrorCodeSkipByExist || c.Code == ErrorCodeSkipByResume
+}
+
 func (c *CodeError) IsCancel() bool {
 	if c == nil {
 		return false
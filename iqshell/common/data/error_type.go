@@ -0,0 +1,144 @@
+package data
+
+import (
+	"errors"
+	"net"
+	"strings"
+)
+
+// 七牛服务端错误码，参考：https://developer.qiniu.com/kodo/3928/error-responses
+var (
+	ErrorCodeBadRequest       = 400
+	ErrorCodeAuthFailed       = 401
+	ErrorCodeForbidden        = 403
+	ErrorCodeNotFound         = 404
+	ErrorCodeTooManyRequests  = 429
+	ErrorCodeRateLimited      = 573
+	ErrorCodeCallbackFailed   = 579
+	ErrorCodeResourceNotFound = 612
+	ErrorCodeResourceExists   = 614
+	ErrorCodeBucketQuota      = 630
+	ErrorCodeBucketNotFound   = 631
+)
+
+// 网络错误码，由 ConvertError 根据 error 的类型设置
+var (
+	ErrorCodeTimeout = -10002
+	ErrorCodeNetwork = -10003
+)
+
+func networkErrorCode(err error) int {
+	var nErr net.Error
+	if errors.As(err, &nErr) {
+		if nErr.Timeout() {
+			return ErrorCodeTimeout
+		}
+		return ErrorCodeNetwork
+	}
+	return 0
+}
+
+// IsNotFound 资源或空间不存在
+func (c *CodeError) IsNotFound() bool {
+	if c == nil {
+		return false
+	}
+	return c.Code == ErrorCodeNotFound || c.Code == ErrorCodeResourceNotFound || c.Code == ErrorCodeBucketNotFound
+}
+
+// IsAlreadyExists 目标资源已存在
+func (c *CodeError) IsAlreadyExists() bool {
+	if c == nil {
+		return false
+	}
+	return c.Code == ErrorCodeResourceExists
+}
+
+// IsRateLimited 请求被限流
+func (c *CodeError) IsRateLimited() bool {
+	if c == nil {
+		return false
+	}
+	return c.Code == ErrorCodeRateLimited || c.Code == ErrorCodeTooManyRequests
+}
+
+// IsQuotaExceeded 超出配额，比如：空间数量达到上限
+func (c *CodeError) IsQuotaExceeded() bool {
+	if c == nil {
+		return false
+	}
+	return c.Code == ErrorCodeBucketQuota
+}
+
+// IsAuthFailed 认证失败，比如：AK/SK 错误、Token 过期
+func (c *CodeError) IsAuthFailed() bool {
+	if c == nil {
+		return false
+	}
+	return c.Code == ErrorCodeAuthFailed
+}
+
+// IsForbidden 没有操作权限
+func (c *CodeError) IsForbidden() bool {
+	if c == nil {
+		return false
+	}
+	return c.Code == ErrorCodeForbidden
+}
+
+// IsClientError 请求错误（4xx），重试也不会成功
+func (c *CodeError) IsClientError() bool {
+	if c == nil {
+		return false
+	}
+	return c.Code >= 400 && c.Code < 500
+}
+
+// IsServerError 服务端错误（5xx），不包含限流及回调失败
+func (c *CodeError) IsServerError() bool {
+	if c == nil {
+		return false
+	}
+	return c.Code >= 500 && c.Code < 600 && !c.IsRateLimited() && c.Code != ErrorCodeCallbackFailed
+}
+
+// IsTimeout 请求超时
+func (c *CodeError) IsTimeout() bool {
+	if c == nil {
+		return false
+	}
+	if c.Code == ErrorCodeTimeout {
+		return true
+	}
+	// 没有错误码的错误只能根据描述判断
+	return c.Code <= 0 && strings.Contains(strings.ToLower(c.Desc), "timeout")
+}
+
+// IsNetworkError 网络错误，比如：连接重置、连接被拒绝，包含超时
+func (c *CodeError) IsNetworkError() bool {
+	if c == nil {
+		return false
+	}
+	if c.Code == ErrorCodeNetwork || c.IsTimeout() {
+		return true
+	}
+	if c.Code > 0 {
+		return false
+	}
+	desc := strings.ToLower(c.Desc)
+	for _, s := range []string{"connection reset", "connection refused", "broken pipe", "unexpected eof"} {
+		if strings.Contains(desc, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRetryable 是否为可以重试的临时错误：网络错误及 5xx 服务端错误；
+// 4xx 及 6xx 等确定性错误不重试，限流错误由 flow 的 Limit 处理，不在此重试
+func (c *CodeError) IsRetryable() bool {
+	if c == nil || c.IsCancel() {
+		return false
+	}
+	return c.IsServerError() || c.IsNetworkError()
+}
@@ -7,6 +7,7 @@ const (
 	StatusOK         = 0 // process success
 	StatusError      = 1 // process error
 	StatusUserCancel = 2 // 用户取消
+	StatusPartError  = 3 // 批量任务部分失败
 )
 
 var (
@@ -28,6 +29,25 @@ func SetCmdStatusError() {
 	SetCmdStatus(StatusError)
 }
 
+// SetCmdStatusByWorkCount 根据批量任务的执行结果设置命令状态，
+// 没有失败时状态不变，全部失败时为 StatusError，部分失败时为 StatusPartError；状态只升级不降级，已为 StatusError 时不再改为 StatusPartError
+func SetCmdStatusByWorkCount(workCount, errorCount int64) {
+	if errorCount <= 0 {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if cmdStatus == StatusUserCancel || cmdStatus == StatusError {
+		return
+	}
+	if errorCount >= workCount {
+		cmdStatus = StatusError
+	} else {
+		cmdStatus = StatusPartError
+	}
+}
+
 func SetCmdStatusUserCancel() {
 	SetCmdStatus(StatusUserCancel)
 }
@@ -0,0 +1,54 @@
+package data
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	OutputFormatText = "text" // 默认格式，便于阅读
+	OutputFormatJson = "json" // JSON Lines 格式，每行一个 JSON 对象，便于脚本解析
+	OutputFormatCSV  = "csv"  // CSV 格式，仅列举命令及 cdnflux 支持，其他命令指定时报错
+)
+
+var (
+	outputFormatMu sync.RWMutex
+	outputFormat   = OutputFormatText
+
+	hideSummary atomic.Bool
+)
+
+func SetOutputFormat(format string) {
+	outputFormatMu.Lock()
+	defer outputFormatMu.Unlock()
+
+	if len(format) == 0 {
+		format = OutputFormatText
+	}
+	outputFormat = format
+}
+
+func GetOutputFormat() string {
+	outputFormatMu.RLock()
+	defer outputFormatMu.RUnlock()
+
+	return outputFormat
+}
+
+func IsOutputFormatJson() bool {
+	return GetOutputFormat() == OutputFormatJson
+}
+
+func IsOutputFormatCSV() bool {
+	return GetOutputFormat() == OutputFormatCSV
+}
+
+// SetShowSummary 设置批量操作结束时是否输出汇总信息
+func SetShowSummary(show bool) {
+	hideSummary.Store(!show)
+}
+
+// IsShowSummary 批量操作结束时是否输出汇总信息，默认输出
+func IsShowSummary() bool {
+	return !hideSummary.Load()
+}
@@ -5,11 +5,13 @@ import (
 )
 
 type FileExporter struct {
-	success   Exporter
-	fail      Exporter
-	skip      Exporter
-	overwrite Exporter
-	result    Exporter
+	success    Exporter
+	fail       Exporter
+	skip       Exporter
+	overwrite  Exporter
+	result     Exporter
+	undo       Exporter
+	deadletter Exporter
 }
 
 func (b *FileExporter) Success() Exporter {
@@ -32,25 +34,40 @@ func (b *FileExporter) Result() Exporter {
 	return b.result
 }
 
+func (b *FileExporter) Undo() Exporter {
+	return b.undo
+}
+
+// Deadletter 失败条目的输入行，格式同命令的输入，可直接作为输入文件重新执行
+func (b *FileExporter) Deadletter() Exporter {
+	return b.deadletter
+}
+
 func (b *FileExporter) Close() *data.CodeError {
 	errS := b.success.Close()
 	errF := b.fail.Close()
 	errO := b.overwrite.Close()
-	if errS == nil && errF == nil && errO == nil {
+	errU := b.undo.Close()
+	errD := b.deadletter.Close()
+	if errS == nil && errF == nil && errO == nil && errU == nil && errD == nil {
 		return nil
 	}
 	return data.NewEmptyError().AppendDesc("export close:").
 		AppendDesc("success").AppendError(errS).
 		AppendDesc("fail").AppendError(errF).
-		AppendDesc("overwrite").AppendError(errO)
+		AppendDesc("overwrite").AppendError(errO).
+		AppendDesc("undo").AppendError(errU).
+		AppendDesc("deadletter").AppendError(errD)
 }
 
 type FileExporterConfig struct {
-	SuccessExportFilePath   string // 输入列表中的成功部分
-	FailExportFilePath      string // 输入列表中的失败部分
-	SkipExportFilePath      string // 输入列表中的跳过部分
-	OverwriteExportFilePath string // 输入列表中的覆盖部分
-	ResultExportFilePath    string // 结果输出
+	SuccessExportFilePath    string // 输入列表中的成功部分
+	FailExportFilePath       string // 输入列表中的失败部分
+	SkipExportFilePath       string // 输入列表中的跳过部分
+	OverwriteExportFilePath  string // 输入列表中的覆盖部分
+	ResultExportFilePath     string // 结果输出
+	UndoExportFilePath       string // 成功操作的回滚信息，每行一个 JSON，可用于撤销操作
+	DeadletterExportFilePath string // 输入列表中的失败部分，不附带错误信息，可直接作为输入文件重新执行
 }
 
 func NewFileExport(config FileExporterConfig) (export *FileExporter, err *data.CodeError) {
@@ -76,6 +93,16 @@ func NewFileExport(config FileExporterConfig) (export *FileExporter, err *data.C
 	}
 
 	export.result, err = New(config.ResultExportFilePath)
+	if err != nil {
+		return
+	}
+
+	export.undo, err = New(config.UndoExportFilePath)
+	if err != nil {
+		return
+	}
+
+	export.deadletter, err = New(config.DeadletterExportFilePath)
 	return
 }
 
@@ -86,5 +113,7 @@ func EmptyFileExport() *FileExporter {
 	export.skip = empty()
 	export.overwrite = empty()
 	export.result = empty()
+	export.undo = empty()
+	export.deadletter = empty()
 	return export
 }
@@ -1,9 +1,13 @@
 package flow
 
 import (
+	"time"
+
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/limit"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/metrics"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 )
 
 func New(info Info) *WorkProvideBuilder {
@@ -17,8 +21,20 @@ func New(info Info) *WorkProvideBuilder {
 }
 
 type WorkProvideBuilder struct {
-	flow *Flow
-	err  error
+	flow           *Flow
+	err            error
+	inputFormat    string
+	inputColumns   []string
+	inputHasHeader bool
+}
+
+// ItemsInput 设置按行切分的输入文件的格式、每列对应的字段名及首行是否为标题行，
+// 仅对 NewItemsWorkCreator 创建的 WorkCreator 有效，需在 WorkProviderWithFile 前调用
+func (b *WorkProvideBuilder) ItemsInput(format string, columns []string, hasHeader bool) *WorkProvideBuilder {
+	b.inputFormat = format
+	b.inputColumns = columns
+	b.inputHasHeader = hasHeader
+	return b
 }
 
 func (b *WorkProvideBuilder) WorkProvider(provider WorkProvider) *WorkerProvideBuilder {
@@ -60,6 +76,12 @@ func (b *WorkProvideBuilder) WorkProviderWithArray(workList []Work) *WorkerProvi
 }
 
 func (b *WorkProvideBuilder) WorkProviderWithFile(filePath string, enableStdin bool, creator WorkCreator) *WorkerProvideBuilder {
+	if err := setupItemsWorkCreator(creator, b.inputFormat, b.inputColumns, b.inputHasHeader); err != nil {
+		return &WorkerProvideBuilder{
+			flow: b.flow,
+			err:  err,
+		}
+	}
 	if provider, err := NewWorkProviderOfFile(filePath, enableStdin, creator); err != nil {
 		return &WorkerProvideBuilder{
 			flow: b.flow,
@@ -107,9 +129,15 @@ func (b *FlowBuilder) SetOverseerEnable(enable bool) *FlowBuilder {
 	return b
 }
 
+// SetOverseerReadOnly 监工只查询工作记录，不记录工作状态
+func (b *FlowBuilder) SetOverseerReadOnly(readOnly bool) *FlowBuilder {
+	b.overseerReadOnly = readOnly
+	return b
+}
+
 func (b *FlowBuilder) SetDBOverseer(dbPath string, blankWorkRecordBuilder func() *WorkRecord) *FlowBuilder {
 	if overseer, err := NewDBRecordOverseer(dbPath, blankWorkRecordBuilder); err != nil {
-		b.err = err
+		b.overseerErr = err
 		return b
 	} else {
 		b.flow.Overseer = overseer
@@ -117,6 +145,17 @@ func (b *FlowBuilder) SetDBOverseer(dbPath string, blankWorkRecordBuilder func()
 	}
 }
 
+// SetUserConfirm 非强制执行时，用户输入验证码后还需通过 confirm 确认，如：要求输入空间名
+func (b *FlowBuilder) SetUserConfirm(confirm func(workTotalCount int64) bool) *FlowBuilder {
+	b.flow.UserConfirm = confirm
+	return b
+}
+
+func (b *FlowBuilder) SetWorkTotalSize(size int64) *FlowBuilder {
+	b.flow.WorkTotalSize = size
+	return b
+}
+
 func (b *FlowBuilder) SetLimit(limit limit.BlockLimit) *FlowBuilder {
 	b.flow.Limit = limit
 	return b
@@ -137,7 +176,7 @@ func (b *FlowBuilder) FlowWillStartFunc(f func(flow *Flow) (err *data.CodeError)
 	return b
 }
 
-func (b *FlowBuilder) FlowWillEndFunc(f func(flow *Flow) (err *data.CodeError)) *FlowBuilder {
+func (b *FlowBuilder) FlowWillEndFunc(f func(flow *Flow, unprocessedCount int64) (err *data.CodeError)) *FlowBuilder {
 	b.flow.EventListener.FlowWillEndFunc = f
 	return b
 }
@@ -152,29 +191,103 @@ func (b *FlowBuilder) OnWorkSkip(f func(workInfo *WorkInfo, result Result, err *
 	return b
 }
 
-func (b *FlowBuilder) OnWorkSuccess(f func(workInfo *WorkInfo, result Result)) *FlowBuilder {
+func (b *FlowBuilder) OnWorkSuccess(f func(workInfo *WorkInfo, result Result, stat *WorkStat)) *FlowBuilder {
 	b.flow.EventListener.OnWorkSuccessFunc = f
 	return b
 }
 
-func (b *FlowBuilder) OnWorkFail(f func(workInfo *WorkInfo, err *data.CodeError)) *FlowBuilder {
+func (b *FlowBuilder) OnWorkFail(f func(workInfo *WorkInfo, err *data.CodeError, stat *WorkStat)) *FlowBuilder {
 	b.flow.EventListener.OnWorkFailFunc = f
 	return b
 }
 
+// Deadletter 失败 work 的输入行导出到 exporter，可直接作为输入文件重新执行
+func (b *FlowBuilder) Deadletter(exporter DeadletterExporter) *FlowBuilder {
+	b.deadletter = exporter
+	return b
+}
+
+// DeadletterInputLine 导出 deadletter 时 work 的输入行，默认为 WorkInfo.Data；用于 work 并非来源于输入文件的 flow
+func (b *FlowBuilder) DeadletterInputLine(f func(work *WorkInfo) string) *FlowBuilder {
+	b.deadletterInputLine = f
+	return b
+}
+
 type FlowBuilder struct {
-	enableOverseer bool
-	flow           *Flow
-	err            error
+	enableOverseer      bool
+	overseerReadOnly    bool
+	overseerErr         *data.CodeError
+	deadletter          DeadletterExporter
+	deadletterInputLine func(work *WorkInfo) string
+	flow                *Flow
+	err                 error
 }
 
 func (b *FlowBuilder) Build() *Flow {
 	if !b.enableOverseer {
 		b.flow.Overseer = nil
+	} else if b.overseerErr != nil {
+		// 记录无法使用时不能从头开始，否则会重复处理已完成的 work，在 Flow.Check() 时报错
+		b.flow.err = b.overseerErr
+	} else if b.overseerReadOnly && b.flow.Overseer != nil {
+		b.flow.Overseer = NewReadOnlyOverseer(b.flow.Overseer)
+	}
+
+	if b.flow.Info.RetryCount > 0 && b.flow.WorkerProvider != nil {
+		backoff := NewExponentialBackoff()
+		if b.flow.Info.RetryMaxDelay > 0 {
+			backoff.MaxDelay = time.Duration(b.flow.Info.RetryMaxDelay) * time.Second
+		}
+		b.flow.WorkerProvider = NewRetryingWorkerProvider(b.flow.WorkerProvider, b.flow.Info.RetryCount, backoff)
+	}
+
+	if b.deadletter != nil {
+		b.flow.EventListener = deadletterEventListener(b.flow.EventListener, b.deadletter, b.deadletterInputLine)
+	}
+
+	if b.flow.Info.ShowProgress {
+		b.flow.EventListener = progressEventListener(b.flow.EventListener, "总进度")
+	}
+
+	if !b.flow.Info.Internal {
+		// 汇总信息默认输出，可通过 --no-summary 关闭
+		if data.IsShowSummary() {
+			b.flow.EventListener = summaryEventListener(b.flow.EventListener)
+		}
+		if m := workspace.GetMetricsConfig(); m != nil {
+			if pusher, err := metrics.NewPusher(m, workspace.GetConfig().CmdId); err != nil {
+				log.WarningF("metrics will not be pushed, %v", err)
+			} else {
+				b.flow.EventListener = metricsEventListener(b.flow.EventListener, pusher)
+			}
+		}
+	}
+
+	// 跳过上次执行已成功的 work，优先于命令自身的跳过逻辑，如：检测目标文件是否存在
+	if len(b.flow.Info.ResumeFile) > 0 {
+		if skipper, err := NewResumeSkipper(b.flow.Info.ResumeFile); err != nil {
+			if b.flow.err == nil {
+				b.flow.err = err
+			}
+		} else {
+			b.flow.Skipper = NewSkippers(skipper, b.flow.Skipper)
+		}
+	}
+
+	// 按 key 及大小过滤优先于其他跳过逻辑
+	if skippers, err := b.flow.Info.filterSkippers(); err != nil {
+		if b.flow.err == nil {
+			b.flow.err = err
+		}
+	} else if len(skippers) > 0 {
+		b.flow.Skipper = NewSkippers(append(skippers, b.flow.Skipper)...)
 	}
 
 	if b.err != nil {
 		log.ErrorF("Flow Builder error:%s", b.err)
+		if b.flow.err == nil {
+			b.flow.err = data.ConvertError(b.err)
+		}
 	}
 	return b.flow
 }
@@ -25,3 +25,22 @@ func UserCodeVerification() (success bool) {
 
 	return true
 }
+
+// UserNameVerification 要求用户输入 name 确认操作，如：空间名
+func UserNameVerification(name string) (success bool) {
+	log.Warning(fmt.Sprintf("<DANGER> Too many works, input the name `%s` again to confirm operation: ", name))
+
+	confirm := ""
+	_, err := fmt.Scanln(&confirm)
+	if err != nil {
+		_, _ = fmt.Fprintf(data.Stdout(), "scan error:%v\n", err)
+		return false
+	}
+
+	if name != confirm {
+		_, _ = fmt.Fprintln(data.Stdout(), "Task quit!")
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,78 @@
+package flow
+
+import (
+	"reflect"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// DeadletterExporter 导出失败 work 的输入行
+type DeadletterExporter interface {
+	Export(a ...interface{})
+}
+
+// SuccessResult 执行完成后仍可能失败的结果，如：batch 操作中的单个操作失败
+type SuccessResult interface {
+	IsSuccess() bool
+}
+
+// deadletterEventListener 在 listener 的基础上将失败 work 的输入行导出，不附带错误信息，可直接作为输入文件重新执行；
+// 主动跳过的 work 不属于失败，不导出；标题行需保留，导出后输入文件的格式不变
+func deadletterEventListener(listener EventListener, exporter DeadletterExporter, inputLine func(work *WorkInfo) string) EventListener {
+	if inputLine == nil {
+		inputLine = func(work *WorkInfo) string {
+			return work.Data
+		}
+	}
+
+	return EventListener{
+		FlowWillStartFunc: listener.FlowWillStartFunc,
+		FlowWillEndFunc:   listener.FlowWillEndFunc,
+		WillWorkFunc:      listener.WillWorkFunc,
+		OnWorkSkipFunc: func(work *WorkInfo, result Result, err *data.CodeError) {
+			if shouldDeadletterSkippedWork(result, err) {
+				exporter.Export(inputLine(work))
+			}
+			listener.OnWorkSkip(work, result, err)
+		},
+		OnWorkSuccessFunc: func(work *WorkInfo, result Result, stat *WorkStat) {
+			if r, ok := result.(SuccessResult); ok && !r.IsSuccess() {
+				exporter.Export(inputLine(work))
+			}
+			listener.OnWorkSuccess(work, result, stat)
+		},
+		OnWorkFailFunc: func(work *WorkInfo, err *data.CodeError, stat *WorkStat) {
+			exporter.Export(inputLine(work))
+			listener.OnWorkFail(work, err, stat)
+		},
+	}
+}
+
+func shouldDeadletterSkippedWork(result Result, err *data.CodeError) bool {
+	if err == nil || err.IsSkipped() {
+		return false
+	}
+	if err.Code == data.ErrorCodeAlreadyDone {
+		// 上次已执行，执行失败时需导出
+		return isNilResult(result) || !result.IsValid()
+	}
+	return true
+}
+
+// isFailedResult 执行完成但结果为失败，如：batch 操作中的单个操作失败
+func isFailedResult(result Result) bool {
+	if isNilResult(result) {
+		return false
+	}
+	r, ok := result.(SuccessResult)
+	return ok && !r.IsSuccess()
+}
+
+// isNilResult result 为 nil 或值为 nil 的指针
+func isNilResult(result Result) bool {
+	if result == nil {
+		return true
+	}
+	v := reflect.ValueOf(result)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
@@ -0,0 +1,67 @@
+package flow
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+type testDeadletterExporter struct {
+	lines []string
+}
+
+func (e *testDeadletterExporter) Export(a ...interface{}) {
+	e.lines = append(e.lines, fmt.Sprint(a...))
+}
+
+type testSuccessResult struct {
+	success bool
+}
+
+func (r *testSuccessResult) IsValid() bool {
+	return true
+}
+
+func (r *testSuccessResult) IsSuccess() bool {
+	return r.success
+}
+
+func TestDeadletterEventListener(t *testing.T) {
+	exporter := &testDeadletterExporter{}
+	var failCount int
+	listener := deadletterEventListener(EventListener{
+		OnWorkFailFunc: func(work *WorkInfo, err *data.CodeError, stat *WorkStat) {
+			failCount++
+		},
+	}, exporter, nil)
+
+	listener.OnWorkSkip(&WorkInfo{Data: "key\tsize"}, nil, data.NewError(data.ErrorCodeLineHeader, "header"))
+	listener.OnWorkSkip(&WorkInfo{Data: "filter"}, nil, data.NewError(data.ErrorCodeSkipByFilter, "filter"))
+	listener.OnWorkSkip(&WorkInfo{Data: "exist"}, nil, data.NewError(data.ErrorCodeSkipByExist, "exist"))
+	listener.OnWorkSkip(&WorkInfo{Data: "done_success"}, &testResult{Value: "ok"}, data.NewError(data.ErrorCodeAlreadyDone, "done"))
+	listener.OnWorkSkip(&WorkInfo{Data: "done_fail"}, (*testResult)(nil), data.NewError(data.ErrorCodeAlreadyDone, "done"))
+	listener.OnWorkSkip(&WorkInfo{Data: "invalid"}, nil, data.NewError(data.ErrorCodeLineInvalid, "invalid"))
+	listener.OnWorkSuccess(&WorkInfo{Data: "success"}, &testResult{Value: "ok"}, nil)
+	listener.OnWorkSuccess(&WorkInfo{Data: "result_fail"}, &testSuccessResult{success: false}, nil)
+	listener.OnWorkFail(&WorkInfo{Data: "fail"}, data.NewEmptyError().AppendDesc("fail"), nil)
+
+	expected := []string{"key\tsize", "done_fail", "invalid", "result_fail", "fail"}
+	if !reflect.DeepEqual(exporter.lines, expected) {
+		t.Fatal("deadletter lines error, expected:", expected, "but:", exporter.lines)
+	}
+	if failCount != 1 {
+		t.Fatal("listener should be called, but fail count:", failCount)
+	}
+
+	// 自定义输入行
+	exporter = &testDeadletterExporter{}
+	listener = deadletterEventListener(EventListener{}, exporter, func(work *WorkInfo) string {
+		return "line:" + work.Data
+	})
+	listener.OnWorkFail(&WorkInfo{Data: "fail"}, data.NewEmptyError().AppendDesc("fail"), nil)
+	if !reflect.DeepEqual(exporter.lines, []string{"line:fail"}) {
+		t.Fatal("deadletter should use custom input line, but:", exporter.lines)
+	}
+}
@@ -4,11 +4,11 @@ import "github.com/qiniu/qshell/v2/iqshell/common/data"
 
 type EventListener struct {
 	FlowWillStartFunc func(flow *Flow) (err *data.CodeError)
-	FlowWillEndFunc   func(flow *Flow) (err *data.CodeError)
+	FlowWillEndFunc   func(flow *Flow, unprocessedCount int64) (err *data.CodeError) // unprocessedCount: flow 提前结束时未被处理的 work 数
 	WillWorkFunc      func(work *WorkInfo) (shouldContinue bool, err *data.CodeError)
 	OnWorkSkipFunc    func(work *WorkInfo, result Result, err *data.CodeError)
-	OnWorkSuccessFunc func(work *WorkInfo, result Result)
-	OnWorkFailFunc    func(work *WorkInfo, err *data.CodeError)
+	OnWorkSuccessFunc func(work *WorkInfo, result Result, stat *WorkStat)
+	OnWorkFailFunc    func(work *WorkInfo, err *data.CodeError, stat *WorkStat) // stat: work 未被执行时为 nil
 }
 
 func (e *EventListener) FlowWillStart(flow *Flow) (err *data.CodeError) {
@@ -18,11 +18,11 @@ func (e *EventListener) FlowWillStart(flow *Flow) (err *data.CodeError) {
 	return e.FlowWillStartFunc(flow)
 }
 
-func (e *EventListener) FlowWillEnd(flow *Flow) (err *data.CodeError) {
+func (e *EventListener) FlowWillEnd(flow *Flow, unprocessedCount int64) (err *data.CodeError) {
 	if e.FlowWillEndFunc == nil {
 		return nil
 	}
-	return e.FlowWillEndFunc(flow)
+	return e.FlowWillEndFunc(flow, unprocessedCount)
 }
 
 func (e *EventListener) WillWork(work *WorkInfo) (shouldContinue bool, err *data.CodeError) {
@@ -39,16 +39,16 @@ func (e *EventListener) OnWorkSkip(work *WorkInfo, result Result, err *data.Code
 	e.OnWorkSkipFunc(work, result, err)
 }
 
-func (e *EventListener) OnWorkSuccess(work *WorkInfo, result Result) {
+func (e *EventListener) OnWorkSuccess(work *WorkInfo, result Result, stat *WorkStat) {
 	if e.OnWorkSuccessFunc == nil {
 		return
 	}
-	e.OnWorkSuccessFunc(work, result)
+	e.OnWorkSuccessFunc(work, result, stat)
 }
 
-func (e *EventListener) OnWorkFail(work *WorkInfo, err *data.CodeError) {
+func (e *EventListener) OnWorkFail(work *WorkInfo, err *data.CodeError, stat *WorkStat) {
 	if e.OnWorkFailFunc == nil {
 		return
 	}
-	e.OnWorkFailFunc(work, err)
+	e.OnWorkFailFunc(work, err, stat)
 }
@@ -1,23 +1,40 @@
 package flow
 
 import (
+	"fmt"
+	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/limit"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 )
 
 type Info struct {
-	Force                     bool // 是否强制直接进行 Flow, 不强制需要用户输入验证码验证
-	WorkerCount               int  // worker 数量
-	MinWorkerCount            int  // 最小 work 数量，当遇到限制错误会减小 work 数，最小 1
-	WorkerCountIncreasePeriod int  // WorkerCount 递增的周期，当在 WorkerCountIncreasePeriod 时间内没有遇到限制错误时，会尝试增加 WorkerCount，最小 10s
-	StopWhenWorkError         bool // 当某个 work 遇到执行错误是否结束 batch 任务
+	Force                     bool     // 是否强制直接进行 Flow, 不强制需要用户输入验证码验证
+	WorkerCount               int      // worker 数量
+	MinWorkerCount            int      // 最小 work 数量，当遇到限制错误会减小 work 数，最小 1
+	WorkerCountIncreasePeriod int      // WorkerCount 递增的周期，当在 WorkerCountIncreasePeriod 时间内没有遇到限制错误时，会尝试增加 WorkerCount，最小 10s
+	MaxWorkerCount            int      // 最大 worker 数，大于 0 时开启 worker 数动态调整：根据 work 执行耗时及限制错误在 [MinWorkerCount, MaxWorkerCount] 之间调整 worker 数，调整周期为 WorkerCountIncreasePeriod
+	StopWhenWorkError         bool     // 当某个 work 遇到执行错误是否结束 batch 任务
+	MaxErrorCount             int64    // 执行错误的 work 数达到此值时结束 batch 任务，0：不限制
+	MaxErrorRate              float64  // 执行错误的 work 占比超过此值时结束 batch 任务，取值范围 [0, 1]，0：不限制
+	ErrorRateMinSampleCount   int64    // 已执行的 work 数达到此值后才检测 MaxErrorRate，避免刚开始时少量错误导致任务结束，默认：100
+	IncludeKeyRegexes         []string // 仅处理 key 匹配其中任一正则的 work，为空不限制
+	ExcludeKeyRegexes         []string // 跳过 key 匹配其中任一正则的 work
+	MinSize                   string   // 跳过大小小于此值的 work，如：10m，为空不限制
+	MaxSize                   string   // 跳过大小大于此值的 work，如：1g，为空不限制
+	ResumeFile                string   // 上次执行的成功列表文件，跳过其中已成功的 work，为空不跳过
+	RetryCount                int      // work 遇到可重试的临时错误时最多重试的次数，0：不重试
+	RetryMaxDelay             int      // 重试前最长的等待时间，等待时间按指数增长，单位：秒，默认：10
+	ShowProgress              bool     // 是否展示整体进度及预估剩余时间，work 总数未知时仅展示已处理的数量
+	Internal                  bool     // 命令内部使用的 flow，如：并发列举；结束时不输出汇总信息，也不上报指标
 }
 
 func (i *Info) Check() *data.CodeError {
@@ -33,29 +50,135 @@ func (i *Info) Check() *data.CodeError {
 		i.WorkerCountIncreasePeriod = 10
 	}
 
+	if i.MaxWorkerCount > 0 {
+		if i.MaxWorkerCount < i.WorkerCount {
+			i.MaxWorkerCount = i.WorkerCount
+		}
+		if i.MinWorkerCount > i.WorkerCount {
+			i.MinWorkerCount = i.WorkerCount
+		}
+	}
+
+	if i.MaxErrorCount < 0 {
+		return alert.Error("MaxErrorCount should be greater than or equal to 0", "")
+	}
+
+	if i.MaxErrorRate < 0 || i.MaxErrorRate > 1 {
+		return alert.Error("MaxErrorRate should be between 0 and 1", "")
+	}
+
+	if i.ErrorRateMinSampleCount < 1 {
+		i.ErrorRateMinSampleCount = 100
+	}
+
+	if _, err := i.filterSkippers(); err != nil {
+		return err
+	}
+
+	if i.RetryCount < 0 {
+		return alert.Error("RetryCount should be greater than or equal to 0", "")
+	}
+
+	if i.RetryMaxDelay <= 0 {
+		i.RetryMaxDelay = 10
+	}
+
 	return nil
 }
 
+// filterSkippers 根据 key 及大小的过滤配置创建 Skipper 列表
+func (i *Info) filterSkippers() ([]Skipper, *data.CodeError) {
+	skippers := make([]Skipper, 0, 2)
+	if s, err := i.keySkipper(); err != nil {
+		return nil, err
+	} else if s != nil {
+		skippers = append(skippers, s)
+	}
+	if s, err := i.sizeSkipper(); err != nil {
+		return nil, err
+	} else if s != nil {
+		skippers = append(skippers, s)
+	}
+	return skippers, nil
+}
+
+// FilterSkipper 根据 key 及大小的过滤配置创建 Skipper，和 flow 中使用的过滤条件相同；未配置时返回 nil
+func (i *Info) FilterSkipper() (Skipper, *data.CodeError) {
+	skippers, err := i.filterSkippers()
+	if err != nil || len(skippers) == 0 {
+		return nil, err
+	}
+	return NewSkippers(skippers...), nil
+}
+
+// keySkipper 根据 IncludeKeyRegexes 和 ExcludeKeyRegexes 创建 Skipper，未配置时返回 nil
+func (i *Info) keySkipper() (Skipper, *data.CodeError) {
+	if len(i.IncludeKeyRegexes) == 0 && len(i.ExcludeKeyRegexes) == 0 {
+		return nil, nil
+	}
+	return NewRegexSkipper(i.IncludeKeyRegexes, i.ExcludeKeyRegexes)
+}
+
+// sizeSkipper 根据 MinSize 和 MaxSize 创建 Skipper，未配置时返回 nil
+func (i *Info) sizeSkipper() (Skipper, *data.CodeError) {
+	if len(i.MinSize) == 0 && len(i.MaxSize) == 0 {
+		return nil, nil
+	}
+
+	var minSize, maxSize int64
+	var err *data.CodeError
+	if len(i.MinSize) > 0 {
+		if minSize, err = utils.ParseFileSize(i.MinSize); err != nil {
+			return nil, alert.Error(fmt.Sprintf("invalid min size, %v", err), "")
+		}
+	}
+	if len(i.MaxSize) > 0 {
+		if maxSize, err = utils.ParseFileSize(i.MaxSize); err != nil {
+			return nil, alert.Error(fmt.Sprintf("invalid max size, %v", err), "")
+		}
+	}
+	if maxSize > 0 && minSize > maxSize {
+		return nil, alert.Error("min size should be less than or equal to max size", "")
+	}
+	return NewSizeSkipper(minSize, maxSize), nil
+}
+
 type Flow struct {
 	Info           Info           // flow 的参数信息 【可选】
 	WorkProvider   WorkProvider   // work 提供者 【必填】
 	WorkerProvider WorkerProvider // worker 提供者 【必填】
 
-	DoWorkInfoListMaxCount int // Worker.DoWork 函数中 works 数组最大长度，默认：250，最小长度为 1
-	doWorkInfoListCount    int // Worker.DoWork 函数中 works 数组长度
-	DoWorkInfoListMinCount int // Worker.DoWork 函数中 works 数组最小长度，默认：50，最小长度为 1
-
-	Limit         limit.BlockLimit // 速度限制，用于限制
-	EventListener EventListener    // work 处理事项监听者 【可选】
-	Overseer      Overseer         // work 监工，涉及 work 是否已处理相关的逻辑 【可选】
-	Skipper       Skipper          // work 是否跳过相关逻辑 【可选】
-	Redo          Redo             // work 是否需要重新做相关逻辑，有些工作虽然已经做过，但下次处理时可能条件发生变化，需要重新处理 【可选】
-
-	mu                sync.Mutex //
-	workErrorHappened bool       // 执行中是否出现错误 【内部变量】
+	DoWorkInfoListMaxCount int   // Worker.DoWork 函数中 works 数组最大长度，默认：250，最小长度为 1
+	doWorkInfoListCount    int   // Worker.DoWork 函数中 works 数组长度
+	DoWorkInfoListMinCount int   // Worker.DoWork 函数中 works 数组最小长度，默认：50，最小长度为 1
+	WorkTotalSize          int64 // 所有 work 的总大小，用于展示整体进度，未知时为 0 【可选】
+
+	Limit         limit.BlockLimit                // 速度限制，用于限制
+	EventListener EventListener                   // work 处理事项监听者 【可选】
+	Overseer      Overseer                        // work 监工，涉及 work 是否已处理相关的逻辑 【可选】
+	Skipper       Skipper                         // work 是否跳过相关逻辑 【可选】
+	Redo          Redo                            // work 是否需要重新做相关逻辑，有些工作虽然已经做过，但下次处理时可能条件发生变化，需要重新处理 【可选】
+	UserConfirm   func(workTotalCount int64) bool // 用户输入验证码后的额外确认，如：要求输入空间名，返回 false 时不执行；workTotalCount 未知时为 UnknownWorkCount 【可选】
+
+	mu               sync.Mutex      //
+	workCount        int64           // 已执行的 work 数 【内部变量】
+	workErrorCount   int64           // 执行出现错误的 work 数 【内部变量】
+	resultCount      int64           // 有结果的 work 数，包含上次已执行的 work 及无法解析的输入行，用于设置命令状态 【内部变量】
+	resultErrorCount int64           // 结果为失败的 work 数，用于设置命令状态 【内部变量】
+	unprocessedCount int64           // 未被处理的 work 数，flow 提前结束时统计 【内部变量】
+	processingCount  int64           // 正在处理的 work 数 【内部变量】
+	limitHitCount    int64           // 触发限流的 work 数 【内部变量】
+	scaler           *workerScaler   // worker 数动态调整 【内部变量】
+	stopOnce         sync.Once       //
+	stopChan         chan struct{}   // flow 需要提前结束时关闭 【内部变量】
+	err              *data.CodeError // 构建 flow 时出现的错误 【内部变量】
 }
 
 func (f *Flow) Check() *data.CodeError {
+	if f.err != nil {
+		return f.err
+	}
+
 	if err := f.Info.Check(); err != nil {
 		return err
 	}
@@ -76,6 +199,7 @@ func (f *Flow) Check() *data.CodeError {
 	}
 
 	f.doWorkInfoListCount = f.DoWorkInfoListMaxCount
+	f.stopChan = make(chan struct{})
 
 	return nil
 }
@@ -83,10 +207,11 @@ func (f *Flow) Check() *data.CodeError {
 func (f *Flow) Start() {
 	if e := f.Check(); e != nil {
 		log.ErrorF("work flow start error:%v", e)
+		data.SetCmdStatusError()
 		return
 	}
 
-	if !f.Info.Force && !UserCodeVerification() {
+	if !f.Info.Force && !f.userVerification() {
 		return
 	}
 
@@ -95,21 +220,61 @@ func (f *Flow) Start() {
 		return
 	}
 
+	if f.Overseer != nil {
+		defer workspace.AddCancelObserver(func(s os.Signal) {
+			f.overseerFlush()
+		})()
+	}
+
+	// 第一次中断时不再处理新的 work，等待正在处理的 work 完成并记录结果，以便再次执行时接续
+	flowDone := make(chan struct{})
+	defer close(flowDone)
+	defer workspace.EnableGracefulInterrupt()()
+	defer workspace.AddInterruptObserver(func() {
+		f.stop()
+		go f.reportProcessingWorks(flowDone)
+	})()
+
 	log.Debug("work flow did start")
 	workChan := make(chan []*WorkInfo, f.Info.WorkerCount)
+	providedCount := int64(0)
 	// 生产者
 	go func() {
 		log.DebugF("work producer start")
 
 		workList := make([]*WorkInfo, 0, f.doWorkInfoListCount)
+		sendWorkList := func() {
+			select {
+			case workChan <- workList:
+			case <-f.stopChan:
+				atomic.AddInt64(&f.unprocessedCount, int64(len(workList)))
+			}
+			workList = make([]*WorkInfo, 0, f.DoWorkInfoListMaxCount)
+		}
 		for {
+			if f.isStopped() {
+				break
+			}
+
 			hasMore, workInfo, err := f.WorkProvider.Provide()
+			if err != nil || (workInfo != nil && workInfo.Work != nil) {
+				atomic.AddInt64(&providedCount, 1)
+			}
 			if err != nil {
-				if err.Code == data.ErrorCodeParamMissing ||
-					err.Code == data.ErrorCodeLineHeader {
+				if err.Code == data.ErrorCodeColumnMissing {
+					// 缺少必须的列时所有的行都无法处理，直接结束
+					log.ErrorF("work flow stop, %v", err)
+					data.SetCmdStatusError()
+					break
+				}
+				if err.Code == data.ErrorCodeLineHeader {
+					f.notifyWorkSkip(workInfo, nil, err)
+				} else if err.Code == data.ErrorCodeParamMissing {
+					f.countWorkResult(true)
 					f.notifyWorkSkip(workInfo, nil, err)
 				} else {
-					f.notifyWorkFail(workInfo, err)
+					f.countWorkResult(true)
+					f.notifyWorkFail(workInfo, err, nil)
 				}
 				continue
 			}
@@ -117,15 +282,25 @@ func (f *Flow) Start() {
 			if workInfo == nil || workInfo.Work == nil {
 				if !hasMore {
 					break
-				} else {
-					continue
 				}
+				// WorkProvider 暂无 work（如：stdin 等待输入超时），已获取的 work 先交给消费者处理
+				if workInfo == nil && len(workList) > 0 {
+					sendWorkList()
+				}
+				continue
 			}
 
 			// 检测 work 是否需要过
 			if skip, cause := f.shouldWorkSkip(workInfo); skip {
 				f.notifyWorkSkip(workInfo, nil, cause)
 				continue
+			} else if cause != nil {
+				// 检测是否跳过时出错（如：获取文件大小失败），此 work 按失败处理
+				atomic.AddInt64(&f.workCount, 1)
+				atomic.AddInt64(&f.workErrorCount, 1)
+				f.countWorkResult(true)
+				f.notifyWorkFail(workInfo, cause, nil)
+				continue
 			}
 
 			// 检测 work 是否已经做过
@@ -135,9 +310,13 @@ func (f *Flow) Start() {
 						cause = data.NewError(data.ErrorCodeAlreadyDone, "already done")
 					}
 					cause.Code = data.ErrorCodeAlreadyDone
+					f.countWorkResult(isNilResult(workRecord.Result) || !workRecord.Result.IsValid())
 					f.notifyWorkSkip(workInfo, workRecord.Result, cause)
 					continue
 				} else {
+					if workRecord != nil && workRecord.Stat != nil {
+						workInfo.attempt = workRecord.Stat.Attempt
+					}
 					if cause == nil {
 						log.DebugF("work redo, %s", workInfo.Data)
 					} else {
@@ -154,13 +333,12 @@ func (f *Flow) Start() {
 
 			workList = append(workList, workInfo)
 			if len(workList) >= f.doWorkInfoListCount {
-				workChan <- workList
-				workList = make([]*WorkInfo, 0, f.DoWorkInfoListMaxCount)
+				sendWorkList()
 			}
 		}
 
 		if len(workList) > 0 {
-			workChan <- workList
+			sendWorkList()
 		}
 
 		close(workChan)
@@ -169,86 +347,288 @@ func (f *Flow) Start() {
 
 	// 消费者
 	wait := &sync.WaitGroup{}
-	wait.Add(f.Info.WorkerCount)
+	f.scaler = newWorkerScaler(f.Info)
 	for i := 0; i < f.Info.WorkerCount; i++ {
 		time.Sleep(time.Millisecond * time.Duration(50))
-		go func(index int) {
+		f.startConsumer(i, workChan, wait)
+	}
+	if f.scaler.enable() {
+		go f.scaleConsumers(workChan, wait)
+	}
+	wait.Wait()
+
+	// 消费者均已结束，剩余的 work 不会再被处理，通知生产者结束并清空队列
+	stopEarly := f.isStopped()
+	f.stop()
+	for workList := range workChan {
+		atomic.AddInt64(&f.unprocessedCount, int64(len(workList)))
+	}
+	unprocessedCount := atomic.LoadInt64(&f.unprocessedCount)
+	if totalCount := f.WorkProvider.WorkTotalCount(); stopEarly && totalCount > providedCount {
+		unprocessedCount += totalCount - atomic.LoadInt64(&providedCount)
+	}
+	if workspace.IsCmdInterrupt() {
+		log.WarningF("work flow interrupted, %d work(s) completed and %d of them failed, %d work(s) were not processed",
+			atomic.LoadInt64(&f.workCount), atomic.LoadInt64(&f.workErrorCount), unprocessedCount)
+	} else if unprocessedCount > 0 {
+		log.WarningF("work flow end early, %d work(s) failed in %d, %d work(s) were not processed",
+			atomic.LoadInt64(&f.workErrorCount), atomic.LoadInt64(&f.workCount), unprocessedCount)
+	}
+	f.overseerFlush()
+
+	// 设置命令状态，最终作为进程的退出码：全部失败为 StatusError，部分失败为 StatusPartError；
+	// 命令内部使用的 flow 由命令自行处理；FlowWillEndFunc 中可根据需要再做调整
+	if !f.Info.Internal {
+		data.SetCmdStatusByWorkCount(atomic.LoadInt64(&f.resultCount), atomic.LoadInt64(&f.resultErrorCount))
+	}
+
+	if err := f.notifyFlowWillEnd(unprocessedCount); err != nil {
+		log.ErrorF("Flow end error:%v", err)
+		return
+	}
+
+	log.Debug("work flow did end")
+}
+
+func (f *Flow) startConsumer(index int, workChan <-chan []*WorkInfo, wait *sync.WaitGroup) {
+	f.scaler.startConsumer(index, f.consumerStarter(workChan, wait))
+}
+
+func (f *Flow) consumerStarter(workChan <-chan []*WorkInfo, wait *sync.WaitGroup) func(index int) {
+	return func(index int) {
+		wait.Add(1)
+		go func() {
 			log.DebugF("work consumer %d start", index)
 			defer func() {
+				f.scaler.consumerDidEnd(index)
 				wait.Done()
 				log.DebugF("work consumer %d   end", index)
 			}()
+			f.consume(index, workChan)
+		}()
+	}
+}
 
-			worker, err := f.WorkerProvider.Provide()
-			if err != nil {
-				log.ErrorF("Create Worker Error:%v", err)
-				return
-			}
-
-			for workList := range workChan {
-				if workspace.IsCmdInterrupt() {
-					break
-				}
+func (f *Flow) consume(index int, workChan <-chan []*WorkInfo) {
+	worker, err := f.WorkerProvider.Provide()
+	if err != nil {
+		log.ErrorF("Create Worker Error:%v", err)
+		return
+	}
 
-				workCount := len(workList)
-
-				_ = f.limitAcquire(workCount)
-				// workRecordList 有数据则长度和 workList 长度相同
-				workRecordList, workErr := worker.DoWork(workList)
-				f.limitRelease(workCount)
-
-				if len(workRecordList) == 0 && workErr != nil {
-					log.ErrorF("Do Worker Error:%+v", workErr)
-					for _, workInfo := range workList {
-						f.handleWorkResult(&WorkRecord{
-							WorkInfo: workInfo,
-							Result:   nil,
-							Err:      workErr,
-						})
-					}
-					break
-				}
+	for {
+		// worker 数减小时，多余的消费者在处理完当前的 work 后退出
+		if f.scaler.shouldConsumerRetire(index) {
+			log.DebugF("work consumer %d retire", index)
+			return
+		}
 
-				f.tryChangeWorkGroupCount(workErr)
+		workList, ok := <-workChan
+		if !ok {
+			f.scaler.workChanDidClose()
+			return
+		}
 
-				hitLimitCount := 0
-				hasTooManyFileError := false
-				for _, record := range workRecordList {
-					if (record.Result == nil || !record.Result.IsValid()) && record.Err == nil {
-						record.Err = workErr
-					}
+		if workspace.IsCmdInterrupt() {
+			f.stop()
+		}
 
-					f.handleWorkResult(record)
-					if f.isWorkResultHitLimit(record) {
-						hitLimitCount += 1
-					}
+		if f.isStopped() {
+			atomic.AddInt64(&f.unprocessedCount, int64(len(workList)))
+			return
+		}
 
-					if !hasTooManyFileError &&
-						record.Err != nil &&
-						strings.Contains(record.Err.Error(), "too many open files") {
-						hasTooManyFileError = true
-					}
+		workCount := len(workList)
+
+		atomic.AddInt64(&f.processingCount, int64(workCount))
+		_ = f.limitAcquire(workCount)
+		// workRecordList 有数据则长度和 workList 长度相同
+		startTime := time.Now()
+		workRecordList, workErr := worker.DoWork(workList)
+		duration := time.Since(startTime)
+		f.scaler.addWorkStat(workCount, duration)
+		f.limitRelease(workCount)
+
+		if len(workRecordList) == 0 && workErr != nil {
+			defer atomic.AddInt64(&f.processingCount, int64(-workCount))
+			log.ErrorF("Do Worker Error:%+v", workErr)
+			for _, workInfo := range workList {
+				record := &WorkRecord{
+					WorkInfo: workInfo,
+					Result:   nil,
+					Err:      workErr,
 				}
-				f.limitCountDecrease(hitLimitCount)
+				fillWorkStat(record, startTime, duration)
+				f.handleWorkResult(record)
+			}
+			return
+		}
 
-				if hasTooManyFileError {
-					time.Sleep(5 * time.Second)
-				}
-				// 检测是否需要停止
-				if f.workErrorHappened && f.Info.StopWhenWorkError {
-					break
-				}
+		f.tryChangeWorkGroupCount(workErr)
+
+		hitLimitCount := 0
+		hasTooManyFileError := false
+		for _, record := range workRecordList {
+			if (record.Result == nil || !record.Result.IsValid()) && record.Err == nil {
+				record.Err = workErr
 			}
-		}(i)
+
+			fillWorkStat(record, startTime, duration)
+			f.handleWorkResult(record)
+			if f.isWorkResultHitLimit(record) {
+				hitLimitCount += 1
+			}
+
+			if !hasTooManyFileError &&
+				record.Err != nil &&
+				strings.Contains(record.Err.Error(), "too many open files") {
+				hasTooManyFileError = true
+			}
+		}
+		f.limitCountDecrease(hitLimitCount)
+		atomic.AddInt64(&f.processingCount, int64(-workCount))
+
+		if hasTooManyFileError {
+			time.Sleep(5 * time.Second)
+		}
+		// 检测是否需要停止
+		if f.shouldStopByWorkError() {
+			f.stop()
+			return
+		}
 	}
-	wait.Wait()
+}
 
-	if err := f.notifyFlowWillEnd(); err != nil {
-		log.ErrorF("Flow end error:%v", err)
-		return
+// reportProcessingWorks 中断后定时输出正在处理的 work 数，直到 flow 结束
+func (f *Flow) reportProcessingWorks(done <-chan struct{}) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if count := atomic.LoadInt64(&f.processingCount); count > 0 {
+			log.WarningF("waiting for %d processing work(s) to finish, %d work(s) completed", count, atomic.LoadInt64(&f.workCount))
+		}
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
 	}
+}
 
-	log.Debug("work flow did end")
+// fillWorkStat 补全 work 的执行统计信息，worker 未统计耗时则使用整组 work 的耗时
+func fillWorkStat(record *WorkRecord, startTime time.Time, duration time.Duration) {
+	if record.Stat == nil {
+		record.Stat = &WorkStat{}
+	}
+	if record.Stat.StartedAt.IsZero() {
+		record.Stat.StartedAt = startTime
+		record.Stat.Duration = duration
+	}
+	if record.Stat.Attempt < 1 {
+		record.Stat.Attempt = 1
+	}
+	if record.WorkInfo != nil {
+		record.Stat.Attempt += record.WorkInfo.attempt
+	}
+}
+
+// scaleConsumers 周期性根据 work 执行情况调整消费者数量
+func (f *Flow) scaleConsumers(workChan <-chan []*WorkInfo, wait *sync.WaitGroup) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	starter := f.consumerStarter(workChan, wait)
+	for {
+		select {
+		case <-f.stopChan:
+			return
+		case <-ticker.C:
+			f.scaler.adjust(starter)
+		}
+	}
+}
+
+func (f *Flow) stop() {
+	f.stopOnce.Do(func() {
+		close(f.stopChan)
+		// 生产者可能阻塞在 Provide 中，通知 WorkProvider 结束
+		if p, ok := f.WorkProvider.(StoppableWorkProvider); ok {
+			p.Stop()
+		}
+	})
+}
+
+func (f *Flow) isStopped() bool {
+	select {
+	case <-f.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// shouldStopByWorkError 根据执行错误的 work 数判断是否需要结束 flow
+func (f *Flow) shouldStopByWorkError() bool {
+	errorCount := atomic.LoadInt64(&f.workErrorCount)
+	if errorCount == 0 {
+		return false
+	}
+
+	if f.Info.StopWhenWorkError {
+		return true
+	}
+
+	if f.Info.MaxErrorCount > 0 && errorCount >= f.Info.MaxErrorCount {
+		log.ErrorF("work flow stop, error count:%d reach max error count:%d", errorCount, f.Info.MaxErrorCount)
+		return true
+	}
+
+	workCount := atomic.LoadInt64(&f.workCount)
+	if f.Info.MaxErrorRate > 0 && workCount >= f.Info.ErrorRateMinSampleCount &&
+		float64(errorCount)/float64(workCount) > f.Info.MaxErrorRate {
+		log.ErrorF("work flow stop, error rate:%.4f(%d/%d) exceed max error rate:%.4f",
+			float64(errorCount)/float64(workCount), errorCount, workCount, f.Info.MaxErrorRate)
+		return true
+	}
+
+	return false
+}
+
+// WorkCount 已执行的 work 数
+func (f *Flow) WorkCount() int64 {
+	return atomic.LoadInt64(&f.workCount)
+}
+
+// WorkErrorCount 执行出现错误的 work 数
+func (f *Flow) WorkErrorCount() int64 {
+	return atomic.LoadInt64(&f.workErrorCount)
+}
+
+// LimitHitCount 触发限流的 work 数
+func (f *Flow) LimitHitCount() int64 {
+	return atomic.LoadInt64(&f.limitHitCount)
+}
+
+// userVerification 展示任务的范围，然后要求用户输入验证码确认；设置了 UserConfirm 时还需通过其确认
+func (f *Flow) userVerification() bool {
+	totalCount := f.WorkProvider.WorkTotalCount()
+	if totalCount == UnknownWorkCount {
+		log.Warning("<DANGER> Operation scope, work count: unknown")
+	} else if f.WorkTotalSize > 0 {
+		log.WarningF("<DANGER> Operation scope, work count: %d, total size: %s", totalCount, utils.BytesToReadable(f.WorkTotalSize))
+	} else {
+		log.WarningF("<DANGER> Operation scope, work count: %d", totalCount)
+	}
+
+	if !UserCodeVerification() {
+		return false
+	}
+
+	if f.UserConfirm == nil {
+		return true
+	}
+	return f.UserConfirm(totalCount)
 }
 
 func (f *Flow) notifyFlowWillStart() *data.CodeError {
@@ -295,11 +675,11 @@ func (f *Flow) limitAcquire(count int) *data.CodeError {
 }
 
 func (f *Flow) isWorkResultHitLimit(workRecord *WorkRecord) bool {
-	if f.Limit == nil || workRecord.Err == nil {
+	if workRecord.Err == nil {
 		return false
 	}
 
-	return workRecord.Err.Code == 573
+	return workRecord.Err.IsRateLimited()
 }
 
 func (f *Flow) limitRelease(count int) {
@@ -310,10 +690,16 @@ func (f *Flow) limitRelease(count int) {
 }
 
 func (f *Flow) limitCountDecrease(count int) {
-	if f.Limit == nil || count <= 0 {
+	if count <= 0 {
 		return
 	}
 
+	// 限制错误同时影响 worker 数的动态调整
+	atomic.AddInt64(&f.limitHitCount, int64(count))
+	f.scaler.addLimitHit(count)
+	if f.Limit == nil {
+		return
+	}
 	f.Limit.AddLimitCount(-1 * count)
 }
 
@@ -335,32 +721,58 @@ func (f *Flow) tryChangeWorkGroupCount(err *data.CodeError) {
 }
 
 func (f *Flow) handleWorkResult(workRecord *WorkRecord) {
+	// worker 跳过的 work 未执行，不记录执行状态，下次执行时会重新检测
+	if workRecord.Err != nil && workRecord.Err.Code == data.ErrorCodeSkipByWorker {
+		f.notifyWorkSkip(workRecord.WorkInfo, workRecord.Result, workRecord.Err)
+		return
+	}
+
 	if f.Overseer != nil {
 		f.Overseer.WorkDone(&WorkRecord{
 			WorkInfo: workRecord.WorkInfo,
 			Result:   workRecord.Result,
 			Err:      workRecord.Err,
+			Stat:     workRecord.Stat,
 		})
 	}
+	atomic.AddInt64(&f.workCount, 1)
+	f.countWorkResult(workRecord.Err != nil || isFailedResult(workRecord.Result))
 	if workRecord.Err != nil {
-		f.notifyWorkFail(workRecord.WorkInfo, workRecord.Err)
-		f.workErrorHappened = true
+		atomic.AddInt64(&f.workErrorCount, 1)
+		f.notifyWorkFail(workRecord.WorkInfo, workRecord.Err, workRecord.Stat)
 	} else {
-		f.notifyWorkSuccess(workRecord.WorkInfo, workRecord.Result)
+		f.notifyWorkSuccess(workRecord.WorkInfo, workRecord.Result, workRecord.Stat)
+	}
+}
+
+// countWorkResult 统计 work 的结果，用于设置命令状态
+func (f *Flow) countWorkResult(failed bool) {
+	atomic.AddInt64(&f.resultCount, 1)
+	if failed {
+		atomic.AddInt64(&f.resultErrorCount, 1)
+	}
+}
+
+func (f *Flow) overseerFlush() {
+	if f.Overseer == nil {
+		return
+	}
+	if err := f.Overseer.Flush(); err != nil {
+		log.ErrorF("work flow flush record error:%v", err)
 	}
 }
 
-func (f *Flow) notifyWorkSuccess(work *WorkInfo, result Result) {
-	f.EventListener.OnWorkSuccess(work, result)
+func (f *Flow) notifyWorkSuccess(work *WorkInfo, result Result, stat *WorkStat) {
+	f.EventListener.OnWorkSuccess(work, result, stat)
 }
 
-func (f *Flow) notifyWorkFail(work *WorkInfo, err *data.CodeError) {
-	f.EventListener.OnWorkFail(work, err)
+func (f *Flow) notifyWorkFail(work *WorkInfo, err *data.CodeError, stat *WorkStat) {
+	f.EventListener.OnWorkFail(work, err, stat)
 }
 
-func (f *Flow) notifyFlowWillEnd() *data.CodeError {
+func (f *Flow) notifyFlowWillEnd(unprocessedCount int64) *data.CodeError {
 	if f.EventListener.FlowWillEndFunc == nil {
 		return nil
 	}
-	return f.EventListener.FlowWillEndFunc(f)
+	return f.EventListener.FlowWillEndFunc(f, unprocessedCount)
 }
@@ -0,0 +1,163 @@
+package flow
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+func TestFlowStopWhenReachMaxErrorCount(t *testing.T) {
+	works := make([]Work, 0, 10)
+	for i := 0; i < 10; i++ {
+		works = append(works, &testWork{Key: fmt.Sprintf("%d", i)})
+	}
+
+	unprocessed := int64(-1)
+	f := New(Info{
+		Force:         true,
+		WorkerCount:   1,
+		MaxErrorCount: 3,
+	}).WorkProviderWithArray(works).
+		WorkerProvider(NewWorkerProvider(func() (Worker, *data.CodeError) {
+			return NewSimpleWorker(func(workInfo *WorkInfo) (Result, *data.CodeError) {
+				return nil, data.NewError(500, "mock error")
+			}), nil
+		})).
+		DoWorkListMaxCount(1).
+		DoWorkListMinCount(1).
+		FlowWillEndFunc(func(flow *Flow, unprocessedCount int64) (err *data.CodeError) {
+			unprocessed = unprocessedCount
+			return nil
+		}).Build()
+	f.Start()
+
+	if f.WorkErrorCount() != 3 {
+		t.Fatalf("work error count should be 3, but:%d", f.WorkErrorCount())
+	}
+	if unprocessed != 7 {
+		t.Fatalf("unprocessed count should be 7, but:%d", unprocessed)
+	}
+}
+
+func TestFlowInfoCheckMaxErrorRate(t *testing.T) {
+	info := &Info{MaxErrorRate: 1.5}
+	if err := info.Check(); err == nil {
+		t.Fatal("max error rate 1.5 should be invalid")
+	}
+
+	info = &Info{MaxErrorRate: 0.1}
+	if err := info.Check(); err != nil {
+		t.Fatalf("max error rate 0.1 should be valid, but:%v", err)
+	}
+	if info.ErrorRateMinSampleCount != 100 {
+		t.Fatalf("error rate min sample count should default to 100, but:%d", info.ErrorRateMinSampleCount)
+	}
+}
+
+func TestFlowWorkStat(t *testing.T) {
+	works := []Work{&testWork{Key: "0"}, &testWork{Key: "1"}}
+
+	var mu sync.Mutex
+	statList := make([]*WorkStat, 0, len(works))
+	f := New(Info{
+		Force:       true,
+		WorkerCount: 1,
+	}).WorkProviderWithArray(works).
+		WorkerProvider(NewWorkerProvider(func() (Worker, *data.CodeError) {
+			return NewSimpleWorker(func(workInfo *WorkInfo) (Result, *data.CodeError) {
+				time.Sleep(10 * time.Millisecond)
+				return nil, data.NewError(500, "mock error")
+			}), nil
+		})).
+		OnWorkFail(func(workInfo *WorkInfo, err *data.CodeError, stat *WorkStat) {
+			mu.Lock()
+			statList = append(statList, stat)
+			mu.Unlock()
+		}).Build()
+	f.Start()
+
+	if len(statList) != len(works) {
+		t.Fatalf("stat count should be %d, but:%d", len(works), len(statList))
+	}
+	for _, stat := range statList {
+		if stat == nil {
+			t.Fatal("stat should not be nil")
+		}
+		if stat.StartedAt.IsZero() || stat.Duration < 10*time.Millisecond {
+			t.Fatalf("stat time is invalid:%+v", stat)
+		}
+		if stat.Attempt != 1 {
+			t.Fatalf("stat attempt should be 1, but:%d", stat.Attempt)
+		}
+	}
+}
+
+func TestFlowWorkSkipByWorker(t *testing.T) {
+	works := []Work{&testWork{Key: "0"}, &testWork{Key: "1"}, &testWork{Key: "2"}}
+
+	var mu sync.Mutex
+	skipCount := 0
+	f := New(Info{
+		Force:       true,
+		WorkerCount: 1,
+	}).WorkProviderWithArray(works).
+		WorkerProvider(NewWorkerProvider(func() (Worker, *data.CodeError) {
+			return NewSimpleWorker(func(workInfo *WorkInfo) (Result, *data.CodeError) {
+				return nil, data.NewError(data.ErrorCodeSkipByWorker, "mock skip")
+			}), nil
+		})).
+		OnWorkSkip(func(workInfo *WorkInfo, result Result, err *data.CodeError) {
+			mu.Lock()
+			skipCount++
+			mu.Unlock()
+		}).Build()
+	f.Start()
+
+	if skipCount != len(works) {
+		t.Fatalf("skip count should be %d, but:%d", len(works), skipCount)
+	}
+	if f.WorkErrorCount() != 0 {
+		t.Fatalf("work error count should be 0, but:%d", f.WorkErrorCount())
+	}
+}
+
+func TestFlowWorkStatAttemptOnRedo(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), ".recorder")
+	runFlow := func() *WorkStat {
+		var stat *WorkStat
+		New(Info{
+			Force:       true,
+			WorkerCount: 1,
+		}).WorkProviderWithArray([]Work{&testWork{Key: "0"}}).
+			WorkerProvider(NewWorkerProvider(func() (Worker, *data.CodeError) {
+				return NewSimpleWorker(func(workInfo *WorkInfo) (Result, *data.CodeError) {
+					return nil, data.NewError(500, "mock error")
+				}), nil
+			})).
+			SetOverseerEnable(true).
+			SetDBOverseer(dbPath, func() *WorkRecord {
+				return &WorkRecord{
+					WorkInfo: &WorkInfo{Work: &testWork{}},
+					Result:   &testResult{},
+				}
+			}).
+			ShouldRedo(func(workInfo *WorkInfo, workRecord *WorkRecord) (shouldRedo bool, cause *data.CodeError) {
+				return true, nil
+			}).
+			OnWorkFail(func(workInfo *WorkInfo, err *data.CodeError, s *WorkStat) {
+				stat = s
+			}).Build().Start()
+		return stat
+	}
+
+	if stat := runFlow(); stat == nil || stat.Attempt != 1 {
+		t.Fatalf("first attempt should be 1, but:%+v", stat)
+	}
+	if stat := runFlow(); stat == nil || stat.Attempt != 2 {
+		t.Fatalf("attempt should be 2 after redo, but:%+v", stat)
+	}
+}
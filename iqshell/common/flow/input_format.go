@@ -0,0 +1,203 @@
+package flow
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// 输入文件的格式
+const (
+	InputFormatAuto = "auto" // 根据输入的前几行自动检测，检测不出时按 tsv 处理
+	InputFormatTsv  = "tsv"  // 每行按分隔符（--sep，默认为 \t）切分，不处理引号
+	InputFormatCsv  = "csv"  // 每行按 CSV 解析，支持引号包裹的含有分隔符的字段
+	InputFormatJson = "json" // 每行为一个 JSON 数组或对象，对象按字段出现的顺序取值
+)
+
+// inputFormatSniffLineCount 自动检测格式时最多参考的行数
+const inputFormatSniffLineCount = 10
+
+func CheckInputFormat(format string) *data.CodeError {
+	switch format {
+	case "", InputFormatAuto, InputFormatTsv, InputFormatCsv, InputFormatJson:
+		return nil
+	default:
+		return data.NewEmptyError().AppendDescF("invalid input format:%s, should be auto, tsv, csv or json", format)
+	}
+}
+
+// inputFormatDetector 需要根据输入内容检测格式的 WorkCreator
+type inputFormatDetector interface {
+	needDetectInputFormat() bool
+	detectInputFormat(lines []string)
+}
+
+// detectInputFormat 根据前几行判断输入格式，hasHeader 为 true 时 lines 的首行为标题行：
+// 1. 多于 1 行，且每行均为 JSON 数组或对象时为 json；key 可能以 { 或 [ 开头，仅首行合法时不作为 json
+// 2. 有行在引号外包含 \t 时为 tsv
+// 3. 多数行能按 CSV 解析，且都为相同数量（至少 2 个）的字段，且有引号包裹的字段或有标题行时为 csv；
+// key 列表中的 key 可能包含逗号，仅按逗号能切分为相同数量的字段不足以判断为 csv，不满足条件时按 tsv 处理，保持和之前的行为一致
+func detectInputFormat(lines []string, comma rune, hasHeader bool) string {
+	var sample []string
+	for _, line := range lines {
+		line = strings.TrimPrefix(line, "\ufeff")
+		if len(strings.TrimSpace(line)) > 0 {
+			sample = append(sample, line)
+		}
+	}
+	records := sample
+	if hasHeader && len(records) > 0 {
+		// 标题行不参与 json 的检测，仅用于判断是否为 csv
+		records = records[1:]
+	}
+	if len(records) == 0 {
+		return InputFormatTsv
+	}
+
+	if isJsonLines(records) {
+		return InputFormatJson
+	}
+
+	hasQuote := false
+	fieldCount, validCount := -1, 0
+	for _, line := range sample {
+		if containsOutsideQuotes(line, '\t') {
+			return InputFormatTsv
+		}
+		fields, err := splitCsvLine(line, comma)
+		if err != nil {
+			// 少量错误行不影响检测，解析时会报告错误行
+			continue
+		}
+		if len(fields) < 2 || (fieldCount >= 0 && fieldCount != len(fields)) {
+			return InputFormatTsv
+		}
+		fieldCount = len(fields)
+		validCount++
+		hasQuote = hasQuote || hasQuotedField(line, comma)
+	}
+	if validCount*2 <= len(sample) {
+		return InputFormatTsv
+	}
+	if hasQuote || hasHeader {
+		return InputFormatCsv
+	}
+	return InputFormatTsv
+}
+
+// hasQuotedField line 中是否有以双引号开头的字段；key 中间的双引号不作为 csv 的引号
+func hasQuotedField(line string, comma rune) bool {
+	return strings.HasPrefix(line, `"`) || strings.Contains(line, string(comma)+`"`)
+}
+
+// isJsonLines lines 是否多于 1 行，且每行均为 JSON 数组或对象
+func isJsonLines(lines []string) bool {
+	if len(lines) < 2 {
+		return false
+	}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") && !strings.HasPrefix(line, "[") {
+			return false
+		}
+		if _, err := splitJsonLine(line); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// containsOutsideQuotes 是否在双引号包裹的内容之外包含字符 c
+func containsOutsideQuotes(line string, c rune) bool {
+	quoted := false
+	for _, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == c && !quoted {
+			return true
+		}
+	}
+	return false
+}
+
+func splitCsvLine(line string, comma rune) ([]string, error) {
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	items, err := reader.Read()
+	// 每次只解析一行，去掉错误信息中的行号，避免和输入文件的行号混淆
+	var pErr *csv.ParseError
+	if errors.As(err, &pErr) {
+		return nil, fmt.Errorf("column %d: %w", pErr.Column, pErr.Err)
+	}
+	return items, err
+}
+
+// splitJsonLine JSON 数组按顺序取值，JSON 对象按字段出现的顺序取值；字符串取其内容，其他类型取其 JSON 文本，null 为空字符串
+func splitJsonLine(line string) ([]string, error) {
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok || (delim != '{' && delim != '[') {
+		return nil, errInvalidJsonLine
+	}
+
+	var items []string
+	for decoder.More() {
+		if delim == '{' {
+			// 字段名
+			if _, err = decoder.Token(); err != nil {
+				return nil, err
+			}
+		}
+		var value json.RawMessage
+		if err = decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		items = append(items, jsonValueString(value))
+	}
+	if _, err = decoder.Token(); err != nil {
+		return nil, err
+	}
+	if _, err = decoder.Token(); err != io.EOF {
+		return nil, errInvalidJsonLine
+	}
+	return items, nil
+}
+
+var errInvalidJsonLine = errors.New("should be a json array or object")
+
+func jsonValueString(value json.RawMessage) string {
+	value = bytes.TrimSpace(value)
+	if bytes.Equal(value, []byte("null")) {
+		return ""
+	}
+	if len(value) > 0 && value[0] == '"' {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			return s
+		}
+	}
+	return string(value)
+}
+
+// csvComma CSV 的分隔符，--sep 为单个字符（\t 除外）时使用 --sep，否则为逗号
+func csvComma(separate string) rune {
+	if separate != DefaultLineItemSeparate && utf8.RuneCountInString(separate) == 1 {
+		r, _ := utf8.DecodeRuneInString(separate)
+		return r
+	}
+	return ','
+}
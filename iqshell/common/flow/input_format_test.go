@@ -0,0 +1,105 @@
+package flow
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+func TestDetectInputFormat(t *testing.T) {
+	for _, c := range []struct {
+		lines  []string
+		format string
+	}{
+		{[]string{"a\tb", "c\td"}, InputFormatTsv},
+		{[]string{"a", "b"}, InputFormatTsv},
+		{[]string{"a,b"}, InputFormatTsv},
+		{[]string{"a,b", "c"}, InputFormatTsv},
+		// key 中可能包含逗号，没有引号包裹的字段时按 tsv 处理
+		{[]string{"a,b", "c,d"}, InputFormatTsv},
+		{[]string{"photo,1.jpg", "photo,2.jpg", "photo,3.jpg"}, InputFormatTsv},
+		{[]string{`a"b,c`, `d"e,f`}, InputFormatTsv},
+		{[]string{`"a,b",c`, `"d,e`, "f,g"}, InputFormatCsv},
+		{[]string{`"a,b",c`}, InputFormatCsv},
+		{[]string{`a,"b,c"`, "d,e"}, InputFormatCsv},
+		{[]string{"\ufeff" + `{"key":"a"}`, `{"key":"b"}`}, InputFormatJson},
+		{[]string{`["a","b"]`, `["c","d"]`}, InputFormatJson},
+		{[]string{`["a","b"]`}, InputFormatTsv},
+		{[]string{`["a","b"]`, "[c]"}, InputFormatTsv},
+		{[]string{"[a", "b"}, InputFormatTsv},
+	} {
+		if format := detectInputFormat(c.lines, ',', false); format != c.format {
+			t.Fatalf("lines:%q should be detected as %s, but:%s", c.lines, c.format, format)
+		}
+	}
+
+	// 有标题行时，标题行和内容按逗号切分为相同数量的字段时为 csv
+	for _, c := range []struct {
+		lines  []string
+		format string
+	}{
+		{[]string{"key,dstKey", "a,b", "c,d"}, InputFormatCsv},
+		{[]string{"key", "a,b", "c,d"}, InputFormatTsv},
+		{[]string{"key\tdstKey", "a,b", "c,d"}, InputFormatTsv},
+		{[]string{"key,dstKey"}, InputFormatTsv},
+		{[]string{"key,size", `{"key":"a","size":1}`, `{"key":"b","size":2}`}, InputFormatJson},
+	} {
+		if format := detectInputFormat(c.lines, ',', true); format != c.format {
+			t.Fatalf("lines:%q with header should be detected as %s, but:%s", c.lines, c.format, format)
+		}
+	}
+}
+
+func TestReaderWorkProviderInputFormat(t *testing.T) {
+	for _, c := range []struct {
+		format string
+		input  string
+		items  [][]string
+	}{
+		{InputFormatAuto, "\"a\tb,1\",c\n\"d, \"\"e\"\"\",f\n", [][]string{{"a\tb,1", "c"}, {`d, "e"`, "f"}}},
+		{InputFormatAuto, `{"key":"a","size":1,"mime":null}` + "\n" + `["b",2]` + "\n", [][]string{{"a", "1", ""}, {"b", "2"}}},
+		{InputFormatTsv, "a,b\nc,d\n", [][]string{{"a,b"}, {"c,d"}}},
+		// 默认自动检测，没有引号包裹的字段时 key 中的逗号不作为分隔符
+		{"", "photo,1.jpg\nphoto,2.jpg\n", [][]string{{"photo,1.jpg"}, {"photo,2.jpg"}}},
+	} {
+		var items [][]string
+		creator := NewItemsWorkCreator("", 1, func(i []string) (work Work, err *data.CodeError) {
+			items = append(items, i)
+			return &testWork{}, nil
+		})
+		_ = setupItemsWorkCreator(creator, c.format, nil, false)
+		provider, _ := NewReaderWorkProvider(strings.NewReader(c.input), creator)
+		for {
+			hasMore, _, err := provider.Provide()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !hasMore {
+				break
+			}
+		}
+		if !reflect.DeepEqual(items, c.items) {
+			t.Fatalf("input:%q should be split to %q, but:%q", c.input, c.items, items)
+		}
+	}
+}
+
+func TestReaderWorkProviderInvalidLine(t *testing.T) {
+	creator := NewItemsWorkCreator("", 1, func(i []string) (work Work, err *data.CodeError) {
+		return &testWork{}, nil
+	})
+	_ = setupItemsWorkCreator(creator, InputFormatJson, nil, false)
+	provider, _ := NewReaderWorkProvider(strings.NewReader("[\"a\"]\n{\"key\":\n"), creator)
+	if _, _, err := provider.Provide(); err != nil {
+		t.Fatal(err)
+	}
+	_, work, err := provider.Provide()
+	if err == nil || err.Code != data.ErrorCodeLineInvalid || !strings.HasPrefix(err.Desc, "line 2,") {
+		t.Fatalf("invalid line should be reported with line number, but:%v", err)
+	}
+	if work.Data != "{\"key\":" {
+		t.Fatalf("work data should be the input line, but:%s", work.Data)
+	}
+}
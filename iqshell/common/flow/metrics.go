@@ -0,0 +1,51 @@
+package flow
+
+import (
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/metrics"
+)
+
+// metricsEventListener 在 listener 的基础上统计 work 的处理结果及耗时，flow 执行期间由 pusher 在后台定时上报
+func metricsEventListener(listener EventListener, pusher *metrics.Pusher) EventListener {
+	collector := pusher.Collector()
+	return EventListener{
+		FlowWillStartFunc: func(flow *Flow) (err *data.CodeError) {
+			collector.SetLimitHitsFunc(flow.LimitHitCount)
+			pusher.Start()
+			return listener.FlowWillStart(flow)
+		},
+		FlowWillEndFunc: func(flow *Flow, unprocessedCount int64) (err *data.CodeError) {
+			err = listener.FlowWillEnd(flow, unprocessedCount)
+			pusher.Stop()
+			return err
+		},
+		WillWorkFunc: listener.WillWorkFunc,
+		OnWorkSkipFunc: func(work *WorkInfo, result Result, err *data.CodeError) {
+			collector.AddSkip()
+			listener.OnWorkSkip(work, result, err)
+		},
+		OnWorkSuccessFunc: func(work *WorkInfo, result Result, stat *WorkStat) {
+			var size int64
+			if w, ok := work.Work.(SizeWork); ok {
+				if s, sErr := w.GetSize(); sErr == nil {
+					size = s
+				}
+			}
+			collector.AddSuccess(size, statDuration(stat))
+			listener.OnWorkSuccess(work, result, stat)
+		},
+		OnWorkFailFunc: func(work *WorkInfo, err *data.CodeError, stat *WorkStat) {
+			collector.AddFailure(statDuration(stat))
+			listener.OnWorkFail(work, err, stat)
+		},
+	}
+}
+
+func statDuration(stat *WorkStat) time.Duration {
+	if stat == nil {
+		return 0
+	}
+	return stat.Duration
+}
@@ -1,11 +1,18 @@
 package flow
 
-import "github.com/qiniu/qshell/v2/iqshell/common/data"
+import (
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
 
 type Overseer interface {
 	WillWork(work *WorkInfo)
 	WorkDone(record *WorkRecord)
 	GetWorkRecordIfHasDone(work *WorkInfo) (hasDone bool, record *WorkRecord)
+
+	// Flush 将已记录的工作状态落盘，flow 结束或被中断时调用
+	Flush() *data.CodeError
 }
 
 type WorkRecord struct {
@@ -13,4 +20,35 @@ type WorkRecord struct {
 
 	Result Result          `json:"result"`
 	Err    *data.CodeError `json:"err"`
+	Stat   *WorkStat       `json:"stat,omitempty"`
+}
+
+// WorkStat work 的执行统计信息
+type WorkStat struct {
+	StartedAt time.Time     `json:"started_at"` // 开始执行的时间
+	Duration  time.Duration `json:"duration"`   // 执行耗时
+	Attempt   int           `json:"attempt"`    // 执行次数，从 1 开始，包含之前被重做前的执行次数
+}
+
+type readOnlyOverseer struct {
+	overseer Overseer
+}
+
+// NewReadOnlyOverseer 只查询工作记录，不记录工作状态；用于 dry run 等不实际执行工作的场景
+func NewReadOnlyOverseer(overseer Overseer) Overseer {
+	return &readOnlyOverseer{overseer: overseer}
+}
+
+func (r *readOnlyOverseer) WillWork(work *WorkInfo) {
+}
+
+func (r *readOnlyOverseer) WorkDone(record *WorkRecord) {
+}
+
+func (r *readOnlyOverseer) GetWorkRecordIfHasDone(work *WorkInfo) (hasDone bool, record *WorkRecord) {
+	return r.overseer.GetWorkRecordIfHasDone(work)
+}
+
+func (r *readOnlyOverseer) Flush() *data.CodeError {
+	return nil
 }
@@ -2,17 +2,26 @@ package flow
 
 import (
 	"encoding/json"
+	"sync"
+	"time"
+
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/recorder"
 )
 
+const (
+	defaultOverseerFlushCount    = 100
+	defaultOverseerFlushInterval = 3 * time.Second
+)
+
 func NewDBRecordOverseer(dbPath string, blankWorkRecordBuilder func() *WorkRecord) (Overseer, *data.CodeError) {
 	if r, err := recorder.CreateDBRecorder(dbPath); err != nil {
-		return nil, err
+		return nil, data.NewEmptyError().AppendDescF("overseer db:%s", dbPath).AppendError(err)
 	} else {
 		return &localDBRecordOverseer{
 			Recorder:               r,
 			BlankWorkRecordBuilder: blankWorkRecordBuilder,
+			lastFlushTime:          time.Now(),
 		}, nil
 	}
 }
@@ -20,6 +29,13 @@ func NewDBRecordOverseer(dbPath string, blankWorkRecordBuilder func() *WorkRecor
 type localDBRecordOverseer struct {
 	Recorder               recorder.Recorder
 	BlankWorkRecordBuilder func() *WorkRecord
+
+	mu             sync.Mutex // 多个 worker 会并发调用 WorkDone，写入需串行
+	notFlushCount  int        // 上次落盘后未落盘的记录数
+	lastKey        string     // 最后一条未落盘记录的 key
+	lastValue      string     // 最后一条未落盘记录的 value
+	lastFlushTime  time.Time  // 上次落盘的时间
+	lastFlushError *data.CodeError
 }
 
 func (l *localDBRecordOverseer) WillWork(work *WorkInfo) {
@@ -62,12 +78,28 @@ func (l *localDBRecordOverseer) GetWorkRecordIfHasDone(work *WorkInfo) (hasDone
 	}
 }
 
+func (l *localDBRecordOverseer) Flush() *data.CodeError {
+	if l == nil || l.Recorder == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.notFlushCount == 0 {
+		return l.lastFlushError
+	}
+	// 重新同步写入最后一条记录，leveldb 会将此前所有的写入一并落盘
+	l.flush(l.lastKey, l.lastValue)
+	return l.lastFlushError
+}
+
 func (l *localDBRecordOverseer) getWorkStatus(work *WorkInfo) *workStatus {
-	if l == nil || l.Recorder == nil || work == nil || work.Work == nil || l.BlankWorkRecordBuilder == nil {
+	if l == nil || l.Recorder == nil || l.BlankWorkRecordBuilder == nil {
 		return nil
 	}
 
-	workId := work.Work.WorkId()
+	workId := getWorkRecordId(work)
 	if len(workId) == 0 {
 		return nil
 	}
@@ -95,11 +127,11 @@ func (l *localDBRecordOverseer) getWorkStatus(work *WorkInfo) *workStatus {
 }
 
 func (l *localDBRecordOverseer) setWorkStatus(work *WorkInfo, status *workStatus) {
-	if l == nil || l.Recorder == nil || work == nil || work.Work == nil || status == nil {
+	if l == nil || l.Recorder == nil || status == nil {
 		return
 	}
 
-	workId := work.Work.WorkId()
+	workId := getWorkRecordId(work)
 	if len(workId) == 0 {
 		return
 	}
@@ -109,7 +141,39 @@ func (l *localDBRecordOverseer) setWorkStatus(work *WorkInfo, status *workStatus
 		return
 	}
 
-	_ = l.Recorder.Put(workId, value)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.notFlushCount++
+	if l.notFlushCount >= defaultOverseerFlushCount ||
+		time.Since(l.lastFlushTime) >= defaultOverseerFlushInterval {
+		l.flush(workId, value)
+	} else if err := l.Recorder.Put(workId, value); err == nil {
+		l.lastKey = workId
+		l.lastValue = value
+	}
+}
+
+// flush 调用方需持有锁
+func (l *localDBRecordOverseer) flush(key, value string) {
+	l.lastFlushError = l.Recorder.PutAndSync(key, value)
+	l.lastFlushTime = time.Now()
+	if l.lastFlushError == nil {
+		l.notFlushCount = 0
+	}
+}
+
+// getWorkRecordId 记录的 key，优先使用 work 的 WorkId，没有则使用 work 的原始数据
+func getWorkRecordId(work *WorkInfo) string {
+	if work == nil {
+		return ""
+	}
+	if work.Work != nil {
+		if workId := work.Work.WorkId(); len(workId) > 0 {
+			return workId
+		}
+	}
+	return work.Data
 }
 
 var (
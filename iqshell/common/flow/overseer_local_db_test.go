@@ -0,0 +1,150 @@
+package flow
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+type testWork struct {
+	Key string `json:"key"`
+}
+
+func (w *testWork) WorkId() string {
+	return w.Key
+}
+
+type testResult struct {
+	Value string `json:"value"`
+}
+
+func (r *testResult) IsValid() bool {
+	return len(r.Value) > 0
+}
+
+func TestDBRecordOverseer(t *testing.T) {
+	overseer, err := NewDBRecordOverseer(filepath.Join(t.TempDir(), ".recorder"), func() *WorkRecord {
+		return &WorkRecord{
+			WorkInfo: &WorkInfo{Work: &testWork{}},
+			Result:   &testResult{},
+		}
+	})
+	if err != nil {
+		t.Fatalf("create overseer error:%v", err)
+	}
+
+	wait := &sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wait.Add(1)
+		go func(index int) {
+			defer wait.Done()
+			key := string(rune('a' + index))
+			record := &WorkRecord{
+				WorkInfo: &WorkInfo{Data: key, Work: &testWork{Key: key}},
+				Result:   &testResult{Value: key},
+			}
+			if index%2 == 1 {
+				record.Err = data.NewError(612, "no such file or directory")
+			}
+			overseer.WorkDone(record)
+		}(i)
+	}
+	wait.Wait()
+
+	if fErr := overseer.Flush(); fErr != nil {
+		t.Fatalf("flush error:%v", fErr)
+	}
+
+	hasDone, record := overseer.GetWorkRecordIfHasDone(&WorkInfo{Work: &testWork{Key: "a"}})
+	if !hasDone || record.Err != nil {
+		t.Fatalf("work a should be done without error, hasDone:%v record:%+v", hasDone, record)
+	}
+	if result, ok := record.Result.(*testResult); !ok || result.Value != "a" {
+		t.Fatalf("work a result error:%+v", record.Result)
+	}
+
+	hasDone, record = overseer.GetWorkRecordIfHasDone(&WorkInfo{Work: &testWork{Key: "b"}})
+	if !hasDone || record.Err == nil || record.Err.Code != 612 {
+		t.Fatalf("work b should be done with error 612, hasDone:%v record:%+v", hasDone, record)
+	}
+
+	if hasDone, _ = overseer.GetWorkRecordIfHasDone(&WorkInfo{Work: &testWork{Key: "z"}}); hasDone {
+		t.Fatal("work z should not be done")
+	}
+}
+
+func TestFlowCheckOverseerLocked(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), ".recorder")
+	db, err := leveldb.OpenFile(dbPath, nil)
+	if err != nil {
+		t.Fatalf("open db error:%v", err)
+	}
+	defer db.Close()
+
+	f := New(Info{}).
+		WorkProviderWithArray([]Work{&testWork{Key: "a"}}).
+		WorkerProvider(NewWorkerProvider(func() (Worker, *data.CodeError) {
+			return NewSimpleWorker(func(workInfo *WorkInfo) (Result, *data.CodeError) {
+				return &testResult{Value: "a"}, nil
+			}), nil
+		})).
+		SetOverseerEnable(true).
+		SetDBOverseer(dbPath, nil).
+		Build()
+	if cErr := f.Check(); cErr == nil {
+		t.Fatal("flow check should fail when overseer db is locked")
+	}
+}
+
+type testSyncRecorder struct {
+	values    map[string]string
+	syncCount int
+}
+
+func (r *testSyncRecorder) Get(key string) (string, *data.CodeError) {
+	return r.values[key], nil
+}
+
+func (r *testSyncRecorder) Put(key, value string) *data.CodeError {
+	r.values[key] = value
+	return nil
+}
+
+func (r *testSyncRecorder) PutAndSync(key, value string) *data.CodeError {
+	r.syncCount++
+	return r.Put(key, value)
+}
+
+func (r *testSyncRecorder) Delete(key string) *data.CodeError {
+	delete(r.values, key)
+	return nil
+}
+
+func TestDBRecordOverseerFlushWithoutExtraRecord(t *testing.T) {
+	r := &testSyncRecorder{values: make(map[string]string)}
+	overseer := &localDBRecordOverseer{
+		Recorder:      r,
+		lastFlushTime: time.Now(),
+	}
+	for _, key := range []string{"a", "b"} {
+		overseer.WorkDone(&WorkRecord{
+			WorkInfo: &WorkInfo{Data: key, Work: &testWork{Key: key}},
+			Result:   &testResult{Value: key},
+		})
+	}
+
+	if fErr := overseer.Flush(); fErr != nil {
+		t.Fatalf("flush error:%v", fErr)
+	}
+	if r.syncCount != 1 {
+		t.Fatalf("flush should sync once, got:%d", r.syncCount)
+	}
+	if len(r.values) != 2 {
+		t.Fatalf("flush should not write extra records, got:%v", r.values)
+	}
+}
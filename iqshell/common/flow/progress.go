@@ -0,0 +1,49 @@
+package flow
+
+import (
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/progress"
+)
+
+// progressEventListener 在 listener 的基础上根据 work 的处理事件展示整体进度，
+// work 总数来源于 WorkProvider.WorkTotalCount()，work 大小来源于 SizeWork
+func progressEventListener(listener EventListener, title string) EventListener {
+	var p *progress.Batch
+	addWork := func(work *WorkInfo) {
+		size := int64(0)
+		if w, ok := work.Work.(SizeWork); ok {
+			if s, err := w.GetSize(); err == nil {
+				size = s
+			}
+		}
+		p.Add(1, size)
+	}
+
+	return EventListener{
+		FlowWillStartFunc: func(flow *Flow) (err *data.CodeError) {
+			if err = listener.FlowWillStart(flow); err != nil {
+				return err
+			}
+			p = progress.NewBatchProgress(title, flow.WorkProvider.WorkTotalCount())
+			p.SetTotalSize(flow.WorkTotalSize)
+			return nil
+		},
+		FlowWillEndFunc: func(flow *Flow, unprocessedCount int64) (err *data.CodeError) {
+			p.End()
+			return listener.FlowWillEnd(flow, unprocessedCount)
+		},
+		WillWorkFunc: listener.WillWorkFunc,
+		OnWorkSkipFunc: func(work *WorkInfo, result Result, err *data.CodeError) {
+			addWork(work)
+			listener.OnWorkSkip(work, result, err)
+		},
+		OnWorkSuccessFunc: func(work *WorkInfo, result Result, stat *WorkStat) {
+			addWork(work)
+			listener.OnWorkSuccess(work, result, stat)
+		},
+		OnWorkFailFunc: func(work *WorkInfo, err *data.CodeError, stat *WorkStat) {
+			addWork(work)
+			listener.OnWorkFail(work, err, stat)
+		},
+	}
+}
@@ -2,6 +2,7 @@ package flow
 
 import "github.com/qiniu/qshell/v2/iqshell/common/data"
 
+// Skipper 检测 work 是否需要跳过；skip 为 false 但 cause 不为 nil 时表示检测出错，此 work 按失败处理
 type Skipper interface {
 	ShouldSkip(work *WorkInfo) (skip bool, cause *data.CodeError)
 }
@@ -0,0 +1,98 @@
+package flow
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// KeyWork 可提供 key 的 work，按 key 过滤时使用；未实现此接口的 work 使用 WorkInfo.Data 进行匹配
+type KeyWork interface {
+	GetKey() string
+}
+
+// NewRegexSkipper 按 key 正则过滤 work：include 不为空时 key 需匹配其中任一正则，且 key 不能匹配 exclude 中的任一正则，否则跳过
+func NewRegexSkipper(include, exclude []string) (Skipper, *data.CodeError) {
+	s := &regexSkipper{}
+	var err *data.CodeError
+	if s.include, err = compileRegexes("include", include); err != nil {
+		return nil, err
+	}
+	if s.exclude, err = compileRegexes("exclude", exclude); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func compileRegexes(name string, patterns []string) ([]*regexp.Regexp, *data.CodeError) {
+	regexes := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if len(pattern) == 0 {
+			continue
+		}
+		r, e := regexp.Compile(pattern)
+		if e != nil {
+			return nil, alert.Error(fmt.Sprintf("invalid %s regex `%s`, %v", name, pattern, e), "")
+		}
+		regexes = append(regexes, r)
+	}
+	return regexes, nil
+}
+
+type regexSkipper struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+func (s *regexSkipper) ShouldSkip(work *WorkInfo) (skip bool, cause *data.CodeError) {
+	key := work.Data
+	if w, ok := work.Work.(KeyWork); ok {
+		key = w.GetKey()
+	}
+
+	if len(s.include) > 0 {
+		matched := false
+		for _, r := range s.include {
+			if r.MatchString(key) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return true, data.NewError(data.ErrorCodeSkipByFilter, fmt.Sprintf("key `%s` doesn't match include regex", key))
+		}
+	}
+
+	for _, r := range s.exclude {
+		if r.MatchString(key) {
+			return true, data.NewError(data.ErrorCodeSkipByFilter, fmt.Sprintf("key `%s` match exclude regex `%s`", key, r))
+		}
+	}
+	return false, nil
+}
+
+// NewSkippers 组合多个 Skipper，任一 Skipper 需要跳过时即跳过
+func NewSkippers(skippers ...Skipper) Skipper {
+	list := make([]Skipper, 0, len(skippers))
+	for _, s := range skippers {
+		if s != nil {
+			list = append(list, s)
+		}
+	}
+	return &multiSkipper{skippers: list}
+}
+
+type multiSkipper struct {
+	skippers []Skipper
+}
+
+func (m *multiSkipper) ShouldSkip(work *WorkInfo) (skip bool, cause *data.CodeError) {
+	for _, s := range m.skippers {
+		if skip, cause = s.ShouldSkip(work); skip {
+			return
+		}
+	}
+	return false, nil
+}
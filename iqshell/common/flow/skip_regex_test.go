@@ -0,0 +1,51 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+type testKeyWork struct {
+	key string
+}
+
+func (w *testKeyWork) WorkId() string {
+	return w.key
+}
+
+func (w *testKeyWork) GetKey() string {
+	return w.key
+}
+
+func TestRegexSkipper(t *testing.T) {
+	if _, err := NewRegexSkipper([]string{"("}, nil); err == nil {
+		t.Fatal("invalid include regex should return error")
+	}
+
+	skipper, err := NewRegexSkipper([]string{`^img/`, `\.mp4$`}, []string{`\.tmp$`})
+	if err != nil {
+		t.Fatal("create regex skipper error:", err)
+	}
+
+	cases := map[string]bool{
+		"img/a.jpg":     false,
+		"img/a.jpg.tmp": true,
+		"video/b.mp4":   false,
+		"doc/c.txt":     true,
+	}
+	for key, shouldSkip := range cases {
+		skip, cause := skipper.ShouldSkip(&WorkInfo{Data: "line", Work: &testKeyWork{key: key}})
+		if skip != shouldSkip {
+			t.Fatalf("key:%s skip should be %v", key, shouldSkip)
+		}
+		if skip && (cause == nil || cause.Code != data.ErrorCodeSkipByFilter) {
+			t.Fatalf("key:%s skip cause error:%v", key, cause)
+		}
+	}
+
+	// 未实现 KeyWork 的 work 使用 Data 匹配
+	if skip, _ := skipper.ShouldSkip(&WorkInfo{Data: "img/d.png"}); skip {
+		t.Fatal("data img/d.png should not be skipped")
+	}
+}
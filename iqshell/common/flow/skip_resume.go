@@ -0,0 +1,91 @@
+package flow
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+// NewResumeSkipper 加载上次执行的成功列表，跳过其中已成功的 work，不使用本地记录（DB）时可用于接续执行；
+// 成功列表的每行为 work 的输入行，行尾可能追加了以 \t 分隔的其他信息，如：下载使用的 host
+func NewResumeSkipper(successListFile string) (Skipper, *data.CodeError) {
+	file, err := os.Open(successListFile)
+	if err != nil {
+		return nil, alert.Error(fmt.Sprintf("open resume file:%s error:%v", successListFile, err), "")
+	}
+	defer file.Close()
+
+	s, count, rErr := newResumeSkipper(file)
+	if rErr != nil {
+		return nil, alert.Error(fmt.Sprintf("read resume file:%s error:%v", successListFile, rErr), "")
+	}
+	log.InfoF("resume from %s, %d succeeded item(s) loaded", successListFile, count)
+	return s, nil
+}
+
+func newResumeSkipper(reader io.Reader) (s *resumeSkipper, count int, err error) {
+	s = &resumeSkipper{
+		lines:    make(map[string]struct{}),
+		prefixes: make(map[string]struct{}),
+	}
+	r := bufio.NewReader(reader)
+	for {
+		line, rErr := r.ReadString('\n')
+		if rErr != nil && rErr != io.EOF {
+			return nil, count, rErr
+		}
+
+		// 兼容 BOM、\r\n 换行及空行
+		if count == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(strings.TrimSpace(line)) > 0 {
+			s.add(line)
+			count++
+		}
+
+		if rErr == io.EOF {
+			return s, count, nil
+		}
+	}
+}
+
+type resumeSkipper struct {
+	lines    map[string]struct{} // 成功列表中的整行
+	prefixes map[string]struct{} // 整行中以 \t 分隔的各个前缀，行尾追加的信息不影响输入行的匹配
+}
+
+func (s *resumeSkipper) add(line string) {
+	s.lines[line] = struct{}{}
+	for i := strings.LastIndex(line, "\t"); i > 0; i = strings.LastIndex(line, "\t") {
+		line = line[:i]
+		s.prefixes[line] = struct{}{}
+	}
+}
+
+func hasItem(items map[string]struct{}, item string) bool {
+	if len(item) == 0 {
+		return false
+	}
+	_, ok := items[item]
+	return ok
+}
+
+func (s *resumeSkipper) ShouldSkip(work *WorkInfo) (skip bool, cause *data.CodeError) {
+	line := strings.TrimRight(work.Data, "\r\n")
+	if hasItem(s.lines, line) || hasItem(s.prefixes, line) {
+		return true, data.NewError(data.ErrorCodeSkipByResume, "succeeded in the previous run")
+	}
+	// 成功列表中仅有 key 时按 key 匹配；不匹配前缀，避免同一个 key 的其他操作被跳过，如：复制到多个目标
+	if w, ok := work.Work.(KeyWork); ok && hasItem(s.lines, w.GetKey()) {
+		return true, data.NewError(data.ErrorCodeSkipByResume, fmt.Sprintf("key `%s` succeeded in the previous run", w.GetKey()))
+	}
+	return false, nil
+}
@@ -0,0 +1,45 @@
+package flow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+func TestResumeSkipper(t *testing.T) {
+	successList := "\ufeffa.jpg\r\n\nsrc.jpg\tdest.jpg\r\nvideo/b.mp4\thttps://cdn.example.com\nonly-key"
+	skipper, count, err := newResumeSkipper(strings.NewReader(successList))
+	if err != nil {
+		t.Fatal("create resume skipper error:", err)
+	}
+	if count != 4 {
+		t.Fatalf("loaded count should be 4, but:%d", count)
+	}
+
+	cases := []struct {
+		data       string
+		key        string
+		shouldSkip bool
+	}{
+		{data: "a.jpg", key: "a.jpg", shouldSkip: true},
+		{data: "src.jpg\tdest.jpg", key: "src.jpg", shouldSkip: true},
+		{data: "src.jpg\tdest2.jpg", key: "src.jpg", shouldSkip: false},
+		{data: "video/b.mp4", key: "video/b.mp4", shouldSkip: true},
+		{data: "only-key\t2048", key: "only-key", shouldSkip: true},
+		{data: "c.jpg", key: "c.jpg", shouldSkip: false},
+	}
+	for _, c := range cases {
+		skip, cause := skipper.ShouldSkip(&WorkInfo{Data: c.data, Work: &testKeyWork{key: c.key}})
+		if skip != c.shouldSkip {
+			t.Fatalf("line:%s skip should be %v", c.data, c.shouldSkip)
+		}
+		if skip && (cause == nil || cause.Code != data.ErrorCodeSkipByResume) {
+			t.Fatalf("line:%s skip cause should be skip by resume, but:%v", c.data, cause)
+		}
+	}
+
+	if _, err := NewResumeSkipper("/not/exist/success.txt"); err == nil {
+		t.Fatal("resume file not exist should return error")
+	}
+}
@@ -0,0 +1,50 @@
+package flow
+
+import (
+	"fmt"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+// SizeWork 可提供文件大小的 work，按大小过滤时使用；未实现此接口的 work 不做过滤
+type SizeWork interface {
+	GetSize() (int64, *data.CodeError)
+}
+
+// NewSizeSkipper 按文件大小过滤 work：大小小于 minSize 或大于 maxSize 时跳过，值小于等于 0 时不限制
+// 获取文件大小失败时不跳过，而是返回错误，flow 会将此 work 按失败处理
+func NewSizeSkipper(minSize, maxSize int64) Skipper {
+	return &sizeSkipper{
+		minSize: minSize,
+		maxSize: maxSize,
+	}
+}
+
+type sizeSkipper struct {
+	minSize int64
+	maxSize int64
+}
+
+func (s *sizeSkipper) ShouldSkip(work *WorkInfo) (skip bool, cause *data.CodeError) {
+	w, ok := work.Work.(SizeWork)
+	if !ok {
+		return false, nil
+	}
+
+	size, err := w.GetSize()
+	if err != nil {
+		return false, data.NewEmptyError().AppendDesc("get size for size filter").AppendError(err)
+	}
+
+	if s.minSize > 0 && size < s.minSize {
+		return true, data.NewError(data.ErrorCodeSkipByFilter, fmt.Sprintf("size %s is less than min size %s",
+			utils.FormatFileSize(size), utils.FormatFileSize(s.minSize)))
+	}
+
+	if s.maxSize > 0 && size > s.maxSize {
+		return true, data.NewError(data.ErrorCodeSkipByFilter, fmt.Sprintf("size %s is greater than max size %s",
+			utils.FormatFileSize(size), utils.FormatFileSize(s.maxSize)))
+	}
+	return false, nil
+}
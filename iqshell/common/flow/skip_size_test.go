@@ -0,0 +1,56 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+type testSizeWork struct {
+	size int64
+	err  *data.CodeError
+}
+
+func (w *testSizeWork) WorkId() string {
+	return "size"
+}
+
+func (w *testSizeWork) GetSize() (int64, *data.CodeError) {
+	return w.size, w.err
+}
+
+func TestSizeSkipper(t *testing.T) {
+	info := Info{MinSize: "1k", MaxSize: "1m"}
+	skippers, err := info.filterSkippers()
+	if err != nil || len(skippers) != 1 {
+		t.Fatal("create size skipper error:", err)
+	}
+	skipper := skippers[0]
+
+	cases := map[int64]bool{
+		100:         true,
+		1024:        false,
+		1024 * 1024: false,
+		2048 * 1024: true,
+	}
+	for size, shouldSkip := range cases {
+		skip, cause := skipper.ShouldSkip(&WorkInfo{Work: &testSizeWork{size: size}})
+		if skip != shouldSkip {
+			t.Fatalf("size:%d skip should be %v", size, shouldSkip)
+		}
+		if skip && (cause == nil || cause.Code != data.ErrorCodeSkipByFilter) {
+			t.Fatalf("size:%d skip cause error:%v", size, cause)
+		}
+	}
+
+	// 获取大小失败时不跳过，返回错误
+	skip, cause := skipper.ShouldSkip(&WorkInfo{Work: &testSizeWork{err: data.NewEmptyError().AppendDesc("stat error")}})
+	if skip || cause == nil {
+		t.Fatal("size skipper should return error when get size failed")
+	}
+
+	info = Info{MinSize: "10m", MaxSize: "1m"}
+	if _, err := info.filterSkippers(); err == nil {
+		t.Fatal("min size greater than max size should return error")
+	}
+}
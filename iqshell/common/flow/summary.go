@@ -0,0 +1,132 @@
+package flow
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+// Summary flow 结束时输出的汇总信息
+type Summary struct {
+	Total          int64   `json:"total"`            // work 总数，包含未被处理的 work
+	Success        int64   `json:"success"`          // 执行成功的 work 数
+	Failure        int64   `json:"failure"`          // 执行失败的 work 数
+	Skipped        int64   `json:"skipped"`          // 跳过的 work 数
+	Unprocessed    int64   `json:"unprocessed"`      // flow 提前结束时未被处理的 work 数
+	Bytes          int64   `json:"bytes"`            // 执行成功的 work 的总大小，来源于 SizeWork，单位：B
+	Duration       float64 `json:"duration"`         // 总耗时，单位：秒
+	ItemsPerSecond float64 `json:"items_per_second"` // 平均每秒处理的 work 数
+	BytesPerSecond float64 `json:"bytes_per_second"` // 平均吞吐量，单位：B/s
+	LimitHits      int64   `json:"limit_hits"`       // 触发限流的 work 数，触发限流后会减小并发数
+}
+
+// summaryOutput --format json 时输出的 JSON 对象，以 summary 字段区分于每个 work 的结果
+type summaryOutput struct {
+	Summary *Summary `json:"summary"`
+}
+
+// summaryEventListener 在 listener 的基础上统计 work 的处理结果，flow 结束时输出汇总信息
+func summaryEventListener(listener EventListener) EventListener {
+	var (
+		startTime                             time.Time
+		successCount, failureCount, skipCount int64
+		bytes                                 int64
+	)
+
+	return EventListener{
+		FlowWillStartFunc: func(flow *Flow) (err *data.CodeError) {
+			startTime = time.Now()
+			return listener.FlowWillStart(flow)
+		},
+		FlowWillEndFunc: func(flow *Flow, unprocessedCount int64) (err *data.CodeError) {
+			err = listener.FlowWillEnd(flow, unprocessedCount)
+
+			duration := time.Since(startTime)
+			s := &Summary{
+				Success:     atomic.LoadInt64(&successCount),
+				Failure:     atomic.LoadInt64(&failureCount),
+				Skipped:     atomic.LoadInt64(&skipCount),
+				Unprocessed: unprocessedCount,
+				Bytes:       atomic.LoadInt64(&bytes),
+				Duration:    duration.Seconds(),
+				LimitHits:   flow.LimitHitCount(),
+			}
+			s.Total = s.Success + s.Failure + s.Skipped + s.Unprocessed
+			if seconds := duration.Seconds(); seconds > 0 {
+				s.ItemsPerSecond = float64(s.Success+s.Failure) / seconds
+				s.BytesPerSecond = float64(s.Bytes) / seconds
+			}
+			outputSummary(s)
+			return err
+		},
+		WillWorkFunc: listener.WillWorkFunc,
+		OnWorkSkipFunc: func(work *WorkInfo, result Result, err *data.CodeError) {
+			// 同命令状态的统计：上次已执行的 work 按上次的结果统计，缺少参数的输入行按失败统计
+			if err != nil && err.Code == data.ErrorCodeAlreadyDone {
+				if isNilResult(result) || !result.IsValid() {
+					atomic.AddInt64(&failureCount, 1)
+				} else {
+					atomic.AddInt64(&successCount, 1)
+				}
+			} else if err != nil && err.Code == data.ErrorCodeParamMissing {
+				atomic.AddInt64(&failureCount, 1)
+			} else {
+				atomic.AddInt64(&skipCount, 1)
+			}
+			listener.OnWorkSkip(work, result, err)
+		},
+		OnWorkSuccessFunc: func(work *WorkInfo, result Result, stat *WorkStat) {
+			if isFailedResult(result) {
+				// 如：batch 操作中的单个操作失败
+				atomic.AddInt64(&failureCount, 1)
+				listener.OnWorkSuccess(work, result, stat)
+				return
+			}
+
+			atomic.AddInt64(&successCount, 1)
+			if w, ok := work.Work.(SizeWork); ok {
+				if size, sErr := w.GetSize(); sErr == nil {
+					atomic.AddInt64(&bytes, size)
+				}
+			}
+			listener.OnWorkSuccess(work, result, stat)
+		},
+		OnWorkFailFunc: func(work *WorkInfo, err *data.CodeError, stat *WorkStat) {
+			atomic.AddInt64(&failureCount, 1)
+			listener.OnWorkFail(work, err, stat)
+		},
+	}
+}
+
+func outputSummary(s *Summary) {
+	if data.IsOutputFormatJson() {
+		if summaryBytes, err := json.Marshal(&summaryOutput{Summary: s}); err == nil {
+			_, _ = fmt.Fprintln(data.Stdout(), string(summaryBytes))
+		}
+		return
+	}
+
+	log.Info("------------------ Summary -----------------")
+	log.InfoF("%20s%10d", "Total:", s.Total)
+	log.InfoF("%20s%10d", "Success:", s.Success)
+	log.InfoF("%20s%10d", "Failure:", s.Failure)
+	log.InfoF("%20s%10d", "Skipped:", s.Skipped)
+	if s.Unprocessed > 0 {
+		log.InfoF("%20s%10d", "Unprocessed:", s.Unprocessed)
+	}
+	if s.Bytes > 0 {
+		log.InfoF("%20s%10s", "Size:", utils.FormatFileSize(s.Bytes))
+	}
+	log.InfoF("%20s%10s", "Duration:", time.Duration(s.Duration*float64(time.Second)).Round(time.Millisecond))
+	log.InfoF("%20s%10.2f/s", "Throughput:", s.ItemsPerSecond)
+	if s.Bytes > 0 {
+		log.InfoF("%20s%10s/s", "Bandwidth:", utils.FormatFileSize(int64(s.BytesPerSecond)))
+	}
+	log.InfoF("%20s%10d", "Limit Hits:", s.LimitHits)
+	log.Info("--------------------------------------------")
+}
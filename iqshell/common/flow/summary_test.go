@@ -0,0 +1,88 @@
+package flow
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+type testSummaryOutput struct {
+	bytes.Buffer
+}
+
+func (o *testSummaryOutput) Close() error {
+	return nil
+}
+
+func TestFlowSummary(t *testing.T) {
+	output := &testSummaryOutput{}
+	stdout := data.Stdout()
+	data.SetStdout(output)
+	data.SetOutputFormat(data.OutputFormatJson)
+	defer func() {
+		data.SetStdout(stdout)
+		data.SetOutputFormat(data.OutputFormatText)
+		data.SetShowSummary(true)
+	}()
+
+	runFlow := func() {
+		works := []Work{
+			&testSizeWork{size: 100},
+			&testSizeWork{size: 200},
+			&testKeyWork{key: "limited"},
+			&testKeyWork{key: "skip"},
+			&testKeyWork{key: "failed"},
+		}
+		New(Info{
+			Force:       true,
+			WorkerCount: 1,
+		}).WorkProviderWithArray(works).
+			WorkerProvider(NewWorkerProvider(func() (Worker, *data.CodeError) {
+				return NewSimpleWorker(func(workInfo *WorkInfo) (Result, *data.CodeError) {
+					if w, ok := workInfo.Work.(*testKeyWork); ok && w.key == "failed" {
+						// 执行完成但结果为失败，按失败统计
+						return &testSuccessResult{success: false}, nil
+					}
+					if _, ok := workInfo.Work.(*testKeyWork); ok {
+						return nil, data.NewError(data.ErrorCodeTooManyRequests, "too many requests")
+					}
+					return &testResult{Value: "ok"}, nil
+				}), nil
+			})).
+			DoWorkListMaxCount(1).
+			DoWorkListMinCount(1).
+			ShouldSkip(func(workInfo *WorkInfo) (skip bool, cause *data.CodeError) {
+				if w, ok := workInfo.Work.(*testKeyWork); ok && w.key == "skip" {
+					return true, data.NewError(data.ErrorCodeSkipByFilter, "skip")
+				}
+				return false, nil
+			}).Build().Start()
+	}
+
+	// --no-summary 时不输出汇总信息
+	data.SetShowSummary(false)
+	runFlow()
+	if output.Len() > 0 {
+		t.Fatalf("summary should not be output with --no-summary, but:%s", output.String())
+	}
+
+	// 默认输出汇总信息
+	data.SetShowSummary(true)
+	runFlow()
+	out := &summaryOutput{}
+	if err := json.Unmarshal(output.Bytes(), out); err != nil || out.Summary == nil {
+		t.Fatalf("summary should be json, but:%s", output.String())
+	}
+	s := out.Summary
+	if s.Total != 5 || s.Success != 2 || s.Failure != 2 || s.Skipped != 1 {
+		t.Fatalf("summary count error:%+v", s)
+	}
+	if s.Bytes != 300 {
+		t.Fatalf("summary bytes should be 300, but:%d", s.Bytes)
+	}
+	if s.LimitHits != 1 {
+		t.Fatalf("summary limit hits should be 1, but:%d", s.LimitHits)
+	}
+}
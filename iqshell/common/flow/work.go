@@ -7,4 +7,8 @@ type Work interface {
 type WorkInfo struct {
 	Data string `json:"data"`
 	Work Work   `json:"work"`
+
+	LineNumber int64 `json:"-"` // 在输入文件中的行号，从 1 开始，非输入文件的 work 为 0
+
+	attempt int // 之前已执行的次数，work 被重做时有值
 }
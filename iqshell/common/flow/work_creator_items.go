@@ -2,33 +2,201 @@ package flow
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 )
 
 const DefaultLineItemSeparate = "\t"
 
+// IgnoredColumn 列映射中忽略的列
+const IgnoredColumn = "-"
+
 type itemsWorkCreator struct {
 	separate      string
+	format        string
+	fields        []string // 每行元素按顺序对应的字段名，为空时不支持列映射
+	requiredCount int      // fields 中前 requiredCount 个字段为必须的字段
+	hasHeader     bool     // 首行是否为标题行
+	headerSkipped bool
+	columnIndexes []int // fields 中每个字段在输入行中的列索引，-1 表示不存在；为空时不映射
 	minItemsCount int
 	creatorFunc   func(items []string) (work Work, err *data.CodeError)
 }
 
 func (l *itemsWorkCreator) Create(info string) (work Work, err *data.CodeError) {
-	items := utils.SplitString(info, l.separate)
+	items, err := l.split(info)
+	if err != nil {
+		return nil, err
+	}
+	if l.hasHeader && !l.headerSkipped {
+		l.headerSkipped = true
+		// 未指定 --columns 时按标题行映射，标题行中多余的列忽略
+		if len(l.fields) > 0 && l.columnIndexes == nil {
+			if l.columnIndexes, err = l.mapColumns(items, false); err != nil {
+				return nil, data.NewError(data.ErrorCodeColumnMissing, err.Desc)
+			}
+		}
+		return nil, data.NewError(data.ErrorCodeLineHeader, "This is header line")
+	}
+	if l.columnIndexes != nil {
+		items = l.mapItems(items)
+	}
 	if len(info) > 0 && len(items) >= l.minItemsCount {
 		return l.creatorFunc(items)
 	}
 	return nil, data.NewError(data.ErrorCodeParamMissing, fmt.Sprintf("at least %d parameter is required", l.minItemsCount))
 }
 
+func (l *itemsWorkCreator) split(info string) ([]string, *data.CodeError) {
+	switch l.format {
+	case InputFormatCsv:
+		items, err := splitCsvLine(strings.TrimPrefix(info, "\ufeff"), csvComma(l.separate))
+		if err != nil && len(strings.TrimSpace(info)) > 0 {
+			return nil, data.NewError(data.ErrorCodeLineInvalid, fmt.Sprintf("invalid csv line, %v", err))
+		}
+		return items, nil
+	case InputFormatJson:
+		if len(strings.TrimSpace(info)) == 0 {
+			return nil, nil
+		}
+		items, err := splitJsonLine(strings.TrimPrefix(info, "\ufeff"))
+		if err != nil {
+			return nil, data.NewError(data.ErrorCodeLineInvalid, fmt.Sprintf("invalid json line, %v", err))
+		}
+		return items, nil
+	default:
+		return utils.SplitString(info, l.separate), nil
+	}
+}
+
+// mapColumns 根据每列的名称计算 fields 中每个字段的列索引，字段名不区分大小写且忽略 _ 和 -；
+// strict 为 true 时不允许出现未知的列名，忽略的列需使用 -
+func (l *itemsWorkCreator) mapColumns(columns []string, strict bool) ([]int, *data.CodeError) {
+	indexes := make([]int, len(l.fields))
+	for i := range indexes {
+		indexes[i] = -1
+	}
+	for column, name := range columns {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if len(name) == 0 || name == IgnoredColumn {
+			continue
+		}
+		field := -1
+		for i, f := range l.fields {
+			if normalizeColumnName(f) == normalizeColumnName(name) {
+				field = i
+				break
+			}
+		}
+		if field < 0 {
+			if strict {
+				return nil, data.NewEmptyError().AppendDescF("unknown column:%s, should be one of %s, or %s to ignore the column",
+					name, strings.Join(l.fields, ","), IgnoredColumn)
+			}
+			continue
+		}
+		if indexes[field] >= 0 {
+			return nil, data.NewEmptyError().AppendDescF("column:%s is duplicated", name)
+		}
+		indexes[field] = column
+	}
+	for i := 0; i < l.requiredCount && i < len(l.fields); i++ {
+		if indexes[i] < 0 {
+			return nil, data.NewEmptyError().AppendDescF("column:%s is required, but not found in columns:%s",
+				l.fields[i], strings.Join(columns, ","))
+		}
+	}
+	return indexes, nil
+}
+
+// mapItems 把输入行中的元素按 fields 的顺序排列，缺少的字段为空字符串，末尾缺少的字段去除
+func (l *itemsWorkCreator) mapItems(items []string) []string {
+	count := 0
+	for field, column := range l.columnIndexes {
+		if column >= 0 && column < len(items) {
+			count = field + 1
+		}
+	}
+	mapped := make([]string, count)
+	for field := 0; field < count; field++ {
+		if column := l.columnIndexes[field]; column >= 0 && column < len(items) {
+			mapped[field] = items[column]
+		}
+	}
+	return mapped
+}
+
+func normalizeColumnName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "", " ", "").Replace(name))
+}
+
+func (l *itemsWorkCreator) needDetectInputFormat() bool {
+	return l.format == InputFormatAuto
+}
+
+func (l *itemsWorkCreator) detectInputFormat(lines []string) {
+	l.format = detectInputFormat(lines, csvComma(l.separate), l.hasHeader)
+	log.DebugF("input format is detected as %s", l.format)
+}
+
 func NewItemsWorkCreator(separate string, minItemsCount int, creatorFunc func(items []string) (work Work, err *data.CodeError)) WorkCreator {
+	return NewItemsWorkCreatorWithFields(separate, nil, 0, minItemsCount, creatorFunc)
+}
+
+// NewItemsWorkCreatorWithFields fields 为每行元素按顺序对应的字段名，如：key、dstKey，前 requiredCount 个为必须的字段；
+// 配置了 --columns 或 --has-header 时按字段名把输入行中的列映射为 fields 的顺序，缺少必须的字段时报错
+func NewItemsWorkCreatorWithFields(separate string, fields []string, requiredCount int, minItemsCount int,
+	creatorFunc func(items []string) (work Work, err *data.CodeError)) WorkCreator {
 	if len(separate) == 0 {
 		separate = DefaultLineItemSeparate
 	}
 	return &itemsWorkCreator{
 		separate:      separate,
+		format:        InputFormatTsv,
+		fields:        fields,
+		requiredCount: requiredCount,
 		minItemsCount: minItemsCount,
 		creatorFunc:   creatorFunc,
 	}
 }
+
+// setupItemsWorkCreator 设置输入文件的格式及列映射，仅对 NewItemsWorkCreator 创建的 WorkCreator 有效；
+// 自定义了分隔符（--sep）时不自动检测格式，仍按分隔符切分
+func setupItemsWorkCreator(creator WorkCreator, format string, columns []string, hasHeader bool) *data.CodeError {
+	if err := CheckInputFormat(format); err != nil {
+		return err
+	}
+
+	c, ok := creator.(*itemsWorkCreator)
+	if !ok {
+		if len(columns) > 0 {
+			return data.NewEmptyError().AppendDesc("column mapping (--columns) is not supported by this command")
+		}
+		return nil
+	}
+
+	if len(format) == 0 {
+		format = InputFormatAuto
+	}
+	if format == InputFormatAuto && c.separate != DefaultLineItemSeparate {
+		format = InputFormatTsv
+	}
+	c.format = format
+	c.hasHeader = hasHeader
+
+	if len(columns) == 0 {
+		return nil
+	}
+	if len(c.fields) == 0 {
+		return data.NewEmptyError().AppendDesc("column mapping (--columns) is not supported by this command")
+	}
+	indexes, err := c.mapColumns(columns, true)
+	if err != nil {
+		return err
+	}
+	c.columnIndexes = indexes
+	return nil
+}
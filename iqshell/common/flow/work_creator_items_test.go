@@ -0,0 +1,59 @@
+package flow
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+func TestItemsWorkCreatorColumns(t *testing.T) {
+	for _, c := range []struct {
+		columns   []string
+		hasHeader bool
+		lines     []string
+		items     [][]string
+	}{
+		{[]string{"-", "dstKey", "key"}, false, []string{"1\tb\ta", "2\t\tc", "3"}, [][]string{{"a", "b"}, {"c", ""}}},
+		{[]string{"-", "key"}, false, []string{"1\ta\tb"}, [][]string{{"a"}}},
+		{nil, true, []string{"ID\tDst_Key\tKey", "1\tb\ta"}, [][]string{{"a", "b"}}},
+		{[]string{"dstKey", "key"}, true, []string{"x\ty", "b\ta"}, [][]string{{"a", "b"}}},
+	} {
+		var items [][]string
+		creator := NewItemsWorkCreatorWithFields("", []string{"key", "dstKey"}, 1, 1, func(i []string) (work Work, err *data.CodeError) {
+			items = append(items, i)
+			return &testWork{}, nil
+		})
+		if err := setupItemsWorkCreator(creator, "", c.columns, c.hasHeader); err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range c.lines {
+			_, _ = creator.Create(line)
+		}
+		if !reflect.DeepEqual(items, c.items) {
+			t.Fatalf("columns:%q hasHeader:%v lines:%q should be mapped to %q, but:%q", c.columns, c.hasHeader, c.lines, c.items, items)
+		}
+	}
+}
+
+func TestItemsWorkCreatorColumnsError(t *testing.T) {
+	newCreator := func() WorkCreator {
+		return NewItemsWorkCreatorWithFields("", []string{"key", "dstKey"}, 2, 2, func(i []string) (work Work, err *data.CodeError) {
+			return &testWork{}, nil
+		})
+	}
+	for _, columns := range [][]string{{"key"}, {"key", "dst"}, {"key", "dstKey", "key"}} {
+		if err := setupItemsWorkCreator(newCreator(), "", columns, false); err == nil {
+			t.Fatalf("columns:%q should be invalid", columns)
+		}
+	}
+	if err := setupItemsWorkCreator(NewItemsWorkCreator("", 1, nil), "", []string{"key"}, false); err == nil {
+		t.Fatal("columns should not be supported without fields")
+	}
+
+	creator := newCreator()
+	_ = setupItemsWorkCreator(creator, "", nil, true)
+	if _, err := creator.Create("key\tsize"); err == nil || err.Code != data.ErrorCodeColumnMissing {
+		t.Fatalf("header without required column should be error, but:%v", err)
+	}
+}
@@ -3,7 +3,6 @@ package flow
 import (
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/limit"
-	"math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -35,6 +34,13 @@ func IncreaseLimitCountPeriod(period time.Duration) AutoLimitOption {
 	}
 }
 
+// Backoff 触发限制错误后的退避策略，默认为 NewExponentialBackoff()
+func Backoff(policy BackoffPolicy) AutoLimitOption {
+	return func(l *autoLimit) {
+		l.backoff = policy
+	}
+}
+
 func NewBlockLimit(limitCount int, options ...AutoLimitOption) limit.BlockLimit {
 	l := &autoLimit{
 		mu:                       sync.RWMutex{},
@@ -45,6 +51,7 @@ func NewBlockLimit(limitCount int, options ...AutoLimitOption) limit.BlockLimit
 		increaseLimitCountPeriod: 30 * time.Second,
 		lastLimitCountChangeTime: time.Now(),
 		increaseLimitCount:       10,
+		backoff:                  NewExponentialBackoff(),
 	}
 	for _, option := range options {
 		option(l)
@@ -64,9 +71,15 @@ type autoLimit struct {
 	increaseLimitCount       int              // 增加幅度
 	shouldWait               bool             //
 	notReleaseCount          int64            //
+	backoff                  BackoffPolicy    // 触发限制错误后的退避策略
+	hitCount                 int64            // 累计触发限制错误的次数，限制数自动增长后清零
 }
 
 func (l *autoLimit) check() {
+	if l.backoff == nil {
+		l.backoff = NewExponentialBackoff()
+	}
+
 	if l.limitCount < 1 {
 		l.limitCount = 1
 	}
@@ -101,6 +114,7 @@ func (l *autoLimit) Release(count int) {
 	l.blockLimit.Release(count)
 }
 
+// AddLimitCount count 小于 0 时表示触发了 -count 次限制错误，实际减小的数量由 BackoffPolicy 决定
 func (l *autoLimit) AddLimitCount(count int) {
 	if count == 0 {
 		return
@@ -109,6 +123,12 @@ func (l *autoLimit) AddLimitCount(count int) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	if count < 0 {
+		l.hitCount += int64(-1 * count)
+		count = -1 * l.backoff.DecreaseCount(l.limitCount, int64(-1*count))
+		// 即使不减小限制数，也需要等待
+		l.shouldWait = true
+	}
 	l.addLimitCount(count)
 }
 
@@ -151,10 +171,10 @@ func (l *autoLimit) shouldAutoIncreaseLimitCount() bool {
 }
 
 func (l *autoLimit) waitIfNeeded() {
-	waitTime := time.Millisecond * time.Duration(rand.Int31n(1000)+500)
-	for {
+	for attempt := 1; ; attempt++ {
 		if l.shouldAutoIncreaseLimitCount() {
 			l.addLimitCount(l.increaseLimitCount)
+			l.hitCount = 0
 		}
 
 		if !l.shouldWait {
@@ -164,6 +184,6 @@ func (l *autoLimit) waitIfNeeded() {
 		if l.notReleaseCount <= (int64(l.limitCount) / 3) {
 			l.shouldWait = false
 		}
-		time.Sleep(waitTime)
+		time.Sleep(l.backoff.NextDelay(attempt, l.hitCount))
 	}
 }
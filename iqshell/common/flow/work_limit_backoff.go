@@ -0,0 +1,67 @@
+package flow
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// BackoffPolicy 触发限制错误后的退避策略
+type BackoffPolicy interface {
+	// NextDelay 触发限制错误后，第 attempt 次（从 1 开始）等待的时长；hitCount 为累计触发限制错误的次数
+	NextDelay(attempt int, hitCount int64) time.Duration
+
+	// DecreaseCount 触发 hitCount 次限制错误时，限制数需要减小的数量；limitCount 为当前的限制数
+	DecreaseCount(limitCount int, hitCount int64) int
+}
+
+// ExponentialBackoff 指数退避，等待时长带有随机抖动
+type ExponentialBackoff struct {
+	BaseDelay    time.Duration // 首次等待时长
+	MaxDelay     time.Duration // 最大等待时长
+	Multiplier   float64       // 每次等待时长的增长倍数
+	Jitter       float64       // 抖动比例，范围：[0, 1]，实际等待时长在 delay * (1 ± Jitter) 之间
+	HalveOnLimit bool          // 触发限制错误时限制数减半，否则按触发次数减小
+}
+
+func NewExponentialBackoff() *ExponentialBackoff {
+	return &ExponentialBackoff{
+		BaseDelay:    time.Second,
+		MaxDelay:     10 * time.Second,
+		Multiplier:   2,
+		Jitter:       0.5,
+		HalveOnLimit: false,
+	}
+}
+
+func (b *ExponentialBackoff) NextDelay(attempt int, hitCount int64) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(b.BaseDelay) * math.Pow(multiplier, float64(attempt-1))
+	if b.MaxDelay > 0 && delay > float64(b.MaxDelay) {
+		delay = float64(b.MaxDelay)
+	}
+
+	jitter := math.Min(math.Max(b.Jitter, 0), 1)
+	if jitter > 0 {
+		delay = delay * (1 - jitter + 2*jitter*rand.Float64())
+	}
+	return time.Duration(delay)
+}
+
+func (b *ExponentialBackoff) DecreaseCount(limitCount int, hitCount int64) int {
+	if hitCount <= 0 {
+		return 0
+	}
+
+	if b.HalveOnLimit {
+		return limitCount / 2
+	}
+	return int(hitCount)
+}
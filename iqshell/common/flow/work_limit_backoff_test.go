@@ -0,0 +1,55 @@
+package flow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoffNextDelay(t *testing.T) {
+	b := &ExponentialBackoff{
+		BaseDelay:  100 * time.Millisecond,
+		MaxDelay:   time.Second,
+		Multiplier: 2,
+	}
+	if d := b.NextDelay(1, 1); d != 100*time.Millisecond {
+		t.Fatalf("attempt 1 delay should be 100ms, but:%s", d)
+	}
+	if d := b.NextDelay(3, 1); d != 400*time.Millisecond {
+		t.Fatalf("attempt 3 delay should be 400ms, but:%s", d)
+	}
+	if d := b.NextDelay(10, 1); d != time.Second {
+		t.Fatalf("attempt 10 delay should be limited to 1s, but:%s", d)
+	}
+
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := b.NextDelay(1, 1); d < 50*time.Millisecond || d > 150*time.Millisecond {
+			t.Fatalf("delay with jitter should in [50ms, 150ms], but:%s", d)
+		}
+	}
+}
+
+func TestExponentialBackoffDecreaseCount(t *testing.T) {
+	b := NewExponentialBackoff()
+	if c := b.DecreaseCount(20, 3); c != 3 {
+		t.Fatalf("decrease count should be 3, but:%d", c)
+	}
+
+	b.HalveOnLimit = true
+	if c := b.DecreaseCount(20, 3); c != 10 {
+		t.Fatalf("decrease count should be 10, but:%d", c)
+	}
+}
+
+func TestAutoLimitDecreaseByBackoff(t *testing.T) {
+	b := NewExponentialBackoff()
+	b.HalveOnLimit = true
+	l := NewBlockLimit(20, Backoff(b)).(*autoLimit)
+	l.AddLimitCount(-1)
+	if l.limitCount != 10 {
+		t.Fatalf("limit count should be halved to 10, but:%d", l.limitCount)
+	}
+	if !l.shouldWait {
+		t.Fatal("limit should wait after hit limit")
+	}
+}
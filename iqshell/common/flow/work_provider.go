@@ -4,7 +4,6 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
-	"os"
 )
 
 const UnknownWorkCount = int64(-1)
@@ -14,14 +13,20 @@ type WorkProvider interface {
 	Provide() (hasMore bool, work *WorkInfo, err *data.CodeError)
 }
 
+// StoppableWorkProvider Provide 可能阻塞的 WorkProvider，Flow 停止时会调用 Stop，Stop 后 Provide 应尽快返回
+type StoppableWorkProvider interface {
+	WorkProvider
+	Stop()
+}
+
 func NewWorkProviderOfFile(filepath string, enableStdin bool, creator WorkCreator) (provider WorkProvider, err *data.CodeError) {
-	if len(filepath) > 0 {
-		return NewFileWorkProvider(filepath, creator)
+	if filepath == StdinFilePath || (len(filepath) == 0 && enableStdin) {
+		log.InfoF("input info with stdin, you can end the input with Ctrl-D or cancel the task with Ctrl-C")
+		return newStdinWorkProviderWithCreator(creator)
 	}
 
-	if enableStdin {
-		log.InfoF("input info with stdin, you can end the input with Ctrl-D or cancel the task with Ctrl-C")
-		return NewReaderWorkProvider(os.Stdin, creator)
+	if len(filepath) > 0 {
+		return NewFileWorkProvider(filepath, creator)
 	}
 
 	return nil, alert.CannotEmptyError("FilePath (WorkProviderOfFile)", "")
@@ -2,6 +2,7 @@ package flow
 
 import (
 	"bufio"
+	"fmt"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"io"
@@ -23,9 +24,12 @@ func NewReaderWorkProvider(reader io.Reader, creator WorkCreator) (WorkProvider,
 }
 
 type readerWorkProvider struct {
-	mu      sync.Mutex
-	scanner *bufio.Scanner
-	creator WorkCreator
+	mu         sync.Mutex
+	scanner    *bufio.Scanner
+	creator    WorkCreator
+	lineNumber int64
+	sniffed    bool
+	pending    []string // 检测输入格式时预读的行
 }
 
 func (p *readerWorkProvider) WorkTotalCount() int64 {
@@ -40,17 +44,42 @@ func (p *readerWorkProvider) Provide() (hasMore bool, work *WorkInfo, err *data.
 }
 
 func (p *readerWorkProvider) provide() (hasMore bool, work *WorkInfo, err *data.CodeError) {
-	success := p.scanner.Scan()
-	if success {
-		line := p.scanner.Text()
-		if items := strings.Split(line, ErrorSeparate); len(items) > 0 {
-			line = items[0]
+	if !p.sniffed {
+		p.sniffed = true
+		if d, ok := p.creator.(inputFormatDetector); ok && d.needDetectInputFormat() {
+			for len(p.pending) < inputFormatSniffLineCount && p.scanner.Scan() {
+				p.pending = append(p.pending, p.scanner.Text())
+			}
+			d.detectInputFormat(p.pending)
 		}
-		w, e := p.creator.Create(line)
-		return true, &WorkInfo{
-			Data: line,
-			Work: w,
-		}, e
 	}
-	return false, &WorkInfo{}, nil
+
+	var line string
+	if len(p.pending) > 0 {
+		line = p.pending[0]
+		p.pending = p.pending[1:]
+	} else if p.scanner.Scan() {
+		line = p.scanner.Text()
+	} else {
+		return false, &WorkInfo{}, nil
+	}
+
+	p.lineNumber++
+	if items := strings.Split(line, ErrorSeparate); len(items) > 0 {
+		line = items[0]
+	}
+	w, e := p.creator.Create(line)
+	return true, &WorkInfo{
+		Data:       line,
+		Work:       w,
+		LineNumber: p.lineNumber,
+	}, lineError(p.lineNumber, e)
+}
+
+// lineError 在错误信息中加入行号，便于在失败列表中定位输入文件中的错误行
+func lineError(lineNumber int64, err *data.CodeError) *data.CodeError {
+	if err == nil || err.Code != data.ErrorCodeLineInvalid {
+		return err
+	}
+	return data.NewError(err.Code, fmt.Sprintf("line %d, %s", lineNumber, err.Desc))
 }
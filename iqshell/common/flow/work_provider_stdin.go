@@ -0,0 +1,170 @@
+package flow
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// StdinFilePath 输入文件路径为 - 时从 stdin 读取
+const StdinFilePath = "-"
+
+// stdin 无数据时 Provide 的最长等待时间，超时后返回 hasMore 为 true 且 work 为空，避免一直阻塞生产者
+const streamProvideTimeout = time.Second
+
+// NewStdinWorkProvider 逐行读取 stdin，每行由 parse 解析为 work
+func NewStdinWorkProvider(parse func(line string) (*WorkInfo, error)) (WorkProvider, *data.CodeError) {
+	return newStreamWorkProvider(os.Stdin, parse, nil, streamProvideTimeout)
+}
+
+// newStdinWorkProviderWithCreator 逐行读取 stdin，每行由 creator 创建 work，creator 需要时会根据前几行检测输入格式
+func newStdinWorkProviderWithCreator(creator WorkCreator) (WorkProvider, *data.CodeError) {
+	if creator == nil {
+		return nil, alert.CannotEmptyError("work creator (StreamWorkProvider)", "")
+	}
+	detector, _ := creator.(inputFormatDetector)
+	return newStreamWorkProvider(os.Stdin, creatorParseFunc(creator), detector, streamProvideTimeout)
+}
+
+// creatorParseFunc 将 WorkCreator 转换为行解析函数
+func creatorParseFunc(creator WorkCreator) func(line string) (*WorkInfo, error) {
+	return func(line string) (*WorkInfo, error) {
+		w, e := creator.Create(line)
+		info := &WorkInfo{
+			Data: line,
+			Work: w,
+		}
+		if e != nil {
+			return info, e
+		}
+		return info, nil
+	}
+}
+
+// newStreamWorkProvider 逐行读取 reader，读取在单独的 goroutine 中进行，Provide 不会无限阻塞；detector 可为空
+func newStreamWorkProvider(reader io.Reader, parse func(line string) (*WorkInfo, error), detector inputFormatDetector,
+	timeout time.Duration) (WorkProvider, *data.CodeError) {
+	if reader == nil {
+		return nil, alert.CannotEmptyError("work reader (StreamWorkProvider)", "")
+	}
+	if parse == nil {
+		return nil, alert.CannotEmptyError("work parse func (StreamWorkProvider)", "")
+	}
+
+	p := &streamWorkProvider{
+		lines:    make(chan streamLine, 100),
+		parse:    parse,
+		detector: detector,
+		timeout:  timeout,
+	}
+	go p.read(bufio.NewReader(reader))
+	return p, nil
+}
+
+type streamWorkProvider struct {
+	lines    chan streamLine
+	parse    func(line string) (*WorkInfo, error)
+	detector inputFormatDetector
+	timeout  time.Duration
+}
+
+type streamLine struct {
+	number int64
+	text   string
+}
+
+func (p *streamWorkProvider) read(reader *bufio.Reader) {
+	defer close(p.lines)
+
+	var lineNumber int64
+	send := func(line string) {
+		lineNumber++
+		p.lines <- streamLine{number: lineNumber, text: line}
+	}
+
+	var readErr error
+	if d := p.detector; d != nil && d.needDetectInputFormat() {
+		// stdin 可能持续输入，仅参考第一行及已经缓冲的行，不等待更多的输入
+		var pending []string
+		for readErr == nil && len(pending) < inputFormatSniffLineCount {
+			var line string
+			if line, readErr = readStreamLine(reader); len(line) > 0 || readErr == nil {
+				pending = append(pending, line)
+			}
+			if reader.Buffered() == 0 {
+				break
+			}
+		}
+		d.detectInputFormat(pending)
+		for _, line := range pending {
+			send(line)
+		}
+	}
+
+	for readErr == nil {
+		var line string
+		// 空行也需要计入行号
+		if line, readErr = readStreamLine(reader); len(line) > 0 || readErr == nil {
+			send(line)
+		}
+	}
+	if readErr != io.EOF {
+		log.ErrorF("read work from stream error:%v", readErr)
+	}
+}
+
+// readStreamLine 读取一行，兼容 CRLF 换行
+func readStreamLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+func (p *streamWorkProvider) WorkTotalCount() int64 {
+	return UnknownWorkCount
+}
+
+func (p *streamWorkProvider) Provide() (hasMore bool, work *WorkInfo, err *data.CodeError) {
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+
+	select {
+	case line, ok := <-p.lines:
+		if !ok {
+			return false, &WorkInfo{}, nil
+		}
+		text := line.text
+		if items := strings.Split(text, ErrorSeparate); len(items) > 0 {
+			text = items[0]
+		}
+		workInfo, e := p.parse(text)
+		if workInfo == nil {
+			workInfo = &WorkInfo{Data: text}
+		}
+		workInfo.LineNumber = line.number
+		return true, workInfo, lineError(line.number, streamParseError(e))
+	case <-timer.C:
+		// 暂无数据，命令被中断时不再等待
+		return !workspace.IsCmdInterrupt(), nil, nil
+	}
+}
+
+// streamParseError 保留解析函数返回的 CodeError 的错误码，其他错误按行无效处理
+func streamParseError(err error) *data.CodeError {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*data.CodeError); ok {
+		if e == nil {
+			return nil
+		}
+		return e
+	}
+	return data.NewError(data.ErrorCodeLineInvalid, err.Error())
+}
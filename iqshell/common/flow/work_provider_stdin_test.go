@@ -0,0 +1,86 @@
+package flow
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+type testWorkCreator struct{}
+
+func (c testWorkCreator) Create(info string) (work Work, err *data.CodeError) {
+	return &testWork{Key: info}, nil
+}
+
+func TestStreamWorkProviderCRLF(t *testing.T) {
+	reader, writer := io.Pipe()
+	provider, err := newStreamWorkProvider(reader, creatorParseFunc(testWorkCreator{}), nil, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 无数据时不阻塞
+	hasMore, work, err := provider.Provide()
+	if !hasMore || work != nil || err != nil {
+		t.Fatalf("provide without data should return has more and no work, but:%t %+v %v", hasMore, work, err)
+	}
+
+	go func() {
+		_, _ = writer.Write([]byte("a\r\nb\nc"))
+		_ = writer.Close()
+	}()
+
+	keys := make([]string, 0, 3)
+	for {
+		hasMore, work, err = provider.Provide()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !hasMore {
+			break
+		}
+		if work != nil && work.Work != nil {
+			keys = append(keys, work.Work.(*testWork).Key)
+		}
+	}
+	if len(keys) != 3 || keys[0] != "a" || keys[1] != "b" || keys[2] != "c" {
+		t.Fatalf("keys should be [a b c], but:%v", keys)
+	}
+}
+
+func TestStreamWorkProviderFlushPendingWorks(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+
+	provider, err := newStreamWorkProvider(reader, func(line string) (*WorkInfo, error) {
+		return &WorkInfo{Data: line, Work: &testWork{Key: line}}, nil
+	}, nil, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan string, 1)
+	f := New(Info{
+		Force:       true,
+		WorkerCount: 1,
+	}).WorkProvider(provider).
+		WorkerProvider(NewWorkerProvider(func() (Worker, *data.CodeError) {
+			return NewSimpleWorker(func(workInfo *WorkInfo) (Result, *data.CodeError) {
+				done <- workInfo.Data
+				return &testResult{Value: workInfo.Data}, nil
+			}), nil
+		})).Build()
+	go f.Start()
+
+	_, _ = writer.Write([]byte("a\n"))
+	select {
+	case key := <-done:
+		if key != "a" {
+			t.Fatalf("work should be a, but:%s", key)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("pending work should be processed before the input ends")
+	}
+}
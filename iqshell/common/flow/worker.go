@@ -1,6 +1,8 @@
 package flow
 
 import (
+	"time"
+
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 )
@@ -46,8 +48,12 @@ func (w *workerStruct) DoWork(workInfoList []*WorkInfo) ([]*WorkRecord, *data.Co
 		for _, workInfo := range workInfoList {
 			record := &WorkRecord{
 				WorkInfo: workInfo,
+				Stat: &WorkStat{
+					StartedAt: time.Now(),
+				},
 			}
 			record.Result, record.Err = w.SimpleDoFunc(workInfo)
+			record.Stat.Duration = time.Since(record.Stat.StartedAt)
 			recordList = append(recordList, record)
 		}
 		return recordList, nil
@@ -0,0 +1,119 @@
+package flow
+
+import (
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// NewRetryingWorkerProvider 为 provider 提供的 worker 增加重试，参考 NewRetryingWorker
+func NewRetryingWorkerProvider(provider WorkerProvider, maxRetry int, backoff BackoffPolicy) WorkerProvider {
+	return NewWorkerProvider(func() (Worker, *data.CodeError) {
+		worker, err := provider.Provide()
+		if err != nil {
+			return nil, err
+		}
+		return NewRetryingWorker(worker, maxRetry, backoff), nil
+	})
+}
+
+// NewRetryingWorker 对执行失败且错误可重试（参考 data.CodeError 的 IsRetryable）的 work 逐个重试，最多重试 maxRetry 次，
+// 每次重试前按 backoff 等待；重试后仍失败的 work 保留最后一次的错误
+func NewRetryingWorker(worker Worker, maxRetry int, backoff BackoffPolicy) Worker {
+	if backoff == nil {
+		backoff = NewExponentialBackoff()
+	}
+	return &retryingWorker{
+		worker:   worker,
+		maxRetry: maxRetry,
+		backoff:  backoff,
+	}
+}
+
+type retryingWorker struct {
+	worker   Worker
+	maxRetry int
+	backoff  BackoffPolicy
+}
+
+func (w *retryingWorker) DoWork(workInfos []*WorkInfo) ([]*WorkRecord, *data.CodeError) {
+	recordList, workErr := w.worker.DoWork(workInfos)
+	for attempt := 1; attempt <= w.maxRetry; attempt++ {
+		// 整组 work 执行出错且没有记录时，整组重试
+		if len(recordList) == 0 {
+			if !workErr.IsRetryable() || !w.waitForRetry(attempt, workErr) {
+				break
+			}
+			recordList, workErr = w.worker.DoWork(workInfos)
+			w.setAttempt(recordList, attempt+1)
+			continue
+		}
+
+		retryIndexes := make([]int, 0)
+		retryWorkInfos := make([]*WorkInfo, 0)
+		var retryErr *data.CodeError
+		for i, record := range recordList {
+			if err := recordError(record, workErr); err.IsRetryable() {
+				retryIndexes = append(retryIndexes, i)
+				retryWorkInfos = append(retryWorkInfos, record.WorkInfo)
+				retryErr = err
+			}
+		}
+		if len(retryWorkInfos) == 0 || !w.waitForRetry(attempt, retryErr) {
+			break
+		}
+
+		retryRecordList, retryWorkErr := w.worker.DoWork(retryWorkInfos)
+		if len(retryRecordList) != len(retryWorkInfos) {
+			// 重试的整组 work 执行出错，记录错误后继续重试
+			for _, i := range retryIndexes {
+				if retryWorkErr != nil {
+					recordList[i].Err = retryWorkErr
+				}
+				w.setAttempt(recordList[i:i+1], attempt+1)
+			}
+			continue
+		}
+
+		for j, i := range retryIndexes {
+			record := retryRecordList[j]
+			if (record.Result == nil || !record.Result.IsValid()) && record.Err == nil {
+				record.Err = retryWorkErr
+			}
+			recordList[i] = record
+			w.setAttempt(recordList[i:i+1], attempt+1)
+		}
+	}
+	return recordList, workErr
+}
+
+func (w *retryingWorker) waitForRetry(attempt int, err *data.CodeError) bool {
+	if workspace.IsCmdInterrupt() {
+		return false
+	}
+	delay := w.backoff.NextDelay(attempt, 0)
+	log.DebugF("work retry %d/%d after %s, because:%v", attempt, w.maxRetry, delay, err)
+	time.Sleep(delay)
+	return !workspace.IsCmdInterrupt()
+}
+
+func (w *retryingWorker) setAttempt(recordList []*WorkRecord, attempt int) {
+	for _, record := range recordList {
+		if record.Stat == nil {
+			record.Stat = &WorkStat{}
+		}
+		record.Stat.Attempt = attempt
+	}
+}
+
+func recordError(record *WorkRecord, workErr *data.CodeError) *data.CodeError {
+	if record.Err != nil {
+		return record.Err
+	}
+	if record.Result == nil || !record.Result.IsValid() {
+		return workErr
+	}
+	return nil
+}
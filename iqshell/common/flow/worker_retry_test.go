@@ -0,0 +1,48 @@
+package flow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+func TestRetryingWorker(t *testing.T) {
+	failures := map[string]*data.CodeError{
+		"transient": data.NewError(503, "service unavailable"),
+		"timeout":   data.NewEmptyError().AppendDesc("read tcp: i/o timeout"),
+		"permanent": data.NewError(404, "not found"),
+	}
+	attempts := make(map[string]int)
+	worker := NewSimpleWorker(func(workInfo *WorkInfo) (Result, *data.CodeError) {
+		attempts[workInfo.Data] += 1
+		// 临时错误前两次失败
+		if err := failures[workInfo.Data]; err != nil && (err.Code == 404 || attempts[workInfo.Data] <= 2) {
+			return nil, err
+		}
+		return &testResult{Value: workInfo.Data}, nil
+	})
+
+	backoff := &ExponentialBackoff{BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 2}
+	retryWorker := NewRetryingWorker(worker, 3, backoff)
+	workInfos := []*WorkInfo{{Data: "ok"}, {Data: "transient"}, {Data: "timeout"}, {Data: "permanent"}}
+	recordList, err := retryWorker.DoWork(workInfos)
+	if err != nil || len(recordList) != len(workInfos) {
+		t.Fatal("do work error:", err)
+	}
+
+	for i, record := range recordList {
+		if record.WorkInfo != workInfos[i] {
+			t.Fatalf("record %d work info not match", i)
+		}
+	}
+	if attempts["ok"] != 1 || attempts["transient"] != 3 || attempts["timeout"] != 3 || attempts["permanent"] != 1 {
+		t.Fatalf("attempts error:%v", attempts)
+	}
+	if recordList[1].Err != nil || recordList[1].Stat.Attempt != 3 {
+		t.Fatalf("transient work should success after retry, err:%v", recordList[1].Err)
+	}
+	if recordList[3].Err == nil || recordList[3].Err.Code != 404 {
+		t.Fatal("permanent work should fail without retry")
+	}
+}
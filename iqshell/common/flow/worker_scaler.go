@@ -0,0 +1,179 @@
+package flow
+
+import (
+	"sync"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+// workerScaler 管理 flow 的消费者（worker），开启动态调整后会根据 work 执行的耗时、吞吐量及限制错误
+// 在 [MinWorkerCount, MaxWorkerCount] 之间调整 worker 数，两次调整的间隔不小于 WorkerCountIncreasePeriod
+type workerScaler struct {
+	mu sync.Mutex
+
+	minCount    int           // 最小 worker 数
+	maxCount    int           // 最大 worker 数，为 0 时不开启动态调整
+	targetCount int           // 当前期望的 worker 数
+	cooldown    time.Duration // 两次调整的最小间隔
+
+	active    map[int]bool // 正在运行的消费者
+	running   int          // 正在运行的消费者数量
+	chanClose bool         // work 队列已关闭，不再创建新的消费者
+
+	// 当前统计周期内的数据
+	windowStart    time.Time
+	workCount      int64
+	workDuration   time.Duration
+	limitHitCount  int64
+	lastAvgLatency time.Duration // 上个统计周期 work 的平均耗时
+	lastThroughput float64       // 上个统计周期的吞吐量，单位：work/s
+	lastChangeTime time.Time     // 上次调整 worker 数的时间
+}
+
+func newWorkerScaler(info Info) *workerScaler {
+	now := time.Now()
+	return &workerScaler{
+		minCount:       info.MinWorkerCount,
+		maxCount:       info.MaxWorkerCount,
+		targetCount:    info.WorkerCount,
+		cooldown:       time.Duration(info.WorkerCountIncreasePeriod) * time.Second,
+		active:         make(map[int]bool),
+		windowStart:    now,
+		lastChangeTime: now,
+	}
+}
+
+func (s *workerScaler) enable() bool {
+	return s != nil && s.maxCount > 0
+}
+
+// startConsumer 启动一个消费者，start 在锁内调用，保证消费者全部结束后不会再启动新的消费者
+func (s *workerScaler) startConsumer(index int, start func(index int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.startConsumerWithoutLock(index, start)
+}
+
+func (s *workerScaler) startConsumerWithoutLock(index int, start func(index int)) {
+	if s.chanClose || s.active[index] {
+		return
+	}
+	s.active[index] = true
+	s.running++
+	start(index)
+}
+
+func (s *workerScaler) consumerDidEnd(index int) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active[index] {
+		delete(s.active, index)
+		s.running--
+	}
+}
+
+func (s *workerScaler) workChanDidClose() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.chanClose = true
+	s.mu.Unlock()
+}
+
+// shouldConsumerRetire 序号不小于期望 worker 数的消费者需要退出
+func (s *workerScaler) shouldConsumerRetire(index int) bool {
+	if !s.enable() {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return index >= s.targetCount
+}
+
+func (s *workerScaler) addWorkStat(count int, duration time.Duration) {
+	if !s.enable() || count <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	s.workCount += int64(count)
+	s.workDuration += duration
+	s.mu.Unlock()
+}
+
+func (s *workerScaler) addLimitHit(count int) {
+	if !s.enable() || count <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	s.limitHitCount += int64(count)
+	s.mu.Unlock()
+}
+
+// adjust 根据当前统计周期的数据调整期望 worker 数，并启动需要新增的消费者；缩减的消费者会在处理完当前 work 后自行退出
+func (s *workerScaler) adjust(start func(index int)) {
+	if !s.enable() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.chanClose || s.running == 0 || time.Since(s.lastChangeTime) < s.cooldown {
+		return
+	}
+
+	target := s.targetCount
+	if s.limitHitCount > 0 {
+		// 遇到限制错误，减小 worker 数
+		target -= 1
+	} else if s.workCount > 0 {
+		avgLatency := s.workDuration / time.Duration(s.workCount)
+		throughput := float64(s.workCount) / time.Since(s.windowStart).Seconds()
+		if s.lastAvgLatency > 0 && avgLatency > s.lastAvgLatency*3/2 && throughput <= s.lastThroughput {
+			// 耗时明显增加且吞吐量没有提升，说明网络等资源已饱和
+			target -= 1
+		} else {
+			target += 1
+		}
+		s.lastAvgLatency = avgLatency
+		s.lastThroughput = throughput
+	} else {
+		// 没有数据，不调整
+		return
+	}
+
+	if target > s.maxCount {
+		target = s.maxCount
+	}
+	if target < s.minCount {
+		target = s.minCount
+	}
+
+	s.windowStart = time.Now()
+	s.workCount = 0
+	s.workDuration = 0
+	s.limitHitCount = 0
+	s.lastChangeTime = time.Now()
+
+	if target != s.targetCount {
+		log.DebugF("work flow worker count change from %d to %d", s.targetCount, target)
+		s.targetCount = target
+	}
+
+	for i := 0; i < s.targetCount; i++ {
+		s.startConsumerWithoutLock(i, start)
+	}
+}
@@ -0,0 +1,72 @@
+package flow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWorkerScalerAdjust(t *testing.T) {
+	s := newWorkerScaler(Info{
+		WorkerCount:    2,
+		MinWorkerCount: 1,
+		MaxWorkerCount: 3,
+	})
+
+	started := make([]int, 0)
+	start := func(index int) {
+		started = append(started, index)
+	}
+	s.startConsumer(0, start)
+	s.startConsumer(1, start)
+
+	// 持续成功，增加 worker
+	s.addWorkStat(10, time.Second)
+	s.adjust(start)
+	if s.targetCount != 3 || len(started) != 3 || started[2] != 2 {
+		t.Fatalf("worker count should increase to 3, target:%d started:%v", s.targetCount, started)
+	}
+
+	// 已达到最大值，不再增加
+	s.addWorkStat(10, time.Second)
+	s.adjust(start)
+	if s.targetCount != 3 || len(started) != 3 {
+		t.Fatalf("worker count should keep 3, target:%d started:%v", s.targetCount, started)
+	}
+
+	// 遇到限制错误，减小 worker
+	s.addWorkStat(10, time.Second)
+	s.addLimitHit(1)
+	s.adjust(start)
+	if s.targetCount != 2 {
+		t.Fatalf("worker count should decrease to 2, target:%d", s.targetCount)
+	}
+	if !s.shouldConsumerRetire(2) || s.shouldConsumerRetire(1) {
+		t.Fatal("consumer 2 should retire and consumer 1 should not")
+	}
+
+	// 消费者队列关闭后不再启动新的消费者
+	s.consumerDidEnd(2)
+	s.workChanDidClose()
+	s.addWorkStat(10, time.Second)
+	s.adjust(start)
+	if len(started) != 3 {
+		t.Fatalf("should not start consumer after work chan closed, started:%v", started)
+	}
+}
+
+func TestWorkerScalerCooldown(t *testing.T) {
+	s := newWorkerScaler(Info{
+		WorkerCount:               1,
+		MinWorkerCount:            1,
+		MaxWorkerCount:            4,
+		WorkerCountIncreasePeriod: 60,
+	})
+	start := func(index int) {}
+	s.startConsumer(0, start)
+
+	s.addWorkStat(10, time.Second)
+	s.adjust(start)
+	if s.targetCount != 1 {
+		t.Fatalf("worker count should not change in cooldown, target:%d", s.targetCount)
+	}
+}
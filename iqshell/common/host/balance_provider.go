@@ -0,0 +1,90 @@
+package host
+
+import (
+	"sync"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// SuccessReporter Provider 可选实现的接口，请求成功后上报，用于恢复 host 的优先级
+type SuccessReporter interface {
+	ReportSuccess(host *Host)
+}
+
+// NewBalanceProvider 在多个 host 间轮询；请求失败的 host 失败次数增加，优先使用失败次数最少的 host，
+// 请求成功后失败次数减少。host 的状态仅保存在内存中，多个任务可共享同一个 Provider
+func NewBalanceProvider(hosts []*Host) Provider {
+	return &balanceProvider{
+		hosts:    hosts,
+		failures: make([]int, len(hosts)),
+	}
+}
+
+type balanceProvider struct {
+	mu       sync.Mutex
+	hosts    []*Host
+	failures []int // 每个 host 的失败次数
+	next     int   // 下一次轮询开始的位置
+}
+
+var _ SuccessReporter = (*balanceProvider)(nil)
+
+func (b *balanceProvider) Available() (available bool, err *data.CodeError) {
+	if b == nil || len(b.hosts) == 0 {
+		return false, data.NewEmptyError().AppendDesc("no host found")
+	}
+	return true, nil
+}
+
+func (b *balanceProvider) Provide() (host *Host, err *data.CodeError) {
+	if available, aErr := b.Available(); !available {
+		return nil, aErr
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	minFailures := b.failures[0]
+	for _, f := range b.failures {
+		if f < minFailures {
+			minFailures = f
+		}
+	}
+
+	count := len(b.hosts)
+	for i := 0; i < count; i++ {
+		index := (b.next + i) % count
+		if b.failures[index] == minFailures {
+			b.next = index + 1
+			return b.hosts[index], nil
+		}
+	}
+	return nil, data.NewEmptyError().AppendDesc("no host found")
+}
+
+func (b *balanceProvider) Freeze(host *Host) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if index := b.indexOf(host); index >= 0 {
+		b.failures[index]++
+	}
+}
+
+func (b *balanceProvider) ReportSuccess(host *Host) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if index := b.indexOf(host); index >= 0 && b.failures[index] > 0 {
+		b.failures[index]--
+	}
+}
+
+func (b *balanceProvider) indexOf(host *Host) int {
+	for i, h := range b.hosts {
+		if h.Equal(host) {
+			return i
+		}
+	}
+	return -1
+}
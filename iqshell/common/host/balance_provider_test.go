@@ -0,0 +1,40 @@
+package host
+
+import "testing"
+
+func TestBalanceProvider(t *testing.T) {
+	hosts := []*Host{{Domain: "a.com"}, {Domain: "b.com"}, {Domain: "c.com"}}
+	p := NewBalanceProvider(hosts)
+
+	// 轮询
+	for i := 0; i < 6; i++ {
+		h, err := p.Provide()
+		if err != nil || h != hosts[i%3] {
+			t.Fatalf("round robin error, index:%d host:%+v err:%v", i, h, err)
+		}
+	}
+
+	// 失败的 host 降低优先级
+	p.Freeze(hosts[0])
+	for i := 0; i < 4; i++ {
+		if h, _ := p.Provide(); h == hosts[0] {
+			t.Fatalf("failing host should be deprioritized, index:%d", i)
+		}
+	}
+
+	// 成功后恢复优先级
+	p.(SuccessReporter).ReportSuccess(hosts[0])
+	found := false
+	for i := 0; i < 3; i++ {
+		if h, _ := p.Provide(); h == hosts[0] {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("host should be used again after success")
+	}
+
+	if _, err := NewBalanceProvider(nil).Provide(); err == nil {
+		t.Fatal("provide should fail without host")
+	}
+}
@@ -39,6 +39,7 @@ var colors = []brush{
 type consoleWriter struct {
 	Level    int  `json:"level"`
 	Colorful bool `json:"color"` //this filed is useful only when system's terminal supports color
+	Stderr   bool `json:"stderr"`
 }
 
 // NewConsole create ConsoleWriter returning as LoggerInterface.
@@ -69,7 +70,7 @@ func (c *consoleWriter) WriteMsg(when time.Time, msg string, level int) (err err
 	if c.Colorful {
 		msg = colors[level](msg)
 	}
-	if level == logs.LevelError {
+	if level == logs.LevelError || c.Stderr {
 		_, err = fmt.Fprintln(data.Stderr(), msg)
 	} else {
 		_, err = fmt.Fprintln(data.Stdout(), msg)
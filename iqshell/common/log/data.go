@@ -21,7 +21,10 @@ type Config struct {
 	Level          int    `json:"level"`
 	Daily          bool   `json:"daily"`
 	MaxDays        int    `json:"maxdays"`
+	MaxSize        int64  `json:"maxsize,omitempty"` // 日志文件超过此大小时轮转，单位：B，0：使用默认值
+	Json           bool   `json:"-"`                 // 日志文件每行为一个 JSON 对象
 	StdOutColorful bool   `json:"color"`
+	StdErrOnly     bool   `json:"stderr"` // 控制台日志均输出到 stderr，stdout 留给命令的结构化输出
 	EnableStdout   bool   `json:"-"`
 }
 
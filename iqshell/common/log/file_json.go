@@ -0,0 +1,189 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+)
+
+const adapterJsonFile = "qn_json_file"
+
+var levelNames = map[int]string{
+	LevelAlert:   "alert",
+	LevelError:   "error",
+	LevelWarning: "warn",
+	LevelInfo:    "info",
+	LevelDebug:   "debug",
+}
+
+// jsonFileWriter 按行输出 JSON 格式的日志到文件，每行为：{"time":"","level":"","msg":""}；
+// 文件超过 MaxSize 或日期变化（Daily）时轮转，轮转后的文件名同文本日志，如：log.2006-01-02.001.txt
+type jsonFileWriter struct {
+	Filename string `json:"filename"`
+	Level    int    `json:"level"`
+	Daily    bool   `json:"daily"`
+	MaxDays  int    `json:"maxdays"`
+	MaxSize  int64  `json:"maxsize"`
+
+	lock     sync.Mutex
+	file     *os.File
+	size     int64
+	openDate string
+}
+
+type jsonLogLine struct {
+	Time  string `json:"time"`
+	Level string `json:"level"`
+	Msg   string `json:"msg"`
+}
+
+func newJsonFileWriter() logs.Logger {
+	return &jsonFileWriter{
+		Level:   LevelDebug,
+		Daily:   true,
+		MaxDays: 7,
+		MaxSize: 1 << 28,
+	}
+}
+
+func (w *jsonFileWriter) Init(jsonConfig string) error {
+	if err := json.Unmarshal([]byte(jsonConfig), w); err != nil {
+		return err
+	}
+	if len(w.Filename) == 0 {
+		return errors.New("json file logger must have filename")
+	}
+	return w.open()
+}
+
+func (w *jsonFileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.Filename), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.Filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0660)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	w.openDate = time.Now().Format("2006-01-02")
+	return nil
+}
+
+func (w *jsonFileWriter) WriteMsg(when time.Time, msg string, level int) error {
+	if level > w.Level {
+		return nil
+	}
+
+	// 去除 beego 添加的级别标识，如：[I]
+	if len(msg) >= 3 && msg[0] == '[' && msg[2] == ']' {
+		msg = strings.TrimLeft(msg[3:], " ")
+	}
+	line, err := json.Marshal(&jsonLogLine{
+		Time:  when.Format(time.RFC3339Nano),
+		Level: levelNames[level],
+		Msg:   msg,
+	})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.needRotate(when, len(line)) {
+		if rErr := w.rotate(); rErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "rotate log file:%s error:%v\n", w.Filename, rErr)
+		}
+	}
+	if w.file == nil {
+		return nil
+	}
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	return err
+}
+
+func (w *jsonFileWriter) needRotate(when time.Time, size int) bool {
+	return (w.MaxSize > 0 && w.size > 0 && w.size+int64(size) > w.MaxSize) ||
+		(w.Daily && when.Format("2006-01-02") != w.openDate)
+}
+
+func (w *jsonFileWriter) rotate() error {
+	if w.file != nil {
+		_ = w.file.Close()
+		w.file = nil
+	}
+
+	ext := filepath.Ext(w.Filename)
+	name := strings.TrimSuffix(w.Filename, ext)
+	if len(ext) == 0 {
+		ext = ".log"
+	}
+	for num := 1; num <= 999; num++ {
+		rotateName := fmt.Sprintf("%s.%s.%03d%s", name, w.openDate, num, ext)
+		if _, err := os.Lstat(rotateName); err == nil {
+			continue
+		}
+		if err := os.Rename(w.Filename, rotateName); err != nil {
+			_ = w.open()
+			return err
+		}
+		break
+	}
+	w.deleteOldLog(name, ext)
+	return w.open()
+}
+
+// deleteOldLog 删除超过 MaxDays 天的轮转文件
+func (w *jsonFileWriter) deleteOldLog(name, ext string) {
+	if w.MaxDays <= 0 {
+		return
+	}
+	files, err := filepath.Glob(name + ".*" + ext)
+	if err != nil {
+		return
+	}
+	deadline := time.Now().Add(-24 * time.Hour * time.Duration(w.MaxDays))
+	for _, file := range files {
+		if info, sErr := os.Stat(file); sErr == nil && info.ModTime().Before(deadline) {
+			_ = os.Remove(file)
+		}
+	}
+}
+
+func (w *jsonFileWriter) Destroy() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.file != nil {
+		_ = w.file.Close()
+		w.file = nil
+	}
+}
+
+func (w *jsonFileWriter) Flush() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.file != nil {
+		_ = w.file.Sync()
+	}
+}
+
+func init() {
+	logs.Register(adapterJsonFile, newJsonFileWriter)
+}
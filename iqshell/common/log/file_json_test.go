@@ -0,0 +1,52 @@
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJsonFileWriter(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "qshell.log")
+
+	w := newJsonFileWriter().(*jsonFileWriter)
+	if err := w.Init(`{"filename":"` + filename + `","level":6,"maxsize":200}`); err != nil {
+		t.Fatal("init json file writer error:", err)
+	}
+	defer w.Destroy()
+
+	now := time.Now()
+	_ = w.WriteMsg(now, "[D]  debug message", LevelDebug)
+	for i := 0; i < 5; i++ {
+		if err := w.WriteMsg(now, "[I]  info message", LevelInfo); err != nil {
+			t.Fatal("write message error:", err)
+		}
+	}
+
+	rotated, _ := filepath.Glob(filepath.Join(dir, "qshell.*.log"))
+	if len(rotated) == 0 {
+		t.Fatal("log file should be rotated when exceeding max size")
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal("open log file error:", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		t.Fatal("log file should not be empty")
+	}
+	line := &jsonLogLine{}
+	if err := json.Unmarshal(scanner.Bytes(), line); err != nil {
+		t.Fatal("log line should be json, but:", scanner.Text())
+	}
+	if line.Level != "info" || line.Msg != "info message" {
+		t.Fatalf("log line should be info message, but:%s", scanner.Text())
+	}
+}
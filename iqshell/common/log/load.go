@@ -27,7 +27,11 @@ func LoadConsole(cfg Config) (err *data.CodeError) {
 
 func LoadFileLogger(cfg Config) (err *data.CodeError) {
 	if len(cfg.Filename) > 0 {
-		if e := progressLog.SetLogger(logs.AdapterFile, cfg.ToJson()); e != nil {
+		adapter := logs.AdapterFile
+		if cfg.Json {
+			adapter = adapterJsonFile
+		}
+		if e := progressLog.SetLogger(adapter, cfg.ToJson()); e != nil {
 			return data.NewEmptyError().AppendDesc("set file logger").AppendError(e)
 		}
 	}
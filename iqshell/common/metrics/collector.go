@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// latencyBuckets 耗时直方图的桶上限，单位：秒，同 Prometheus 客户端默认的桶并扩展到 60s
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// maxLatencySamples 每个上报周期最多保留的耗时样本数，超过后按比例采样，仅 StatsD 使用
+const maxLatencySamples = 1000
+
+// Collector 统计批量操作的指标，并发安全
+type Collector struct {
+	mu sync.Mutex
+
+	success  int64
+	failures int64
+	skipped  int64
+	bytes    int64
+
+	latencyCounts []int64 // 每个桶的计数，非累计，最后一个为 +Inf
+	latencySum    float64
+	latencyCount  int64
+
+	samples       []float64 // 本周期的耗时样本，单位：毫秒
+	sampleObserve int64     // 本周期观测到的耗时个数
+
+	limitHits func() int64
+}
+
+// Snapshot 某一时刻指标的累计值
+type Snapshot struct {
+	Success   int64
+	Failures  int64
+	Skipped   int64
+	Bytes     int64
+	LimitHits int64
+
+	LatencyBuckets []float64 // 桶上限，单位：秒
+	LatencyCounts  []int64   // 每个桶的累计计数，同 Prometheus 的 le 语义，最后一个为 +Inf
+	LatencySum     float64   // 单位：秒
+	LatencyCount   int64
+
+	Samples    []float64 // 上次快照后的耗时样本，单位：毫秒
+	SampleRate float64   // 样本占上次快照后观测总数的比例
+}
+
+func NewCollector() *Collector {
+	return &Collector{
+		latencyCounts: make([]int64, len(latencyBuckets)+1),
+	}
+}
+
+// SetLimitHitsFunc 设置获取触发限流次数的方法，限流次数由 flow 统计
+func (c *Collector) SetLimitHitsFunc(f func() int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limitHits = f
+}
+
+func (c *Collector) AddSuccess(bytes int64, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.success++
+	c.bytes += bytes
+	c.observeLatency(latency)
+}
+
+func (c *Collector) AddFailure(latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures++
+	c.observeLatency(latency)
+}
+
+func (c *Collector) AddSkip() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.skipped++
+}
+
+func (c *Collector) observeLatency(latency time.Duration) {
+	if latency <= 0 {
+		return
+	}
+
+	seconds := latency.Seconds()
+	index := len(latencyBuckets)
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			index = i
+			break
+		}
+	}
+	c.latencyCounts[index]++
+	c.latencySum += seconds
+	c.latencyCount++
+
+	c.sampleObserve++
+	ms := float64(latency) / float64(time.Millisecond)
+	if len(c.samples) < maxLatencySamples {
+		c.samples = append(c.samples, ms)
+	} else if i := c.sampleObserve % maxLatencySamples; i < int64(len(c.samples)) {
+		// 超过上限后轮流替换，样本覆盖整个周期
+		c.samples[i] = ms
+	}
+}
+
+// Snapshot 获取指标的累计值，并清空本周期的耗时样本
+func (c *Collector) Snapshot() *Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &Snapshot{
+		Success:        c.success,
+		Failures:       c.failures,
+		Skipped:        c.skipped,
+		Bytes:          c.bytes,
+		LatencyBuckets: latencyBuckets,
+		LatencyCounts:  make([]int64, len(c.latencyCounts)),
+		LatencySum:     c.latencySum,
+		LatencyCount:   c.latencyCount,
+		Samples:        c.samples,
+		SampleRate:     1,
+	}
+	if c.limitHits != nil {
+		s.LimitHits = c.limitHits()
+	}
+
+	var count int64
+	for i, n := range c.latencyCounts {
+		count += n
+		s.LatencyCounts[i] = count
+	}
+
+	if c.sampleObserve > int64(len(c.samples)) && c.sampleObserve > 0 {
+		s.SampleRate = float64(len(c.samples)) / float64(c.sampleObserve)
+	}
+	c.samples = nil
+	c.sampleObserve = 0
+	return s
+}
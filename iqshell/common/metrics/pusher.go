@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+// pushTimeout 单次上报的超时时间，上报在后台进行，不会阻塞批量操作
+const pushTimeout = 5 * time.Second
+
+const defaultInterval = 10 * time.Second
+
+type reporter interface {
+	report(s *Snapshot) error
+	close()
+}
+
+// Pusher 定时把 Collector 统计的指标上报到 StatsD 或 Prometheus pushgateway；
+// 上报失败只输出一次警告，之后的失败仅在 debug 模式下输出，不影响批量操作
+type Pusher struct {
+	endpoint  string
+	interval  time.Duration
+	collector *Collector
+	reporter  reporter
+
+	errOnce  sync.Once
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// NewPusher 根据配置创建 Pusher，command 为 pushgateway 分组中 command 的值，为空不分组
+func NewPusher(cfg *config.Metrics, command string) (*Pusher, *data.CodeError) {
+	endpoint, err := cfg.GetEndpointURL()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := cfg.Prefix.Value()
+	if len(prefix) == 0 {
+		prefix = "qshell"
+	}
+	interval := time.Duration(cfg.Interval.Value()) * time.Second
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	p := &Pusher{
+		endpoint:  endpoint.Redacted(),
+		interval:  interval,
+		collector: NewCollector(),
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+	}
+	switch strings.ToLower(endpoint.Scheme) {
+	case config.MetricsSchemeStatsD, config.MetricsSchemeUdp:
+		p.reporter = newStatsDReporter(endpoint.Host, statsDPrefix(prefix))
+	default:
+		p.reporter = newPushgatewayReporter(endpoint, prefix, command)
+	}
+	return p, nil
+}
+
+func (p *Pusher) Collector() *Collector {
+	return p.collector
+}
+
+// Start 在后台定时上报
+func (p *Pusher) Start() {
+	log.DebugF("push metrics to %s every %s", p.endpoint, p.interval)
+	go func() {
+		defer close(p.doneCh)
+		defer p.reporter.close()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.push()
+			case <-p.stopCh:
+				p.push()
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止定时上报，并上报最终的指标；最多等待一次上报的超时时间
+func (p *Pusher) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopCh)
+		select {
+		case <-p.doneCh:
+		case <-time.After(pushTimeout + time.Second):
+			log.DebugF("push metrics to %s timeout, give up", p.endpoint)
+		}
+	})
+}
+
+func (p *Pusher) push() {
+	if err := p.reporter.report(p.collector.Snapshot()); err != nil {
+		p.errOnce.Do(func() {
+			log.WarningF("push metrics to %s error:%v, will keep trying in background and not report again", p.endpoint, err)
+		})
+		log.DebugF("push metrics to %s error:%v", p.endpoint, err)
+	}
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+func TestStatsDPusher(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("listen udp error:", err)
+	}
+	defer conn.Close()
+
+	pusher, pErr := NewPusher(&config.Metrics{
+		Endpoint: data.NewString("statsd://" + conn.LocalAddr().String()),
+		Prefix:   data.NewString("test"),
+	}, "batchstat")
+	if pErr != nil {
+		t.Fatal("create pusher error:", pErr)
+	}
+	pusher.Collector().AddSuccess(100, 20*time.Millisecond)
+	pusher.Collector().AddFailure(time.Second)
+	pusher.Collector().AddSkip()
+	pusher.Start()
+	pusher.Stop()
+
+	buf := make([]byte, statsDMaxPacketSize)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal("read statsd packet error:", err)
+	}
+	packet := string(buf[:n])
+	for _, line := range []string{"test.items:2|c", "test.failures:1|c", "test.skipped:1|c", "test.bytes:100|c", "test.latency:20.000|ms"} {
+		if !strings.Contains(packet, line) {
+			t.Fatalf("statsd packet should contain %s, but:%s", line, packet)
+		}
+	}
+}
+
+func TestPushgatewayPusher(t *testing.T) {
+	var path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		path, body = r.URL.Path, string(b)
+	}))
+	defer server.Close()
+
+	pusher, pErr := NewPusher(&config.Metrics{
+		Endpoint: data.NewString(server.URL),
+		Prefix:   data.NewString("qshell-test"),
+	}, "batchstat")
+	if pErr != nil {
+		t.Fatal("create pusher error:", pErr)
+	}
+	pusher.Collector().AddSuccess(100, 20*time.Millisecond)
+	pusher.Collector().AddSuccess(100, 2*time.Minute)
+	pusher.Start()
+	pusher.Stop()
+
+	if path != "/metrics/job/qshell-test/command/batchstat" {
+		t.Fatalf("push path error:%s", path)
+	}
+	for _, line := range []string{
+		"qshell_test_items_total 2",
+		"qshell_test_bytes_total 200",
+		`qshell_test_latency_seconds_bucket{le="0.025"} 1`,
+		`qshell_test_latency_seconds_bucket{le="60"} 1`,
+		`qshell_test_latency_seconds_bucket{le="+Inf"} 2`,
+		"qshell_test_latency_seconds_count 2",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Fatalf("push body should contain %s, but:%s", line, body)
+		}
+	}
+}
+
+func TestMetricsEndpointCheck(t *testing.T) {
+	for _, endpoint := range []string{"tcp://127.0.0.1:8125", "127.0.0.1:8125", "http://"} {
+		m := &config.Metrics{Endpoint: data.NewString(endpoint)}
+		if err := m.Check(); err == nil {
+			t.Fatalf("endpoint:%s should be invalid", endpoint)
+		}
+	}
+}
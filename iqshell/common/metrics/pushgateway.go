@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// pushgatewayReporter 以 Prometheus 文本格式 PUT 到 pushgateway，计数器及直方图上报的是累计值，
+// 分组为：/metrics/job/<prefix>/command/<command>
+type pushgatewayReporter struct {
+	url    string
+	prefix string
+	client *http.Client
+}
+
+func newPushgatewayReporter(endpoint *url.URL, prefix, command string) *pushgatewayReporter {
+	pushUrl := strings.TrimRight(endpoint.String(), "/") + "/metrics/job/" + url.PathEscape(prefix)
+	if len(command) > 0 {
+		pushUrl += "/command/" + url.PathEscape(command)
+	}
+	return &pushgatewayReporter{
+		url:    pushUrl,
+		prefix: prometheusName(prefix),
+		client: &http.Client{Timeout: pushTimeout},
+	}
+}
+
+func (r *pushgatewayReporter) report(s *Snapshot) error {
+	body := &bytes.Buffer{}
+	for _, c := range []struct {
+		name  string
+		help  string
+		value int64
+	}{
+		{"items_total", "Number of processed items, including failures.", s.Success + s.Failures},
+		{"success_total", "Number of succeeded items.", s.Success},
+		{"failures_total", "Number of failed items.", s.Failures},
+		{"skipped_total", "Number of skipped items.", s.Skipped},
+		{"bytes_total", "Total size of succeeded items in bytes.", s.Bytes},
+		{"limit_hits_total", "Number of times the concurrency was reduced by rate limit.", s.LimitHits},
+	} {
+		name := r.prefix + "_" + c.name
+		_, _ = fmt.Fprintf(body, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, c.help, name, name, c.value)
+	}
+
+	name := r.prefix + "_latency_seconds"
+	_, _ = fmt.Fprintf(body, "# HELP %s Latency of processing an item.\n# TYPE %s histogram\n", name, name)
+	for i, bound := range s.LatencyBuckets {
+		_, _ = fmt.Fprintf(body, "%s_bucket{le=\"%s\"} %d\n", name, strconv.FormatFloat(bound, 'f', -1, 64), s.LatencyCounts[i])
+	}
+	_, _ = fmt.Fprintf(body, "%s_bucket{le=\"+Inf\"} %d\n", name, s.LatencyCounts[len(s.LatencyCounts)-1])
+	_, _ = fmt.Fprintf(body, "%s_sum %s\n%s_count %d\n", name, strconv.FormatFloat(s.LatencySum, 'f', -1, 64), name, s.LatencyCount)
+
+	req, err := http.NewRequest(http.MethodPut, r.url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("pushgateway response status:%s", resp.Status)
+	}
+	return nil
+}
+
+func (r *pushgatewayReporter) close() {
+	r.client.CloseIdleConnections()
+}
+
+// prometheusName Prometheus 指标名仅支持字母、数字、_ 及 :，其他字符替换为 _
+func prometheusName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == ':'
+		if !isLetter && !(i > 0 && c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// statsDMaxPacketSize 单个 UDP 包的最大长度，避免在常见 MTU 下分片
+const statsDMaxPacketSize = 1432
+
+// statsDReporter 以 StatsD 协议通过 UDP 上报，计数器上报的是两次上报之间的增量（增量为 0 时不上报），耗时以 timer（ms）上报
+type statsDReporter struct {
+	address string
+	prefix  string
+	conn    net.Conn
+	last    *Snapshot
+}
+
+func newStatsDReporter(address, prefix string) *statsDReporter {
+	return &statsDReporter{
+		address: address,
+		prefix:  prefix,
+		last:    &Snapshot{},
+	}
+}
+
+func (r *statsDReporter) report(s *Snapshot) error {
+	if r.conn == nil {
+		conn, err := net.DialTimeout("udp", r.address, pushTimeout)
+		if err != nil {
+			return err
+		}
+		r.conn = conn
+	}
+
+	var lines []string
+	for _, c := range []struct {
+		name  string
+		value int64
+	}{
+		{"items", s.Success + s.Failures - r.last.Success - r.last.Failures},
+		{"success", s.Success - r.last.Success},
+		{"failures", s.Failures - r.last.Failures},
+		{"skipped", s.Skipped - r.last.Skipped},
+		{"bytes", s.Bytes - r.last.Bytes},
+		{"limit_hits", s.LimitHits - r.last.LimitHits},
+	} {
+		if c.value == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s.%s:%d|c", r.prefix, c.name, c.value))
+	}
+
+	rate := ""
+	if s.SampleRate < 1 {
+		rate = "|@" + strconv.FormatFloat(s.SampleRate, 'f', -1, 64)
+	}
+	for _, ms := range s.Samples {
+		lines = append(lines, fmt.Sprintf("%s.latency:%s|ms%s", r.prefix, strconv.FormatFloat(ms, 'f', 3, 64), rate))
+	}
+	if err := r.send(lines); err != nil {
+		return err
+	}
+	r.last = s
+	return nil
+}
+
+func (r *statsDReporter) send(lines []string) error {
+	var packet bytes.Buffer
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_ = r.conn.SetWriteDeadline(time.Now().Add(pushTimeout))
+		_, err := r.conn.Write(packet.Bytes())
+		packet.Reset()
+		return err
+	}
+
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > statsDMaxPacketSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	return flush()
+}
+
+func (r *statsDReporter) close() {
+	if r.conn != nil {
+		_ = r.conn.Close()
+	}
+}
+
+// statsDPrefix StatsD 指标名以 . 分隔层级，去除前缀两端的 .
+func statsDPrefix(prefix string) string {
+	return strings.Trim(prefix, ".")
+}
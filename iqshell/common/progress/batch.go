@@ -0,0 +1,159 @@
+package progress
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+const (
+	batchThroughputWindow = 30 * time.Second // 计算速度的滑动窗口
+	batchSampleInterval   = time.Second      // 采样的最小间隔
+)
+
+type batchSample struct {
+	time  time.Time
+	count int64
+}
+
+// Batch 批量任务的整体进度，根据滑动窗口内的处理速度预估剩余时间；总数未知时仅展示已处理的数量
+type Batch struct {
+	mu          sync.Mutex
+	title       string
+	totalCount  int64
+	totalSize   int64
+	doneCount   int64
+	doneSize    int64
+	samples     []batchSample
+	progressBar *progressbar.ProgressBar
+}
+
+// NewBatchProgress totalCount 小于等于 0 表示总数未知；进度输出到 stderr，避免影响 stdout 的导出结果
+func NewBatchProgress(title string, totalCount int64) *Batch {
+	maxCount := totalCount
+	if maxCount <= 0 {
+		maxCount = -1
+	}
+	b := &Batch{
+		title:      title,
+		totalCount: totalCount,
+		samples:    []batchSample{{time: time.Now()}},
+		progressBar: progressbar.NewOptions64(maxCount,
+			progressbar.OptionSetWriter(os.Stderr),
+			progressbar.OptionFullWidth(),
+			progressbar.OptionEnableColorCodes(true),
+			progressbar.OptionShowCount(),
+			progressbar.OptionSetPredictTime(false),
+			progressbar.OptionThrottle(time.Millisecond*500),
+			progressbar.OptionOnCompletion(func() {
+				_, _ = fmt.Fprintf(os.Stderr, "\n")
+			}),
+			progressbar.OptionSpinnerType(14),
+			progressbar.OptionSetDescription("[green]"+title+"[reset]"),
+			progressbar.OptionSetTheme(progressbar.Theme{
+				Saucer:        "[green]-[reset]",
+				SaucerHead:    "[green]>[reset]",
+				SaucerPadding: " ",
+				BarStart:      "[",
+				BarEnd:        "]",
+			})),
+	}
+	_ = b.progressBar.RenderBlank()
+	return b
+}
+
+// SetTotalSize 所有 work 的总大小，单位：B，未知时无需设置
+func (b *Batch) SetTotalSize(size int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.totalSize = size
+	b.mu.Unlock()
+}
+
+// Add 增加已处理的 work 数及大小
+func (b *Batch) Add(count, size int64) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.doneCount += count
+	b.doneSize += size
+
+	now := time.Now()
+	if now.Sub(b.samples[len(b.samples)-1].time) >= batchSampleInterval {
+		b.samples = append(b.samples, batchSample{time: now, count: b.doneCount})
+		for len(b.samples) > 2 && now.Sub(b.samples[0].time) > batchThroughputWindow {
+			b.samples = b.samples[1:]
+		}
+	}
+
+	b.progressBar.Describe(b.description())
+	_ = b.progressBar.Add64(count)
+}
+
+// ETA 预估的剩余时间，总数未知或还无法计算速度时 ok 为 false
+func (b *Batch) ETA() (eta time.Duration, ok bool) {
+	if b == nil {
+		return 0, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.eta()
+}
+
+func (b *Batch) eta() (time.Duration, bool) {
+	if b.totalCount <= 0 || len(b.samples) < 2 {
+		return 0, false
+	}
+
+	first, last := b.samples[0], b.samples[len(b.samples)-1]
+	duration := last.time.Sub(first.time)
+	count := last.count - first.count
+	if duration <= 0 || count <= 0 {
+		return 0, false
+	}
+
+	left := b.totalCount - b.doneCount
+	if left < 0 {
+		left = 0
+	}
+	return time.Duration(float64(left) / float64(count) * float64(duration)), true
+}
+
+func (b *Batch) description() string {
+	size := utils.FormatFileSize(b.doneSize)
+	if b.totalSize > 0 {
+		size += "/" + utils.FormatFileSize(b.totalSize)
+	}
+	desc := fmt.Sprintf("[green]%s[reset] %s", b.title, size)
+	if eta, ok := b.eta(); ok {
+		desc += " ETA:" + eta.Round(time.Second).String()
+	}
+	return desc
+}
+
+// End 结束进度展示
+func (b *Batch) End() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.progressBar.Describe(b.description())
+	if b.totalCount > 0 && b.doneCount >= b.totalCount {
+		_ = b.progressBar.Finish()
+		return
+	}
+	// 任务提前结束或总数未知时保留当前进度
+	_, _ = fmt.Fprintf(os.Stderr, "\n")
+}
@@ -0,0 +1,26 @@
+package progress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBatchETA(t *testing.T) {
+	b := NewBatchProgress("test", 100)
+	if _, ok := b.ETA(); ok {
+		t.Fatal("eta should be unknown before any sample")
+	}
+
+	now := time.Now()
+	b.samples = []batchSample{{time: now.Add(-10 * time.Second), count: 0}, {time: now, count: 20}}
+	b.doneCount = 20
+	if eta, ok := b.ETA(); !ok || eta != 40*time.Second {
+		t.Fatalf("eta should be 40s, but:%s", eta)
+	}
+
+	b = NewBatchProgress("test", -1)
+	b.samples = []batchSample{{time: now.Add(-10 * time.Second), count: 0}, {time: now, count: 20}}
+	if _, ok := b.ETA(); ok {
+		t.Fatal("eta should be unknown when total count is unknown")
+	}
+}
@@ -0,0 +1,92 @@
+package progress
+
+import (
+	"sync"
+	"time"
+)
+
+// Callback 进度回调，done：已完成的字节数，total：总字节数，未知时为 0
+type Callback func(done, total int64)
+
+type callback struct {
+	mu         sync.Mutex
+	interval   time.Duration
+	fn         Callback
+	total      int64
+	current    int64
+	lastNotify time.Time
+}
+
+// NewCallbackProgress 以回调的方式通知进度，两次回调的间隔不小于 interval，开始和结束时一定会回调；
+// 回调在锁内执行，同一个 Progress 的回调不会并发，但可能来自不同的 goroutine，回调中不应执行耗时操作
+func NewCallbackProgress(interval time.Duration, fn Callback) Progress {
+	return &callback{
+		interval: interval,
+		fn:       fn,
+	}
+}
+
+var _ Progress = (*callback)(nil)
+
+func (c *callback) Start() {
+	c.mu.Lock()
+	c.notify(true)
+	c.mu.Unlock()
+}
+
+func (c *callback) SetFileSize(fileSize int64) {
+	c.mu.Lock()
+	c.total = fileSize
+	c.mu.Unlock()
+}
+
+func (c *callback) SendSize(newSize int64) {
+	c.mu.Lock()
+	c.current += newSize
+	if c.total > 0 && c.current > c.total {
+		c.current = c.total
+	}
+	c.notify(false)
+	c.mu.Unlock()
+}
+
+func (c *callback) Write(b []byte) (int, error) {
+	c.SendSize(int64(len(b)))
+	return len(b), nil
+}
+
+func (c *callback) Progress(current int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.total == 0 {
+		return
+	}
+	if current > c.total {
+		current = c.total
+	}
+	c.current = current
+	c.notify(false)
+}
+
+func (c *callback) End() {
+	c.mu.Lock()
+	if c.total > 0 {
+		c.current = c.total
+	}
+	c.notify(true)
+	c.mu.Unlock()
+}
+
+// notify 需在锁内调用
+func (c *callback) notify(force bool) {
+	if c.fn == nil {
+		return
+	}
+	now := time.Now()
+	if !force && now.Sub(c.lastNotify) < c.interval {
+		return
+	}
+	c.lastNotify = now
+	c.fn(c.current, c.total)
+}
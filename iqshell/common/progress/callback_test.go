@@ -0,0 +1,26 @@
+package progress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCallbackProgress(t *testing.T) {
+	var calls, lastDone, lastTotal int64
+	p := NewCallbackProgress(time.Hour, func(done, total int64) {
+		calls++
+		lastDone, lastTotal = done, total
+	})
+	p.SetFileSize(100)
+	p.Start()
+	p.SendSize(10)
+	p.Progress(50)
+	if calls != 1 || lastDone != 0 {
+		t.Fatalf("callback should be throttled, calls:%d done:%d", calls, lastDone)
+	}
+
+	p.End()
+	if calls != 2 || lastDone != 100 || lastTotal != 100 {
+		t.Fatalf("callback should be invoked on end, calls:%d done:%d total:%d", calls, lastDone, lastTotal)
+	}
+}
@@ -2,26 +2,23 @@ package progress
 
 import (
 	"fmt"
-	"github.com/schollz/progressbar/v3"
-	"sync"
 	"time"
+
+	"github.com/schollz/progressbar/v3"
 )
 
 const (
 	wordsCountPerLine = 80
 )
 
+// printer 基于 callback 实现，在回调中刷新终端的进度条
 type printer struct {
-	mu          sync.Mutex
-	title       string
-	fileSize    int64
-	current     int64
+	callback    Progress
 	progressBar *progressbar.ProgressBar
 }
 
 func NewPrintProgress(title string) Progress {
-	return &printer{
-		title: title,
+	p := &printer{
 		progressBar: progressbar.NewOptions(0,
 			progressbar.OptionFullWidth(),
 			progressbar.OptionShowBytes(true),
@@ -41,53 +38,37 @@ func NewPrintProgress(title string) Progress {
 				BarEnd:        "]",
 			})),
 	}
+	// 进度条自身有刷新频率的限制，回调无需节流
+	p.callback = NewCallbackProgress(0, func(done, total int64) {
+		_ = p.progressBar.Set64(done)
+	})
+	return p
 }
 
 var _ Progress = (*printer)(nil)
 
 func (p *printer) Start() {
-	_ = p.progressBar.Add(0)
+	p.callback.Start()
 }
 
 func (p *printer) SetFileSize(fileSize int64) {
-	p.fileSize = fileSize
 	p.progressBar.ChangeMax64(fileSize)
+	p.callback.SetFileSize(fileSize)
 }
 
 func (p *printer) SendSize(newSize int64) {
-	p.mu.Lock()
-	if p.current+newSize > p.fileSize {
-		newSize = p.fileSize - p.current
-	}
-	_ = p.progressBar.Add(int(newSize))
-	p.current += newSize
-	p.mu.Unlock()
+	p.callback.SendSize(newSize)
 }
 
 func (p *printer) Write(b []byte) (int, error) {
-	if n, e := p.progressBar.Write(b); e != nil {
-		return n, e
-	} else {
-		return n, nil
-	}
+	return p.callback.Write(b)
 }
 
 func (p *printer) Progress(current int64) {
-	if p.fileSize == 0 {
-		return
-	}
-	if current > p.fileSize {
-		current = p.fileSize
-	}
-
-	p.mu.Lock()
-	newSize := current - p.current
-	_ = p.progressBar.Add(int(newSize))
-	p.current = current
-	p.mu.Unlock()
+	p.callback.Progress(current)
 }
 
 func (p *printer) End() {
-	_ = p.progressBar.Add(int(p.fileSize) - int(p.progressBar.State().CurrentBytes))
+	p.callback.End()
 	_ = p.progressBar.Finish()
 }
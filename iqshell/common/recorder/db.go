@@ -69,11 +69,19 @@ func (db *dbRecorder) Get(key string) (string, *data.CodeError) {
 }
 
 func (db *dbRecorder) Put(key, value string) *data.CodeError {
+	return db.put(key, value, false)
+}
+
+func (db *dbRecorder) PutAndSync(key, value string) *data.CodeError {
+	return db.put(key, value, true)
+}
+
+func (db *dbRecorder) put(key, value string, sync bool) *data.CodeError {
 	if db.db == nil {
 		return data.NewEmptyError().AppendDescF("db put key:%s for value:%s error:no db exist", key, value)
 	}
 	err := db.db.Put([]byte(key), []byte(value), &opt.WriteOptions{
-		Sync: false,
+		Sync: sync,
 	})
 	if err != nil {
 		return data.NewEmptyError().AppendError(err)
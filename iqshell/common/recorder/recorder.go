@@ -10,6 +10,9 @@ type Recorder interface {
 	// Put 添加记录
 	Put(key, value string) *data.CodeError
 
+	// PutAndSync 添加记录，并将此记录及之前所有的记录同步落盘
+	PutAndSync(key, value string) *data.CodeError
+
 	// Delete 删除记录
 	Delete(key string) *data.CodeError
 }
@@ -9,8 +9,8 @@ import (
 )
 
 type Info struct {
-	StdInEnable bool // true: InputFile 未设置时使用 stdin
-	InputFile   string
+	StdInEnable bool   // true: InputFile 未设置时使用 stdin
+	InputFile   string // 为 - 时使用 stdin
 }
 
 type Scanner interface {
@@ -22,7 +22,7 @@ type Scanner interface {
 // NewScanner 输入
 func NewScanner(info Info) (Scanner, *data.CodeError) {
 	s := &lineScanner{}
-	if len(info.InputFile) > 0 {
+	if len(info.InputFile) > 0 && info.InputFile != "-" {
 		f, err := os.Open(info.InputFile)
 		if err != nil {
 			return nil, data.NewEmptyError().AppendDescF("open inout file error:%v", err)
@@ -31,7 +31,7 @@ func NewScanner(info Info) (Scanner, *data.CodeError) {
 		s.file = f
 		s.scanner = bufio.NewScanner(f)
 		log.InfoF("read data from file:%s", info.InputFile)
-	} else if info.StdInEnable {
+	} else if info.StdInEnable || info.InputFile == "-" {
 		s.scanner = bufio.NewScanner(os.Stdin)
 		log.Info("read data from stdin, you can end input with ctrl + D and cancel by ctrl + C")
 	} else {
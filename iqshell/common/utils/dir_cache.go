@@ -113,3 +113,65 @@ func DirCache(cacheRootPath string, cacheResultFile string) (int64, *data.CodeEr
 	log.DebugF("Total file count cached %d", fileCount)
 	return fileCount, nil
 }
+
+// DirCacheWithGlobs
+// generate the file list for the files matched by the glob patterns in the specified directory
+// @param cacheRootPath - dir to generate cache file, the patterns are relative to it
+// @param patterns - shell style glob patterns, `**` matches any levels of directories
+// @param cacheResultFile - cache result file path
+// @return (fileCount, retErr) - total file count and any error meets
+func DirCacheWithGlobs(cacheRootPath string, patterns []string, cacheResultFile string) (int64, *data.CodeError) {
+	cacheRootPath = filepath.Join(cacheRootPath, "")
+	if err := os.MkdirAll(filepath.Dir(cacheResultFile), os.ModePerm); err != nil {
+		return 0, data.NewEmptyError().AppendError(err)
+	}
+
+	cacheResultFh, createErr := os.Create(cacheResultFile)
+	if createErr != nil {
+		log.ErrorF("Failed to open cache file `%s`, %s", cacheResultFile, createErr)
+		return 0, data.NewEmptyError().AppendError(createErr)
+	}
+	defer cacheResultFh.Close()
+
+	bWriter := bufio.NewWriter(cacheResultFh)
+	var fileCount int64 = 0
+	cached := make(map[string]bool)
+	for _, pattern := range patterns {
+		files, err := Glob(cacheRootPath, pattern)
+		if err != nil {
+			return 0, err
+		}
+		if len(files) == 0 {
+			log.WarningF("No file matches glob pattern `%s` in `%s`", pattern, cacheRootPath)
+			continue
+		}
+
+		for _, relativePath := range files {
+			if cached[relativePath] {
+				continue
+			}
+			cached[relativePath] = true
+
+			fi, statErr := os.Stat(filepath.Join(cacheRootPath, relativePath))
+			if statErr != nil {
+				log.ErrorF("Failed to stat file `%s`, %s", relativePath, statErr)
+				continue
+			}
+
+			//Unit is 100ns
+			fmeta := fmt.Sprintf("%s\t%d\t%d\n", relativePath, fi.Size(), fi.ModTime().UnixNano()/100)
+			if _, wErr := bWriter.WriteString(fmeta); wErr != nil {
+				log.ErrorF("Failed to write data `%s` to cache file `%s`", fmeta, cacheResultFile)
+			} else {
+				fileCount += 1
+			}
+		}
+	}
+
+	if fErr := bWriter.Flush(); fErr != nil {
+		log.ErrorF("Failed to flush to cache file `%s`", cacheResultFile)
+		return 0, data.NewEmptyError().AppendError(fErr)
+	}
+	log.DebugF("Total file count cached %d by glob patterns %v", fileCount, patterns)
+	return fileCount, nil
+}
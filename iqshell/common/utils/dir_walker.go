@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+// WalkedFile 遍历目录得到的普通文件
+type WalkedFile struct {
+	RelPath    string // 相对于根目录的路径，使用 / 分隔
+	FilePath   string
+	Size       int64
+	ModifyTime int64 // 单位：ns
+}
+
+// DirWalker 按相对路径的字节序遍历目录，与空间列举的 key 顺序一致；
+// 普通文件交由 OnFile 处理，无法读取的目录及文件交由 OnFail 处理，不中断遍历
+type DirWalker struct {
+	FollowSymlinks bool // 是否跟随软链接，不跟随时跳过软链接
+	OnFile         func(file *WalkedFile)
+	OnFail         func(relPath string, err *data.CodeError)
+
+	visitedDirs map[string]bool // 跟随软链接时已遍历目录的真实路径，避免软链接成环
+}
+
+type walkedEntry struct {
+	sortKey  string // 目录的排序 key 以 / 结尾，保证目录下的文件与同级文件按完整路径排序
+	name     string
+	filePath string
+	info     os.FileInfo
+}
+
+// Walk 遍历 dirPath，遍历结束后返回
+func (w *DirWalker) Walk(dirPath string) {
+	w.visitedDirs = make(map[string]bool)
+	w.walk(dirPath, "")
+}
+
+func (w *DirWalker) walk(dirPath string, relDir string) {
+	if w.FollowSymlinks {
+		realPath, err := filepath.EvalSymlinks(dirPath)
+		if err != nil {
+			w.OnFail(relDir, data.NewEmptyError().AppendDescF("resolve directory:%s error:%v", dirPath, err))
+			return
+		}
+		if w.visitedDirs[realPath] {
+			log.WarningF("Skip directory:%s because it has been walked through, maybe a symlink loop", dirPath)
+			return
+		}
+		w.visitedDirs[realPath] = true
+	}
+
+	dirEntries, err := os.ReadDir(dirPath)
+	if err != nil {
+		w.OnFail(relDir, data.NewEmptyError().AppendDescF("read directory:%s error:%v", dirPath, err))
+		return
+	}
+
+	entries := make([]*walkedEntry, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		filePath := filepath.Join(dirPath, dirEntry.Name())
+		relPath := relDir + dirEntry.Name()
+		if dirEntry.Type()&os.ModeSymlink != 0 && !w.FollowSymlinks {
+			log.DebugF("Skip symlink:%s", filePath)
+			continue
+		}
+
+		// os.Stat 会跟随软链接
+		stat, sErr := os.Stat(filePath)
+		if sErr != nil {
+			w.OnFail(relPath, data.NewEmptyError().AppendDescF("get file:%s status error:%v", filePath, sErr))
+			continue
+		}
+		entry := &walkedEntry{
+			sortKey:  dirEntry.Name(),
+			name:     dirEntry.Name(),
+			filePath: filePath,
+			info:     stat,
+		}
+		if stat.IsDir() {
+			entry.sortKey += "/"
+		} else if !stat.Mode().IsRegular() {
+			log.DebugF("Skip file:%s because it is not a regular file", filePath)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].sortKey < entries[j].sortKey
+	})
+
+	for _, entry := range entries {
+		if entry.info.IsDir() {
+			w.walk(entry.filePath, relDir+entry.name+"/")
+		} else {
+			w.OnFile(&WalkedFile{
+				RelPath:    relDir + entry.name,
+				FilePath:   entry.filePath,
+				Size:       entry.info.Size(),
+				ModifyTime: entry.info.ModTime().UnixNano(),
+			})
+		}
+	}
+}
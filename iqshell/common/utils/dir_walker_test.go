@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+func walkDir(dir string, followSymlinks bool) (files []string, fails []string) {
+	walker := &DirWalker{
+		FollowSymlinks: followSymlinks,
+		OnFile: func(file *WalkedFile) {
+			files = append(files, file.RelPath)
+		},
+		OnFail: func(relPath string, err *data.CodeError) {
+			fails = append(fails, relPath)
+		},
+	}
+	walker.Walk(dir)
+	return
+}
+
+func TestDirWalkerOrder(t *testing.T) {
+	dir := t.TempDir()
+	// a/b 与 a-c：按完整路径的字节序 a-c 在 a/b 之前
+	for _, name := range []string{"a/b", "a-c", "b", "a/d/e"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, fails := walkDir(dir, false)
+	expected := []string{"a-c", "a/b", "a/d/e", "b"}
+	if len(fails) != 0 || len(files) != len(expected) {
+		t.Fatalf("walk error, files:%v fails:%v", files, fails)
+	}
+	for i := range expected {
+		if files[i] != expected[i] {
+			t.Fatalf("walk order error, files:%v expected:%v", files, expected)
+		}
+	}
+}
+
+func TestDirWalkerSymlink(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "sub/b"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "a"), filepath.Join(dir, "link")); err != nil {
+		t.Skip("symlink not supported:", err)
+	}
+	_ = os.Symlink(dir, filepath.Join(dir, "sub", "loop"))
+	_ = os.Symlink(filepath.Join(dir, "none"), filepath.Join(dir, "dangling"))
+
+	files, fails := walkDir(dir, false)
+	if len(fails) != 0 || len(files) != 2 || files[0] != "a" || files[1] != "sub/b" {
+		t.Fatalf("walk without following symlinks error, files:%v fails:%v", files, fails)
+	}
+
+	files, fails = walkDir(dir, true)
+	if len(fails) != 1 || fails[0] != "dangling" {
+		t.Fatalf("dangling symlink should fail, fails:%v", fails)
+	}
+	if len(files) != 3 || files[0] != "a" || files[1] != "link" || files[2] != "sub/b" {
+		t.Fatalf("walk with following symlinks error, files:%v", files)
+	}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"crypto/sha1"
 	"encoding/base64"
+	"encoding/hex"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"io"
 	"os"
@@ -29,6 +30,20 @@ func GetEtag(filename string) (etag string, err *data.CodeError) {
 	return EtagV1(f)
 }
 
+// GetFileEtag 计算本地文件的 etag；parts 为分片上传 v2 的分片信息，为空时使用 v1 算法
+func GetFileEtag(filePath string, parts []int64) (string, *data.CodeError) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", data.NewEmptyError().AppendDescF("open local file:%s error:%v", filePath, err)
+	}
+	defer file.Close()
+
+	if len(parts) == 0 {
+		return EtagV1(file)
+	}
+	return EtagV2(file, parts)
+}
+
 func EtagV1(reader io.Reader) (string, *data.CodeError) {
 	data, err := etagV1WithoutBase64Encoded(reader)
 	if err != nil {
@@ -96,6 +111,99 @@ func EtagV2(reader io.Reader, parts []int64) (string, *data.CodeError) {
 	return base64.URLEncoding.EncodeToString(sha1Buf), nil
 }
 
+// EtagBlock 计算 etag 时每块数据（最大 4M）的 sha1
+type EtagBlock struct {
+	Part   int    `json:"part"` // 所在分片的序号，从 0 开始，v1 算法只有一个分片
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	Sha1   string `json:"sha1"` // hex 编码
+}
+
+// EtagDetail etag 及计算过程中每块数据的 sha1，用于排查 hash 不一致的问题
+type EtagDetail struct {
+	Etag     string      `json:"etag"`
+	Version  string      `json:"version"`             // v1 或 v2
+	Size     int64       `json:"size"`                // 数据大小
+	PartSize int64       `json:"part_size,omitempty"` // v2 算法的分片大小
+	Blocks   []EtagBlock `json:"blocks"`
+}
+
+// EtagParts 按分片大小切分数据，分片大小不大于 0 时返回 nil，表示使用 v1 算法
+func EtagParts(size int64, partSize int64) []int64 {
+	if partSize <= 0 {
+		return nil
+	}
+
+	parts := make([]int64, 0, (size+partSize-1)/partSize+1)
+	for offset := int64(0); offset < size; offset += partSize {
+		if size-offset < partSize {
+			parts = append(parts, size-offset)
+		} else {
+			parts = append(parts, partSize)
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, 0)
+	}
+	return parts
+}
+
+// GetEtagDetail 流式读取数据计算 etag，并记录每块数据的 sha1；
+// size 为数据大小，partSize 为分片上传 v2 的分片大小，不大于 0 或各分片均为 4M 时（和 v1 算法结果一致）使用 v1 算法
+func GetEtagDetail(reader io.Reader, size int64, partSize int64) (*EtagDetail, *data.CodeError) {
+	parts := EtagParts(size, partSize)
+	detail := &EtagDetail{
+		Version: "v1",
+		Size:    size,
+		Blocks:  make([]EtagBlock, 0, (size+defaultChunkSize-1)/defaultChunkSize),
+	}
+	if len(parts) == 0 || is4MbParts(parts) {
+		parts = []int64{size}
+	} else {
+		detail.Version = "v2"
+		detail.PartSize = partSize
+	}
+
+	var offset int64
+	var sha1Buf []byte
+	for partIndex, partSize := range parts {
+		var partSha1s [][]byte
+		for leftSize := partSize; leftSize > 0; {
+			readSize := defaultChunkSize
+			if readSize > leftSize {
+				readSize = leftSize
+			}
+
+			sha1Hash := sha1.New()
+			if n, err := io.Copy(sha1Hash, io.LimitReader(reader, readSize)); err != nil {
+				return nil, data.NewEmptyError().AppendDescF("etag read data:%v", err)
+			} else if n != readSize {
+				return nil, data.NewEmptyError().AppendDescF("etag read data size Unexpected, %d:%d", n, readSize)
+			}
+			sha1Result := sha1Hash.Sum(nil)
+			partSha1s = append(partSha1s, sha1Result)
+			detail.Blocks = append(detail.Blocks, EtagBlock{
+				Part:   partIndex,
+				Offset: offset,
+				Size:   readSize,
+				Sha1:   hex.EncodeToString(sha1Result),
+			})
+			offset += readSize
+			leftSize -= readSize
+		}
+
+		if detail.Version == "v1" {
+			detail.Etag = base64.URLEncoding.EncodeToString(hashSha1s(partSha1s))
+			return detail, nil
+		}
+		sha1Buf = append(sha1Buf, hashSha1s(partSha1s)[1:]...)
+	}
+
+	sha1Result := sha1.Sum(sha1Buf)
+	detail.Etag = base64.URLEncoding.EncodeToString(append([]byte{0x9e}, sha1Result[:]...))
+	return detail, nil
+}
+
 func IsSignByEtagV2(etag string) bool {
 	etagData, err := base64.URLEncoding.DecodeString(etag)
 	if len(etagData) < 1 || err != nil {
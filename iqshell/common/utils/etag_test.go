@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGetEtagDetail(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 9*MB/16+3)
+	size := int64(len(content))
+
+	v1, _ := EtagV1(bytes.NewReader(content))
+	detail, err := GetEtagDetail(bytes.NewReader(content), size, 0)
+	if err != nil || detail.Etag != v1 || detail.Version != "v1" || len(detail.Blocks) != 3 {
+		t.Fatalf("v1 detail error:%v detail:%+v", err, detail)
+	}
+
+	// 分片均为 4M 时和 v1 一致
+	detail, err = GetEtagDetail(bytes.NewReader(content), size, 4*MB)
+	if err != nil || detail.Etag != v1 || detail.Version != "v1" {
+		t.Fatalf("4M parts detail error:%v detail:%+v", err, detail)
+	}
+
+	for _, partSize := range []int64{MB, 3 * MB, 5 * MB, 8 * MB} {
+		v2, _ := EtagV2(bytes.NewReader(content), EtagParts(size, partSize))
+		detail, err = GetEtagDetail(bytes.NewReader(content), size, partSize)
+		if err != nil || detail.Etag != v2 {
+			t.Fatalf("part size %d etag should be %s, err:%v detail:%+v", partSize, v2, err, detail)
+		}
+		if !IsSignByEtagV2(detail.Etag) || detail.Version != "v2" {
+			t.Fatalf("part size %d etag should be v2, detail:%+v", partSize, detail)
+		}
+	}
+
+	empty, _ := EtagV1(bytes.NewReader(nil))
+	if detail, err = GetEtagDetail(bytes.NewReader(nil), 0, MB); err != nil || detail.Etag != empty {
+		t.Fatalf("empty data etag should be %s, err:%v detail:%+v", empty, err, detail)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -38,6 +39,35 @@ func FormatFileSize(size int64) (result string) {
 	return
 }
 
+// ParseFileSize 解析人工可读的文件大小，如：1024、512k、5m、1.5G、2MB，单位不区分大小写，无单位时为 B
+func ParseFileSize(size string) (int64, *data.CodeError) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+	value = strings.TrimSuffix(value, "B")
+
+	unit := int64(1)
+	if len(value) > 0 {
+		switch value[len(value)-1] {
+		case 'K':
+			unit = KB
+		case 'M':
+			unit = MB
+		case 'G':
+			unit = GB
+		case 'T':
+			unit = TB
+		}
+		if unit > 1 {
+			value = value[:len(value)-1]
+		}
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || number < 0 {
+		return 0, data.NewEmptyError().AppendDescF("invalid size:%s", size)
+	}
+	return int64(number * float64(unit)), nil
+}
+
 func MarshalToFile(filePath string, v interface{}) *data.CodeError {
 	if v == nil {
 		return nil
@@ -116,8 +146,12 @@ func LocalFileSize(filePath string) (int64, *data.CodeError) {
 }
 
 type NetworkFileInfo struct {
-	Size int64
-	Hash string
+	Size         int64
+	Hash         string
+	SupportRange bool  // 服务端是否支持 Range 请求，由 Accept-Ranges 决定
+	LastModified int64 // 服务端文件的修改时间，由 Last-Modified 决定，单位：秒；未知时为 0
+
+	ContentEncoding string // 服务端文件的 Content-Encoding，如 gzip；未压缩时为空
 }
 
 func NetworkFileLength(srcResUrl string) (fileSize int64, err *data.CodeError) {
@@ -129,8 +163,13 @@ func NetworkFileLength(srcResUrl string) (fileSize int64, err *data.CodeError) {
 }
 
 func GetNetworkFileInfo(srcResUrl string) (*NetworkFileInfo, *data.CodeError) {
+	return GetNetworkFileInfoWithClient(client.DefaultStorageClient().Client, srcResUrl)
+}
 
-	resp, respErr := client.DefaultStorageClient().Head(srcResUrl)
+// GetNetworkFileInfoWithClient 使用指定的 client 发送 HEAD 请求获取网络文件信息
+func GetNetworkFileInfoWithClient(c *http.Client, srcResUrl string) (*NetworkFileInfo, *data.CodeError) {
+
+	resp, respErr := c.Head(srcResUrl)
 	if respErr != nil {
 		return nil, data.NewEmptyError().AppendDescF("New head request failed, %s", respErr.Error())
 	}
@@ -156,6 +195,12 @@ func GetNetworkFileInfo(srcResUrl string) (*NetworkFileInfo, *data.CodeError) {
 		return file, data.NewEmptyError().AppendDescF("network file(%s) hasn't Content-Length", srcResUrl)
 	}
 
+	file.SupportRange = strings.EqualFold(resp.Header.Get("Accept-Ranges"), "bytes")
+	file.ContentEncoding = resp.Header.Get("Content-Encoding")
+	if lastModified, pErr := http.ParseTime(resp.Header.Get("Last-Modified")); pErr == nil {
+		file.LastModified = lastModified.Unix()
+	}
+
 	etag := resp.Header.Get("ETag")
 	if contentLength != "" {
 		file.Hash = ParseEtag(etag)
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// Glob 获取 root 目录下匹配 pattern 的所有文件，返回相对于 root 的路径；
+// pattern 为 shell 风格，相对于 root，使用 / 分隔，支持 `**` 匹配任意层级的目录；
+// 匹配到的目录会包含其下的所有文件。
+func Glob(root, pattern string) ([]string, *data.CodeError) {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("invalid glob pattern:%s", pattern).AppendError(err)
+	}
+
+	var matches []string
+	if !strings.Contains(pattern, "**") {
+		if m, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern))); err != nil {
+			return nil, data.NewEmptyError().AppendDescF("glob pattern:%s", pattern).AppendError(err)
+		} else {
+			matches = m
+		}
+	} else {
+		patternItems := strings.Split(pattern, "/")
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || path == root {
+				return err
+			}
+			relativePath, rErr := filepath.Rel(root, path)
+			if rErr != nil {
+				return rErr
+			}
+			if globMatch(patternItems, strings.Split(filepath.ToSlash(relativePath), "/")) {
+				matches = append(matches, path)
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, data.NewEmptyError().AppendDescF("glob pattern:%s", pattern).AppendError(err)
+		}
+	}
+
+	files := make([]string, 0, len(matches))
+	for _, match := range matches {
+		err := filepath.Walk(match, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			relativePath, rErr := filepath.Rel(root, path)
+			if rErr != nil {
+				return rErr
+			}
+			files = append(files, relativePath)
+			return nil
+		})
+		if err != nil {
+			return nil, data.NewEmptyError().AppendDescF("glob pattern:%s", pattern).AppendError(err)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// GlobMatch relativePath 是否匹配 pattern，pattern 的规则同 Glob，使用 / 分隔
+func GlobMatch(pattern, relativePath string) bool {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	relativePath = strings.TrimPrefix(filepath.ToSlash(relativePath), "./")
+	return globMatch(strings.Split(pattern, "/"), strings.Split(relativePath, "/"))
+}
+
+// globMatch 按路径分段匹配，`**` 匹配零个或多个分段，其他分段使用 filepath.Match 匹配
+func globMatch(patternItems, pathItems []string) bool {
+	if len(patternItems) == 0 {
+		return len(pathItems) == 0
+	}
+
+	if patternItems[0] == "**" {
+		for i := 0; i <= len(pathItems); i++ {
+			if globMatch(patternItems[1:], pathItems[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(pathItems) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(patternItems[0], pathItems[0]); !ok {
+		return false
+	}
+	return globMatch(patternItems[1:], pathItems[1:])
+}
@@ -0,0 +1,182 @@
+package operations
+
+import (
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+type BatchEtagInfo struct {
+	flow.Info
+	export.FileExporterConfig
+
+	Dir            string
+	BlockSize      string // 分片上传 v2 的分片大小，如 8m；为空时使用 v1 算法
+	FollowSymlinks bool   // 是否跟随软链接，不跟随时跳过软链接
+	Sort           bool   // 是否在全部计算结束后按相对路径排序输出
+
+	partSize int64
+}
+
+func (info *BatchEtagInfo) Check() *data.CodeError {
+	if len(info.Dir) == 0 {
+		return alert.CannotEmptyError("LocalDir", "")
+	}
+	if stat, err := os.Stat(info.Dir); err != nil {
+		return alert.Error("get directory status error: "+err.Error(), "")
+	} else if !stat.IsDir() {
+		return alert.Error(info.Dir+" is not a directory", "")
+	}
+
+	if len(info.BlockSize) > 0 {
+		partSize, err := utils.ParseFileSize(info.BlockSize)
+		if err != nil || partSize <= 0 {
+			return alert.Error("invalid block size: "+info.BlockSize, "block size should be like 4m, 8m")
+		}
+		info.partSize = partSize
+	}
+
+	if info.WorkerCount <= 0 {
+		info.WorkerCount = runtime.NumCPU()
+	}
+	// 仅读取本地文件，无需用户确认
+	info.Force = true
+	return info.Info.Check()
+}
+
+type batchEtagWork struct {
+	RelPath  string // 相对于根目录的路径，使用 / 分隔
+	FilePath string
+	Size     int64
+}
+
+func (w *batchEtagWork) WorkId() string {
+	return w.RelPath
+}
+
+type batchEtagResult struct {
+	RelPath string
+	Etag    string
+	Size    int64
+}
+
+func (r *batchEtagResult) IsValid() bool {
+	return len(r.Etag) > 0
+}
+
+// BatchEtag 遍历目录，并行计算目录下所有文件的 etag，每行输出：相对路径\tetag\t文件大小
+func BatchEtag(cfg *iqshell.Config, info BatchEtagInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	exporter, err := export.NewFileExport(info.FileExporterConfig)
+	if err != nil {
+		log.Error(err)
+		data.SetCmdStatusError()
+		return
+	}
+	defer exporter.Close()
+
+	output := func(result *batchEtagResult) {
+		if len(info.ResultExportFilePath) > 0 {
+			exporter.Result().ExportF("%s\t%s\t%d", result.RelPath, result.Etag, result.Size)
+		} else {
+			log.AlertF("%s\t%s\t%d", result.RelPath, result.Etag, result.Size)
+		}
+	}
+
+	var successCount, failureCount int64
+	onFail := func(relPath string, err *data.CodeError) {
+		atomic.AddInt64(&failureCount, 1)
+		exporter.Fail().ExportF("%s%s%v", relPath, flow.ErrorSeparate, err)
+		log.ErrorF("Etag Failed, %s, Error: %v", relPath, err)
+	}
+
+	works := make(chan flow.Work)
+	go func() {
+		defer close(works)
+		walker := &utils.DirWalker{
+			FollowSymlinks: info.FollowSymlinks,
+			OnFile: func(file *utils.WalkedFile) {
+				works <- &batchEtagWork{
+					RelPath:  file.RelPath,
+					FilePath: file.FilePath,
+					Size:     file.Size,
+				}
+			},
+			OnFail: onFail,
+		}
+		walker.Walk(info.Dir)
+	}()
+
+	var resultsLock sync.Mutex
+	var results []*batchEtagResult
+	flow.New(info.Info).
+		WorkProviderWithChan(works).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
+				work := workInfo.Work.(*batchEtagWork)
+				return fileEtag(work, info.partSize)
+			}), nil
+		})).
+		OnWorkSkip(func(workInfo *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			log.InfoF("Skip file:%s because:%v", workInfo.Work.WorkId(), err)
+		}).
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
+			atomic.AddInt64(&successCount, 1)
+			etagResult, _ := result.(*batchEtagResult)
+			if etagResult == nil {
+				return
+			}
+			if !info.Sort {
+				output(etagResult)
+				return
+			}
+			resultsLock.Lock()
+			results = append(results, etagResult)
+			resultsLock.Unlock()
+		}).
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
+			onFail(workInfo.Work.WorkId(), err)
+		}).Build().Start()
+
+	if info.Sort {
+		sort.Slice(results, func(i, j int) bool {
+			return results[i].RelPath < results[j].RelPath
+		})
+		for _, result := range results {
+			output(result)
+		}
+	}
+
+	if failureCount > 0 {
+		// 无法读取的本地文件不在 flow 中，需和 flow 中失败的 work 一起设置命令状态
+		data.SetCmdStatusByWorkCount(successCount+failureCount, failureCount)
+		log.ErrorF("%d file(s) failed to calculate etag", failureCount)
+	}
+}
+
+func fileEtag(work *batchEtagWork, partSize int64) (*batchEtagResult, *data.CodeError) {
+	etag, err := utils.GetFileEtag(work.FilePath, utils.EtagParts(work.Size, partSize))
+	if err != nil {
+		return nil, err
+	}
+	return &batchEtagResult{
+		RelPath: work.RelPath,
+		Etag:    etag,
+		Size:    work.Size,
+	}, nil
+}
@@ -0,0 +1,32 @@
+package operations
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+func TestFileEtag(t *testing.T) {
+	content := bytes.Repeat([]byte("qshell"), 2*1024*1024)
+	filePath := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, partSize := range []int64{0, 4 * 1024 * 1024, 5 * 1024 * 1024} {
+		detail, err := utils.GetEtagDetail(bytes.NewReader(content), int64(len(content)), partSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := fileEtag(&batchEtagWork{RelPath: "file", FilePath: filePath, Size: int64(len(content))}, partSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Etag != detail.Etag || result.Size != detail.Size {
+			t.Fatalf("etag error, part size:%d etag:%s expected:%s", partSize, result.Etag, detail.Etag)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package operations
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/auth/qbox"
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+const (
+	signTypeDownloadUrl = "download url"
+	signTypeUploadToken = "upload token"
+)
+
+type CheckSignInfo struct {
+	AccessKey string
+	SecretKey string
+	Sign      string // 私有下载链接、带 token 的上传链接或者上传 token
+}
+
+func (info *CheckSignInfo) Check() *data.CodeError {
+	if len(info.Sign) == 0 {
+		return alert.CannotEmptyError("UrlOrToken", "")
+	}
+	return nil
+}
+
+type signCheckResult struct {
+	Type           string
+	AccessKey      string
+	AccessKeyMatch bool
+	SignatureValid bool
+	Deadline       int64  // 过期时间，单位：秒，0 表示未设置
+	Policy         string // 上传策略，已格式化
+}
+
+// CheckSign 使用当前账号的密钥重新计算签名，检查签名是否有效及过期时间
+func CheckSign(cfg *iqshell.Config, info CheckSignInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	var mac *qbox.Mac
+	if info.AccessKey != "" && info.SecretKey != "" {
+		mac = qbox.NewMac(info.AccessKey, info.SecretKey)
+	} else {
+		var mErr *data.CodeError
+		if mac, mErr = account.GetMac(); mErr != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("get mac: %v", mErr)
+			return
+		}
+	}
+
+	now := time.Now()
+	result, err := checkSign(mac, info.Sign)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	log.AlertF("%-16s%s", "Type:", result.Type)
+	if result.AccessKeyMatch {
+		log.AlertF("%-16s%s", "AccessKey:", result.AccessKey)
+	} else {
+		log.AlertF("%-16s%s (mismatch, current is %s)", "AccessKey:", result.AccessKey, mac.AccessKey)
+	}
+	if result.SignatureValid {
+		log.AlertF("%-16s%s", "Signature:", "valid")
+	} else {
+		log.AlertF("%-16s%s", "Signature:", "invalid")
+	}
+	if result.Deadline > 0 {
+		deadline := time.Unix(result.Deadline, 0)
+		if deadline.After(now) {
+			log.AlertF("%-16s%s (expires in %s)", "Deadline:", deadline.Format("2006-01-02 15:04:05"), deadline.Sub(now).Round(time.Second))
+		} else {
+			log.AlertF("%-16s%s (expired %s ago)", "Deadline:", deadline.Format("2006-01-02 15:04:05"), now.Sub(deadline).Round(time.Second))
+		}
+	} else {
+		log.AlertF("%-16s%s", "Deadline:", "not set")
+	}
+	if len(result.Policy) > 0 {
+		log.AlertF("%-16s\n%s", "PutPolicy:", result.Policy)
+	}
+
+	if !result.AccessKeyMatch || !result.SignatureValid || (result.Deadline > 0 && result.Deadline <= now.Unix()) {
+		data.SetCmdStatusError()
+	}
+}
+
+// checkSign sign 可以是：
+// 1. 私有下载链接：<Url>?e=<Deadline>&token=<AccessKey>:<Sign>
+// 2. 带上传 token 的链接：<Url>?token=<UploadToken>
+// 3. 上传 token：[UpToken ]<AccessKey>:<Sign>:<EncodedPutPolicy>
+func checkSign(mac *qbox.Mac, sign string) (*signCheckResult, *data.CodeError) {
+	sign = strings.TrimSpace(sign)
+	if strings.HasPrefix(sign, "http://") || strings.HasPrefix(sign, "https://") {
+		return checkUrlSign(mac, sign)
+	}
+	return checkUploadToken(mac, sign)
+}
+
+func checkUrlSign(mac *qbox.Mac, signedUrl string) (*signCheckResult, *data.CodeError) {
+	index := strings.LastIndex(signedUrl, "token=")
+	if index < 1 || (signedUrl[index-1] != '&' && signedUrl[index-1] != '?') {
+		return nil, data.NewEmptyError().AppendDesc("no token found in url")
+	}
+
+	token := signedUrl[index+len("token="):]
+	if i := strings.Index(token, "&"); i >= 0 {
+		token = token[:i]
+	}
+	if t, err := url.QueryUnescape(token); err == nil {
+		token = t
+	}
+	if strings.Count(token, ":") == 2 {
+		// 链接中携带的是上传 token
+		return checkUploadToken(mac, token)
+	}
+
+	items := strings.Split(token, ":")
+	if len(items) != 2 {
+		return nil, data.NewEmptyError().AppendDescF("invalid token:%s, should be <AccessKey>:<Sign>", token)
+	}
+
+	// 签名的内容为 token 参数之前的部分，token 为链接的最后一个参数
+	urlToSign := signedUrl[:index-1]
+	result := &signCheckResult{
+		Type:           signTypeDownloadUrl,
+		AccessKey:      items[0],
+		AccessKeyMatch: items[0] == mac.AccessKey,
+		SignatureValid: mac.Sign([]byte(urlToSign)) == token,
+	}
+
+	if u, err := url.Parse(urlToSign); err == nil {
+		if e := u.Query().Get("e"); len(e) > 0 {
+			deadline, pErr := strconv.ParseInt(e, 10, 64)
+			if pErr != nil {
+				return nil, data.NewEmptyError().AppendDescF("invalid deadline e=%s", e)
+			}
+			result.Deadline = deadline
+		}
+	}
+	return result, nil
+}
+
+func checkUploadToken(mac *qbox.Mac, token string) (*signCheckResult, *data.CodeError) {
+	t, err := decodeUploadToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &signCheckResult{
+		Type:           signTypeUploadToken,
+		AccessKey:      t.AccessKey,
+		AccessKeyMatch: t.AccessKey == mac.AccessKey,
+		SignatureValid: mac.Sign([]byte(t.EncodedPolicy)) == t.AccessKey+":"+t.Sign,
+		Deadline:       t.Deadline,
+		Policy:         t.Policy,
+	}, nil
+}
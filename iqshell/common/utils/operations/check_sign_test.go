@@ -0,0 +1,43 @@
+package operations
+
+import (
+	"testing"
+
+	"github.com/qiniu/go-sdk/v7/auth/qbox"
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+func TestCheckSign(t *testing.T) {
+	mac := qbox.NewMac("ak", "sk")
+
+	publicUrl := "http://test.com/a.jpg?e=1700000000"
+	privateUrl := publicUrl + "&token=" + mac.Sign([]byte(publicUrl))
+	result, err := checkSign(mac, privateUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Type != signTypeDownloadUrl || !result.AccessKeyMatch || !result.SignatureValid || result.Deadline != 1700000000 {
+		t.Fatalf("check private url error:%+v", result)
+	}
+
+	if result, err = checkSign(qbox.NewMac("ak", "sk2"), privateUrl); err != nil || result.SignatureValid {
+		t.Fatalf("signature should be invalid, result:%+v err:%v", result, err)
+	}
+
+	token := (&storage.PutPolicy{Scope: "bucket", Expires: 3600}).UploadToken(mac)
+	for _, sign := range []string{token, "UpToken " + token, "http://upload.qiniup.com/?token=" + token} {
+		result, err = checkSign(mac, sign)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Type != signTypeUploadToken || !result.SignatureValid || result.Deadline == 0 || len(result.Policy) == 0 {
+			t.Fatalf("check upload token %s error:%+v", sign, result)
+		}
+	}
+
+	for _, sign := range []string{"http://test.com/a.jpg", "ak:sign", "ak:sign:!!!"} {
+		if _, err = checkSign(mac, sign); err == nil {
+			t.Fatalf("sign %s should be invalid", sign)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package operations
 
 import (
+	"encoding/json"
+	"os"
+
 	"github.com/qiniu/qshell/v2/iqshell"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
@@ -9,13 +12,25 @@ import (
 )
 
 type EtagInfo struct {
-	FilePath string
+	FilePath   string
+	BlockSize  string // 分片上传 v2 的分片大小，如 8m；为空时使用 v1 算法
+	ShowDetail bool   // 以 JSON 格式输出 etag 及每块数据的 sha1
+	Verify     string // 期望的 etag，不一致时命令返回错误
+
+	partSize int64
 }
 
 func (info *EtagInfo) Check() *data.CodeError {
 	if len(info.FilePath) == 0 {
 		return alert.CannotEmptyError("LocalFilePath", "")
 	}
+	if len(info.BlockSize) > 0 {
+		partSize, err := utils.ParseFileSize(info.BlockSize)
+		if err != nil || partSize <= 0 {
+			return alert.Error("invalid block size: "+info.BlockSize, "block size should be like 4m, 8m")
+		}
+		info.partSize = partSize
+	}
 	return nil
 }
 
@@ -27,17 +42,42 @@ func CreateEtag(cfg *iqshell.Config, info EtagInfo) {
 		return
 	}
 
-	if len(info.FilePath) == 0 {
+	file, oErr := os.Open(info.FilePath)
+	if oErr != nil {
 		data.SetCmdStatusError()
-		log.Error(alert.CannotEmpty("file path", ""))
+		log.ErrorF("open file:%s error:%v", info.FilePath, oErr)
 		return
 	}
+	defer file.Close()
 
-	etag, err := utils.GetEtag(info.FilePath)
+	stat, sErr := file.Stat()
+	if sErr != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("get file:%s status error:%v", info.FilePath, sErr)
+		return
+	}
+
+	detail, err := utils.GetEtagDetail(file, stat.Size(), info.partSize)
 	if err != nil {
 		data.SetCmdStatusError()
 		log.Error(err)
 		return
 	}
-	log.Alert(etag)
+
+	if info.ShowDetail {
+		detailBytes, mErr := json.MarshalIndent(detail, "", "\t")
+		if mErr != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("marshal etag detail error:%v", mErr)
+			return
+		}
+		log.Alert(string(detailBytes))
+	} else {
+		log.Alert(detail.Etag)
+	}
+
+	if len(info.Verify) > 0 && utils.ParseEtag(info.Verify) != detail.Etag {
+		data.SetCmdStatusError()
+		log.ErrorF("etag doesn't match, expected:%s but:%s", info.Verify, detail.Etag)
+	}
 }
@@ -0,0 +1,220 @@
+package operations
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/auth/qbox"
+	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+// maxRelativePolicyDeadline 上传策略中小于此值的 deadline 当作有效时长（秒），否则当作时间戳
+const maxRelativePolicyDeadline = 1000000000
+
+func UpToken(cfg *iqshell.Config) {
+	iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{})
+}
+
+type UpTokenCreateInfo struct {
+	AccessKey         string
+	SecretKey         string
+	PutPolicyFilePath string
+}
+
+func (info *UpTokenCreateInfo) Check() *data.CodeError {
+	if len(info.PutPolicyFilePath) == 0 {
+		return alert.CannotEmptyError("PutPolicyJsonFile", "")
+	}
+	return nil
+}
+
+// CreateUpToken 根据上传策略文件生成上传 token，会校验上传策略的字段
+func CreateUpToken(cfg *iqshell.Config, info UpTokenCreateInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	policyData, rErr := os.ReadFile(info.PutPolicyFilePath)
+	if rErr != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("read put policy file %s: %v", info.PutPolicyFilePath, rErr)
+		return
+	}
+
+	putPolicy, warnings, err := parsePutPolicy(policyData, time.Now())
+	for _, w := range warnings {
+		log.Warning(w)
+	}
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("parse put policy file %s: %v", info.PutPolicyFilePath, err)
+		return
+	}
+
+	var mac *qbox.Mac
+	if info.AccessKey != "" && info.SecretKey != "" {
+		mac = qbox.NewMac(info.AccessKey, info.SecretKey)
+	} else {
+		var mErr *data.CodeError
+		if mac, mErr = account.GetMac(); mErr != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("get mac: %v", mErr)
+			return
+		}
+	}
+	log.Alert(putPolicy.UploadToken(mac))
+}
+
+type UpTokenDecodeInfo struct {
+	Token string
+}
+
+func (info *UpTokenDecodeInfo) Check() *data.CodeError {
+	if len(info.Token) == 0 {
+		return alert.CannotEmptyError("UploadToken", "")
+	}
+	return nil
+}
+
+// DecodeUpToken 解码上传 token，输出格式化的上传策略，不校验签名
+func DecodeUpToken(cfg *iqshell.Config, info UpTokenDecodeInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	token, err := decodeUploadToken(info.Token)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	log.DebugF("upload token access key:%s", token.AccessKey)
+	log.Alert(token.Policy)
+}
+
+type uploadToken struct {
+	AccessKey     string
+	Sign          string
+	EncodedPolicy string
+	Deadline      int64
+	Policy        string // 上传策略，已格式化
+}
+
+// decodeUploadToken token 格式：[UpToken ]<AccessKey>:<Sign>:<EncodedPutPolicy>
+func decodeUploadToken(token string) (*uploadToken, *data.CodeError) {
+	token = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(token), "UpToken "))
+	items := strings.Split(token, ":")
+	if len(items) != 3 {
+		return nil, data.NewEmptyError().AppendDesc("invalid upload token, should be <AccessKey>:<Sign>:<EncodedPutPolicy>")
+	}
+
+	policyData, err := base64.URLEncoding.DecodeString(items[2])
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("decode put policy error:%v", err)
+	}
+
+	policy := &struct {
+		Deadline int64 `json:"deadline"`
+	}{}
+	if err := json.Unmarshal(policyData, policy); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("parse put policy error:%v", err)
+	}
+
+	policyBuffer := &bytes.Buffer{}
+	if err := json.Indent(policyBuffer, policyData, "", "    "); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("format put policy error:%v", err)
+	}
+
+	return &uploadToken{
+		AccessKey:     items[0],
+		Sign:          items[1],
+		EncodedPolicy: items[2],
+		Deadline:      policy.Deadline,
+		Policy:        policyBuffer.String(),
+	}, nil
+}
+
+// putPolicyKeys 上传策略支持的字段，来自 storage.PutPolicy 的 json tag
+func putPolicyKeys() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(storage.PutPolicy{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; len(name) > 0 && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// parsePutPolicy 解析并校验上传策略；不支持的字段会被忽略并返回警告
+// deadline 小于 1000000000 时当作有效时长（秒），否则当作过期时间戳，未设置时有效期为 1 小时
+func parsePutPolicy(policyData []byte, now time.Time) (policy *storage.PutPolicy, warnings []string, err *data.CodeError) {
+	// remove UTF-8 BOM
+	policyData = bytes.TrimPrefix(policyData, []byte("\xef\xbb\xbf"))
+
+	fields := make(map[string]json.RawMessage)
+	if e := json.Unmarshal(policyData, &fields); e != nil {
+		return nil, nil, data.NewEmptyError().AppendDescF("invalid json:%v", e)
+	}
+
+	knownKeys := putPolicyKeys()
+	unknownKeys := make([]string, 0)
+	for key := range fields {
+		if !knownKeys[key] {
+			unknownKeys = append(unknownKeys, key)
+			delete(fields, key)
+		}
+	}
+	sort.Strings(unknownKeys)
+	for _, key := range unknownKeys {
+		warnings = append(warnings, "unknown put policy key `"+key+"`, ignored")
+	}
+
+	// json 解析字段名时不区分大小写，移除不支持的字段后再解析，避免拼写错误的字段生效
+	policyData, _ = json.Marshal(fields)
+	policy = &storage.PutPolicy{}
+	if e := json.Unmarshal(policyData, policy); e != nil {
+		return nil, warnings, data.NewEmptyError().AppendDescF("invalid put policy:%v", e)
+	}
+
+	if len(policy.Scope) == 0 {
+		return nil, warnings, alert.CannotEmptyError("scope", "")
+	}
+	if len(policy.CallbackURL) > 0 && len(policy.CallbackBody) == 0 {
+		return nil, warnings, data.NewEmptyError().AppendDesc("callbackBody can't be empty when callbackUrl is set")
+	}
+	if len(policy.CallbackURL) == 0 && (len(policy.CallbackBody) > 0 || len(policy.CallbackHost) > 0 || len(policy.CallbackBodyType) > 0) {
+		warnings = append(warnings, "callbackBody, callbackHost and callbackBodyType are ignored when callbackUrl is not set")
+	}
+	if len(policy.PersistentOps) > 0 && len(policy.PersistentNotifyURL) == 0 {
+		warnings = append(warnings, "persistentNotifyUrl is not set, the result of persistentOps can only be queried by prefop")
+	}
+	if len(policy.ReturnURL) > 0 && len(policy.CallbackURL) > 0 {
+		warnings = append(warnings, "returnUrl is ignored when callbackUrl is set")
+	}
+
+	// storage.PutPolicy 生成 token 时 deadline 为有效时长
+	if policy.Expires >= maxRelativePolicyDeadline {
+		if int64(policy.Expires) <= now.Unix() {
+			return nil, warnings, data.NewEmptyError().AppendDescF("deadline %d is expired", policy.Expires)
+		}
+		policy.Expires = uint64(int64(policy.Expires) - now.Unix())
+	}
+	return policy, warnings, nil
+}
@@ -0,0 +1,24 @@
+package operations
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePutPolicy(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	policy, warnings, err := parsePutPolicy([]byte(`{"scope":"bucket","deadline":1700003600,"callBackUrl":"x"}`), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Expires != 3600 || len(warnings) != 1 || !strings.Contains(warnings[0], "callBackUrl") {
+		t.Fatalf("parse put policy error, policy:%+v warnings:%v", policy, warnings)
+	}
+
+	for _, p := range []string{`{}`, `{"scope":"bucket","callbackUrl":"http://a.com"}`, `{"scope":"bucket","deadline":1600000000}`, `[]`} {
+		if _, _, err = parsePutPolicy([]byte(p), now); err == nil {
+			t.Fatalf("put policy %s should be invalid", p)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -23,6 +25,32 @@ func TestBytesToReadable(t *testing.T) {
 	}
 }
 
+func TestParseFileSize(t *testing.T) {
+	sizes := map[string]int64{
+		"512":   512,
+		"512b":  512,
+		"1k":    1024,
+		"1.5K":  1536,
+		"5m":    5 * MB,
+		"5MB":   5 * MB,
+		"2G":    2 * GB,
+		" 1t  ": TB,
+	}
+
+	for size, want := range sizes {
+		got, err := ParseFileSize(size)
+		if err != nil || got != want {
+			t.Fatalf("size:%s got=%d, want=%d, err:%v", size, got, want, err)
+		}
+	}
+
+	for _, size := range []string{"", "m", "-1k", "5x"} {
+		if _, err := ParseFileSize(size); err == nil {
+			t.Fatalf("size:%s should be invalid", size)
+		}
+	}
+}
+
 func TestKeyFromUrl(t *testing.T) {
 	url := "http://vod4a6mk39q.nosdn.127.net/b258912a66334476851b698d6fe64931_1558331445602_1558331488089_2062207192-00000.mp4?download=%E7%A7%80%E7%9B%B4%E6%92%AD%E7%BC%96%E5%8F%B72114_20190520-135045_20190520-135128.mp4"
 	want := "b258912a66334476851b698d6fe64931_1558331445602_1558331488089_2062207192-00000.mp4"
@@ -57,3 +85,38 @@ func TestRemoveUrlScheme(t *testing.T) {
 		t.Fatalf("RemoveUrlScheme https:// failed, excpet:%s but:%s\n", host, result)
 	}
 }
+
+func TestGlob(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{"logs/2024-01/a.gz", "logs/2024-02/b.gz", "logs/2024-02/c.txt", "logs/2023-12/d.gz", "img/x/y/z.png"} {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := map[string][]string{
+		"logs/2024-*/*.gz": {"logs/2024-01/a.gz", "logs/2024-02/b.gz"},
+		"**/*.png":         {"img/x/y/z.png"},
+		"logs/**/c.txt":    {"logs/2024-02/c.txt"},
+		"img":              {"img/x/y/z.png"},
+		"video/*":          {},
+	}
+	for pattern, expect := range cases {
+		files, err := Glob(root, pattern)
+		if err != nil {
+			t.Fatalf("glob %s error:%v", pattern, err)
+		}
+		if len(files) != len(expect) {
+			t.Fatalf("glob %s, expect:%v but:%v", pattern, expect, files)
+		}
+		for i, f := range files {
+			if filepath.ToSlash(f) != expect[i] {
+				t.Fatalf("glob %s, expect:%v but:%v", pattern, expect, files)
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package workspace
 
 import (
 	"github.com/qiniu/go-sdk/v7/auth"
+	"github.com/qiniu/go-sdk/v7/storage"
 	"github.com/qiniu/qshell/v2/iqshell/common/config"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 )
@@ -12,7 +13,9 @@ func defaultConfig() *config.Config {
 			AccessKey: "",
 			SecretKey: nil,
 		},
-		UseHttps: data.NewBool(false),
+		UseHttps:       data.NewBool(false),
+		DefaultRegion:  data.NewString("z0"),
+		RegionCacheTTL: data.NewInt(3600),
 		Hosts: &config.Hosts{
 			UC: []string{"uc.qbox.me"},
 		},
@@ -22,29 +25,57 @@ func defaultConfig() *config.Config {
 			LogRotate: data.NewInt(7),
 			LogStdout: data.NewBool(true),
 		},
+		Metrics: &config.Metrics{
+			Prefix:   data.NewString("qshell"),
+			Interval: data.NewInt(10),
+		},
 	}
 }
 
 func checkConfig(cfg *config.Config) (err *data.CodeError) {
 	// host
-	configHostCount := 0
-	if len(cfg.Hosts.Api) > 0 {
-		configHostCount += 1
-	}
-	if len(cfg.Hosts.Rs) > 0 {
-		configHostCount += 1
+	if cfg.Hosts != nil {
+		if err = cfg.Hosts.Check(); err != nil {
+			err = data.NewEmptyError().AppendDescF("hosts: %v", err)
+			return
+		}
 	}
-	if len(cfg.Hosts.Rsf) > 0 {
-		configHostCount += 1
+
+	// log
+	if cfg.Log != nil {
+		if err = cfg.Log.Check(); err != nil {
+			return
+		}
 	}
-	if len(cfg.Hosts.Io) > 0 {
-		configHostCount += 1
+
+	// metrics
+	if cfg.Metrics != nil {
+		if err = cfg.Metrics.Check(); err != nil {
+			return
+		}
 	}
-	if len(cfg.Hosts.Up) > 0 {
-		configHostCount += 1
+
+	// timeout
+	for name, timeout := range map[string]*data.Int{
+		"connect_timeout": cfg.ConnectTimeout,
+		"read_timeout":    cfg.ReadTimeout,
+		"overall_timeout": cfg.OverallTimeout,
+	} {
+		if timeout.Value() < 0 {
+			err = data.NewEmptyError().AppendDescF("%s can't be negative, but is:%d", name, timeout.Value())
+			return
+		}
 	}
-	if configHostCount != 0 && configHostCount != 5 {
-		err = data.NewEmptyError().AppendDesc("hosts: api/rs/rsf/io/up should config all")
+
+	// region
+	for _, regionId := range []string{cfg.Region.Value(), cfg.DefaultRegion.Value()} {
+		if len(regionId) == 0 {
+			continue
+		}
+		if _, ok := storage.GetRegionByID(storage.RegionID(regionId)); !ok {
+			err = data.NewEmptyError().AppendDescF("region:%s is invalid, should be z0, z1, z2, na0, as0 etc", regionId)
+			return
+		}
 	}
 	return
 }
@@ -11,44 +11,132 @@ import (
 
 var (
 	// 程序是否退出
-	isCmdInterrupt  uint32 = 0
-	locker          sync.Mutex
-	cancelObservers = make([]func(s os.Signal), 0)
+	isCmdInterrupt     uint32 = 0
+	locker             sync.Mutex
+	cancelObservers    = make([]*cancelObserver, 0)
+	interruptObservers = make([]*interruptObserver, 0)
+	// 大于 0 时收到第一次中断信号不立即退出，参考 EnableGracefulInterrupt
+	gracefulInterruptCount int32 = 0
 )
 
-func AddCancelObserver(observer func(s os.Signal)) {
+type cancelObserver struct {
+	observe func(s os.Signal)
+}
+
+type interruptObserver struct {
+	observe func()
+}
+
+// AddCancelObserver 添加程序退出的监听，返回的函数用于移除此监听，监听者生命周期短于程序时（如：flow）需在结束时移除
+func AddCancelObserver(observer func(s os.Signal)) (remove func()) {
 	if observer == nil {
-		return
+		return func() {}
 	}
 
+	o := &cancelObserver{observe: observer}
 	locker.Lock()
-	cancelObservers = append(cancelObservers, observer)
+	cancelObservers = append(cancelObservers, o)
 	locker.Unlock()
+
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			locker.Lock()
+			defer locker.Unlock()
+			for i, item := range cancelObservers {
+				if item == o {
+					cancelObservers = append(cancelObservers[:i:i], cancelObservers[i+1:]...)
+					break
+				}
+			}
+		})
+	}
 }
 
 func notifyCancelSignalToObservers(s os.Signal) {
 	locker.Lock()
 	for _, observer := range cancelObservers {
-		observer(s)
+		observer.observe(s)
 	}
 	locker.Unlock()
 }
 
+// AddInterruptObserver 优雅中断时（参考 EnableGracefulInterrupt）收到第一次中断信号的监听，
+// 返回的函数用于移除此监听，与 AddCancelObserver 相同，监听者生命周期短于程序时需在结束时移除
+func AddInterruptObserver(observer func()) (remove func()) {
+	if observer == nil {
+		return func() {}
+	}
+
+	o := &interruptObserver{observe: observer}
+	locker.Lock()
+	interruptObservers = append(interruptObservers, o)
+	locker.Unlock()
+
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			locker.Lock()
+			defer locker.Unlock()
+			for i, item := range interruptObservers {
+				if item == o {
+					interruptObservers = append(interruptObservers[:i:i], interruptObservers[i+1:]...)
+					break
+				}
+			}
+		})
+	}
+}
+
+func notifyInterruptToObservers() {
+	locker.Lock()
+	observers := interruptObservers
+	locker.Unlock()
+
+	for _, observer := range observers {
+		observer.observe()
+	}
+}
+
+// EnableGracefulInterrupt 开启优雅中断：第一次收到中断信号时仅标记中断（IsCmdInterrupt），不再处理新的任务，
+// 等待正在处理的任务完成后由调用方结束；第二次收到中断信号时强制退出。返回的函数用于关闭优雅中断
+func EnableGracefulInterrupt() (disable func()) {
+	atomic.AddInt32(&gracefulInterruptCount, 1)
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			atomic.AddInt32(&gracefulInterruptCount, -1)
+		})
+	}
+}
+
+func isGracefulInterruptEnable() bool {
+	return atomic.LoadInt32(&gracefulInterruptCount) > 0
+}
+
 func IsCmdInterrupt() bool {
 	return atomic.LoadUint32(&isCmdInterrupt) > 0
 }
 
 func observerCmdInterrupt() {
-	s := make(chan os.Signal, 1)
+	s := make(chan os.Signal, 2)
 	signal.Notify(s, os.Interrupt, os.Kill)
 	go func() {
-		si := <-s
-		log.Alert("")
-		log.DebugF("Got signal:%s", si)
-		atomic.StoreUint32(&isCmdInterrupt, 1)
-		data.SetCmdStatusUserCancel()
-		Cancel()
-		notifyCancelSignalToObservers(si)
-		os.Exit(data.StatusUserCancel)
+		for si := range s {
+			log.DebugF("Got signal:%s", si)
+			if atomic.CompareAndSwapUint32(&isCmdInterrupt, 0, 1) {
+				data.SetCmdStatusUserCancel()
+				if isGracefulInterruptEnable() {
+					log.Warning("Interrupted, stop processing new works and wait for the processing works to finish, press Ctrl-C again to force quit")
+					notifyInterruptToObservers()
+					continue
+				}
+			}
+
+			log.Alert("")
+			Cancel()
+			notifyCancelSignalToObservers(si)
+			os.Exit(data.StatusUserCancel)
+		}
 	}()
 }
@@ -0,0 +1,27 @@
+package workspace
+
+import "testing"
+
+func TestAddInterruptObserver(t *testing.T) {
+	var first, second int
+	removeFirst := AddInterruptObserver(func() {
+		first++
+	})
+	removeSecond := AddInterruptObserver(func() {
+		second++
+	})
+	defer removeSecond()
+
+	notifyInterruptToObservers()
+	if first != 1 || second != 1 {
+		t.Fatal("all observers should be notified, but:", first, second)
+	}
+
+	// 移除后不再通知，重复移除不影响其他监听
+	removeFirst()
+	removeFirst()
+	notifyInterruptToObservers()
+	if first != 1 || second != 2 {
+		t.Fatal("removed observer should not be notified, but:", first, second)
+	}
+}
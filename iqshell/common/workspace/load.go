@@ -2,6 +2,7 @@ package workspace
 
 import (
 	"path/filepath"
+	"time"
 
 	"github.com/qiniu/go-sdk/v7/auth"
 	"github.com/qiniu/go-sdk/v7/storage"
@@ -9,6 +10,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 
 	"github.com/qiniu/qshell/v2/iqshell/common/account"
+	"github.com/qiniu/qshell/v2/iqshell/common/client"
 	"github.com/qiniu/qshell/v2/iqshell/common/config"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
@@ -19,6 +21,8 @@ type LoadInfo struct {
 	CmdConfig        *config.Config
 	WorkspacePath    string
 	JobPathBuilder   func(cmdPath string) string
+	Profile          string
+	SkipAccount      bool // 不加载账户信息，使用默认的用户目录，如：仅校验输入文件时
 	globalConfigPath string
 }
 
@@ -57,6 +61,7 @@ func Load(info LoadInfo) (err *data.CodeError) {
 		AccountPath:    accountPath,
 		OldAccountPath: oldAccountPath,
 		AccountDBPath:  accountDBPath,
+		Profile:        info.Profile,
 	})
 	if err != nil {
 		log.ErrorF("load account error:%v", err)
@@ -71,9 +76,13 @@ func Load(info LoadInfo) (err *data.CodeError) {
 			return
 		}
 
-		loadUserInfo()
+		if err = loadUserInfo(info); err != nil {
+			return
+		}
 	} else {
-		loadUserInfo()
+		if err = loadUserInfo(info); err != nil {
+			return
+		}
 		info.UserConfigPath = filepath.Join(userDir, configFileName)
 
 		err = config.LoadUserConfig(info.UserConfigPath)
@@ -101,6 +110,18 @@ func Load(info LoadInfo) (err *data.CodeError) {
 		return
 	}
 
+	// 代理及 TLS 需要在发起请求前设置，证书文件无效时直接报错
+	if err = client.SetProxy(cfg.Proxy.Value()); err != nil {
+		return
+	}
+	if err = client.SetTLS(cfg.CAFile.Value(), cfg.IsInsecureSkipVerify()); err != nil {
+		return
+	}
+	client.SetTimeout(time.Duration(cfg.ConnectTimeout.Value())*time.Second, time.Duration(cfg.ReadTimeout.Value())*time.Second)
+	if cfg.IsInsecureSkipVerify() {
+		log.Warning("insecure-skip-verify is enabled, TLS certificates of servers will NOT be verified, this is insecure and should only be used for testing")
+	}
+
 	// 配置 Job path
 	jobDir = filepath.Join(userDir, info.CmdConfig.CmdId)
 	if info.JobPathBuilder != nil {
@@ -134,8 +155,30 @@ func (w *LoadInfo) initInfo() *data.CodeError {
 	return nil
 }
 
-func loadUserInfo() {
+func loadUserInfo(info LoadInfo) *data.CodeError {
+	if info.SkipAccount {
+		userDir = filepath.Join(workspaceDir, usersDirName, defaultUserDirName)
+		log.DebugF("user dir:%s", userDir)
+		return nil
+	}
+
+	profile := info.Profile
+	provider, err := account.NewCredentialProvider(config.GetCredentialProvider(config.ConfigTypeDefault))
+	if err != nil {
+		return err
+	}
+	account.SetCredentialProvider(provider)
+
 	acc, err := account.GetAccount()
+	if err != nil {
+		// 指定的账户不存在、外部凭证无效或已过期时不再继续，避免使用其他账户执行命令
+		if len(profile) > 0 {
+			return data.NewEmptyError().AppendDescF("get user of profile:%s error:%v", profile, err)
+		}
+		if account.HasEnvCredentials() || account.HasCredentialProvider() {
+			return err
+		}
+	}
 	if err == nil {
 		currentAccount = &acc
 		accountName := acc.Name
@@ -143,9 +186,16 @@ func loadUserInfo() {
 			accountName = currentAccount.AccessKey
 		}
 		log.DebugF("current user name:%s", accountName)
+		if acc.Source == account.SourceEnv || acc.Source == account.SourceCredentialsFile || acc.Source == account.SourceProvider {
+			log.InfoF("use credentials from %s, AccessKey:%s", acc.Source, acc.AccessKey)
+		} else {
+			log.DebugF("use credentials from %s", acc.Source)
+		}
 
 		userDir = filepath.Join(workspaceDir, usersDirName, accountName)
 
+		client.SetSessionToken(acc.SessionToken)
+
 		// 配置 config 的 Credentials
 		cfg.Credentials = &auth.Credentials{
 			AccessKey: acc.AccessKey,
@@ -156,4 +206,5 @@ func loadUserInfo() {
 	}
 
 	log.DebugF("user dir:%s", userDir)
+	return nil
 }
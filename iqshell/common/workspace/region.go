@@ -0,0 +1,107 @@
+package workspace
+
+import (
+	"sync"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+type bucketRegion struct {
+	region   *storage.Region
+	deadline time.Time
+}
+
+// bucketRegionQuery 正在进行的区域查询，同一空间同时只查询一次，其他调用等待查询结果
+type bucketRegionQuery struct {
+	done   chan struct{}
+	region *storage.Region
+}
+
+// bucketRegionFallbackTTL 查询失败时使用的默认区域只短暂缓存，避免一次偶发的查询失败长时间使用错误的区域
+const bucketRegionFallbackTTL = 10 * time.Second
+
+var (
+	bucketRegionLock    sync.Mutex
+	bucketRegions       = make(map[string]*bucketRegion)
+	bucketRegionQueries = make(map[string]*bucketRegionQuery)
+
+	// 查询空间所在的区域，测试时替换
+	bucketRegionQuerier = queryBucketRegion
+)
+
+// GetBucketRegion 获取空间所在的区域：优先使用 --region 指定的区域及配置的 hosts；否则查询空间所在的区域，
+// 查询结果按空间缓存 region_cache_ttl 秒；查询失败时使用 default_region 并输出警告，default_region 无效时返回 nil；
+// 仅配置了部分 hosts 时，使用配置的服务地址替换区域中对应的服务地址
+func GetBucketRegion(bucket string) *storage.Region {
+	return cfg.Hosts.OverrideRegion(getBucketRegion(bucket))
+}
+
+func getBucketRegion(bucket string) *storage.Region {
+	if region := cfg.GetRegion(); region != nil {
+		return region
+	}
+
+	bucketRegionLock.Lock()
+	if r, ok := bucketRegions[bucket]; ok && time.Now().Before(r.deadline) {
+		bucketRegionLock.Unlock()
+		return r.region
+	}
+	if query, ok := bucketRegionQueries[bucket]; ok {
+		bucketRegionLock.Unlock()
+		<-query.done
+		return query.region
+	}
+	query := &bucketRegionQuery{done: make(chan struct{})}
+	bucketRegionQueries[bucket] = query
+	bucketRegionLock.Unlock()
+
+	// 查询在锁外进行，不同空间的查询互不阻塞
+	ttl := time.Duration(cfg.RegionCacheTTL.Value()) * time.Second
+	region, err := bucketRegionQuerier(bucket)
+	if err != nil {
+		region = nil
+		if defaultRegion, ok := storage.GetRegionByID(storage.RegionID(cfg.DefaultRegion.Value())); ok {
+			log.WarningF("query region of bucket:%s error:%v, use default region:%s", bucket, err, cfg.DefaultRegion.Value())
+			region = &defaultRegion
+		} else {
+			log.WarningF("query region of bucket:%s error:%v", bucket, err)
+		}
+		if ttl > bucketRegionFallbackTTL {
+			ttl = bucketRegionFallbackTTL
+		}
+	} else {
+		log.DebugF("region of bucket:%s, rs:%s", bucket, region.RsHost)
+	}
+
+	bucketRegionLock.Lock()
+	if region != nil {
+		bucketRegions[bucket] = &bucketRegion{
+			region:   region,
+			deadline: time.Now().Add(ttl),
+		}
+	}
+	delete(bucketRegionQueries, bucket)
+	bucketRegionLock.Unlock()
+
+	query.region = region
+	close(query.done)
+	return region
+}
+
+func queryBucketRegion(bucket string) (*storage.Region, error) {
+	acc, err := GetAccount()
+	if err != nil {
+		return nil, err
+	}
+	var ucHosts []string
+	if cfg.Hosts != nil {
+		ucHosts = cfg.Hosts.UC
+	}
+	return storage.GetRegionWithOptions(acc.AccessKey, bucket, storage.UCApiOptions{
+		UseHttps: cfg.IsUseHttps(),
+		Hosts:    ucHosts,
+	})
+}
@@ -0,0 +1,105 @@
+package workspace
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+func TestGetBucketRegion(t *testing.T) {
+	oldCfg, oldAccount := cfg, currentAccount
+	defer func() {
+		cfg, currentAccount = oldCfg, oldAccount
+		bucketRegions = make(map[string]*bucketRegion)
+		bucketRegionQuerier = queryBucketRegion
+	}()
+
+	z1, _ := storage.GetRegionByID(storage.RegionID("z1"))
+	z2, _ := storage.GetRegionByID(storage.RegionID("z2"))
+
+	// 指定的区域优先
+	cfg = &config.Config{Hosts: &config.Hosts{}, Region: data.NewString("z1")}
+	if region := GetBucketRegion("bucket"); region == nil || region.RsHost != z1.RsHost {
+		t.Fatal("region should be z1, but:", region)
+	}
+
+	// 查询失败时使用默认区域，默认区域只短暂缓存
+	currentAccount = nil
+	cfg = &config.Config{Hosts: &config.Hosts{}, DefaultRegion: data.NewString("z2"), RegionCacheTTL: data.NewInt(3600)}
+	if region := GetBucketRegion("bucket"); region == nil || region.RsHost != z2.RsHost {
+		t.Fatal("region should fall back to z2, but:", region)
+	}
+	if r := bucketRegions["bucket"]; r == nil || time.Until(r.deadline) > bucketRegionFallbackTTL {
+		t.Fatal("default region should be cached for a short time, but:", r)
+	}
+
+	// 查询成功时按空间缓存
+	bucketRegions = make(map[string]*bucketRegion)
+	bucketRegionQuerier = func(bucket string) (*storage.Region, error) {
+		return &z1, nil
+	}
+	if region := GetBucketRegion("bucket"); region == nil || region.RsHost != z1.RsHost {
+		t.Fatal("region should be z1, but:", region)
+	}
+	bucketRegionQuerier = func(bucket string) (*storage.Region, error) {
+		return nil, errors.New("query error")
+	}
+	if region := GetBucketRegion("bucket"); region == nil || region.RsHost != z1.RsHost {
+		t.Fatal("region should be cached, but:", region)
+	}
+
+	// 缓存过期后重新获取
+	cfg.RegionCacheTTL = data.NewInt(0)
+	bucketRegions = make(map[string]*bucketRegion)
+	_ = GetBucketRegion("bucket")
+	if region := GetBucketRegion("bucket"); region == nil || region.RsHost != z2.RsHost {
+		t.Fatal("region should be refreshed after ttl, but:", region)
+	}
+}
+
+func TestGetBucketRegionConcurrently(t *testing.T) {
+	oldCfg := cfg
+	defer func() {
+		cfg = oldCfg
+		bucketRegions = make(map[string]*bucketRegion)
+		bucketRegionQuerier = queryBucketRegion
+	}()
+
+	z1, _ := storage.GetRegionByID(storage.RegionID("z1"))
+	cfg = &config.Config{Hosts: &config.Hosts{}, RegionCacheTTL: data.NewInt(3600)}
+	bucketRegions = make(map[string]*bucketRegion)
+
+	// 同一空间同时只查询一次
+	var queryCount int32
+	release := make(chan struct{})
+	bucketRegionQuerier = func(bucket string) (*storage.Region, error) {
+		atomic.AddInt32(&queryCount, 1)
+		<-release
+		return &z1, nil
+	}
+
+	wait := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			if region := GetBucketRegion("bucket"); region == nil || region.RsHost != z1.RsHost {
+				t.Error("region should be z1, but:", region)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wait.Wait()
+
+	if queryCount != 1 {
+		t.Fatal("region of the same bucket should be queried once, but:", queryCount)
+	}
+}
@@ -3,12 +3,14 @@ package workspace
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
 	"github.com/qiniu/go-sdk/v7/storage"
 	"github.com/qiniu/go-sdk/v7/storagev2/http_client"
 	"github.com/qiniu/go-sdk/v7/storagev2/region"
 	"github.com/qiniu/qshell/v2/iqshell/common/account"
+	"github.com/qiniu/qshell/v2/iqshell/common/client"
 	"github.com/qiniu/qshell/v2/iqshell/common/config"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
@@ -51,8 +53,15 @@ func GetLogConfig() *config.LogSetting {
 	return cfg.Log
 }
 
+func GetMetricsConfig() *config.Metrics {
+	if cfg == nil || !cfg.Metrics.Enable() {
+		return nil
+	}
+	return cfg.Metrics
+}
+
 func GetStorageConfig() *storage.Config {
-	r := cfg.GetRegion()
+	r := cfg.Hosts.OverrideRegion(cfg.GetRegion())
 	ucHost := cfg.Hosts.GetOneUc()
 	if len(ucHost) > 0 {
 		log.DebugF("ucHost: %s", ucHost)
@@ -64,13 +73,20 @@ func GetStorageConfig() *storage.Config {
 		Region:        r,
 		Zone:          r,
 		CentralRsHost: cfg.Hosts.GetOneRs(),
+		RsHost:        cfg.Hosts.GetOneRs(),
+		RsfHost:       cfg.Hosts.GetOneRsf(),
+		ApiHost:       cfg.Hosts.GetOneApi(),
+		IoHost:        cfg.Hosts.GetOneIo(),
+		UpHost:        cfg.Hosts.GetOneUp(),
 	}
 }
 
 func GetHttpClientOptions() *http_client.Options {
 	var options http_client.Options
 	options.UseInsecureProtocol = !cfg.IsUseHttps()
-	if region := cfg.GetRegion(); region != nil {
+	// 与 qshell 的其他请求使用相同的 client，代理、限速及临时凭证的 session token 等配置同样生效
+	options.BasicHTTPClient = client.DefaultStorageClient().Client
+	if region := cfg.Hosts.OverrideRegion(cfg.GetRegion()); region != nil {
 		options.Regions = region
 	}
 	ucHost := cfg.Hosts.GetOneUc()
@@ -81,10 +97,30 @@ func GetHttpClientOptions() *http_client.Options {
 	return &options
 }
 
+// GetAccount 获取当前账户，SecretKey 使用口令加密时在首次获取时解密；临时凭证过期时返回错误
 func GetAccount() (account.Account, *data.CodeError) {
 	if currentAccount == nil {
 		return account.Account{}, data.NewEmptyError().AppendDesc("can't get current user")
 	}
+	// 凭证提供方的凭证过期前会重新获取
+	if currentAccount.Source == account.SourceProvider {
+		acc, err := account.GetAccount()
+		if err != nil {
+			return account.Account{}, err
+		}
+		// 重新获取的凭证可能更新了 session token
+		client.SetSessionToken(acc.SessionToken)
+		return acc, nil
+	}
+	if err := currentAccount.CheckExpiration(); err != nil {
+		return account.Account{}, err
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if err := currentAccount.Unlock(); err != nil {
+		return account.Account{}, err
+	}
 	return *currentAccount, nil
 }
 
@@ -121,6 +157,22 @@ func GetContext() context.Context {
 	return cancelCtx
 }
 
+// GetOverallTimeout 单个文件上传、下载、抓取的总超时时间，为 0 表示不超时
+func GetOverallTimeout() time.Duration {
+	if cfg == nil {
+		return 0
+	}
+	return time.Duration(cfg.OverallTimeout.Value()) * time.Second
+}
+
+// NewTransferContext 单个文件上传、下载、抓取使用的 context，超过 overall timeout 或命令被中断时取消
+func NewTransferContext() (context.Context, context.CancelFunc) {
+	if timeout := GetOverallTimeout(); timeout > 0 {
+		return context.WithTimeout(GetContext(), timeout)
+	}
+	return context.WithCancel(GetContext())
+}
+
 func Cancel() {
 	if cancelFunc != nil {
 		cancelFunc()
@@ -18,19 +18,43 @@ import (
 )
 
 type Config struct {
-	Document       bool                        // 是否展示 document
-	Silence        bool                        // 开启命令行的静默模式，只输出 Error 和 Warning
-	DebugEnable    bool                        // 开启命令行的调试模式
-	DDebugEnable   bool                        // go SDK client 和命令行开启调试模式
-	ConfigFilePath string                      // 配置文件路径，用户可以指定配置文件
-	Local          bool                        // 是否使用当前文件夹作为工作区
-	StdoutColorful bool                        // 控制台输出是否多彩
-	JobPathBuilder func(cmdPath string) string // job 路径生成器
-	CmdCfg         config.Config
+	Document           bool                        // 是否展示 document
+	Silence            bool                        // 开启命令行的静默模式，只输出 Error 和 Warning
+	DebugEnable        bool                        // 开启命令行的调试模式
+	DDebugEnable       bool                        // go SDK client 和命令行开启调试模式
+	ConfigFilePath     string                      // 配置文件路径，用户可以指定配置文件
+	Local              bool                        // 是否使用当前文件夹作为工作区
+	StdoutColorful     bool                        // 控制台输出是否多彩
+	OutputFormat       string                      // 输出格式，json: 批量操作的结果以 JSON Lines 格式输出到 stdout，日志输出到 stderr
+	NoSummary          bool                        // 批量操作结束时不输出汇总信息
+	Profile            string                      // 本次命令使用的账户名称，不修改当前账户
+	Region             string                      // 指定空间所在的区域 Id，不再自动查询
+	RsHost             string                      // 指定 rs 服务地址
+	RsfHost            string                      // 指定 rsf 服务地址
+	ApiHost            string                      // 指定 api 服务地址
+	UcHost             string                      // 指定 uc 服务地址
+	HostsFile          string                      // 服务地址文件，格式同配置文件中的 hosts，优先级低于 --rs-host 等选项
+	Proxy              string                      // 代理地址，优先级高于配置文件及环境变量
+	CAFile             string                      // 自定义根证书文件
+	InsecureSkipVerify bool                        // 不校验服务端证书，仅用于测试
+	ConnectTimeout     int                         // 建立连接的超时时间，单位：秒
+	ReadTimeout        int                         // 等待响应及读取响应数据的超时时间，单位：秒
+	OverallTimeout     int                         // 单个文件上传、下载、抓取的总超时时间，单位：秒
+	LogLevel           string                      // 日志级别：debug、info、warn、error，优先级高于 --debug 及 --silence
+	LogFile            string                      // 日志同时输出到此文件
+	LogJson            bool                        // 日志文件每行为一个 JSON 对象
+	LogMaxSize         string                      // 日志文件超过此大小时轮转，如：100m
+	MetricsEndpoint    string                      // 指标上报地址，statsd://host:port 或 pushgateway 地址 http(s)://host:port
+	MetricsPrefix      string                      // 指标名前缀
+	MetricsInterval    int                         // 指标上报间隔，单位：秒
+	Validate           bool                        // 仅校验批量命令的输入文件，不加载账户信息
+	JobPathBuilder     func(cmdPath string) string // job 路径生成器
+	CmdCfg             config.Config
 }
 
 type CheckAndLoadInfo struct {
 	Checker           data.Checker
+	SupportCSVOutput  bool // 命令是否支持 --format csv，不支持时指定 csv 格式会报错
 	BeforeLoadFileLog func()
 	AfterLoadFileLog  func()
 }
@@ -46,6 +70,13 @@ func CheckAndLoad(cfg *Config, info CheckAndLoadInfo) (shouldContinue bool) {
 }
 
 func load(cfg *Config, info CheckAndLoadInfo) (shouldContinue bool) {
+	if cfg.OutputFormat == data.OutputFormatCSV && !info.SupportCSVOutput {
+		_, _ = fmt.Fprintf(os.Stderr, "format %s is not supported by this command, use %s or %s\n",
+			data.OutputFormatCSV, data.OutputFormatText, data.OutputFormatJson)
+		data.SetCmdStatusError()
+		return false
+	}
+
 	if !loadBase(cfg) {
 		data.SetCmdStatusError()
 		return false
@@ -109,12 +140,32 @@ func loadBase(cfg *Config) (shouldContinue bool) {
 	} else if cfg.Silence {
 		logLevel = log.LevelWarning
 	}
+	if len(cfg.LogLevel) > 0 {
+		level, ok := config.ParseLogLevel(cfg.LogLevel)
+		if !ok {
+			_, _ = fmt.Fprintf(os.Stderr, "log level should be %s, %s, %s or %s, but is:%s\n",
+				config.DebugKey, config.InfoKey, config.WarnKey, config.ErrorKey, cfg.LogLevel)
+			return false
+		}
+		logLevel = level
+	}
+
+	// 输出格式
+	if len(cfg.OutputFormat) > 0 && cfg.OutputFormat != data.OutputFormatText &&
+		cfg.OutputFormat != data.OutputFormatJson && cfg.OutputFormat != data.OutputFormatCSV {
+		_, _ = fmt.Fprintf(os.Stderr, "format should be %s, %s or %s, but is:%s\n",
+			data.OutputFormatText, data.OutputFormatJson, data.OutputFormatCSV, cfg.OutputFormat)
+		return false
+	}
+	data.SetOutputFormat(cfg.OutputFormat)
+	data.SetShowSummary(!cfg.NoSummary)
 
 	// 加载本地输出
 	_ = log.Prepare()
 	_ = log.LoadConsole(log.Config{
 		Level:          logLevel,
 		StdOutColorful: cfg.StdoutColorful,
+		StdErrOnly:     data.IsOutputFormatJson() || data.IsOutputFormatCSV(),
 	})
 	return true
 }
@@ -131,12 +182,44 @@ func loadWorkspace(cfg *Config) (shouldContinue bool) {
 		workspacePath = dir
 	}
 
+	if len(cfg.Region) > 0 {
+		cfg.CmdCfg.Region = data.NewString(cfg.Region)
+	}
+	if len(cfg.Proxy) > 0 {
+		cfg.CmdCfg.Proxy = data.NewString(cfg.Proxy)
+	}
+	if len(cfg.CAFile) > 0 {
+		cfg.CmdCfg.CAFile = data.NewString(cfg.CAFile)
+	}
+	if cfg.InsecureSkipVerify {
+		cfg.CmdCfg.InsecureSkipVerify = data.NewBool(true)
+	}
+	if cfg.ConnectTimeout != 0 {
+		cfg.CmdCfg.ConnectTimeout = data.NewInt(cfg.ConnectTimeout)
+	}
+	if cfg.ReadTimeout != 0 {
+		cfg.CmdCfg.ReadTimeout = data.NewInt(cfg.ReadTimeout)
+	}
+	if cfg.OverallTimeout != 0 {
+		cfg.CmdCfg.OverallTimeout = data.NewInt(cfg.OverallTimeout)
+	}
+
+	loadLogSetting(cfg)
+	loadMetricsSetting(cfg)
+
+	if err := loadHosts(cfg); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "load hosts error: %v\n", err)
+		return false
+	}
+
 	// 加载工作区
 	if err := workspace.Load(workspace.LoadInfo{
 		CmdConfig:      &cfg.CmdCfg,
 		WorkspacePath:  workspacePath,
 		UserConfigPath: cfg.ConfigFilePath,
 		JobPathBuilder: cfg.JobPathBuilder,
+		Profile:        cfg.Profile,
+		SkipAccount:    cfg.Validate,
 	}); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "load workspace error: %v\n", err)
 		return false
@@ -144,6 +227,82 @@ func loadWorkspace(cfg *Config) (shouldContinue bool) {
 	return true
 }
 
+// loadLogSetting 将 --log-file 等选项合并至命令的日志配置，日志文件的级别同控制台
+func loadMetricsSetting(cfg *Config) {
+	if len(cfg.MetricsEndpoint) == 0 && len(cfg.MetricsPrefix) == 0 && cfg.MetricsInterval == 0 {
+		return
+	}
+
+	if cfg.CmdCfg.Metrics == nil {
+		cfg.CmdCfg.Metrics = &config.Metrics{}
+	}
+	m := cfg.CmdCfg.Metrics
+	if len(cfg.MetricsEndpoint) > 0 {
+		m.Endpoint = data.NewString(cfg.MetricsEndpoint)
+	}
+	if len(cfg.MetricsPrefix) > 0 {
+		m.Prefix = data.NewString(cfg.MetricsPrefix)
+	}
+	if cfg.MetricsInterval != 0 {
+		m.Interval = data.NewInt(cfg.MetricsInterval)
+	}
+}
+
+func loadLogSetting(cfg *Config) {
+	if cfg.CmdCfg.Log == nil {
+		cfg.CmdCfg.Log = &config.LogSetting{}
+	}
+
+	ls := cfg.CmdCfg.Log
+	if len(cfg.LogFile) > 0 {
+		ls.LogFile = data.NewString(cfg.LogFile)
+		if len(cfg.LogLevel) > 0 {
+			ls.LogLevel = data.NewString(cfg.LogLevel)
+		} else if cfg.DebugEnable || cfg.DDebugEnable {
+			ls.LogLevel = data.NewString(config.DebugKey)
+		} else {
+			ls.LogLevel = data.NewString(config.InfoKey)
+		}
+	}
+	if cfg.LogJson {
+		ls.LogJson = data.NewBool(true)
+	}
+	if len(cfg.LogMaxSize) > 0 {
+		ls.LogMaxSize = data.NewString(cfg.LogMaxSize)
+	}
+}
+
+// loadHosts 将 --rs-host 等选项及 --hosts-file 中的服务地址合并至命令配置，优先级高于配置文件
+func loadHosts(cfg *Config) *data.CodeError {
+	hosts := &config.Hosts{}
+	if len(cfg.RsHost) > 0 {
+		hosts.Rs = []string{cfg.RsHost}
+	}
+	if len(cfg.RsfHost) > 0 {
+		hosts.Rsf = []string{cfg.RsfHost}
+	}
+	if len(cfg.ApiHost) > 0 {
+		hosts.Api = []string{cfg.ApiHost}
+	}
+	if len(cfg.UcHost) > 0 {
+		hosts.UC = []string{cfg.UcHost}
+	}
+	if err := hosts.Check(); err != nil {
+		return err
+	}
+
+	hostsCfg := &config.Config{Hosts: hosts}
+	if len(cfg.HostsFile) > 0 {
+		fileHosts, err := config.LoadHostsFile(cfg.HostsFile)
+		if err != nil {
+			return err
+		}
+		hostsCfg.Merge(&config.Config{Hosts: fileHosts})
+	}
+	cfg.CmdCfg.Merge(hostsCfg)
+	return nil
+}
+
 func loadFileLog(cfg *Config) (shouldContinue bool) {
 	// 配置日志文件输出
 	if ls := workspace.GetLogConfig(); ls != nil && ls.Enable() {
@@ -155,14 +314,21 @@ func loadFileLog(cfg *Config) (shouldContinue bool) {
 			_, _ = fmt.Fprintf(os.Stderr, "load file log, create log file error: %v\n", err)
 			return false
 		}
-		_ = log.LoadFileLogger(log.Config{
+		// log_max_size 在加载工作区时已检查
+		maxSize, _ := ls.GetLogMaxSize()
+		if lErr := log.LoadFileLogger(log.Config{
 			Filename:       ls.LogFile.Value(),
 			Level:          ls.GetLogLevel(),
 			Daily:          true,
 			StdOutColorful: false,
 			EnableStdout:   ls.IsLogStdout(),
 			MaxDays:        ls.LogRotate.Value(),
-		})
+			MaxSize:        maxSize,
+			Json:           ls.IsLogJson(),
+		}); lErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "load file log error: %v\n", lErr)
+			return false
+		}
 		log.AlertF("Writing log to file:%s", ls.LogFile.Value())
 	} else {
 		log.DebugF("log file not enable, log level:%s", workspace.GetConfig().Log.LogLevel.Value())
@@ -4,6 +4,7 @@ import (
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
 	"github.com/qiniu/go-sdk/v7/storage"
 
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
 	"github.com/qiniu/qshell/v2/iqshell/common/client"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
@@ -16,11 +17,15 @@ func GetBucketManager() (manager *storage.BucketManager, err *data.CodeError) {
 		return
 	}
 
+	return GetBucketManagerWithAccount(acc), nil
+}
+
+// GetBucketManagerWithAccount 使用指定账户的 BucketManager，用于同时操作多个账户的空间
+func GetBucketManagerWithAccount(acc account.Account) *storage.BucketManager {
 	mac := qbox.NewMac(acc.AccessKey, acc.SecretKey)
 	cfg := workspace.GetStorageConfig()
 	c := client.DefaultStorageClient()
-	manager = storage.NewBucketManagerEx(mac, cfg, &c)
-	return
+	return storage.NewBucketManagerEx(mac, cfg, &c)
 }
 
 type GetBucketApiInfo struct {
@@ -0,0 +1,132 @@
+package bucket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/qiniu/go-sdk/v7/storagev2/apis"
+	"github.com/qiniu/go-sdk/v7/storagev2/apis/set_bucket_cors_rules"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// CorsRulesMaxCount 一个空间最多可以设置的跨域规则数
+const CorsRulesMaxCount = 10
+
+var corsMethods = []string{"GET", "PUT", "POST", "DELETE", "HEAD", "OPTIONS"}
+
+// CorsRule 空间的跨域规则，字段与七牛接口一致
+type CorsRule struct {
+	AllowedOrigin []string `json:"allowed_origin"`
+	AllowedMethod []string `json:"allowed_method"`
+	AllowedHeader []string `json:"allowed_header,omitempty"`
+	ExposedHeader []string `json:"exposed_header,omitempty"`
+	MaxAge        int64    `json:"max_age,omitempty"` // 单位：秒
+}
+
+func (r *CorsRule) check() error {
+	if len(r.AllowedOrigin) == 0 {
+		return fmt.Errorf("allowed_origin can't be empty")
+	}
+	for _, origin := range r.AllowedOrigin {
+		if len(strings.TrimSpace(origin)) == 0 {
+			return fmt.Errorf("allowed_origin can't contain empty origin")
+		}
+	}
+	if len(r.AllowedMethod) == 0 {
+		return fmt.Errorf("allowed_method can't be empty")
+	}
+	for _, method := range r.AllowedMethod {
+		if !isCorsMethodValid(method) {
+			return fmt.Errorf("invalid allowed_method: %s, should be one of %s", method, strings.Join(corsMethods, ", "))
+		}
+	}
+	if r.MaxAge < 0 {
+		return fmt.Errorf("max_age can't be less than 0")
+	}
+	return nil
+}
+
+func isCorsMethodValid(method string) bool {
+	for _, m := range corsMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCorsRules 解析 JSON 数组格式的跨域规则，不允许未知的字段
+func ParseCorsRules(content []byte) ([]*CorsRule, *data.CodeError) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	rules := make([]*CorsRule, 0)
+	if err := decoder.Decode(&rules); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("parse cors rules error:%v", err)
+	}
+	if len(rules) > CorsRulesMaxCount {
+		return nil, data.NewEmptyError().AppendDescF("at most %d cors rules can be set, but got %d", CorsRulesMaxCount, len(rules))
+	}
+	for i, rule := range rules {
+		if rule == nil {
+			return nil, data.NewEmptyError().AppendDescF("cors rule[%d] can't be null", i)
+		}
+		if err := rule.check(); err != nil {
+			return nil, data.NewEmptyError().AppendDescF("cors rule[%d] invalid, %v", i, err)
+		}
+	}
+	return rules, nil
+}
+
+// CorsRules 获取空间的跨域规则
+func CorsRules(bucket string) ([]*CorsRule, *data.CodeError) {
+	storageClient, err := GetStorageV2()
+	if err != nil {
+		return nil, err
+	}
+
+	response, gErr := storageClient.GetBucketCORSRules(workspace.GetContext(), &apis.GetBucketCORSRulesRequest{
+		Bucket: bucket,
+	}, nil)
+	if gErr != nil {
+		return nil, data.ConvertError(gErr)
+	}
+	rules := make([]*CorsRule, 0, len(response.CORSRules))
+	for _, rule := range response.CORSRules {
+		rules = append(rules, &CorsRule{
+			AllowedOrigin: rule.AllowedOrigin,
+			AllowedMethod: rule.AllowedMethod,
+			AllowedHeader: rule.AllowedHeader,
+			ExposedHeader: rule.ExposedHeader,
+			MaxAge:        rule.MaxAge,
+		})
+	}
+	return rules, nil
+}
+
+// SetCorsRules 设置空间的跨域规则，会覆盖空间已有的规则
+func SetCorsRules(bucket string, rules []*CorsRule) *data.CodeError {
+	storageClient, err := GetStorageV2()
+	if err != nil {
+		return err
+	}
+
+	corsRules := make(set_bucket_cors_rules.CORSRules, 0, len(rules))
+	for _, rule := range rules {
+		corsRules = append(corsRules, set_bucket_cors_rules.CORSRule{
+			AllowedOrigin: rule.AllowedOrigin,
+			AllowedMethod: rule.AllowedMethod,
+			AllowedHeader: rule.AllowedHeader,
+			ExposedHeader: rule.ExposedHeader,
+			MaxAge:        rule.MaxAge,
+		})
+	}
+	_, sErr := storageClient.SetBucketCORSRules(workspace.GetContext(), &apis.SetBucketCORSRulesRequest{
+		Bucket:    bucket,
+		CORSRules: corsRules,
+	}, nil)
+	return data.ConvertError(sErr)
+}
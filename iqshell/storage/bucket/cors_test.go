@@ -0,0 +1,32 @@
+package bucket
+
+import "testing"
+
+func TestParseCorsRules(t *testing.T) {
+	rules, err := ParseCorsRules([]byte(`[{"allowed_origin":["https://a.com"],"allowed_method":["GET","head"],"allowed_header":["*"],"max_age":3600}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].AllowedOrigin[0] != "https://a.com" || rules[0].MaxAge != 3600 {
+		t.Fatalf("parse cors rules error, rules:%+v", rules[0])
+	}
+
+	if rules, err = ParseCorsRules([]byte(`[]`)); err != nil || len(rules) != 0 {
+		t.Fatalf("empty rules should be valid, err:%v", err)
+	}
+
+	for _, content := range []string{
+		`{"allowed_origin":["*"],"allowed_method":["GET"]}`,
+		`[{"allowed_origin":["*"],"allowed_method":["GET"],"allow_header":["*"]}]`,
+		`[{"allowed_method":["GET"]}]`,
+		`[{"allowed_origin":["*"]}]`,
+		`[{"allowed_origin":["*"],"allowed_method":["FETCH"]}]`,
+		`[{"allowed_origin":["*"],"allowed_method":["GET"],"max_age":-1}]`,
+		`[null]`,
+		`[{"allowed_origin":"*","allowed_method":["GET"]}]`,
+	} {
+		if _, err = ParseCorsRules([]byte(content)); err == nil {
+			t.Fatalf("invalid rules should fail, content:%s", content)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package bucket
+
+import (
+	"strings"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// EventTypes 空间事件通知支持的事件
+var EventTypes = []string{"put", "mkfile", "delete", "copy", "move", "append", "disable", "enable", "deleteMarkerCreate"}
+
+// EventRule 空间的事件通知规则，匹配的文件发生指定事件时回调 CallbackUrls
+type EventRule struct {
+	Name         string   `json:"name"`
+	Prefix       string   `json:"prefix"`
+	Suffix       string   `json:"suffix"`
+	Events       []string `json:"events"`
+	CallbackUrls []string `json:"callback_urls"`
+	Host         string   `json:"host,omitempty"` // 回调请求的 Host
+}
+
+func (r *EventRule) Check() *data.CodeError {
+	if len(r.Name) == 0 {
+		return alert.CannotEmptyError("rule name", "")
+	}
+	if len(r.Events) == 0 {
+		return alert.CannotEmptyError("event", "")
+	}
+	for _, event := range r.Events {
+		if !isEventTypeValid(event) {
+			return alert.Error("invalid event: "+event, "event should be one of "+strings.Join(EventTypes, ", "))
+		}
+	}
+	if len(r.CallbackUrls) == 0 {
+		return alert.CannotEmptyError("callback url", "")
+	}
+	for _, u := range r.CallbackUrls {
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			return alert.Error("invalid callback url: "+u, "callback url should start with http:// or https://")
+		}
+	}
+	return nil
+}
+
+func isEventTypeValid(event string) bool {
+	for _, t := range EventTypes {
+		if t == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *EventRule) toStorageRule() *storage.BucketEventRule {
+	return &storage.BucketEventRule{
+		Name:        r.Name,
+		Prefix:      r.Prefix,
+		Suffix:      r.Suffix,
+		Event:       r.Events,
+		CallbackURL: r.CallbackUrls,
+		Host:        r.Host,
+	}
+}
+
+// EventRules 获取空间的事件通知规则
+func EventRules(bucket string) ([]*EventRule, *data.CodeError) {
+	bucketManager, err := GetBucketManager()
+	if err != nil {
+		return nil, err
+	}
+
+	rules, gErr := bucketManager.GetBucketEvent(bucket)
+	if gErr != nil {
+		return nil, data.ConvertError(gErr)
+	}
+	result := make([]*EventRule, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, &EventRule{
+			Name:         rule.Name,
+			Prefix:       rule.Prefix,
+			Suffix:       rule.Suffix,
+			Events:       rule.Event,
+			CallbackUrls: rule.CallbackURL,
+			Host:         rule.Host,
+		})
+	}
+	return result, nil
+}
+
+// PutEventRule 添加空间的事件通知规则，同名的规则已存在时更新规则；返回是否为更新
+func PutEventRule(bucket string, rule *EventRule) (updated bool, err *data.CodeError) {
+	if err = rule.Check(); err != nil {
+		return false, err
+	}
+
+	rules, err := EventRules(bucket)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range rules {
+		if r.Name == rule.Name {
+			updated = true
+			break
+		}
+	}
+
+	bucketManager, err := GetBucketManager()
+	if err != nil {
+		return false, err
+	}
+	if updated {
+		return true, data.ConvertError(bucketManager.UpdateBucketEnvent(bucket, rule.toStorageRule()))
+	}
+	return false, data.ConvertError(bucketManager.AddBucketEvent(bucket, rule.toStorageRule()))
+}
+
+// DeleteEventRule 删除空间的事件通知规则
+func DeleteEventRule(bucket string, name string) *data.CodeError {
+	bucketManager, err := GetBucketManager()
+	if err != nil {
+		return err
+	}
+	return data.ConvertError(bucketManager.DelBucketEvent(bucket, name))
+}
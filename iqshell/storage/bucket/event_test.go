@@ -0,0 +1,22 @@
+package bucket
+
+import "testing"
+
+func TestEventRuleCheck(t *testing.T) {
+	for _, c := range []struct {
+		rule  EventRule
+		valid bool
+	}{
+		{rule: EventRule{Name: "r", Events: []string{"put", "delete"}, CallbackUrls: []string{"https://a.com/cb"}}, valid: true},
+		{rule: EventRule{Name: "r", Events: []string{"deleteMarkerCreate"}, CallbackUrls: []string{"http://a.com"}}, valid: true},
+		{rule: EventRule{Name: "r", Events: []string{"upload"}, CallbackUrls: []string{"https://a.com/cb"}}, valid: false},
+		{rule: EventRule{Name: "r", CallbackUrls: []string{"https://a.com/cb"}}, valid: false},
+		{rule: EventRule{Name: "r", Events: []string{"put"}}, valid: false},
+		{rule: EventRule{Name: "r", Events: []string{"put"}, CallbackUrls: []string{"a.com/cb"}}, valid: false},
+		{rule: EventRule{Events: []string{"put"}, CallbackUrls: []string{"https://a.com/cb"}}, valid: false},
+	} {
+		if err := c.rule.Check(); (err == nil) != c.valid {
+			t.Fatalf("check rule:%+v error, err:%v expected valid:%v", c.rule, err, c.valid)
+		}
+	}
+}
@@ -26,7 +26,24 @@ type ApiInfo struct {
 	V1Limit    int
 }
 
-type Item storage.ListItem
+// Item 同 storage.ListItem，增加了归档存储的解冻信息
+type Item struct {
+	Key      string `json:"key"`
+	PutTime  int64  `json:"putTime"`
+	Hash     string `json:"hash"`
+	Fsize    int64  `json:"fsize"`
+	MimeType string `json:"mimeType"`
+	EndUser  string `json:"endUser"`
+	Type     int    `json:"type"`
+	Status   int    `json:"status"`
+	Md5      string `json:"md5"`
+	Parts    []uint `json:"parts"`
+
+	// 归档存储文件的解冻状态，0：未解冻，1：解冻中，2：已解冻；非归档存储的文件无此字段
+	RestoreStatus int `json:"restoreStatus"`
+	// 解冻的过期时间，Unix 时间戳，单位：秒；服务端未返回时为 0
+	RestoreExpiry int64 `json:"restoreExpiry"`
+}
 
 func (l *Item) IsNull() bool {
 	if l == nil {
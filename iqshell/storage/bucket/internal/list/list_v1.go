@@ -2,30 +2,65 @@ package list
 
 import (
 	"context"
-	"github.com/qiniu/go-sdk/v7/storage"
-	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
+
+	"github.com/qiniu/go-sdk/v7/auth"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
 )
 
+// listBucketV1Ret 同 storage.ListFilesRet，Item 中包含 storage.ListItem 未解析的字段
+type listBucketV1Ret struct {
+	Marker         string   `json:"marker"`
+	Items          []Item   `json:"items"`
+	CommonPrefixes []string `json:"commonPrefixes"`
+}
+
 func listBucketByV1(ctx context.Context, info ApiInfo, handler Handler) (hasMore bool, err *data.CodeError) {
-	rets, hasMore, e := info.Manager.ListFilesWithContext(ctx, info.Bucket,
-		storage.ListInputOptionsMarker(info.Marker),
-		storage.ListInputOptionsPrefix(info.Prefix),
-		storage.ListInputOptionsLimit(info.V1Limit),
-		storage.ListInputOptionsDelimiter(info.Delimiter))
-	if e == nil && rets == nil {
-		return hasMore, data.NewError(0, "v1 meet empty body when list not completed")
+	if info.V1Limit <= 0 || info.V1Limit > 1000 {
+		return false, data.NewEmptyError().AppendDesc("invalid list limit, only allow [1, 1000]")
 	}
 
-	if e != nil {
-		return hasMore, data.ConvertError(e)
+	ctx = auth.WithCredentialsType(ctx, info.Manager.Mac, auth.TokenQiniu)
+	reqHost, reqErr := info.Manager.RsfReqHost(info.Bucket)
+	if reqErr != nil {
+		return false, data.ConvertError(reqErr)
 	}
 
+	var rets *listBucketV1Ret
+	reqURL := fmt.Sprintf("%s%s", reqHost, createListBucketV1Uri(info))
+	if e := info.Manager.Client.CredentialedCall(ctx, info.Manager.Mac, auth.TokenQiniu, &rets, "POST", reqURL, nil); e != nil {
+		return false, data.ConvertError(e)
+	}
+	if rets == nil {
+		return false, data.NewError(0, "v1 meet empty body when list not completed")
+	}
+
+	hasMore = len(rets.Marker) > 0
 	dir := strings.Join(rets.CommonPrefixes, info.Delimiter)
 	for _, item := range rets.Items {
-		if handler(rets.Marker, dir, Item(item)) {
+		if handler(rets.Marker, dir, item) {
 			break
 		}
 	}
 	return hasMore, nil
 }
+
+func createListBucketV1Uri(info ApiInfo) string {
+	query := make(url.Values)
+	query.Add("bucket", info.Bucket)
+	if info.Prefix != "" {
+		query.Add("prefix", info.Prefix)
+	}
+	if info.Delimiter != "" {
+		query.Add("delimiter", info.Delimiter)
+	}
+	if info.Marker != "" {
+		query.Add("marker", info.Marker)
+	}
+	query.Add("limit", strconv.Itoa(info.V1Limit))
+	query.Add("needparts", "false")
+	return fmt.Sprintf("/list?%s", query.Encode())
+}
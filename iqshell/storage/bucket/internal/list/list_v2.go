@@ -37,12 +37,12 @@ func listBucketByV2(ctx context.Context, info ApiInfo, handler Handler) (hasMore
 	defer resp.Body.Close()
 
 	dec := json.NewDecoder(resp.Body)
-	var ret listBucketV2Ret
 	for {
+		var ret listBucketV2Ret
 		dErr := dec.Decode(&ret)
 		if dErr != nil {
 			if dErr != io.EOF {
-				return false, data.NewEmptyError().AppendDescF("decode error: %v", err)
+				return false, data.NewEmptyError().AppendDescF("decode error: %v", dErr)
 			}
 			break
 		}
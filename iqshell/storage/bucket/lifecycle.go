@@ -0,0 +1,116 @@
+package bucket
+
+import (
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// LifecycleRule 空间的生命周期规则，天数为 0 表示不执行对应的操作
+type LifecycleRule struct {
+	Name                   string `json:"name"`
+	Prefix                 string `json:"prefix"`
+	ToIAAfterDays          int    `json:"to_ia_after_days"`
+	ToArchiveIRAfterDays   int    `json:"to_archive_ir_after_days"`
+	ToArchiveAfterDays     int    `json:"to_archive_after_days"`
+	ToDeepArchiveAfterDays int    `json:"to_deep_archive_after_days"`
+	DeleteAfterDays        int    `json:"delete_after_days"`
+}
+
+// Check 检查规则，各阶段的天数需按 低频 < 归档直读 < 归档 < 深度归档 < 删除 递增
+func (r *LifecycleRule) Check() *data.CodeError {
+	if len(r.Name) == 0 {
+		return alert.CannotEmptyError("rule name", "")
+	}
+	for _, c := range r.Name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return alert.Error("invalid rule name: "+r.Name, "rule name consists of letters, numbers and underscores")
+		}
+	}
+
+	stages := []struct {
+		name string
+		days int
+	}{
+		{"to-ia", r.ToIAAfterDays},
+		{"to-archive-ir", r.ToArchiveIRAfterDays},
+		{"to-archive", r.ToArchiveAfterDays},
+		{"to-deep-archive", r.ToDeepArchiveAfterDays},
+		{"delete", r.DeleteAfterDays},
+	}
+	lastName, lastDays := "", 0
+	for _, stage := range stages {
+		if stage.days < 0 {
+			return alert.Error("days of "+stage.name+" can't be less than 0", "")
+		}
+		if stage.days == 0 {
+			continue
+		}
+		if lastDays > 0 && stage.days <= lastDays {
+			return data.NewEmptyError().AppendDescF("days of %s(%d) must be greater than days of %s(%d)",
+				stage.name, stage.days, lastName, lastDays)
+		}
+		lastName, lastDays = stage.name, stage.days
+	}
+	if lastDays == 0 {
+		return alert.Error("rule has no action", "at least one of to-ia, to-archive-ir, to-archive, to-deep-archive and delete should be set")
+	}
+	return nil
+}
+
+// LifecycleRules 获取空间的生命周期规则
+func LifecycleRules(bucket string) ([]*LifecycleRule, *data.CodeError) {
+	bucketManager, err := GetBucketManager()
+	if err != nil {
+		return nil, err
+	}
+
+	rules, gErr := bucketManager.GetBucketLifeCycleRule(bucket)
+	if gErr != nil {
+		return nil, data.ConvertError(gErr)
+	}
+	result := make([]*LifecycleRule, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, &LifecycleRule{
+			Name:                   rule.Name,
+			Prefix:                 rule.Prefix,
+			ToIAAfterDays:          rule.ToLineAfterDays,
+			ToArchiveIRAfterDays:   rule.ToArchiveIRAfterDays,
+			ToArchiveAfterDays:     rule.ToArchiveAfterDays,
+			ToDeepArchiveAfterDays: rule.ToDeepArchiveAfterDays,
+			DeleteAfterDays:        rule.DeleteAfterDays,
+		})
+	}
+	return result, nil
+}
+
+// AddLifecycleRule 添加空间的生命周期规则，规则名在空间内需唯一
+func AddLifecycleRule(bucket string, rule *LifecycleRule) *data.CodeError {
+	if err := rule.Check(); err != nil {
+		return err
+	}
+
+	bucketManager, err := GetBucketManager()
+	if err != nil {
+		return err
+	}
+	return data.ConvertError(bucketManager.AddBucketLifeCycleRule(bucket, &storage.BucketLifeCycleRule{
+		Name:                   rule.Name,
+		Prefix:                 rule.Prefix,
+		DeleteAfterDays:        rule.DeleteAfterDays,
+		ToLineAfterDays:        rule.ToIAAfterDays,
+		ToArchiveIRAfterDays:   rule.ToArchiveIRAfterDays,
+		ToArchiveAfterDays:     rule.ToArchiveAfterDays,
+		ToDeepArchiveAfterDays: rule.ToDeepArchiveAfterDays,
+	}))
+}
+
+// DeleteLifecycleRule 删除空间的生命周期规则
+func DeleteLifecycleRule(bucket string, name string) *data.CodeError {
+	bucketManager, err := GetBucketManager()
+	if err != nil {
+		return err
+	}
+	return data.ConvertError(bucketManager.DelBucketLifeCycleRule(bucket, name))
+}
@@ -0,0 +1,23 @@
+package bucket
+
+import "testing"
+
+func TestLifecycleRuleCheck(t *testing.T) {
+	for _, c := range []struct {
+		rule  LifecycleRule
+		valid bool
+	}{
+		{rule: LifecycleRule{Name: "logs", ToIAAfterDays: 30, ToArchiveAfterDays: 90, DeleteAfterDays: 365}, valid: true},
+		{rule: LifecycleRule{Name: "logs", DeleteAfterDays: 7}, valid: true},
+		{rule: LifecycleRule{Name: "logs", ToIAAfterDays: 90, ToArchiveAfterDays: 30}, valid: false},
+		{rule: LifecycleRule{Name: "logs", ToArchiveAfterDays: 30, DeleteAfterDays: 30}, valid: false},
+		{rule: LifecycleRule{Name: "logs", ToIAAfterDays: -1, DeleteAfterDays: 30}, valid: false},
+		{rule: LifecycleRule{Name: "logs"}, valid: false},
+		{rule: LifecycleRule{Name: "a-b", DeleteAfterDays: 7}, valid: false},
+		{rule: LifecycleRule{DeleteAfterDays: 7}, valid: false},
+	} {
+		if err := c.rule.Check(); (err == nil) != c.valid {
+			t.Fatalf("check rule:%+v error, err:%v expected valid:%v", c.rule, err, c.valid)
+		}
+	}
+}
@@ -2,10 +2,12 @@ package bucket
 
 import (
 	"bufio"
+	"encoding/csv"
 	"io"
 	"math"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -19,27 +21,31 @@ import (
 )
 
 type ListApiInfo struct {
-	Bucket             string    // 空间名	【必选】
-	Prefix             string    // 前缀
-	Marker             string    // 标记
-	Delimiter          string    //
-	StartTime          time.Time // list item 的 put time 区间的开始时间 【闭区间】
-	EndTime            time.Time // list item 的 put time 区间的终止时间 【闭区间】
-	Suffixes           []string  // list item 必须包含后缀
-	FileTypes          []int     // list item 存储类型，多个使用逗号隔开， 0:普通存储 1:低频存储 2:归档存储 3:深度归档存储 4:归档直读存储
-	MimeTypes          []string  // list item Mimetype类型，多个使用逗号隔开
-	MinFileSize        int64     // 文件最小值，单位: B
-	MaxFileSize        int64     // 文件最大值，单位: B
-	MaxRetry           int       // -1: 无限重试
-	ShowFields         []string  // 需要展示的字段  【必选】
-	ApiVersion         string    // list api 版本，v1 / v2【可选】
-	V1Limit            int       // 每次请求 size ，list v1 特有
-	OutputLimit        int       // 最大输出条数，默认：-1, 无限输出
-	OutputFieldsSep    string    // 输出信息，每行的分隔符 【必选】
-	OutputFileMaxLines int64     // 输出文件的最大行数，超过则自动创建新的文件，0：不限制输出文件的行数 【可选】
-	OutputFileMaxSize  int64     // 输出文件的最大 Size，超过则自动创建新的文件，0：不限制输出文件的大小 【可选】
-	EnableRecord       bool      // 是否开启 record 记录，开启后会记录 list 信息，下次 list 会自动指定 Marker 继续 list 【可选】
-	CacheDir           string    // 历史数据存储路径 【内部使用】
+	Bucket             string         // 空间名	【必选】
+	Prefix             string         // 前缀
+	Marker             string         // 标记
+	Delimiter          string         //
+	StartTime          time.Time      // list item 的 put time 区间的开始时间 【闭区间】
+	EndTime            time.Time      // list item 的 put time 区间的终止时间 【闭区间】
+	Suffixes           []string       // list item 必须包含后缀
+	ExcludeSuffixes    []string       // list item 不能包含后缀
+	ExcludeKeyRegex    *regexp.Regexp // list item 的 key 不能匹配此正则
+	FileTypes          []int          // list item 存储类型，多个使用逗号隔开， 0:普通存储 1:低频存储 2:归档存储 3:深度归档存储 4:归档直读存储
+	MimeTypes          []string       // list item Mimetype类型，多个使用逗号隔开
+	MinFileSize        int64          // 文件最小值，单位: B
+	MaxFileSize        int64          // 文件最大值，单位: B
+	Filter             *ListFilter    // 过滤表达式，参考 ParseListFilter
+	MaxRetry           int            // -1: 无限重试
+	ShowFields         []string       // 需要展示的字段  【必选】
+	ApiVersion         string         // list api 版本，v1 / v2【可选】
+	V1Limit            int            // 每次请求 size ，list v1 特有
+	OutputLimit        int            // 最大输出条数，默认：-1, 无限输出
+	OutputFieldsSep    string         // 输出信息，每行的分隔符 【必选】
+	OutputFileMaxLines int64          // 输出文件的最大行数，超过则自动创建新的文件，0：不限制输出文件的行数 【可选】
+	OutputFileMaxSize  int64          // 输出文件的最大 Size，超过则自动创建新的文件，0：不限制输出文件的大小 【可选】
+	EnableRecord       bool           // 是否开启 record 记录，开启后会记录 list 信息，下次 list 会自动指定 Marker 继续 list 【可选】
+	CacheDir           string         // 历史数据存储路径 【内部使用】
+	isShard            bool           // 是否为并发列举中的一个区间 【内部使用】
 }
 
 func (l *ListApiInfo) init() {
@@ -48,25 +54,28 @@ func (l *ListApiInfo) init() {
 	}
 }
 
+// ListObjectField 获取字段的标准名称，忽略大小写，支持简称（size、mime、type），不支持的字段返回空字符串
 func ListObjectField(field string) string {
-	for _, f := range listObjectFields {
-		if strings.EqualFold(field, f) {
-			return f
-		}
-	}
-	return ""
+	return listObjectField(field)
 }
 
 type ListObject = list.Item
 
-// List list 某个 bucket 所有的文件
+// List list 某个 bucket 所有的文件，返回最后一次列举的错误，列举完成或被 objectHandler 终止时返回 nil
 func List(info ListApiInfo,
 	objectHandler func(marker string, object ListObject) (shouldContinue bool, err *data.CodeError),
-	errorHandler func(marker string, err *data.CodeError)) {
+	errorHandler func(marker string, err *data.CodeError)) *data.CodeError {
+	return listBucket(info, objectHandler, errorHandler)
+}
+
+// listBucket 同 List，返回最后一次列举的错误，列举完成或被 objectHandler 终止时返回 nil
+func listBucket(info ListApiInfo,
+	objectHandler func(marker string, object ListObject) (shouldContinue bool, err *data.CodeError),
+	errorHandler func(marker string, err *data.CodeError)) *data.CodeError {
 	if objectHandler == nil {
 		data.SetCmdStatus(data.StatusError)
 		log.Error(alert.CannotEmpty("list bucket: object handler", ""))
-		return
+		return alert.CannotEmptyError("list bucket: object handler", "")
 	}
 
 	if errorHandler == nil {
@@ -81,7 +90,7 @@ func List(info ListApiInfo,
 	bucketManager, err := GetBucketManager()
 	if err != nil {
 		errorHandler("", err)
-		return
+		return err
 	}
 
 	info.init()
@@ -105,6 +114,11 @@ func List(info ListApiInfo,
 			return false
 		}
 
+		if isKeyExcluded(listItem.Key, info.ExcludeSuffixes, info.ExcludeKeyRegex) {
+			log.DebugF("filter %s: key excluded, exclude suffixes:%s exclude regex:%v", listItem.Key, info.ExcludeSuffixes, info.ExcludeKeyRegex)
+			return false
+		}
+
 		if shouldCheckFileTypes && !filterByFileType(listItem.Type, info.FileTypes) {
 			log.DebugF("filter %s: key not match, fileType:%d FileTypes:%s ", listItem.Key, listItem.Type, info.Suffixes)
 			return false
@@ -120,6 +134,11 @@ func List(info ListApiInfo,
 			return false
 		}
 
+		if info.Filter != nil && !info.Filter.Match(&listItem) {
+			log.DebugF("filter %s: not match filter expression:%s", listItem.Key, info.Filter)
+			return false
+		}
+
 		return true
 	}
 
@@ -223,6 +242,7 @@ func List(info ListApiInfo,
 
 			if workspace.IsCmdInterrupt() || // 取消
 				lErr.Code >= 300 && lErr.Code < 500 || // Bad Request
+				lErr.IsNotFound() || // 空间不存在，直接结束
 				strings.Contains(lErr.Error(), "no such bucket") || // 空间不存在，直接结束
 				strings.Contains(lErr.Error(), "incorrect zone") || // 空间不正确
 				strings.Contains(lErr.Error(), "query region error") || // 查询空间错误
@@ -250,6 +270,8 @@ func List(info ListApiInfo,
 		} else {
 			log.InfoF("list complete, remove cache status: %s", cache.cachePath)
 		}
+	} else if info.isShard {
+		log.DebugF("Marker: %s", info.Marker)
 	} else {
 		log.InfoF("Marker: %s", info.Marker)
 	}
@@ -257,6 +279,7 @@ func List(info ListApiInfo,
 	log.Debug("list bucket end")
 
 	listWaiter.Done()
+	return lErr
 }
 
 type ListToFileApiInfo struct {
@@ -264,6 +287,11 @@ type ListToFileApiInfo struct {
 	FilePath   string // file 不存在则输出到 stdout
 	AppendMode bool
 	Readable   bool
+	Format     string // 输出格式，tsv / csv，默认：tsv
+	TimeFormat string // PutTime 的时间格式，为空时输出原始值
+
+	ShardPrefixes    []string // 分片前缀，不为空时按分片前缀并发列举，参考 ListParallel
+	ShardWorkerCount int      // 并发列举的 worker 数
 }
 
 func ListToFile(info ListToFileApiInfo, errorHandler func(marker string, err *data.CodeError)) {
@@ -284,6 +312,11 @@ func ListToFile(info ListToFileApiInfo, errorHandler func(marker string, err *da
 		info.OutputFieldsSep = data.DefaultLineSeparate
 	}
 
+	isCSV := info.Format == ListOutputFormatCSV
+	if isCSV {
+		info.OutputFieldsSep = ","
+	}
+
 	// 文件头
 	title := strings.Join(info.ShowFields, info.OutputFieldsSep)
 
@@ -312,14 +345,26 @@ func ListToFile(info ListToFileApiInfo, errorHandler func(marker string, err *da
 	}
 
 	bWriter := bufio.NewWriter(output)
+	csvWriter := csv.NewWriter(bWriter)
 	lineCreator := &ListLineCreator{
-		Fields:   info.ShowFields,
-		Sep:      info.OutputFieldsSep,
-		Readable: info.Readable,
+		Fields:     info.ShowFields,
+		Sep:        info.OutputFieldsSep,
+		Readable:   info.Readable,
+		TimeFormat: info.TimeFormat,
 	}
-	List(info.ListApiInfo, func(marker string, object ListObject) (bool, *data.CodeError) {
-		lineData := lineCreator.Create(&object)
-		if _, wErr := bWriter.WriteString(lineData + "\n"); wErr != nil {
+	writeLock := sync.Mutex{}
+	objectHandler := func(marker string, object ListObject) (bool, *data.CodeError) {
+		writeLock.Lock()
+		defer writeLock.Unlock()
+
+		if isCSV {
+			// 字段中包含逗号、引号或换行时需要转义
+			_ = csvWriter.Write(lineCreator.Values(&object))
+			csvWriter.Flush()
+			if wErr := csvWriter.Error(); wErr != nil {
+				return false, data.NewEmptyError().AppendDesc("write error:" + wErr.Error())
+			}
+		} else if _, wErr := bWriter.WriteString(lineCreator.Create(&object) + "\n"); wErr != nil {
 			return false, data.NewEmptyError().AppendDesc("write error:" + wErr.Error())
 		}
 
@@ -327,7 +372,13 @@ func ListToFile(info ListToFileApiInfo, errorHandler func(marker string, err *da
 			return false, data.NewEmptyError().AppendDesc("flush error:" + fErr.Error())
 		}
 		return true, nil
-	}, errorHandler)
+	}
+
+	if len(info.ShardPrefixes) > 0 {
+		ListParallel(info.ListApiInfo, info.ShardPrefixes, info.ShardWorkerCount, objectHandler, errorHandler)
+	} else {
+		List(info.ListApiInfo, objectHandler, errorHandler)
+	}
 }
 
 func filterByPutTime(putTime, startDate, endDate time.Time) bool {
@@ -345,6 +396,16 @@ func filterByPutTime(putTime, startDate, endDate time.Time) bool {
 	}
 }
 
+// isKeyExcluded key 以 excludeSuffixes 中任一后缀结尾或匹配 excludeKeyRegex 时被排除
+func isKeyExcluded(key string, excludeSuffixes []string, excludeKeyRegex *regexp.Regexp) bool {
+	for _, s := range excludeSuffixes {
+		if strings.HasSuffix(key, s) {
+			return true
+		}
+	}
+	return excludeKeyRegex != nil && excludeKeyRegex.MatchString(key)
+}
+
 func filterBySuffixes(key string, suffixes []string) bool {
 	hasSuffix := false
 	if len(suffixes) == 0 {
@@ -0,0 +1,408 @@
+package bucket
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+// ListFilter 列举结果的过滤表达式，如：size > 1m && mime == "image/jpeg" && putTime < 2023-01-01
+// 支持的字段：key、hash、mime、endUser、size、putTime、storageType
+// 支持的运算符：== != > >= < <= =~（正则匹配，仅字符串字段支持）&& || ! 及括号
+// size 的值支持单位，如：512k、1.5g；putTime 的值为本地时间，如：2023-01-01、"2023-01-01 08:00:00"
+type ListFilter struct {
+	expr string
+	root filterNode
+}
+
+// ParseListFilter 解析过滤表达式，表达式有误时返回错误的位置
+func ParseListFilter(expr string) (*ListFilter, *data.CodeError) {
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != filterTokenEOF {
+		return nil, filterError(t.pos, "unexpected `%s`", t.value)
+	}
+	return &ListFilter{expr: expr, root: root}, nil
+}
+
+func (f *ListFilter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expr
+}
+
+// Match object 是否满足过滤表达式，f 为 nil 时均满足
+func (f *ListFilter) Match(object *ListObject) bool {
+	if f == nil || f.root == nil {
+		return true
+	}
+	return f.root.match(object)
+}
+
+func filterError(pos int, format string, a ...interface{}) *data.CodeError {
+	return data.NewEmptyError().AppendDescF("filter error at column %d: %s", pos, fmt.Sprintf(format, a...))
+}
+
+type filterTokenKind int
+
+const (
+	filterTokenEOF filterTokenKind = iota
+	filterTokenWord
+	filterTokenString
+	filterTokenOperator
+	filterTokenAnd
+	filterTokenOr
+	filterTokenNot
+	filterTokenLeftParen
+	filterTokenRightParen
+)
+
+type filterToken struct {
+	kind  filterTokenKind
+	value string
+	pos   int // 在表达式中的列，从 1 开始
+}
+
+func lexFilter(expr string) ([]filterToken, *data.CodeError) {
+	tokens := make([]filterToken, 0)
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		pos := i + 1
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, filterToken{kind: filterTokenLeftParen, value: "(", pos: pos})
+			i++
+		case c == ')':
+			tokens = append(tokens, filterToken{kind: filterTokenRightParen, value: ")", pos: pos})
+			i++
+		case c == '&' || c == '|':
+			if next != c {
+				return nil, filterError(pos, "unexpected `%c`, do you mean `%c%c`", c, c, c)
+			}
+			kind := filterTokenAnd
+			if c == '|' {
+				kind = filterTokenOr
+			}
+			tokens = append(tokens, filterToken{kind: kind, value: string([]rune{c, c}), pos: pos})
+			i += 2
+		case c == '=' || c == '!' || c == '<' || c == '>':
+			if c == '!' && next != '=' {
+				tokens = append(tokens, filterToken{kind: filterTokenNot, value: "!", pos: pos})
+				i++
+			} else if next == '=' || (c == '=' && next == '~') {
+				tokens = append(tokens, filterToken{kind: filterTokenOperator, value: string([]rune{c, next}), pos: pos})
+				i += 2
+			} else if c == '=' {
+				return nil, filterError(pos, "unexpected `=`, do you mean `==`")
+			} else {
+				tokens = append(tokens, filterToken{kind: filterTokenOperator, value: string(c), pos: pos})
+				i++
+			}
+		case c == '"' || c == '\'':
+			value := strings.Builder{}
+			j := i + 1
+			for ; j < len(runes) && runes[j] != c; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				value.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, filterError(pos, "unterminated string")
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenString, value: value.String(), pos: pos})
+			i = j + 1
+		default:
+			j := i
+			for ; j < len(runes) && !strings.ContainsRune(" \t\n\r()&|=!<>\"'", runes[j]); j++ {
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenWord, value: string(runes[i:j]), pos: pos})
+			i = j
+		}
+	}
+	tokens = append(tokens, filterToken{kind: filterTokenEOF, value: "end of expression", pos: len(runes) + 1})
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	index  int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.index]
+}
+
+func (p *filterParser) next() filterToken {
+	t := p.tokens[p.index]
+	if t.kind != filterTokenEOF {
+		p.index++
+	}
+	return t
+}
+
+func (p *filterParser) parseOr() (filterNode, *data.CodeError) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == filterTokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterOrNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, *data.CodeError) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == filterTokenAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterAndNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, *data.CodeError) {
+	t := p.next()
+	switch t.kind {
+	case filterTokenNot:
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &filterNotNode{node: node}, nil
+	case filterTokenLeftParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != filterTokenRightParen {
+			return nil, filterError(r.pos, "expect `)` but got `%s`", r.value)
+		}
+		return node, nil
+	case filterTokenWord:
+		return p.parseCompare(t)
+	default:
+		return nil, filterError(t.pos, "expect field but got `%s`", t.value)
+	}
+}
+
+func (p *filterParser) parseCompare(fieldToken filterToken) (filterNode, *data.CodeError) {
+	field, ok := filterFields[strings.ToLower(fieldToken.value)]
+	if !ok {
+		return nil, filterError(fieldToken.pos, "unknown field `%s`, should be one of key, hash, mime, endUser, size, putTime, storageType", fieldToken.value)
+	}
+
+	opToken := p.next()
+	if opToken.kind != filterTokenOperator {
+		return nil, filterError(opToken.pos, "expect operator after `%s` but got `%s`", fieldToken.value, opToken.value)
+	}
+
+	valueToken := p.next()
+	if valueToken.kind != filterTokenWord && valueToken.kind != filterTokenString {
+		return nil, filterError(valueToken.pos, "expect value after `%s` but got `%s`", opToken.value, valueToken.value)
+	}
+
+	node := &filterCompareNode{field: field, op: opToken.value}
+	if field.isString {
+		switch node.op {
+		case "==", "!=":
+			node.str = valueToken.value
+		case "=~":
+			re, err := regexp.Compile(valueToken.value)
+			if err != nil {
+				return nil, filterError(valueToken.pos, "invalid regular expression `%s`, %v", valueToken.value, err)
+			}
+			node.re = re
+		default:
+			return nil, filterError(opToken.pos, "operator `%s` is not supported by field `%s`", node.op, fieldToken.value)
+		}
+		return node, nil
+	}
+
+	if node.op == "=~" {
+		return nil, filterError(opToken.pos, "operator `=~` is not supported by field `%s`", fieldToken.value)
+	}
+	number, err := field.parseNumber(valueToken.value)
+	if err != nil {
+		return nil, filterError(valueToken.pos, "invalid value `%s` of field `%s`, %v", valueToken.value, fieldToken.value, err)
+	}
+	node.number = number
+	return node, nil
+}
+
+type filterField struct {
+	isString    bool
+	stringValue func(object *ListObject) string
+	numberValue func(object *ListObject) int64
+	parseNumber func(value string) (int64, error)
+}
+
+func filterStringField(value func(object *ListObject) string) *filterField {
+	return &filterField{isString: true, stringValue: value}
+}
+
+var (
+	filterKeyField = filterStringField(func(object *ListObject) string {
+		return object.Key
+	})
+	filterHashField = filterStringField(func(object *ListObject) string {
+		return object.Hash
+	})
+	filterMimeField = filterStringField(func(object *ListObject) string {
+		return object.MimeType
+	})
+	filterEndUserField = filterStringField(func(object *ListObject) string {
+		return object.EndUser
+	})
+	filterSizeField = &filterField{
+		numberValue: func(object *ListObject) int64 {
+			return object.Fsize
+		},
+		parseNumber: func(value string) (int64, error) {
+			size, err := utils.ParseFileSize(value)
+			if err != nil {
+				return 0, err
+			}
+			return size, nil
+		},
+	}
+	filterPutTimeField = &filterField{
+		numberValue: func(object *ListObject) int64 {
+			return object.PutTime
+		},
+		parseNumber: func(value string) (int64, error) {
+			for _, layout := range []string{"2006-01-02", "2006-01-02 15:04:05", "2006-01-02T15:04:05", time.RFC3339} {
+				if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+					// putTime 的单位为 100ns
+					return t.UnixNano() / 100, nil
+				}
+			}
+			return 0, fmt.Errorf("time format should be 2006-01-02 or 2006-01-02 15:04:05")
+		},
+	}
+	filterStorageTypeField = &filterField{
+		numberValue: func(object *ListObject) int64 {
+			return int64(object.Type)
+		},
+		parseNumber: func(value string) (int64, error) {
+			return strconv.ParseInt(value, 10, 64)
+		},
+	}
+)
+
+// filterFields 字段名不区分大小写
+var filterFields = map[string]*filterField{
+	"key":         filterKeyField,
+	"hash":        filterHashField,
+	"mime":        filterMimeField,
+	"mimetype":    filterMimeField,
+	"enduser":     filterEndUserField,
+	"size":        filterSizeField,
+	"filesize":    filterSizeField,
+	"puttime":     filterPutTimeField,
+	"storagetype": filterStorageTypeField,
+	"filetype":    filterStorageTypeField,
+	"type":        filterStorageTypeField,
+}
+
+type filterNode interface {
+	match(object *ListObject) bool
+}
+
+type filterAndNode struct {
+	left  filterNode
+	right filterNode
+}
+
+func (n *filterAndNode) match(object *ListObject) bool {
+	return n.left.match(object) && n.right.match(object)
+}
+
+type filterOrNode struct {
+	left  filterNode
+	right filterNode
+}
+
+func (n *filterOrNode) match(object *ListObject) bool {
+	return n.left.match(object) || n.right.match(object)
+}
+
+type filterNotNode struct {
+	node filterNode
+}
+
+func (n *filterNotNode) match(object *ListObject) bool {
+	return !n.node.match(object)
+}
+
+type filterCompareNode struct {
+	field  *filterField
+	op     string
+	str    string
+	re     *regexp.Regexp
+	number int64
+}
+
+func (n *filterCompareNode) match(object *ListObject) bool {
+	if n.field.isString {
+		value := n.field.stringValue(object)
+		switch n.op {
+		case "==":
+			return value == n.str
+		case "!=":
+			return value != n.str
+		default:
+			return n.re.MatchString(value)
+		}
+	}
+
+	value := n.field.numberValue(object)
+	switch n.op {
+	case "==":
+		return value == n.number
+	case "!=":
+		return value != n.number
+	case ">":
+		return value > n.number
+	case ">=":
+		return value >= n.number
+	case "<":
+		return value < n.number
+	default:
+		return value <= n.number
+	}
+}
@@ -0,0 +1,81 @@
+package bucket
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListFilter(t *testing.T) {
+	putTime := time.Date(2022, 6, 1, 0, 0, 0, 0, time.Local).UnixNano() / 100
+	object := &ListObject{
+		Key:      "img/a.jpg",
+		Fsize:    2 * 1024 * 1024,
+		MimeType: "image/jpeg",
+		PutTime:  putTime,
+		Type:     1,
+	}
+
+	cases := map[string]bool{
+		`size > 1m && mime == "image/jpeg" && putTime < 2023-01-01`: true,
+		`size > 1m && mime == "image/png"`:                          false,
+		`size <= 1m || storageType == 1`:                            true,
+		`!(key =~ "^img/") || putTime >= "2022-06-01 00:00:00"`:     true,
+		`PutTime < 2022-01-01`:                                      false,
+		`mime != 'image/jpeg'`:                                      false,
+	}
+	for expr, expected := range cases {
+		filter, err := ParseListFilter(expr)
+		if err != nil {
+			t.Fatalf("parse %s error:%v", expr, err)
+		}
+		if filter.Match(object) != expected {
+			t.Fatalf("%s should match:%v", expr, expected)
+		}
+	}
+}
+
+func TestListFilterParseError(t *testing.T) {
+	cases := map[string]string{
+		`size > 1m &&`:         "column 13",
+		`color == "red"`:       "column 1",
+		`size > 1x`:            "column 8",
+		`putTime < 2023/01/01`: "column 11",
+		`(size > 1m`:           "column 11",
+		`key > "a"`:            "column 5",
+		`mime == "image/jpeg`:  "column 9",
+		`size = 1m`:            "column 6",
+	}
+	for expr, position := range cases {
+		_, err := ParseListFilter(expr)
+		if err == nil {
+			t.Fatalf("parse %s should fail", expr)
+		}
+		if !strings.Contains(err.Error(), position) {
+			t.Fatalf("parse %s error should contain %s, but:%v", expr, position, err)
+		}
+	}
+}
+
+func TestIsKeyExcluded(t *testing.T) {
+	excludeKeyRegex := regexp.MustCompile(`^tmp/|\.bak$`)
+	cases := map[string]bool{
+		"a.jpg":      false,
+		"a.done":     true,
+		"a.tmp":      true,
+		"tmp/a.jpg":  true,
+		"img/a.bak":  true,
+		"img/tmp/a":  false,
+		"a.done.jpg": false,
+	}
+	for key, excluded := range cases {
+		if isKeyExcluded(key, []string{".done", ".tmp"}, excludeKeyRegex) != excluded {
+			t.Fatalf("%s should be excluded:%v", key, excluded)
+		}
+	}
+
+	if isKeyExcluded("a.done", nil, nil) {
+		t.Fatal("key should not be excluded without exclude conditions")
+	}
+}
@@ -2,11 +2,18 @@ package bucket
 
 import (
 	"fmt"
-	"github.com/qiniu/qshell/v2/iqshell/common/data"
-	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+const (
+	ListOutputFormatTSV = "tsv" // 字段之间使用 OutputFieldsSep 分隔
+	ListOutputFormatCSV = "csv" // 按 RFC 4180 输出，字段之间使用逗号分隔
 )
 
 const (
@@ -17,6 +24,9 @@ const (
 	listObjectFieldsMimeType = "MimeType"
 	listObjectFieldsFileType = "FileType"
 	listObjectFieldsEndUser  = "EndUser"
+
+	listObjectFieldsRestoreStatus = "RestoreStatus"
+	listObjectFieldsRestoreExpiry = "RestoreExpiry"
 )
 
 var listObjectFields = []string{
@@ -29,6 +39,27 @@ var listObjectFields = []string{
 	listObjectFieldsEndUser,
 }
 
+// listObjectOptionalFields 默认不输出的字段，需要通过 --show-fields 或 --columns 指定
+var listObjectOptionalFields = []string{
+	listObjectFieldsRestoreStatus,
+	listObjectFieldsRestoreExpiry,
+}
+
+// 解冻状态，仅归档存储及深度归档存储的文件有解冻状态，其他文件输出 n/a
+const (
+	listObjectRestoreStatusNotApplicable = "n/a"
+	listObjectRestoreStatusFrozen        = "frozen"
+	listObjectRestoreStatusRestoring     = "restoring"
+	listObjectRestoreStatusRestored      = "restored"
+)
+
+// listObjectFieldAliases 字段的简称，如：--columns key,size,mime
+var listObjectFieldAliases = map[string]string{
+	"size": listObjectFieldsFileSize,
+	"mime": listObjectFieldsMimeType,
+	"type": listObjectFieldsFileType,
+}
+
 type ListLineParser struct {
 	mu          sync.Mutex
 	isFirstLine bool
@@ -76,13 +107,23 @@ func (p *ListLineParser) convert(items []string) (*ListObject, *data.CodeError)
 }
 
 type ListLineCreator struct {
-	Fields   []string // 需要输出的字段
-	Sep      string   // 分隔符
-	Readable bool     // 是否可读
+	Fields     []string // 需要输出的字段
+	Sep        string   // 分隔符
+	Readable   bool     // 是否可读
+	TimeFormat string   // PutTime 的时间格式，如：2006-01-02 15:04:05，为空时输出原始值（单位：100ns）
 }
 
 func (l *ListLineCreator) Create(object *ListObject) string {
-	return listObjectDescWithFields(object, l.Fields, l.Sep, l.Readable)
+	return strings.Join(l.Values(object), l.Sep)
+}
+
+// Values 按 Fields 的顺序获取 object 的字段值
+func (l *ListLineCreator) Values(object *ListObject) []string {
+	values := make([]string, 0, len(l.Fields))
+	for _, field := range l.Fields {
+		values = append(values, listObjectFieldStringValue(object, field, l.Readable, l.TimeFormat))
+	}
+	return values
 }
 
 func getKeyItems(items []string) (bool, []string) {
@@ -115,18 +156,15 @@ func listObjectField(field string) string {
 			return f
 		}
 	}
-	return ""
-}
-
-func listObjectDescWithFields(object *ListObject, fields []string, outputFieldsSep string, readable bool) string {
-	var values []string
-	for _, field := range fields {
-		values = append(values, listObjectFieldStringValue(object, field, readable))
+	for _, f := range listObjectOptionalFields {
+		if strings.EqualFold(field, f) {
+			return f
+		}
 	}
-	return strings.Join(values, outputFieldsSep)
+	return listObjectFieldAliases[strings.ToLower(field)]
 }
 
-func listObjectFieldStringValue(object *ListObject, field string, readable bool) string {
+func listObjectFieldStringValue(object *ListObject, field string, readable bool, timeFormat string) string {
 	if object == nil {
 		return ""
 	}
@@ -147,7 +185,11 @@ func listObjectFieldStringValue(object *ListObject, field string, readable bool)
 		value = object.Hash
 		break
 	case listObjectFieldsPutTime:
-		value = object.PutTime
+		if len(timeFormat) > 0 {
+			value = time.Unix(0, object.PutTime*100).Format(timeFormat)
+		} else {
+			value = object.PutTime
+		}
 		break
 	case listObjectFieldsMimeType:
 		value = object.MimeType
@@ -158,11 +200,44 @@ func listObjectFieldStringValue(object *ListObject, field string, readable bool)
 	case listObjectFieldsEndUser:
 		value = object.EndUser
 		break
+	case listObjectFieldsRestoreStatus:
+		value = listObjectRestoreStatus(object)
+		break
+	case listObjectFieldsRestoreExpiry:
+		if !isArchiveObject(object) {
+			value = listObjectRestoreStatusNotApplicable
+		} else if object.RestoreExpiry <= 0 {
+			value = ""
+		} else if len(timeFormat) > 0 {
+			value = time.Unix(object.RestoreExpiry, 0).Format(timeFormat)
+		} else {
+			value = object.RestoreExpiry
+		}
+		break
 	default:
 	}
 	return fmt.Sprintf("%v", value)
 }
 
+// isArchiveObject 是否为需要解冻的归档存储或深度归档存储文件
+func isArchiveObject(object *ListObject) bool {
+	return object.Type == 2 || object.Type == 3
+}
+
+func listObjectRestoreStatus(object *ListObject) string {
+	if !isArchiveObject(object) {
+		return listObjectRestoreStatusNotApplicable
+	}
+	switch object.RestoreStatus {
+	case 1:
+		return listObjectRestoreStatusRestoring
+	case 2:
+		return listObjectRestoreStatusRestored
+	default:
+		return listObjectRestoreStatusFrozen
+	}
+}
+
 func listObjectSetFieldWithStringValue(object *ListObject, field string, value string) *data.CodeError {
 	if object == nil {
 		return nil
@@ -203,6 +278,21 @@ func listObjectSetFieldWithStringValue(object *ListObject, field string, value s
 	case listObjectFieldsEndUser:
 		object.EndUser = value
 		break
+	case listObjectFieldsRestoreStatus:
+		switch value {
+		case listObjectRestoreStatusRestoring:
+			object.RestoreStatus = 1
+		case listObjectRestoreStatusRestored:
+			object.RestoreStatus = 2
+		default:
+			object.RestoreStatus = 0
+		}
+		break
+	case listObjectFieldsRestoreExpiry:
+		if i, e := strconv.ParseInt(value, 10, 64); e == nil {
+			object.RestoreExpiry = i
+		}
+		break
 	default:
 	}
 
@@ -0,0 +1,60 @@
+package bucket
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListLineCreatorValues(t *testing.T) {
+	var fields []string
+	for _, column := range []string{"key", "size", "mime", "putTime", "type"} {
+		f := ListObjectField(column)
+		if len(f) == 0 {
+			t.Fatalf("column %s should be supported", column)
+		}
+		fields = append(fields, f)
+	}
+
+	putTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.Local)
+	creator := &ListLineCreator{
+		Fields:     fields,
+		Sep:        ",",
+		TimeFormat: "2006-01-02 15:04:05",
+	}
+	values := creator.Values(&ListObject{
+		Key:      "a,b.jpg",
+		Fsize:    1024,
+		MimeType: "image/jpeg",
+		PutTime:  putTime.UnixNano() / 100,
+		Type:     1,
+	})
+	expected := []string{"a,b.jpg", "1024", "image/jpeg", "2023-01-02 03:04:05", "1"}
+	for i, v := range expected {
+		if values[i] != v {
+			t.Fatalf("value of %s should be %s, but:%s", fields[i], v, values[i])
+		}
+	}
+}
+
+func TestListLineCreatorRestoreValues(t *testing.T) {
+	creator := &ListLineCreator{
+		Fields: []string{ListObjectField("restoreStatus"), ListObjectField("restoreExpiry")},
+		Sep:    "\t",
+	}
+
+	cases := []struct {
+		object   *ListObject
+		expected string
+	}{
+		{&ListObject{Type: 0}, "n/a\tn/a"},
+		{&ListObject{Type: 1, RestoreStatus: 2}, "n/a\tn/a"},
+		{&ListObject{Type: 2}, "frozen\t"},
+		{&ListObject{Type: 3, RestoreStatus: 1}, "restoring\t"},
+		{&ListObject{Type: 2, RestoreStatus: 2, RestoreExpiry: 1700000000}, "restored\t1700000000"},
+	}
+	for _, c := range cases {
+		if line := creator.Create(c.object); line != c.expected {
+			t.Fatalf("line of %+v should be %q, but:%q", c.object, c.expected, line)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package bucket
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// listRange 列举区间 [Lower, Upper)，Upper 为空表示直到 Prefix 的末尾
+type listRange struct {
+	Lower string
+	Upper string
+}
+
+func (r *listRange) WorkId() string {
+	return r.Lower
+}
+
+type listRangeResult struct{}
+
+func (r *listRangeResult) IsValid() bool {
+	return true
+}
+
+// ShardPrefixes 解析分片前缀，多个使用逗号分隔；hex:N 表示所有长度为 N 的十六进制前缀，如：hex:2 表示 00 ~ ff
+func ShardPrefixes(prefixes string) ([]string, *data.CodeError) {
+	if strings.HasPrefix(prefixes, "hex:") {
+		length := 0
+		if _, err := fmt.Sscanf(prefixes, "hex:%d", &length); err != nil || length < 1 || length > 4 {
+			return nil, alert.Error(fmt.Sprintf("shard prefixes %s is invalid, hex length should be between 1 and 4", prefixes), "")
+		}
+
+		count := 1 << (4 * length)
+		ret := make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			ret = append(ret, fmt.Sprintf("%0*x", length, i))
+		}
+		return ret, nil
+	}
+
+	ret := make([]string, 0)
+	for _, p := range strings.Split(prefixes, ",") {
+		if p = strings.TrimSpace(p); len(p) > 0 {
+			ret = append(ret, p)
+		}
+	}
+	return ret, nil
+}
+
+// listRanges 根据分片前缀将 prefix 下的 key 切分为连续、互不重叠的区间：
+// [prefix, prefix+shard_0), [prefix+shard_0, prefix+shard_1) ... [prefix+shard_n, )
+// 区间覆盖了 prefix 下所有的 key，因此分片前缀重叠或 key 不匹配任何分片前缀时，结果也不会重复或遗漏
+func listRanges(prefix string, shardPrefixes []string) []*listRange {
+	boundSet := make(map[string]bool)
+	for _, shard := range shardPrefixes {
+		if len(shard) > 0 {
+			boundSet[prefix+shard] = true
+		}
+	}
+	bounds := make([]string, 0, len(boundSet)+1)
+	bounds = append(bounds, prefix)
+	for b := range boundSet {
+		bounds = append(bounds, b)
+	}
+	sort.Strings(bounds[1:])
+
+	ranges := make([]*listRange, 0, len(bounds))
+	for i, lower := range bounds {
+		r := &listRange{Lower: lower}
+		if i+1 < len(bounds) {
+			r.Upper = bounds[i+1]
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// listMarkerAfterKey 构造从 key 之后开始列举的 marker，格式同 list v1 接口返回的 marker
+func listMarkerAfterKey(key string) string {
+	marker, _ := json.Marshal(map[string]interface{}{
+		"c": 0,
+		"k": key,
+	})
+	return base64.URLEncoding.EncodeToString(marker)
+}
+
+// ListParallel 按分片前缀将列举范围切分为多个区间，每个区间由一个 worker 列举，最多 workerCount 个区间并发；
+// 同一区间内的输出有序，不同区间的输出交替出现；objectHandler 会被并发调用，需要保证线程安全
+func ListParallel(info ListApiInfo, shardPrefixes []string, workerCount int,
+	objectHandler func(marker string, object ListObject) (shouldContinue bool, err *data.CodeError),
+	errorHandler func(marker string, err *data.CodeError)) {
+	if len(info.Marker) > 0 || info.EnableRecord {
+		errorHandler("", alert.Error("list bucket: marker and record are not supported when listing with shard prefixes", ""))
+		return
+	}
+
+	// 输出条数的限制由所有区间共享
+	outputLimit := info.OutputLimit
+	info.OutputLimit = -1
+	info.isShard = true
+	mu := sync.Mutex{}
+	outputCount := 0
+	handler := func(marker string, object ListObject) (bool, *data.CodeError) {
+		if outputLimit > 0 {
+			mu.Lock()
+			if outputCount >= outputLimit {
+				mu.Unlock()
+				return false, nil
+			}
+			outputCount++
+			mu.Unlock()
+		}
+		return objectHandler(marker, object)
+	}
+
+	ranges := listRanges(info.Prefix, shardPrefixes)
+	works := make([]flow.Work, 0, len(ranges))
+	for _, r := range ranges {
+		works = append(works, r)
+	}
+	log.DebugF("list bucket with %d ranges, worker count:%d", len(ranges), workerCount)
+
+	flow.New(flow.Info{
+		Force:       true,
+		WorkerCount: workerCount,
+		Internal:    true,
+	}).WorkProviderWithArray(works).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
+				if err := listBucketRange(info, workInfo.Work.(*listRange), handler, errorHandler); err != nil {
+					return nil, err
+				}
+				return &listRangeResult{}, nil
+			}), nil
+		})).
+		DoWorkListMaxCount(1).
+		DoWorkListMinCount(1).
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
+			r := workInfo.Work.(*listRange)
+			log.ErrorF("list range [%s, %s) error:%v", r.Lower, r.Upper, err)
+			data.SetCmdStatusError()
+		}).Build().Start()
+}
+
+// listBucketRange 列举区间 [Lower, Upper) 内的 key：先按前缀 Lower 列举，再从最后一个 key 之后按 Prefix 列举直到 Upper
+func listBucketRange(info ListApiInfo, r *listRange,
+	objectHandler func(marker string, object ListObject) (shouldContinue bool, err *data.CodeError),
+	errorHandler func(marker string, err *data.CodeError)) *data.CodeError {
+	stopped := false
+	lastKey := r.Lower
+	handler := func(marker string, object ListObject) (bool, *data.CodeError) {
+		if len(r.Upper) > 0 && object.Key >= r.Upper {
+			stopped = true
+			return false, nil
+		}
+		lastKey = object.Key
+		shouldContinue, err := objectHandler(marker, object)
+		if !shouldContinue {
+			stopped = true
+		}
+		return shouldContinue, err
+	}
+
+	if r.Lower != info.Prefix {
+		shardInfo := info
+		shardInfo.Prefix = r.Lower
+		if err := listBucket(shardInfo, handler, errorHandler); err != nil {
+			return err
+		}
+		if stopped {
+			return nil
+		}
+		if workspace.IsCmdInterrupt() {
+			return data.CancelError
+		}
+		// 以 Lower 为前缀的 key 已全部列举，剩余的 key 均大于 lastKey
+		info.Marker = listMarkerAfterKey(lastKey)
+	}
+
+	return listBucket(info, handler, errorHandler)
+}
@@ -0,0 +1,46 @@
+package bucket
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestShardPrefixes(t *testing.T) {
+	prefixes, err := ShardPrefixes("hex:2")
+	if err != nil || len(prefixes) != 256 || prefixes[0] != "00" || prefixes[255] != "ff" {
+		t.Fatalf("hex:2 should be 00~ff, err:%v", err)
+	}
+
+	if _, err = ShardPrefixes("hex:9"); err == nil {
+		t.Fatal("hex:9 should be invalid")
+	}
+
+	prefixes, _ = ShardPrefixes("a, b,,c")
+	if len(prefixes) != 3 {
+		t.Fatalf("prefixes count should be 3, but:%d", len(prefixes))
+	}
+}
+
+func TestListRangesCoverKeys(t *testing.T) {
+	// 分片前缀重叠、乱序，部分 key 不匹配任何分片前缀
+	ranges := listRanges("img/", []string{"b", "a", "ab", "a", "c"})
+	keys := []string{"img/", "img/0", "img/A.jpg", "img/a", "img/aa", "img/ab", "img/abc", "img/b1", "img/bz", "img/c", "img/d", "img/~"}
+	for _, key := range keys {
+		count := 0
+		for _, r := range ranges {
+			if key >= r.Lower && (len(r.Upper) == 0 || key < r.Upper) {
+				count++
+			}
+		}
+		if count != 1 {
+			t.Fatalf("key %s should be in exactly one range, but:%d", key, count)
+		}
+	}
+}
+
+func TestListMarkerAfterKey(t *testing.T) {
+	marker, err := base64.URLEncoding.DecodeString(listMarkerAfterKey("a.jpg"))
+	if err != nil || string(marker) != `{"c":0,"k":"a.jpg"}` {
+		t.Fatalf("marker error:%v %s", err, marker)
+	}
+}
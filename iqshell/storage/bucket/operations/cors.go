@@ -0,0 +1,95 @@
+package operations
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+)
+
+type CorsGetInfo struct {
+	Bucket string // 空间名 【必选】
+}
+
+func (info *CorsGetInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	return nil
+}
+
+// CorsGet 以格式化的 JSON 输出空间的跨域规则，输出可直接作为 cors set 的规则文件
+func CorsGet(cfg *iqshell.Config, info CorsGetInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	rules, err := bucket.CorsRules(info.Bucket)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+	if len(rules) == 0 {
+		log.WarningF("No cors rules found for bucket `%s`, all cross-origin requests are allowed", info.Bucket)
+	}
+
+	bytes, mErr := json.MarshalIndent(rules, "", "  ")
+	if mErr != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("marshal cors rules error:%v", mErr)
+		return
+	}
+	log.Alert(string(bytes))
+}
+
+type CorsSetInfo struct {
+	Bucket    string // 空间名 【必选】
+	RulesFile string // JSON 格式的规则文件 【必选】
+
+	rules []*bucket.CorsRule
+}
+
+func (info *CorsSetInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	if len(info.RulesFile) == 0 {
+		return alert.CannotEmptyError("RulesFile", "")
+	}
+
+	content, err := os.ReadFile(info.RulesFile)
+	if err != nil {
+		return data.NewEmptyError().AppendDescF("read cors rules file:%s error:%v", info.RulesFile, err)
+	}
+	var pErr *data.CodeError
+	if info.rules, pErr = bucket.ParseCorsRules(content); pErr != nil {
+		return pErr
+	}
+	return nil
+}
+
+// CorsSet 使用规则文件中的跨域规则覆盖空间已有的规则，规则在提交前校验
+func CorsSet(cfg *iqshell.Config, info CorsSetInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if len(info.rules) == 0 {
+		log.WarningF("cors rules file is empty, all cors rules of bucket:%s will be cleared", info.Bucket)
+	}
+	if err := bucket.SetCorsRules(info.Bucket, info.rules); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Set cors rules of bucket:%s error:%v", info.Bucket, err)
+		return
+	}
+	log.InfoF("Set %d cors rule(s) of bucket:%s success", len(info.rules), info.Bucket)
+}
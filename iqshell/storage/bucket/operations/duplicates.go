@@ -0,0 +1,176 @@
+package operations
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+)
+
+type FindDuplicatesInfo struct {
+	Bucket         string // 空间名 【必选】
+	Prefix         string // 仅查找此前缀下的文件 【可选】
+	MaxRetry       int    // 列举出错时的最大重试次数，-1: 无限重试 【可选】
+	Readable       bool   // 文件大小以人工可读的方式展示 【可选】
+	DeleteListFile string // 每组保留一个文件，其余文件的 key 保存到此文件，可作为 batchdelete 的输入 【可选】
+}
+
+func (info *FindDuplicatesInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	return nil
+}
+
+// DuplicateGroup hash 相同的一组文件
+type DuplicateGroup struct {
+	Hash       string   `json:"hash"`
+	Size       int64    `json:"size"`
+	Count      int      `json:"count"`
+	WastedSize int64    `json:"wasted_size"` // 除保留的一个文件外，其余文件占用的大小
+	Keys       []string `json:"keys"`        // 按列举顺序排列，第一个为保留的文件
+}
+
+// duplicateFinder 按 hash 对文件分组，内存占用和不同 hash 的数量成正比
+type duplicateFinder struct {
+	count  int64
+	groups map[string]*DuplicateGroup
+}
+
+func newDuplicateFinder() *duplicateFinder {
+	return &duplicateFinder{
+		groups: make(map[string]*DuplicateGroup),
+	}
+}
+
+// add hash 为空的文件（如：列举结果中缺少 hash）无法比较内容，不参与分组
+func (f *duplicateFinder) add(object *bucket.ListObject) {
+	f.count++
+	if len(object.Hash) == 0 {
+		log.DebugF("Skip file:%s because its hash is empty", object.Key)
+		return
+	}
+	group := f.groups[object.Hash]
+	if group == nil {
+		f.groups[object.Hash] = &DuplicateGroup{
+			Hash:  object.Hash,
+			Size:  object.Fsize,
+			Count: 1,
+			Keys:  []string{object.Key},
+		}
+		return
+	}
+	group.Count++
+	group.WastedSize += object.Fsize
+	group.Keys = append(group.Keys, object.Key)
+}
+
+// duplicateGroups 文件数大于 1 的分组，按浪费的大小从大到小排序，大小相同时按 hash 排序
+func (f *duplicateFinder) duplicateGroups() []*DuplicateGroup {
+	groups := make([]*DuplicateGroup, 0)
+	for _, group := range f.groups {
+		if group.Count > 1 {
+			groups = append(groups, group)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].WastedSize != groups[j].WastedSize {
+			return groups[i].WastedSize > groups[j].WastedSize
+		}
+		return groups[i].Hash < groups[j].Hash
+	})
+	return groups
+}
+
+// FindDuplicates 列举空间，按文件的 hash 分组，输出内容相同但 key 不同的文件
+func FindDuplicates(cfg *iqshell.Config, info FindDuplicatesInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	finder := newDuplicateFinder()
+	listErr := bucket.List(bucket.ListApiInfo{
+		Bucket:   info.Bucket,
+		Prefix:   info.Prefix,
+		MaxRetry: info.MaxRetry,
+	}, func(marker string, object bucket.ListObject) (bool, *data.CodeError) {
+		finder.add(&object)
+		if finder.count%100000 == 0 {
+			log.InfoF("find duplicates, %d files have been listed", finder.count)
+		}
+		return true, nil
+	}, func(marker string, err *data.CodeError) {
+		log.ErrorF("list bucket error, marker:%s error:%v", marker, err)
+	})
+
+	groups := finder.duplicateGroups()
+	wastedSize := int64(0)
+	for _, group := range groups {
+		wastedSize += group.WastedSize
+		outputDuplicateGroup(group, info.Readable)
+	}
+	log.InfoF("find duplicates, files:%d duplicate groups:%d wasted size:%s",
+		finder.count, len(groups), utils.FormatFileSize(wastedSize))
+
+	if len(info.DeleteListFile) > 0 {
+		if err := saveDuplicateDeleteList(info.DeleteListFile, groups); err != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("save delete list error:%v", err)
+		}
+	}
+
+	if listErr != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("list bucket:%s error, the duplicates are incomplete, error:%v", info.Bucket, listErr)
+	}
+}
+
+func outputDuplicateGroup(group *DuplicateGroup, readable bool) {
+	if data.IsOutputFormatJson() {
+		if bytes, err := json.Marshal(group); err != nil {
+			log.ErrorF("marshal duplicate group of hash:%s error:%v", group.Hash, err)
+		} else {
+			log.Alert(string(bytes))
+		}
+		return
+	}
+
+	size, wastedSize := fmt.Sprintf("%d", group.Size), fmt.Sprintf("%d", group.WastedSize)
+	if readable {
+		size, wastedSize = utils.FormatFileSize(group.Size), utils.FormatFileSize(group.WastedSize)
+	}
+	log.AlertF("%s\t%d\t%s\t%s", group.Hash, group.Count, size, wastedSize)
+	for _, key := range group.Keys {
+		log.AlertF("\t%s", key)
+	}
+}
+
+// saveDuplicateDeleteList 每组保留第一个文件，其余文件的 key 每行一个保存到 path
+func saveDuplicateDeleteList(path string, groups []*DuplicateGroup) *data.CodeError {
+	out, err := os.Create(path)
+	if err != nil {
+		return data.NewEmptyError().AppendDescF("create delete list error:%v", err)
+	}
+	defer out.Close()
+
+	writer := bufio.NewWriter(out)
+	for _, group := range groups {
+		for _, key := range group.Keys[1:] {
+			_, _ = writer.WriteString(key + "\n")
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		return data.NewEmptyError().AppendDescF("write delete list error:%v", err)
+	}
+	return nil
+}
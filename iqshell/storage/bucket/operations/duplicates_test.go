@@ -0,0 +1,49 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+)
+
+func TestDuplicateFinder(t *testing.T) {
+	finder := newDuplicateFinder()
+	objects := []bucket.ListObject{
+		{Key: "a.jpg", Hash: "h1", Fsize: 100},
+		{Key: "b.jpg", Hash: "h2", Fsize: 10},
+		{Key: "c.jpg", Hash: "h1", Fsize: 100},
+		{Key: "d.jpg", Hash: "h3", Fsize: 1000},
+		{Key: "e.jpg", Hash: "h2", Fsize: 10},
+		{Key: "f.jpg", Hash: "h1", Fsize: 100},
+		{Key: "g.jpg", Hash: "", Fsize: 100},
+		{Key: "h.jpg", Hash: "", Fsize: 100},
+	}
+	for i := range objects {
+		finder.add(&objects[i])
+	}
+
+	groups := finder.duplicateGroups()
+	if len(groups) != 2 {
+		t.Fatalf("duplicate groups should be 2, but:%d", len(groups))
+	}
+	if groups[0].Hash != "h1" || groups[0].Count != 3 || groups[0].WastedSize != 200 {
+		t.Fatalf("first group error:%+v", groups[0])
+	}
+	if groups[1].Hash != "h2" || groups[1].Count != 2 || groups[1].WastedSize != 10 {
+		t.Fatalf("second group error:%+v", groups[1])
+	}
+
+	deleteList := filepath.Join(t.TempDir(), "delete.txt")
+	if err := saveDuplicateDeleteList(deleteList, groups); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(deleteList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "c.jpg\nf.jpg\ne.jpg\n" {
+		t.Fatalf("delete list error:%q", string(content))
+	}
+}
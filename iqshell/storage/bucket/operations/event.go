@@ -0,0 +1,121 @@
+package operations
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+)
+
+type EventListInfo struct {
+	Bucket string // 空间名 【必选】
+}
+
+func (info *EventListInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	return nil
+}
+
+// EventList 列举空间的事件通知规则，每个规则输出一行
+func EventList(cfg *iqshell.Config, info EventListInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	rules, err := bucket.EventRules(info.Bucket)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+	if len(rules) == 0 && !data.IsOutputFormatJson() {
+		log.WarningF("No event rules found for bucket `%s`", info.Bucket)
+		return
+	}
+
+	for _, rule := range rules {
+		if data.IsOutputFormatJson() {
+			if bytes, mErr := json.Marshal(rule); mErr != nil {
+				log.ErrorF("marshal event rule:%s error:%v", rule.Name, mErr)
+			} else {
+				log.Alert(string(bytes))
+			}
+			continue
+		}
+
+		log.AlertF("%s\tprefix:%s\tsuffix:%s\tevents:%s\tcallback:%s", rule.Name, rule.Prefix, rule.Suffix,
+			strings.Join(rule.Events, ","), strings.Join(rule.CallbackUrls, ","))
+	}
+}
+
+type EventAddInfo struct {
+	Bucket string // 空间名 【必选】
+	bucket.EventRule
+}
+
+func (info *EventAddInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	return info.EventRule.Check()
+}
+
+// EventAdd 添加空间的事件通知规则，同名的规则已存在时更新规则
+func EventAdd(cfg *iqshell.Config, info EventAddInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	updated, err := bucket.PutEventRule(info.Bucket, &info.EventRule)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Put event rule:%s to bucket:%s error:%v", info.Name, info.Bucket, err)
+		return
+	}
+	if updated {
+		log.InfoF("Event rule:%s of bucket:%s exists, update success", info.Name, info.Bucket)
+	} else {
+		log.InfoF("Add event rule:%s to bucket:%s success", info.Name, info.Bucket)
+	}
+}
+
+type EventDeleteInfo struct {
+	Bucket string // 空间名 【必选】
+	Name   string // 规则名 【必选】
+}
+
+func (info *EventDeleteInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	if len(info.Name) == 0 {
+		return alert.CannotEmptyError("Name", "")
+	}
+	return nil
+}
+
+// EventDelete 删除空间的事件通知规则
+func EventDelete(cfg *iqshell.Config, info EventDeleteInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if err := bucket.DeleteEventRule(info.Bucket, info.Name); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Delete event rule:%s of bucket:%s error:%v", info.Name, info.Bucket, err)
+		return
+	}
+	log.InfoF("Delete event rule:%s of bucket:%s success", info.Name, info.Bucket)
+}
@@ -0,0 +1,145 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+)
+
+type LifecycleListInfo struct {
+	Bucket string // 空间名 【必选】
+}
+
+func (info *LifecycleListInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	return nil
+}
+
+// LifecycleList 以表格输出空间的生命周期规则
+func LifecycleList(cfg *iqshell.Config, info LifecycleListInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	rules, err := bucket.LifecycleRules(info.Bucket)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	if data.IsOutputFormatJson() {
+		for _, rule := range rules {
+			if bytes, mErr := json.Marshal(rule); mErr != nil {
+				log.ErrorF("marshal lifecycle rule:%s error:%v", rule.Name, mErr)
+			} else {
+				log.Alert(string(bytes))
+			}
+		}
+		return
+	}
+
+	if len(rules) == 0 {
+		log.WarningF("No lifecycle rules found for bucket `%s`", info.Bucket)
+		return
+	}
+	for _, line := range lifecycleTable(rules) {
+		log.Alert(line)
+	}
+}
+
+// lifecycleTable 每个规则一行，列宽按最长的规则名及前缀对齐；天数为 0 的列显示为 -
+func lifecycleTable(rules []*bucket.LifecycleRule) []string {
+	nameWidth, prefixWidth := len("Name"), len("Prefix")
+	for _, rule := range rules {
+		if len(rule.Name) > nameWidth {
+			nameWidth = len(rule.Name)
+		}
+		if len(rule.Prefix) > prefixWidth {
+			prefixWidth = len(rule.Prefix)
+		}
+	}
+
+	days := func(d int) string {
+		if d <= 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%d", d)
+	}
+	format := fmt.Sprintf("%%-%ds  %%-%ds  %%-4s  %%-11s  %%-9s  %%-13s  %%s", nameWidth, prefixWidth)
+	lines := []string{fmt.Sprintf(format, "Name", "Prefix", "ToIA", "ToArchiveIR", "ToArchive", "ToDeepArchive", "Delete")}
+	for _, rule := range rules {
+		lines = append(lines, fmt.Sprintf(format, rule.Name, rule.Prefix, days(rule.ToIAAfterDays),
+			days(rule.ToArchiveIRAfterDays), days(rule.ToArchiveAfterDays), days(rule.ToDeepArchiveAfterDays),
+			days(rule.DeleteAfterDays)))
+	}
+	return lines
+}
+
+type LifecycleAddInfo struct {
+	Bucket string // 空间名 【必选】
+	bucket.LifecycleRule
+}
+
+func (info *LifecycleAddInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	return info.LifecycleRule.Check()
+}
+
+// LifecycleAdd 添加空间的生命周期规则
+func LifecycleAdd(cfg *iqshell.Config, info LifecycleAddInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if err := bucket.AddLifecycleRule(info.Bucket, &info.LifecycleRule); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Add lifecycle rule:%s to bucket:%s error:%v", info.Name, info.Bucket, err)
+		return
+	}
+	log.InfoF("Add lifecycle rule:%s to bucket:%s success", info.Name, info.Bucket)
+}
+
+type LifecycleDeleteInfo struct {
+	Bucket string // 空间名 【必选】
+	Name   string // 规则名 【必选】
+}
+
+func (info *LifecycleDeleteInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	if len(info.Name) == 0 {
+		return alert.CannotEmptyError("Name", "")
+	}
+	return nil
+}
+
+// LifecycleDelete 删除空间的生命周期规则
+func LifecycleDelete(cfg *iqshell.Config, info LifecycleDeleteInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if err := bucket.DeleteLifecycleRule(info.Bucket, info.Name); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Delete lifecycle rule:%s of bucket:%s error:%v", info.Name, info.Bucket, err)
+		return
+	}
+	log.InfoF("Delete lifecycle rule:%s of bucket:%s success", info.Name, info.Bucket)
+}
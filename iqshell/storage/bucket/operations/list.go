@@ -3,6 +3,7 @@ package operations
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -25,15 +26,20 @@ type ListInfo struct {
 	StartDate          string // list item 的 put time 区间的开始时间 【闭区间】 【可选】
 	EndDate            string // list item 的 put time 区间的终止时间 【闭区间】 【可选】
 	Suffixes           string // list item 必须包含后缀 【可选】
+	ExcludeSuffixes    string // list item 不能包含后缀，多个使用逗号隔开 【可选】
+	ExcludeKeyRegex    string // list item 的 key 不能匹配此正则 【可选】
 	FileTypes          string // list item 存储类型，多个使用逗号隔开， 0:普通存储 1:低频存储 2:归档存储 3:深度归档存储 4:归档直读存储【可选】
 	MimeTypes          string // list item Mimetype类型，多个使用逗号隔开 【可选】
 	MinFileSize        string // 文件最小值，单位: B 【可选】
 	MaxFileSize        string // 文件最大值，单位: B 【可选】
+	Filter             string // 过滤表达式，如：size > 1m && mime == "image/jpeg"，参考 bucket.ParseListFilter 【可选】
 	MaxRetry           int    // -1: 无限重试 【可选】
 	SaveToFile         string // 【可选】
 	AppendMode         bool   // 【可选】
 	Readable           bool   // 【可选】
 	ShowFields         string // 需要展示的字段
+	Columns            string // 需要展示的字段，同 ShowFields，支持简称，如：key,size,hash,mime,putTime,type,endUser 【可选】
+	TimeFormat         string // PutTime 的时间格式，为 Go 的时间格式，如：2006-01-02 15:04:05，为空时输出原始值 【可选】
 	ApiVersion         string // list api 版本，v1 / v2【可选】
 	ApiLimit           int    // 每次请求 size ，当前仅支持 list v1 【可选】
 	OutputLimit        int    // 最大输出条数，默认：-1, 无限输出 【可选】
@@ -41,6 +47,12 @@ type ListInfo struct {
 	OutputFileMaxLines int64  // 输出文件的最大行数，超过则自动创建新的文件，0：不限制输出文件的行数 【可选】
 	OutputFileMaxSize  int64  // 输出文件的最大 Size，超过则自动创建新的文件，0：不限制输出文件的大小 【可选】
 	EnableRecord       bool   // 是否开启 record 记录，开启后会记录 list 信息，下次 list 会自动指定 Marker 继续 list 【可选】
+	ShardPrefixes      string // 分片前缀，多个使用逗号分隔，或 hex:N 表示所有长度为 N 的十六进制前缀；配置后按分片并发列举 【可选】
+	ShardWorkerCount   int    // 按分片并发列举时的并发数，默认：10 【可选】
+
+	shardPrefixes   []string
+	filter          *bucket.ListFilter
+	excludeKeyRegex *regexp.Regexp
 }
 
 func (info *ListInfo) Check() *data.CodeError {
@@ -57,14 +69,37 @@ func (info *ListInfo) Check() *data.CodeError {
 		return alert.Error("list bucket: api version is error, should set one of v1 and v2", "")
 	}
 
+	if len(info.Filter) > 0 {
+		if filter, err := bucket.ParseListFilter(info.Filter); err != nil {
+			return err
+		} else {
+			info.filter = filter
+		}
+	}
+
+	if len(info.ExcludeKeyRegex) > 0 {
+		if re, err := regexp.Compile(info.ExcludeKeyRegex); err != nil {
+			return alert.Error(fmt.Sprintf("list bucket: key-regex-not error:%v", err), "")
+		} else {
+			info.excludeKeyRegex = re
+		}
+	}
+
+	if len(info.Columns) > 0 {
+		if len(info.ShowFields) > 0 {
+			return alert.Error("list bucket: columns and show-fields can't be set at the same time", "")
+		}
+		info.ShowFields = info.Columns
+	}
+
 	if len(info.ShowFields) > 0 {
 		var fieldsNew []string
 		fields := info.getShowFields()
 		if len(fields) > 0 {
 			for _, field := range fields {
-				f := bucket.ListObjectField(field)
+				f := bucket.ListObjectField(strings.TrimSpace(field))
 				if len(f) == 0 {
-					return data.NewEmptyError().AppendDescF("show-fields value error:%s not support", field)
+					return data.NewEmptyError().AppendDescF("show-fields/columns value error:%s not support", field)
 				}
 				fieldsNew = append(fieldsNew, f)
 			}
@@ -72,6 +107,20 @@ func (info *ListInfo) Check() *data.CodeError {
 		info.ShowFields = strings.Join(fieldsNew, ",")
 	}
 
+	if len(info.ShardPrefixes) > 0 {
+		if info.EnableRecord || len(info.Marker) > 0 {
+			return alert.Error("list bucket: shard-prefixes can't be used with enable-record or marker", "")
+		}
+		if prefixes, err := bucket.ShardPrefixes(info.ShardPrefixes); err != nil {
+			return err
+		} else {
+			info.shardPrefixes = prefixes
+		}
+		if info.ShardWorkerCount <= 0 {
+			info.ShardWorkerCount = 10
+		}
+	}
+
 	if info.EnableRecord {
 		// 记录模式开启 append
 		info.AppendMode = true
@@ -100,6 +149,14 @@ func (info *ListInfo) getShowFields() []string {
 	return strings.Split(info.ShowFields, ",")
 }
 
+// getOutputFormat 全局选项 --format 为 csv 时按 CSV 格式输出
+func (info *ListInfo) getOutputFormat() string {
+	if data.IsOutputFormatCSV() {
+		return bucket.ListOutputFormatCSV
+	}
+	return bucket.ListOutputFormatTSV
+}
+
 func (info *ListInfo) getOutputFieldsSep() string {
 	if len(info.OutputFieldsSep) == 0 {
 		return "\t"
@@ -113,7 +170,8 @@ func List(cfg *iqshell.Config, info ListInfo) {
 	}
 
 	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
-		Checker: &info,
+		Checker:          &info,
+		SupportCSVOutput: true,
 	}); !shouldContinue {
 		return
 	}
@@ -143,10 +201,13 @@ func List(cfg *iqshell.Config, info ListInfo) {
 			StartTime:          startTime,
 			EndTime:            endTime,
 			Suffixes:           info.getSuffixes(),
+			ExcludeSuffixes:    splitListValues(info.ExcludeSuffixes),
+			ExcludeKeyRegex:    info.excludeKeyRegex,
 			FileTypes:          info.getFileTypes(),
 			MimeTypes:          info.getMimeTypes(),
 			MinFileSize:        info.getMinFileSize(),
 			MaxFileSize:        info.getMaxFileSize(),
+			Filter:             info.filter,
 			MaxRetry:           info.MaxRetry,
 			ShowFields:         info.getShowFields(),
 			ApiVersion:         info.ApiVersion,
@@ -161,6 +222,11 @@ func List(cfg *iqshell.Config, info ListInfo) {
 		FilePath:   info.SaveToFile,
 		AppendMode: info.AppendMode,
 		Readable:   info.Readable,
+		Format:     info.getOutputFormat(),
+		TimeFormat: info.TimeFormat,
+
+		ShardPrefixes:    info.shardPrefixes,
+		ShardWorkerCount: info.ShardWorkerCount,
 	}, func(marker string, err *data.CodeError) {
 		data.SetCmdStatus(data.StatusError)
 		log.ErrorF("marker: %s", marker)
@@ -198,12 +264,16 @@ func (info *ListInfo) getEndDate() (time.Time, *data.CodeError) {
 }
 
 func (info *ListInfo) getSuffixes() []string {
+	return splitListValues(info.Suffixes)
+}
+
+// splitListValues 按逗号分隔，去掉空白及空值
+func splitListValues(values string) []string {
 	ret := make([]string, 0)
-	suffixes := strings.Split(info.Suffixes, ",")
-	for _, s := range suffixes {
+	for _, s := range strings.Split(values, ",") {
 		s = strings.TrimSpace(s)
 		if len(s) > 0 {
-			ret = append(ret, strings.TrimSpace(s))
+			ret = append(ret, s)
 		}
 	}
 	return ret
@@ -0,0 +1,30 @@
+package operations
+
+import (
+	"testing"
+)
+
+func TestListInfoCheckExcludeFilters(t *testing.T) {
+	info := &ListInfo{
+		Bucket:          "bucket",
+		ExcludeSuffixes: " .done, ,.tmp ",
+		ExcludeKeyRegex: `^tmp/`,
+	}
+	if err := info.Check(); err != nil {
+		t.Fatalf("check error:%v", err)
+	}
+	if suffixes := splitListValues(info.ExcludeSuffixes); len(suffixes) != 2 || suffixes[0] != ".done" || suffixes[1] != ".tmp" {
+		t.Fatalf("exclude suffixes should be [.done .tmp], but:%v", suffixes)
+	}
+	if info.excludeKeyRegex == nil || !info.excludeKeyRegex.MatchString("tmp/a.jpg") {
+		t.Fatal("exclude key regex should be compiled")
+	}
+
+	info = &ListInfo{
+		Bucket:          "bucket",
+		ExcludeKeyRegex: `(`,
+	}
+	if err := info.Check(); err == nil {
+		t.Fatal("check should fail with invalid key-regex-not")
+	}
+}
@@ -0,0 +1,93 @@
+package operations
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+)
+
+type RefererGetInfo struct {
+	Bucket string // 空间名 【必选】
+}
+
+func (info *RefererGetInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	return nil
+}
+
+// RefererGet 输出空间的 Referer 防盗链配置，明确提示是否允许空 Referer 访问
+func RefererGet(cfg *iqshell.Config, info RefererGetInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	config, err := bucket.GetRefererConfig(info.Bucket)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	if data.IsOutputFormatJson() {
+		if bytes, mErr := json.Marshal(config); mErr != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("marshal referer config error:%v", mErr)
+		} else {
+			log.Alert(string(bytes))
+		}
+		return
+	}
+
+	log.AlertF("%-20s%s", "Mode:", config.Mode)
+	if config.Mode == bucket.RefererModeOff {
+		return
+	}
+	log.AlertF("%-20s%s", "Patterns:", strings.Join(config.Patterns, ";"))
+	if config.AllowEmptyReferer {
+		log.AlertF("%-20s%s", "AllowEmptyReferer:", "yes, requests without Referer are ALLOWED")
+	} else {
+		log.AlertF("%-20s%s", "AllowEmptyReferer:", "no, requests without Referer are DENIED")
+	}
+	log.AlertF("%-20s%v", "SourceEnabled:", config.SourceEnabled)
+}
+
+type RefererSetInfo struct {
+	Bucket string // 空间名 【必选】
+	bucket.RefererConfig
+}
+
+func (info *RefererSetInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	return info.RefererConfig.Check()
+}
+
+// RefererSet 设置空间的 Referer 防盗链配置
+func RefererSet(cfg *iqshell.Config, info RefererSetInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if err := bucket.SetRefererConfig(info.Bucket, &info.RefererConfig); err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Set referer of bucket:%s error:%v", info.Bucket, err)
+		return
+	}
+	if info.Mode == bucket.RefererModeOff {
+		log.InfoF("Turn off referer anti-leech of bucket:%s success", info.Bucket)
+	} else {
+		log.InfoF("Set referer %s of bucket:%s success, allow empty referer:%v", info.Mode, info.Bucket, info.AllowEmptyReferer)
+	}
+}
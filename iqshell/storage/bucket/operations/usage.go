@@ -0,0 +1,212 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+)
+
+// UsageRootPrefix 未指定 --prefix 时，直接位于空间根目录下的文件所属的分组
+const UsageRootPrefix = "."
+
+var usageStorageTypes = []string{"STANDARD", "IA", "ARCHIVE", "DEEP_ARCHIVE", "ARCHIVE_IR"}
+
+func usageStorageTypeName(fileType int) string {
+	if fileType >= 0 && fileType < len(usageStorageTypes) {
+		return usageStorageTypes[fileType]
+	}
+	return fmt.Sprintf("TYPE_%d", fileType)
+}
+
+type UsageInfo struct {
+	Bucket   string // 空间名 【必选】
+	Prefix   string // 仅统计此前缀下的文件 【可选】
+	Depth    int    // 按 key 中 / 分隔的前缀分组的深度，0 表示不分组 【可选】
+	MaxRetry int    // 列举出错时的最大重试次数，-1: 无限重试 【可选】
+	Readable bool   // 文件大小以人工可读的方式展示 【可选】
+}
+
+func (info *UsageInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	if info.Depth < 0 {
+		return alert.Error("group by prefix depth can't be less than 0", "")
+	}
+	return nil
+}
+
+// UsageStat 一个前缀分组的统计信息
+type UsageStat struct {
+	Prefix string                  `json:"prefix"`
+	Count  int64                   `json:"count"`
+	Size   int64                   `json:"size"`
+	Types  map[string]*UsageCounts `json:"types"` // 按存储类型统计
+}
+
+type UsageCounts struct {
+	Count int64 `json:"count"`
+	Size  int64 `json:"size"`
+}
+
+func (s *UsageStat) add(object *bucket.ListObject) {
+	s.Count++
+	s.Size += object.Fsize
+	typeName := usageStorageTypeName(object.Type)
+	counts := s.Types[typeName]
+	if counts == nil {
+		counts = &UsageCounts{}
+		s.Types[typeName] = counts
+	}
+	counts.Count++
+	counts.Size += object.Fsize
+}
+
+// usagePrefixOfKey 文件所属的分组：去除 prefix 后取前 depth 级目录，目录层级不足时取文件所在的目录
+func usagePrefixOfKey(prefix string, key string, depth int) string {
+	relativeKey := strings.TrimPrefix(key, prefix)
+	groupEnd := 0
+	for i := 0; i < depth; i++ {
+		index := strings.Index(relativeKey[groupEnd:], "/")
+		if index < 0 {
+			break
+		}
+		groupEnd += index + 1
+	}
+
+	group := prefix + relativeKey[:groupEnd]
+	if len(group) == 0 {
+		return UsageRootPrefix
+	}
+	return group
+}
+
+// usageAggregator 按前缀分组累加统计信息，仅保存分组的统计信息，不保存文件列表
+type usageAggregator struct {
+	prefix string
+	depth  int
+	total  *UsageStat
+	groups map[string]*UsageStat
+}
+
+func newUsageAggregator(prefix string, depth int) *usageAggregator {
+	return &usageAggregator{
+		prefix: prefix,
+		depth:  depth,
+		total:  &UsageStat{Prefix: "TOTAL", Types: make(map[string]*UsageCounts)},
+		groups: make(map[string]*UsageStat),
+	}
+}
+
+func (a *usageAggregator) add(object *bucket.ListObject) {
+	a.total.add(object)
+	if a.depth <= 0 {
+		return
+	}
+
+	group := usagePrefixOfKey(a.prefix, object.Key, a.depth)
+	stat := a.groups[group]
+	if stat == nil {
+		stat = &UsageStat{Prefix: group, Types: make(map[string]*UsageCounts)}
+		a.groups[group] = stat
+	}
+	stat.add(object)
+}
+
+// sortedGroups 按总大小从大到小排序，大小相同时按前缀排序
+func (a *usageAggregator) sortedGroups() []*UsageStat {
+	groups := make([]*UsageStat, 0, len(a.groups))
+	for _, stat := range a.groups {
+		groups = append(groups, stat)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Size != groups[j].Size {
+			return groups[i].Size > groups[j].Size
+		}
+		return groups[i].Prefix < groups[j].Prefix
+	})
+	return groups
+}
+
+// Usage 列举空间，按前缀及存储类型统计文件数及总大小，类似于 du
+func Usage(cfg *iqshell.Config, info UsageInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	aggregator := newUsageAggregator(info.Prefix, info.Depth)
+	listErr := bucket.List(bucket.ListApiInfo{
+		Bucket:   info.Bucket,
+		Prefix:   info.Prefix,
+		MaxRetry: info.MaxRetry,
+	}, func(marker string, object bucket.ListObject) (bool, *data.CodeError) {
+		aggregator.add(&object)
+		if aggregator.total.Count%100000 == 0 {
+			log.InfoF("bucket usage, %d files have been counted", aggregator.total.Count)
+		}
+		return true, nil
+	}, func(marker string, err *data.CodeError) {
+		log.ErrorF("list bucket error, marker:%s error:%v", marker, err)
+	})
+
+	stats := aggregator.sortedGroups()
+	stats = append(stats, aggregator.total)
+	for _, stat := range stats {
+		outputUsageStat(stat, info.Readable)
+	}
+
+	if listErr != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("list bucket:%s error, the usage is incomplete, error:%v", info.Bucket, listErr)
+	}
+}
+
+func outputUsageStat(stat *UsageStat, readable bool) {
+	if data.IsOutputFormatJson() {
+		if bytes, err := json.Marshal(stat); err != nil {
+			log.ErrorF("marshal usage of prefix:%s error:%v", stat.Prefix, err)
+		} else {
+			log.Alert(string(bytes))
+		}
+		return
+	}
+
+	formatSize := func(size int64) string {
+		if readable {
+			return utils.FormatFileSize(size)
+		}
+		return fmt.Sprintf("%d", size)
+	}
+
+	typeNames := make([]string, 0, len(stat.Types))
+	for typeName := range stat.Types {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Slice(typeNames, func(i, j int) bool {
+		return usageStorageTypeIndex(typeNames[i]) < usageStorageTypeIndex(typeNames[j])
+	})
+	for _, typeName := range typeNames {
+		counts := stat.Types[typeName]
+		log.AlertF("%s\t%s\t%d\t%s", stat.Prefix, typeName, counts.Count, formatSize(counts.Size))
+	}
+	log.AlertF("%s\t%s\t%d\t%s", stat.Prefix, "ALL", stat.Count, formatSize(stat.Size))
+}
+
+func usageStorageTypeIndex(typeName string) int {
+	for i, name := range usageStorageTypes {
+		if name == typeName {
+			return i
+		}
+	}
+	return len(usageStorageTypes)
+}
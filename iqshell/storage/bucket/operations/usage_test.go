@@ -0,0 +1,53 @@
+package operations
+
+import (
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+)
+
+func TestUsagePrefixOfKey(t *testing.T) {
+	cases := []struct {
+		prefix string
+		key    string
+		depth  int
+		group  string
+	}{
+		{"", "a/b/c.jpg", 1, "a/"},
+		{"", "a/b/c.jpg", 2, "a/b/"},
+		{"", "a/b/c.jpg", 3, "a/b/"},
+		{"", "d.jpg", 1, UsageRootPrefix},
+		{"p/", "p/a/b.jpg", 1, "p/a/"},
+		{"p/", "p/b.jpg", 1, "p/"},
+		{"p", "p/a/b.jpg", 1, "p/"},
+	}
+	for _, c := range cases {
+		if group := usagePrefixOfKey(c.prefix, c.key, c.depth); group != c.group {
+			t.Fatalf("prefix:%s key:%s depth:%d, group:%s expected:%s", c.prefix, c.key, c.depth, group, c.group)
+		}
+	}
+}
+
+func TestUsageAggregator(t *testing.T) {
+	aggregator := newUsageAggregator("", 1)
+	for _, object := range []bucket.ListObject{
+		{Key: "a/1", Fsize: 10, Type: 0},
+		{Key: "a/2", Fsize: 20, Type: 1},
+		{Key: "b/1", Fsize: 50, Type: 0},
+		{Key: "c", Fsize: 5, Type: 2},
+	} {
+		object := object
+		aggregator.add(&object)
+	}
+
+	groups := aggregator.sortedGroups()
+	if len(groups) != 3 || groups[0].Prefix != "b/" || groups[1].Prefix != "a/" || groups[2].Prefix != UsageRootPrefix {
+		t.Fatalf("groups order error:%+v", groups)
+	}
+	if a := groups[1]; a.Count != 2 || a.Size != 30 || a.Types["STANDARD"].Size != 10 || a.Types["IA"].Count != 1 {
+		t.Fatalf("group a/ stat error:%+v", a)
+	}
+	if total := aggregator.total; total.Count != 4 || total.Size != 85 || total.Types["ARCHIVE"].Size != 5 {
+		t.Fatalf("total stat error:%+v", total)
+	}
+}
@@ -0,0 +1,132 @@
+package bucket
+
+import (
+	"strings"
+
+	"github.com/qiniu/go-sdk/v7/storagev2/apis"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// Referer 防盗链模式
+const (
+	RefererModeOff       = "off"
+	RefererModeWhitelist = "whitelist"
+	RefererModeBlacklist = "blacklist"
+)
+
+var refererModes = []string{RefererModeOff, RefererModeWhitelist, RefererModeBlacklist}
+
+// RefererConfig 空间的 Referer 防盗链配置
+type RefererConfig struct {
+	Mode              string   `json:"mode"`
+	Patterns          []string `json:"patterns"`
+	AllowEmptyReferer bool     `json:"allow_empty_referer"`
+	SourceEnabled     bool     `json:"source_enabled"` // 是否同时开启源站的防盗链，否则仅对 CDN 生效
+}
+
+func (c *RefererConfig) Check() *data.CodeError {
+	modeCode := refererModeCode(c.Mode)
+	if modeCode < 0 {
+		return alert.Error("invalid referer mode: "+c.Mode, "mode should be one of "+strings.Join(refererModes, ", "))
+	}
+	if modeCode == 0 {
+		return nil
+	}
+	if len(c.Patterns) == 0 {
+		return alert.CannotEmptyError("patterns", "patterns are required when mode is "+c.Mode)
+	}
+	for _, pattern := range c.Patterns {
+		if !isRefererPatternValid(pattern) {
+			return alert.Error("invalid referer pattern: "+pattern, "pattern should be a domain like foo.com, a wildcard domain like *.foo.com or *")
+		}
+	}
+	return nil
+}
+
+func refererModeCode(mode string) int64 {
+	for i, m := range refererModes {
+		if m == mode {
+			return int64(i)
+		}
+	}
+	return -1
+}
+
+func refererModeName(code int) string {
+	if code >= 0 && code < len(refererModes) {
+		return refererModes[code]
+	}
+	return RefererModeOff
+}
+
+// isRefererPatternValid 规则为 *、泛域名 *.foo.com 或域名 foo.com
+func isRefererPatternValid(pattern string) bool {
+	if pattern == "*" {
+		return true
+	}
+	domain := strings.TrimPrefix(pattern, "*.")
+	if len(domain) == 0 || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") || strings.Contains(domain, "..") {
+		return false
+	}
+	for _, c := range domain {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == ':') {
+			return false
+		}
+	}
+	return true
+}
+
+// GetRefererConfig 获取空间的 Referer 防盗链配置
+func GetRefererConfig(bucket string) (*RefererConfig, *data.CodeError) {
+	info, err := GetBucketInfo(GetBucketApiInfo{Bucket: bucket})
+	if err != nil {
+		return nil, err
+	}
+
+	config := &RefererConfig{
+		Mode:              refererModeName(info.AntiLeechMode),
+		AllowEmptyReferer: info.NoRefer,
+		SourceEnabled:     info.EnableSource,
+	}
+	switch config.Mode {
+	case RefererModeWhitelist:
+		config.Patterns = info.ReferWl
+	case RefererModeBlacklist:
+		config.Patterns = info.ReferBl
+	}
+	if config.Patterns == nil {
+		config.Patterns = []string{}
+	}
+	return config, nil
+}
+
+// SetRefererConfig 设置空间的 Referer 防盗链配置；模式为 off 时关闭防盗链并恢复默认配置
+func SetRefererConfig(bucket string, config *RefererConfig) *data.CodeError {
+	if err := config.Check(); err != nil {
+		return err
+	}
+
+	storageClient, err := GetStorageV2()
+	if err != nil {
+		return err
+	}
+
+	request := &apis.SetBucketReferAntiLeechRequest{
+		Bucket: bucket,
+		Mode:   refererModeCode(config.Mode),
+	}
+	if request.Mode != 0 {
+		request.Pattern = strings.Join(config.Patterns, ";")
+		if config.AllowEmptyReferer {
+			request.AllowEmptyReferer = 1
+		}
+		if config.SourceEnabled {
+			request.SourceEnabled = 1
+		}
+	}
+	_, sErr := storageClient.SetBucketReferAntiLeech(workspace.GetContext(), request, nil)
+	return data.ConvertError(sErr)
+}
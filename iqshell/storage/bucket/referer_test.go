@@ -0,0 +1,24 @@
+package bucket
+
+import "testing"
+
+func TestRefererConfigCheck(t *testing.T) {
+	for _, c := range []struct {
+		config RefererConfig
+		valid  bool
+	}{
+		{config: RefererConfig{Mode: RefererModeWhitelist, Patterns: []string{"example.com", "*.foo.com", "*"}}, valid: true},
+		{config: RefererConfig{Mode: RefererModeBlacklist, Patterns: []string{"bad.com:8080"}}, valid: true},
+		{config: RefererConfig{Mode: RefererModeOff}, valid: true},
+		{config: RefererConfig{Mode: "white"}, valid: false},
+		{config: RefererConfig{Mode: RefererModeWhitelist}, valid: false},
+		{config: RefererConfig{Mode: RefererModeWhitelist, Patterns: []string{"http://example.com"}}, valid: false},
+		{config: RefererConfig{Mode: RefererModeWhitelist, Patterns: []string{"foo.*.com"}}, valid: false},
+		{config: RefererConfig{Mode: RefererModeWhitelist, Patterns: []string{"*."}}, valid: false},
+		{config: RefererConfig{Mode: RefererModeWhitelist, Patterns: []string{""}}, valid: false},
+	} {
+		if err := c.config.Check(); (err == nil) != c.valid {
+			t.Fatalf("check config:%+v error, err:%v expected valid:%v", c.config, err, c.valid)
+		}
+	}
+}
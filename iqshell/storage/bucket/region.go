@@ -4,16 +4,16 @@ import (
 	"github.com/qiniu/go-sdk/v7/storage"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 )
 
+// Region 获取空间所在的区域，参考 workspace.GetBucketRegion
 func Region(b string) (*storage.Zone, *data.CodeError) {
-	bucketManager, err := GetBucketManager()
-	if err != nil {
-		return nil, err
+	region := workspace.GetBucketRegion(b)
+	if region == nil {
+		return nil, data.NewEmptyError().AppendDescF("can't get region of bucket:%s", b)
 	}
-
-	region, e := bucketManager.Zone(b)
-	return region, data.ConvertError(e)
+	return region, nil
 }
 
 func CompleteBucketManagerRegion(bucketManager *storage.BucketManager, bucket string) *data.CodeError {
@@ -29,9 +29,9 @@ func CompleteBucketManagerRegion(bucketManager *storage.BucketManager, bucket st
 		return nil
 	}
 
-	region, e := storage.GetZone(bucketManager.Mac.AccessKey, bucket)
-	if e != nil {
-		return data.ConvertError(e)
+	region, err := Region(bucket)
+	if err != nil {
+		return err
 	}
 	bucketManager.Cfg.CentralRsHost = utils.RemoveUrlScheme(region.RsHost)
 	bucketManager.Cfg.Region = region
@@ -0,0 +1,173 @@
+package object
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/client"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// AvInfoFop 获取音视频元信息的处理指令
+const AvInfoFop = "avinfo"
+
+// AvInfoNotMedia 文件不是音视频时 AvInfoResult.Error 的值
+const AvInfoNotMedia = "not a media file"
+
+type AvInfoResult struct {
+	Source   string          `json:"source"` // bucket:key 或 url
+	IsMedia  bool            `json:"is_media"`
+	Format   string          `json:"format,omitempty"`
+	Duration float64         `json:"duration,omitempty"` // 单位：秒
+	BitRate  int64           `json:"bit_rate,omitempty"` // 单位：bps
+	Size     int64           `json:"size,omitempty"`
+	Video    *AvVideoStream  `json:"video,omitempty"`
+	Audio    *AvAudioStream  `json:"audio,omitempty"`
+	Error    string          `json:"error,omitempty"` // 不是音视频时的说明
+	Raw      json.RawMessage `json:"-"`               // avinfo 返回的原始内容
+}
+
+// AvVideoStream 第一个视频流的信息
+type AvVideoStream struct {
+	Codec     string  `json:"codec"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	BitRate   int64   `json:"bit_rate,omitempty"`
+	FrameRate float64 `json:"frame_rate,omitempty"`
+}
+
+// AvAudioStream 第一个音频流的信息
+type AvAudioStream struct {
+	Codec      string `json:"codec"`
+	SampleRate int    `json:"sample_rate,omitempty"`
+	Channels   int    `json:"channels,omitempty"`
+	BitRate    int64  `json:"bit_rate,omitempty"`
+}
+
+var _ flow.Result = (*AvInfoResult)(nil)
+
+func (r *AvInfoResult) IsValid() bool {
+	return len(r.Source) > 0
+}
+
+// avInfoRet avinfo 的返回，数值字段多以字符串返回
+type avInfoRet struct {
+	Streams []struct {
+		CodecType  string `json:"codec_type"`
+		CodecName  string `json:"codec_name"`
+		Width      int    `json:"width"`
+		Height     int    `json:"height"`
+		BitRate    string `json:"bit_rate"`
+		FrameRate  string `json:"avg_frame_rate"`
+		SampleRate string `json:"sample_rate"`
+		Channels   int    `json:"channels"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		BitRate    string `json:"bit_rate"`
+		Size       string `json:"size"`
+	} `json:"format"`
+}
+
+// parseFrameRate 解析形如 25/1 的帧率
+func parseFrameRate(rate string) float64 {
+	items := strings.Split(rate, "/")
+	value, _ := strconv.ParseFloat(items[0], 64)
+	if len(items) != 2 {
+		return value
+	}
+	den, _ := strconv.ParseFloat(items[1], 64)
+	if den == 0 {
+		return 0
+	}
+	return value / den
+}
+
+// parseAvInfo 解析 avinfo 的返回，没有音视频流时返回 IsMedia 为 false 的结果
+func parseAvInfo(source string, body []byte) *AvInfoResult {
+	result := &AvInfoResult{
+		Source: source,
+		Raw:    body,
+	}
+
+	ret := &avInfoRet{}
+	if e := json.Unmarshal(body, ret); e != nil {
+		result.Error = AvInfoNotMedia
+		return result
+	}
+	for _, stream := range ret.Streams {
+		bitRate, _ := strconv.ParseInt(stream.BitRate, 10, 64)
+		switch stream.CodecType {
+		case "video":
+			if result.Video == nil {
+				result.Video = &AvVideoStream{
+					Codec:     stream.CodecName,
+					Width:     stream.Width,
+					Height:    stream.Height,
+					BitRate:   bitRate,
+					FrameRate: parseFrameRate(stream.FrameRate),
+				}
+			}
+		case "audio":
+			if result.Audio == nil {
+				sampleRate, _ := strconv.Atoi(stream.SampleRate)
+				result.Audio = &AvAudioStream{
+					Codec:      stream.CodecName,
+					SampleRate: sampleRate,
+					Channels:   stream.Channels,
+					BitRate:    bitRate,
+				}
+			}
+		}
+	}
+	if result.Video == nil && result.Audio == nil {
+		result.Error = AvInfoNotMedia
+		return result
+	}
+
+	result.IsMedia = true
+	result.Format = ret.Format.FormatName
+	result.Duration, _ = strconv.ParseFloat(ret.Format.Duration, 64)
+	result.BitRate, _ = strconv.ParseInt(ret.Format.BitRate, 10, 64)
+	result.Size, _ = strconv.ParseInt(ret.Format.Size, 10, 64)
+	return result
+}
+
+// AvInfo 请求 avInfoUrl（已包含 avinfo 处理指令及签名）获取音视频信息；
+// 文件不是音视频时不返回错误，返回 IsMedia 为 false 的结果
+func AvInfo(source string, avInfoUrl string) (*AvInfoResult, *data.CodeError) {
+	if len(avInfoUrl) == 0 {
+		return nil, alert.CannotEmptyError("url", "")
+	}
+
+	resp, err := client.DefaultStorageClient().DoRequest(workspace.GetContext(), "GET", avInfoUrl, nil)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("get av info of %s error:%v", source, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("read av info of %s error:%v", source, err)
+	}
+
+	// 对非音视频文件执行 avinfo 时，服务端返回格式不支持的错误
+	if isFopUnsupportedFormat(resp.StatusCode, body) {
+		return &AvInfoResult{
+			Source: source,
+			Error:  AvInfoNotMedia,
+			Raw:    body,
+		}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, data.NewError(resp.StatusCode, "get av info of "+source+" error:"+strings.TrimSpace(string(body)))
+	}
+	return parseAvInfo(source, body), nil
+}
@@ -0,0 +1,60 @@
+package object
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAvInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != AvInfoFop {
+			t.Errorf("query error, query:%s", r.URL.RawQuery)
+		}
+		switch r.URL.Path {
+		case "/a.mp4":
+			_, _ = w.Write([]byte(`{"streams":[{"codec_type":"video","codec_name":"h264","width":1280,"height":720,"bit_rate":"1100000","avg_frame_rate":"30000/1001"},` +
+				`{"codec_type":"audio","codec_name":"aac","sample_rate":"44100","channels":2,"bit_rate":"128000"}],` +
+				`"format":{"format_name":"mov,mp4,m4a,3gp,3g2,mj2","duration":"10.010000","bit_rate":"1235000","size":"1545312"}}`))
+		case "/a.txt":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"unsupported format"}`))
+		case "/c.mp4":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid argument"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	result, err := AvInfo("a.mp4", FopUrl(server.URL+"/a.mp4", AvInfoFop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsMedia || result.Duration != 10.01 || result.BitRate != 1235000 || result.Size != 1545312 {
+		t.Fatalf("av info error, result:%+v", result)
+	}
+	if v := result.Video; v == nil || v.Codec != "h264" || v.Width != 1280 || v.Height != 720 || v.FrameRate < 29.97 || v.FrameRate > 29.98 {
+		t.Fatalf("video info error, video:%+v", v)
+	}
+	if a := result.Audio; a == nil || a.Codec != "aac" || a.SampleRate != 44100 || a.Channels != 2 || a.BitRate != 128000 {
+		t.Fatalf("audio info error, audio:%+v", a)
+	}
+
+	result, err = AvInfo("a.txt", FopUrl(server.URL+"/a.txt", AvInfoFop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsMedia || result.Error != AvInfoNotMedia {
+		t.Fatalf("not media result error, result:%+v", result)
+	}
+
+	if _, err = AvInfo("c.mp4", FopUrl(server.URL+"/c.mp4", AvInfoFop)); err == nil || err.Code != http.StatusBadRequest {
+		t.Fatalf("other bad request should fail with 400, err:%v", err)
+	}
+
+	if _, err = AvInfo("b.mp4", FopUrl(server.URL+"/b.mp4", AvInfoFop)); err == nil || err.Code != http.StatusNotFound {
+		t.Fatalf("missing file should fail with 404, err:%v", err)
+	}
+}
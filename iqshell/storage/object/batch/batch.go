@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/qiniu/go-sdk/v7/storage"
+
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
@@ -24,15 +26,26 @@ type Info struct {
 	Overwrite bool // 是否覆盖
 
 	// 工作数据源
-	WorkList      []flow.Work // 工作数据源：列表
-	InputFile     string      // 工作数据源：文件
-	ItemSeparate  string      // 工作数据源：每行元素按分隔符分的分隔符
-	MinItemsCount int         // 工作数据源：每行元素最小数量
-	EnableStdin   bool        // 工作数据源：stdin, 当 InputFile 不存在时使用 stdin
-
-	EnableRecord             bool // 是否开启 record
-	RecordRedoWhileError     bool // 重新执行任务时，如果任务已执行但是失败，则再重新执行一次。
-	OperationCountPerRequest int  // 每批操作最大的子任务数
+	WorkList      []flow.Work       // 工作数据源：列表
+	WorkProvider  flow.WorkProvider // 工作数据源：自定义，如：边列举边处理，work 需为 Operation
+	InputFile     string            // 工作数据源：文件
+	ItemSeparate  string            // 工作数据源：每行元素按分隔符分的分隔符
+	MinItemsCount int               // 工作数据源：每行元素最小数量
+	EnableStdin   bool              // 工作数据源：stdin, 当 InputFile 不存在时使用 stdin
+
+	InputFormat    string   // 工作数据源：输入文件的格式：auto、tsv、csv、json，为空时为 auto
+	InputColumns   []string // 工作数据源：输入文件每列对应的字段名，- 表示忽略该列，为空时按命令默认的列顺序
+	InputHasHeader bool     // 工作数据源：输入文件的首行是否为标题行，未指定 InputColumns 时按标题行中的列名映射
+
+	ConfirmThreshold int64  // 非强制执行时，work 数超过此值或 work 数未知时，还需用户输入 ConfirmName 确认，0：不需要
+	ConfirmName      string // work 数超过 ConfirmThreshold 时用户需要输入的名称，如：空间名
+
+	EnableRecord             bool  // 是否开启 record
+	RecordRedoWhileError     bool  // 重新执行任务时，如果任务已执行但是失败，则再重新执行一次。
+	OperationCountPerRequest int   // 每批操作最大的子任务数
+	DryRun                   bool  // 仅预览将要执行的操作，不实际执行；已完成的记录仍会被查询但不会被修改
+	Validate                 bool  // 仅校验输入文件，使用和执行时相同的解析逻辑检查每一行，不发送请求，也不读写任务记录
+	WorkTotalSize            int64 // 所有操作涉及文件的总大小，用于确认及展示进度，未知时为 0
 }
 
 func (info *Info) Check() *data.CodeError {
@@ -40,6 +53,10 @@ func (info *Info) Check() *data.CodeError {
 		return err
 	}
 
+	if err := flow.CheckInputFormat(info.InputFormat); err != nil {
+		return err
+	}
+
 	if info.MinItemsCount < 1 {
 		info.MinItemsCount = 1
 	}
@@ -56,9 +73,34 @@ func (info *Info) Check() *data.CodeError {
 	return nil
 }
 
+// NewFlow 创建 flow，输入文件按 InputFormat、InputColumns、InputHasHeader 解析
+func (info *Info) NewFlow() *flow.WorkProvideBuilder {
+	return flow.New(info.Info).ItemsInput(info.InputFormat, info.InputColumns, info.InputHasHeader)
+}
+
+// userConfirm work 数超过 ConfirmThreshold 或 work 数未知时，要求用户输入 ConfirmName 确认
+func (info *Info) userConfirm(workTotalCount int64) bool {
+	if info.ConfirmThreshold <= 0 || len(info.ConfirmName) == 0 {
+		return true
+	}
+	if workTotalCount != flow.UnknownWorkCount && workTotalCount <= info.ConfirmThreshold {
+		return true
+	}
+	return flow.UserNameVerification(info.ConfirmName)
+}
+
+// MaxLimitWorkerCount 开启 worker 数动态调整时，限制数需按最大 worker 数计算
+func (info *Info) MaxLimitWorkerCount() int {
+	if info.MaxWorkerCount > info.WorkerCount {
+		return info.MaxWorkerCount
+	}
+	return info.WorkerCount
+}
+
 type Handler interface {
 	EmptyOperation(emptyOperation func() flow.Work) Handler
 	SetFileExport(exporter *export.FileExporter) Handler
+	ItemFields(requiredCount int, fields ...string) Handler
 	ItemsToOperation(func(items []string) (operation Operation, err *data.CodeError)) Handler
 	OnResult(func(operationInfo string, operation Operation, result *OperationResult)) Handler
 	OnError(func(err *data.CodeError)) Handler
@@ -77,6 +119,8 @@ type handler struct {
 	info                  *Info
 	emptyOperation        func() flow.Work
 	exporter              *export.FileExporter
+	itemFields            []string // 每行元素按顺序对应的字段名，用于列映射（--columns、--has-header）
+	requiredItemCount     int      // itemFields 中前 requiredItemCount 个为必须的字段
 	operationItemsCreator func(items []string) (operation Operation, err *data.CodeError)
 	onError               func(err *data.CodeError)
 	onResult              func(operationInfo string, operation Operation, result *OperationResult)
@@ -92,6 +136,13 @@ func (h *handler) SetFileExport(exporter *export.FileExporter) Handler {
 	return h
 }
 
+// ItemFields 每行元素按顺序对应的字段名，前 requiredCount 个为必须的字段
+func (h *handler) ItemFields(requiredCount int, fields ...string) Handler {
+	h.itemFields = fields
+	h.requiredItemCount = requiredCount
+	return h
+}
+
 func (h *handler) ItemsToOperation(reader func(items []string) (operation Operation, err *data.CodeError)) Handler {
 	h.operationItemsCreator = reader
 	return h
@@ -107,10 +158,29 @@ func (h *handler) OnError(handler func(err *data.CodeError)) Handler {
 	return h
 }
 
+// newWorkCreator 把输入文件的每一行转为 Operation，执行和校验（Validate）使用相同的逻辑
+func (h *handler) newWorkCreator() flow.WorkCreator {
+	return flow.NewItemsWorkCreatorWithFields(h.info.ItemSeparate, h.itemFields, h.requiredItemCount, h.info.MinItemsCount,
+		func(items []string) (work flow.Work, err *data.CodeError) {
+			return h.operationItemsCreator(items)
+		})
+}
+
 func (h *handler) Start() {
+	if h.info.Validate {
+		h.validate()
+		return
+	}
+
+	if h.info.DryRun {
+		// dry run 不会修改数据，无需确认
+		h.info.Force = true
+	}
+
 	isArraySource := h.info.WorkList != nil && len(h.info.WorkList) > 0
 	if !isArraySource {
 		if e := locker.TryLock(); e != nil {
+			data.SetCmdStatusError()
 			log.ErrorF("batch job, %v", e)
 			return
 		}
@@ -120,36 +190,40 @@ func (h *handler) Start() {
 				log.ErrorF("batch job, %v", e)
 			}
 		}
-		workspace.AddCancelObserver(func(s os.Signal) {
+		defer workspace.AddCancelObserver(func(s os.Signal) {
 			unlockHandler()
-		})
+		})()
 		defer unlockHandler()
 	}
 
-	bucketManager, err := bucket.GetBucketManager()
-	if err != nil {
-		h.onError(err)
-		return
+	// dry run 不发送请求，无需账号信息
+	var bucketManager *storage.BucketManager
+	if !h.info.DryRun {
+		var err *data.CodeError
+		if bucketManager, err = bucket.GetBucketManager(); err != nil {
+			data.SetCmdStatusError()
+			h.onError(err)
+			return
+		}
 	}
 
-	workBuilder := flow.New(h.info.Info)
+	workBuilder := h.info.NewFlow()
 	var workerBuilder *flow.WorkerProvideBuilder
 	if isArraySource {
 		workerBuilder = workBuilder.WorkProviderWithArray(h.info.WorkList)
+	} else if h.info.WorkProvider != nil {
+		workerBuilder = workBuilder.WorkProvider(h.info.WorkProvider)
 	} else {
 		log.DebugF("forceFlag: %v, overwriteFlag: %v, worker: %v, inputFile: %q, successFilePath: %q, failureFilePath: %q, sep: %q",
 			h.info.Force, h.info.Overwrite, h.info.WorkerCount, h.info.InputFile, h.info.SuccessExportFilePath, h.info.FailExportFilePath, h.info.ItemSeparate)
 
 		if h.operationItemsCreator == nil {
+			data.SetCmdStatusError()
 			log.Error(data.NewEmptyError().AppendDesc(alert.CannotEmpty("operation reader", "")))
 			return
 		}
 
-		workerBuilder = workBuilder.WorkProviderWithFile(h.info.InputFile,
-			h.info.EnableStdin,
-			flow.NewItemsWorkCreator(h.info.ItemSeparate, h.info.MinItemsCount, func(items []string) (work flow.Work, err *data.CodeError) {
-				return h.operationItemsCreator(items)
-			}))
+		workerBuilder = workBuilder.WorkProviderWithFile(h.info.InputFile, h.info.EnableStdin, h.newWorkCreator())
 	}
 
 	// overseer， EnableRecord 未开启不记录中间状态（数组类型的数据源默认关闭）
@@ -163,72 +237,107 @@ func (h *handler) Start() {
 	}
 
 	metric := &Metric{}
-	if isArraySource {
+	if isArraySource || h.info.ShowProgress {
 		metric.DisablePrintProgress()
 	}
 	metric.Start()
-	workerBuilder.
-		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
-			return flow.NewWorker(func(workInfoList []*flow.WorkInfo) ([]*flow.WorkRecord, *data.CodeError) {
-
-				recordList := make([]*flow.WorkRecord, 0, len(workInfoList))
-				operationBucket := ""
-				operationStringList := make([]string, 0, len(workInfoList))
-				operationWorkInfoList := make([]*flow.WorkInfo, 0, len(workInfoList))
-				for _, workInfo := range workInfoList {
-					if operation, ok := workInfo.Work.(Operation); !ok {
-						return nil, alert.Error("batch WorkerProvider, operation type conv error", "")
+	workerProvider := flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+		return flow.NewWorker(func(workInfoList []*flow.WorkInfo) ([]*flow.WorkRecord, *data.CodeError) {
+
+			recordList := make([]*flow.WorkRecord, 0, len(workInfoList))
+			operationBucket := ""
+			operationStringList := make([]string, 0, len(workInfoList))
+			operationWorkInfoList := make([]*flow.WorkInfo, 0, len(workInfoList))
+			for _, workInfo := range workInfoList {
+				if operation, ok := workInfo.Work.(Operation); !ok {
+					return nil, alert.Error("batch WorkerProvider, operation type conv error", "")
+				} else {
+					if len(operationBucket) == 0 {
+						operationBucket = operation.GetBucket()
+					}
+
+					if operationString, e := operation.ToOperation(); e != nil {
+						recordList = append(recordList, &flow.WorkRecord{
+							WorkInfo: workInfo,
+							Result:   nil,
+							Err:      e,
+						})
 					} else {
-						if len(operationBucket) == 0 {
-							operationBucket = operation.GetBucket()
-						}
-
-						if operationString, e := operation.ToOperation(); e != nil {
-							recordList = append(recordList, &flow.WorkRecord{
-								WorkInfo: workInfo,
-								Result:   nil,
-								Err:      e,
-							})
-						} else {
-							operationStringList = append(operationStringList, operationString)
-							operationWorkInfoList = append(operationWorkInfoList, workInfo)
-						}
+						operationStringList = append(operationStringList, operationString)
+						operationWorkInfoList = append(operationWorkInfoList, workInfo)
 					}
 				}
+			}
 
-				if cErr := bucket.CompleteBucketManagerRegion(bucketManager, operationBucket); cErr != nil {
-					return nil, cErr
-				}
+			if cErr := bucket.CompleteBucketManagerRegion(bucketManager, operationBucket); cErr != nil {
+				return nil, cErr
+			}
 
-				resultList, e := bucketManager.Batch(operationStringList)
-				if len(resultList) != len(operationStringList) {
-					return recordList, data.ConvertError(e)
+			if skipRecords, remainIndexes := skipWorksByStat(bucketManager, operationWorkInfoList); len(skipRecords) > 0 {
+				recordList = append(recordList, skipRecords...)
+				remainStringList := make([]string, 0, len(remainIndexes))
+				remainWorkInfoList := make([]*flow.WorkInfo, 0, len(remainIndexes))
+				for _, index := range remainIndexes {
+					remainStringList = append(remainStringList, operationStringList[index])
+					remainWorkInfoList = append(remainWorkInfoList, operationWorkInfoList[index])
 				}
+				operationStringList = remainStringList
+				operationWorkInfoList = remainWorkInfoList
+			}
+			if len(operationStringList) == 0 {
+				return recordList, nil
+			}
 
-				for i, r := range resultList {
-					result := &OperationResult{
-						Code:     r.Code,
-						Hash:     r.Data.Hash,
-						FSize:    r.Data.Fsize,
-						PutTime:  r.Data.PutTime,
-						MimeType: r.Data.MimeType,
-						Type:     r.Data.Type,
-						Error:    r.Data.Error,
-					}
-					record := &flow.WorkRecord{
-						WorkInfo: operationWorkInfoList[i],
-						Result:   result,
-					}
-					if !result.IsSuccess() {
-						record.Err = data.NewError(result.Code, result.Error)
-					}
-					recordList = append(recordList, record)
+			resultList, e := bucketManager.Batch(operationStringList)
+			if len(resultList) != len(operationStringList) {
+				return recordList, data.ConvertError(e)
+			}
+
+			for i, r := range resultList {
+				result := &OperationResult{
+					Code:     r.Code,
+					Hash:     r.Data.Hash,
+					FSize:    r.Data.Fsize,
+					PutTime:  r.Data.PutTime,
+					MimeType: r.Data.MimeType,
+					Type:     r.Data.Type,
+					EndUser:  r.Data.EndUser,
+					MD5:      r.Data.Md5,
+					Error:    r.Data.Error,
 				}
-				return recordList, nil
-			}), nil
-		})).
+				if r.Data.RestoreStatus != nil {
+					result.RestoreStatus = *r.Data.RestoreStatus
+				}
+				if r.Data.Status != nil {
+					result.Status = *r.Data.Status
+				}
+				if r.Data.Expiration != nil {
+					result.Expiration = *r.Data.Expiration
+				}
+				record := &flow.WorkRecord{
+					WorkInfo: operationWorkInfoList[i],
+					Result:   result,
+				}
+				if !result.IsSuccess() {
+					record.Err = data.NewError(result.Code, result.Error)
+				}
+				recordList = append(recordList, record)
+			}
+			return recordList, nil
+		}), nil
+	})
+	if h.info.DryRun {
+		log.Warning("Dry run mode, operations will not be executed")
+		workerProvider = newDryRunWorkerProvider()
+	}
+
+	workerBuilder.
+		WorkerProvider(workerProvider).
 		DoWorkListMaxCount(h.info.OperationCountPerRequest).
 		SetOverseerEnable(h.info.EnableRecord).
+		SetWorkTotalSize(h.info.WorkTotalSize).
+		SetUserConfirm(h.info.userConfirm).
+		SetOverseerReadOnly(h.info.DryRun).
 		SetDBOverseer(dbPath, func() *flow.WorkRecord {
 			return &flow.WorkRecord{
 				WorkInfo: &flow.WorkInfo{
@@ -240,7 +349,7 @@ func (h *handler) Start() {
 			}
 		}).
 		SetLimit(flow.NewBlockLimit(h.info.WorkerCount*h.info.OperationCountPerRequest,
-			flow.MaxLimitCount(h.info.WorkerCount*h.info.OperationCountPerRequest),
+			flow.MaxLimitCount(h.info.MaxLimitWorkerCount()*h.info.OperationCountPerRequest),
 			flow.MinLimitCount(h.info.MinWorkerCount*h.info.OperationCountPerRequest),
 			flow.IncreaseLimitCount(h.info.OperationCountPerRequest),
 			flow.IncreaseLimitCountPeriod(time.Duration(h.info.WorkerCountIncreasePeriod)*time.Second))).
@@ -271,11 +380,15 @@ func (h *handler) Start() {
 			}
 			return false, nil
 		}).
+		Deadletter(h.exporter.Deadletter()).
 		OnWorkSkip(func(work *flow.WorkInfo, result flow.Result, err *data.CodeError) {
 			metric.AddCurrentCount(1)
 			metric.PrintProgress("Batching:" + work.Data)
 
 			operationResult, _ := result.(*OperationResult)
+			if data.IsOutputFormatJson() {
+				outputOperationResult(work, OutputStatusSkipped, operationResult, err, nil)
+			}
 			if err != nil && err.Code == data.ErrorCodeAlreadyDone {
 				if operationResult != nil && operationResult.IsValid() {
 					metric.AddSuccessCount(1)
@@ -290,6 +403,15 @@ func (h *handler) Start() {
 					log.InfoF("Skip line:%s because have done and failure, %v%s", work.Data, err, errDesc)
 					h.exporter.Fail().ExportF("%s%s-%s", work.Data, flow.ErrorSeparate, errDesc)
 				}
+			} else if err.IsSkipped() {
+				metric.AddSkippedCount(1)
+				log.InfoF("Skip line:%s because:%v", work.Data, err)
+				h.exporter.Skip().Export(work.Data)
+			} else if err != nil && err.Code == data.ErrorCodeLineHeader {
+				metric.AddSkippedCount(1)
+				log.InfoF("Skip line:%s because:%v", work.Data, err)
+				// 保留标题行，失败列表可以直接作为输入文件重新执行
+				h.exporter.Fail().Export(work.Data)
 			} else {
 				metric.AddSkippedCount(1)
 
@@ -299,19 +421,32 @@ func (h *handler) Start() {
 					Error: fmt.Sprintf("%v", err),
 				})
 				log.InfoF("Skip line:%s because:%v", work.Data, err)
-				if err != nil && err.Code == data.ErrorCodeLineHeader {
-					h.exporter.Fail().Export(work.Data)
-				} else {
-					h.exporter.Fail().ExportF("%s%s-%v", work.Data, flow.ErrorSeparate, err)
-				}
+				h.exporter.Fail().ExportF("%s%s-%v", work.Data, flow.ErrorSeparate, err)
 			}
 		}).
-		OnWorkSuccess(func(work *flow.WorkInfo, result flow.Result) {
+		OnWorkSuccess(func(work *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
 			metric.AddCurrentCount(1)
 			metric.PrintProgress("Batching:" + work.Data)
 
 			operation, _ := work.Work.(Operation)
 			operationResult, _ := result.(*OperationResult)
+			if h.info.DryRun {
+				// 未实际执行，不导出到成功列表，避免通过 --resume 使用时跳过未执行的操作
+				metric.AddSuccessCount(1)
+				if data.IsOutputFormatJson() {
+					outputOperationResult(work, OutputStatusDryRun, operationResult, nil, stat)
+				}
+				log.InfoF("Dry run, would %s, [%s:%s]", dryRunOperationName(operation), operation.GetBucket(), operation.GetKey())
+				return
+			}
+
+			if data.IsOutputFormatJson() {
+				if operationResult != nil && operationResult.IsSuccess() {
+					outputOperationResult(work, OutputStatusSuccess, operationResult, nil, stat)
+				} else {
+					outputOperationResult(work, OutputStatusFailure, operationResult, nil, stat)
+				}
+			}
 			if operationResult != nil && operationResult.IsSuccess() {
 				metric.AddSuccessCount(1)
 				h.exporter.Success().Export(work.Data)
@@ -325,11 +460,14 @@ func (h *handler) Start() {
 			}
 			h.onResult(work.Data, operation, operationResult)
 		}).
-		OnWorkFail(func(work *flow.WorkInfo, err *data.CodeError) {
+		OnWorkFail(func(work *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
 			metric.AddCurrentCount(1)
 			metric.AddFailureCount(1)
 			metric.PrintProgress("Batching:" + work.Data)
 			h.exporter.Fail().ExportF("%s%s[%d]%s", work.Data, flow.ErrorSeparate, err.Code, err.Desc)
+			if data.IsOutputFormatJson() {
+				outputOperationResult(work, OutputStatusFailure, nil, err, stat)
+			}
 
 			operation, _ := work.Work.(Operation)
 			h.onResult(work.Data, operation, &OperationResult{
@@ -343,6 +481,12 @@ func (h *handler) Start() {
 		metric.TotalCount = metric.SuccessCount + metric.FailureCount + metric.SkippedCount
 	}
 
+	if h.info.DryRun {
+		// 汇总信息中的成功数即为实际执行时会操作的文件数
+		log.AlertF("dry run, %d operation(s) would be executed, nothing has been changed", metric.SuccessCount)
+		return
+	}
+
 	if !isArraySource {
 		log.InfoF("job dir:%s, there is a cache related to this command in this folder, which will also be used next time the same command is executed. If you are sure that you don’t need it, you can delete this folder.", workspace.GetJobDir())
 
@@ -353,13 +497,5 @@ func (h *handler) Start() {
 		} else {
 			log.DebugF("save batch result to path:%s", resultPath)
 		}
-
-		log.Alert("--------------- Batch Result ---------------")
-		log.AlertF("%20s%10d", "Total:", metric.TotalCount)
-		log.AlertF("%20s%10d", "Success:", metric.SuccessCount)
-		log.AlertF("%20s%10d", "Failure:", metric.FailureCount)
-		log.AlertF("%20s%10d", "Skipped:", metric.SkippedCount)
-		log.AlertF("%20s%10ds", "Duration:", metric.Duration)
-		log.AlertF("--------------------------------------------")
 	}
 }
@@ -46,6 +46,23 @@ type Operation interface {
 
 	ToOperation() (string, *data.CodeError)
 	GetBucket() string
+	GetKey() string
+}
+
+// DestOperation 有目标文件的 Operation，如：移动、复制；校验输入文件时会同时检查目标文件的 key
+type DestOperation interface {
+	Operation
+
+	GetDestKey() string
+}
+
+// StatSkipOperation 执行前需要根据文件 stat 信息判断是否跳过的 Operation，如：已解冻的归档文件无需再次解冻
+// batch 会先批量 stat 文件，跳过的 Operation 通过 OnWorkSkip 通知
+type StatSkipOperation interface {
+	Operation
+
+	IsStatSkipEnable() bool
+	ShouldSkipByStat(stat *OperationResult) (skip bool, cause string)
 }
 
 type OperationCreator interface {
@@ -62,6 +79,13 @@ type OperationResult struct {
 	EndUser  string  `json:"endUser"`
 	Error    string  `json:"error"`
 	Parts    []int64 `json:"parts"`
+
+	RestoreStatus int `json:"restoreStatus"` // 归档文件的解冻状态，仅 stat 操作返回，1：解冻中，2：已解冻
+
+	// 以下字段仅 stat 操作返回
+	MD5        string `json:"md5,omitempty"`
+	Status     int    `json:"status,omitempty"`     // 1：禁用
+	Expiration int64  `json:"expiration,omitempty"` // 过期删除日期，Unix 时间戳
 }
 
 var _ flow.Result = (*OperationResult)(nil)
@@ -0,0 +1,55 @@
+package batch
+
+import (
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+)
+
+// newDryRunWorkerProvider dry run 时使用，worker 仅校验 operation 能否构建，不发送请求
+func newDryRunWorkerProvider() flow.WorkerProvider {
+	return flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+		return flow.NewWorker(func(workInfoList []*flow.WorkInfo) ([]*flow.WorkRecord, *data.CodeError) {
+			recordList := make([]*flow.WorkRecord, 0, len(workInfoList))
+			for _, workInfo := range workInfoList {
+				operation, ok := workInfo.Work.(Operation)
+				if !ok {
+					return nil, alert.Error("batch dry run WorkerProvider, operation type conv error", "")
+				}
+
+				record := &flow.WorkRecord{
+					WorkInfo: workInfo,
+				}
+				if _, e := operation.ToOperation(); e != nil {
+					record.Err = e
+				} else {
+					record.Result = &OperationResult{
+						Code: 200,
+					}
+				}
+				recordList = append(recordList, record)
+			}
+			return recordList, nil
+		}), nil
+	})
+}
+
+// dryRunOperationName 操作名称，取自 operation 的请求路径，如：/delete/<EncodedEntry> 的名称为 delete
+func dryRunOperationName(operation Operation) string {
+	if operation == nil {
+		return "unknown"
+	}
+
+	operationString, err := operation.ToOperation()
+	if err != nil {
+		return "unknown"
+	}
+
+	items := strings.Split(strings.TrimPrefix(operationString, "/"), "/")
+	if len(items) == 0 || len(items[0]) == 0 {
+		return "unknown"
+	}
+	return items[0]
+}
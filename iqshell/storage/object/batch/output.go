@@ -0,0 +1,86 @@
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+)
+
+const (
+	OutputStatusSuccess = "success"
+	OutputStatusFailure = "failure"
+	OutputStatusSkipped = "skipped"
+	OutputStatusDryRun  = "dry_run"
+)
+
+// OutputRecord --format json 时每个 operation 结果输出的 JSON 对象
+type OutputRecord struct {
+	Data     string  `json:"data"`               // 输入数据
+	Bucket   string  `json:"bucket,omitempty"`   // 空间名
+	Key      string  `json:"key"`                // 文件名
+	Status   string  `json:"status"`             // 状态：success, failure, skipped, dry_run
+	Code     int     `json:"code"`               // 七牛错误码，成功时为 200
+	Error    *string `json:"error"`              // 错误信息，成功时为 null
+	FSize    int64   `json:"fsize,omitempty"`    // 文件大小
+	Hash     string  `json:"hash,omitempty"`     // 文件 hash
+	MimeType string  `json:"mimeType,omitempty"` // 文件 MimeType
+	PutTime  int64   `json:"putTime,omitempty"`  // 文件上传时间
+	Duration int64   `json:"duration,omitempty"` // 执行耗时，单位：ms
+}
+
+var outputMu sync.Mutex
+
+// outputJsonRecord 输出一行 JSON，每条记录单独写入，长时间运行的任务可以流式读取结果
+func outputJsonRecord(record *OutputRecord) {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	_, _ = fmt.Fprintln(data.Stdout(), string(recordBytes))
+}
+
+func outputOperationResult(work *flow.WorkInfo, status string, result *OperationResult, err *data.CodeError, stat *flow.WorkStat) {
+	record := &OutputRecord{
+		Data:   work.Data,
+		Status: status,
+	}
+	if operation, ok := work.Work.(Operation); ok && operation != nil {
+		record.Bucket = operation.GetBucket()
+		record.Key = operation.GetKey()
+	}
+	if stat != nil {
+		record.Duration = stat.Duration.Milliseconds()
+	}
+
+	if result != nil {
+		record.Code = result.Code
+		record.FSize = result.FSize
+		record.Hash = result.Hash
+		record.MimeType = result.MimeType
+		record.PutTime = result.PutTime
+		if len(result.Error) > 0 {
+			record.Error = &result.Error
+		}
+	}
+	if err != nil {
+		record.Code = err.Code
+		desc := err.Desc
+		record.Error = &desc
+	}
+
+	if status == OutputStatusSuccess {
+		if record.Code == 0 {
+			record.Code = 200
+		}
+	} else if record.Error == nil {
+		desc := "no result"
+		record.Error = &desc
+	}
+	outputJsonRecord(record)
+}
@@ -0,0 +1,44 @@
+package batch
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+// ScanInputFile 在执行前逐行扫描输入文件，用于提前检查或统计输入，lineNumber 从 1 开始，空行不回调；
+// 输入来自标准输入时无法预先扫描，返回 scanned 为 false
+func ScanInputFile(info *Info, handler func(lineNumber int, items []string)) (scanned bool, err *data.CodeError) {
+	if len(info.InputFile) == 0 || info.InputFile == "-" || handler == nil {
+		return false, nil
+	}
+
+	f, oErr := os.Open(info.InputFile)
+	if oErr != nil {
+		return false, data.NewEmptyError().AppendDescF("scan input file, open file error:%v", oErr)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		// 同 work provider，忽略失败列表中追加的错误信息
+		if items := strings.Split(line, flow.ErrorSeparate); len(items) > 0 {
+			line = items[0]
+		}
+		if len(line) == 0 {
+			continue
+		}
+		handler(lineNumber, utils.SplitString(line, info.ItemSeparate))
+	}
+	if sErr := scanner.Err(); sErr != nil {
+		return true, data.NewEmptyError().AppendDescF("scan input file error:%v", sErr)
+	}
+	return true, nil
+}
@@ -0,0 +1,61 @@
+package batch
+
+import (
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+// skipWorksByStat 批量 stat 实现了 StatSkipOperation 的 work，返回需要跳过的 work 记录，以及剩余 work 在原列表中的索引
+// 单个文件 stat 失败时 stat 结果中包含错误码，由 Operation 判断是否跳过；整个请求失败时不跳过，由后续的操作返回具体的错误
+func skipWorksByStat(bucketManager *storage.BucketManager, workInfoList []*flow.WorkInfo) (skipRecords []*flow.WorkRecord, remainIndexes []int) {
+	statIndexes := make([]int, 0, len(workInfoList))
+	statOperations := make([]string, 0, len(workInfoList))
+	for i, workInfo := range workInfoList {
+		if operation, ok := workInfo.Work.(StatSkipOperation); ok && operation.IsStatSkipEnable() {
+			statIndexes = append(statIndexes, i)
+			statOperations = append(statOperations, storage.URIStat(operation.GetBucket(), operation.GetKey()))
+		}
+	}
+
+	skipIndexes := make(map[int]bool)
+	if len(statOperations) > 0 {
+		if resultList, e := bucketManager.Batch(statOperations); len(resultList) != len(statOperations) {
+			log.DebugF("batch stat before operation error:%v", e)
+		} else {
+			for i, r := range resultList {
+				stat := &OperationResult{
+					Code:     r.Code,
+					Hash:     r.Data.Hash,
+					FSize:    r.Data.Fsize,
+					PutTime:  r.Data.PutTime,
+					MimeType: r.Data.MimeType,
+					Type:     r.Data.Type,
+					Error:    r.Data.Error,
+				}
+				if r.Data.RestoreStatus != nil {
+					stat.RestoreStatus = *r.Data.RestoreStatus
+				}
+
+				workInfo := workInfoList[statIndexes[i]]
+				if skip, cause := workInfo.Work.(StatSkipOperation).ShouldSkipByStat(stat); skip {
+					skipIndexes[statIndexes[i]] = true
+					skipRecords = append(skipRecords, &flow.WorkRecord{
+						WorkInfo: workInfo,
+						Result:   stat,
+						Err:      data.NewError(data.ErrorCodeSkipByWorker, cause),
+					})
+				}
+			}
+		}
+	}
+
+	for i := range workInfoList {
+		if !skipIndexes[i] {
+			remainIndexes = append(remainIndexes, i)
+		}
+	}
+	return skipRecords, remainIndexes
+}
@@ -0,0 +1,145 @@
+package batch
+
+import (
+	"fmt"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+// maxKeyLength 文件名（key）的最大长度，单位：字节
+const maxKeyLength = 750
+
+// validate 仅校验输入文件：使用和执行时相同的逻辑解析每一行并构建 operation，检查字段数量及 key，输出所有错误的行；
+// 合法的行导出到成功列表（可作为清洗后的输入文件），错误的行导出到失败列表
+func (h *handler) validate() {
+	if h.operationItemsCreator == nil {
+		log.Error(data.NewEmptyError().AppendDesc(alert.CannotEmpty("operation reader", "")))
+		return
+	}
+
+	// 校验不发送请求，无需确认；单个 worker 保证错误按行的顺序输出；错误的行需全部输出，不因错误过多结束
+	info := h.info.Info
+	info.Force = true
+	info.WorkerCount = 1
+	info.MaxWorkerCount = 0
+	info.StopWhenWorkError = false
+	info.MaxErrorCount = 0
+	info.MaxErrorRate = 0
+	info.RetryCount = 0
+	info.ShowProgress = false
+	info.Internal = true
+
+	var total, valid, invalid, skipped int64
+	onInvalid := func(work *flow.WorkInfo, err *data.CodeError) {
+		atomic.AddInt64(&total, 1)
+		atomic.AddInt64(&invalid, 1)
+		desc := err.Desc
+		if err.Code != data.ErrorCodeLineInvalid {
+			// 格式错误的行的错误信息中已包含行号
+			desc = fmt.Sprintf("line %d, %s", work.LineNumber, desc)
+		}
+		log.ErrorF("Invalid %s, input:%s", desc, work.Data)
+		h.exporter.Fail().ExportF("%s%s[%d]%s", work.Data, flow.ErrorSeparate, err.Code, err.Desc)
+	}
+
+	log.Warning("Validate mode, only the input file is checked and operations will not be executed")
+	flow.New(info).
+		ItemsInput(h.info.InputFormat, h.info.InputColumns, h.info.InputHasHeader).
+		WorkProviderWithFile(h.info.InputFile, h.info.EnableStdin, h.newWorkCreator()).
+		WorkerProvider(newValidateWorkerProvider()).
+		DoWorkListMaxCount(h.info.OperationCountPerRequest).
+		Deadletter(h.exporter.Deadletter()).
+		OnWorkSkip(func(work *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			if err != nil && err.Code == data.ErrorCodeLineHeader {
+				// 保留标题行，导出的文件可以直接作为输入文件
+				h.exporter.Success().Export(work.Data)
+				h.exporter.Fail().Export(work.Data)
+				return
+			}
+			if err == nil || err.IsSkipped() {
+				atomic.AddInt64(&total, 1)
+				atomic.AddInt64(&skipped, 1)
+				h.exporter.Skip().Export(work.Data)
+				return
+			}
+			// 字段数量不足等
+			onInvalid(work, err)
+		}).
+		OnWorkSuccess(func(work *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
+			atomic.AddInt64(&total, 1)
+			atomic.AddInt64(&valid, 1)
+			h.exporter.Success().Export(work.Data)
+		}).
+		OnWorkFail(func(work *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
+			onInvalid(work, err)
+		}).Build().Start()
+
+	if invalid > 0 {
+		data.SetCmdStatusError()
+	}
+	log.Alert("--------------- Validate Result ---------------")
+	log.AlertF("%20s%10d", "Total:", total)
+	log.AlertF("%20s%10d", "Valid:", valid)
+	log.AlertF("%20s%10d", "Invalid:", invalid)
+	log.AlertF("%20s%10d", "Skipped:", skipped)
+	log.AlertF("-----------------------------------------------")
+}
+
+// newValidateWorkerProvider 校验时使用，worker 检查 operation 能否构建及 key 是否合法，不发送请求
+func newValidateWorkerProvider() flow.WorkerProvider {
+	return flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+		return flow.NewWorker(func(workInfoList []*flow.WorkInfo) ([]*flow.WorkRecord, *data.CodeError) {
+			recordList := make([]*flow.WorkRecord, 0, len(workInfoList))
+			for _, workInfo := range workInfoList {
+				operation, ok := workInfo.Work.(Operation)
+				if !ok {
+					return nil, alert.Error("batch validate WorkerProvider, operation type conv error", "")
+				}
+
+				record := &flow.WorkRecord{
+					WorkInfo: workInfo,
+				}
+				if e := validateOperation(operation); e != nil {
+					record.Err = e
+				} else {
+					record.Result = &OperationResult{
+						Code: 200,
+					}
+				}
+				recordList = append(recordList, record)
+			}
+			return recordList, nil
+		}), nil
+	})
+}
+
+func validateOperation(operation Operation) *data.CodeError {
+	if err := validateKey("key", operation.GetKey()); err != nil {
+		return err
+	}
+	if o, ok := operation.(DestOperation); ok {
+		if err := validateKey("dest key", o.GetDestKey()); err != nil {
+			return err
+		}
+	}
+	_, err := operation.ToOperation()
+	return err
+}
+
+func validateKey(name, key string) *data.CodeError {
+	if len(key) == 0 {
+		return data.NewEmptyError().AppendDescF("%s is empty", name)
+	}
+	if !utf8.ValidString(key) {
+		return data.NewEmptyError().AppendDescF("%s:%q is not valid UTF-8", name, key)
+	}
+	if len(key) > maxKeyLength {
+		return data.NewEmptyError().AppendDescF("%s is too long, %d bytes, should not be greater than %d bytes", name, len(key), maxKeyLength)
+	}
+	return nil
+}
@@ -20,6 +20,14 @@ func (m *CopyApiInfo) GetBucket() string {
 	return m.SourceBucket
 }
 
+func (m *CopyApiInfo) GetKey() string {
+	return m.SourceKey
+}
+
+func (m *CopyApiInfo) GetDestKey() string {
+	return m.DestKey
+}
+
 func (m *CopyApiInfo) ToOperation() (string, *data.CodeError) {
 	if len(m.SourceBucket) == 0 || len(m.SourceKey) == 0 || len(m.DestBucket) == 0 || len(m.DestKey) == 0 {
 		return "", alert.CannotEmptyError("copy operation bucket or key of source and dest", "")
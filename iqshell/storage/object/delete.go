@@ -20,6 +20,10 @@ func (d *DeleteApiInfo) GetBucket() string {
 	return d.Bucket
 }
 
+func (d *DeleteApiInfo) GetKey() string {
+	return d.Key
+}
+
 func (d *DeleteApiInfo) ToOperation() (string, *data.CodeError) {
 	if len(d.Bucket) == 0 || len(d.Key) == 0 {
 		return "", alert.CannotEmptyError("delete operation bucket or key", "")
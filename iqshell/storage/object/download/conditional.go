@@ -0,0 +1,62 @@
+package download
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/client"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// IsServerFileNotModified 使用上次下载时服务端返回的 etag 和 Last-Modified 发送条件 HEAD 请求，服务端返回 304 时说明文件未改变
+func IsServerFileNotModified(info *DownloadActionInfo, etag string, lastModified int64) (notModified bool, err *data.CodeError) {
+	if len(etag) == 0 && lastModified <= 0 {
+		return false, data.NewEmptyError().AppendDesc("no etag or last modified time for conditional request")
+	}
+	if info.HostProvider == nil {
+		return false, data.NewEmptyError().AppendDesc("no host provider for conditional request")
+	}
+
+	h, pErr := info.HostProvider.Provide()
+	if h == nil || pErr != nil {
+		return false, data.NewEmptyError().AppendDescF("no available host:%+v", pErr)
+	}
+
+	downloadUrl, cErr := createDownloadUrl(&DownloadApiInfo{
+		Bucket:         info.Bucket,
+		Key:            info.Key,
+		IsPublicBucket: info.IsPublic,
+		UseGetFileApi:  info.UseGetFileApi,
+		Host:           h.GetServer(),
+	})
+	if cErr != nil {
+		return false, cErr
+	}
+
+	headers := http.Header{}
+	if len(etag) > 0 {
+		headers.Add("If-None-Match", fmt.Sprintf("\"%s\"", etag))
+	}
+	if lastModified > 0 {
+		headers.Add("If-Modified-Since", time.Unix(lastModified, 0).UTC().Format(http.TimeFormat))
+	}
+	if len(info.Referer) > 0 {
+		headers.Add("Referer", info.Referer)
+	}
+
+	resp, rErr := client.DefaultStorageClient().DoRequest(workspace.GetContext(), "HEAD", downloadUrl, headers)
+	if rErr != nil {
+		return false, data.NewEmptyError().AppendDesc("conditional request error").AppendError(rErr)
+	}
+	if resp.Body != nil {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+	log.DebugF("conditional request [%s:%s] status:%d", info.Bucket, info.Key, resp.StatusCode)
+	return resp.StatusCode == http.StatusNotModified, nil
+}
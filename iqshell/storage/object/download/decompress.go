@@ -0,0 +1,73 @@
+package download
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+const (
+	ContentEncodingGzip = "gzip"
+	ContentEncodingZstd = "zstd"
+)
+
+// isDecompressSupported 是否支持解压此 Content-Encoding，为空时表示未压缩
+func isDecompressSupported(contentEncoding string) bool {
+	contentEncoding = strings.ToLower(strings.TrimSpace(contentEncoding))
+	return contentEncoding == ContentEncodingGzip || contentEncoding == ContentEncodingZstd
+}
+
+// decompressFile 按 Content-Encoding 将 filePath 解压为原始内容，解压后的内容替换原文件；返回解压后的大小
+func decompressFile(filePath string, contentEncoding string) (size int64, err *data.CodeError) {
+	src, oErr := os.Open(filePath)
+	if oErr != nil {
+		return 0, data.NewEmptyError().AppendDescF("open file:%s error:%v", filePath, oErr)
+	}
+	defer src.Close()
+
+	var reader io.Reader
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case ContentEncodingGzip:
+		gzipReader, gErr := gzip.NewReader(src)
+		if gErr != nil {
+			return 0, data.NewEmptyError().AppendDescF("create gzip reader error:%v", gErr)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case ContentEncodingZstd:
+		zstdReader, zErr := zstd.NewReader(src)
+		if zErr != nil {
+			return 0, data.NewEmptyError().AppendDescF("create zstd reader error:%v", zErr)
+		}
+		defer zstdReader.Close()
+		reader = zstdReader
+	default:
+		return 0, data.NewEmptyError().AppendDescF("content encoding:%s is not supported", contentEncoding)
+	}
+
+	tempFile := filePath + ".decompress"
+	dst, cErr := os.Create(tempFile)
+	if cErr != nil {
+		return 0, data.NewEmptyError().AppendDescF("create file:%s error:%v", tempFile, cErr)
+	}
+	size, wErr := io.Copy(dst, reader)
+	if closeErr := dst.Close(); wErr == nil {
+		wErr = closeErr
+	}
+	if wErr != nil {
+		_ = os.Remove(tempFile)
+		return 0, data.NewEmptyError().AppendDescF("decompress file:%s error:%v", filePath, wErr)
+	}
+
+	_ = src.Close()
+	if rErr := os.Rename(tempFile, filePath); rErr != nil {
+		_ = os.Remove(tempFile)
+		return 0, data.NewEmptyError().AppendDescF("rename decompressed file to %s error:%v", filePath, rErr)
+	}
+	return size, nil
+}
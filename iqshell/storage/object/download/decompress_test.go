@@ -0,0 +1,54 @@
+package download
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestDecompressFile(t *testing.T) {
+	content := bytes.Repeat([]byte("qshell decompress "), 100)
+
+	var gzipData bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipData)
+	_, _ = gzipWriter.Write(content)
+	_ = gzipWriter.Close()
+
+	zstdEncoder, _ := zstd.NewWriter(nil)
+	zstdData := zstdEncoder.EncodeAll(content, nil)
+	_ = zstdEncoder.Close()
+
+	dir := t.TempDir()
+	for encoding, compressed := range map[string][]byte{
+		ContentEncodingGzip: gzipData.Bytes(),
+		ContentEncodingZstd: zstdData,
+	} {
+		filePath := filepath.Join(dir, encoding)
+		if err := os.WriteFile(filePath, compressed, 0644); err != nil {
+			t.Fatal(err)
+		}
+		size, err := decompressFile(filePath, encoding)
+		if err != nil {
+			t.Fatalf("decompress %s error:%v", encoding, err)
+		}
+		if size != int64(len(content)) {
+			t.Fatalf("decompress %s size error, size:%d expected:%d", encoding, size, len(content))
+		}
+		if result, _ := os.ReadFile(filePath); !bytes.Equal(result, content) {
+			t.Fatalf("decompress %s content error", encoding)
+		}
+	}
+
+	filePath := filepath.Join(dir, "br")
+	_ = os.WriteFile(filePath, content, 0644)
+	if _, err := decompressFile(filePath, "br"); err == nil {
+		t.Fatal("unsupported encoding should fail")
+	}
+	if result, _ := os.ReadFile(filePath); !bytes.Equal(result, content) {
+		t.Fatal("file should be kept when encoding is unsupported")
+	}
+}
@@ -1,6 +1,7 @@
 package download
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,6 +17,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
 	"github.com/qiniu/qshell/v2/iqshell/common/progress"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
 )
 
@@ -34,6 +36,11 @@ type DownloadActionInfo struct {
 	DownloadFileSize       int64             `json:"download_file_size"`   // 下载的文件大小，下载整个文件时，等于 ServerFileSize；切片下载则为切片大小；有值则会检测文件大小【选填】
 	CheckSize              bool              `json:"-"`                    // 是否检测文件大小 【选填】
 	CheckHash              bool              `json:"-"`                    // 是否检测文件 hash 【选填】
+	VerifyHash             bool              `json:"-"`                    // 下载完成后是否校验文件 hash，不一致则删除文件并返回错误 【选填】
+	Force                  bool              `json:"-"`                    // 本地文件已存在时不做检查，总是重新下载 【选填】
+	IfNoneMatch            string            `json:"-"`                    // 上次下载时服务端返回的 etag，本地文件已存在时先发送条件请求，服务端文件未改变则不再下载 【选填】
+	IfModifiedSince        int64             `json:"-"`                    // 上次下载时服务端返回的 Last-Modified，单位：秒，用法同 IfNoneMatch 【选填】
+	Decompress             bool              `json:"-"`                    // 按服务端返回的 Content-Encoding(gzip/zstd) 解压下载的文件，hash 校验针对解压前的数据 【选填】
 	FromBytes              int64             `json:"-"`                    // 下载开始的位置，内部会缓存 【内部使用】
 	ToBytes                int64             `json:"-"`                    // 下载的终止位置【内部使用】
 	RemoveTempWhileError   bool              `json:"-"`                    // 当遇到错误时删除临时文件 【选填】
@@ -49,11 +56,26 @@ func (i *DownloadActionInfo) WorkId() string {
 	return fmt.Sprintf("%s:%s:%s", i.Bucket, i.Key, i.ToFile)
 }
 
+func (i *DownloadActionInfo) GetKey() string {
+	return i.Key
+}
+
+// GetSize 服务端文件大小，由列举或 stat 获取
+func (i *DownloadActionInfo) GetSize() (int64, *data.CodeError) {
+	return i.ServerFileSize, nil
+}
+
 type DownloadActionResult struct {
-	FileModifyTime int64  `json:"file_modify_time"` // 下载后文件修改时间
-	FileAbsPath    string `json:"file_abs_path"`    // 文件被保存的绝对路径
-	IsUpdate       bool   `json:"is_update"`        // 是否为接续下载
-	IsExist        bool   `json:"is_exist"`         // 是否为已存在
+	FileModifyTime int64  `json:"file_modify_time"`        // 下载后文件修改时间
+	FileAbsPath    string `json:"file_abs_path"`           // 文件被保存的绝对路径
+	IsUpdate       bool   `json:"is_update"`               // 是否为接续下载
+	IsExist        bool   `json:"is_exist"`                // 是否为已存在
+	DownloadedSize int64  `json:"downloaded_size"`         // 下载失败时临时文件中已下载的大小，再次下载时从此位置接续
+	Host           string `json:"host,omitempty"`          // 下载文件使用的域名，文件未实际下载时为空
+	Etag           string `json:"etag,omitempty"`          // 下载时服务端返回的 etag，用于再次下载时的条件请求
+	LastModified   int64  `json:"last_modified,omitempty"` // 下载时服务端返回的 Last-Modified，单位：秒，用于再次下载时的条件请求
+	Decompressed   bool   `json:"decompressed,omitempty"`  // 文件是否已按 Content-Encoding 解压
+	FileSize       int64  `json:"file_size,omitempty"`     // 本地文件的大小，解压时为解压后的大小
 }
 
 var _ flow.Result = (*DownloadActionResult)(nil)
@@ -103,9 +125,24 @@ func Download(info *DownloadActionInfo) (res *DownloadActionResult, err *data.Co
 	// 读不到 status 按不存在该文件处理
 	fileStatus, _ := os.Stat(f.toAbsFile)
 	tempFileStatus, _ := os.Stat(f.tempFile)
-	if fileStatus != nil {
-		// 文件已下载，检测文件内容
-		if checkMode < 0 {
+	if fileStatus != nil && info.Force {
+		log.DebugF("force download, ignore local file:%s", f.toAbsFile)
+	} else if fileStatus != nil && (len(info.IfNoneMatch) > 0 || info.IfModifiedSince > 0) {
+		// 服务端文件的 hash 未知，向服务端确认文件是否改变，未改变则不再下载，否则重新下载
+		notModified, cErr := IsServerFileNotModified(info, info.IfNoneMatch, info.IfModifiedSince)
+		if cErr != nil {
+			log.DebugF("conditional request error, [%s:%s], %v", info.Bucket, info.Key, cErr)
+		} else if notModified {
+			res.IsExist = true
+			res.Etag = info.IfNoneMatch
+			res.LastModified = info.IfModifiedSince
+			res.FileModifyTime = fileStatus.ModTime().Unix()
+			res.FileSize = fileStatus.Size()
+			return res, nil
+		}
+	} else if fileStatus != nil {
+		// 文件已下载，检测文件内容；开启解压时本地文件可能为解压后的内容，无法和服务端文件对比
+		if checkMode < 0 || info.Decompress {
 			// 文件已存在，无论文件是什么均认为是预期
 			res.IsExist = true
 			return res, nil
@@ -140,19 +177,11 @@ func Download(info *DownloadActionInfo) (res *DownloadActionResult, err *data.Co
 		// 文件已下载了一部分，需要继续下载
 		res.IsUpdate = true
 
-		// 下载了一半， 先检查文件是否已经改变，改变则移除临时文件，重新下载
-		status, sErr := object.Status(object.StatusApiInfo{
-			Bucket:   info.Bucket,
-			Key:      info.Key,
-			NeedPart: false,
-		})
-		if sErr != nil {
-			return nil, data.NewEmptyError().AppendDescF("download part, get file status error:%v", sErr)
-		}
-		if info.ServerFileSize != status.FSize || (len(info.ServerFileHash) > 0 && info.ServerFileHash != status.Hash) {
-			f.fromBytes = 0
-			log.DebugF("download part, remove download file because file doesn't match")
-			if rErr := os.Remove(f.tempFile); rErr != nil {
+		// 下载了一半，临时文件开始下载时的服务端文件和当前的服务端文件不同则移除临时文件，重新下载；
+		// 下载时还会和服务端返回的文件信息对比，并通过 If-Range 确认
+		if len(info.ServerFileHash) > 0 && !f.isPartMatch(info.ServerFileHash, info.ServerFileSize) {
+			log.DebugF("download part, remove download file because file doesn't match, etag before:%s now:%s", f.partEtag, info.ServerFileHash)
+			if rErr := f.cleanTempFile(); rErr != nil {
 				log.ErrorF("download part, remove download file error:%s", rErr)
 			}
 		}
@@ -161,16 +190,26 @@ func Download(info *DownloadActionInfo) (res *DownloadActionResult, err *data.Co
 	// 下载
 	err = download(f, info)
 	if err != nil {
+		if tempStatus, sErr := os.Stat(f.tempFile); sErr == nil {
+			res.DownloadedSize = tempStatus.Size()
+		}
 		return
 	}
 
+	res.Host = f.servedHost
+	res.Etag = f.servedEtag
+	res.LastModified = f.servedLastModified
 	if fStatus, sErr := os.Stat(f.toAbsFile); sErr != nil {
 		return res, data.NewEmptyError().AppendDesc("get file stat error after download").AppendError(sErr)
 	} else {
 		res.FileModifyTime = fStatus.ModTime().Unix()
+		res.FileSize = fStatus.Size()
 	}
 
-	// 检查下载后的数据是否符合预期
+	// 检查下载后的数据是否符合预期，开启校验时下载后总是校验 hash
+	if info.VerifyHash {
+		checkMode = object.MatchCheckModeFileHash
+	}
 	if checkMode >= 0 {
 		checkResult, mErr := object.Match(object.MatchApiInfo{
 			Bucket:         info.Bucket,
@@ -185,10 +224,35 @@ func Download(info *DownloadActionInfo) (res *DownloadActionResult, err *data.Co
 			if rErr := os.Remove(f.toAbsFile); rErr != nil {
 				log.ErrorF("after download, remove download file error:%s", rErr)
 			}
+			if checkResult != nil && checkResult.Checked && len(checkResult.LocalFileHash) > 0 && !checkResult.Match {
+				return res, data.NewEmptyError().AppendDescF("check hash error after download, expected hash:%s but local file hash:%s",
+					checkResult.ServerFileHash, checkResult.LocalFileHash)
+			}
 			return res, data.NewEmptyError().AppendDesc("check error after download").AppendError(mErr)
 		}
 	}
 
+	// 解压在 hash 校验之后，校验的是服务端存储的原始数据
+	if info.Decompress && len(f.servedEncoding) > 0 {
+		if !isDecompressSupported(f.servedEncoding) {
+			log.WarningF("[%s:%s] content encoding:%s is not supported, keep the file as it is", info.Bucket, info.Key, f.servedEncoding)
+			return res, nil
+		}
+		size, dErr := decompressFile(f.toAbsFile, f.servedEncoding)
+		if dErr != nil {
+			if rErr := os.Remove(f.toAbsFile); rErr != nil {
+				log.ErrorF("after download, remove file which failed to decompress error:%s", rErr)
+			}
+			return res, data.NewEmptyError().AppendDescF("decompress %s file error", f.servedEncoding).AppendError(dErr)
+		}
+		log.DebugF("decompress [%s:%s] => %s, encoding:%s size:%d", info.Bucket, info.Key, f.toAbsFile, f.servedEncoding, size)
+		res.Decompressed = true
+		res.FileSize = size
+		if fStatus, sErr := os.Stat(f.toAbsFile); sErr == nil {
+			res.FileModifyTime = fStatus.ModTime().Unix()
+		}
+	}
+
 	return res, nil
 }
 
@@ -204,8 +268,11 @@ func download(fInfo *fileInfo, info *DownloadActionInfo) (err *data.CodeError) {
 		}
 	}()
 
+	ctx, cancel := workspace.NewTransferContext()
+	defer cancel()
+
 	info.FromBytes = fInfo.fromBytes
-	err = downloadTempFile(fInfo, info)
+	err = downloadTempFile(ctx, fInfo, info)
 	if err != nil {
 		return err
 	}
@@ -214,7 +281,7 @@ func download(fInfo *fileInfo, info *DownloadActionInfo) (err *data.CodeError) {
 	return err
 }
 
-func downloadTempFile(fInfo *fileInfo, info *DownloadActionInfo) (err *data.CodeError) {
+func downloadTempFile(ctx context.Context, fInfo *fileInfo, info *DownloadActionInfo) (err *data.CodeError) {
 	for times := 0; times < 6; times++ {
 		dl, cErr := createDownloader(info)
 		if cErr != nil {
@@ -252,14 +319,18 @@ func downloadTempFile(fInfo *fileInfo, info *DownloadActionInfo) (err *data.Code
 			CheckHash:      info.CheckHash,
 			FileHash:       info.ServerFileHash,
 			Progress:       info.Progress,
+			ctx:            ctx,
 		})
 		if err == nil {
+			fInfo.servedHost = hostString
+			if reporter, ok := info.HostProvider.(host.SuccessReporter); ok {
+				reporter.ReportSuccess(h)
+			}
 			break
 		}
 
 		log.DebugF("Download[%d] [%s:%s] => %s, err:%+v", times, info.Bucket, info.Key, info.ToFile, err)
-		if (err.Code > 399 && err.Code < 500) ||
-			err.Code == 612 || err.Code == 631 {
+		if err.IsClientError() || err.IsNotFound() {
 			log.DebugF("Stop download [%s:%s] => %s, because [%+v]", info.Bucket, info.Key, info.ToFile, err)
 			break
 		}
@@ -290,8 +361,21 @@ func downloadTempFileWithDownloader(dl downloader, fInfo *fileInfo, info *Downlo
 	} else {
 		info.FileSize = file.Size
 		info.FileHash = file.Hash
+		fInfo.servedEtag = file.Hash
+		fInfo.servedLastModified = file.LastModified
+		fInfo.servedEncoding = file.ContentEncoding
 	}
 
+	// 临时文件开始下载后服务端文件已改变，已下载的部分不可用
+	if fInfo.partSize > 0 && !fInfo.isPartMatch(info.FileHash, info.FileSize) {
+		log.DebugF("download part, server file has changed, etag before:%s now:%s, download from start", fInfo.partEtag, info.FileHash)
+		info.RangeFromBytes -= fInfo.partSize
+		if e := fInfo.cleanTempFile(); e != nil {
+			return e.HeaderInsertDesc("download, clean temp file error:")
+		}
+	}
+	info.IfRangeEtag = fInfo.partEtag
+
 	// 检查 fromBytes 和 fileSize，fromBytes 不能 > fileSize
 	if info.RangeFromBytes > 0 {
 		if info.RangeFromBytes > info.FileSize || (info.RangeFromBytes > info.RangeToBytes && info.RangeToBytes > 0) {
@@ -332,11 +416,22 @@ func downloadTempFileWithDownloader(dl downloader, fInfo *fileInfo, info *Downlo
 	var fErr error
 	var tempFileHandle *os.File
 	isExist, _ := utils.ExistFile(fInfo.tempFile)
-	if isExist {
+	// 服务端不支持 Range 或文件已改变时会从头下载，此时 RangeFromBytes 为 0，需清空临时文件
+	if isExist && info.RangeFromBytes > 0 {
 		tempFileHandle, fErr = os.OpenFile(fInfo.tempFile, os.O_APPEND|os.O_WRONLY, 0655)
 		log.DebugF("download %s => %s from:%d", downloadUrl, fInfo.toFile, info.RangeFromBytes)
 	} else {
 		tempFileHandle, fErr = os.Create(fInfo.tempFile)
+		if fErr == nil {
+			// 记录临时文件对应的服务端文件，优先使用下载响应中的 etag
+			etag := info.FileHash
+			if e := utils.ParseEtag(response.Header.Get("Etag")); len(e) > 0 {
+				etag = e
+			}
+			if e := fInfo.saveTempFileInfo(etag, info.FileSize); e != nil {
+				log.WarningF("download, save temp file info error:%v", e)
+			}
+		}
 	}
 	if fErr != nil {
 		return data.NewEmptyError().AppendDesc(" Open local temp file error:" + fInfo.tempFile + " error:" + fErr.Error())
@@ -363,6 +458,7 @@ func renameTempFile(fInfo *fileInfo) *data.CodeError {
 	if err != nil {
 		return data.NewEmptyError().AppendDescF(" Rename temp file to final file error:%v", err.Error())
 	}
+	_ = os.Remove(fInfo.tempInfoFile)
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package download
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -26,7 +27,16 @@ type DownloadApiInfo struct {
 	FileSize       int64
 	CheckHash      bool
 	FileHash       string
+	IfRangeEtag    string // 接续下载时临时文件对应的服务端文件 etag，服务端文件已改变时会返回完整的文件
 	Progress       progress.Progress
+	ctx            context.Context // 下载请求使用的 context，超过 overall timeout 时取消
+}
+
+func (i *DownloadApiInfo) context() context.Context {
+	if i.ctx == nil {
+		return workspace.GetContext()
+	}
+	return i.ctx
 }
 
 type downloaderFile struct {
@@ -65,7 +75,9 @@ func (d *downloaderFile) download(info *DownloadApiInfo) (response *http.Respons
 	}
 
 	// 设置断点续传
+	isRangeRequest := false
 	if info.RangeFromBytes >= 0 && info.RangeToBytes >= 0 && (info.RangeFromBytes+info.RangeToBytes) > 0 {
+		isRangeRequest = true
 		if info.RangeFromBytes > 0 && info.RangeFromBytes == info.RangeToBytes {
 			return &http.Response{
 				Status:     "already download",
@@ -76,17 +88,24 @@ func (d *downloaderFile) download(info *DownloadApiInfo) (response *http.Respons
 		} else {
 			headers.Add("Range", fmt.Sprintf("bytes=%d-%d", info.RangeFromBytes, info.RangeToBytes))
 		}
+		// 文件在临时文件开始下载后改变时，服务端会返回完整的文件
+		if len(info.IfRangeEtag) > 0 {
+			headers.Add("If-Range", fmt.Sprintf("\"%s\"", info.IfRangeEtag))
+		}
 	}
 
+	// 获取服务端存储的原始数据，避免 http client 自动解压 gzip 导致 hash 校验失败
+	headers.Add("Accept-Encoding", "identity")
+
 	// 配置 referer
 	if len(info.Referer) > 0 {
 		headers.Add("Referer", info.Referer)
 	}
 
-	if workspace.IsCmdInterrupt() {
-		return nil, data.CancelError
+	if e := info.context().Err(); e != nil {
+		return nil, data.ConvertError(e)
 	}
-	response, rErr := client.DefaultStorageClient().DoRequest(workspace.GetContext(), "GET", info.downloadUrl, headers)
+	response, rErr := client.DefaultStorageClient().DoRequest(info.context(), "GET", info.downloadUrl, headers)
 	if info.CheckHash && len(info.FileHash) != 0 && response != nil && response.Header != nil {
 		etag := fmt.Sprintf(response.Header.Get("Etag"))
 		etag = utils.ParseEtag(etag)
@@ -96,6 +115,17 @@ func (d *downloaderFile) download(info *DownloadApiInfo) (response *http.Respons
 	}
 
 	if rErr == nil {
+		// 请求了 Range 但服务端返回了完整的文件（不支持 Range 或文件已改变）
+		if isRangeRequest && response != nil && response.StatusCode == http.StatusOK {
+			if info.RangeToBytes > 0 {
+				if response.Body != nil {
+					_ = response.Body.Close()
+				}
+				return nil, data.NewEmptyError().AppendDescF("range request is not supported, url:%s", info.downloadUrl)
+			}
+			log.DebugF("range request is not supported or file has changed, download from start, url:%s", info.downloadUrl)
+			info.RangeFromBytes = 0
+		}
 		return response, nil
 	}
 
@@ -0,0 +1,155 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// testFileServer 支持 Range 及 If-Range 的文件服务，etag 为内容的 md5；
+// afterHead 不为空时，HEAD 请求之后将内容替换为 afterHead，模拟获取文件信息之后文件被修改
+type testFileServer struct {
+	*httptest.Server
+	lock      sync.Mutex
+	content   []byte
+	afterHead []byte
+	ranges    []string
+}
+
+func newTestFileServer(content []byte) *testFileServer {
+	s := &testFileServer{content: content}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		content := s.content
+		if r.Method == http.MethodHead && s.afterHead != nil {
+			s.content = s.afterHead
+			s.afterHead = nil
+		}
+		if r.Method == http.MethodGet {
+			s.ranges = append(s.ranges, r.Header.Get("Range"))
+		}
+		s.lock.Unlock()
+
+		w.Header().Set("Etag", "\""+testEtag(content)+"\"")
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	return s
+}
+
+func testEtag(content []byte) string {
+	hash := md5.Sum(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// testDownloadPart 创建已下载了 part 的临时文件，part 为 etag 对应文件的前一部分；etag 为空时不记录临时文件信息
+func testDownloadPart(t *testing.T, toFile string, part []byte, etag string, fileSize int64) {
+	if err := os.WriteFile(toFile+".part", part, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Remove(toFile + ".part.info")
+	if len(etag) > 0 {
+		f := &fileInfo{tempInfoFile: toFile + ".part.info"}
+		if err := f.saveTempFileInfo(etag, fileSize); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testDownloadTempFile(t *testing.T, server *testFileServer, toFile string) []byte {
+	f, err := createDownloadFiles(toFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = downloadTempFileWithDownloader(&downloaderFile{}, f, &DownloadApiInfo{
+		Bucket:         "bucket",
+		Key:            "key",
+		IsPublicBucket: true,
+		Host:           server.URL,
+		RangeFromBytes: f.fromBytes,
+		ctx:            context.Background(),
+	})
+	if err != nil {
+		t.Fatal("download error:", err)
+	}
+	content, rErr := os.ReadFile(f.tempFile)
+	if rErr != nil {
+		t.Fatal(rErr)
+	}
+	return content
+}
+
+func TestDownloadTempFileResume(t *testing.T) {
+	// 使用指定的区域，避免查询空间所在的区域
+	cfg := workspace.GetConfig()
+	oldRegion := cfg.Region
+	cfg.Region = data.NewString("z0")
+	defer func() {
+		cfg.Region = oldRegion
+	}()
+
+	oldContent := bytes.Repeat([]byte("old content "), 100)
+	newContent := bytes.Repeat([]byte("new content "), 120)
+	toFile := filepath.Join(t.TempDir(), "file")
+
+	// 服务端文件未改变，从已下载的位置接续下载
+	server := newTestFileServer(oldContent)
+	defer server.Close()
+	testDownloadPart(t, toFile, oldContent[:100], testEtag(oldContent), int64(len(oldContent)))
+	if content := testDownloadTempFile(t, server, toFile); !bytes.Equal(content, oldContent) {
+		t.Fatal("resume download content error:", string(content))
+	}
+	if len(server.ranges) != 1 || server.ranges[0] != "bytes=100-" {
+		t.Fatal("should resume with range request, but:", server.ranges)
+	}
+
+	// 两次下载之间服务端文件改变，重新下载
+	server.content = newContent
+	server.ranges = nil
+	testDownloadPart(t, toFile, oldContent[:100], testEtag(oldContent), int64(len(oldContent)))
+	if content := testDownloadTempFile(t, server, toFile); !bytes.Equal(content, newContent) {
+		t.Fatal("file changed between downloads, content error:", string(content))
+	}
+	if len(server.ranges) != 1 || server.ranges[0] != "" {
+		t.Fatal("should download from start, but:", server.ranges)
+	}
+
+	// 获取文件信息之后服务端文件改变，If-Range 不匹配时服务端返回 200，需清空临时文件重新下载
+	server.content = oldContent
+	server.afterHead = newContent
+	server.ranges = nil
+	testDownloadPart(t, toFile, oldContent[:100], testEtag(oldContent), int64(len(oldContent)))
+	if content := testDownloadTempFile(t, server, toFile); !bytes.Equal(content, newContent) {
+		t.Fatal("file changed while downloading, content error:", string(content))
+	}
+	if len(server.ranges) != 1 || server.ranges[0] != "bytes=100-" {
+		t.Fatal("should send range request, but:", server.ranges)
+	}
+	f, err := createDownloadFiles(toFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.partEtag != testEtag(newContent) {
+		t.Fatal("temp file info should be updated, but etag:", f.partEtag)
+	}
+
+	// 临时文件对应的服务端文件未知，不可接续下载
+	server.ranges = nil
+	testDownloadPart(t, toFile, newContent[:100], "", 0)
+	if content := testDownloadTempFile(t, server, toFile); !bytes.Equal(content, newContent) {
+		t.Fatal("temp file without info, content error:", string(content))
+	}
+	if len(server.ranges) != 1 || server.ranges[0] != "" {
+		t.Fatal("temp file without info should be discarded, but:", server.ranges)
+	}
+}
@@ -2,10 +2,13 @@ package download
 
 import (
 	"fmt"
-	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 )
 
 type fileInfo struct {
@@ -14,8 +17,18 @@ type fileInfo struct {
 	fileEncoding string // 文件编码方式
 
 	fileDir   string // 保存文件的路径，从 ToFile 解析
-	tempFile  string // 临时保存的文件路径 ToFile + .tmp
+	tempFile  string // 临时保存的文件路径 ToFile + .part
 	fromBytes int64  // 下载开始位置，检查本地 tempFile 文件，读取已下载文件长度
+
+	tempInfoFile string // 临时文件对应的服务端文件信息 ToFile + .part.info，接续下载时用于确认服务端文件未改变
+	partEtag     string // 临时文件开始下载时服务端文件的 etag
+	partFileSize int64  // 临时文件开始下载时服务端文件的大小
+	partSize     int64  // 临时文件中已下载的大小
+
+	servedHost         string // 下载成功时使用的 host
+	servedEtag         string // 下载时服务端返回的 etag
+	servedLastModified int64  // 下载时服务端返回的 Last-Modified，单位：秒
+	servedEncoding     string // 下载时服务端返回的 Content-Encoding
 }
 
 func createDownloadFiles(toFile, fileEncoding string) (*fileInfo, *data.CodeError) {
@@ -56,7 +69,8 @@ func (d *fileInfo) prepare() *data.CodeError {
 	}
 
 	d.fileDir = filepath.Dir(d.toAbsFile)
-	d.tempFile = fmt.Sprintf("%s.tmp", d.toAbsFile)
+	d.tempFile = fmt.Sprintf("%s.part", d.toAbsFile)
+	d.tempInfoFile = fmt.Sprintf("%s.info", d.tempFile)
 
 	err = os.MkdirAll(d.fileDir, 0775)
 	if err != nil {
@@ -70,23 +84,59 @@ func (d *fileInfo) prepare() *data.CodeError {
 	}
 
 	if tempFileStatus != nil && !tempFileStatus.IsDir() {
-		d.fromBytes = tempFileStatus.Size()
+		d.partSize = tempFileStatus.Size()
+	}
+
+	// 不知道临时文件对应的服务端文件时，无法确认已下载的部分是否可用，需重新下载
+	if d.partSize > 0 {
+		partInfo := &tempFileInfo{}
+		if e := utils.UnMarshalFromFile(d.tempInfoFile, partInfo); e != nil || len(partInfo.Etag) == 0 {
+			log.DebugF("download part, remove temp file:%s because its etag is unknown", d.tempFile)
+			return d.cleanTempFile()
+		}
+		d.partEtag = partInfo.Etag
+		d.partFileSize = partInfo.FileSize
 	}
+	d.fromBytes = d.partSize
 
 	return nil
 }
 
+// tempFileInfo 临时文件开始下载时服务端文件的信息
+type tempFileInfo struct {
+	Etag     string `json:"etag"`
+	FileSize int64  `json:"file_size"`
+}
+
+// isPartMatch 临时文件对应的服务端文件和当前服务端文件是否一致
+func (d *fileInfo) isPartMatch(etag string, fileSize int64) bool {
+	return d.partEtag == etag && d.partFileSize == fileSize
+}
+
+// saveTempFileInfo 开始下载临时文件时，记录对应的服务端文件信息
+func (d *fileInfo) saveTempFileInfo(etag string, fileSize int64) *data.CodeError {
+	d.partEtag = etag
+	d.partFileSize = fileSize
+	return utils.MarshalToFile(d.tempInfoFile, &tempFileInfo{
+		Etag:     etag,
+		FileSize: fileSize,
+	})
+}
+
 func (d *fileInfo) clean() *data.CodeError {
-	d.fromBytes = 0
 	err := os.Remove(d.toAbsFile)
-	if e := os.Remove(d.tempFile); err == nil {
-		err = e
+	if e := d.cleanTempFile(); err == nil && e != nil {
+		return e
 	}
 	return data.ConvertError(err)
 }
 
 func (d *fileInfo) cleanTempFile() *data.CodeError {
 	d.fromBytes = 0
+	d.partSize = 0
+	d.partEtag = ""
+	d.partFileSize = 0
+	_ = os.Remove(d.tempInfoFile)
 	err := os.Remove(d.tempFile)
 	return data.ConvertError(err)
 }
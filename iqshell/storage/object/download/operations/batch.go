@@ -7,6 +7,7 @@ import (
 
 	"github.com/qiniu/qshell/v2/iqshell"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/bandwidth"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
@@ -27,6 +28,15 @@ type BatchDownloadWithConfigInfo struct {
 	ItemSeparate string // 工作数据源：每行元素按分隔符分的分隔符
 
 	LocalDownloadConfig string
+	NoVerify            bool   // 下载完成后不校验文件 hash，优先级高于配置文件
+	RateLimit           string // 下载总带宽限制，优先级高于配置文件
+	KeyFile             string // 指定需要下载的 key 列表文件，优先级高于配置文件
+	StripPrefix         string // 计算本地路径时去除 key 的此前缀，优先级高于配置文件
+	Flatten             bool   // 不创建子目录，key 中的 / 替换为 FlattenSeparator，优先级高于配置文件
+	FlattenSeparator    string // 优先级高于配置文件
+	Domains             string // 多个下载域名，以逗号分隔，优先级高于配置文件
+	ForceDownload       bool   // 总是重新下载，优先级高于配置文件
+	Decompress          bool   // 按 Content-Encoding 解压下载的文件，优先级高于配置文件
 }
 
 func (info *BatchDownloadWithConfigInfo) Check() *data.CodeError {
@@ -65,6 +75,33 @@ func BatchDownloadWithConfig(cfg *iqshell.Config, info BatchDownloadWithConfigIn
 		log.ErrorF("UnMarshal: read log setting error:%v config file:%s", info.LocalDownloadConfig, err)
 		return
 	}
+	if info.NoVerify {
+		downloadInfo.NoVerify = true
+	}
+	if len(info.RateLimit) > 0 {
+		downloadInfo.RateLimit = info.RateLimit
+	}
+	if len(info.KeyFile) > 0 {
+		downloadInfo.KeyFile = info.KeyFile
+	}
+	if len(info.StripPrefix) > 0 {
+		downloadInfo.StripPrefix = info.StripPrefix
+	}
+	if info.Flatten {
+		downloadInfo.Flatten = true
+	}
+	if len(info.FlattenSeparator) > 0 {
+		downloadInfo.FlattenSeparator = info.FlattenSeparator
+	}
+	if len(info.Domains) > 0 {
+		downloadInfo.Domains = info.Domains
+	}
+	if info.ForceDownload {
+		downloadInfo.ForceDownload = true
+	}
+	if info.Decompress {
+		downloadInfo.Decompress = true
+	}
 	BatchDownload(cfg, downloadInfo)
 }
 
@@ -128,6 +165,11 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 	defer unlockHandler()
 
 	info.InputFile = info.KeyFile
+	if len(info.KeyFile) > 0 && len(info.Prefix) > 0 {
+		// 指定 key 列表时不再列举空间，前缀不生效
+		log.WarningF("prefix:%s is ignored because key file is set, all the keys in key file will be downloaded", info.Prefix)
+		info.Prefix = ""
+	}
 	hosts := getDownloadHosts(workspace.GetConfig(), &info.DownloadCfg)
 	if len(hosts) == 0 {
 		data.SetCmdStatusError()
@@ -135,13 +177,31 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 		return
 	}
 
+	// 配置多个域名时所有文件共享同一个 host provider，以便在域名间轮询并记录域名的状态
+	var sharedHostProvider host.Provider
+	if len(info.Domains) > 0 {
+		sharedHostProvider = host.NewBalanceProvider(hosts)
+	}
+
+	if len(info.RateLimit) > 0 {
+		rateLimit, pErr := utils.ParseFileSize(info.RateLimit)
+		if pErr != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("invalid rate limit:%v", pErr)
+			return
+		}
+		bandwidth.SetGlobalLimit(rateLimit)
+		log.InfoF("download rate limit:%s/s", utils.FormatFileSize(rateLimit))
+	}
+
 	dbPath := filepath.Join(workspace.GetJobDir(), ".recorder")
 	log.InfoF("download db dir:%s", dbPath)
 
 	exporter, err := export.NewFileExport(export.FileExporterConfig{
-		SuccessExportFilePath:   info.SuccessExportFilePath,
-		FailExportFilePath:      info.FailExportFilePath,
-		OverwriteExportFilePath: info.OverwriteExportFilePath,
+		SuccessExportFilePath:    info.SuccessExportFilePath,
+		FailExportFilePath:       info.FailExportFilePath,
+		OverwriteExportFilePath:  info.OverwriteExportFilePath,
+		DeadletterExportFilePath: info.DeadletterExportFilePath,
 	})
 	if err != nil {
 		log.Error(err)
@@ -150,6 +210,9 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 	}
 
 	metric := &Metric{}
+	if info.ShowProgress {
+		metric.DisablePrintProgress()
+	}
 	metric.Start()
 
 	hasPrefixes := len(info.Prefix) > 0
@@ -193,6 +256,23 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 		}
 	}
 
+	// 开启 flatten 或 strip prefix 时，不同的 key 可能对应同一个本地文件，需检测冲突，避免相互覆盖
+	var owners *localPathOwners
+	if info.Flatten || len(info.StripPrefix) > 0 {
+		if owners, err = newLocalPathOwners(filepath.Join(workspace.GetJobDir(), ".local_path_owners")); err != nil {
+			log.Error(err)
+			data.SetCmdStatusError()
+			return
+		}
+		defer owners.close()
+	}
+	checkLocalPathCollision := func(key, toFile string) *data.CodeError {
+		if owners == nil {
+			return nil
+		}
+		return owners.check(key, toFile)
+	}
+
 	apiPrefix := ""
 	if len(prefixes) == 1 {
 		// api 不支持多个 prefix
@@ -203,11 +283,18 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 		WorkProvider(NewWorkProvider(info.Bucket, apiPrefix, info.InputFile, info.ItemSeparate, func(apiInfo *download.DownloadActionInfo) *data.CodeError {
 			apiInfo.Bucket = info.Bucket
 			apiInfo.IsPublic = info.Public
-			apiInfo.HostProvider = host.NewListProvider(hosts)
+			if sharedHostProvider != nil {
+				apiInfo.HostProvider = sharedHostProvider
+			} else {
+				apiInfo.HostProvider = host.NewListProvider(hosts)
+			}
 			apiInfo.Referer = info.Referer
 			apiInfo.FileEncoding = info.FileEncoding
 			apiInfo.CheckHash = info.CheckHash
 			apiInfo.CheckSize = info.CheckSize
+			apiInfo.VerifyHash = !info.NoVerify
+			apiInfo.Force = info.ForceDownload
+			apiInfo.Decompress = info.Decompress
 			apiInfo.RemoveTempWhileError = info.RemoveTempWhileError
 			apiInfo.UseGetFileApi = info.GetFileApi
 			apiInfo.EnableSlice = info.EnableSlice
@@ -216,7 +303,11 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 			apiInfo.SliceFileSizeThreshold = info.SliceFileSizeThreshold
 
 			apiInfo.DestDir = info.DestDir
-			apiInfo.ToFile = filepath.Join(info.DestDir, apiInfo.Key)
+			if info.isKeyIgnoredByPath(apiInfo.Key) {
+				// 由 ShouldSkip 跳过
+				return nil
+			}
+			apiInfo.ToFile = filepath.Join(info.DestDir, info.localRelativePath(apiInfo.Key))
 			if savePathTemplate != nil {
 				if path, rErr := savePathTemplate.Run(apiInfo); rErr != nil {
 					return rErr
@@ -224,7 +315,7 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 					apiInfo.ToFile = path
 				}
 			}
-			return nil
+			return checkLocalPathCollision(apiInfo.Key, apiInfo.ToFile)
 		})).
 		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
 			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
@@ -233,6 +324,10 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 				metric.PrintProgress("Downloading: " + workInfo.Data)
 
 				if file, e := downloadFile(apiInfo); e != nil {
+					if file != nil {
+						// 保留已下载的大小，下次执行时接续下载
+						return file, e
+					}
 					return nil, e
 				} else {
 					log.DebugF("Download Result:%+v", file)
@@ -255,9 +350,16 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 		}).
 		ShouldRedo(func(workInfo *flow.WorkInfo, workRecord *flow.WorkRecord) (shouldRedo bool, cause *data.CodeError) {
 			if workRecord.Err != nil {
+				if result, _ := workRecord.Result.(*download.DownloadActionResult); result != nil && result.DownloadedSize > 0 {
+					log.DebugF("download part, %d bytes have been downloaded, %s", result.DownloadedSize, workInfo.Data)
+				}
 				return true, workRecord.Err
 			}
 
+			if info.ForceDownload {
+				return true, data.NewEmptyError().AppendDesc("force download")
+			}
+
 			apiInfo, _ := workInfo.Work.(*download.DownloadActionInfo)
 			recordApiInfo, _ := workRecord.Work.(*download.DownloadActionInfo)
 
@@ -270,17 +372,27 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 			}
 
 			isLocalFileNotChange, _ := utils.IsLocalFileMatchFileModifyTime(apiInfo.ToFile, result.FileModifyTime)
-			isServerFileNotChange := apiInfo.ServerFileHash == recordApiInfo.ServerFileHash
-			// 本地文件和服务端文件均没有变化，则不需要重新下载
-			if isLocalFileNotChange && isServerFileNotChange {
-				return false, nil
-			} else if !isLocalFileNotChange {
+			if !isLocalFileNotChange {
 				// 本地有变动，尝试检查 hash，hash 统一由单文件上传之前检查
 				return true, data.NewEmptyError().AppendDesc("local file has change")
-			} else {
-				// 服务端文件有变动，尝试检查 hash，hash 统一由单文件上传之前检查
+			}
+			if len(apiInfo.ServerFileHash) > 0 {
+				// 列举信息中有 hash 时以 hash 为准
+				if apiInfo.ServerFileHash == recordApiInfo.ServerFileHash {
+					return false, nil
+				}
 				return true, data.NewEmptyError().AppendDesc("server file has change")
 			}
+			if len(result.Etag) > 0 || result.LastModified > 0 {
+				// 服务端文件的 hash 未知，由 worker 使用上次下载时记录的 etag 和 Last-Modified 发送条件请求，304 时不再下载
+				apiInfo.IfNoneMatch = result.Etag
+				apiInfo.IfModifiedSince = result.LastModified
+				return true, data.NewEmptyError().AppendDesc("server file hash is unknown")
+			}
+			if len(recordApiInfo.ServerFileHash) > 0 {
+				return true, data.NewEmptyError().AppendDesc("server file hash is unknown")
+			}
+			return false, nil
 		}).
 		ShouldSkip(func(workInfo *flow.WorkInfo) (skip bool, cause *data.CodeError) {
 			apiInfo, _ := workInfo.Work.(*download.DownloadActionInfo)
@@ -292,12 +404,16 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 				//log.InfoF("Download Skip because key suffix doesn't match, [%s:%s]", apiInfo.Bucket, apiInfo.Key)
 				return true, data.NewEmptyError().AppendDescF("[%s:%s], suffix filter not match", apiInfo.Bucket, apiInfo.Key)
 			}
+			if info.isKeyIgnoredByPath(apiInfo.Key) {
+				return true, data.NewEmptyError().AppendDescF("[%s:%s], no local file for the key after strip prefix or flatten", apiInfo.Bucket, apiInfo.Key)
+			}
 			return false, nil
 		}).
 		FlowWillStartFunc(func(flow *flow.Flow) (err *data.CodeError) {
 			metric.AddTotalCount(flow.WorkProvider.WorkTotalCount())
 			return nil
 		}).
+		Deadletter(exporter.Deadletter()).
 		OnWorkSkip(func(workInfo *flow.WorkInfo, result flow.Result, err *data.CodeError) {
 			metric.AddCurrentCount(1)
 			metric.PrintProgress("Downloading: " + workInfo.Data)
@@ -317,8 +433,11 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 				exporter.Skip().Export(workInfo.Data)
 			}
 		}).
-		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result) {
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
 			res, _ := result.(*download.DownloadActionResult)
+			if res.Decompressed {
+				log.InfoF("Decompress [%s:%s], size:%d", info.Bucket, res.FileAbsPath, res.FileSize)
+			}
 			if res.IsExist {
 				metric.AddExistCount(1)
 			} else if res.IsUpdate {
@@ -327,9 +446,14 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 				metric.AddSuccessCount(1)
 			}
 
-			exporter.Success().Export(workInfo.Data)
+			if len(info.Domains) > 0 && len(res.Host) > 0 {
+				log.InfoF("Download [%s:%s] from domain:%s", info.Bucket, workInfo.Data, res.Host)
+				exporter.Success().ExportF("%s\t%s", workInfo.Data, res.Host)
+			} else {
+				exporter.Success().Export(workInfo.Data)
+			}
 		}).
-		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
 			metric.AddFailureCount(1)
 
 			exporter.Fail().ExportF("%s%s%s", workInfo.Data, flow.ErrorSeparate, err)
@@ -351,20 +475,8 @@ func BatchDownload(cfg *iqshell.Config, info BatchDownloadInfo) {
 		log.DebugF("save download result to path:%s", resultPath)
 	}
 
-	log.Info("-------Download Result-------")
-	log.InfoF("%10s%10d", "Total:", metric.TotalCount)
-	log.InfoF("%10s%10d", "Skipped:", metric.SkippedCount)
-	log.InfoF("%10s%10d", "Exists:", metric.ExistCount)
-	log.InfoF("%10s%10d", "Success:", metric.SuccessCount)
-	log.InfoF("%10s%10d", "Update:", metric.UpdateCount)
-	log.InfoF("%10s%10d", "Failure:", metric.FailureCount)
-	log.InfoF("%10s%10ds", "Duration:", metric.Duration)
-	log.InfoF("-----------------------------")
+	log.InfoF("Exists:%d, Update:%d", metric.ExistCount, metric.UpdateCount)
 	if workspace.GetConfig().Log.Enable() {
 		log.InfoF("See download log at path:%s", workspace.GetConfig().Log.LogFile.Value())
 	}
-
-	if !metric.IsCompletedSuccessfully() {
-		data.SetCmdStatusError()
-	}
 }
@@ -2,6 +2,8 @@ package operations
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
@@ -19,6 +21,7 @@ type DownloadCfg struct {
 	Public                 bool   `json:"public,omitempty"`
 	CheckSize              bool   `json:"check_size,omitempty"`
 	CheckHash              bool   `json:"check_hash,omitempty"`
+	NoVerify               bool   `json:"no_verify,omitempty"` // 下载完成后不校验文件 hash，下载经过处理的文件时 hash 不会一致
 	EnableSlice            bool   `json:"enable_slice"`
 	SliceFileSizeThreshold int64  `json:"slice_file_size_threshold"`
 	SliceSize              int64  `json:"slice_size"`
@@ -29,6 +32,15 @@ type DownloadCfg struct {
 	CdnDomain string `json:"cdn_domain,omitempty"` // 废弃
 	Domain    string `json:"domain,omitempty"`
 
+	// 按服务端返回的 Content-Encoding 解压下载的文件，支持 gzip、zstd
+	Decompress bool `json:"decompress,omitempty"`
+
+	// 总是重新下载，不检查本地文件及下载记录
+	ForceDownload bool `json:"force_download,omitempty"`
+
+	// 多个下载域名，以逗号分隔；下载时在域名间轮询，出错的域名降低优先级，优先级高于 Domain
+	Domains string `json:"domains,omitempty"`
+
 	// 是否使用 getfile api，私有云使用
 	GetFileApi bool `json:"get_file_api"`
 
@@ -37,6 +49,16 @@ type DownloadCfg struct {
 
 	// 下载状态保存路径
 	RecordRoot string `json:"record_root,omitempty"`
+
+	// 下载总带宽限制，所有线程共享，如：512k、5m，单位：B/s；为空时不限制
+	RateLimit string `json:"rate_limit,omitempty"`
+
+	// 计算本地路径时去除 key 的此前缀，不以此前缀开头的 key 不受影响
+	StripPrefix string `json:"strip_prefix,omitempty"`
+
+	// 将 key 中的 / 替换为 FlattenSeparator，所有文件均保存在 DestDir 下，不创建子目录
+	Flatten          bool   `json:"flatten,omitempty"`
+	FlattenSeparator string `json:"flatten_separator,omitempty"`
 }
 
 func DefaultDownloadCfg() DownloadCfg {
@@ -80,5 +102,43 @@ func (d *DownloadCfg) Check() *data.CodeError {
 	// 兼容处理，防止其他地方使用
 	d.CdnDomain = d.Domain
 
+	if len(d.Domains) > 0 && len(d.domainList()) == 0 {
+		return alert.Error("domains can't be empty: "+d.Domains, "")
+	}
+
+	if d.Flatten {
+		if len(d.FlattenSeparator) == 0 {
+			d.FlattenSeparator = "_"
+		}
+		if strings.ContainsAny(d.FlattenSeparator, "/\\") {
+			return alert.Error("flatten separator can't contain / or \\", "")
+		}
+	}
+
 	return nil
 }
+
+func (d *DownloadCfg) domainList() []string {
+	domains := make([]string, 0)
+	for _, domain := range strings.Split(d.Domains, ",") {
+		if domain = strings.TrimSpace(domain); len(domain) > 0 {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// isKeyIgnoredByPath key 无对应的本地路径时忽略：去除 StripPrefix 后为空，或开启 Flatten 时的文件夹
+func (d *DownloadCfg) isKeyIgnoredByPath(key string) bool {
+	relativePath := strings.TrimPrefix(key, d.StripPrefix)
+	return len(relativePath) == 0 || (d.Flatten && strings.HasSuffix(relativePath, "/"))
+}
+
+// localRelativePath 文件相对于 DestDir 的路径：先去除 StripPrefix，开启 Flatten 时再将 / 替换为 FlattenSeparator
+func (d *DownloadCfg) localRelativePath(key string) string {
+	relativePath := strings.TrimPrefix(key, d.StripPrefix)
+	if d.Flatten {
+		relativePath = strings.ReplaceAll(relativePath, "/", d.FlattenSeparator)
+	}
+	return relativePath
+}
@@ -18,7 +18,14 @@ func getDownloadHostProvider(cfg *config.Config, downloadCfg *DownloadCfg) host.
 
 func getDownloadHosts(cfg *config.Config, downloadCfg *DownloadCfg) []*host.Host {
 	var hosts []*host.Host
-	if downloadCfg.GetFileApi {
+	if domains := downloadCfg.domainList(); len(domains) > 0 {
+		for _, domain := range domains {
+			hosts = append(hosts, &host.Host{
+				Host:   "",
+				Domain: domain,
+			})
+		}
+	} else if downloadCfg.GetFileApi {
 		hosts = getFileApiHosts(cfg, downloadCfg)
 	} else {
 		hosts = defaultDownloadHosts(cfg, downloadCfg)
@@ -0,0 +1,59 @@
+package operations
+
+import (
+	"os"
+	"sync"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// localPathOwners 记录本地文件对应的 key，用于检测不同 key 对应同一个本地文件的冲突；
+// 记录保存在 leveldb 中，key 数量较多时不会占用过多内存
+type localPathOwners struct {
+	dbPath string
+	lock   sync.Mutex // 检测与记录需原子执行
+	db     *leveldb.DB
+}
+
+// newLocalPathOwners 记录仅在本次下载中有效，打开前会清除 dbPath 下已有的记录
+func newLocalPathOwners(dbPath string) (*localPathOwners, *data.CodeError) {
+	if err := os.RemoveAll(dbPath); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("remove local path owners db:%s error:%v", dbPath, err)
+	}
+	db, err := leveldb.OpenFile(dbPath, nil)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("open local path owners db:%s error:%v", dbPath, err)
+	}
+	return &localPathOwners{
+		dbPath: dbPath,
+		db:     db,
+	}, nil
+}
+
+// check 本地文件已对应其他 key 时返回错误，否则记录本地文件对应的 key
+func (o *localPathOwners) check(key, toFile string) *data.CodeError {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	owner, err := o.db.Get([]byte(toFile), nil)
+	if err == nil {
+		if string(owner) != key {
+			return data.NewEmptyError().AppendDescF("local file:%s conflicts with key:%s", toFile, string(owner))
+		}
+		return nil
+	} else if err != leveldb.ErrNotFound {
+		return data.NewEmptyError().AppendDescF("get owner of local file:%s error:%v", toFile, err)
+	}
+
+	if err = o.db.Put([]byte(toFile), []byte(key), nil); err != nil {
+		return data.NewEmptyError().AppendDescF("save owner of local file:%s error:%v", toFile, err)
+	}
+	return nil
+}
+
+// close 关闭并删除记录
+func (o *localPathOwners) close() {
+	_ = o.db.Close()
+	_ = os.RemoveAll(o.dbPath)
+}
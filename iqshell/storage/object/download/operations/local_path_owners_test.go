@@ -0,0 +1,27 @@
+package operations
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalPathOwners(t *testing.T) {
+	owners, err := newLocalPathOwners(filepath.Join(t.TempDir(), "owners"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer owners.close()
+
+	if err := owners.check("a/x.jpg", "dest/a_x.jpg"); err != nil {
+		t.Fatalf("first key should not conflict:%v", err)
+	}
+	if err := owners.check("a/x.jpg", "dest/a_x.jpg"); err != nil {
+		t.Fatalf("same key should not conflict:%v", err)
+	}
+	if err := owners.check("a_x.jpg", "dest/a_x.jpg"); err == nil {
+		t.Fatal("another key should conflict")
+	}
+	if err := owners.check("a_x.jpg", "dest/a_x.jpg.1"); err != nil {
+		t.Fatalf("another local file should not conflict:%v", err)
+	}
+}
@@ -175,7 +175,12 @@ func (w *workProvider) getWorkInfoOfKeys(keys []string) {
 				}
 				if w.infoResetHandler != nil {
 					if e := w.infoResetHandler(info); e != nil {
-						log.ErrorF("reset download api error:%v", e)
+						w.downloadItemChan <- &downloadItem{
+							workInfo: &flow.WorkInfo{
+								Data: item.Key,
+							},
+							err: data.NewEmptyError().AppendDesc("reset download api").AppendError(e),
+						}
 						continue
 					}
 				}
@@ -226,7 +231,14 @@ func (w *workProvider) getWorkInfoFromBucket() {
 			}
 			if w.infoResetHandler != nil {
 				if err := w.infoResetHandler(info); err != nil {
-					return false, err
+					// 单个文件出错不影响其他文件的下载
+					w.downloadItemChan <- &downloadItem{
+						workInfo: &flow.WorkInfo{
+							Data: object.Key,
+						},
+						err: data.NewEmptyError().AppendDesc("reset download api").AppendError(err),
+					}
+					return true, nil
 				}
 			}
 
@@ -6,7 +6,6 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
-	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 	"io"
 	"net/http"
 	"os"
@@ -148,8 +147,9 @@ func (s *sliceDownloader) download(info *DownloadApiInfo) (response *http.Respon
 	for i := 0; i < s.ConcurrentCount; i++ {
 		go func() {
 			for sl := range s.slices {
-				if workspace.IsCmdInterrupt() {
-					s.setDownloadError(data.CancelError)
+				// 中断时正在下载的文件继续下载，强制退出时 context 被取消
+				if e := info.context().Err(); e != nil {
+					s.setDownloadError(data.ConvertError(e))
 					break
 				}
 
@@ -224,6 +224,7 @@ func (s *sliceDownloader) downloadSlice(info *DownloadApiInfo, sl slice) *data.C
 		CheckHash:      false,
 		FileHash:       info.FileHash,
 		Progress:       nil,
+		ctx:            info.ctx,
 	})
 	if err != nil {
 		return err
@@ -0,0 +1,239 @@
+package object
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+const (
+	etagCacheFileName    = "etag_cache.jsonl"
+	etagCacheMaxSize     = 64 * 1024 * 1024 // 缓存文件（包括轮转的文件）的最大总大小，单位：B
+	etagAlgorithmV1      = "v1"
+	etagAlgorithmV2Start = "v2:"
+)
+
+// etagCacheEntry 本地文件的 etag，文件的大小或修改时间变化后失效
+type etagCacheEntry struct {
+	Key        string `json:"key"`
+	Size       int64  `json:"size"`
+	ModifyTime int64  `json:"modify_time"` // 单位：ns
+	Etag       string `json:"etag"`
+
+	current bool // 是否已保存在当前的缓存文件中
+}
+
+// etagCache 本地文件 etag 的缓存，key 为文件的绝对路径和 etag 算法；
+// 缓存以每行一条的方式追加到工作目录下的缓存文件中，后追加的覆盖之前的；当前文件超过 maxSize 的一半时轮转为 <文件名>.1，
+// 加载时先读 .1 再读当前文件，.1 中的缓存被使用时会重新追加到当前文件，因此最久未使用的缓存随轮转淘汰，缓存文件的总大小不超过 maxSize
+type etagCache struct {
+	mu       sync.Mutex
+	filePath string
+	maxSize  int64
+	loaded   bool
+	entries  map[string]*etagCacheEntry
+	pending  []*etagCacheEntry // 还未追加到缓存文件中的缓存
+}
+
+var defaultEtagCache = &etagCache{
+	maxSize: etagCacheMaxSize,
+}
+
+// etagAlgorithm etag v1 与分片信息无关，etag v2 与服务端文件的分片大小有关，分片信息不同的缓存不能混用
+func etagAlgorithm(parts []int64) string {
+	if len(parts) == 0 {
+		return etagAlgorithmV1
+	}
+	items := make([]string, 0, len(parts))
+	for _, part := range parts {
+		items = append(items, strconv.FormatInt(part, 10))
+	}
+	return etagAlgorithmV2Start + utils.Md5Hex(strings.Join(items, ","))
+}
+
+func etagCacheKey(filePath string, algorithm string) string {
+	if absPath, err := filepath.Abs(filePath); err == nil {
+		filePath = absPath
+	}
+	return filePath + "|" + algorithm
+}
+
+func (c *etagCache) rotatedFilePath() string {
+	return c.filePath + ".1"
+}
+
+func (c *etagCache) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	c.entries = make(map[string]*etagCacheEntry)
+
+	if len(c.filePath) == 0 {
+		if workspaceDir := workspace.GetWorkspace(); len(workspaceDir) > 0 {
+			c.filePath = filepath.Join(workspaceDir, etagCacheFileName)
+		}
+	}
+	if len(c.filePath) == 0 {
+		return
+	}
+
+	c.loadFile(c.rotatedFilePath(), false)
+	c.loadFile(c.filePath, true)
+}
+
+// loadFile 读取缓存文件，无法解析的行（如：写入时中断）忽略
+func (c *etagCache) loadFile(filePath string, current bool) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WarningF("read etag cache error:%v", err)
+		}
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		entry := &etagCacheEntry{}
+		if e := json.Unmarshal(scanner.Bytes(), entry); e != nil || len(entry.Key) == 0 {
+			continue
+		}
+		entry.current = current
+		c.entries[entry.Key] = entry
+	}
+	if err = scanner.Err(); err != nil {
+		log.WarningF("read etag cache error:%v", err)
+	}
+}
+
+func (c *etagCache) get(key string, size, modifyTime int64) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.load()
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	if entry.Size != size || entry.ModifyTime != modifyTime || len(entry.Etag) == 0 {
+		delete(c.entries, key)
+		return "", false
+	}
+	if !entry.current {
+		// 仍在使用的缓存追加到当前文件，避免轮转时被淘汰
+		entry.current = true
+		c.pending = append(c.pending, entry)
+	}
+	return entry.Etag, true
+}
+
+func (c *etagCache) set(key string, size, modifyTime int64, etag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.load()
+	entry := &etagCacheEntry{
+		Key:        key,
+		Size:       size,
+		ModifyTime: modifyTime,
+		Etag:       etag,
+		current:    true,
+	}
+	c.entries[key] = entry
+	c.pending = append(c.pending, entry)
+}
+
+// flush 把新增的缓存追加到缓存文件，当前文件超过 maxSize 的一半时轮转
+func (c *etagCache) flush() *data.CodeError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.pending) == 0 || len(c.filePath) == 0 {
+		return nil
+	}
+
+	buffer := &bytes.Buffer{}
+	for _, entry := range c.pending {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return data.NewEmptyError().AppendDesc("marshal etag cache").AppendError(err)
+		}
+		buffer.Write(line)
+		buffer.WriteByte('\n')
+	}
+
+	file, err := os.OpenFile(c.filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return data.NewEmptyError().AppendDesc("open etag cache").AppendError(err)
+	}
+	_, err = file.Write(buffer.Bytes())
+	if cErr := file.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return data.NewEmptyError().AppendDesc("write etag cache").AppendError(err)
+	}
+	c.pending = nil
+
+	if stat, sErr := os.Stat(c.filePath); sErr != nil || stat.Size() <= c.maxSize/2 {
+		return nil
+	}
+	if err = os.Rename(c.filePath, c.rotatedFilePath()); err != nil {
+		return data.NewEmptyError().AppendDesc("rotate etag cache").AppendError(err)
+	}
+	for _, entry := range c.entries {
+		entry.current = false
+	}
+	return nil
+}
+
+// FlushEtagCache 将本地文件 etag 的缓存保存到工作目录，使用了缓存的命令结束前调用
+func FlushEtagCache() {
+	if err := defaultEtagCache.flush(); err != nil {
+		log.WarningF("flush etag cache error:%v", err)
+	}
+}
+
+// LocalFileEtag 使用 etag v1 算法计算本地文件的 etag，useCache 参考 localFileEtag
+func LocalFileEtag(filePath string, useCache bool) (string, *data.CodeError) {
+	return localFileEtag(filePath, nil, useCache)
+}
+
+// localFileEtag 计算本地文件的 etag；parts 为服务端文件的分片信息，为空时使用 etag v1 算法；
+// useCache 为 true 时，文件的大小和修改时间与缓存一致则直接使用缓存的 etag，不再读取文件
+func localFileEtag(filePath string, parts []int64, useCache bool) (string, *data.CodeError) {
+	stat, sErr := os.Stat(filePath)
+	if sErr != nil {
+		return "", data.NewEmptyError().AppendDescF("get local file:%s status error:%v", filePath, sErr)
+	}
+
+	key := etagCacheKey(filePath, etagAlgorithm(parts))
+	if useCache {
+		if etag, ok := defaultEtagCache.get(key, stat.Size(), stat.ModTime().UnixNano()); ok {
+			log.DebugF("get etag of %s from cache:%s", filePath, etag)
+			return etag, nil
+		}
+	}
+
+	etag, err := utils.GetFileEtag(filePath, parts)
+	if err != nil {
+		return "", err
+	}
+
+	if useCache {
+		defaultEtagCache.set(key, stat.Size(), stat.ModTime().UnixNano(), etag)
+	}
+	return etag, nil
+}
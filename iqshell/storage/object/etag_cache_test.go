@@ -0,0 +1,109 @@
+package object
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+func TestLocalFileEtagCache(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(filePath, []byte("0123456789"), 0644); err != nil {
+		t.Fatal("write file error:", err)
+	}
+	expectEtag, _ := utils.GetEtag(filePath)
+
+	defaultEtagCache = &etagCache{filePath: filepath.Join(dir, etagCacheFileName), maxSize: etagCacheMaxSize}
+	if etag, err := localFileEtag(filePath, nil, true); err != nil || etag != expectEtag {
+		t.Fatalf("etag should be %s but %s, err:%v", expectEtag, etag, err)
+	}
+
+	// 篡改缓存，文件未变化时应使用缓存
+	stat, _ := os.Stat(filePath)
+	key := etagCacheKey(filePath, etagAlgorithmV1)
+	defaultEtagCache.set(key, stat.Size(), stat.ModTime().UnixNano(), "cached")
+	if etag, _ := localFileEtag(filePath, nil, true); etag != "cached" {
+		t.Fatalf("etag should be from cache but %s", etag)
+	}
+	if etag, _ := localFileEtag(filePath, nil, false); etag != expectEtag {
+		t.Fatalf("etag should not be from cache but %s", etag)
+	}
+
+	// 修改时间变化后缓存失效
+	modifyTime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filePath, modifyTime, modifyTime); err != nil {
+		t.Fatal("change file time error:", err)
+	}
+	if etag, _ := localFileEtag(filePath, nil, true); etag != expectEtag {
+		t.Fatalf("cache should be invalid after modify time changed, etag:%s", etag)
+	}
+
+	// 保存后重新加载
+	if err := defaultEtagCache.flush(); err != nil {
+		t.Fatal("flush error:", err)
+	}
+	defaultEtagCache = &etagCache{filePath: filepath.Join(dir, etagCacheFileName), maxSize: etagCacheMaxSize}
+	stat, _ = os.Stat(filePath)
+	if etag, ok := defaultEtagCache.get(key, stat.Size(), stat.ModTime().UnixNano()); !ok || etag != expectEtag {
+		t.Fatalf("etag should be loaded from file, etag:%s ok:%v", etag, ok)
+	}
+}
+
+func TestEtagCacheRotate(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), etagCacheFileName)
+	cache := &etagCache{filePath: filePath, maxSize: 400}
+	cache.set("a", 1, 1, "etag-a")
+	cache.set("b", 1, 1, "etag-b")
+	if err := cache.flush(); err != nil {
+		t.Fatal("flush error:", err)
+	}
+
+	// 追加而不是重写
+	cache.set("c", 1, 1, "etag-c")
+	if err := cache.flush(); err != nil {
+		t.Fatal("flush error:", err)
+	}
+	content, _ := os.ReadFile(filePath)
+	if lines := strings.Count(string(content), "\n"); lines != 3 {
+		t.Fatalf("cache file should have 3 lines, but:%d", lines)
+	}
+
+	// 超过最大大小的一半时轮转
+	cache.set("d", 1, 1, "etag-d")
+	if err := cache.flush(); err != nil {
+		t.Fatal("flush error:", err)
+	}
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Fatal("cache file should be rotated, err:", err)
+	}
+
+	// 轮转后使用的缓存追加到当前文件，未使用的随下次轮转淘汰
+	cache = &etagCache{filePath: filePath, maxSize: 400}
+	if etag, ok := cache.get("a", 1, 1); !ok || etag != "etag-a" {
+		t.Fatalf("etag should be loaded from rotated file, etag:%s ok:%v", etag, ok)
+	}
+	cache.set("e", 1, 1, "etag-e")
+	cache.set("f", 1, 1, "etag-f")
+	cache.set("g", 1, 1, "etag-g")
+	if err := cache.flush(); err != nil {
+		t.Fatal("flush error:", err)
+	}
+
+	cache = &etagCache{filePath: filePath, maxSize: 400}
+	cache.load()
+	for _, key := range []string{"a", "e", "f", "g"} {
+		if _, ok := cache.entries[key]; !ok {
+			t.Fatalf("%s should be cached", key)
+		}
+	}
+	for _, key := range []string{"b", "c", "d"} {
+		if _, ok := cache.entries[key]; ok {
+			t.Fatalf("%s should be evicted", key)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package object
 
 import (
-	"context"
 	"fmt"
 	"github.com/qiniu/go-sdk/v7/auth"
 	"github.com/qiniu/go-sdk/v7/storage"
@@ -74,8 +73,13 @@ type AsyncFetchApiInfo struct {
 	CallbackURL      string `json:"callbackurl,omitempty"`
 	CallbackBody     string `json:"callbackbody,omitempty"`
 	CallbackBodyType string `json:"callbackbodytype,omitempty"`
+	CallbackHost     string `json:"callbackhost,omitempty"`
 	FileType         int    `json:"file_type,omitempty"`
-	IgnoreSameKey    bool   `json:"ignore_same_key"` // false: 如果空间中已经存在同名文件则放弃本次抓取(仅对比 Key，不校验文件内容), true: 有同名会抓取
+
+	PersistentOps       string `json:"persistentOps,omitempty"`       // 抓取成功后触发的持久化处理指令
+	PersistentNotifyUrl string `json:"persistentNotifyUrl,omitempty"` // 持久化处理结果的通知地址
+	PersistentPipeline  string `json:"persistentPipeline,omitempty"`  // 持久化处理使用的队列
+	IgnoreSameKey       bool   `json:"ignore_same_key"`               // false: 如果空间中已经存在同名文件则放弃本次抓取(仅对比 Key，不校验文件内容), true: 有同名会抓取
 }
 
 type AsyncFetchApiResult struct {
@@ -103,8 +107,11 @@ func AsyncFetch(info AsyncFetchApiInfo) (result *AsyncFetchApiResult, err *data.
 
 	reqUrl += "/sisyphus/fetch"
 
+	ctx, cancel := workspace.NewTransferContext()
+	defer cancel()
+
 	result = &AsyncFetchApiResult{}
-	e = bm.Client.CredentialedCallWithJson(context.Background(), bm.Mac, auth.TokenQiniu, result, "POST", reqUrl, nil, info)
+	e = bm.Client.CredentialedCallWithJson(ctx, bm.Mac, auth.TokenQiniu, result, "POST", reqUrl, nil, info)
 	return result, data.ConvertError(e)
 }
 
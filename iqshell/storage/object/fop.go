@@ -2,11 +2,18 @@ package object
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
 
+	"github.com/qiniu/go-sdk/v7/auth"
 	"github.com/qiniu/go-sdk/v7/storage"
 	"github.com/qiniu/qshell/v2/iqshell/common/account"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/client"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 )
 
@@ -85,8 +92,8 @@ func getOperationManager(bucket string) (*storage.OperationManager, *data.CodeEr
 	cfg := workspace.GetStorageConfig()
 	opManager := storage.NewOperationManager(mac, cfg)
 	if len(bucket) != 0 && (opManager.Cfg.Region == nil || opManager.Cfg.Zone == nil || len(opManager.Cfg.Region.ApiHost) == 0) {
-		if region, e := storage.GetZone(opManager.Mac.AccessKey, bucket); e != nil {
-			return nil, data.ConvertError(e)
+		if region := workspace.GetBucketRegion(bucket); region == nil {
+			return nil, data.NewEmptyError().AppendDescF("can't get region of bucket:%s", bucket)
 		} else {
 			opManager.Cfg.Region = region
 			opManager.Cfg.Zone = region
@@ -95,3 +102,68 @@ func getOperationManager(bucket string) (*storage.OperationManager, *data.CodeEr
 	}
 	return opManager, nil
 }
+
+// pipelineApiHost 多媒体处理队列管理接口的服务地址，优先使用配置的 api 服务地址，否则使用空间所在区域的 api 服务地址
+func pipelineApiHost(bucket string) (string, *data.CodeError) {
+	cfg := workspace.GetStorageConfig()
+	if len(cfg.ApiHost) > 0 {
+		return utils.Endpoint(cfg.UseHTTPS, cfg.ApiHost), nil
+	}
+
+	opManager, err := getOperationManager(bucket)
+	if err != nil {
+		return "", err
+	}
+	if opManager.Cfg.Region == nil || len(opManager.Cfg.Region.ApiHost) == 0 {
+		return "", data.NewEmptyError().AppendDescF("can't get api host of bucket:%s", bucket)
+	}
+	return opManager.Cfg.Region.GetApiHost(cfg.UseHTTPS), nil
+}
+
+// PipelineExists 检查多媒体处理队列是否存在，bucket 用于确定多媒体处理接口所在的区域
+func PipelineExists(bucket, pipeline string) (bool, *data.CodeError) {
+	if len(pipeline) == 0 {
+		return false, alert.CannotEmptyError("pipeline", "")
+	}
+
+	mac, err := account.GetMac()
+	if err != nil {
+		return false, err
+	}
+
+	apiHost, err := pipelineApiHost(bucket)
+	if err != nil {
+		return false, err
+	}
+
+	reqUrl := apiHost + "/pipeline/" + url.PathEscape(pipeline)
+	e := client.DefaultStorageClient().CredentialedCall(workspace.GetContext(), mac, auth.TokenQiniu, nil, "GET", reqUrl, nil)
+	if e == nil {
+		return true, nil
+	}
+	if info, ok := e.(*storage.ErrorInfo); ok && (info.Code == http.StatusNotFound || info.Code == 612) {
+		return false, nil
+	}
+	return false, data.ConvertError(e)
+}
+
+// fopUnsupportedFormatError 对格式不受支持的文件执行 avinfo、imageInfo 等处理时，服务端返回的错误信息
+const fopUnsupportedFormatError = "unsupported format"
+
+// isFopUnsupportedFormat 判断处理接口的返回是否说明文件格式不受支持：状态码为 415，或状态码为 400 且错误信息为 unsupported format；
+// 其他 400 错误（如：参数错误）不属于此类
+func isFopUnsupportedFormat(statusCode int, body []byte) bool {
+	if statusCode == http.StatusUnsupportedMediaType {
+		return true
+	}
+	if statusCode != http.StatusBadRequest {
+		return false
+	}
+	ret := &struct {
+		Error string `json:"error"`
+	}{}
+	if e := json.Unmarshal(body, ret); e != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(ret.Error), fopUnsupportedFormatError)
+}
@@ -0,0 +1,98 @@
+package object
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/client"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+)
+
+// ImageInfoFop 获取图片基本信息的处理指令
+const ImageInfoFop = "imageInfo"
+
+// ImageInfoNotImage 文件不是图片时 ImageInfoResult.Error 的值
+const ImageInfoNotImage = "not an image"
+
+type ImageInfoResult struct {
+	Source     string `json:"source"` // bucket:key 或 url
+	IsImage    bool   `json:"is_image"`
+	Width      int    `json:"width,omitempty"`
+	Height     int    `json:"height,omitempty"`
+	Format     string `json:"format,omitempty"`
+	ColorModel string `json:"color_model,omitempty"`
+	Size       int64  `json:"size,omitempty"`
+	Error      string `json:"error,omitempty"` // 不是图片时的说明
+}
+
+var _ flow.Result = (*ImageInfoResult)(nil)
+
+func (r *ImageInfoResult) IsValid() bool {
+	return len(r.Source) > 0
+}
+
+// FopUrl 在文件链接后追加处理指令，链接需为未签名的链接
+func FopUrl(fileUrl string, fop string) string {
+	if strings.Contains(fileUrl, "?") {
+		return fileUrl + "&" + fop
+	}
+	return fileUrl + "?" + fop
+}
+
+// ImageInfo 请求 imageInfoUrl（已包含 imageInfo 处理指令及签名）获取图片信息；
+// 文件不是图片时不返回错误，返回 IsImage 为 false 的结果
+func ImageInfo(source string, imageInfoUrl string) (*ImageInfoResult, *data.CodeError) {
+	if len(imageInfoUrl) == 0 {
+		return nil, alert.CannotEmptyError("url", "")
+	}
+
+	resp, err := client.DefaultStorageClient().DoRequest(workspace.GetContext(), "GET", imageInfoUrl, nil)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("get image info of %s error:%v", source, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("read image info of %s error:%v", source, err)
+	}
+
+	// 对非图片文件执行 imageInfo 时，服务端返回格式不支持的错误
+	if isFopUnsupportedFormat(resp.StatusCode, body) {
+		return &ImageInfoResult{
+			Source: source,
+			Error:  ImageInfoNotImage,
+		}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, data.NewError(resp.StatusCode, "get image info of "+source+" error:"+strings.TrimSpace(string(body)))
+	}
+
+	ret := &struct {
+		Width      int    `json:"width"`
+		Height     int    `json:"height"`
+		Format     string `json:"format"`
+		ColorModel string `json:"colorModel"`
+		Size       int64  `json:"size"`
+	}{}
+	if e := json.Unmarshal(body, ret); e != nil || len(ret.Format) == 0 {
+		return &ImageInfoResult{
+			Source: source,
+			Error:  ImageInfoNotImage,
+		}, nil
+	}
+	return &ImageInfoResult{
+		Source:     source,
+		IsImage:    true,
+		Width:      ret.Width,
+		Height:     ret.Height,
+		Format:     ret.Format,
+		ColorModel: ret.ColorModel,
+		Size:       ret.Size,
+	}, nil
+}
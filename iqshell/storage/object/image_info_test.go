@@ -0,0 +1,52 @@
+package object
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestImageInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != ImageInfoFop {
+			t.Errorf("query error, query:%s", r.URL.RawQuery)
+		}
+		switch r.URL.Path {
+		case "/a.jpg":
+			_, _ = w.Write([]byte(`{"size":1024,"format":"jpeg","width":640,"height":427,"colorModel":"ycbcr"}`))
+		case "/a.mp4":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"unsupported format"}`))
+		case "/c.jpg":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid argument"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	result, err := ImageInfo("a.jpg", FopUrl(server.URL+"/a.jpg", ImageInfoFop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsImage || result.Width != 640 || result.Height != 427 || result.Format != "jpeg" || result.ColorModel != "ycbcr" {
+		t.Fatalf("image info error, result:%+v", result)
+	}
+
+	result, err = ImageInfo("a.mp4", FopUrl(server.URL+"/a.mp4", ImageInfoFop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsImage || result.Error != ImageInfoNotImage {
+		t.Fatalf("not image result error, result:%+v", result)
+	}
+
+	if _, err = ImageInfo("c.jpg", FopUrl(server.URL+"/c.jpg", ImageInfoFop)); err == nil || err.Code != http.StatusBadRequest {
+		t.Fatalf("other bad request should fail with 400, err:%v", err)
+	}
+
+	if _, err = ImageInfo("b.jpg", FopUrl(server.URL+"/b.jpg", ImageInfoFop)); err == nil || err.Code != http.StatusNotFound {
+		t.Fatalf("missing file should fail with 404, err:%v", err)
+	}
+}
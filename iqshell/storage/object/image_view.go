@@ -0,0 +1,76 @@
+package object
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+const (
+	ImageViewMaxMode = 5    // imageView2 的模式为 0 ~ 5
+	ImageViewMaxSize = 9999 // imageView2 的宽、高最大为 9999
+)
+
+var imageViewFormats = []string{"jpg", "jpeg", "gif", "png", "webp", "tiff", "bmp", "heic", "avif"}
+
+// ImageViewInfo imageView2 缩略图的参数
+type ImageViewInfo struct {
+	Mode    int    // 缩略模式，0 ~ 5
+	Width   int    // 宽，0 表示不限制
+	Height  int    // 高，0 表示不限制
+	Format  string // 输出格式，为空表示和原图相同
+	Quality int    // 输出质量，1 ~ 100，0 表示默认质量
+}
+
+func (i *ImageViewInfo) Check() *data.CodeError {
+	if i.Mode < 0 || i.Mode > ImageViewMaxMode {
+		return alert.Error(fmt.Sprintf("invalid mode: %d", i.Mode), fmt.Sprintf("mode should be between 0 and %d", ImageViewMaxMode))
+	}
+	if i.Width < 0 || i.Width > ImageViewMaxSize {
+		return alert.Error(fmt.Sprintf("invalid width: %d", i.Width), fmt.Sprintf("width should be between 1 and %d", ImageViewMaxSize))
+	}
+	if i.Height < 0 || i.Height > ImageViewMaxSize {
+		return alert.Error(fmt.Sprintf("invalid height: %d", i.Height), fmt.Sprintf("height should be between 1 and %d", ImageViewMaxSize))
+	}
+	if i.Width == 0 && i.Height == 0 {
+		return alert.Error("width and height can't be both empty", "")
+	}
+	if i.Quality < 0 || i.Quality > 100 {
+		return alert.Error(fmt.Sprintf("invalid quality: %d", i.Quality), "quality should be between 1 and 100")
+	}
+	if len(i.Format) > 0 {
+		for _, format := range imageViewFormats {
+			if strings.EqualFold(format, i.Format) {
+				return nil
+			}
+		}
+		return alert.Error("invalid format: "+i.Format, "format should be one of "+strings.Join(imageViewFormats, ", "))
+	}
+	return nil
+}
+
+// Fop imageView2 处理指令，如：imageView2/2/w/200/h/200
+func (i *ImageViewInfo) Fop() string {
+	fop := fmt.Sprintf("imageView2/%d", i.Mode)
+	if i.Width > 0 {
+		fop += fmt.Sprintf("/w/%d", i.Width)
+	}
+	if i.Height > 0 {
+		fop += fmt.Sprintf("/h/%d", i.Height)
+	}
+	if len(i.Format) > 0 {
+		fop += "/format/" + strings.ToLower(i.Format)
+	}
+	if i.Quality > 0 {
+		fop += fmt.Sprintf("/q/%d", i.Quality)
+	}
+	return fop
+}
+
+// SaveAsFop 在处理指令后追加 saveas，将处理结果保存到 bucket:key
+func SaveAsFop(fop string, bucket string, key string) string {
+	return fop + "|saveas/" + base64.URLEncoding.EncodeToString([]byte(bucket+":"+key))
+}
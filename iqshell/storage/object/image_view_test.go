@@ -0,0 +1,38 @@
+package object
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestImageViewFop(t *testing.T) {
+	info := &ImageViewInfo{Mode: 2, Width: 200, Height: 100, Format: "PNG", Quality: 80}
+	if err := info.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if fop := info.Fop(); fop != "imageView2/2/w/200/h/100/format/png/q/80" {
+		t.Fatalf("fop error, fop:%s", fop)
+	}
+	if fop := (&ImageViewInfo{Mode: 0, Height: 50}).Fop(); fop != "imageView2/0/h/50" {
+		t.Fatalf("fop error, fop:%s", fop)
+	}
+
+	saveAs := SaveAsFop("imageView2/0/h/50", "bucket", "thumb.jpg")
+	if saveAs != "imageView2/0/h/50|saveas/"+base64.URLEncoding.EncodeToString([]byte("bucket:thumb.jpg")) {
+		t.Fatalf("save as fop error, fop:%s", saveAs)
+	}
+
+	for _, invalid := range []*ImageViewInfo{
+		{Mode: 6, Width: 100},
+		{Mode: -1, Width: 100},
+		{Mode: 1, Width: 10000},
+		{Mode: 1, Height: -1},
+		{Mode: 1},
+		{Mode: 1, Width: 100, Quality: 101},
+		{Mode: 1, Width: 100, Format: "psd"},
+	} {
+		if err := invalid.Check(); err == nil {
+			t.Fatalf("image view should be invalid, info:%+v", invalid)
+		}
+	}
+}
@@ -26,6 +26,10 @@ func (l *ChangeLifecycleApiInfo) GetBucket() string {
 	return l.Bucket
 }
 
+func (l *ChangeLifecycleApiInfo) GetKey() string {
+	return l.Key
+}
+
 func (l *ChangeLifecycleApiInfo) ToOperation() (string, *data.CodeError) {
 	if len(l.Bucket) == 0 || len(l.Key) == 0 {
 		return "", alert.CannotEmptyError("change lifecycle operation bucket or key", "")
@@ -21,6 +21,7 @@ type MatchApiInfo struct {
 	CheckMode      int    `json:"-"`          // 检测模式， 0: 检测 hash，其他检测 size 【可选】
 	ServerFileHash string `json:"file_hash"`  // 服务端文件 Etag，可以是 etagV1, 也可以是 etagV2；【可选】没有会从服务获取
 	ServerFileSize int64  `json:"file_size"`  // 服务端文件大小；【可选】没有会从服务获取
+	UseEtagCache   bool   `json:"-"`          // 本地文件的大小和修改时间未变化时使用缓存的 etag，不再读取文件计算；【可选】
 }
 
 func (m *MatchApiInfo) WorkId() string {
@@ -32,8 +33,12 @@ func (m *MatchApiInfo) CheckModeHash() bool {
 }
 
 type MatchResult struct {
-	Exist bool
-	Match bool
+	Exist   bool
+	Match   bool
+	Checked bool // 是否已完成本地文件与服务端文件的对比，为 false 时表示对比前出错，如：读取本地文件失败
+
+	LocalFileHash  string // 本地文件的 hash，仅检测 hash 且已完成对比时有值
+	ServerFileHash string // 服务端文件的 hash，仅检测 hash 且已完成对比时有值
 }
 
 var _ flow.Result = (*MatchResult)(nil)
@@ -82,6 +87,7 @@ func matchSize(info MatchApiInfo) (result *MatchResult, err *data.CodeError) {
 	if sErr != nil {
 		return result, data.NewEmptyError().AppendDescF("Match check size, get local file status").AppendError(sErr)
 	}
+	result.Checked = true
 	if info.ServerFileSize == stat.Size() {
 		result.Match = true
 		return result, nil
@@ -97,12 +103,6 @@ func matchHash(info MatchApiInfo) (result *MatchResult, err *data.CodeError) {
 		Match: false,
 	}
 
-	hashFile, oErr := os.Open(info.LocalFile)
-	if oErr != nil {
-		return result, data.NewEmptyError().AppendDescF("Match check hash, get local file error:%v", oErr)
-	}
-	defer hashFile.Close()
-
 	var serverObjectStat *StatusResult
 	if len(info.ServerFileHash) == 0 {
 		if stat, sErr := Status(StatusApiInfo{
@@ -135,7 +135,10 @@ func matchHash(info MatchApiInfo) (result *MatchResult, err *data.CodeError) {
 				serverObjectStat = &stat
 			}
 		}
-		if h, eErr := utils.EtagV2(hashFile, serverObjectStat.Parts); eErr != nil {
+		if len(serverObjectStat.Parts) == 0 {
+			return result, data.NewEmptyError().AppendDesc("Match check hash, etag v2, object part info is empty")
+		}
+		if h, eErr := localFileEtag(info.LocalFile, serverObjectStat.Parts, info.UseEtagCache); eErr != nil {
 			return result, data.NewEmptyError().AppendDescF("Match check hash, get file etag v2").AppendError(eErr)
 		} else {
 			hash = h
@@ -143,7 +146,7 @@ func matchHash(info MatchApiInfo) (result *MatchResult, err *data.CodeError) {
 		log.DebugF("Match check hash, get etag by v2 for key:%s hash:%s", info.Key, hash)
 	} else {
 		log.DebugF("Match check hash, get etag by v1 for key:%s", info.Key)
-		if h, eErr := utils.EtagV1(hashFile); eErr != nil {
+		if h, eErr := localFileEtag(info.LocalFile, nil, info.UseEtagCache); eErr != nil {
 			return result, data.NewEmptyError().AppendDescF("Match check hash, get file etag v1").AppendError(eErr)
 		} else {
 			hash = h
@@ -151,6 +154,9 @@ func matchHash(info MatchApiInfo) (result *MatchResult, err *data.CodeError) {
 		log.DebugF("Match check hash, get etag by v1 for key:%s hash:%s", info.Key, hash)
 	}
 	log.DebugF("Match check hash,       server hash, key:%s hash:%s", info.Key, info.ServerFileHash)
+	result.Checked = true
+	result.LocalFileHash = hash
+	result.ServerFileHash = info.ServerFileHash
 	if hash != info.ServerFileHash {
 		return result, data.NewEmptyError().AppendDescF("Match check hash, file hash doesn't match for key:%s, local file hash:%s server file hash:%s", info.Key, hash, info.ServerFileHash)
 	}
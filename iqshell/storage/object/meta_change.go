@@ -0,0 +1,61 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+type ChangeMetaApiInfo struct {
+	Bucket   string            `json:"bucket"`
+	Key      string            `json:"key"`
+	MetaData map[string]string `json:"meta_data"` // 自定义元数据，名称不含 x-qn-meta- 前缀，值为空表示删除此元数据
+}
+
+func (c *ChangeMetaApiInfo) GetBucket() string {
+	return c.Bucket
+}
+
+func (c *ChangeMetaApiInfo) GetKey() string {
+	return c.Key
+}
+
+func (c *ChangeMetaApiInfo) ToOperation() (string, *data.CodeError) {
+	if len(c.Bucket) == 0 || len(c.Key) == 0 {
+		return "", alert.CannotEmptyError("change meta operation bucket or key", "")
+	}
+
+	if len(c.MetaData) == 0 {
+		return "", alert.CannotEmptyError("change meta operation meta data", "")
+	}
+
+	return storage.URIChangeMimeAndMeta(c.Bucket, c.Key, "", c.MetaData), nil
+}
+
+func (c *ChangeMetaApiInfo) WorkId() string {
+	return fmt.Sprintf("ChangeMeta|%s|%s|%s", c.Bucket, c.Key, c.MetaDataString())
+}
+
+// MetaDataString 按名称排序输出元数据，如：x-qn-meta-a=1,x-qn-meta-b=，值为空表示删除
+func (c *ChangeMetaApiInfo) MetaDataString() string {
+	names := make([]string, 0, len(c.MetaData))
+	for name := range c.MetaData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	items := make([]string, 0, len(names))
+	for _, name := range names {
+		items = append(items, fmt.Sprintf("x-qn-meta-%s=%s", name, c.MetaData[name]))
+	}
+	return strings.Join(items, ",")
+}
+
+func ChangeMeta(info *ChangeMetaApiInfo) (*batch.OperationResult, *data.CodeError) {
+	return batch.One(info)
+}
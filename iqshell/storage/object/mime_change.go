@@ -18,6 +18,10 @@ func (c *ChangeMimeApiInfo) GetBucket() string {
 	return c.Bucket
 }
 
+func (c *ChangeMimeApiInfo) GetKey() string {
+	return c.Key
+}
+
 func (c *ChangeMimeApiInfo) ToOperation() (string, *data.CodeError) {
 	if len(c.Bucket) == 0 || len(c.Key) == 0 {
 		return "", alert.CannotEmptyError("change mime operation bucket or key", "")
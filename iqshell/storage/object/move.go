@@ -14,12 +14,22 @@ type MoveApiInfo struct {
 	DestBucket   string `json:"dest_bucket"`
 	DestKey      string `json:"dest_key"`
 	Force        bool   `json:"force"`
+
+	SkipSourceNotExist bool `json:"-"` // 执行前 stat 源文件，源文件不存在时跳过，用于撤销移动操作
 }
 
 func (m *MoveApiInfo) GetBucket() string {
 	return m.SourceBucket
 }
 
+func (m *MoveApiInfo) GetKey() string {
+	return m.SourceKey
+}
+
+func (m *MoveApiInfo) GetDestKey() string {
+	return m.DestKey
+}
+
 func (m *MoveApiInfo) ToOperation() (string, *data.CodeError) {
 	if len(m.SourceBucket) == 0 || len(m.SourceKey) == 0 || len(m.DestBucket) == 0 || len(m.DestKey) == 0 {
 		return "", alert.CannotEmptyError("move operation bucket or key of source and dest", "")
@@ -28,6 +38,18 @@ func (m *MoveApiInfo) ToOperation() (string, *data.CodeError) {
 	return storage.URIMove(m.SourceBucket, m.SourceKey, m.DestBucket, m.DestKey, m.Force), nil
 }
 
+func (m *MoveApiInfo) IsStatSkipEnable() bool {
+	return m.SkipSourceNotExist
+}
+
+// ShouldSkipByStat 源文件不存在时跳过
+func (m *MoveApiInfo) ShouldSkipByStat(stat *batch.OperationResult) (skip bool, cause string) {
+	if stat != nil && data.NewError(stat.Code, stat.Error).IsNotFound() {
+		return true, "source not exist"
+	}
+	return false, ""
+}
+
 func (m *MoveApiInfo) WorkId() string {
 	return fmt.Sprintf("Move|%s|%s|%s|%s", m.SourceBucket, m.SourceKey, m.DestBucket, m.DestKey)
 }
@@ -0,0 +1,284 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+type AvInfoInfo struct {
+	Source string // <Bucket>:<Key> 或者文件链接 【必选】
+	Domain string // 输入为 <Bucket>:<Key> 时使用的下载域名，默认为空间的第一个域名 【可选】
+	Sign   bool   // 输入为链接时是否对链接签名，私有空间的链接需要签名 【可选】
+	Raw    bool   // 输出 avinfo 返回的原始内容 【可选】
+
+	bucket string
+	key    string
+}
+
+func (info *AvInfoInfo) Check() *data.CodeError {
+	if len(info.Source) == 0 {
+		return alert.CannotEmptyError("Bucket:Key or Url", "")
+	}
+	if isHttpUrl(info.Source) {
+		return nil
+	}
+
+	var ok bool
+	if info.bucket, info.key, ok = splitBucketKey(info.Source); !ok {
+		return alert.Error("invalid source: "+info.Source, "source should be <Bucket>:<Key> or an url")
+	}
+	return nil
+}
+
+// AvInfo 获取音视频的时长、码率、编码、分辨率及采样率等信息
+func AvInfo(cfg *iqshell.Config, info AvInfoInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	requestUrl, err := sourceFopRequestUrl(info.Source, info.bucket, info.key, info.Domain, info.Sign, object.AvInfoFop)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	result, err := object.AvInfo(info.Source, requestUrl)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+	if !result.IsMedia {
+		data.SetCmdStatusError()
+	}
+
+	if info.Raw && len(result.Raw) > 0 {
+		log.Alert(string(result.Raw))
+	} else if data.IsOutputFormatJson() {
+		outputAvInfoJson(result)
+	} else {
+		log.Alert(avInfoString(result))
+	}
+}
+
+func outputAvInfoJson(result *object.AvInfoResult) string {
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		log.ErrorF("marshal av info of %s error:%v", result.Source, err)
+		return ""
+	}
+	log.Alert(string(bytes))
+	return string(bytes)
+}
+
+func avVideoString(video *object.AvVideoStream) string {
+	if video == nil {
+		return "-"
+	}
+	desc := fmt.Sprintf("%s %dx%d", video.Codec, video.Width, video.Height)
+	if video.FrameRate > 0 {
+		desc += fmt.Sprintf(" %.2ffps", video.FrameRate)
+	}
+	if video.BitRate > 0 {
+		desc += fmt.Sprintf(" %dkbps", video.BitRate/1000)
+	}
+	return desc
+}
+
+func avAudioString(audio *object.AvAudioStream) string {
+	if audio == nil {
+		return "-"
+	}
+	desc := audio.Codec
+	if audio.SampleRate > 0 {
+		desc += fmt.Sprintf(" %dHz", audio.SampleRate)
+	}
+	if audio.Channels > 0 {
+		desc += fmt.Sprintf(" %dch", audio.Channels)
+	}
+	if audio.BitRate > 0 {
+		desc += fmt.Sprintf(" %dkbps", audio.BitRate/1000)
+	}
+	return desc
+}
+
+func avInfoString(result *object.AvInfoResult) string {
+	if !result.IsMedia {
+		return fmt.Sprintf("%s: %s", result.Source, result.Error)
+	}
+
+	builder := &strings.Builder{}
+	builder.WriteString(fmt.Sprintf("%-10s%s\n", "Source:", result.Source))
+	builder.WriteString(fmt.Sprintf("%-10s%s\n", "Format:", result.Format))
+	builder.WriteString(fmt.Sprintf("%-10s%.3fs\n", "Duration:", result.Duration))
+	builder.WriteString(fmt.Sprintf("%-10s%dkbps\n", "BitRate:", result.BitRate/1000))
+	builder.WriteString(fmt.Sprintf("%-10s%s\n", "Size:", utils.FormatFileSize(result.Size)))
+	builder.WriteString(fmt.Sprintf("%-10s%s\n", "Video:", avVideoString(result.Video)))
+	builder.WriteString(fmt.Sprintf("%-10s%s", "Audio:", avAudioString(result.Audio)))
+	return builder.String()
+}
+
+// avInfoLine 批量获取时每个文件输出一行：来源、时长、码率、视频、音频，以 \t 分隔
+func avInfoLine(result *object.AvInfoResult) string {
+	if !result.IsMedia {
+		return fmt.Sprintf("%s\t%s", result.Source, result.Error)
+	}
+	return fmt.Sprintf("%s\t%.3fs\t%dkbps\t%s\t%s", result.Source, result.Duration, result.BitRate/1000,
+		avVideoString(result.Video), avAudioString(result.Audio))
+}
+
+type avInfoWork struct {
+	Source string // key 或 url
+	Url    string // 包含处理指令的请求链接
+}
+
+func (w *avInfoWork) WorkId() string {
+	return w.Source
+}
+
+type BatchAvInfoInfo struct {
+	BatchInfo batch.Info
+	Bucket    string // 输入为 key 时，根据空间获取下载域名
+	Domain    string // 输入为 key 时使用的下载域名，优先级高于 Bucket
+	Sign      bool   // 输入为链接时是否对链接签名
+	Raw       bool   // 每个文件输出一行 avinfo 返回的原始内容
+}
+
+func (info *BatchAvInfoInfo) Check() *data.CodeError {
+	return info.BatchInfo.Check()
+}
+
+// isKeyMode 输入的每行是否为 key，否则为文件链接
+func (info *BatchAvInfoInfo) isKeyMode() bool {
+	return len(info.Bucket) > 0 || len(info.Domain) > 0
+}
+
+// BatchAvInfo 批量获取音视频信息，每个文件输出一行；非音视频文件单独统计，不视为失败
+func BatchAvInfo(cfg *iqshell.Config, info BatchAvInfoInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	exporter, err := export.NewFileExport(info.BatchInfo.FileExporterConfig)
+	if err != nil {
+		log.Error(err)
+		data.SetCmdStatusError()
+		return
+	}
+
+	domain := info.Domain
+	if info.isKeyMode() && len(domain) == 0 {
+		if domain, err = bucket.DomainOfBucket(info.Bucket); err != nil {
+			log.Error(err)
+			data.SetCmdStatusError()
+			return
+		}
+	}
+
+	var notMediaCount int64
+	metric := &batch.Metric{}
+	metric.Start()
+	info.BatchInfo.NewFlow().
+		WorkProviderWithFile(info.BatchInfo.InputFile,
+			info.BatchInfo.EnableStdin,
+			flow.NewItemsWorkCreator(info.BatchInfo.ItemSeparate, 1, func(items []string) (work flow.Work, err *data.CodeError) {
+				source := strings.TrimSpace(items[0])
+				if source == "" {
+					return nil, alert.Error("key or url invalid", "")
+				}
+
+				if !info.isKeyMode() {
+					requestUrl, uErr := fopRequestUrl(source, object.AvInfoFop, info.Sign)
+					if uErr != nil {
+						return nil, uErr
+					}
+					return &avInfoWork{Source: source, Url: requestUrl}, nil
+				}
+
+				requestUrl, uErr := sourceFopRequestUrl(source, info.Bucket, source, domain, true, object.AvInfoFop)
+				if uErr != nil {
+					return nil, uErr
+				}
+				return &avInfoWork{Source: source, Url: requestUrl}, nil
+			})).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
+				work := workInfo.Work.(*avInfoWork)
+				result, iErr := object.AvInfo(work.Source, work.Url)
+				if iErr != nil {
+					return nil, iErr
+				}
+				return result, nil
+			}), nil
+		})).
+		FlowWillStartFunc(func(flow *flow.Flow) (err *data.CodeError) {
+			metric.AddTotalCount(flow.WorkProvider.WorkTotalCount())
+			return nil
+		}).
+		Deadletter(exporter.Deadletter()).
+		OnWorkSkip(func(work *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			metric.AddCurrentCount(1)
+			metric.AddSkippedCount(1)
+			metric.PrintProgress("Batching:" + work.Data)
+			exporter.Fail().ExportF("%s%s%v", work.Data, flow.ErrorSeparate, err)
+			log.DebugF("Skip line:%s because:%v", work.Data, err)
+		}).
+		OnWorkSuccess(func(work *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
+			metric.AddCurrentCount(1)
+			metric.AddSuccessCount(1)
+			metric.PrintProgress("Batching:" + work.Data)
+
+			r, _ := result.(*object.AvInfoResult)
+			if r == nil {
+				return
+			}
+			if !r.IsMedia {
+				atomic.AddInt64(&notMediaCount, 1)
+			}
+			exporter.Success().Export(work.Data)
+
+			var line string
+			if info.Raw && len(r.Raw) > 0 {
+				line = string(r.Raw)
+				log.Alert(line)
+			} else if data.IsOutputFormatJson() {
+				line = outputAvInfoJson(r)
+			} else {
+				line = avInfoLine(r)
+				log.Alert(line)
+			}
+			if len(line) > 0 {
+				exporter.Result().Export(line)
+			}
+		}).
+		OnWorkFail(func(work *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
+			metric.AddCurrentCount(1)
+			metric.AddFailureCount(1)
+			metric.PrintProgress("Batching:" + work.Data)
+
+			exporter.Fail().ExportF("%s%s%v", work.Data, flow.ErrorSeparate, err)
+			log.Error(err)
+		}).Build().Start()
+
+	metric.End()
+
+	log.InfoF("NotMedia:%d", notMediaCount)
+}
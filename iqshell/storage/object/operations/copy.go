@@ -15,7 +15,11 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
 )
 
-type CopyInfo object.CopyApiInfo
+type CopyInfo struct {
+	object.CopyApiInfo
+
+	Preserve CopyPreserveInfo
+}
 
 func (info *CopyInfo) Check() *data.CodeError {
 	if len(info.SourceBucket) == 0 {
@@ -41,7 +45,25 @@ func Copy(cfg *iqshell.Config, info CopyInfo) {
 		return
 	}
 
-	result, err := object.Copy((*object.CopyApiInfo)(&info))
+	// 复制前查询源文件，用于补齐目标文件的属性
+	var source *object.StatusResult
+	if info.Preserve.enabled() {
+		status, sErr := object.Status(object.StatusApiInfo{
+			Bucket: info.SourceBucket,
+			Key:    info.SourceKey,
+		})
+		if sErr != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("Copy Failed, '%s:%s' => '%s:%s', stat source file Error: %v",
+				info.SourceBucket, info.SourceKey,
+				info.DestBucket, info.DestKey,
+				sErr)
+			return
+		}
+		source = &status
+	}
+
+	result, err := object.Copy(&info.CopyApiInfo)
 	if err != nil || result == nil {
 		data.SetCmdStatusError()
 		log.ErrorF("Copy Failed, '%s:%s' => '%s:%s', Error: %v",
@@ -55,6 +77,9 @@ func Copy(cfg *iqshell.Config, info CopyInfo) {
 		log.InfoF("Copy Success, [%s:%s] => [%s:%s]",
 			info.SourceBucket, info.SourceKey,
 			info.DestBucket, info.DestKey)
+		if info.Preserve.enabled() {
+			preserveCopied(source, &info.CopyApiInfo, info.Preserve)
+		}
 	} else {
 		data.SetCmdStatusError()
 		log.ErrorF("Copy Failed, '%s:%s' => '%s:%s', Code: %d, Error: %s",
@@ -68,6 +93,7 @@ type BatchCopyInfo struct {
 	BatchInfo    batch.Info
 	SourceBucket string
 	DestBucket   string
+	Preserve     CopyPreserveInfo
 }
 
 func (info *BatchCopyInfo) Check() *data.CodeError {
@@ -104,11 +130,18 @@ func BatchCopy(cfg *iqshell.Config, info BatchCopyInfo) {
 		return
 	}
 
+	// 复制成功的文件攒够一批后批量补齐属性
+	var preserver *copyPreserver
+	if info.Preserve.enabled() {
+		preserver = newCopyPreserver(info.Preserve)
+	}
+
 	batch.NewHandler(info.BatchInfo).
 		EmptyOperation(func() flow.Work {
 			return &object.CopyApiInfo{}
 		}).
 		SetFileExport(exporter).
+		ItemFields(1, "key", "dstKey").
 		ItemsToOperation(func(items []string) (operation batch.Operation, err *data.CodeError) {
 			// 如果只有一个参数，源 key 即为目标 key
 			srcKey, destKey := items[0], items[0]
@@ -130,25 +163,29 @@ func BatchCopy(cfg *iqshell.Config, info BatchCopyInfo) {
 		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
 			apiInfo, ok := (operation).(*object.CopyApiInfo)
 			if apiInfo == nil || !ok {
-				data.SetCmdStatusError()
 				log.ErrorF("Copy Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
 				return
 			}
 
-			in := (*CopyInfo)(apiInfo)
 			if result.IsSuccess() {
 				log.InfoF("Copy Success, '%s:%s' => '%s:%s'",
-					in.SourceBucket, in.SourceKey,
-					in.DestBucket, in.DestKey)
+					apiInfo.SourceBucket, apiInfo.SourceKey,
+					apiInfo.DestBucket, apiInfo.DestKey)
+				if preserver != nil {
+					preserver.add(apiInfo)
+				}
 			} else {
-				data.SetCmdStatusError()
 				log.ErrorF("Copy Failed, '%s:%s' => '%s:%s', Code: %d, Error: %s",
-					in.SourceBucket, in.SourceKey,
-					in.DestBucket, in.DestKey,
+					apiInfo.SourceBucket, apiInfo.SourceKey,
+					apiInfo.DestBucket, apiInfo.DestKey,
 					result.Code, result.Error)
 			}
 		}).
 		OnError(func(err *data.CodeError) {
 			log.ErrorF("Batch copy error:%v:", err)
 		}).Start()
+
+	if preserver != nil {
+		preserver.flush()
+	}
 }
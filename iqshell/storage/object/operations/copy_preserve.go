@@ -0,0 +1,231 @@
+package operations
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+// copyPreserveBatchSize 批量补齐属性时每批的文件数，每个文件需要查询源文件和目标文件
+const copyPreserveBatchSize = 500
+
+// CopyPreserveInfo 复制后需要保证目标文件与源文件一致的属性
+type CopyPreserveInfo struct {
+	Meta bool // 保留源文件的自定义元数据 【可选】
+	Type bool // 保留源文件的存储类型 【可选】
+}
+
+func (p *CopyPreserveInfo) enabled() bool {
+	return p.Meta || p.Type
+}
+
+// copyAttributeGaps 对比源文件和目标文件，返回目标文件缺失或与源文件不同的自定义元数据，以及是否需要修改存储类型；
+// 目标文件上额外的元数据不做处理
+func copyAttributeGaps(source, dest *object.StatusResult, preserve CopyPreserveInfo) (metaData map[string]string, changeType bool) {
+	if preserve.Meta {
+		for name, value := range source.MetaData {
+			if destValue, ok := dest.MetaData[name]; ok && destValue == value {
+				continue
+			}
+			if metaData == nil {
+				metaData = make(map[string]string)
+			}
+			metaData[name] = value
+		}
+	}
+	if preserve.Type {
+		changeType = source.Type != dest.Type
+	}
+	return metaData, changeType
+}
+
+// preserveCopiedAttributes 复制成功后查询目标文件，补齐复制时未保留的源文件的自定义元数据及存储类型
+func preserveCopiedAttributes(source *object.StatusResult, destBucket, destKey string, preserve CopyPreserveInfo) (applied []string, err *data.CodeError) {
+	dest, err := object.Status(object.StatusApiInfo{
+		Bucket: destBucket,
+		Key:    destKey,
+	})
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("stat dest file error:%v", err)
+	}
+
+	metaData, changeType := copyAttributeGaps(source, &dest, preserve)
+	if len(metaData) > 0 {
+		apiInfo := &object.ChangeMetaApiInfo{
+			Bucket:   destBucket,
+			Key:      destKey,
+			MetaData: metaData,
+		}
+		if result, cErr := object.ChangeMeta(apiInfo); cErr != nil {
+			return applied, data.NewEmptyError().AppendDescF("set meta '%s' error:%v", apiInfo.MetaDataString(), cErr)
+		} else if !result.IsSuccess() {
+			return applied, data.NewEmptyError().AppendDescF("set meta '%s' error, code:%d error:%s", apiInfo.MetaDataString(), result.Code, result.Error)
+		}
+		applied = append(applied, "meta:"+apiInfo.MetaDataString())
+	}
+	if changeType {
+		if result, cErr := object.ChangeType(&object.ChangeTypeApiInfo{
+			Bucket: destBucket,
+			Key:    destKey,
+			Type:   source.Type,
+		}); cErr != nil {
+			return applied, data.NewEmptyError().AppendDescF("change type %d => %d error:%v", dest.Type, source.Type, cErr)
+		} else if !result.IsSuccess() {
+			return applied, data.NewEmptyError().AppendDescF("change type %d => %d error, code:%d error:%s", dest.Type, source.Type, result.Code, result.Error)
+		}
+		applied = append(applied, fmt.Sprintf("type:%d => %d", dest.Type, source.Type))
+	}
+	return applied, nil
+}
+
+// preserveCopied 复制成功后补齐目标文件的属性并输出结果；source 为空时先查询源文件
+func preserveCopied(source *object.StatusResult, in *object.CopyApiInfo, preserve CopyPreserveInfo) {
+	if source == nil {
+		status, err := object.Status(object.StatusApiInfo{
+			Bucket: in.SourceBucket,
+			Key:    in.SourceKey,
+		})
+		if err != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("Preserve Failed, '%s:%s' => '%s:%s', stat source file Error: %v",
+				in.SourceBucket, in.SourceKey, in.DestBucket, in.DestKey, err)
+			return
+		}
+		source = &status
+	}
+
+	applied, err := preserveCopiedAttributes(source, in.DestBucket, in.DestKey, preserve)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Preserve Failed, '%s:%s' => '%s:%s', Error: %v",
+			in.SourceBucket, in.SourceKey, in.DestBucket, in.DestKey, err)
+		return
+	}
+	if len(applied) > 0 {
+		log.InfoF("Preserve Success, '%s:%s' => '%s:%s', re-applied %s",
+			in.SourceBucket, in.SourceKey, in.DestBucket, in.DestKey, strings.Join(applied, ", "))
+	}
+}
+
+// copyPreserver 收集复制成功的文件，攒够一批后批量查询源文件和目标文件，再批量补齐目标文件缺失的属性；
+// 批量复制时使用，避免每个文件串行发送多个请求
+type copyPreserver struct {
+	preserve CopyPreserveInfo
+	lock     sync.Mutex
+	pending  []*object.CopyApiInfo
+}
+
+func newCopyPreserver(preserve CopyPreserveInfo) *copyPreserver {
+	return &copyPreserver{
+		preserve: preserve,
+		pending:  make([]*object.CopyApiInfo, 0, copyPreserveBatchSize),
+	}
+}
+
+// add 添加复制成功的文件，攒够一批时补齐这一批文件的属性
+func (p *copyPreserver) add(in *object.CopyApiInfo) {
+	p.lock.Lock()
+	p.pending = append(p.pending, in)
+	var ins []*object.CopyApiInfo
+	if len(p.pending) >= copyPreserveBatchSize {
+		ins = p.pending
+		p.pending = make([]*object.CopyApiInfo, 0, copyPreserveBatchSize)
+	}
+	p.lock.Unlock()
+
+	if len(ins) > 0 {
+		p.preserveBatch(ins)
+	}
+}
+
+// flush 补齐剩余文件的属性，复制全部结束后调用
+func (p *copyPreserver) flush() {
+	p.lock.Lock()
+	ins := p.pending
+	p.pending = nil
+	p.lock.Unlock()
+
+	if len(ins) > 0 {
+		p.preserveBatch(ins)
+	}
+}
+
+func (p *copyPreserver) preserveBatch(ins []*object.CopyApiInfo) {
+	failed := func(in *object.CopyApiInfo, format string, args ...interface{}) {
+		data.SetCmdStatusError()
+		log.ErrorF("Preserve Failed, '%s:%s' => '%s:%s', Error: %s",
+			in.SourceBucket, in.SourceKey, in.DestBucket, in.DestKey, fmt.Sprintf(format, args...))
+	}
+
+	// 批量查询源文件和目标文件，结果中源文件和目标文件交替排列
+	statInfos := make([]object.StatusApiInfo, 0, len(ins)*2)
+	for _, in := range ins {
+		statInfos = append(statInfos,
+			object.StatusApiInfo{Bucket: in.SourceBucket, Key: in.SourceKey},
+			object.StatusApiInfo{Bucket: in.DestBucket, Key: in.DestKey})
+	}
+	stats, err := object.BatchStatus(statInfos)
+	if err != nil {
+		for _, in := range ins {
+			failed(in, "batch stat error:%v", err)
+		}
+		return
+	}
+
+	type change struct {
+		in          *object.CopyApiInfo
+		description string
+	}
+	changes := make([]*change, 0, len(ins))
+	changeOperations := make([]batch.Operation, 0, len(ins))
+	for i, in := range ins {
+		source, dest := stats[2*i], stats[2*i+1]
+		if !source.IsSuccess() {
+			failed(in, "stat source file error, %s", source.ErrorDescription())
+			continue
+		}
+		if !dest.IsSuccess() {
+			failed(in, "stat dest file error, %s", dest.ErrorDescription())
+			continue
+		}
+
+		metaData, changeType := copyAttributeGaps(source, dest, p.preserve)
+		if len(metaData) > 0 {
+			apiInfo := &object.ChangeMetaApiInfo{
+				Bucket:   in.DestBucket,
+				Key:      in.DestKey,
+				MetaData: metaData,
+			}
+			changes = append(changes, &change{in: in, description: "meta:" + apiInfo.MetaDataString()})
+			changeOperations = append(changeOperations, apiInfo)
+		}
+		if changeType {
+			changes = append(changes, &change{in: in, description: fmt.Sprintf("type:%d => %d", dest.Type, source.Type)})
+			changeOperations = append(changeOperations, &object.ChangeTypeApiInfo{
+				Bucket: in.DestBucket,
+				Key:    in.DestKey,
+				Type:   source.Type,
+			})
+		}
+	}
+	if len(changeOperations) == 0 {
+		return
+	}
+
+	results, err := batch.Some(changeOperations)
+	for i, c := range changes {
+		if i >= len(results) {
+			failed(c.in, "re-apply %s error:%v", c.description, err)
+		} else if !results[i].IsSuccess() {
+			failed(c.in, "re-apply %s error, %s", c.description, results[i].ErrorDescription())
+		} else {
+			log.InfoF("Preserve Success, '%s:%s' => '%s:%s', re-applied %s",
+				c.in.SourceBucket, c.in.SourceKey, c.in.DestBucket, c.in.DestKey, c.description)
+		}
+	}
+}
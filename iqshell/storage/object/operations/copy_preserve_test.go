@@ -0,0 +1,31 @@
+package operations
+
+import (
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+)
+
+func TestCopyAttributeGaps(t *testing.T) {
+	source := &object.StatusResult{MetaData: map[string]string{"a": "1", "b": "2", "c": "3"}}
+	source.Type = 1
+	dest := &object.StatusResult{MetaData: map[string]string{"a": "1", "b": "x", "d": "4"}}
+
+	metaData, changeType := copyAttributeGaps(source, dest, CopyPreserveInfo{Meta: true, Type: true})
+	if len(metaData) != 2 || metaData["b"] != "2" || metaData["c"] != "3" {
+		t.Fatalf("meta gaps error:%v", metaData)
+	}
+	if !changeType {
+		t.Fatal("type should be changed")
+	}
+
+	metaData, changeType = copyAttributeGaps(source, dest, CopyPreserveInfo{})
+	if metaData != nil || changeType {
+		t.Fatal("nothing should be preserved when disabled")
+	}
+
+	dest.Type = 1
+	if _, changeType = copyAttributeGaps(source, dest, CopyPreserveInfo{Type: true}); changeType {
+		t.Fatal("type should not be changed when it is the same")
+	}
+}
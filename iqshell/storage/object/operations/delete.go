@@ -19,12 +19,25 @@ import (
 type DeleteInfo struct {
 	Bucket string
 	Key    string
+
+	Prefix    string     // 删除此前缀的所有文件，和 Key 不能同时指定
+	Limit     int64      // 按前缀删除时最多删除的文件数，0：不限制
+	BatchInfo batch.Info // 按前缀删除时批量删除的配置
 }
 
 func (info *DeleteInfo) Check() *data.CodeError {
 	if len(info.Bucket) == 0 {
 		return alert.CannotEmptyError("Bucket", "")
 	}
+	if len(info.Prefix) > 0 {
+		if len(info.Key) > 0 {
+			return alert.Error("Key and --prefix can't be specified at the same time", "")
+		}
+		if info.Limit < 0 {
+			return alert.Error("--limit should be greater than or equal to 0", "")
+		}
+		return info.BatchInfo.Check()
+	}
 	if len(info.Key) == 0 {
 		return alert.CannotEmptyError("Key", "")
 	}
@@ -32,12 +45,23 @@ func (info *DeleteInfo) Check() *data.CodeError {
 }
 
 func Delete(cfg *iqshell.Config, info DeleteInfo) {
+	if len(info.Prefix) > 0 {
+		cfg.JobPathBuilder = func(cmdPath string) string {
+			jobId := utils.Md5Hex(fmt.Sprintf("%s:%s:%s", cfg.CmdCfg.CmdId, info.Bucket, info.Prefix))
+			return filepath.Join(cmdPath, jobId)
+		}
+	}
 	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
 		Checker: &info,
 	}); !shouldContinue {
 		return
 	}
 
+	if len(info.Prefix) > 0 {
+		deleteByPrefix(info)
+		return
+	}
+
 	result, err := object.Delete(&object.DeleteApiInfo{
 		Bucket:          info.Bucket,
 		Key:             info.Key,
@@ -95,6 +119,12 @@ func BatchDelete(cfg *iqshell.Config, info BatchDeleteInfo) {
 		return
 	}
 
+	// 删除前展示删除的范围，输入为 listbucket 的结果时可以统计出删除的总大小
+	if !info.BatchInfo.Force && !info.BatchInfo.DryRun && !info.BatchInfo.Validate {
+		info.BatchInfo.ConfirmName = info.Bucket
+		info.BatchInfo.WorkTotalSize = batchDeleteTotalSize(&info.BatchInfo)
+	}
+
 	lineParser := bucket.NewListLineParser()
 	batch.NewHandler(info.BatchInfo).
 		SetFileExport(exporter).
@@ -120,35 +150,51 @@ func BatchDelete(cfg *iqshell.Config, info BatchDeleteInfo) {
 				},
 			}, nil
 		}).
-		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
-			apiInfo, ok := (operation).(*object.DeleteApiInfo)
-			if !ok {
-				data.SetCmdStatusError()
-				log.ErrorF("Delete Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
-				return
-			}
-			if result.IsSuccess() {
-				if len(apiInfo.Condition.PutTime) == 0 {
-					log.InfoF("Delete Success, [%s:%s]", apiInfo.Bucket, apiInfo.Key)
-				} else {
-					log.InfoF("Delete Success, [%s:%s], PutTime:'%s'", apiInfo.Bucket, apiInfo.Key, apiInfo.Condition.PutTime)
-				}
-			} else {
-				data.SetCmdStatusError()
-				if len(apiInfo.Condition.PutTime) == 0 {
-					log.ErrorF("Delete Failed, [%s:%s], Code: %d, Error: %s",
-						apiInfo.Bucket, apiInfo.Key, result.Code, result.Error)
-				} else {
-					log.ErrorF("Delete Failed, [%s:%s], PutTime:'%s', Code: %d, Error: %s",
-						apiInfo.Bucket, apiInfo.Key, apiInfo.Condition.PutTime, result.Code, result.Error)
-				}
-			}
-		}).
+		OnResult(onBatchDeleteResult).
 		OnError(func(err *data.CodeError) {
 			log.ErrorF("Batch delete error:%v:", err)
 		}).Start()
 }
 
+// onBatchDeleteResult 输出批量删除的结果
+func onBatchDeleteResult(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
+	apiInfo, ok := (operation).(*object.DeleteApiInfo)
+	if !ok {
+		log.ErrorF("Delete Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
+		return
+	}
+	if result.IsSuccess() {
+		if len(apiInfo.Condition.PutTime) == 0 {
+			log.InfoF("Delete Success, [%s:%s]", apiInfo.Bucket, apiInfo.Key)
+		} else {
+			log.InfoF("Delete Success, [%s:%s], PutTime:'%s'", apiInfo.Bucket, apiInfo.Key, apiInfo.Condition.PutTime)
+		}
+	} else {
+		if len(apiInfo.Condition.PutTime) == 0 {
+			log.ErrorF("Delete Failed, [%s:%s], Code: %d, Error: %s",
+				apiInfo.Bucket, apiInfo.Key, result.Code, result.Error)
+		} else {
+			log.ErrorF("Delete Failed, [%s:%s], PutTime:'%s', Code: %d, Error: %s",
+				apiInfo.Bucket, apiInfo.Key, apiInfo.Condition.PutTime, result.Code, result.Error)
+		}
+	}
+}
+
+// batchDeleteTotalSize 统计输入文件中所有文件的总大小，输入中没有文件大小或从标准输入读取时返回 0
+func batchDeleteTotalSize(info *batch.Info) int64 {
+	totalSize := int64(0)
+	lineParser := bucket.NewListLineParser()
+	if _, err := batch.ScanInputFile(info, func(lineNumber int, items []string) {
+		if listObject, e := lineParser.Parse(items); e == nil && listObject != nil {
+			totalSize += listObject.Fsize
+		}
+	}); err != nil {
+		log.WarningF("get total size of input file error:%v", err)
+		return 0
+	}
+	return totalSize
+}
+
 type DeleteAfterInfo struct {
 	Bucket    string
 	Key       string
@@ -265,7 +311,6 @@ func BatchDeleteAfter(cfg *iqshell.Config, info BatchDeleteInfo) {
 		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
 			apiInfo, ok := (operation).(*object.DeleteApiInfo)
 			if !ok {
-				data.SetCmdStatusError()
 				log.ErrorF("Delete Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
 				return
 			}
@@ -276,7 +321,6 @@ func BatchDeleteAfter(cfg *iqshell.Config, info BatchDeleteInfo) {
 					log.InfoF("Expire Success, [%s:%s], delete after '%d' days", apiInfo.Bucket, apiInfo.Key, apiInfo.DeleteAfterDays)
 				}
 			} else {
-				data.SetCmdStatusError()
 				log.ErrorF("Expire Failed, [%s:%s], DeleteAfterDays:'%d', Code: %d, Error: %s", apiInfo.Bucket, apiInfo.Key, apiInfo.DeleteAfterDays, result.Code, result.Error)
 			}
 		}).
@@ -0,0 +1,71 @@
+package operations
+
+import (
+	"strconv"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+// deleteByPrefix 边列举边删除前缀下的所有文件，列举的结果通过 chan 传递，内存占用和文件数无关；
+// 删除时以列举到的 PutTime 为条件，列举后被覆盖的文件不会被删除
+func deleteByPrefix(info DeleteInfo) {
+	bucketManager, err := bucket.GetBucketManager()
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Delete Failed, get bucket manager error:%v", err)
+		return
+	}
+
+	exporter, err := export.NewFileExport(info.BatchInfo.FileExporterConfig)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	if !info.BatchInfo.Force && !info.BatchInfo.DryRun {
+		if info.Limit > 0 {
+			log.WarningF("<DANGER> Delete at most %d files with prefix:%s in bucket:%s", info.Limit, info.Prefix, info.Bucket)
+		} else {
+			log.WarningF("<DANGER> Delete all the files with prefix:%s in bucket:%s", info.Prefix, info.Bucket)
+		}
+		// 文件数未知，输入验证码后还需输入空间名确认
+		info.BatchInfo.ConfirmName = info.Bucket
+		info.BatchInfo.ConfirmThreshold = 1
+	}
+
+	lister, err := newPrefixLister(bucketManager, info.Bucket, info.Prefix, info.Limit, &info.BatchInfo.Info, func(entry storage.ListItem) flow.Work {
+		return &object.DeleteApiInfo{
+			Bucket: info.Bucket,
+			Key:    entry.Key,
+			Condition: batch.OperationCondition{
+				PutTime: strconv.FormatInt(entry.PutTime, 10),
+			},
+		}
+	})
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+	defer lister.stop()
+	info.BatchInfo.WorkProvider = lister
+
+	batch.NewHandler(info.BatchInfo).
+		SetFileExport(exporter).
+		EmptyOperation(func() flow.Work {
+			return &object.DeleteApiInfo{}
+		}).
+		OnResult(onBatchDeleteResult).
+		OnError(func(err *data.CodeError) {
+			data.SetCmdStatusError()
+			log.ErrorF("Delete by prefix error:%v", err)
+		}).Start()
+}
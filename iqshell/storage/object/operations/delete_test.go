@@ -0,0 +1,159 @@
+package operations
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+func TestBatchDeleteTotalSize(t *testing.T) {
+	inputFile := filepath.Join(t.TempDir(), "input.txt")
+	content := "a.jpg\t1024\tFhash\t16000000000000000\n\nb.jpg\t2048\tFhash\t16000000000000000\n"
+	if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	size := batchDeleteTotalSize(&batch.Info{InputFile: inputFile, ItemSeparate: "\t"})
+	if size != 3072 {
+		t.Fatalf("total size should be 3072, but:%d", size)
+	}
+
+	size = batchDeleteTotalSize(&batch.Info{InputFile: "-", ItemSeparate: "\t"})
+	if size != 0 {
+		t.Fatalf("total size of stdin should be 0, but:%d", size)
+	}
+}
+
+// newTestBatchServer 批量操作服务，key 以 missing 开头的文件不存在，其他操作成功；ops 不为空时记录收到的操作
+func newTestBatchServer(ops *[]string) *httptest.Server {
+	lock := &sync.Mutex{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		results := make([]map[string]interface{}, 0, len(r.PostForm["op"]))
+		for _, op := range r.PostForm["op"] {
+			if ops != nil {
+				lock.Lock()
+				*ops = append(*ops, op)
+				lock.Unlock()
+			}
+			items := strings.Split(op, "/")
+			entry, _ := base64.URLEncoding.DecodeString(items[2])
+			if strings.Contains(string(entry), ":missing") {
+				results = append(results, map[string]interface{}{
+					"code": 612,
+					"data": map[string]string{"error": "no such file or directory"},
+				})
+			} else {
+				results = append(results, map[string]interface{}{"code": 200})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Reqid", "reqid")
+		_ = json.NewEncoder(w).Encode(results)
+	}))
+}
+
+// setupTestBatchAccount 使用临时的工作区及环境变量中的账户，避免读写用户目录
+func setupTestBatchAccount(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(account.AccessKeyEnvKey, "ak")
+	t.Setenv(account.SecretKeyEnvKey, "sk")
+}
+
+func testBatchDeleteStatus(t *testing.T, server *httptest.Server, content string) int {
+	inputFile := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data.SetCmdStatus(data.StatusOK)
+	BatchDelete(&iqshell.Config{
+		Region: "z0",
+		RsHost: server.URL,
+		CmdCfg: config.Config{CmdId: "batchdelete"},
+	}, BatchDeleteInfo{
+		BatchInfo: batch.Info{
+			Info:      flow.Info{Force: true},
+			InputFile: inputFile,
+		},
+		Bucket: "bucket",
+	})
+	return data.GetCmdStatus()
+}
+
+func TestBatchDeleteStatus(t *testing.T) {
+	setupTestBatchAccount(t)
+	defer data.SetCmdStatus(data.StatusOK)
+
+	server := newTestBatchServer(nil)
+	defer server.Close()
+
+	if status := testBatchDeleteStatus(t, server, "a.jpg\nb.jpg\n"); status != data.StatusOK {
+		t.Fatal("all success, status should be 0, but:", status)
+	}
+	if status := testBatchDeleteStatus(t, server, "a.jpg\nmissing.jpg\nb.jpg\n"); status != data.StatusPartError {
+		t.Fatal("part failure, status should be 3, but:", status)
+	}
+	if status := testBatchDeleteStatus(t, server, "missing1.jpg\nmissing2.jpg\n"); status != data.StatusError {
+		t.Fatal("all failure, status should be 1, but:", status)
+	}
+}
+
+func TestBatchDeleteDryRunSuccessList(t *testing.T) {
+	setupTestBatchAccount(t)
+	defer data.SetCmdStatus(data.StatusOK)
+
+	var ops []string
+	server := newTestBatchServer(&ops)
+	defer server.Close()
+
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(inputFile, []byte("a.jpg\nb.jpg\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	successList := filepath.Join(dir, "success.txt")
+
+	// dry run 未实际执行，不导出成功列表，用于 --resume 时不会跳过任何操作
+	cfg := &iqshell.Config{
+		Region: "z0",
+		RsHost: server.URL,
+		CmdCfg: config.Config{CmdId: "batchdelete"},
+	}
+	info := BatchDeleteInfo{
+		BatchInfo: batch.Info{
+			Info:      flow.Info{Force: true},
+			InputFile: inputFile,
+			DryRun:    true,
+		},
+		Bucket: "bucket",
+	}
+	info.BatchInfo.SuccessExportFilePath = successList
+	BatchDelete(cfg, info)
+	if len(ops) > 0 {
+		t.Fatal("dry run should not send requests, but:", ops)
+	}
+	if content, err := os.ReadFile(successList); err == nil && len(content) > 0 {
+		t.Fatalf("dry run should not export success list, but:%q", content)
+	}
+
+	info.BatchInfo.DryRun = false
+	info.BatchInfo.ResumeFile = successList
+	BatchDelete(cfg, info)
+	if len(ops) != 2 {
+		t.Fatal("resume with the success list of dry run should delete all files, but:", ops)
+	}
+}
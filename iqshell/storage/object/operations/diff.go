@@ -0,0 +1,312 @@
+package operations
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+)
+
+const (
+	DiffFlagLocalOnly  = "+" // 仅本地存在
+	DiffFlagRemoteOnly = "-" // 仅空间中存在
+	DiffFlagModified   = "M" // 两端均存在，但大小或 hash 不同
+)
+
+// DiffSource 对比的本地目录及空间前缀
+type DiffSource struct {
+	LocalDir       string
+	Bucket         string
+	Prefix         string // 本地文件的 key 为 Prefix + 相对路径
+	FollowSymlinks bool   // 是否跟随软链接，不跟随时跳过软链接
+	MaxRetry       int    // 列举空间出错时的最大重试次数，-1：无限重试
+}
+
+func (s *DiffSource) Check() *data.CodeError {
+	if len(s.LocalDir) == 0 {
+		return alert.CannotEmptyError("LocalDir", "")
+	}
+	if stat, err := os.Stat(s.LocalDir); err != nil {
+		return alert.Error("get directory status error: "+err.Error(), "")
+	} else if !stat.IsDir() {
+		return alert.Error(s.LocalDir+" is not a directory", "")
+	}
+	if len(s.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	return nil
+}
+
+type DiffInfo struct {
+	flow.Info
+	export.FileExporterConfig
+	DiffSource
+
+	SizeOnly bool // 仅对比文件大小，不计算 hash
+}
+
+// ParseBucketPrefix 解析 <Bucket>[:<Prefix>] 格式的参数
+func ParseBucketPrefix(bucketPrefix string) (bucketName string, prefix string) {
+	items := strings.SplitN(bucketPrefix, ":", 2)
+	bucketName = items[0]
+	if len(items) > 1 {
+		prefix = items[1]
+	}
+	return
+}
+
+func (info *DiffInfo) Check() *data.CodeError {
+	if err := info.DiffSource.Check(); err != nil {
+		return err
+	}
+
+	if info.WorkerCount <= 0 {
+		info.WorkerCount = runtime.NumCPU()
+	}
+	// 仅读取本地文件及列举空间，无需用户确认
+	info.Force = true
+	return info.Info.Check()
+}
+
+type DiffWork struct {
+	Key             string
+	LocalFile       string // 为空表示仅空间中存在
+	LocalSize       int64
+	LocalModifyTime int64              // 本地文件的修改时间，单位：ns
+	Remote          *bucket.ListObject // 为 nil 表示仅本地存在
+}
+
+func (w *DiffWork) WorkId() string {
+	return w.Key
+}
+
+type DiffResult struct {
+	Flag       string // 为空表示两端一致
+	Key        string
+	LocalSize  int64
+	RemoteSize int64
+}
+
+func (r *DiffResult) IsValid() bool {
+	return r != nil
+}
+
+func (r *DiffResult) String() string {
+	switch r.Flag {
+	case DiffFlagLocalOnly:
+		return strings.Join([]string{r.Flag, r.Key, strconv.FormatInt(r.LocalSize, 10)}, "\t")
+	case DiffFlagRemoteOnly:
+		return strings.Join([]string{r.Flag, r.Key, strconv.FormatInt(r.RemoteSize, 10)}, "\t")
+	default:
+		return strings.Join([]string{r.Flag, r.Key, strconv.FormatInt(r.LocalSize, 10), strconv.FormatInt(r.RemoteSize, 10)}, "\t")
+	}
+}
+
+// Diff 对比本地目录与空间中指定前缀下的文件，本地文件与空间文件均按 key 排序后流式归并，不会全部加载到内存；
+// 输出：仅本地存在 +\tKey\tLocalSize，仅空间存在 -\tKey\tRemoteSize，不一致 M\tKey\tLocalSize\tRemoteSize
+func Diff(cfg *iqshell.Config, info DiffInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	exporter, err := export.NewFileExport(info.FileExporterConfig)
+	if err != nil {
+		log.Error(err)
+		data.SetCmdStatusError()
+		return
+	}
+	defer exporter.Close()
+	defer object.FlushEtagCache()
+
+	var failureCount, localOnlyCount, remoteOnlyCount, modifiedCount, sameCount int64
+	onFail := func(key string, err *data.CodeError) {
+		atomic.AddInt64(&failureCount, 1)
+		exporter.Fail().ExportF("%s%s%v", key, flow.ErrorSeparate, err)
+		log.ErrorF("Diff Failed, %s, Error: %v", key, err)
+	}
+
+	works, listError := DiffWorks(info.DiffSource, onFail)
+	flow.New(info.Info).
+		WorkProviderWithChan(works).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
+				return CompareDiffWork(info.Bucket, workInfo.Work.(*DiffWork), info.SizeOnly)
+			}), nil
+		})).
+		OnWorkSkip(func(workInfo *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			log.InfoF("Skip key:%s because:%v", workInfo.Work.WorkId(), err)
+		}).
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
+			r, _ := result.(*DiffResult)
+			if r == nil {
+				return
+			}
+			switch r.Flag {
+			case DiffFlagLocalOnly:
+				atomic.AddInt64(&localOnlyCount, 1)
+			case DiffFlagRemoteOnly:
+				atomic.AddInt64(&remoteOnlyCount, 1)
+			case DiffFlagModified:
+				atomic.AddInt64(&modifiedCount, 1)
+			default:
+				atomic.AddInt64(&sameCount, 1)
+				return
+			}
+			if len(info.ResultExportFilePath) > 0 {
+				exporter.Result().Export(r.String())
+			} else {
+				log.Alert(r.String())
+			}
+		}).
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
+			onFail(workInfo.Work.WorkId(), err)
+		}).Build().Start()
+
+	log.InfoF("Diff result, local only:%d remote only:%d modified:%d same:%d failure:%d",
+		localOnlyCount, remoteOnlyCount, modifiedCount, sameCount, failureCount)
+	if listErr := listError(); listErr != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("list bucket:%s error, the diff result is incomplete, error:%v", info.Bucket, listErr)
+	}
+	if failureCount > 0 {
+		// 无法读取的本地文件不在 flow 中，需和 flow 中失败的 work 一起设置命令状态
+		data.SetCmdStatusByWorkCount(localOnlyCount+remoteOnlyCount+modifiedCount+sameCount+failureCount, failureCount)
+	}
+}
+
+// DiffWorks 遍历本地目录并列举空间，将两端的文件按 key 流式归并为 *DiffWork；无法读取的本地文件及目录交由 onFail 处理；
+// works 关闭后可通过 listError 获取列举空间的错误，出错时归并的结果不完整
+func DiffWorks(source DiffSource, onFail func(key string, err *data.CodeError)) (works <-chan flow.Work, listError func() *data.CodeError) {
+	localFiles := make(chan *utils.WalkedFile, 1000)
+	go func() {
+		defer close(localFiles)
+		walker := &utils.DirWalker{
+			FollowSymlinks: source.FollowSymlinks,
+			OnFile: func(file *utils.WalkedFile) {
+				localFiles <- file
+			},
+			OnFail: func(relPath string, err *data.CodeError) {
+				onFail(source.Prefix+relPath, err)
+			},
+		}
+		walker.Walk(source.LocalDir)
+	}()
+
+	var listErr *data.CodeError
+	remoteObjects := make(chan *bucket.ListObject, 1000)
+	go func() {
+		defer close(remoteObjects)
+		listErr = bucket.List(bucket.ListApiInfo{
+			Bucket:   source.Bucket,
+			Prefix:   source.Prefix,
+			MaxRetry: source.MaxRetry,
+		}, func(marker string, object bucket.ListObject) (bool, *data.CodeError) {
+			// 目录占位文件在本地无对应的文件
+			if strings.HasSuffix(object.Key, "/") && object.Fsize == 0 {
+				return true, nil
+			}
+			remoteObjects <- &object
+			return true, nil
+		}, func(marker string, err *data.CodeError) {
+			log.ErrorF("list bucket error, marker:%s error:%v", marker, err)
+		})
+	}()
+
+	workChan := make(chan flow.Work)
+	go func() {
+		defer close(workChan)
+		mergeDiffWorks(source.Prefix, localFiles, remoteObjects, func() *data.CodeError {
+			return listErr
+		}, workChan)
+	}()
+	return workChan, func() *data.CodeError {
+		return listErr
+	}
+}
+
+// mergeDiffWorks 归并两个均按 key 升序排列的本地文件及空间文件序列；空间列举出错时，剩余的本地文件无法判断是否仅本地存在，不再输出
+func mergeDiffWorks(prefix string, localFiles <-chan *utils.WalkedFile, remoteObjects <-chan *bucket.ListObject,
+	listError func() *data.CodeError, works chan<- flow.Work) {
+	local, localOk := <-localFiles
+	remote, remoteOk := <-remoteObjects
+	for localOk || remoteOk {
+		if !remoteOk && listError() != nil {
+			break
+		}
+
+		var localKey string
+		if localOk {
+			localKey = prefix + local.RelPath
+		}
+
+		if localOk && (!remoteOk || localKey < remote.Key) {
+			works <- &DiffWork{Key: localKey, LocalFile: local.FilePath, LocalSize: local.Size, LocalModifyTime: local.ModifyTime}
+			local, localOk = <-localFiles
+		} else if remoteOk && (!localOk || remote.Key < localKey) {
+			works <- &DiffWork{Key: remote.Key, Remote: remote}
+			remote, remoteOk = <-remoteObjects
+		} else {
+			works <- &DiffWork{Key: localKey, LocalFile: local.FilePath, LocalSize: local.Size, LocalModifyTime: local.ModifyTime, Remote: remote}
+			local, localOk = <-localFiles
+			remote, remoteOk = <-remoteObjects
+		}
+	}
+
+	// 保证遍历本地目录的协程可以结束
+	for range localFiles {
+	}
+}
+
+// CompareDiffWork 对比 work 两端的文件，两端均存在时先对比大小，大小一致且 sizeOnly 为 false 时再对比 hash
+func CompareDiffWork(bucketName string, work *DiffWork, sizeOnly bool) (*DiffResult, *data.CodeError) {
+	result := &DiffResult{
+		Key:       work.Key,
+		LocalSize: work.LocalSize,
+	}
+	if work.Remote == nil {
+		result.Flag = DiffFlagLocalOnly
+		return result, nil
+	}
+	result.RemoteSize = work.Remote.Fsize
+	if len(work.LocalFile) == 0 {
+		result.Flag = DiffFlagRemoteOnly
+		return result, nil
+	}
+	if work.LocalSize != work.Remote.Fsize {
+		result.Flag = DiffFlagModified
+		return result, nil
+	}
+	if sizeOnly {
+		return result, nil
+	}
+
+	match, err := object.Match(object.MatchApiInfo{
+		Bucket:         bucketName,
+		Key:            work.Key,
+		LocalFile:      work.LocalFile,
+		CheckMode:      object.MatchCheckModeFileHash,
+		ServerFileHash: work.Remote.Hash,
+		ServerFileSize: work.Remote.Fsize,
+		UseEtagCache:   true,
+	})
+	if match != nil && match.Checked {
+		if !match.Match {
+			result.Flag = DiffFlagModified
+		}
+		return result, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,49 @@
+package operations
+
+import (
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+)
+
+func TestMergeDiffWorks(t *testing.T) {
+	localFiles := make(chan *utils.WalkedFile, 10)
+	for _, relPath := range []string{"a", "b", "d"} {
+		localFiles <- &utils.WalkedFile{RelPath: relPath, FilePath: relPath}
+	}
+	close(localFiles)
+
+	remoteObjects := make(chan *bucket.ListObject, 10)
+	for _, key := range []string{"p/b", "p/c", "p/d", "p/e"} {
+		remoteObjects <- &bucket.ListObject{Key: key}
+	}
+	close(remoteObjects)
+
+	works := make(chan flow.Work, 10)
+	mergeDiffWorks("p/", localFiles, remoteObjects, func() *data.CodeError {
+		return nil
+	}, works)
+	close(works)
+
+	expected := []string{"p/a+", "p/b=", "p/c-", "p/d=", "p/e-"}
+	index := 0
+	for w := range works {
+		work := w.(*DiffWork)
+		flag := "="
+		if work.Remote == nil {
+			flag = "+"
+		} else if len(work.LocalFile) == 0 {
+			flag = "-"
+		}
+		if index >= len(expected) || work.Key+flag != expected[index] {
+			t.Fatalf("merge error, index:%d work:%s%s expected:%v", index, work.Key, flag, expected)
+		}
+		index++
+	}
+	if index != len(expected) {
+		t.Fatalf("merge work count error, count:%d expected:%d", index, len(expected))
+	}
+}
@@ -0,0 +1,253 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+const expireTimeLayout = "2006-01-02 15:04:05 -0700"
+
+// ExpireState 文件的过期删除状态
+type ExpireState struct {
+	Bucket          string `json:"bucket"`
+	Key             string `json:"key"`
+	PutTime         string `json:"put_time"`
+	Expires         bool   `json:"expires"`                     // 是否设置了过期删除
+	DeleteAfterDays int    `json:"delete_after_days,omitempty"` // 上传后多少天删除
+	ExpireAt        string `json:"expire_at,omitempty"`         // 删除的时间
+	RemainingDays   int    `json:"remaining_days,omitempty"`    // 距离删除的天数，不足一天按一天计算
+}
+
+var _ flow.Result = (*ExpireState)(nil)
+
+func (s *ExpireState) IsValid() bool {
+	return len(s.Key) > 0
+}
+
+// newExpireState 根据上传时间（单位：100ns）及过期时间（Unix 时间戳，0 表示未设置）计算过期状态
+func newExpireState(bucket, key string, putTime int64, expiration int64, now time.Time) *ExpireState {
+	put := time.Unix(0, putTime*100)
+	state := &ExpireState{
+		Bucket:  bucket,
+		Key:     key,
+		PutTime: put.Format(expireTimeLayout),
+	}
+	if expiration <= 0 {
+		return state
+	}
+
+	expireAt := time.Unix(expiration, 0)
+	state.Expires = true
+	state.ExpireAt = expireAt.Format(expireTimeLayout)
+	// 删除时间为上传时间加上天数后取整到之后的零点，即：put + days 天 < expireAt <= put + (days + 1) 天；
+	// 直接向下取整时，上传时间恰好为零点的文件会多算一天
+	day := 24 * time.Hour
+	if days := int((expireAt.Sub(put)+day-1)/day) - 1; days > 0 {
+		state.DeleteAfterDays = days
+	}
+	if remaining := expireAt.Sub(now); remaining > 0 {
+		state.RemainingDays = int(math.Ceil(remaining.Hours() / 24))
+	}
+	return state
+}
+
+func expireStateOf(bucket, key string) (*ExpireState, *data.CodeError) {
+	status, err := object.Status(object.StatusApiInfo{
+		Bucket: bucket,
+		Key:    key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newExpireState(bucket, key, status.PutTime, status.Expiration, time.Now()), nil
+}
+
+type ObjectExpireInfo struct {
+	Source string // <Bucket>:<Key> 【必选】
+
+	bucket string
+	key    string
+}
+
+func (info *ObjectExpireInfo) Check() *data.CodeError {
+	if len(info.Source) == 0 {
+		return alert.CannotEmptyError("Bucket:Key", "")
+	}
+	var ok bool
+	if info.bucket, info.key, ok = splitBucketKey(info.Source); !ok {
+		return alert.Error("invalid source: "+info.Source, "source should be <Bucket>:<Key>")
+	}
+	return nil
+}
+
+// ObjectExpire 查询文件的过期删除天数，并根据上传时间计算删除的时间
+func ObjectExpire(cfg *iqshell.Config, info ObjectExpireInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	state, err := expireStateOf(info.bucket, info.key)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Get expire state Failed, [%s:%s], Error:%v", info.bucket, info.key, err)
+		return
+	}
+
+	if data.IsOutputFormatJson() {
+		outputExpireStateJson(state)
+		return
+	}
+	log.AlertF("%-18s%s", "Bucket:", state.Bucket)
+	log.AlertF("%-18s%s", "Key:", state.Key)
+	log.AlertF("%-18s%s", "PutTime:", state.PutTime)
+	if !state.Expires {
+		log.AlertF("%-18s%s", "Expiration:", "none, the file will not be deleted by lifecycle")
+		return
+	}
+	log.AlertF("%-18s%d", "DeleteAfterDays:", state.DeleteAfterDays)
+	log.AlertF("%-18s%s (in %d days)", "ExpireAt:", state.ExpireAt, state.RemainingDays)
+}
+
+func outputExpireStateJson(state *ExpireState) string {
+	bytes, err := json.Marshal(state)
+	if err != nil {
+		log.ErrorF("marshal expire state of %s error:%v", state.Key, err)
+		return ""
+	}
+	log.Alert(string(bytes))
+	return string(bytes)
+}
+
+// expireStateLine 批量查询时每个文件输出一行：key、上传时间、过期天数、删除时间，未设置过期时后两列为 - 及 never
+func expireStateLine(state *ExpireState) string {
+	if !state.Expires {
+		return fmt.Sprintf("%s\t%s\t-\tnever", state.Key, state.PutTime)
+	}
+	return fmt.Sprintf("%s\t%s\t%d\t%s", state.Key, state.PutTime, state.DeleteAfterDays, state.ExpireAt)
+}
+
+type expireWork struct {
+	Key string
+}
+
+func (w *expireWork) WorkId() string {
+	return w.Key
+}
+
+type BatchObjectExpireInfo struct {
+	BatchInfo    batch.Info
+	Bucket       string // 文件所在的空间 【必选】
+	OnlyExpiring bool   // 仅输出设置了过期删除的文件 【可选】
+}
+
+func (info *BatchObjectExpireInfo) Check() *data.CodeError {
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	return info.BatchInfo.Check()
+}
+
+// BatchObjectExpire 批量查询文件的过期删除状态，结束时统计设置了过期删除的文件数
+func BatchObjectExpire(cfg *iqshell.Config, info BatchObjectExpireInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	exporter, err := export.NewFileExport(info.BatchInfo.FileExporterConfig)
+	if err != nil {
+		log.Error(err)
+		data.SetCmdStatusError()
+		return
+	}
+
+	var expiringCount int64
+	metric := &batch.Metric{}
+	metric.Start()
+	info.BatchInfo.NewFlow().
+		WorkProviderWithFile(info.BatchInfo.InputFile,
+			info.BatchInfo.EnableStdin,
+			flow.NewItemsWorkCreator(info.BatchInfo.ItemSeparate, 1, func(items []string) (work flow.Work, err *data.CodeError) {
+				if strings.TrimSpace(items[0]) == "" {
+					return nil, alert.Error("key invalid", "")
+				}
+				return &expireWork{Key: items[0]}, nil
+			})).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
+				work := workInfo.Work.(*expireWork)
+				state, sErr := expireStateOf(info.Bucket, work.Key)
+				if sErr != nil {
+					return nil, sErr
+				}
+				return state, nil
+			}), nil
+		})).
+		FlowWillStartFunc(func(flow *flow.Flow) (err *data.CodeError) {
+			metric.AddTotalCount(flow.WorkProvider.WorkTotalCount())
+			return nil
+		}).
+		Deadletter(exporter.Deadletter()).
+		OnWorkSkip(func(work *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			metric.AddCurrentCount(1)
+			metric.AddSkippedCount(1)
+			metric.PrintProgress("Batching:" + work.Data)
+			exporter.Fail().ExportF("%s%s%v", work.Data, flow.ErrorSeparate, err)
+			log.DebugF("Skip line:%s because:%v", work.Data, err)
+		}).
+		OnWorkSuccess(func(work *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
+			metric.AddCurrentCount(1)
+			metric.AddSuccessCount(1)
+			metric.PrintProgress("Batching:" + work.Data)
+
+			state, _ := result.(*ExpireState)
+			if state == nil {
+				return
+			}
+			exporter.Success().Export(work.Data)
+			if state.Expires {
+				atomic.AddInt64(&expiringCount, 1)
+			} else if info.OnlyExpiring {
+				return
+			}
+
+			var line string
+			if data.IsOutputFormatJson() {
+				line = outputExpireStateJson(state)
+			} else {
+				line = expireStateLine(state)
+				log.Alert(line)
+			}
+			if len(line) > 0 {
+				exporter.Result().Export(line)
+			}
+		}).
+		OnWorkFail(func(work *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
+			metric.AddCurrentCount(1)
+			metric.AddFailureCount(1)
+			metric.PrintProgress("Batching:" + work.Data)
+
+			exporter.Fail().ExportF("%s%s%v", work.Data, flow.ErrorSeparate, err)
+			log.ErrorF("Get expire state Failed, [%s:%s], Error:%v", info.Bucket, work.Data, err)
+		}).Build().Start()
+
+	metric.End()
+
+	log.InfoF("Expiring:%d", expiringCount)
+}
@@ -0,0 +1,38 @@
+package operations
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewExpireState(t *testing.T) {
+	put := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	putTime := put.UnixNano() / 100
+	expiration := time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC).Unix()
+	now := time.Date(2024, 6, 20, 12, 0, 0, 0, time.UTC)
+
+	state := newExpireState("b", "a.jpg", putTime, expiration, now)
+	if !state.Expires || state.DeleteAfterDays != 30 || state.RemainingDays != 12 {
+		t.Fatalf("expire state error, state:%+v", state)
+	}
+
+	state = newExpireState("b", "a.jpg", putTime, expiration, time.Date(2024, 7, 3, 0, 0, 0, 0, time.UTC))
+	if !state.Expires || state.RemainingDays != 0 {
+		t.Fatalf("expired state error, state:%+v", state)
+	}
+
+	// 上传时间恰好为零点
+	midnightPutTime := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC).UnixNano() / 100
+	state = newExpireState("b", "a.jpg", midnightPutTime, expiration, now)
+	if !state.Expires || state.DeleteAfterDays != 30 {
+		t.Fatalf("midnight put time state error, state:%+v", state)
+	}
+
+	state = newExpireState("b", "a.jpg", putTime, 0, now)
+	if state.Expires || state.DeleteAfterDays != 0 || len(state.ExpireAt) != 0 {
+		t.Fatalf("no expiry state error, state:%+v", state)
+	}
+	if line := expireStateLine(state); line != "a.jpg\t"+state.PutTime+"\t-\tnever" {
+		t.Fatalf("no expiry line error, line:%s", line)
+	}
+}
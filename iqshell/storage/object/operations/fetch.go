@@ -13,9 +13,22 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
 	"path/filepath"
+	"time"
 )
 
-type FetchInfo object.FetchApiInfo
+type FetchInfo struct {
+	object.FetchApiInfo
+
+	// 以下参数仅异步抓取支持，设置了 CallbackUrl 或 PersistentOps 时使用异步抓取
+	CallbackUrl         string
+	CallbackBody        string
+	CallbackBodyType    string
+	CallbackHost        string
+	PersistentOps       string
+	PersistentNotifyUrl string
+	PersistentPipeline  string
+	NoWait              bool // 异步抓取时不等待抓取完成
+}
 
 func (info *FetchInfo) Check() *data.CodeError {
 	if len(info.Bucket) == 0 {
@@ -24,9 +37,16 @@ func (info *FetchInfo) Check() *data.CodeError {
 	if len(info.FromUrl) == 0 {
 		return alert.CannotEmptyError("RemoteResourceUrl", "")
 	}
+	if len(info.CallbackUrl) > 0 && len(info.CallbackBody) == 0 {
+		return alert.CannotEmptyError("CallbackBody", "callback body is required when callback url is set, set it by --callback-body")
+	}
 	return nil
 }
 
+func (info *FetchInfo) isAsync() bool {
+	return len(info.CallbackUrl) > 0 || len(info.PersistentOps) > 0
+}
+
 func Fetch(cfg *iqshell.Config, info FetchInfo) {
 	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
 		Checker: &info,
@@ -34,7 +54,12 @@ func Fetch(cfg *iqshell.Config, info FetchInfo) {
 		return
 	}
 
-	result, err := object.Fetch(object.FetchApiInfo(info))
+	if info.isAsync() {
+		asyncFetch(info)
+		return
+	}
+
+	result, err := object.Fetch(info.FetchApiInfo)
 	if err != nil {
 		data.SetCmdStatusError()
 		log.ErrorF("Fetch Failed, '%s' => [%s:%s], Error:%v",
@@ -48,6 +73,106 @@ func Fetch(cfg *iqshell.Config, info FetchInfo) {
 	}
 }
 
+// asyncFetch 同步抓取接口不支持回调及持久化处理，使用异步抓取接口，提交成功后等待抓取完成并查询文件信息
+func asyncFetch(info FetchInfo) {
+	key := info.Key
+	if len(key) == 0 {
+		k, err := utils.KeyFromUrl(info.FromUrl)
+		if err != nil || len(k) == 0 {
+			data.SetCmdStatusError()
+			log.ErrorF("Fetch Failed, get key from url:%s error:%v, please set key by -k", info.FromUrl, err)
+			return
+		}
+		key = k
+	}
+
+	if _, cause := asyncFetchExistCause(info.Bucket, key, object.Status); cause != nil {
+		log.InfoF("Fetch Skipped, '%s' => [%s:%s], %s", info.FromUrl, info.Bucket, key, cause.Desc)
+		return
+	}
+
+	ret, err := object.AsyncFetch(object.AsyncFetchApiInfo{
+		Url:                 info.FromUrl,
+		Bucket:              info.Bucket,
+		Key:                 key,
+		CallbackURL:         info.CallbackUrl,
+		CallbackBody:        info.CallbackBody,
+		CallbackBodyType:    info.CallbackBodyType,
+		CallbackHost:        info.CallbackHost,
+		PersistentOps:       info.PersistentOps,
+		PersistentNotifyUrl: info.PersistentNotifyUrl,
+		PersistentPipeline:  info.PersistentPipeline,
+		IgnoreSameKey:       true,
+	})
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Fetch Failed, '%s' => [%s:%s], Error:%v", info.FromUrl, info.Bucket, key, err)
+		return
+	}
+	log.InfoF("Fetch Accepted, '%s' => [%s:%s], id:%s wait:%d", info.FromUrl, info.Bucket, key, ret.Id, ret.Wait)
+	log.AlertF("Id:%s", ret.Id)
+	if info.NoWait {
+		log.InfoF("Fetch is asynchronous, you can check the result by: qshell acheck %s %s", info.Bucket, ret.Id)
+		return
+	}
+
+	deadline := time.Now().Add(time.Duration(asyncFetchCheckMaxDuration(0)) * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(3 * time.Second)
+		if workspace.IsCmdInterrupt() {
+			data.SetCmdStatusError()
+			return
+		}
+
+		status, cErr := object.CheckAsyncFetchStatus(info.Bucket, ret.Id)
+		if cErr != nil {
+			log.DebugF("check async fetch status, id:%s error:%v", ret.Id, cErr)
+			continue
+		}
+		if status.Wait >= 0 {
+			log.DebugF("async fetch id:%s is waiting, wait:%d", ret.Id, status.Wait)
+			continue
+		}
+
+		// 已处理过至少一次，文件存在表示抓取成功，否则可能在重试中
+		stat, sErr := object.Status(object.StatusApiInfo{
+			Bucket: info.Bucket,
+			Key:    key,
+		})
+		if sErr != nil {
+			log.DebugF("async fetch id:%s, stat [%s:%s] error:%v", ret.Id, info.Bucket, key, sErr)
+			continue
+		}
+
+		log.InfoF("Fetch Success, '%s' => [%s:%s]", info.FromUrl, info.Bucket, key)
+		log.AlertF("Key:%s", key)
+		log.AlertF("FileHash:%s", stat.Hash)
+		log.AlertF("Fsize: %d (%s)", stat.FSize, utils.FormatFileSize(stat.FSize))
+		log.AlertF("Mime:%s", stat.MimeType)
+		return
+	}
+
+	data.SetCmdStatusError()
+	log.ErrorF("Fetch is not completed in %ds, you can check the result by: qshell acheck %s %s",
+		asyncFetchCheckMaxDuration(0), info.Bucket, ret.Id)
+}
+
+// asyncFetchExistCause 异步抓取时忽略同名文件（IgnoreSameKey），文件已存在时服务端不会抓取，返回跳过的原因；
+// 查询失败时无法判断是否存在，由服务端决定是否抓取
+func asyncFetchExistCause(bucket, key string, status func(info object.StatusApiInfo) (object.StatusResult, *data.CodeError)) (object.StatusResult, *data.CodeError) {
+	stat, err := status(object.StatusApiInfo{
+		Bucket: bucket,
+		Key:    key,
+	})
+	if err != nil {
+		if !err.IsNotFound() {
+			log.DebugF("async fetch, stat [%s:%s] error:%v", bucket, key, err)
+		}
+		return stat, nil
+	}
+	return stat, data.NewError(data.ErrorCodeSkipByExist, fmt.Sprintf("object [%s:%s] already exists", bucket, key))
+}
+
 type BatchFetchInfo struct {
 	BatchInfo batch.Info
 	Bucket    string
@@ -91,7 +216,7 @@ func BatchFetch(cfg *iqshell.Config, info BatchFetchInfo) {
 
 	metric := &batch.Metric{}
 	metric.Start()
-	flow.New(info.BatchInfo.Info).
+	info.BatchInfo.NewFlow().
 		WorkProviderWithFile(info.BatchInfo.InputFile,
 			info.BatchInfo.EnableStdin,
 			flow.NewItemsWorkCreator(info.BatchInfo.ItemSeparate, 1, func(items []string) (work flow.Work, err *data.CodeError) {
@@ -151,6 +276,7 @@ func BatchFetch(cfg *iqshell.Config, info BatchFetchInfo) {
 			}
 			return false, nil
 		}).
+		Deadletter(exporter.Deadletter()).
 		OnWorkSkip(func(work *flow.WorkInfo, result flow.Result, err *data.CodeError) {
 			metric.AddCurrentCount(1)
 			metric.PrintProgress("Batching:" + work.Data)
@@ -173,7 +299,7 @@ func BatchFetch(cfg *iqshell.Config, info BatchFetchInfo) {
 			}
 
 		}).
-		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result) {
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
 			metric.AddCurrentCount(1)
 			metric.AddSuccessCount(1)
 			metric.PrintProgress("Batching:" + workInfo.Data)
@@ -182,7 +308,7 @@ func BatchFetch(cfg *iqshell.Config, info BatchFetchInfo) {
 			exporter.Success().ExportF("%s\t%s", in.FromUrl, in.Key)
 			log.InfoF("Fetch Success, '%s' => [%s:%s]", in.FromUrl, info.Bucket, in.Key)
 		}).
-		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
 			metric.AddCurrentCount(1)
 			metric.AddFailureCount(1)
 			metric.PrintProgress("Batching:" + workInfo.Data)
@@ -210,16 +336,4 @@ func BatchFetch(cfg *iqshell.Config, info BatchFetchInfo) {
 	} else {
 		log.DebugF("save batch fetch result to path:%s", resultPath)
 	}
-
-	log.Info("--------------- Batch Result ---------------")
-	log.InfoF("%20s%10d", "Total:", metric.TotalCount)
-	log.InfoF("%20s%10d", "Success:", metric.SuccessCount)
-	log.InfoF("%20s%10d", "Failure:", metric.FailureCount)
-	log.InfoF("%20s%10d", "Skipped:", metric.SkippedCount)
-	log.InfoF("%20s%10ds", "Duration:", metric.Duration)
-	log.InfoF("--------------------------------------------")
-
-	if !metric.IsCompletedSuccessfully() {
-		data.SetCmdStatusError()
-	}
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -52,15 +53,28 @@ func CheckAsyncFetchStatus(cfg *iqshell.Config, info CheckAsyncFetchStatusInfo)
 
 type BatchAsyncFetchInfo struct {
 	BatchInfo               batch.Info
-	Bucket                  string // fetch 的目的 bucket
-	Host                    string // 从指定URL下载时指定的HOST
-	CallbackUrl             string // 抓取成功的回调地址
-	CallbackBody            string //
-	CallbackBodyType        string //
-	CallbackHost            string // 回调时使用的HOST
-	FileType                int    // 文件存储类型， 0 标准存储， 1 低频存储
-	Overwrite               bool   //
-	DisableCheckFetchResult bool   // 不检测是否 fetch 成功
+	Bucket                  string   // fetch 的目的 bucket
+	Host                    string   // 从指定URL下载时指定的HOST
+	CallbackUrl             string   // 抓取成功的回调地址
+	CallbackBody            string   //
+	CallbackBodyType        string   //
+	CallbackHost            string   // 回调时使用的HOST
+	FileType                int      // 文件存储类型， 0 标准存储， 1 低频存储
+	Overwrite               bool     //
+	DisableCheckFetchResult bool     // 不检测是否 fetch 成功
+	MirrorHosts             []string // 源站临时不可用时，依次使用这些 host 替换链接中的 host 重新抓取
+	SkipExisting            bool     // 抓取前检查目标文件是否存在，存在且相同时跳过，存在但不同时覆盖；Overwrite 时不检查
+	Wait                    bool     // 等待模式：轮询抓取任务直到完成或超时，超时的任务及其 id 会导出到失败列表
+	WaitInterval            int      // 等待模式的轮询间隔，单位：秒
+	WaitTimeout             int      // 等待模式的超时时间，单位：秒，小于等于 0 时根据文件大小确定
+}
+
+func (info *BatchAsyncFetchInfo) waitOption() asyncFetchWaitOption {
+	return asyncFetchWaitOption{
+		Enable:   info.Wait,
+		Interval: info.WaitInterval,
+		Timeout:  info.WaitTimeout,
+	}
 }
 
 func (info *BatchAsyncFetchInfo) Check() *data.CodeError {
@@ -71,9 +85,38 @@ func (info *BatchAsyncFetchInfo) Check() *data.CodeError {
 	if len(info.Bucket) == 0 {
 		return alert.CannotEmptyError("Bucket", "")
 	}
+
+	if info.Wait && info.DisableCheckFetchResult {
+		return alert.Error("--wait is not supported when --disable-check-fetch-result is set", "")
+	}
+	if len(info.MirrorHosts) > 0 && info.DisableCheckFetchResult {
+		return alert.Error("--mirror-hosts is not supported when --disable-check-fetch-result is set", "")
+	}
+	for _, host := range info.MirrorHosts {
+		if _, err := replaceUrlHost("http://example.com", host); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func (info *BatchAsyncFetchInfo) asyncFetchApiInfo(fromUrl, saveKey string) object.AsyncFetchApiInfo {
+	return object.AsyncFetchApiInfo{
+		Url:              fromUrl,
+		Host:             info.Host,
+		Bucket:           info.Bucket,
+		Key:              saveKey,
+		Md5:              "", // 设置了该值，抓取的过程使用文件md5值进行校验, 校验失败不存在七牛空间
+		Etag:             "", // 设置了该值， 抓取的过程中使用etag进行校验，失败不保存在存储空间中
+		CallbackURL:      info.CallbackUrl,
+		CallbackBody:     info.CallbackBody,
+		CallbackHost:     info.CallbackHost,
+		CallbackBodyType: info.CallbackBodyType,
+		FileType:         info.FileType,
+		IgnoreSameKey:    !info.Overwrite, // 此处需要翻转逻辑
+	}
+}
+
 func BatchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo) {
 	cfg.JobPathBuilder = func(cmdPath string) string {
 		jobId := utils.Md5Hex(fmt.Sprintf("%s:%s:%s", cfg.CmdCfg.CmdId, info.Bucket, info.BatchInfo.InputFile))
@@ -93,6 +136,17 @@ func BatchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo) {
 		return
 	}
 
+	var existIndex *asyncFetchExistIndex
+	if info.SkipExisting {
+		if info.Overwrite {
+			log.Warning("abfetch: --skip-existing is ignored when --overwrite is set")
+		} else if existIndex, err = newAsyncFetchExistIndex(&info); err != nil {
+			log.Error(err)
+			data.SetCmdStatusError()
+			return
+		}
+	}
+
 	fetchResultChan := make(chan flow.Work, info.BatchInfo.Info.WorkerCount*10)
 
 	wait := &sync.WaitGroup{}
@@ -100,7 +154,7 @@ func BatchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo) {
 
 	// fetch
 	go func() {
-		batchAsyncFetch(cfg, info, exporter, fetchResultChan)
+		batchAsyncFetch(cfg, info, existIndex, exporter, fetchResultChan)
 		close(fetchResultChan)
 		wait.Done()
 	}()
@@ -114,7 +168,7 @@ func BatchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo) {
 	wait.Wait()
 }
 
-func batchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo,
+func batchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo, existIndex *asyncFetchExistIndex,
 	exporter *export.FileExporter, fetchResultChan chan<- flow.Work) {
 
 	metric := &batch.Metric{}
@@ -127,7 +181,7 @@ func batchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo,
 		log.Debug("batch async fetch recorder:Not Enable")
 	}
 
-	flow.New(info.BatchInfo.Info).
+	info.BatchInfo.NewFlow().
 		WorkProviderWithFile(info.BatchInfo.InputFile,
 			info.BatchInfo.EnableStdin,
 			flow.NewItemsWorkCreator(info.BatchInfo.ItemSeparate, 1, func(items []string) (work flow.Work, err *data.CodeError) {
@@ -141,32 +195,20 @@ func batchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo,
 					size = s
 				}
 
-				saveKey := ""
-				if len(items) > 2 && len(items[2]) > 0 {
-					saveKey = items[2]
-				} else {
-					key, pErr := utils.KeyFromUrl(fromUrl)
-					if pErr != nil || len(key) == 0 {
-						return nil, alert.Error("get key form url error:"+pErr.Error()+" check url style", "")
-					}
-					saveKey = key
+				saveKey, kErr := asyncFetchSaveKey(items)
+				if kErr != nil {
+					return nil, kErr
+				}
+
+				fileHash := ""
+				if len(items) > 3 {
+					fileHash = items[3]
 				}
 
 				return &asyncFetchItem{
 					fileSize: size,
-					info: object.AsyncFetchApiInfo{
-						Url:              fromUrl,
-						Host:             info.Host,
-						Bucket:           info.Bucket,
-						Key:              saveKey,
-						Md5:              "", // 设置了该值，抓取的过程使用文件md5值进行校验, 校验失败不存在七牛空间
-						Etag:             "", // 设置了该值， 抓取的过程中使用etag进行校验，失败不保存在存储空间中
-						CallbackURL:      info.CallbackUrl,
-						CallbackBody:     info.CallbackBody,
-						CallbackBodyType: info.CallbackBodyType,
-						FileType:         info.FileType,
-						IgnoreSameKey:    !info.Overwrite, // 此处需要翻转逻辑
-					},
+					fileHash: fileHash,
+					info:     info.asyncFetchApiInfo(fromUrl, saveKey),
 				}, nil
 			})).
 		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
@@ -181,10 +223,12 @@ func batchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo,
 					Key:      in.info.Key,
 					Url:      in.info.Url,
 					FileSize: in.fileSize,
+					FileHash: in.fileHash,
 					Info:     result,
 				}, e
 			}), nil
 		})).
+		ShouldSkip(existIndex.shouldSkipWork).
 		SetOverseerEnable(info.BatchInfo.EnableRecord).
 		SetDBOverseer(dbPath, func() *flow.WorkRecord {
 			return &flow.WorkRecord{
@@ -217,11 +261,16 @@ func batchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo,
 			metric.AddTotalCount(flow.WorkProvider.WorkTotalCount())
 			return nil
 		}).
+		Deadletter(exporter.Deadletter()).
 		OnWorkSkip(func(work *flow.WorkInfo, result flow.Result, err *data.CodeError) {
 			metric.AddCurrentCount(1)
 			metric.PrintProgress("Batching:" + work.Data)
 
-			if err != nil && err.Code == data.ErrorCodeAlreadyDone {
+			if err != nil && err.Code == data.ErrorCodeSkipByExist {
+				metric.AddSkippedCount(1)
+				exporter.Skip().ExportF("%s%s%v", work.Data, flow.ErrorSeparate, err)
+				log.InfoF("Fetch skip line:%s because:%v", work.Data, err)
+			} else if err != nil && err.Code == data.ErrorCodeAlreadyDone {
 				if result != nil && result.IsValid() {
 					metric.AddSuccessCount(1)
 					if info.DisableCheckFetchResult {
@@ -245,7 +294,7 @@ func batchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo,
 				log.InfoF("Fetch skip line:%s because:%v", work.Data, err)
 			}
 		}).
-		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result) {
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
 			metric.AddSuccessCount(1)
 			metric.AddCurrentCount(1)
 			metric.PrintProgress("Batching:" + workInfo.Data)
@@ -259,7 +308,7 @@ func batchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo,
 			log.InfoF("Fetch Response, '%s' => [%s:%s] id:%s wait:%d",
 				in.info.Url, in.info.Bucket, in.info.Key, res.Info.Id, res.Info.Wait)
 		}).
-		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
 			metric.AddFailureCount(1)
 			metric.AddCurrentCount(1)
 			metric.PrintProgress("Batching:" + workInfo.Data)
@@ -288,19 +337,6 @@ func batchAsyncFetch(cfg *iqshell.Config, info BatchAsyncFetchInfo,
 	} else {
 		log.DebugF("save batch async fetch result to path:%s", resultPath)
 	}
-
-	log.Info("")
-	log.Info("------------- Batch Fetch Request Result ------------")
-	log.InfoF("%20s%10d", "Total:", metric.TotalCount)
-	log.InfoF("%20s%10d", "Success:", metric.SuccessCount)
-	log.InfoF("%20s%10d", "Failure:", metric.FailureCount)
-	log.InfoF("%20s%10d", "Skipped:", metric.SkippedCount)
-	log.InfoF("%20s%10ds", "Duration:", metric.Duration)
-	log.InfoF("---------------------------------------------------")
-
-	if !metric.IsCompletedSuccessfully() {
-		data.SetCmdStatusError()
-	}
 }
 
 func batchAsyncFetchCheck(cfg *iqshell.Config, info BatchAsyncFetchInfo,
@@ -332,40 +368,40 @@ func batchAsyncFetchCheck(cfg *iqshell.Config, info BatchAsyncFetchInfo,
 				metric.AddCurrentCount(1)
 				metric.PrintProgress(fmt.Sprintf("Checking, %s => [%s:%s]", in.Url, in.Bucket, in.Key))
 
-				checkTimes := 0
-				maxDuration := asyncFetchCheckMaxDuration(in.FileSize)
-				minDuration := 2
-				checkStartTime := time.Now().Add(time.Duration(minDuration) * time.Second)
-				checkEndTime := time.Now().Add(time.Duration(maxDuration) * time.Second)
-				for {
-					current := time.Now()
-					if current.After(checkStartTime) {
-						checkTimes += 1
-						ret, cErr := object.CheckAsyncFetchStatus(in.Bucket, in.Info.Id)
-						log.DebugF("batch async fetch check [%d], bucket:%s key:%s id:%s wait:%d", checkTimes, in.Bucket, in.Key, in.Key, ret.Wait)
-						if cErr != nil {
-							log.ErrorF("CheckAsyncFetchStatus: %v", cErr)
-						} else if ret.Wait < 0 { // 视频抓取过一次，有可能成功了，有可能失败了
-							if exist, err := object.Exist(object.ExistApiInfo{
-								Bucket: in.Bucket,
-								Key:    in.Key,
-							}); exist {
-								log.DebugF("batch async fetch check [%d], bucket:%s key:%s exist", checkTimes, in.Bucket, in.Key)
-								return in, nil
-							} else {
-								log.ErrorF("Check Stat[%d]:%s error:%v ID:%s", checkTimes, in.Key, err, in.Info.Id)
-							}
-						}
+				err := waitAsyncFetchResult(in, info.waitOption())
+				for _, host := range info.MirrorHosts {
+					if err == nil || workspace.IsCmdInterrupt() {
+						break
 					}
-
-					if checkTimes == 0 || current.Before(checkEndTime) {
-						time.Sleep(3 * time.Second)
-					} else {
+					// 只有源站临时不可用时才切换源站，源站返回确定性的错误（如：404）时切换也不会成功
+					fetchUrl := in.Url
+					if len(in.Host) > 0 {
+						fetchUrl, _ = replaceUrlHost(in.Url, in.Host)
+					}
+					if sErr := probeFetchSource(fetchUrl, info.Host); sErr == nil || !sErr.IsRetryable() {
+						log.DebugF("batch async fetch check, source %s is not temporarily unavailable, probe:%v", fetchUrl, sErr)
 						break
 					}
+
+					mirrorUrl, rErr := replaceUrlHost(in.Url, host)
+					if rErr != nil {
+						log.ErrorF("batch async fetch check, replace host of %s with %s error:%v", in.Url, host, rErr)
+						continue
+					}
+					log.InfoF("Fetch from source failed, retry with mirror host, %s => [%s:%s]", mirrorUrl, in.Bucket, in.Key)
+					result, fErr := object.AsyncFetch(info.asyncFetchApiInfo(mirrorUrl, in.Key))
+					if fErr != nil {
+						err = fErr
+						continue
+					}
+					in.Host = host
+					in.Info = result
+					err = waitAsyncFetchResult(in, info.waitOption())
+				}
+				if err != nil {
+					return nil, err
 				}
-				log.ErrorF("batch async fetch check [%s:%s] for [%d] times, but can't object in qiniu server", in.Bucket, in.Key, checkTimes)
-				return nil, data.NewEmptyError().AppendDesc("can't find object in bucket")
+				return in, nil
 			}), nil
 		})).
 		SetOverseerEnable(info.BatchInfo.EnableRecord).
@@ -397,6 +433,13 @@ func batchAsyncFetchCheck(cfg *iqshell.Config, info BatchAsyncFetchInfo,
 			}
 			return false, nil
 		}).
+		Deadletter(exporter.Deadletter()).
+		DeadletterInputLine(func(work *flow.WorkInfo) string {
+			if in, ok := work.Work.(*asyncFetchResult); ok {
+				return in.inputLine(info.BatchInfo.ItemSeparate)
+			}
+			return work.Data
+		}).
 		OnWorkSkip(func(work *flow.WorkInfo, result flow.Result, err *data.CodeError) {
 			metric.AddCurrentCount(1)
 			metric.PrintProgress("Batching:" + work.Data)
@@ -419,15 +462,25 @@ func batchAsyncFetchCheck(cfg *iqshell.Config, info BatchAsyncFetchInfo,
 				log.InfoF("Check skip line:%s because:%v", work.Data, err)
 			}
 		}).
-		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result) {
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
 			metric.AddSuccessCount(1)
 			metric.PrintProgress("Batching:" + workInfo.Data)
 
 			in := workInfo.Work.(*asyncFetchResult)
-			exporter.Success().ExportF("%s\t%s", in.Url, in.Key)
-			log.InfoF("Fetch Success, %s => [%s:%s]", in.Url, in.Bucket, in.Key)
+			if len(info.MirrorHosts) > 0 {
+				// 标记抓取成功时使用的 host，源站为：-
+				host := in.Host
+				if len(host) == 0 {
+					host = "-"
+				}
+				exporter.Success().ExportF("%s\t%s\t%s", in.Url, in.Key, host)
+				log.InfoF("Fetch Success, %s => [%s:%s] host:%s", in.Url, in.Bucket, in.Key, host)
+			} else {
+				exporter.Success().ExportF("%s\t%s", in.Url, in.Key)
+				log.InfoF("Fetch Success, %s => [%s:%s]", in.Url, in.Bucket, in.Key)
+			}
 		}).
-		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
 			metric.AddFailureCount(1)
 			metric.PrintProgress("Batching:" + workInfo.Data)
 
@@ -455,19 +508,73 @@ func batchAsyncFetchCheck(cfg *iqshell.Config, info BatchAsyncFetchInfo,
 	} else {
 		log.DebugF("save batch async fetch check result to path:%s", resultPath)
 	}
+}
 
-	log.Info("")
-	log.Info("------------ Batch Fetch Check Result ------------")
-	log.InfoF("%20s%10d", "Total:", metric.TotalCount)
-	log.InfoF("%20s%10d", "Success:", metric.SuccessCount)
-	log.InfoF("%20s%10d", "Failure:", metric.FailureCount)
-	log.InfoF("%20s%10d", "Skipped:", metric.SkippedCount)
-	log.InfoF("%20s%10ds", "Duration:", metric.Duration)
-	log.InfoF("--------------------------------------------------")
+// waitAsyncFetchResult 等待异步抓取完成，文件存在于空间中时返回 nil
+func waitAsyncFetchResult(in *asyncFetchResult, option asyncFetchWaitOption) *data.CodeError {
+	checkTimes := 0
+	interval := option.interval()
+	timeout := option.timeout(in.FileSize)
+	minDuration := 2
+	checkStartTime := time.Now().Add(time.Duration(minDuration) * time.Second)
+	checkEndTime := time.Now().Add(timeout)
+	for {
+		current := time.Now()
+		if current.After(checkStartTime) {
+			checkTimes += 1
+			ret, cErr := object.CheckAsyncFetchStatus(in.Bucket, in.Info.Id)
+			log.DebugF("batch async fetch check [%d], bucket:%s key:%s id:%s wait:%d", checkTimes, in.Bucket, in.Key, in.Info.Id, ret.Wait)
+			if cErr != nil {
+				log.ErrorF("CheckAsyncFetchStatus: %v", cErr)
+			} else if ret.Wait < 0 { // 视频抓取过一次，有可能成功了，有可能失败了
+				if exist, err := object.Exist(object.ExistApiInfo{
+					Bucket: in.Bucket,
+					Key:    in.Key,
+				}); exist {
+					log.DebugF("batch async fetch check [%d], bucket:%s key:%s exist", checkTimes, in.Bucket, in.Key)
+					return nil
+				} else {
+					log.ErrorF("Check Stat[%d]:%s error:%v ID:%s", checkTimes, in.Key, err, in.Info.Id)
+				}
+			}
+		}
 
-	if !metric.IsCompletedSuccessfully() {
-		data.SetCmdStatusError()
+		if workspace.IsCmdInterrupt() {
+			return data.CancelError
+		}
+		if checkTimes == 0 || current.Before(checkEndTime) {
+			time.Sleep(interval)
+		} else {
+			break
+		}
+	}
+	log.ErrorF("batch async fetch check [%s:%s] for [%d] times, but can't object in qiniu server", in.Bucket, in.Key, checkTimes)
+	if option.Enable {
+		// 记录任务 id，便于使用 acheck 重新查询
+		return data.NewEmptyError().AppendDescF("wait for fetch job timeout after %s, id:%s", timeout, in.Info.Id)
+	}
+	return data.NewEmptyError().AppendDesc("can't find object in bucket")
+}
+
+// asyncFetchWaitOption 检测抓取结果时的轮询配置，未开启时轮询间隔为 3s，超时时间根据文件大小确定
+type asyncFetchWaitOption struct {
+	Enable   bool
+	Interval int // 轮询间隔，单位：秒
+	Timeout  int // 超时时间，单位：秒，小于等于 0 时根据文件大小确定
+}
+
+func (o asyncFetchWaitOption) interval() time.Duration {
+	if !o.Enable || o.Interval <= 0 {
+		return 3 * time.Second
+	}
+	return time.Duration(o.Interval) * time.Second
+}
+
+func (o asyncFetchWaitOption) timeout(fileSize uint64) time.Duration {
+	if !o.Enable || o.Timeout <= 0 {
+		return time.Duration(asyncFetchCheckMaxDuration(fileSize)) * time.Second
 	}
+	return time.Duration(o.Timeout) * time.Second
 }
 
 func asyncFetchCheckMaxDuration(size uint64) int {
@@ -490,6 +597,7 @@ func asyncFetchCheckMaxDuration(size uint64) int {
 
 type asyncFetchItem struct {
 	fileSize uint64
+	fileHash string // 输入中指定的文件 hash，用于检查已存在的文件是否相同
 	info     object.AsyncFetchApiInfo
 }
 
@@ -502,12 +610,23 @@ type asyncFetchResult struct {
 	Key      string                      `json:"key"`
 	Url      string                      `json:"url"`
 	FileSize uint64                      `json:"file_size"`
+	FileHash string                      `json:"file_hash,omitempty"`
 	Info     *object.AsyncFetchApiResult `json:"info"`
+	Host     string                      `json:"host,omitempty"` // 使用镜像 host 抓取时有值
 }
 
 var _ flow.Work = (*asyncFetchResult)(nil)
 var _ flow.Result = (*asyncFetchResult)(nil)
 
+// inputLine 转换为 abfetch 的输入行：url、fsize、key 及 hash（有值时），用于在检查阶段失败时导出 deadletter
+func (f *asyncFetchResult) inputLine(sep string) string {
+	items := []string{f.Url, strconv.FormatUint(f.FileSize, 10), f.Key}
+	if len(f.FileHash) > 0 {
+		items = append(items, f.FileHash)
+	}
+	return strings.Join(items, sep)
+}
+
 func (f *asyncFetchResult) String() string {
 	return fmt.Sprintf("%s => [%s:%s]", f.Url, f.Bucket, f.Key)
 }
@@ -0,0 +1,144 @@
+package operations
+
+import (
+	"fmt"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+// 批量 stat 时单次请求的最大操作数
+const asyncFetchExistStatCountPerRequest = 1000
+
+// asyncFetchSaveKey 获取抓取保存的 key，未指定时从链接中获取 path 作为 key
+func asyncFetchSaveKey(items []string) (string, *data.CodeError) {
+	if len(items) > 2 && len(items[2]) > 0 {
+		return items[2], nil
+	}
+
+	key, pErr := utils.KeyFromUrl(items[0])
+	if pErr != nil || len(key) == 0 {
+		return "", alert.Error(fmt.Sprintf("get key form url error:%v check url style", pErr), "")
+	}
+	return key, nil
+}
+
+// asyncFetchExistIndex 目标空间中已存在的文件，key -> 文件信息
+// 输入为文件时，在抓取前扫描输入文件并批量 stat 所有的 key；输入为标准输入时无法预先扫描，
+// 不再逐个 stat，由抓取接口忽略已存在的同名文件（IgnoreSameKey）
+type asyncFetchExistIndex struct {
+	bucket  string
+	scanned bool
+	objects map[string]asyncFetchExistObject
+}
+
+type asyncFetchExistObject struct {
+	fsize int64
+	hash  string
+}
+
+func newAsyncFetchExistIndex(info *BatchAsyncFetchInfo) (*asyncFetchExistIndex, *data.CodeError) {
+	index := &asyncFetchExistIndex{
+		bucket:  info.Bucket,
+		objects: make(map[string]asyncFetchExistObject),
+	}
+
+	keySet := make(map[string]bool)
+	keys := make([]string, 0)
+	scanned, err := batch.ScanInputFile(&info.BatchInfo, func(lineNumber int, items []string) {
+		if key, kErr := asyncFetchSaveKey(items); kErr == nil && !keySet[key] {
+			keySet[key] = true
+			keys = append(keys, key)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !scanned {
+		log.Warning("abfetch: input is read from stdin, existing objects are skipped by the fetch api without comparing the hash")
+		return index, nil
+	}
+	index.scanned = true
+
+	bucketManager, err := bucket.GetBucketManager()
+	if err != nil {
+		return nil, err
+	}
+	if err = bucket.CompleteBucketManagerRegion(bucketManager, info.Bucket); err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(keys); start += asyncFetchExistStatCountPerRequest {
+		end := start + asyncFetchExistStatCountPerRequest
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		operations := make([]string, 0, end-start)
+		for _, key := range keys[start:end] {
+			operations = append(operations, storage.URIStat(info.Bucket, key))
+		}
+		resultList, e := bucketManager.Batch(operations)
+		if len(resultList) != len(operations) {
+			return nil, data.NewEmptyError().AppendDescF("batch stat objects before fetch error:%v", data.ConvertError(e))
+		}
+		for i, r := range resultList {
+			if r.Code == 200 {
+				index.objects[keys[start+i]] = asyncFetchExistObject{
+					fsize: r.Data.Fsize,
+					hash:  r.Data.Hash,
+				}
+			}
+		}
+	}
+	log.InfoF("abfetch: %d of %d objects already exist in bucket:%s", len(index.objects), len(keys), info.Bucket)
+	return index, nil
+}
+
+// isSame 输入中指定了 FileHash 时比较 hash，否则指定了 FileSize 时比较大小，均未指定时认为相同
+func (o asyncFetchExistObject) isSame(item *asyncFetchItem) bool {
+	if len(item.fileHash) > 0 {
+		return o.hash == item.fileHash
+	}
+	if item.fileSize > 0 {
+		return uint64(o.fsize) == item.fileSize
+	}
+	return true
+}
+
+// shouldSkip 文件已存在且和要抓取的文件相同时跳过；文件已存在但不同时不跳过，是否覆盖仅由 --overwrite 决定
+func (i *asyncFetchExistIndex) shouldSkip(item *asyncFetchItem) (bool, *data.CodeError) {
+	if !i.scanned {
+		return false, nil
+	}
+
+	exist, ok := i.objects[item.info.Key]
+	if !ok {
+		return false, nil
+	}
+	if !exist.isSame(item) {
+		log.WarningF("abfetch: object [%s:%s] exists, but hash:%s size:%d is not equal to hash:%s size:%d, it won't be overwritten without --overwrite",
+			i.bucket, item.info.Key, exist.hash, exist.fsize, item.fileHash, item.fileSize)
+		return false, nil
+	}
+	return true, data.NewError(data.ErrorCodeSkipByExist, fmt.Sprintf("object [%s:%s] already exists", i.bucket, item.info.Key))
+}
+
+// shouldSkipWork 用于 flow 的 Skipper，i 为 nil 时不跳过
+func (i *asyncFetchExistIndex) shouldSkipWork(workInfo *flow.WorkInfo) (bool, *data.CodeError) {
+	if i == nil {
+		return false, nil
+	}
+	item, ok := workInfo.Work.(*asyncFetchItem)
+	if !ok {
+		return false, nil
+	}
+	return i.shouldSkip(item)
+}
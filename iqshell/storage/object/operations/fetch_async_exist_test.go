@@ -0,0 +1,69 @@
+package operations
+
+import (
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+)
+
+func TestAsyncFetchSaveKey(t *testing.T) {
+	if key, err := asyncFetchSaveKey([]string{"http://a.com/a/b.png", "0", "c.png"}); err != nil || key != "c.png" {
+		t.Fatalf("key should be c.png, but:%s err:%v", key, err)
+	}
+	if key, err := asyncFetchSaveKey([]string{"http://a.com/a/b.png"}); err != nil || key != "a/b.png" {
+		t.Fatalf("key should be a/b.png, but:%s err:%v", key, err)
+	}
+}
+
+func TestAsyncFetchExistIndexShouldSkip(t *testing.T) {
+	index := &asyncFetchExistIndex{
+		bucket:  "bucket",
+		scanned: true,
+		objects: map[string]asyncFetchExistObject{"a": {fsize: 10, hash: "Fa"}},
+	}
+
+	cases := []struct {
+		key  string
+		size uint64
+		hash string
+		skip bool
+	}{
+		{key: "a", size: 0, skip: true},
+		{key: "a", size: 10, skip: true},
+		{key: "a", size: 11, skip: false},
+		{key: "a", size: 10, hash: "Fa", skip: true},
+		{key: "a", size: 10, hash: "Fb", skip: false},
+		{key: "a", size: 11, hash: "Fa", skip: true},
+		{key: "b", size: 0, skip: false},
+	}
+	for _, c := range cases {
+		item := &asyncFetchItem{
+			fileSize: c.size,
+			fileHash: c.hash,
+			info:     object.AsyncFetchApiInfo{Key: c.key, IgnoreSameKey: true},
+		}
+		skip, cause := index.shouldSkip(item)
+		if skip != c.skip {
+			t.Fatalf("key:%s size:%d hash:%s skip should be %v", c.key, c.size, c.hash, c.skip)
+		}
+		if skip && (cause == nil || cause.Code != data.ErrorCodeSkipByExist) {
+			t.Fatalf("skip cause should be exist, but:%v", cause)
+		}
+		// 是否覆盖仅由 --overwrite 决定，已存在但不同的文件也不覆盖
+		if !item.info.IgnoreSameKey {
+			t.Fatalf("key:%s size:%d hash:%s IgnoreSameKey should not be changed", c.key, c.size, c.hash)
+		}
+	}
+
+	// 标准输入不预先查询，由抓取接口忽略同名文件
+	stdinIndex := &asyncFetchExistIndex{bucket: "bucket"}
+	if skip, _ := stdinIndex.shouldSkip(&asyncFetchItem{info: object.AsyncFetchApiInfo{Key: "a"}}); skip {
+		t.Fatal("stdin index should not skip")
+	}
+
+	var nilIndex *asyncFetchExistIndex
+	if skip, _ := nilIndex.shouldSkipWork(nil); skip {
+		t.Fatal("nil index should not skip")
+	}
+}
@@ -0,0 +1,128 @@
+package operations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/client"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+// replaceUrlHost 使用 host 替换链接中的 host，host 可以包含 scheme，如：http://a.com 或 a.com:8080
+func replaceUrlHost(rawUrl string, host string) (string, *data.CodeError) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", data.NewEmptyError().AppendDescF("invalid url:%s, %v", rawUrl, err)
+	}
+
+	host = strings.TrimSuffix(strings.TrimSpace(host), "/")
+	if strings.Contains(host, "://") {
+		h, pErr := url.Parse(host)
+		if pErr != nil || len(h.Host) == 0 || (len(h.Path) > 0 && h.Path != "/") {
+			return "", alert.Error(fmt.Sprintf("invalid mirror host:%s", host), "mirror host should be like a.com, a.com:8080 or https://a.com")
+		}
+		u.Scheme = h.Scheme
+		host = h.Host
+	}
+	if len(host) == 0 || strings.ContainsAny(host, "/?#") {
+		return "", alert.Error(fmt.Sprintf("invalid mirror host:%s", host), "mirror host should be like a.com, a.com:8080 or https://a.com")
+	}
+	u.Host = host
+	return u.String(), nil
+}
+
+const (
+	fetchSourceProbeTimeout       = 3 * time.Second  // 探测源站时建立连接及等待第一个字节的超时
+	fetchSourceProbeCacheDuration = 30 * time.Second // 源站的探测结果的缓存时间
+)
+
+var (
+	fetchSourceProbeClientOnce sync.Once
+	fetchSourceProbeClient     *http.Client
+	fetchSourceProbeResults    = &fetchSourceProbeCache{}
+)
+
+// fetchSourceProbeCache 按源站 host 缓存探测结果，避免源站不可用时每个文件都要等待探测超时
+type fetchSourceProbeCache struct {
+	mu      sync.Mutex
+	results map[string]fetchSourceProbeResult
+}
+
+type fetchSourceProbeResult struct {
+	err      *data.CodeError
+	expireAt time.Time
+}
+
+func (c *fetchSourceProbeCache) get(host string) (*data.CodeError, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, ok := c.results[host]
+	if !ok || time.Now().After(result.expireAt) {
+		return nil, false
+	}
+	return result.err, true
+}
+
+func (c *fetchSourceProbeCache) set(host string, err *data.CodeError) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.results == nil {
+		c.results = make(map[string]fetchSourceProbeResult)
+	}
+	c.results[host] = fetchSourceProbeResult{
+		err:      err,
+		expireAt: time.Now().Add(fetchSourceProbeCacheDuration),
+	}
+}
+
+// probeFetchSource 请求源站的第一个字节，判断源站是否可用：
+// 5xx 及网络错误表示源站临时不可用（IsRetryable），4xx 表示确定性的错误；
+// 源站可用及临时不可用的结果按源站 host 缓存，4xx 仅和具体的文件相关，不缓存
+func probeFetchSource(sourceUrl string, hostHeader string) *data.CodeError {
+	u, err := url.Parse(sourceUrl)
+	if err != nil {
+		return data.ConvertError(err)
+	}
+	host := u.Scheme + "://" + u.Host + "/" + hostHeader
+	if cErr, ok := fetchSourceProbeResults.get(host); ok {
+		return cErr
+	}
+
+	cErr := doProbeFetchSource(sourceUrl, hostHeader)
+	if cErr == nil || cErr.IsRetryable() {
+		fetchSourceProbeResults.set(host, cErr)
+	}
+	return cErr
+}
+
+func doProbeFetchSource(sourceUrl string, hostHeader string) *data.CodeError {
+	req, err := http.NewRequest(http.MethodGet, sourceUrl, nil)
+	if err != nil {
+		return data.ConvertError(err)
+	}
+	if len(hostHeader) > 0 {
+		req.Host = hostHeader
+	}
+	req.Header.Set("Range", "bytes=0-0")
+
+	fetchSourceProbeClientOnce.Do(func() {
+		fetchSourceProbeClient = client.NewProbeClient(fetchSourceProbeTimeout)
+	})
+	resp, err := fetchSourceProbeClient.Do(req)
+	if err != nil {
+		return data.ConvertError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return data.NewError(resp.StatusCode, fmt.Sprintf("source %s response status:%s", sourceUrl, resp.Status))
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package operations
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+func TestReplaceUrlHost(t *testing.T) {
+	cases := map[string]string{
+		"b.com":               "http://b.com/a/b.png?x=1",
+		"b.com:8080":          "http://b.com:8080/a/b.png?x=1",
+		"https://b.com":       "https://b.com/a/b.png?x=1",
+		"https://b.com:8443/": "https://b.com:8443/a/b.png?x=1",
+	}
+	for host, expected := range cases {
+		if ret, err := replaceUrlHost("http://a.com/a/b.png?x=1", host); err != nil || ret != expected {
+			t.Fatalf("replace host with %s should be %s, but:%s err:%v", host, expected, ret, err)
+		}
+	}
+
+	for _, host := range []string{"", "b.com/path", "https://b.com/path"} {
+		if _, err := replaceUrlHost("http://a.com/a.png", host); err == nil {
+			t.Fatalf("host %s should be invalid", host)
+		}
+	}
+}
+
+func TestProbeFetchSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusPartialContent)
+		case "/busy":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fetchSourceProbeResults = &fetchSourceProbeCache{}
+	if err := probeFetchSource(server.URL+"/ok", ""); err != nil {
+		t.Fatal("source should be available, err:", err)
+	}
+	fetchSourceProbeResults = &fetchSourceProbeCache{}
+	if err := probeFetchSource(server.URL+"/busy", ""); err == nil || !err.IsRetryable() {
+		t.Fatal("5xx should be retryable, err:", err)
+	}
+	fetchSourceProbeResults = &fetchSourceProbeCache{}
+	if err := probeFetchSource(server.URL+"/none", ""); err == nil || err.IsRetryable() {
+		t.Fatal("404 should not be retryable, err:", err)
+	}
+
+	server.Close()
+	fetchSourceProbeResults = &fetchSourceProbeCache{}
+	if err := probeFetchSource(server.URL+"/ok", ""); err == nil || !err.IsRetryable() {
+		t.Fatal("network error should be retryable, err:", err)
+	}
+}
+
+func TestProbeFetchSourceCache(t *testing.T) {
+	var requestCount int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		if r.URL.Path == "/none" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	fetchSourceProbeResults = &fetchSourceProbeCache{}
+	for _, path := range []string{"/a", "/b"} {
+		if err := probeFetchSource(server.URL+path, ""); err == nil || !err.IsRetryable() {
+			t.Fatal("5xx should be retryable, err:", err)
+		}
+	}
+	if count := atomic.LoadInt64(&requestCount); count != 1 {
+		t.Fatalf("unavailable source host should be probed once, but:%d", count)
+	}
+
+	// 不同的 Host 头为不同的源站
+	_ = probeFetchSource(server.URL+"/a", "b.com")
+	if count := atomic.LoadInt64(&requestCount); count != 2 {
+		t.Fatalf("source with another host header should be probed, but:%d", count)
+	}
+
+	// 4xx 和具体的文件相关，不缓存
+	fetchSourceProbeResults = &fetchSourceProbeCache{}
+	for i := 0; i < 2; i++ {
+		_ = probeFetchSource(server.URL+"/none", "")
+	}
+	if count := atomic.LoadInt64(&requestCount); count != 4 {
+		t.Fatalf("4xx should not be cached, but request count:%d", count)
+	}
+}
+
+func TestAsyncFetchWaitOption(t *testing.T) {
+	option := asyncFetchWaitOption{Interval: 10, Timeout: 30}
+	if option.interval() != 3*time.Second || option.timeout(0) != 120*time.Second {
+		t.Fatal("option should be ignored when wait is not enabled")
+	}
+
+	option.Enable = true
+	if option.interval() != 10*time.Second || option.timeout(0) != 30*time.Second {
+		t.Fatal("option should be used when wait is enabled")
+	}
+
+	option.Timeout = 0
+	if option.timeout(600*utils.MB) != 600*time.Second {
+		t.Fatal("timeout should depend on the file size when not set")
+	}
+}
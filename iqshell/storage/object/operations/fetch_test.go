@@ -0,0 +1,30 @@
+package operations
+
+import (
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+)
+
+func TestAsyncFetchExistCause(t *testing.T) {
+	exist := func(info object.StatusApiInfo) (object.StatusResult, *data.CodeError) {
+		return object.StatusResult{}, nil
+	}
+	if _, cause := asyncFetchExistCause("bucket", "a", exist); cause == nil || cause.Code != data.ErrorCodeSkipByExist {
+		t.Fatalf("existing key should be skipped, but:%v", cause)
+	}
+
+	for _, err := range []*data.CodeError{
+		data.NewError(data.ErrorCodeResourceNotFound, "no such file or directory"),
+		data.NewError(599, "server error"),
+	} {
+		statErr := err
+		status := func(info object.StatusApiInfo) (object.StatusResult, *data.CodeError) {
+			return object.StatusResult{}, statErr
+		}
+		if _, cause := asyncFetchExistCause("bucket", "a", status); cause != nil {
+			t.Fatalf("key should not be skipped when stat error:%v, but:%v", statErr, cause)
+		}
+	}
+}
@@ -1,6 +1,14 @@
 package operations
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+
 	"github.com/qiniu/qshell/v2/iqshell"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
@@ -37,7 +45,91 @@ func PreFopStatus(cfg *iqshell.Config, info PreFopStatusInfo) {
 		return
 	}
 
-	log.Alert(ret.String())
+	if data.IsOutputFormatJson() {
+		if bytes, mErr := json.Marshal(ret); mErr != nil {
+			log.ErrorF("marshal prefop result error:%v", mErr)
+		} else {
+			log.Alert(string(bytes))
+		}
+		return
+	}
+	log.Alert(prefopString(&ret))
+}
+
+// fopStatus 持久化处理状态码的说明
+func fopStatus(code int) string {
+	switch code {
+	case 0:
+		return "success"
+	case 1:
+		return "waiting"
+	case 2:
+		return "processing"
+	case 3:
+		return "failed"
+	case 4:
+		return "callback failed"
+	default:
+		return "unknown"
+	}
+}
+
+// fopSaveAs 解析处理指令中 saveas 保存的位置，没有 saveas 时返回空
+func fopSaveAs(cmd string) string {
+	index := strings.Index(cmd, "|saveas/")
+	if index < 0 {
+		return ""
+	}
+	entry := cmd[index+len("|saveas/"):]
+	if end := strings.Index(entry, "/"); end >= 0 {
+		entry = entry[:end]
+	}
+	decoded, err := base64.URLEncoding.DecodeString(entry)
+	if err != nil {
+		return ""
+	}
+	return string(decoded)
+}
+
+// prefopString 输出处理的整体状态及每个处理指令的状态
+func prefopString(ret *storage.PrefopRet) string {
+	lines := []string{
+		fmt.Sprintf("Id: %s", ret.ID),
+		fmt.Sprintf("Status: %s(%d), %s", fopStatus(ret.Code), ret.Code, ret.Desc),
+	}
+	if len(ret.InputBucket) > 0 || len(ret.InputKey) > 0 {
+		lines = append(lines, fmt.Sprintf("Input: %s:%s", ret.InputBucket, ret.InputKey))
+	}
+	if len(ret.Pipeline) > 0 {
+		lines = append(lines, "Pipeline: "+ret.Pipeline)
+	}
+	if len(ret.Reqid) > 0 {
+		lines = append(lines, "Reqid: "+ret.Reqid)
+	}
+	if !ret.CreatedAt.IsZero() {
+		lines = append(lines, "CreatedAt: "+ret.CreatedAt.Format(time.RFC3339))
+	}
+
+	for i, item := range ret.Items {
+		lines = append(lines, "", fmt.Sprintf("[%d/%d] %s", i+1, len(ret.Items), item.Cmd))
+		lines = append(lines, fmt.Sprintf("\tStatus: %s(%d), %s", fopStatus(item.Code), item.Code, item.Desc))
+		if saveAs := fopSaveAs(item.Cmd); len(saveAs) > 0 {
+			lines = append(lines, "\tSaveAs: "+saveAs)
+		}
+		if len(item.Error) > 0 {
+			lines = append(lines, "\tError: "+item.Error)
+		}
+		if len(item.Key) > 0 {
+			lines = append(lines, "\tKey: "+item.Key)
+		}
+		for _, key := range item.Keys {
+			lines = append(lines, "\tKey: "+key)
+		}
+		if len(item.Hash) > 0 {
+			lines = append(lines, "\tHash: "+item.Hash)
+		}
+	}
+	return strings.Join(lines, "\n")
 }
 
 type PfopInfo object.PfopApiInfo
@@ -51,9 +143,22 @@ func (info *PfopInfo) Check() *data.CodeError {
 		return alert.CannotEmptyError("Key", "")
 	}
 
-	if len(info.Fops) == 0 && len(info.WorkflowTemplateID) == 0 {
-		return alert.CannotEmptyError("Fops", "")
+	if len(info.WorkflowTemplateID) > 0 && len(strings.TrimSpace(info.Fops)) == 0 {
+		return nil
+	}
+
+	// 多个处理指令以 ; 分隔，每个处理指令都不能为空
+	fops := strings.Split(info.Fops, ";")
+	for i, fop := range fops {
+		fops[i] = strings.TrimSpace(fop)
+		if len(fops[i]) == 0 {
+			if len(fops) == 1 {
+				return alert.CannotEmptyError("Fops", "")
+			}
+			return alert.Error("fops has empty command: "+info.Fops, "fops should be like avthumb/mp4;vframe/jpg/offset/1")
+		}
 	}
+	info.Fops = strings.Join(fops, ";")
 	return nil
 }
 
@@ -64,6 +169,16 @@ func Pfop(cfg *iqshell.Config, info PfopInfo) {
 		return
 	}
 
+	if len(info.Pipeline) > 0 {
+		if exists, eErr := object.PipelineExists(info.Bucket, info.Pipeline); eErr != nil {
+			log.WarningF("check pipeline:%s error:%v", info.Pipeline, eErr)
+		} else if !exists {
+			data.SetCmdStatusError()
+			log.ErrorF("pipeline:%s doesn't exist", info.Pipeline)
+			return
+		}
+	}
+
 	persistentId, err := object.Pfop(object.PfopApiInfo(info))
 	if err != nil {
 		data.SetCmdStatusError()
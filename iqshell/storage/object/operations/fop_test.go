@@ -0,0 +1,52 @@
+package operations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+func TestPfopInfoCheck(t *testing.T) {
+	info := &PfopInfo{Bucket: "bucket", Key: "key", Fops: " avthumb/mp4 ; vframe/jpg/offset/1"}
+	if err := info.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if info.Fops != "avthumb/mp4;vframe/jpg/offset/1" {
+		t.Fatalf("fops error, fops:%s", info.Fops)
+	}
+
+	for _, fops := range []string{"", " ", "avthumb/mp4;;vframe/jpg", "avthumb/mp4;"} {
+		if err := (&PfopInfo{Bucket: "bucket", Key: "key", Fops: fops}).Check(); err == nil {
+			t.Fatalf("fops:%s should be invalid", fops)
+		}
+	}
+
+	if err := (&PfopInfo{Bucket: "bucket", Key: "key", WorkflowTemplateID: "id"}).Check(); err != nil {
+		t.Fatalf("fops can be empty with workflow template, err:%v", err)
+	}
+}
+
+func TestPrefopString(t *testing.T) {
+	result := prefopString(&storage.PrefopRet{
+		ID:   "z0.id",
+		Code: 3,
+		Desc: "The fop is failed",
+		Items: []storage.FopResult{
+			{Cmd: "avthumb/mp4|saveas/YnVja2V0OmEubXA0", Code: 0, Desc: "ok", Key: "a.mp4"},
+			{Cmd: "vframe/jpg/offset/1", Code: 3, Desc: "failed", Error: "bad offset"},
+		},
+	})
+	for _, expected := range []string{
+		"Status: failed(3), The fop is failed",
+		"[1/2] avthumb/mp4|saveas/YnVja2V0OmEubXA0",
+		"SaveAs: bucket:a.mp4",
+		"[2/2] vframe/jpg/offset/1",
+		"Status: failed(3), failed",
+		"Error: bad offset",
+	} {
+		if !strings.Contains(result, expected) {
+			t.Fatalf("prefop string should contain:%s, result:\n%s", expected, result)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package operations
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/download"
+)
+
+// imageInfoWork 一个需要获取图片信息的文件
+type imageInfoWork struct {
+	Source string // bucket:key、key 或 url
+	Url    string // 包含处理指令的请求链接
+}
+
+func (w *imageInfoWork) WorkId() string {
+	return w.Source
+}
+
+// isHttpUrl 是否为 http/https 链接
+func isHttpUrl(source string) bool {
+	source = strings.ToLower(source)
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// splitBucketKey 拆分 <Bucket>:<Key>
+func splitBucketKey(source string) (bucket string, key string, ok bool) {
+	index := strings.Index(source, ":")
+	if index <= 0 || index == len(source)-1 {
+		return "", "", false
+	}
+	return source[:index], source[index+1:], true
+}
+
+// fopRequestUrl 生成处理指令的请求链接，sign 为 true 时对链接签名，签名对公有空间同样有效
+func fopRequestUrl(fileUrl string, fop string, sign bool) (string, *data.CodeError) {
+	fopUrl := object.FopUrl(fileUrl, fop)
+	if !sign {
+		return fopUrl, nil
+	}
+	result, err := download.PublicUrlToPrivate(download.PublicUrlToPrivateApiInfo{
+		PublicUrl: fopUrl,
+		Deadline:  time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.Url, nil
+}
+
+// sourceFopRequestUrl 生成 <Bucket>:<Key> 或文件链接的处理指令请求链接；key 为空时 source 为文件链接，
+// 否则使用 domain（为空时使用空间的第一个域名）生成链接并签名
+func sourceFopRequestUrl(source, bucketName, key, domain string, sign bool, fop string) (string, *data.CodeError) {
+	if len(key) == 0 {
+		return fopRequestUrl(source, fop, sign)
+	}
+
+	if len(domain) == 0 {
+		var err *data.CodeError
+		if domain, err = bucket.DomainOfBucket(bucketName); err != nil {
+			return "", err
+		}
+	}
+	return fopRequestUrl(download.PublicUrl(download.UrlApiInfo{
+		BucketDomain: domain,
+		Key:          key,
+		UseHttps:     workspace.GetConfig().IsUseHttps(),
+	}), fop, true)
+}
+
+type ImageInfoInfo struct {
+	Source string // <Bucket>:<Key> 或者文件链接 【必选】
+	Domain string // 输入为 <Bucket>:<Key> 时使用的下载域名，默认为空间的第一个域名 【可选】
+	Sign   bool   // 输入为链接时是否对链接签名，私有空间的链接需要签名 【可选】
+
+	bucket string
+	key    string
+}
+
+func (info *ImageInfoInfo) Check() *data.CodeError {
+	if len(info.Source) == 0 {
+		return alert.CannotEmptyError("Bucket:Key or Url", "")
+	}
+	if isHttpUrl(info.Source) {
+		return nil
+	}
+
+	var ok bool
+	if info.bucket, info.key, ok = splitBucketKey(info.Source); !ok {
+		return alert.Error("invalid source: "+info.Source, "source should be <Bucket>:<Key> or an url")
+	}
+	return nil
+}
+
+// ImageInfo 获取图片的宽、高、格式及颜色模型，以 json 格式输出
+func ImageInfo(cfg *iqshell.Config, info ImageInfoInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	requestUrl, err := sourceFopRequestUrl(info.Source, info.bucket, info.key, info.Domain, info.Sign, object.ImageInfoFop)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	result, err := object.ImageInfo(info.Source, requestUrl)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+	outputImageInfo(result)
+}
+
+func outputImageInfo(result *object.ImageInfoResult) string {
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		log.ErrorF("marshal image info of %s error:%v", result.Source, err)
+		return ""
+	}
+	log.Alert(string(bytes))
+	return string(bytes)
+}
+
+type BatchImageInfoInfo struct {
+	BatchInfo batch.Info
+	Bucket    string // 输入为 key 时，根据空间获取下载域名
+	Domain    string // 输入为 key 时使用的下载域名，优先级高于 Bucket
+	Sign      bool   // 输入为链接时是否对链接签名
+}
+
+func (info *BatchImageInfoInfo) Check() *data.CodeError {
+	return info.BatchInfo.Check()
+}
+
+// isKeyMode 输入的每行是否为 key，否则为文件链接
+func (info *BatchImageInfoInfo) isKeyMode() bool {
+	return len(info.Bucket) > 0 || len(info.Domain) > 0
+}
+
+// BatchImageInfo 批量获取图片信息，每个文件输出一行 json，非图片文件的 is_image 为 false
+func BatchImageInfo(cfg *iqshell.Config, info BatchImageInfoInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	exporter, err := export.NewFileExport(info.BatchInfo.FileExporterConfig)
+	if err != nil {
+		log.Error(err)
+		data.SetCmdStatusError()
+		return
+	}
+
+	domain := info.Domain
+	if info.isKeyMode() && len(domain) == 0 {
+		if domain, err = bucket.DomainOfBucket(info.Bucket); err != nil {
+			log.Error(err)
+			data.SetCmdStatusError()
+			return
+		}
+	}
+	useHttps := workspace.GetConfig().IsUseHttps()
+
+	metric := &batch.Metric{}
+	metric.Start()
+	info.BatchInfo.NewFlow().
+		WorkProviderWithFile(info.BatchInfo.InputFile,
+			info.BatchInfo.EnableStdin,
+			flow.NewItemsWorkCreator(info.BatchInfo.ItemSeparate, 1, func(items []string) (work flow.Work, err *data.CodeError) {
+				source := strings.TrimSpace(items[0])
+				if source == "" {
+					return nil, alert.Error("key or url invalid", "")
+				}
+
+				if !info.isKeyMode() {
+					requestUrl, uErr := fopRequestUrl(source, object.ImageInfoFop, info.Sign)
+					if uErr != nil {
+						return nil, uErr
+					}
+					return &imageInfoWork{Source: source, Url: requestUrl}, nil
+				}
+
+				requestUrl, uErr := fopRequestUrl(download.PublicUrl(download.UrlApiInfo{
+					BucketDomain: domain,
+					Key:          source,
+					UseHttps:     useHttps,
+				}), object.ImageInfoFop, true)
+				if uErr != nil {
+					return nil, uErr
+				}
+				return &imageInfoWork{Source: source, Url: requestUrl}, nil
+			})).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
+				work := workInfo.Work.(*imageInfoWork)
+				result, iErr := object.ImageInfo(work.Source, work.Url)
+				if iErr != nil {
+					return nil, iErr
+				}
+				return result, nil
+			}), nil
+		})).
+		FlowWillStartFunc(func(flow *flow.Flow) (err *data.CodeError) {
+			metric.AddTotalCount(flow.WorkProvider.WorkTotalCount())
+			return nil
+		}).
+		Deadletter(exporter.Deadletter()).
+		OnWorkSkip(func(work *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			metric.AddCurrentCount(1)
+			metric.AddSkippedCount(1)
+			metric.PrintProgress("Batching:" + work.Data)
+			exporter.Fail().ExportF("%s%s%v", work.Data, flow.ErrorSeparate, err)
+			log.DebugF("Skip line:%s because:%v", work.Data, err)
+		}).
+		OnWorkSuccess(func(work *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
+			metric.AddCurrentCount(1)
+			metric.AddSuccessCount(1)
+			metric.PrintProgress("Batching:" + work.Data)
+
+			r, _ := result.(*object.ImageInfoResult)
+			exporter.Success().Export(work.Data)
+			if line := outputImageInfo(r); len(line) > 0 {
+				exporter.Result().Export(line)
+			}
+		}).
+		OnWorkFail(func(work *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
+			metric.AddCurrentCount(1)
+			metric.AddFailureCount(1)
+			metric.PrintProgress("Batching:" + work.Data)
+
+			exporter.Fail().ExportF("%s%s%v", work.Data, flow.ErrorSeparate, err)
+			log.Error(err)
+		}).Build().Start()
+
+	metric.End()
+}
@@ -154,7 +154,6 @@ func BatchChangeLifecycle(cfg *iqshell.Config, info BatchChangeLifecycleInfo) {
 		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
 			apiInfo, ok := (operation).(*object.ChangeLifecycleApiInfo)
 			if !ok {
-				data.SetCmdStatusError()
 				log.ErrorF("Change lifecycle Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
 				return
 			}
@@ -163,7 +162,6 @@ func BatchChangeLifecycle(cfg *iqshell.Config, info BatchChangeLifecycleInfo) {
 				log.InfoF("Change lifecycle Success, [%s:%s] => '%d:%d:%d:%d:%d'", in.Bucket, in.Key,
 					in.ToIAAfterDays, in.ToArchiveIRAfterDays, in.ToArchiveAfterDays, in.ToDeepArchiveAfterDays, in.DeleteAfterDays)
 			} else {
-				data.SetCmdStatusError()
 				log.ErrorF("Change lifecycle Failed, [%s:%s], Code: %d, Error: %s", in.Bucket, in.Key, result.Code, result.Error)
 			}
 		}).
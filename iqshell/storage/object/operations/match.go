@@ -105,7 +105,7 @@ func BatchMatch(cfg *iqshell.Config, info BatchMatchInfo) {
 	metric := &batch.Metric{}
 	metric.Start()
 	lineParser := bucket.NewListLineParser()
-	flow.New(info.BatchInfo.Info).
+	info.BatchInfo.NewFlow().
 		WorkProviderWithFile(info.BatchInfo.InputFile,
 			info.BatchInfo.EnableStdin,
 			flow.NewItemsWorkCreator(info.BatchInfo.ItemSeparate, 1, func(items []string) (work flow.Work, err *data.CodeError) {
@@ -164,6 +164,7 @@ func BatchMatch(cfg *iqshell.Config, info BatchMatchInfo) {
 			}
 			return false, nil
 		}).
+		Deadletter(exporter.Deadletter()).
 		OnWorkSkip(func(work *flow.WorkInfo, result flow.Result, err *data.CodeError) {
 			metric.AddCurrentCount(1)
 			metric.PrintProgress("Batching:" + work.Data)
@@ -185,7 +186,7 @@ func BatchMatch(cfg *iqshell.Config, info BatchMatchInfo) {
 				log.InfoF("Skip line:%s because:%v", work.Data, err)
 			}
 		}).
-		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result) {
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
 			metric.AddCurrentCount(1)
 			metric.AddSuccessCount(1)
 			metric.PrintProgress("Batching:" + workInfo.Data)
@@ -194,7 +195,7 @@ func BatchMatch(cfg *iqshell.Config, info BatchMatchInfo) {
 			exporter.Success().ExportF("%s\t \t%s", in.Key, in.ServerFileHash)
 			log.InfoF("Match Success, [%s:%s] => '%s'", info.Bucket, in.Key, in.LocalFile)
 		}).
-		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
 			metric.AddCurrentCount(1)
 			metric.AddFailureCount(1)
 			metric.PrintProgress("Batching:" + workInfo.Data)
@@ -222,16 +223,4 @@ func BatchMatch(cfg *iqshell.Config, info BatchMatchInfo) {
 	} else {
 		log.DebugF("save batch match result to path:%s", resultPath)
 	}
-
-	log.Info("--------------- Batch Match Result ---------------")
-	log.InfoF("%20s%10d", "Total:", metric.TotalCount)
-	log.InfoF("%20s%10d", "Success:", metric.SuccessCount)
-	log.InfoF("%20s%10d", "Failure:", metric.FailureCount)
-	log.InfoF("%20s%10d", "Skipped:", metric.SkippedCount)
-	log.InfoF("%20s%10ds", "Duration:", metric.Duration)
-	log.InfoF("--------------------------------------------------")
-
-	if !metric.IsCompletedSuccessfully() {
-		data.SetCmdStatusError()
-	}
 }
@@ -0,0 +1,231 @@
+package operations
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/upload"
+)
+
+// parseMetaData 解析 k1=v1,k2=v2 形式的元数据及需要删除的元数据名，返回不含 x-qn-meta- 前缀的名称到值的映射，值为空表示删除
+func parseMetaData(meta string, remove []string) (map[string]string, *data.CodeError) {
+	metaData := make(map[string]string)
+	names := make(map[string]string)
+	addMeta := func(key, value string) *data.CodeError {
+		name, err := upload.NormalizeMetadataName(key)
+		if err != nil {
+			return err
+		}
+		lowerName := strings.ToLower(name)
+		if lowerName == "content-type" {
+			return alert.Error("metadata Content-Type can't be set", "please use chgm to change the mime type of file")
+		}
+		if len(value) > 0 {
+			if err = upload.CheckMetadataValue(key, value); err != nil {
+				return err
+			}
+		}
+		if other, ok := names[lowerName]; ok {
+			return alert.Error(fmt.Sprintf("metadata %s and %s are duplicate", other, key), "metadata key is case-insensitive")
+		}
+		names[lowerName] = key
+		metaData[name] = value
+		return nil
+	}
+
+	for _, item := range strings.Split(meta, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		index := strings.Index(item, "=")
+		if index < 0 {
+			return nil, alert.Error("invalid metadata: "+item, "metadata should be like key1=value1,key2=value2")
+		}
+		if err := addMeta(strings.TrimSpace(item[:index]), item[index+1:]); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range remove {
+		key = strings.TrimSpace(key)
+		if len(key) == 0 {
+			continue
+		}
+		if err := addMeta(key, ""); err != nil {
+			return nil, err
+		}
+	}
+	return metaData, nil
+}
+
+// mergeMetaData 合并元数据，名称不区分大小写，相同名称的以 override 中的为准
+func mergeMetaData(base map[string]string, override map[string]string) map[string]string {
+	metaData := make(map[string]string, len(base)+len(override))
+	names := make(map[string]bool, len(override))
+	for name, value := range override {
+		metaData[name] = value
+		names[strings.ToLower(name)] = true
+	}
+	for name, value := range base {
+		if !names[strings.ToLower(name)] {
+			metaData[name] = value
+		}
+	}
+	return metaData
+}
+
+type SetMetaInfo struct {
+	Source string   // <Bucket>:<Key> 【必选】
+	Meta   string   // 需要设置的元数据，格式：k1=v1,k2=v2，值为空表示删除此元数据 【可选】
+	Remove []string // 需要删除的元数据名 【可选】
+
+	apiInfo object.ChangeMetaApiInfo
+}
+
+func (info *SetMetaInfo) Check() *data.CodeError {
+	if len(info.Source) == 0 {
+		return alert.CannotEmptyError("Bucket:Key", "")
+	}
+	var ok bool
+	if info.apiInfo.Bucket, info.apiInfo.Key, ok = splitBucketKey(info.Source); !ok {
+		return alert.Error("invalid source: "+info.Source, "source should be <Bucket>:<Key>")
+	}
+
+	metaData, err := parseMetaData(info.Meta, info.Remove)
+	if err != nil {
+		return err
+	}
+	if len(metaData) == 0 {
+		return alert.Error("no metadata to set or remove", "please set metadata by --meta or --remove")
+	}
+	info.apiInfo.MetaData = metaData
+	return nil
+}
+
+// SetMeta 修改已存在文件的自定义元数据，无需重新上传
+func SetMeta(cfg *iqshell.Config, info SetMetaInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	apiInfo := &info.apiInfo
+	result, err := object.ChangeMeta(apiInfo)
+	if err != nil || result == nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Set meta Failed, [%s:%s] => '%s', Error:%v", apiInfo.Bucket, apiInfo.Key, apiInfo.MetaDataString(), err)
+		return
+	}
+
+	if result.IsSuccess() {
+		log.InfoF("Set meta Success, [%s:%s] => '%s'", apiInfo.Bucket, apiInfo.Key, apiInfo.MetaDataString())
+	} else {
+		data.SetCmdStatusError()
+		log.ErrorF("Set meta Failed, [%s:%s] => '%s', Code:%d, Error:%v",
+			apiInfo.Bucket, apiInfo.Key, apiInfo.MetaDataString(), result.Code, result.Error)
+	}
+}
+
+type BatchSetMetaInfo struct {
+	BatchInfo batch.Info
+	Bucket    string
+	Meta      string   // 所有文件都需要设置的元数据，格式：k1=v1,k2=v2 【可选】
+	Remove    []string // 所有文件都需要删除的元数据名 【可选】
+
+	metaData map[string]string
+}
+
+func (info *BatchSetMetaInfo) Check() *data.CodeError {
+	if err := info.BatchInfo.Check(); err != nil {
+		return err
+	}
+
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+
+	metaData, err := parseMetaData(info.Meta, info.Remove)
+	if err != nil {
+		return err
+	}
+	info.metaData = metaData
+	return nil
+}
+
+// BatchSetMeta 批量修改文件的自定义元数据，每行的格式为：<Key>[<Sep><k1=v1,k2=v2>]，
+// 行内的元数据与 --meta、--remove 指定的元数据合并，名称相同的以行内的为准
+func BatchSetMeta(cfg *iqshell.Config, info BatchSetMetaInfo) {
+	cfg.JobPathBuilder = func(cmdPath string) string {
+		jobId := utils.Md5Hex(fmt.Sprintf("%s:%s:%s", cfg.CmdCfg.CmdId, info.Bucket, info.BatchInfo.InputFile))
+		return filepath.Join(cmdPath, jobId)
+	}
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	exporter, err := export.NewFileExport(info.BatchInfo.FileExporterConfig)
+	if err != nil {
+		log.Error(err)
+		data.SetCmdStatusError()
+		return
+	}
+
+	batch.NewHandler(info.BatchInfo).
+		EmptyOperation(func() flow.Work {
+			return &object.ChangeMetaApiInfo{}
+		}).
+		SetFileExport(exporter).
+		ItemsToOperation(func(items []string) (operation batch.Operation, err *data.CodeError) {
+			key := items[0]
+			if key == "" {
+				return nil, alert.Error("key invalid", "")
+			}
+
+			metaData := info.metaData
+			if len(items) > 1 {
+				lineMetaData, pErr := parseMetaData(items[1], nil)
+				if pErr != nil {
+					return nil, pErr
+				}
+				metaData = mergeMetaData(info.metaData, lineMetaData)
+			}
+			if len(metaData) == 0 {
+				return nil, alert.Error("no metadata to set or remove", "")
+			}
+			return &object.ChangeMetaApiInfo{
+				Bucket:   info.Bucket,
+				Key:      key,
+				MetaData: metaData,
+			}, nil
+		}).
+		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
+			apiInfo, ok := (operation).(*object.ChangeMetaApiInfo)
+			if !ok {
+				log.ErrorF("Set meta Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
+				return
+			}
+			if result.IsSuccess() {
+				log.InfoF("Set meta Success, [%s:%s] => '%s'", apiInfo.Bucket, apiInfo.Key, apiInfo.MetaDataString())
+			} else {
+				log.ErrorF("Set meta Failed, [%s:%s] => '%s', Code: %d, Error: %s",
+					apiInfo.Bucket, apiInfo.Key, apiInfo.MetaDataString(), result.Code, result.Error)
+			}
+		}).
+		OnError(func(err *data.CodeError) {
+			data.SetCmdStatusError()
+			log.ErrorF("Batch set meta error:%v:", err)
+		}).Start()
+}
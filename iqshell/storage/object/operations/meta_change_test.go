@@ -0,0 +1,37 @@
+package operations
+
+import (
+	"testing"
+)
+
+func TestParseMetaData(t *testing.T) {
+	metaData, err := parseMetaData("author=qiniu, x-qn-meta-Version=2,tmp=", []string{"old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"author": "qiniu", "Version": "2", "tmp": "", "old": ""}
+	if len(metaData) != len(expected) {
+		t.Fatalf("parse meta data error:%v", metaData)
+	}
+	for name, value := range expected {
+		if v, ok := metaData[name]; !ok || v != value {
+			t.Fatalf("meta data %s error, value:%s expected:%s", name, v, value)
+		}
+	}
+
+	for _, meta := range []string{"author", "a b=1", "Content-Type=text/plain", "etag=1", "a=1,A=2", "a=x\ny"} {
+		if _, err := parseMetaData(meta, nil); err == nil {
+			t.Fatalf("meta:%q should be invalid", meta)
+		}
+	}
+	if _, err := parseMetaData("a=1", []string{"x-qn-meta-a"}); err == nil {
+		t.Fatal("set and remove the same meta should be invalid")
+	}
+}
+
+func TestMergeMetaData(t *testing.T) {
+	metaData := mergeMetaData(map[string]string{"a": "1", "b": "2"}, map[string]string{"A": "", "c": "3"})
+	if len(metaData) != 3 || metaData["A"] != "" || metaData["b"] != "2" || metaData["c"] != "3" {
+		t.Fatalf("merge meta data error:%v", metaData)
+	}
+}
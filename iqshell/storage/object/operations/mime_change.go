@@ -11,10 +11,16 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/upload"
 	"path/filepath"
 )
 
-type ChangeMimeInfo object.ChangeMimeApiInfo
+type ChangeMimeInfo struct {
+	Bucket        string // 空间名 【必选】
+	Key           string // 文件名 【必选】
+	Mime          string // 新的 MimeType，和 FromExtension 二选一 【可选】
+	FromExtension bool   // 根据 Key 的扩展名设置 MimeType 【可选】
+}
 
 func (info *ChangeMimeInfo) Check() *data.CodeError {
 	if len(info.Bucket) == 0 {
@@ -23,12 +29,36 @@ func (info *ChangeMimeInfo) Check() *data.CodeError {
 	if len(info.Key) == 0 {
 		return alert.CannotEmptyError("Key", "")
 	}
-	if len(info.Mime) == 0 {
-		return alert.CannotEmptyError("MimeType", "")
+	mimeType, err := mimeTypeOfKey(info.Key, info.Mime, info.FromExtension)
+	if err != nil {
+		return err
 	}
+	info.Mime = mimeType
 	return nil
 }
 
+// mimeTypeOfKey 获取并校验文件新的 MimeType，fromExtension 为 true 时根据 key 的扩展名获取
+func mimeTypeOfKey(key string, mimeType string, fromExtension bool) (string, *data.CodeError) {
+	if fromExtension {
+		if len(mimeType) > 0 {
+			return "", alert.Error("NewMimeType and --from-extension can't be set at the same time", "")
+		}
+		if mimeType = mimeTypeDetector.Detect(key); len(mimeType) == 0 {
+			return "", alert.Error("can't detect mime type from the extension of key:"+key, "please set NewMimeType")
+		}
+	}
+	if len(mimeType) == 0 {
+		return "", alert.CannotEmptyError("MimeType", "")
+	}
+	if err := upload.CheckMimeType(mimeType); err != nil {
+		return "", err
+	}
+	return mimeType, nil
+}
+
+var mimeTypeDetector, _ = upload.NewMimeTypeDetector("")
+
+// ChangeMime 修改文件的 MimeType，并输出修改前后的 MimeType
 func ChangeMime(cfg *iqshell.Config, info ChangeMimeInfo) {
 	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
 		Checker: &info,
@@ -36,25 +66,44 @@ func ChangeMime(cfg *iqshell.Config, info ChangeMimeInfo) {
 		return
 	}
 
-	result, err := object.ChangeMimeType((*object.ChangeMimeApiInfo)(&info))
+	status, err := object.Status(object.StatusApiInfo{
+		Bucket: info.Bucket,
+		Key:    info.Key,
+	})
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Change mimetype Failed, [%s:%s] => '%s', get current mimetype Error:%v", info.Bucket, info.Key, info.Mime, err)
+		return
+	}
+	if status.MimeType == info.Mime {
+		log.InfoF("Mimetype of [%s:%s] is already '%s', no need to change", info.Bucket, info.Key, info.Mime)
+		return
+	}
+
+	result, err := object.ChangeMimeType(&object.ChangeMimeApiInfo{
+		Bucket: info.Bucket,
+		Key:    info.Key,
+		Mime:   info.Mime,
+	})
 	if err != nil || result == nil {
 		data.SetCmdStatusError()
-		log.ErrorF("Change mimetype Failed, [%s:%s] => '%s', Error:%v", info.Bucket, info.Key, info.Mime, err)
+		log.ErrorF("Change mimetype Failed, [%s:%s] '%s' => '%s', Error:%v", info.Bucket, info.Key, status.MimeType, info.Mime, err)
 		return
 	}
 
 	if result.IsSuccess() {
-		log.InfoF("Change mimetype Success, [%s:%s] => '%s'", info.Bucket, info.Key, info.Mime)
+		log.InfoF("Change mimetype Success, [%s:%s] '%s' => '%s'", info.Bucket, info.Key, status.MimeType, info.Mime)
 	} else {
 		data.SetCmdStatusError()
-		log.ErrorF("Change mimetype Failed, [%s:%s] => '%s', Code:%d, Error:%v",
-			info.Bucket, info.Key, info.Mime, result.Code, result.Error)
+		log.ErrorF("Change mimetype Failed, [%s:%s] '%s' => '%s', Code:%d, Error:%v",
+			info.Bucket, info.Key, status.MimeType, info.Mime, result.Code, result.Error)
 	}
 }
 
 type BatchChangeMimeInfo struct {
-	BatchInfo batch.Info
-	Bucket    string
+	BatchInfo     batch.Info
+	Bucket        string
+	FromExtension bool // 输入行中没有 MimeType 时根据 Key 的扩展名设置 MimeType 【可选】
 }
 
 func (info *BatchChangeMimeInfo) Check() *data.CodeError {
@@ -86,39 +135,48 @@ func BatchChangeMime(cfg *iqshell.Config, info BatchChangeMimeInfo) {
 		return
 	}
 
+	// 根据扩展名推断 MimeType 时，MimeType 列可以省略
+	requiredItemCount := 2
+	if info.FromExtension {
+		requiredItemCount = 1
+	}
 	batch.NewHandler(info.BatchInfo).
 		EmptyOperation(func() flow.Work {
 			return &object.ChangeMimeApiInfo{}
 		}).
 		SetFileExport(exporter).
+		ItemFields(requiredItemCount, "key", "mimeType").
 		ItemsToOperation(func(items []string) (operation batch.Operation, err *data.CodeError) {
+			key, mimeType := items[0], ""
+			if key == "" {
+				return nil, alert.Error("key invalid", "")
+			}
 			if len(items) > 1 {
-				key, mime := items[0], items[1]
-				if key != "" && mime != "" {
-					return &object.ChangeMimeApiInfo{
-						Bucket: info.Bucket,
-						Key:    key,
-						Mime:   mime,
-					}, nil
-				}
-				return nil, alert.Error("key or mime invalid", "")
+				mimeType = items[1]
+			} else if !info.FromExtension {
+				return nil, alert.Error("need more than one param", "")
+			}
+			// 行内指定了 MimeType 时以行内的为准
+			if mimeType, err = mimeTypeOfKey(key, mimeType, info.FromExtension && len(mimeType) == 0); err != nil {
+				return nil, err
 			}
-			return nil, alert.Error("need more than one param", "")
+			return &object.ChangeMimeApiInfo{
+				Bucket: info.Bucket,
+				Key:    key,
+				Mime:   mimeType,
+			}, nil
 		}).
 		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
 			apiInfo, ok := (operation).(*object.ChangeMimeApiInfo)
 			if !ok {
-				data.SetCmdStatusError()
 				log.ErrorF("Change mimetype Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
 				return
 			}
-			in := (*ChangeMimeInfo)(apiInfo)
 			if result.IsSuccess() {
-				log.InfoF("Change mimetype Success, [%s:%s] => '%s'", in.Bucket, in.Key, in.Mime)
+				log.InfoF("Change mimetype Success, [%s:%s] => '%s'", apiInfo.Bucket, apiInfo.Key, apiInfo.Mime)
 			} else {
-				data.SetCmdStatusError()
 				log.ErrorF("Change mimetype Failed, [%s:%s] => '%s', Code: %d, Error: %s",
-					in.Bucket, in.Key, in.Mime, result.Code, result.Error)
+					apiInfo.Bucket, apiInfo.Key, apiInfo.Mime, result.Code, result.Error)
 			}
 		}).
 		OnError(func(err *data.CodeError) {
@@ -107,6 +107,7 @@ func BatchMove(cfg *iqshell.Config, info BatchMoveInfo) {
 		EmptyOperation(func() flow.Work {
 			return &object.MoveApiInfo{}
 		}).
+		ItemFields(1, "key", "dstKey").
 		ItemsToOperation(func(items []string) (operation batch.Operation, err *data.CodeError) {
 			srcKey, destKey := items[0], items[0]
 			if len(items) > 1 {
@@ -126,17 +127,16 @@ func BatchMove(cfg *iqshell.Config, info BatchMoveInfo) {
 		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
 			apiInfo, ok := (operation).(*object.MoveApiInfo)
 			if !ok {
-				data.SetCmdStatusError()
 				log.ErrorF("Change mimetype Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
 				return
 			}
 
 			if result.IsSuccess() {
+				exportMoveUndoRecord(exporter, apiInfo)
 				log.InfoF("Move Success, [%s:%s] => [%s:%s]",
 					apiInfo.SourceBucket, apiInfo.SourceKey,
 					apiInfo.DestBucket, apiInfo.DestKey)
 			} else {
-				data.SetCmdStatusError()
 				log.ErrorF("Move Failed, [%s:%s] => [%s:%s], Code: %d, Error: %s",
 					apiInfo.SourceBucket, apiInfo.SourceKey,
 					apiInfo.DestBucket, apiInfo.DestKey,
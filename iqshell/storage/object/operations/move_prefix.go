@@ -0,0 +1,177 @@
+package operations
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+type MovePrefixInfo struct {
+	BatchInfo    batch.Info
+	SourceBucket string
+	DestBucket   string // 为空时和 SourceBucket 相同
+	FromPrefix   string // 源文件的前缀
+	ToPrefix     string // 目标文件的前缀，目标文件名为源文件名中开头的 FromPrefix 替换为 ToPrefix
+	Limit        int64  // 最多移动的文件数，0：不限制
+	Copy         bool   // 复制而不是移动
+}
+
+func (info *MovePrefixInfo) Check() *data.CodeError {
+	if len(info.SourceBucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	if len(info.DestBucket) == 0 {
+		info.DestBucket = info.SourceBucket
+	}
+	if len(info.FromPrefix) == 0 {
+		return alert.CannotEmptyError("--from", "")
+	}
+	if info.Limit < 0 {
+		return alert.Error("--limit should be greater than or equal to 0", "")
+	}
+	if info.SourceBucket == info.DestBucket {
+		if info.FromPrefix == info.ToPrefix {
+			return alert.Error("--from and --to can't be the same in the same bucket", "")
+		}
+		// 目标文件会被再次列举到，导致重复移动
+		if strings.HasPrefix(info.ToPrefix, info.FromPrefix) {
+			return alert.Error("--to can't start with --from in the same bucket, the moved files would be listed again", "")
+		}
+		// 如：--from a/b/ --to a/，a/b/b/x 移动为 a/b/x，排在当前列举位置之后，会被再次移动
+		if strings.HasPrefix(info.FromPrefix, info.ToPrefix) {
+			return alert.Error("--from can't start with --to in the same bucket, the moved files may be listed again", "")
+		}
+	}
+	return info.BatchInfo.Check()
+}
+
+// destKey 只替换开头的 FromPrefix，文件名中间出现的 FromPrefix 保持不变
+func (info *MovePrefixInfo) destKey(srcKey string) string {
+	return info.ToPrefix + strings.TrimPrefix(srcKey, info.FromPrefix)
+}
+
+// MovePrefix 边列举边移动（或复制）FromPrefix 前缀的所有文件，相当于重命名一个"文件夹"
+func MovePrefix(cfg *iqshell.Config, info MovePrefixInfo) {
+	cfg.JobPathBuilder = func(cmdPath string) string {
+		jobId := utils.Md5Hex(fmt.Sprintf("%s:%s:%s:%s:%s:%v", cfg.CmdCfg.CmdId, info.SourceBucket, info.DestBucket,
+			info.FromPrefix, info.ToPrefix, info.Copy))
+		return filepath.Join(cmdPath, jobId)
+	}
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	bucketManager, err := bucket.GetBucketManager()
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("Move prefix Failed, get bucket manager error:%v", err)
+		return
+	}
+
+	exporter, err := export.NewFileExport(info.BatchInfo.FileExporterConfig)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	action := "Move"
+	if info.Copy {
+		action = "Copy"
+	}
+	if !info.BatchInfo.Force && !info.BatchInfo.DryRun {
+		if info.Limit > 0 {
+			log.WarningF("%s at most %d files with prefix:%s in bucket:%s to prefix:%s in bucket:%s",
+				action, info.Limit, info.FromPrefix, info.SourceBucket, info.ToPrefix, info.DestBucket)
+		} else {
+			log.WarningF("%s all the files with prefix:%s in bucket:%s to prefix:%s in bucket:%s",
+				action, info.FromPrefix, info.SourceBucket, info.ToPrefix, info.DestBucket)
+		}
+		// 文件数未知，输入验证码后还需输入空间名确认
+		info.BatchInfo.ConfirmName = info.SourceBucket
+		info.BatchInfo.ConfirmThreshold = 1
+	}
+
+	lister, err := newPrefixLister(bucketManager, info.SourceBucket, info.FromPrefix, info.Limit, &info.BatchInfo.Info, func(entry storage.ListItem) flow.Work {
+		destKey := info.destKey(entry.Key)
+		if len(destKey) == 0 {
+			// 文件名和 --from 相同且 --to 为空，不能移动
+			data.SetCmdStatusError()
+			log.ErrorF("Skip file:%s because the dest key is empty", entry.Key)
+			return nil
+		}
+		if info.Copy {
+			return &object.CopyApiInfo{
+				SourceBucket: info.SourceBucket,
+				SourceKey:    entry.Key,
+				DestBucket:   info.DestBucket,
+				DestKey:      destKey,
+				Force:        info.BatchInfo.Overwrite,
+			}
+		}
+		return &object.MoveApiInfo{
+			SourceBucket: info.SourceBucket,
+			SourceKey:    entry.Key,
+			DestBucket:   info.DestBucket,
+			DestKey:      destKey,
+			Force:        info.BatchInfo.Overwrite,
+		}
+	})
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+	defer lister.stop()
+	info.BatchInfo.WorkProvider = lister
+
+	batch.NewHandler(info.BatchInfo).
+		SetFileExport(exporter).
+		EmptyOperation(func() flow.Work {
+			if info.Copy {
+				return &object.CopyApiInfo{}
+			}
+			return &object.MoveApiInfo{}
+		}).
+		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
+			var srcBucket, srcKey, destBucket, destKey string
+			switch apiInfo := operation.(type) {
+			case *object.MoveApiInfo:
+				if result.IsSuccess() {
+					exportMoveUndoRecord(exporter, apiInfo)
+				}
+				srcBucket, srcKey, destBucket, destKey = apiInfo.SourceBucket, apiInfo.SourceKey, apiInfo.DestBucket, apiInfo.DestKey
+			case *object.CopyApiInfo:
+				srcBucket, srcKey, destBucket, destKey = apiInfo.SourceBucket, apiInfo.SourceKey, apiInfo.DestBucket, apiInfo.DestKey
+			default:
+				log.ErrorF("%s Failed, %s, Code: %d, Error: %s", action, operationInfo, result.Code, result.Error)
+				return
+			}
+
+			if result.IsSuccess() {
+				log.InfoF("%s Success, [%s:%s] => [%s:%s]", action, srcBucket, srcKey, destBucket, destKey)
+			} else {
+				// 目标文件已存在且未指定 --overwrite 时 Code 为 614
+				log.ErrorF("%s Failed, [%s:%s] => [%s:%s], Code: %d, Error: %s",
+					action, srcBucket, srcKey, destBucket, destKey, result.Code, result.Error)
+			}
+		}).
+		OnError(func(err *data.CodeError) {
+			data.SetCmdStatusError()
+			log.ErrorF("%s by prefix error:%v", action, err)
+		}).Start()
+}
@@ -0,0 +1,38 @@
+package operations
+
+import (
+	"testing"
+)
+
+func TestMovePrefixDestKey(t *testing.T) {
+	info := &MovePrefixInfo{FromPrefix: "old/", ToPrefix: "new/"}
+	cases := map[string]string{
+		"old/a.txt":       "new/a.txt",
+		"old/a/old/b.txt": "new/a/old/b.txt",
+		"old/":            "new/",
+	}
+	for srcKey, want := range cases {
+		if got := info.destKey(srcKey); got != want {
+			t.Fatalf("dest key of %s should be %s, but:%s", srcKey, want, got)
+		}
+	}
+}
+
+func TestMovePrefixCheck(t *testing.T) {
+	info := &MovePrefixInfo{SourceBucket: "bucket", FromPrefix: "old/", ToPrefix: "old/new/"}
+	if err := info.Check(); err == nil {
+		t.Fatal("--to starts with --from in the same bucket should be invalid")
+	}
+
+	for _, toPrefix := range []string{"", "a/"} {
+		info = &MovePrefixInfo{SourceBucket: "bucket", FromPrefix: "a/b/", ToPrefix: toPrefix}
+		if err := info.Check(); err == nil {
+			t.Fatalf("--from starts with --to:%q in the same bucket should be invalid", toPrefix)
+		}
+	}
+
+	info = &MovePrefixInfo{SourceBucket: "bucket", DestBucket: "bucket2", FromPrefix: "old/", ToPrefix: "old/"}
+	if err := info.Check(); err != nil {
+		t.Fatalf("same prefix across buckets should be valid, but:%v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package operations
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+// moveUndoRecord 回滚日志中的一条记录，记录一次成功的移动操作
+type moveUndoRecord struct {
+	SourceBucket string `json:"source_bucket"`
+	SourceKey    string `json:"source_key"`
+	DestBucket   string `json:"dest_bucket"`
+	DestKey      string `json:"dest_key"`
+}
+
+func exportMoveUndoRecord(exporter *export.FileExporter, apiInfo *object.MoveApiInfo) {
+	record, err := json.Marshal(&moveUndoRecord{
+		SourceBucket: apiInfo.SourceBucket,
+		SourceKey:    apiInfo.SourceKey,
+		DestBucket:   apiInfo.DestBucket,
+		DestKey:      apiInfo.DestKey,
+	})
+	if err != nil {
+		log.ErrorF("export undo record error:%v", err)
+		return
+	}
+	exporter.Undo().Export(string(record))
+}
+
+type BatchMoveUndoInfo struct {
+	BatchInfo   batch.Info
+	UndoLogFile string // batchmove 或 batchrename 通过 --undo-log 导出的回滚日志
+}
+
+func (info *BatchMoveUndoInfo) Check() *data.CodeError {
+	if err := info.BatchInfo.Check(); err != nil {
+		return err
+	}
+
+	if len(info.UndoLogFile) == 0 {
+		return alert.CannotEmptyError("UndoLogFile", "")
+	}
+	return nil
+}
+
+// BatchMoveUndo 按回滚日志倒序将文件移回原位置，原目标文件已不存在的记录会被跳过
+func BatchMoveUndo(cfg *iqshell.Config, info BatchMoveUndoInfo) {
+	cfg.JobPathBuilder = func(cmdPath string) string {
+		jobId := utils.Md5Hex(fmt.Sprintf("%s:undo:%s", cfg.CmdCfg.CmdId, info.UndoLogFile))
+		return filepath.Join(cmdPath, jobId)
+	}
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	// 后执行的操作先撤销
+	inputFile := filepath.Join(workspace.GetJobDir(), ".undo_input")
+	if err := reverseFileLines(info.UndoLogFile, inputFile); err != nil {
+		log.ErrorF("Batch undo move error:%v", err)
+		data.SetCmdStatusError()
+		return
+	}
+	info.BatchInfo.InputFile = inputFile
+	info.BatchInfo.EnableStdin = false
+	// 回滚日志每行为一条 JSON 记录，不按 --input-format 等选项解析
+	info.BatchInfo.InputFormat = flow.InputFormatTsv
+	info.BatchInfo.InputColumns = nil
+	info.BatchInfo.InputHasHeader = false
+
+	exporter, err := export.NewFileExport(info.BatchInfo.FileExporterConfig)
+	if err != nil {
+		log.Error(err)
+		data.SetCmdStatusError()
+		return
+	}
+
+	batch.NewHandler(info.BatchInfo).
+		SetFileExport(exporter).
+		EmptyOperation(func() flow.Work {
+			return &object.MoveApiInfo{}
+		}).
+		ItemsToOperation(func(items []string) (operation batch.Operation, err *data.CodeError) {
+			// 记录为 JSON，key 中可能包含分隔符
+			line := strings.Join(items, info.BatchInfo.ItemSeparate)
+			record := &moveUndoRecord{}
+			if e := json.Unmarshal([]byte(line), record); e != nil {
+				return nil, alert.Error(fmt.Sprintf("invalid undo record:%v", e), "")
+			}
+			if len(record.SourceBucket) == 0 || len(record.SourceKey) == 0 ||
+				len(record.DestBucket) == 0 || len(record.DestKey) == 0 {
+				return nil, alert.Error("invalid undo record, bucket or key of source and dest can't be empty", "")
+			}
+
+			return &object.MoveApiInfo{
+				SourceBucket:       record.DestBucket,
+				SourceKey:          record.DestKey,
+				DestBucket:         record.SourceBucket,
+				DestKey:            record.SourceKey,
+				Force:              false,
+				SkipSourceNotExist: true,
+			}, nil
+		}).
+		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
+			apiInfo, ok := (operation).(*object.MoveApiInfo)
+			if !ok {
+				log.ErrorF("Undo Move Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
+				return
+			}
+
+			if result.IsSuccess() {
+				log.InfoF("Undo Move Success, [%s:%s] => [%s:%s]",
+					apiInfo.SourceBucket, apiInfo.SourceKey,
+					apiInfo.DestBucket, apiInfo.DestKey)
+			} else {
+				log.ErrorF("Undo Move Failed, [%s:%s] => [%s:%s], Code: %d, Error: %s",
+					apiInfo.SourceBucket, apiInfo.SourceKey,
+					apiInfo.DestBucket, apiInfo.DestKey,
+					result.Code, result.Error)
+			}
+		}).
+		OnError(func(err *data.CodeError) {
+			data.SetCmdStatusError()
+			log.ErrorF("Batch undo move error:%v:", err)
+		}).Start()
+}
+
+// reverseFileLines 将 src 中的非空行倒序写入 dest
+func reverseFileLines(src, dest string) *data.CodeError {
+	f, err := os.Open(src)
+	if err != nil {
+		return data.NewEmptyError().AppendDescF("open undo log error:%v", err)
+	}
+	defer f.Close()
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); len(strings.TrimSpace(line)) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return data.NewEmptyError().AppendDescF("read undo log error:%v", err)
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return data.NewEmptyError().AppendDescF("create undo input error:%v", err)
+	}
+	defer out.Close()
+
+	writer := bufio.NewWriter(out)
+	for i := len(lines) - 1; i >= 0; i-- {
+		_, _ = writer.WriteString(lines[i] + "\n")
+	}
+	if err = writer.Flush(); err != nil {
+		return data.NewEmptyError().AppendDescF("write undo input error:%v", err)
+	}
+	return nil
+}
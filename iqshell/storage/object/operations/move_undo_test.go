@@ -0,0 +1,85 @@
+package operations
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/config"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+func TestReverseFileLines(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "undo.log")
+	dest := filepath.Join(dir, "undo_input")
+	if err := os.WriteFile(src, []byte("a\n\nb\nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reverseFileLines(src, dest); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "c\nb\na\n" {
+		t.Fatalf("reversed content should be c b a, but:%q", string(content))
+	}
+}
+
+func TestBatchMoveUndo(t *testing.T) {
+	setupTestBatchAccount(t)
+	defer data.SetCmdStatus(data.StatusOK)
+
+	var ops []string
+	server := newTestBatchServer(&ops)
+	defer server.Close()
+
+	// key 中包含逗号及引号，且指定了 --input-format csv 等选项，回滚日志仍需按行解析
+	undoLog := filepath.Join(t.TempDir(), "undo.log")
+	content := `{"source_bucket":"bucket","source_key":"a,1.jpg","dest_bucket":"bucket","dest_key":"b,1.jpg"}` + "\n" +
+		`{"source_bucket":"bucket","source_key":"a\"2.jpg","dest_bucket":"bucket2","dest_key":"b\"2.jpg"}` + "\n"
+	if err := os.WriteFile(undoLog, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data.SetCmdStatus(data.StatusOK)
+	BatchMoveUndo(&iqshell.Config{
+		Region: "z0",
+		RsHost: server.URL,
+		CmdCfg: config.Config{CmdId: "batchmove"},
+	}, BatchMoveUndoInfo{
+		BatchInfo: batch.Info{
+			Info:           flow.Info{Force: true},
+			InputFormat:    flow.InputFormatCsv,
+			InputColumns:   []string{"key"},
+			InputHasHeader: true,
+		},
+		UndoLogFile: undoLog,
+	})
+	if status := data.GetCmdStatus(); status != data.StatusOK {
+		t.Fatal("undo status should be 0, but:", status)
+	}
+
+	// 后执行的操作先撤销，文件由目标位置移回源位置；移动前会先查询源文件是否存在
+	moves := make([]string, 0, len(ops))
+	for _, op := range ops {
+		items := strings.Split(op, "/")
+		if len(items) < 4 || items[1] != "move" {
+			continue
+		}
+		src, _ := base64.URLEncoding.DecodeString(items[2])
+		dest, _ := base64.URLEncoding.DecodeString(items[3])
+		moves = append(moves, string(src)+"=>"+string(dest))
+	}
+	if len(moves) != 2 || moves[0] != `bucket2:b"2.jpg=>bucket:a"2.jpg` || moves[1] != "bucket:b,1.jpg=>bucket:a,1.jpg" {
+		t.Fatal("undo moves error:", moves)
+	}
+}
@@ -0,0 +1,127 @@
+package operations
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+// prefixListLimit 按前缀操作时每次列举的文件数
+const prefixListLimit = 1000
+
+// prefixLister 列举 bucket 中 prefix 前缀的文件，通过 workCreator 转为 work 后提供给 flow；
+// flow 首次获取 work 时（用户确认之后）才开始在后台列举，flow 结束后需调用 stop 结束列举
+type prefixLister struct {
+	bucketManager *storage.BucketManager
+	bucket        string
+	prefix        string
+	limit         int64        // 最多提供的 work 数，被 keyFilter 过滤的文件不计入，0：不限制
+	keyFilter     flow.Skipper // 按 key 过滤，和 flow 的过滤条件一致
+	workCreator   func(entry storage.ListItem) flow.Work
+
+	works     chan flow.Work
+	done      chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// newPrefixLister workCreator 返回 nil 时跳过此文件且不计入 limit；info 中的 include、exclude 用于计算 limit
+func newPrefixLister(bucketManager *storage.BucketManager, bucket, prefix string, limit int64, info *flow.Info,
+	workCreator func(entry storage.ListItem) flow.Work) (*prefixLister, *data.CodeError) {
+	l := &prefixLister{
+		bucketManager: bucketManager,
+		bucket:        bucket,
+		prefix:        prefix,
+		limit:         limit,
+		workCreator:   workCreator,
+		works:         make(chan flow.Work, prefixListLimit),
+		done:          make(chan struct{}),
+	}
+	if len(info.IncludeKeyRegexes) > 0 || len(info.ExcludeKeyRegexes) > 0 {
+		keyFilter, err := flow.NewRegexSkipper(info.IncludeKeyRegexes, info.ExcludeKeyRegexes)
+		if err != nil {
+			return nil, err
+		}
+		l.keyFilter = keyFilter
+	}
+	return l, nil
+}
+
+func (l *prefixLister) WorkTotalCount() int64 {
+	return flow.UnknownWorkCount
+}
+
+// Provide work 为 batch.Operation 时 Data 为其 key，导出的成功、失败列表可以直接作为批量命令的输入文件
+func (l *prefixLister) Provide() (hasMore bool, work *flow.WorkInfo, err *data.CodeError) {
+	l.startOnce.Do(func() {
+		go l.list()
+	})
+
+	w, ok := <-l.works
+	if !ok {
+		return false, &flow.WorkInfo{}, nil
+	}
+	info := &flow.WorkInfo{
+		Work: w,
+	}
+	if operation, ok := w.(batch.Operation); ok {
+		info.Data = operation.GetKey()
+	} else {
+		info.Data = fmt.Sprintf("%+v", w)
+	}
+	return true, info, nil
+}
+
+// stop 结束列举，未开始列举时不再列举
+func (l *prefixLister) stop() {
+	l.stopOnce.Do(func() {
+		close(l.done)
+	})
+}
+
+// list 列举结束、列举出错、达到 limit、用户中断或调用 stop 后结束，并关闭 works
+func (l *prefixLister) list() {
+	defer close(l.works)
+
+	marker := ""
+	listedCount := int64(0)
+	for {
+		entries, _, nextMarker, hasNext, lErr := l.bucketManager.ListFiles(l.bucket, l.prefix, "", marker, prefixListLimit)
+		if lErr != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("list bucket:%s with prefix:%s error, marker:%s error:%v", l.bucket, l.prefix, marker, lErr)
+			return
+		}
+		for _, entry := range entries {
+			if l.limit > 0 && listedCount >= l.limit {
+				log.InfoF("reach the limit:%d, stop listing", l.limit)
+				return
+			}
+			work := l.workCreator(entry)
+			if work == nil {
+				continue
+			}
+			// 被过滤的文件仍交由 flow 跳过，但不计入 limit
+			if l.keyFilter == nil {
+				listedCount++
+			} else if skip, _ := l.keyFilter.ShouldSkip(&flow.WorkInfo{Data: entry.Key, Work: work}); !skip {
+				listedCount++
+			}
+			select {
+			case l.works <- work:
+			case <-l.done:
+				return
+			}
+		}
+		if !hasNext || len(nextMarker) == 0 || workspace.IsCmdInterrupt() {
+			return
+		}
+		marker = nextMarker
+	}
+}
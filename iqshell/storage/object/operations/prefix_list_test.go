@@ -0,0 +1,101 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/auth/qbox"
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+)
+
+// newTestListServer 每次列举返回 count 个文件，key 为 a0、b1、a2...，始终有下一页
+func newTestListServer(count int, requestCount *int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(requestCount, 1)
+		items := make([]storage.ListItem, 0, count)
+		for i := 0; i < count; i++ {
+			prefix := "a"
+			if i%2 == 1 {
+				prefix = "b"
+			}
+			items = append(items, storage.ListItem{Key: fmt.Sprintf("%s%d", prefix, i)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"marker": "next",
+			"items":  items,
+		})
+	}))
+}
+
+func newTestPrefixLister(t *testing.T, server *httptest.Server, limit int64, info *flow.Info) *prefixLister {
+	bucketManager := storage.NewBucketManager(qbox.NewMac("ak", "sk"), &storage.Config{RsfHost: server.URL})
+	lister, err := newPrefixLister(bucketManager, "bucket", "", limit, info, func(entry storage.ListItem) flow.Work {
+		return &object.DeleteApiInfo{Bucket: "bucket", Key: entry.Key}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lister
+}
+
+func TestPrefixListerStartLazily(t *testing.T) {
+	var requestCount int64
+	server := newTestListServer(10, &requestCount)
+	defer server.Close()
+
+	// 用户未确认时不会获取 work，不应列举
+	lister := newTestPrefixLister(t, server, 0, &flow.Info{})
+	lister.stop()
+	time.Sleep(100 * time.Millisecond)
+	if c := atomic.LoadInt64(&requestCount); c != 0 {
+		t.Fatal("should not list before providing work, but request count:", c)
+	}
+
+	// flow 提前结束后，列举需结束
+	lister = newTestPrefixLister(t, server, 0, &flow.Info{})
+	if hasMore, work, _ := lister.Provide(); !hasMore || work.Data != "a0" {
+		t.Fatal("work data should be the key, but:", work.Data)
+	}
+	lister.stop()
+	done := make(chan struct{})
+	go func() {
+		for range lister.works {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("lister should stop listing after stop")
+	}
+}
+
+func TestPrefixListerLimit(t *testing.T) {
+	var requestCount int64
+	server := newTestListServer(10, &requestCount)
+	defer server.Close()
+
+	// 被过滤的文件不计入 limit
+	lister := newTestPrefixLister(t, server, 3, &flow.Info{IncludeKeyRegexes: []string{"^a"}})
+	defer lister.stop()
+	var keys []string
+	for {
+		hasMore, work, _ := lister.Provide()
+		if !hasMore {
+			break
+		}
+		keys = append(keys, work.Data)
+	}
+	if fmt.Sprint(keys) != "[a0 b1 a2 b3 a4]" {
+		t.Fatal("should provide 3 works matching the filter, but:", keys)
+	}
+}
@@ -15,15 +15,20 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/download"
 )
 
 const (
 	DefaultDeadline = 3600
+
+	// maxRelativeDeadline 小于此值的整数 deadline 当作有效时长（秒），否则当作时间戳
+	maxRelativeDeadline = 1000000000
 )
 
 type PrivateUrlInfo struct {
+	Key       string // 按 key 签名时有值，输出格式为：key\tPrivateUrl
 	PublicUrl string
 	Deadline  string
 }
@@ -40,15 +45,47 @@ func (p *PrivateUrlInfo) Check() *data.CodeError {
 }
 
 func (p PrivateUrlInfo) getDeadlineOfInt() (int64, *data.CodeError) {
-	if len(p.Deadline) == 0 {
-		return time.Now().Add(time.Second * DefaultDeadline).Unix(), nil
+	return parseDeadline(p.Deadline, time.Now())
+}
+
+// parseDeadline 解析 deadline，支持：
+// 1. 时间戳，单位：秒，如：1473840685
+// 2. 有效时长，单位：秒，如：3600 或 +3600；数值小于 1000000000 时当作有效时长
+// 3. 带单位的有效时长，如：30m、2h、7d
+func parseDeadline(deadline string, now time.Time) (int64, *data.CodeError) {
+	deadline = strings.TrimSpace(deadline)
+	if len(deadline) == 0 {
+		return now.Add(time.Second * DefaultDeadline).Unix(), nil
 	}
 
-	if val, err := strconv.ParseInt(p.Deadline, 10, 64); err != nil {
-		return 0, data.NewEmptyError().AppendDesc("invalid deadline")
-	} else {
+	relative := strings.HasPrefix(deadline, "+")
+	deadline = strings.TrimPrefix(deadline, "+")
+	if val, err := strconv.ParseInt(deadline, 10, 64); err == nil {
+		if val < 1 {
+			return 0, data.NewEmptyError().AppendDescF("invalid deadline:%s", deadline)
+		}
+		if relative || val < maxRelativeDeadline {
+			return now.Add(time.Second * time.Duration(val)).Unix(), nil
+		}
 		return val, nil
 	}
+
+	var duration time.Duration
+	if strings.HasSuffix(deadline, "d") {
+		days, err := strconv.ParseInt(strings.TrimSuffix(deadline, "d"), 10, 64)
+		if err != nil {
+			return 0, data.NewEmptyError().AppendDescF("invalid deadline:%s", deadline)
+		}
+		duration = time.Hour * 24 * time.Duration(days)
+	} else if d, err := time.ParseDuration(deadline); err != nil {
+		return 0, data.NewEmptyError().AppendDescF("invalid deadline:%s, should be a timestamp or a duration like 3600, 30m, 2h, 7d", deadline)
+	} else {
+		duration = d
+	}
+	if duration < time.Second {
+		return 0, data.NewEmptyError().AppendDescF("invalid deadline:%s", deadline)
+	}
+	return now.Add(duration).Unix(), nil
 }
 
 func PrivateUrl(cfg *iqshell.Config, info PrivateUrlInfo) {
@@ -81,18 +118,28 @@ func PrivateUrl(cfg *iqshell.Config, info PrivateUrlInfo) {
 type BatchPrivateUrlInfo struct {
 	BatchInfo batch.Info
 	Deadline  string
+	Bucket    string // 输入为 key 时，根据空间获取下载域名
+	Domain    string // 输入为 key 时使用的下载域名，优先级高于 Bucket
 }
 
 func (info *BatchPrivateUrlInfo) Check() *data.CodeError {
 	if err := info.BatchInfo.Check(); err != nil {
 		return err
 	}
+	if _, err := parseDeadline(info.Deadline, time.Now()); err != nil {
+		return err
+	}
 	return nil
 }
 
+// isKeyMode 输入的每行是否为 key，否则为公开外链
+func (info *BatchPrivateUrlInfo) isKeyMode() bool {
+	return len(info.Bucket) > 0 || len(info.Domain) > 0
+}
+
 func BatchPrivateUrl(cfg *iqshell.Config, info BatchPrivateUrlInfo) {
 	cfg.JobPathBuilder = func(cmdPath string) string {
-		jobId := utils.Md5Hex(fmt.Sprintf("%s:%s:%s", cfg.CmdCfg.CmdId, info.Deadline, info.BatchInfo.InputFile))
+		jobId := utils.Md5Hex(fmt.Sprintf("%s:%s:%s:%s:%s", cfg.CmdCfg.CmdId, info.Deadline, info.Bucket, info.Domain, info.BatchInfo.InputFile))
 		return filepath.Join(cmdPath, jobId)
 	}
 	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
@@ -108,6 +155,25 @@ func BatchPrivateUrl(cfg *iqshell.Config, info BatchPrivateUrlInfo) {
 		return
 	}
 
+	domain := info.Domain
+	if info.isKeyMode() && len(domain) == 0 {
+		if domain, err = bucket.DomainOfBucket(info.Bucket); err != nil {
+			log.Error(err)
+			data.SetCmdStatusError()
+			return
+		}
+	}
+	useHttps := workspace.GetConfig().IsUseHttps()
+
+	// 所有链接使用相同的过期时间
+	deadline, err := parseDeadline(info.Deadline, time.Now())
+	if err != nil {
+		log.Error(err)
+		data.SetCmdStatusError()
+		return
+	}
+	deadlineString := strconv.FormatInt(deadline, 10)
+
 	dbPath := filepath.Join(workspace.GetJobDir(), ".recorder")
 	if info.BatchInfo.EnableRecord {
 		log.DebugF("batch sign recorder:%s", dbPath)
@@ -117,7 +183,7 @@ func BatchPrivateUrl(cfg *iqshell.Config, info BatchPrivateUrlInfo) {
 
 	metric := &batch.Metric{}
 	metric.Start()
-	flow.New(info.BatchInfo.Info).
+	info.BatchInfo.NewFlow().
 		WorkProviderWithFile(info.BatchInfo.InputFile,
 			info.BatchInfo.EnableStdin,
 			flow.NewItemsWorkCreator(info.BatchInfo.ItemSeparate, 1, func(items []string) (work flow.Work, err *data.CodeError) {
@@ -130,9 +196,20 @@ func BatchPrivateUrl(cfg *iqshell.Config, info BatchPrivateUrlInfo) {
 				if urlToSign == "" {
 					return nil, alert.Error("url invalid after TrimSpace", "")
 				}
+				if info.isKeyMode() {
+					return &PrivateUrlInfo{
+						Key: url,
+						PublicUrl: download.PublicUrl(download.UrlApiInfo{
+							BucketDomain: domain,
+							Key:          url,
+							UseHttps:     useHttps,
+						}),
+						Deadline: deadlineString,
+					}, nil
+				}
 				return &PrivateUrlInfo{
 					PublicUrl: url,
-					Deadline:  info.Deadline,
+					Deadline:  deadlineString,
 				}, nil
 			})).
 		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
@@ -184,6 +261,7 @@ func BatchPrivateUrl(cfg *iqshell.Config, info BatchPrivateUrlInfo) {
 			}
 			return false, nil
 		}).
+		Deadletter(exporter.Deadletter()).
 		OnWorkSkip(func(work *flow.WorkInfo, result flow.Result, err *data.CodeError) {
 			metric.AddCurrentCount(1)
 			metric.PrintProgress("Batching:" + work.Data)
@@ -206,17 +284,21 @@ func BatchPrivateUrl(cfg *iqshell.Config, info BatchPrivateUrlInfo) {
 			}
 
 		}).
-		OnWorkSuccess(func(work *flow.WorkInfo, result flow.Result) {
+		OnWorkSuccess(func(work *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
 			metric.AddCurrentCount(1)
 			metric.AddSuccessCount(1)
 			metric.PrintProgress("Batching:" + work.Data)
 
 			r, _ := result.(*download.PublicUrlToPrivateApiResult)
+			line := r.Url
+			if in, ok := work.Work.(*PrivateUrlInfo); ok && len(in.Key) > 0 {
+				line = in.Key + "\t" + r.Url
+			}
 			exporter.Success().Export(work.Data)
-			exporter.Result().ExportF(r.Url)
-			log.Alert(r.Url)
+			exporter.Result().Export(line)
+			log.Alert(line)
 		}).
-		OnWorkFail(func(work *flow.WorkInfo, err *data.CodeError) {
+		OnWorkFail(func(work *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
 			metric.AddCurrentCount(1)
 			metric.AddFailureCount(1)
 			metric.PrintProgress("Batching:" + work.Data)
@@ -239,16 +321,4 @@ func BatchPrivateUrl(cfg *iqshell.Config, info BatchPrivateUrlInfo) {
 	} else {
 		log.DebugF("save batch sign result to path:%s", resultPath)
 	}
-
-	log.Info("\n--------------- Batch Sign Result ---------------")
-	log.InfoF("%20s%10d", "Total:", metric.TotalCount)
-	log.InfoF("%20s%10d", "Success:", metric.SuccessCount)
-	log.InfoF("%20s%10d", "Failure:", metric.FailureCount)
-	log.InfoF("%20s%10d", "Skipped:", metric.SkippedCount)
-	log.InfoF("%20s%10ds", "Duration:", metric.Duration)
-	log.InfoF("-------------------------------------------------")
-
-	if !metric.IsCompletedSuccessfully() {
-		data.SetCmdStatusError()
-	}
 }
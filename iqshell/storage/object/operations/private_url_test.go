@@ -0,0 +1,30 @@
+package operations
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDeadline(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cases := map[string]int64{
+		"":           1700000000 + 3600,
+		"3600":       1700000000 + 3600,
+		"+60":        1700000000 + 60,
+		"30m":        1700000000 + 1800,
+		"2h":         1700000000 + 7200,
+		"7d":         1700000000 + 7*24*3600,
+		"1800000000": 1800000000,
+	}
+	for value, expected := range cases {
+		if ret, err := parseDeadline(value, now); err != nil || ret != expected {
+			t.Fatalf("deadline of %s should be %d, but:%d err:%v", value, expected, ret, err)
+		}
+	}
+
+	for _, value := range []string{"0", "-1", "abc", "1ms", "xd"} {
+		if _, err := parseDeadline(value, now); err == nil {
+			t.Fatalf("deadline %s should be invalid", value)
+		}
+	}
+}
@@ -31,6 +31,9 @@ func (info *RenameInfo) Check() *data.CodeError {
 	if len(info.DestKey) == 0 {
 		return alert.CannotEmptyError("DestKey", "")
 	}
+	if info.SourceBucket == info.DestBucket && info.SourceKey == info.DestKey {
+		return alert.Error("DestKey is the same as SrcKey: "+info.SourceKey, "rename to a different key")
+	}
 	return nil
 }
 
@@ -103,9 +106,13 @@ func BatchRename(cfg *iqshell.Config, info BatchRenameInfo) {
 			return &object.MoveApiInfo{}
 		}).
 		SetFileExport(exporter).
+		ItemFields(2, "key", "dstKey").
 		ItemsToOperation(func(items []string) (operation batch.Operation, err *data.CodeError) {
 			if len(items) > 1 {
 				sourceKey, destKey := items[0], items[1]
+				if sourceKey == destKey {
+					return nil, alert.Error("dest key is the same as source key", "")
+				}
 				if sourceKey != "" && destKey != "" {
 					return &object.MoveApiInfo{
 						SourceBucket: info.Bucket,
@@ -123,17 +130,16 @@ func BatchRename(cfg *iqshell.Config, info BatchRenameInfo) {
 		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
 			apiInfo, ok := (operation).(*object.MoveApiInfo)
 			if !ok {
-				data.SetCmdStatusError()
 				log.ErrorF("Rename Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
 				return
 			}
 			in := (*RenameInfo)(apiInfo)
 			if result.IsSuccess() {
+				exportMoveUndoRecord(exporter, apiInfo)
 				log.InfoF("Rename Success, [%s:%s] => [%s:%s]",
 					in.SourceBucket, in.SourceKey,
 					in.DestBucket, in.DestKey)
 			} else {
-				data.SetCmdStatusError()
 				log.ErrorF("Rename Failed, [%s:%s] => [%s:%s], Code: %d, Error: %s",
 					in.SourceBucket, in.SourceKey,
 					in.DestBucket, in.DestKey,
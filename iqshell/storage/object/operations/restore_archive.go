@@ -23,7 +23,7 @@ func convertFreezeAfterDaysToInt(freezeAfterDays string) (int, *data.CodeError)
 	if freezeAfterDaysInt, err := strconv.Atoi(freezeAfterDays); err != nil {
 		return 0, alert.Error("FreezeAfterDays is invalid:"+err.Error(), "")
 	} else {
-		if freezeAfterDaysInt > 0 || freezeAfterDaysInt < 8 {
+		if freezeAfterDaysInt > 0 && freezeAfterDaysInt < 8 {
 			return freezeAfterDaysInt, nil
 		}
 		return 0, alert.Error("FreezeAfterDays must between 1 and 7, include 1 and 7", "")
@@ -92,6 +92,8 @@ type BatchRestoreArchiveInfo struct {
 	Bucket             string
 	FreezeAfterDays    string
 	freezeAfterDaysInt int
+	DaysColumnEnable   bool // 输入每行的第二列为 FreezeAfterDays，没有第二列时使用 FreezeAfterDays
+	SkipRestored       bool // 执行前 stat 文件，已解冻的文件跳过
 }
 
 func (info *BatchRestoreArchiveInfo) Check() *data.CodeError {
@@ -103,6 +105,11 @@ func (info *BatchRestoreArchiveInfo) Check() *data.CodeError {
 		return alert.CannotEmptyError("Bucket", "")
 	}
 
+	// 按行指定 FreezeAfterDays 时，FreezeAfterDays 可以为空
+	if info.DaysColumnEnable && len(info.FreezeAfterDays) == 0 {
+		return nil
+	}
+
 	if freezeAfterDaysInt, err := convertFreezeAfterDaysToInt(info.FreezeAfterDays); err != nil {
 		return err
 	} else {
@@ -137,19 +144,32 @@ func BatchRestoreArchive(cfg *iqshell.Config, info BatchRestoreArchiveInfo) {
 		SetFileExport(exporter).
 		ItemsToOperation(func(items []string) (operation batch.Operation, err *data.CodeError) {
 			key := items[0]
-			if len(key) > 0 {
-				return &object.RestoreArchiveApiInfo{
-					Bucket:          info.Bucket,
-					Key:             key,
-					FreezeAfterDays: info.freezeAfterDaysInt,
-				}, nil
+			if len(key) == 0 {
+				return nil, alert.Error("key invalid", "")
+			}
+
+			freezeAfterDays := info.freezeAfterDaysInt
+			if info.DaysColumnEnable && len(items) > 1 && len(items[1]) > 0 {
+				days, err := convertFreezeAfterDaysToInt(items[1])
+				if err != nil {
+					return nil, err
+				}
+				freezeAfterDays = days
+			}
+			if freezeAfterDays == 0 {
+				return nil, alert.CannotEmptyError("FreezeAfterDays", "")
 			}
-			return nil, alert.Error("key invalid", "")
+
+			return &object.RestoreArchiveApiInfo{
+				Bucket:          info.Bucket,
+				Key:             key,
+				FreezeAfterDays: freezeAfterDays,
+				SkipRestored:    info.SkipRestored,
+			}, nil
 		}).
 		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
 			apiInfo, ok := (operation).(*object.RestoreArchiveApiInfo)
 			if !ok {
-				data.SetCmdStatusError()
 				log.ErrorF("Restore archive Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
 				return
 			}
@@ -158,7 +178,6 @@ func BatchRestoreArchive(cfg *iqshell.Config, info BatchRestoreArchiveInfo) {
 				log.InfoF("Restore archive Success, [%s:%s], FreezeAfterDays:%d",
 					apiInfo.Bucket, apiInfo.Key, apiInfo.FreezeAfterDays)
 			} else {
-				data.SetCmdStatusError()
 				log.ErrorF("Restore archive Failed, [%s:%s], FreezeAfterDays:%d, Code: %d, Error: %s",
 					apiInfo.Bucket, apiInfo.Key, apiInfo.FreezeAfterDays,
 					result.Code, result.Error)
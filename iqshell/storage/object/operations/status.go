@@ -1,8 +1,10 @@
 package operations
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/qiniu/qshell/v2/iqshell"
@@ -43,7 +45,12 @@ func Status(cfg *iqshell.Config, info StatusInfo) {
 		return
 	}
 
-	if result.IsSuccess() {
+	if !result.IsSuccess() {
+		return
+	}
+	if data.IsOutputFormatJson() {
+		outputStatusJson(newStatusJson(info.Bucket, info.Key, &result))
+	} else {
 		log.Alert(getResultInfo(info.Bucket, info.Key, result))
 	}
 }
@@ -100,18 +107,27 @@ func BatchStatus(cfg *iqshell.Config, info BatchStatusInfo) {
 		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
 			apiInfo, ok := (operation).(*object.StatusApiInfo)
 			if !ok {
-				data.SetCmdStatusError()
 				log.ErrorF("Status Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
 				return
 			}
 			in := (*StatusInfo)(apiInfo)
-			if result.IsSuccess() {
+			if result.IsSuccess() && data.IsOutputFormatJson() {
+				// 批量 stat 不返回自定义元数据及生命周期转换的日期
+				if line := outputStatusJson(newStatusJson(in.Bucket, in.Key, &object.StatusResult{
+					OperationResult: *result,
+					RestoreStatus:   result.RestoreStatus,
+					Status:          result.Status,
+					MD5:             result.MD5,
+					Expiration:      result.Expiration,
+				})); len(line) > 0 {
+					exporter.Result().Export(line)
+				}
+			} else if result.IsSuccess() {
 				infoString := fmt.Sprintf("%s\t%d\t%s\t%s\t%d\t%d",
 					in.Key, result.FSize, result.Hash, result.MimeType, result.PutTime, result.Type)
 				log.Alert(infoString)
 				exporter.Result().Export(infoString)
 			} else {
-				data.SetCmdStatusError()
 				log.ErrorF("Status Failed, [%s:%s], Code: %d, Error: %s", in.Bucket, in.Key, result.Code, result.Error)
 			}
 		}).
@@ -140,29 +156,36 @@ func getResultInfo(bucket, key string, status object.StatusResult) string {
 		}
 	}
 
+	// 接口未返回的字段不输出
+	optionalFieldAdder := func(name string, value string) {
+		if len(value) > 0 {
+			fieldAdder(name, value, "")
+		}
+	}
+
 	fieldAdder("Bucket", bucket, "")
 	fieldAdder("Key", key, "")
 	fieldAdder("Etag", status.Hash, "")
-	fieldAdder("MD5", status.MD5, "")
+	optionalFieldAdder("MD5", status.MD5)
 	fieldAdder("Fsize", status.FSize, utils.FormatFileSize(status.FSize))
 	fieldAdder("PutTime", status.PutTime, time.Unix(0, status.PutTime*100).String())
 	fieldAdder("MimeType", status.MimeType, "")
-	fieldAdder("EndUser", status.EndUser, "")
+	optionalFieldAdder("EndUser", status.EndUser)
 
 	fieldAdderWithValueDescs("Status", status.Status,
 		map[interface{}]string{1: "禁用"},
 		"未禁用")
 
-	fieldAdderWithValueDescs("RestoreStatus", status.Status,
-		map[interface{}]string{1: "解冻中", 2: "解冻完成"},
-		"无解冻操作")
+	if status.RestoreStatus > 0 {
+		fieldAdderWithValueDescs("RestoreStatus", status.RestoreStatus,
+			map[interface{}]string{1: "解冻中", 2: "解冻完成"},
+			"无解冻操作")
+	}
 
 	lifecycleFieldAdder := func(name string, date int64) {
 		if date > 0 {
 			t := time.Unix(date, 0)
 			fieldAdder(name, date, t.String())
-		} else {
-			fieldAdder(name, "未设置", "")
 		}
 	}
 	lifecycleFieldAdder("Expiration", status.Expiration)
@@ -171,13 +194,99 @@ func getResultInfo(bucket, key string, status object.StatusResult) string {
 	lifecycleFieldAdder("TransitionToArchive", status.TransitionToARCHIVE)
 	lifecycleFieldAdder("TransitionToDeepArchive", status.TransitionToDeepArchive)
 
-	fieldAdder("FileType", status.Type, getFileTypeDescription(status.Type))
+	fieldAdder("FileType", status.Type, getFileTypeName(status.Type)+" "+getFileTypeDescription(status.Type))
+
+	for _, name := range sortedMetaDataNames(status.MetaData) {
+		fieldAdder(metaDataPrefix+name, status.MetaData[name], "")
+	}
 
 	return statInfo
 }
 
 var objectTypes = []string{"标准存储", "低频存储", "归档存储", "深度归档存储", "归档直读存储"}
 
+var objectTypeNames = []string{"STANDARD", "IA", "ARCHIVE", "DEEP_ARCHIVE", "ARCHIVE_IR"}
+
+func getFileTypeName(fileType int) string {
+	if fileType >= 0 && fileType < len(objectTypeNames) {
+		return objectTypeNames[fileType]
+	}
+	return fmt.Sprintf("TYPE_%d", fileType)
+}
+
+// metaDataPrefix 自定义元数据的前缀，stat 返回的元数据 key 不包含此前缀
+const metaDataPrefix = "x-qn-meta-"
+
+func sortedMetaDataNames(metaData map[string]string) []string {
+	names := make([]string, 0, len(metaData))
+	for name := range metaData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StatusJson stat 以 json 格式输出的文件信息，接口未返回的字段不输出
+type StatusJson struct {
+	Bucket                  string            `json:"bucket"`
+	Key                     string            `json:"key"`
+	Hash                    string            `json:"hash"`
+	MD5                     string            `json:"md5,omitempty"`
+	FSize                   int64             `json:"fsize"`
+	PutTime                 int64             `json:"put_time"`
+	MimeType                string            `json:"mime_type"`
+	EndUser                 string            `json:"end_user,omitempty"`
+	Status                  int               `json:"status,omitempty"`
+	RestoreStatus           int               `json:"restore_status,omitempty"`
+	Expiration              int64             `json:"expiration,omitempty"`
+	TransitionToIA          int64             `json:"transition_to_ia,omitempty"`
+	TransitionToArchiveIR   int64             `json:"transition_to_archive_ir,omitempty"`
+	TransitionToArchive     int64             `json:"transition_to_archive,omitempty"`
+	TransitionToDeepArchive int64             `json:"transition_to_deep_archive,omitempty"`
+	FileType                int               `json:"file_type"`
+	StorageType             string            `json:"storage_type"`       // 存储类型的名称
+	MetaData                map[string]string `json:"metadata,omitempty"` // key 包含 x-qn-meta- 前缀
+}
+
+func newStatusJson(bucket, key string, status *object.StatusResult) *StatusJson {
+	ret := &StatusJson{
+		Bucket:                  bucket,
+		Key:                     key,
+		Hash:                    status.Hash,
+		MD5:                     status.MD5,
+		FSize:                   status.FSize,
+		PutTime:                 status.PutTime,
+		MimeType:                status.MimeType,
+		EndUser:                 status.EndUser,
+		Status:                  status.Status,
+		RestoreStatus:           status.RestoreStatus,
+		Expiration:              status.Expiration,
+		TransitionToIA:          status.TransitionToIA,
+		TransitionToArchiveIR:   status.TransitionToArchiveIR,
+		TransitionToArchive:     status.TransitionToARCHIVE,
+		TransitionToDeepArchive: status.TransitionToDeepArchive,
+		FileType:                status.Type,
+		StorageType:             getFileTypeName(status.Type),
+	}
+	if len(status.MetaData) > 0 {
+		ret.MetaData = make(map[string]string, len(status.MetaData))
+		for name, value := range status.MetaData {
+			ret.MetaData[metaDataPrefix+name] = value
+		}
+	}
+	return ret
+}
+
+func outputStatusJson(statusJson *StatusJson) string {
+	bytes, err := json.Marshal(statusJson)
+	if err != nil {
+		log.ErrorF("marshal stat of %s:%s error:%v", statusJson.Bucket, statusJson.Key, err)
+		return ""
+	}
+	log.Alert(string(bytes))
+	return string(bytes)
+}
+
 func getFileTypeDescription(fileTypes int) string {
 	typeString := "未知类型"
 	if fileTypes >= 0 && fileTypes < len(objectTypes) {
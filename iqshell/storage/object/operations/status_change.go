@@ -143,14 +143,12 @@ func BatchChangeStatus(cfg *iqshell.Config, info BatchChangeStatusInfo) {
 		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
 			in, ok := (operation).(*object.ChangeStatusApiInfo)
 			if !ok {
-				data.SetCmdStatusError()
 				log.ErrorF("Change status Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
 				return
 			}
 			if result.IsSuccess() {
 				log.InfoF("Change status Success, [%s:%s] => '%d'", in.Bucket, in.Key, in.Status)
 			} else {
-				data.SetCmdStatusError()
 				log.ErrorF("Change status Failed, [%s:%s] => %d, Code: %d, Error: %s",
 					in.Bucket, in.Key, in.Status, result.Code, result.Error)
 			}
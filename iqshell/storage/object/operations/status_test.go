@@ -0,0 +1,40 @@
+package operations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+func TestStatusOutput(t *testing.T) {
+	status := object.StatusResult{
+		OperationResult: batch.OperationResult{
+			Hash:     "FmDZwqadA4-ib_15hYfQpb7UXUYR",
+			FSize:    1024,
+			PutTime:  17171717171717171,
+			MimeType: "image/jpeg",
+			Type:     1,
+		},
+		MetaData: map[string]string{"b": "2", "a": "1"},
+	}
+
+	info := getResultInfo("bucket", "a.jpg", status)
+	for _, field := range []string{"MD5:", "EndUser:", "RestoreStatus:", "Expiration:"} {
+		if strings.Contains(info, field) {
+			t.Fatalf("field %s not returned should be omitted, info:\n%s", field, info)
+		}
+	}
+	if !strings.Contains(info, "IA 低频存储") {
+		t.Fatalf("storage type name missing, info:\n%s", info)
+	}
+	if a, b := strings.Index(info, "x-qn-meta-a:"), strings.Index(info, "x-qn-meta-b:"); a < 0 || b < a {
+		t.Fatalf("metadata should be sorted, info:\n%s", info)
+	}
+
+	statusJson := newStatusJson("bucket", "a.jpg", &status)
+	if statusJson.StorageType != "IA" || statusJson.MetaData["x-qn-meta-a"] != "1" || len(statusJson.MetaData) != 2 {
+		t.Fatalf("status json error, json:%+v", statusJson)
+	}
+}
@@ -0,0 +1,107 @@
+package operations
+
+import (
+	"encoding/json"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/download"
+)
+
+type ThumbnailInfo struct {
+	object.ImageViewInfo
+
+	Source   string // <Bucket>:<Key> 【必选】
+	Domain   string // 拼接链接使用的下载域名，默认为空间的第一个域名 【可选】
+	SaveAs   string // 缩略图保存的位置，<Bucket>:<Key> 或者 <Key>，只有 <Key> 时保存到原图所在的空间 【可选】
+	Pipeline string // 持久化处理使用的队列 【可选】
+
+	bucket     string
+	key        string
+	saveBucket string
+	saveKey    string
+}
+
+func (info *ThumbnailInfo) Check() *data.CodeError {
+	if len(info.Source) == 0 {
+		return alert.CannotEmptyError("Bucket:Key", "")
+	}
+	var ok bool
+	if info.bucket, info.key, ok = splitBucketKey(info.Source); !ok {
+		return alert.Error("invalid source: "+info.Source, "source should be <Bucket>:<Key>")
+	}
+	if len(info.SaveAs) > 0 {
+		if info.saveBucket, info.saveKey, ok = splitBucketKey(info.SaveAs); !ok {
+			info.saveBucket, info.saveKey = info.bucket, info.SaveAs
+		}
+	}
+	return info.ImageViewInfo.Check()
+}
+
+type thumbnailResult struct {
+	Url          string `json:"url"`
+	PersistentId string `json:"persistent_id,omitempty"`
+}
+
+// Thumbnail 生成 imageView2 缩略图的链接，指定 SaveAs 时提交持久化处理将缩略图保存到空间
+func Thumbnail(cfg *iqshell.Config, info ThumbnailInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	domain := info.Domain
+	var err *data.CodeError
+	if len(domain) == 0 {
+		if domain, err = bucket.DomainOfBucket(info.bucket); err != nil {
+			data.SetCmdStatusError()
+			log.Error(err)
+			return
+		}
+	}
+
+	fop := info.ImageViewInfo.Fop()
+	result := &thumbnailResult{}
+	if result.Url, err = fopRequestUrl(download.PublicUrl(download.UrlApiInfo{
+		BucketDomain: domain,
+		Key:          info.key,
+		UseHttps:     workspace.GetConfig().IsUseHttps(),
+	}), fop, true); err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+
+	if len(info.SaveAs) > 0 {
+		if result.PersistentId, err = object.Pfop(object.PfopApiInfo{
+			Bucket:   info.bucket,
+			Key:      info.key,
+			Fops:     object.SaveAsFop(fop, info.saveBucket, info.saveKey),
+			Pipeline: info.Pipeline,
+		}); err != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("pfop error:%v", err)
+			log.Alert("Url: " + result.Url)
+			return
+		}
+	}
+
+	if data.IsOutputFormatJson() {
+		if bytes, mErr := json.Marshal(result); mErr != nil {
+			log.ErrorF("marshal thumbnail result error:%v", mErr)
+		} else {
+			log.Alert(string(bytes))
+		}
+		return
+	}
+	log.Alert("Url: " + result.Url)
+	if len(result.PersistentId) > 0 {
+		log.Alert("PersistentId: " + result.PersistentId)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/qiniu/qshell/v2/iqshell"
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
@@ -36,15 +37,34 @@ func (info *ChangeTypeInfo) Check() *data.CodeError {
 }
 
 func (info *ChangeTypeInfo) getTypeOfInt() (int, *data.CodeError) {
-	if len(info.Type) == 0 {
+	return parseFileType(info.Type)
+}
+
+// fileTypeNames 存储类型的名称，不区分大小写
+var fileTypeNames = map[string]int{
+	"standard":     0,
+	"line":         1,
+	"ia":           1,
+	"archive":      2,
+	"deep_archive": 3,
+	"archive_ir":   4,
+}
+
+// parseFileType 解析存储类型，支持数字（0 ~ 4）及名称（standard、line、archive、deep_archive、archive_ir）
+func parseFileType(t string) (int, *data.CodeError) {
+	t = strings.TrimSpace(t)
+	if len(t) == 0 {
 		return -1, data.NewEmptyError().AppendDesc(alert.CannotEmpty("type", ""))
 	}
 
-	ret, err := strconv.Atoi(info.Type)
-	if err != nil {
-		return -1, data.NewEmptyError().AppendDesc("Parse type error:" + err.Error())
+	if ret, ok := fileTypeNames[strings.ToLower(t)]; ok {
+		return ret, nil
 	}
 
+	ret, err := strconv.Atoi(t)
+	if err != nil || ret < 0 || ret >= len(objectTypes) {
+		return -1, data.NewEmptyError().AppendDescF("invalid type `%s`, should be one of 0 ~ %d or standard, line, archive, deep_archive, archive_ir", t, len(objectTypes)-1)
+	}
 	return ret, nil
 }
 
@@ -97,6 +117,37 @@ func (info *BatchChangeTypeInfo) Check() *data.CodeError {
 	if len(info.Bucket) == 0 {
 		return alert.CannotEmptyError("Bucket", "")
 	}
+
+	return info.checkInputFileTypes()
+}
+
+// checkInputFileTypes 执行前检查输入文件每行的存储类型，避免执行到中途才发现错误；输入来自标准输入时仅在执行时检查
+func (info *BatchChangeTypeInfo) checkInputFileTypes() *data.CodeError {
+	const maxErrorLineCount = 10
+	errorLines := make([]string, 0, maxErrorLineCount)
+	errorCount := 0
+	_, err := batch.ScanInputFile(&info.BatchInfo, func(lineNumber int, items []string) {
+		var e *data.CodeError
+		if len(items) < 2 {
+			e = alert.Error("need more than one param", "")
+		} else {
+			_, e = parseFileType(items[1])
+		}
+		if e == nil {
+			return
+		}
+
+		errorCount++
+		if len(errorLines) < maxErrorLineCount {
+			errorLines = append(errorLines, fmt.Sprintf("line %d: %v", lineNumber, e))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if errorCount > 0 {
+		return data.NewEmptyError().AppendDescF("input file has %d invalid line(s):\n%s", errorCount, strings.Join(errorLines, "\n"))
+	}
 	return nil
 }
 
@@ -122,12 +173,13 @@ func BatchChangeType(cfg *iqshell.Config, info BatchChangeTypeInfo) {
 			return &object.ChangeTypeApiInfo{}
 		}).
 		SetFileExport(exporter).
+		ItemFields(2, "key", "type").
 		ItemsToOperation(func(items []string) (operation batch.Operation, err *data.CodeError) {
 			if len(items) > 1 {
 				key, t := items[0], items[1]
-				if tInt, e := strconv.Atoi(t); e != nil {
-					return nil, data.NewEmptyError().AppendDescF("parse type error:%v", e)
-				} else if len(key) > 0 && len(t) > 0 {
+				if tInt, e := parseFileType(t); e != nil {
+					return nil, e
+				} else if len(key) > 0 {
 					return &object.ChangeTypeApiInfo{
 						Bucket: info.Bucket,
 						Key:    key,
@@ -140,7 +192,6 @@ func BatchChangeType(cfg *iqshell.Config, info BatchChangeTypeInfo) {
 		OnResult(func(operationInfo string, operation batch.Operation, result *batch.OperationResult) {
 			in, ok := (operation).(*object.ChangeTypeApiInfo)
 			if !ok {
-				data.SetCmdStatusError()
 				log.ErrorF("Change status Failed, %s, Code: %d, Error: %s", operationInfo, result.Code, result.Error)
 				return
 			}
@@ -148,7 +199,6 @@ func BatchChangeType(cfg *iqshell.Config, info BatchChangeTypeInfo) {
 				log.InfoF("Change Type Success, [%s:%s] => '%d'(%s) ",
 					info.Bucket, in.Key, in.Type, getFileTypeDescription(in.Type))
 			} else {
-				data.SetCmdStatusError()
 				log.ErrorF("Change Type Failed, [%s:%s] => '%d'(%s), Code: %d, Error: %s",
 					info.Bucket, in.Key, in.Type, getFileTypeDescription(in.Type), result.Code, result.Error)
 			}
@@ -0,0 +1,53 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+func TestParseFileType(t *testing.T) {
+	cases := map[string]int{
+		"0":            0,
+		"standard":     0,
+		"LINE":         1,
+		"2":            2,
+		"archive":      2,
+		"deep_archive": 3,
+		"4":            4,
+	}
+	for value, expected := range cases {
+		if ret, err := parseFileType(value); err != nil || ret != expected {
+			t.Fatalf("type of %s should be %d, but:%d err:%v", value, expected, ret, err)
+		}
+	}
+
+	for _, value := range []string{"", "5", "-1", "archve"} {
+		if _, err := parseFileType(value); err == nil {
+			t.Fatalf("type %s should be invalid", value)
+		}
+	}
+}
+
+func TestBatchChangeTypeCheckInputFile(t *testing.T) {
+	inputFile := filepath.Join(t.TempDir(), "input.txt")
+	content := "a.jpg\t1\nb.jpg\tarchive\n\nc.jpg\tarchve\nd.jpg\n"
+	if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info := &BatchChangeTypeInfo{
+		BatchInfo: batch.Info{InputFile: inputFile, ItemSeparate: "\t"},
+		Bucket:    "bucket",
+	}
+	err := info.checkInputFileTypes()
+	if err == nil {
+		t.Fatal("input file should be invalid")
+	}
+	if !strings.Contains(err.Desc, "line 4:") || !strings.Contains(err.Desc, "line 5:") {
+		t.Fatalf("error should contain line 4 and line 5, but:%s", err.Desc)
+	}
+}
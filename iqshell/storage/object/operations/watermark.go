@@ -0,0 +1,248 @@
+package operations
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+type BatchWatermarkInfo struct {
+	BatchInfo batch.Info
+	object.WatermarkInfo
+
+	Bucket     string // 源文件所在的空间 【必选】
+	DestBucket string // 结果保存的空间，默认和源文件相同 【可选】
+	DestPrefix string // 结果文件名的前缀 【可选】
+	DestSuffix string // 结果文件名的后缀，添加在扩展名之前 【可选】
+	Pipeline   string // 处理队列 【可选】
+	NotifyURL  string // 处理结果的通知地址 【可选】
+
+	Wait                  bool   // 提交后等待处理结束，处理失败的文件导出到 JobFailExportFilePath
+	WaitInterval          int    // 查询处理状态的间隔，单位：秒
+	WaitTimeout           int    // 等待单个处理结束的最长时间，超时后按处理失败导出，单位：秒
+	JobFailExportFilePath string // 处理失败的输入行的导出文件
+}
+
+func (info *BatchWatermarkInfo) Check() *data.CodeError {
+	if err := info.BatchInfo.Check(); err != nil {
+		return err
+	}
+	if len(info.Bucket) == 0 {
+		return alert.CannotEmptyError("Bucket", "")
+	}
+	if len(info.DestBucket) == 0 {
+		info.DestBucket = info.Bucket
+	}
+	if info.DestBucket == info.Bucket && len(info.DestPrefix) == 0 && len(info.DestSuffix) == 0 {
+		return alert.Error("the watermarked file will overwrite the source file", "set --dest-prefix, --dest-suffix or --dest-bucket")
+	}
+	if info.WaitInterval <= 0 {
+		info.WaitInterval = 5
+	}
+	if info.WaitTimeout <= 0 {
+		info.WaitTimeout = 600
+	}
+	return info.WatermarkInfo.Check()
+}
+
+// watermarkDestKey 结果文件名：前缀 + 文件名 + 后缀 + 扩展名，如：wm/a/b_wm.jpg
+func watermarkDestKey(key string, prefix string, suffix string) string {
+	ext := path.Ext(key)
+	if strings.Contains(ext, "/") {
+		ext = ""
+	}
+	return prefix + strings.TrimSuffix(key, ext) + suffix + ext
+}
+
+type watermarkWork struct {
+	Key     string
+	DestKey string
+}
+
+func (w *watermarkWork) WorkId() string {
+	return w.Key
+}
+
+type watermarkResult struct {
+	PersistentId string
+	JobError     string // 等待处理结束时，处理失败的原因
+}
+
+func (r *watermarkResult) IsValid() bool {
+	return len(r.PersistentId) > 0
+}
+
+// BatchWatermark 为 key 列表中的每个文件提交水印持久化处理，结果保存到派生的文件名；
+// 结果输出格式为：<Key>\t<PersistentId>\t<DestBucket>:<DestKey>
+func BatchWatermark(cfg *iqshell.Config, info BatchWatermarkInfo) {
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	if len(info.Pipeline) > 0 {
+		if exists, eErr := object.PipelineExists(info.Bucket, info.Pipeline); eErr != nil {
+			log.WarningF("check pipeline:%s error:%v", info.Pipeline, eErr)
+		} else if !exists {
+			data.SetCmdStatusError()
+			log.ErrorF("pipeline:%s doesn't exist", info.Pipeline)
+			return
+		}
+	}
+
+	exporter, err := export.NewFileExport(info.BatchInfo.FileExporterConfig)
+	if err != nil {
+		log.Error(err)
+		data.SetCmdStatusError()
+		return
+	}
+	jobFailExporter, err := export.New(info.JobFailExportFilePath)
+	if err != nil {
+		log.Error(err)
+		data.SetCmdStatusError()
+		return
+	}
+	defer func() {
+		_ = exporter.Close()
+		_ = jobFailExporter.Close()
+	}()
+
+	watermarkFop := info.WatermarkInfo.Fop()
+	var jobFailureCount int64
+	metric := &batch.Metric{}
+	metric.Start()
+	info.BatchInfo.NewFlow().
+		WorkProviderWithFile(info.BatchInfo.InputFile,
+			info.BatchInfo.EnableStdin,
+			flow.NewItemsWorkCreator(info.BatchInfo.ItemSeparate, 1, func(items []string) (work flow.Work, err *data.CodeError) {
+				key := items[0]
+				if len(key) == 0 {
+					return nil, alert.Error("key invalid", "")
+				}
+				return &watermarkWork{
+					Key:     key,
+					DestKey: watermarkDestKey(key, info.DestPrefix, info.DestSuffix),
+				}, nil
+			})).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
+				work := workInfo.Work.(*watermarkWork)
+				persistentId, pErr := object.Pfop(object.PfopApiInfo{
+					Bucket:    info.Bucket,
+					Key:       work.Key,
+					Fops:      object.SaveAsFop(watermarkFop, info.DestBucket, work.DestKey),
+					Pipeline:  info.Pipeline,
+					NotifyURL: info.NotifyURL,
+				})
+				if pErr != nil {
+					return nil, pErr
+				}
+
+				result := &watermarkResult{PersistentId: persistentId}
+				if info.Wait {
+					result.JobError = waitFopDone(info.Bucket, persistentId,
+						time.Duration(info.WaitInterval)*time.Second, time.Duration(info.WaitTimeout)*time.Second)
+				}
+				return result, nil
+			}), nil
+		})).
+		FlowWillStartFunc(func(flow *flow.Flow) (err *data.CodeError) {
+			metric.AddTotalCount(flow.WorkProvider.WorkTotalCount())
+			return nil
+		}).
+		Deadletter(exporter.Deadletter()).
+		OnWorkSkip(func(work *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			metric.AddCurrentCount(1)
+			metric.AddSkippedCount(1)
+			metric.PrintProgress("Batching:" + work.Data)
+			exporter.Fail().ExportF("%s%s%v", work.Data, flow.ErrorSeparate, err)
+			log.DebugF("Skip line:%s because:%v", work.Data, err)
+		}).
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
+			metric.AddCurrentCount(1)
+			metric.AddSuccessCount(1)
+			metric.PrintProgress("Batching:" + workInfo.Data)
+
+			work, _ := workInfo.Work.(*watermarkWork)
+			r, _ := result.(*watermarkResult)
+			line := fmt.Sprintf("%s\t%s\t%s:%s", work.Key, r.PersistentId, info.DestBucket, work.DestKey)
+			exporter.Success().Export(workInfo.Data)
+			exporter.Result().Export(line)
+			log.Alert(line)
+
+			if len(r.JobError) > 0 {
+				atomic.AddInt64(&jobFailureCount, 1)
+				jobFailExporter.ExportF("%s%s%s", workInfo.Data, flow.ErrorSeparate, r.JobError)
+				log.ErrorF("Watermark job Failed, %s, PersistentId: %s, Error: %s", work.Key, r.PersistentId, r.JobError)
+			}
+		}).
+		OnWorkFail(func(work *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
+			metric.AddCurrentCount(1)
+			metric.AddFailureCount(1)
+			metric.PrintProgress("Batching:" + work.Data)
+
+			exporter.Fail().ExportF("%s%s%v", work.Data, flow.ErrorSeparate, err)
+			log.ErrorF("Watermark submit Failed, %s, Error: %v", work.Data, err)
+		}).Build().Start()
+
+	metric.End()
+	if jobFailureCount > 0 {
+		// 提交成功的 work 在 flow 中按成功统计，处理失败的需单独设置命令状态
+		data.SetCmdStatusByWorkCount(metric.SuccessCount, jobFailureCount)
+	}
+
+	if info.Wait {
+		log.InfoF("Job Failure:%d", jobFailureCount)
+	}
+}
+
+// waitFopDone 轮询持久化处理的状态直到处理结束，处理失败时返回失败原因；超过 timeout 仍未结束时按失败处理
+func waitFopDone(bucket string, persistentId string, interval time.Duration, timeout time.Duration) string {
+	deadline := time.Now().Add(timeout)
+	var lastErr *data.CodeError
+	for {
+		if time.Now().Add(interval).After(deadline) {
+			if lastErr != nil {
+				return fmt.Sprintf("wait timeout(%s), last error:%v", timeout, lastErr)
+			}
+			return fmt.Sprintf("wait timeout(%s), the job is still processing", timeout)
+		}
+		time.Sleep(interval)
+
+		ret, err := object.PreFopStatus(object.PreFopStatusApiInfo{
+			Id:     persistentId,
+			Bucket: bucket,
+		})
+		if err != nil {
+			lastErr = err
+			log.WarningF("prefop %s error:%v, retry later", persistentId, err)
+			continue
+		}
+		lastErr = nil
+
+		switch ret.Code {
+		case 0:
+			return ""
+		case 1, 2:
+			continue
+		}
+		for _, item := range ret.Items {
+			if len(item.Error) > 0 {
+				return item.Error
+			}
+		}
+		return fmt.Sprintf("%s(%d), %s", fopStatus(ret.Code), ret.Code, ret.Desc)
+	}
+}
@@ -0,0 +1,21 @@
+package operations
+
+import "testing"
+
+func TestWatermarkDestKey(t *testing.T) {
+	for _, c := range []struct {
+		key    string
+		prefix string
+		suffix string
+		expect string
+	}{
+		{key: "a/b.jpg", prefix: "wm/", expect: "wm/a/b.jpg"},
+		{key: "a/b.jpg", suffix: "_wm", expect: "a/b_wm.jpg"},
+		{key: "a.b/c", prefix: "wm/", suffix: "_wm", expect: "wm/a.b/c_wm"},
+		{key: "noext", suffix: "_wm", expect: "noext_wm"},
+	} {
+		if destKey := watermarkDestKey(c.key, c.prefix, c.suffix); destKey != c.expect {
+			t.Fatalf("dest key of %s error, dest key:%s expected:%s", c.key, destKey, c.expect)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package operations
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/auth/qbox"
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/account"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+)
+
+// xcopyUrlExpire 源文件下载链接的有效期，需覆盖抓取排队及执行的时间
+const xcopyUrlExpire = 6 * time.Hour
+
+type XCopyInfo struct {
+	BatchInfo  batch.Info
+	SrcUser    string // 源空间所属账户的名称，为空时使用当前账户 【可选】
+	DestUser   string // 目标空间所属账户的名称，为空时使用当前账户 【可选】
+	SrcBucket  string // 源空间 【必选】
+	DestBucket string // 目标空间 【必选】
+	Prefix     string // 仅复制此前缀的文件 【可选】
+	SrcDomain  string // 源空间的下载域名，为空时使用源空间绑定的第一个域名 【可选】
+}
+
+func (info *XCopyInfo) Check() *data.CodeError {
+	if len(info.SrcBucket) == 0 {
+		return alert.CannotEmptyError("SrcBucket", "")
+	}
+	if len(info.DestBucket) == 0 {
+		return alert.CannotEmptyError("DestBucket", "")
+	}
+	if len(info.SrcUser) == 0 && len(info.DestUser) == 0 {
+		return alert.Error("source user and dest user can't both be empty", "please set --src-user or --dest-user, and use batchcopy to copy files in one account")
+	}
+	if info.BatchInfo.WorkerCount <= 0 {
+		info.BatchInfo.WorkerCount = 10
+	}
+	return info.BatchInfo.Info.Check()
+}
+
+type xcopyWork struct {
+	Key   string `json:"key"`
+	Hash  string `json:"hash"`
+	Fsize int64  `json:"fsize"`
+}
+
+func (w *xcopyWork) WorkId() string {
+	return fmt.Sprintf("%s:%s", w.Key, w.Hash)
+}
+
+type xcopyResult struct {
+	Key    string `json:"key"`
+	Hash   string `json:"hash"`
+	Exists bool   `json:"exists"` // 目标空间已存在 hash 相同的文件，未复制
+}
+
+var _ flow.Result = (*xcopyResult)(nil)
+
+func (r *xcopyResult) IsValid() bool {
+	return len(r.Key) > 0 && len(r.Hash) > 0
+}
+
+// xcopyAccount 获取名称为 userName 的账户，为空时使用当前账户
+func xcopyAccount(userName string) (account.Account, *data.CodeError) {
+	if len(userName) == 0 {
+		return workspace.GetAccount()
+	}
+	return account.GetUser(userName)
+}
+
+// XCopy 在两个账户的空间之间复制文件：使用源账户列举源空间并签发下载链接，由目标账户抓取到目标空间；
+// 目标空间已存在 hash 相同的文件时跳过
+func XCopy(cfg *iqshell.Config, info XCopyInfo) {
+	cfg.JobPathBuilder = func(cmdPath string) string {
+		jobId := utils.Md5Hex(fmt.Sprintf("%s:%s:%s:%s:%s:%s", cfg.CmdCfg.CmdId, info.SrcUser, info.SrcBucket, info.DestUser, info.DestBucket, info.Prefix))
+		return filepath.Join(cmdPath, jobId)
+	}
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	srcAccount, err := xcopyAccount(info.SrcUser)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("get source user error:%v", err)
+		return
+	}
+	destAccount, err := xcopyAccount(info.DestUser)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("get dest user error:%v", err)
+		return
+	}
+	srcManager := bucket.GetBucketManagerWithAccount(srcAccount)
+	destManager := bucket.GetBucketManagerWithAccount(destAccount)
+	srcMac := qbox.NewMac(srcAccount.AccessKey, srcAccount.SecretKey)
+
+	if len(info.SrcDomain) == 0 {
+		domains, dErr := srcManager.ListBucketDomains(info.SrcBucket)
+		if dErr != nil || len(domains) == 0 {
+			data.SetCmdStatusError()
+			log.ErrorF("get domain of source bucket:%s error:%v, please set it by --src-domain", info.SrcBucket, dErr)
+			return
+		}
+		info.SrcDomain = domains[0].Domain
+		log.InfoF("use domain:%s of source bucket:%s", info.SrcDomain, info.SrcBucket)
+	}
+	srcDomain := utils.Endpoint(workspace.GetConfig().IsUseHttps(), info.SrcDomain)
+
+	exporter, err := export.NewFileExport(info.BatchInfo.FileExporterConfig)
+	if err != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("get export error:%v", err)
+		return
+	}
+
+	lister, err := newPrefixLister(srcManager, info.SrcBucket, info.Prefix, 0, &info.BatchInfo.Info, func(entry storage.ListItem) flow.Work {
+		return &xcopyWork{Key: entry.Key, Hash: entry.Hash, Fsize: entry.Fsize}
+	})
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error(err)
+		return
+	}
+	defer lister.stop()
+
+	dbPath := filepath.Join(workspace.GetJobDir(), ".recorder")
+	if info.BatchInfo.EnableRecord {
+		log.InfoF("xcopy recorder:%s", dbPath)
+	}
+
+	var existCount int64
+	metric := &batch.Metric{}
+	metric.Start()
+	flow.New(info.BatchInfo.Info).
+		WorkProvider(lister).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
+				work := workInfo.Work.(*xcopyWork)
+				result := &xcopyResult{Key: work.Key, Hash: work.Hash}
+				if destFile, sErr := destManager.Stat(info.DestBucket, work.Key); sErr == nil {
+					if destFile.Hash == work.Hash {
+						result.Exists = true
+						return result, nil
+					}
+				} else if e := data.ConvertError(sErr); e.Code != 612 {
+					return nil, e.HeaderInsertDesc("stat dest file")
+				}
+
+				fromUrl := storage.MakePrivateURL(srcMac, srcDomain, work.Key, time.Now().Add(xcopyUrlExpire).Unix())
+				fetchResult, fErr := destManager.Fetch(fromUrl, info.DestBucket, work.Key)
+				if fErr != nil {
+					return nil, data.ConvertError(fErr).HeaderInsertDesc("fetch")
+				}
+				if fetchResult.Hash != work.Hash {
+					return nil, data.NewEmptyError().AppendDescF("hash of dest file:%s is different from source:%s", fetchResult.Hash, work.Hash)
+				}
+				return result, nil
+			}), nil
+		})).
+		FlowWillStartFunc(func(flow *flow.Flow) (err *data.CodeError) {
+			metric.AddTotalCount(flow.WorkProvider.WorkTotalCount())
+			return nil
+		}).
+		SetOverseerEnable(info.BatchInfo.EnableRecord).
+		SetDBOverseer(dbPath, func() *flow.WorkRecord {
+			return &flow.WorkRecord{
+				WorkInfo: &flow.WorkInfo{
+					Data: "",
+					Work: &xcopyWork{},
+				},
+				Result: &xcopyResult{},
+				Err:    nil,
+			}
+		}).
+		ShouldRedo(func(workInfo *flow.WorkInfo, workRecord *flow.WorkRecord) (shouldRedo bool, cause *data.CodeError) {
+			if workRecord.Err == nil {
+				return false, nil
+			}
+
+			if !info.BatchInfo.RecordRedoWhileError {
+				return false, workRecord.Err
+			}
+			return true, workRecord.Err
+		}).
+		OnWorkSkip(func(workInfo *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			metric.AddCurrentCount(1)
+			metric.PrintProgress("XCopying:" + workInfo.Data)
+
+			if err != nil && err.Code == data.ErrorCodeAlreadyDone {
+				if r, _ := result.(*xcopyResult); r != nil && r.IsValid() {
+					metric.AddSuccessCount(1)
+					log.DebugF("Skip line:%s because have done and success", workInfo.Data)
+				} else {
+					metric.AddFailureCount(1)
+					log.DebugF("Skip line:%s because have done and failure, %v", workInfo.Data, err)
+				}
+			} else {
+				metric.AddSkippedCount(1)
+				log.DebugF("Skip line:%s because:%v", workInfo.Data, err)
+			}
+		}).
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
+			metric.AddCurrentCount(1)
+			metric.AddSuccessCount(1)
+			metric.PrintProgress("XCopying:" + workInfo.Data)
+
+			r, _ := result.(*xcopyResult)
+			if r == nil {
+				return
+			}
+			exporter.Success().Export(r.Key)
+			if r.Exists {
+				atomic.AddInt64(&existCount, 1)
+				log.InfoF("XCopy Skip, [%s:%s] exists with the same hash", info.DestBucket, r.Key)
+			} else {
+				log.InfoF("XCopy Success, [%s:%s] => [%s:%s]", info.SrcBucket, r.Key, info.DestBucket, r.Key)
+			}
+		}).
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
+			metric.AddCurrentCount(1)
+			metric.AddFailureCount(1)
+			metric.PrintProgress("XCopying:" + workInfo.Data)
+
+			if work, ok := workInfo.Work.(*xcopyWork); ok {
+				exporter.Fail().ExportF("%s%s%v", work.Key, flow.ErrorSeparate, err)
+				log.ErrorF("XCopy Failed, [%s:%s] => [%s:%s], Error: %v", info.SrcBucket, work.Key, info.DestBucket, work.Key, err)
+			} else {
+				exporter.Fail().ExportF("%s%s%v", workInfo.Data, flow.ErrorSeparate, err)
+				log.ErrorF("XCopy Failed, %s, Error: %v", workInfo.Data, err)
+			}
+		}).Build().Start()
+
+	metric.End()
+
+	log.InfoF("Exists:%d", existCount)
+}
@@ -12,12 +12,17 @@ type RestoreArchiveApiInfo struct {
 	Bucket          string `json:"bucket"`
 	Key             string `json:"key"`
 	FreezeAfterDays int    `json:"freeze_after_days"`
+	SkipRestored    bool   `json:"-"` // 已解冻的文件跳过，不再解冻
 }
 
 func (r *RestoreArchiveApiInfo) GetBucket() string {
 	return r.Bucket
 }
 
+func (r *RestoreArchiveApiInfo) GetKey() string {
+	return r.Key
+}
+
 func (r *RestoreArchiveApiInfo) ToOperation() (string, *data.CodeError) {
 	if len(r.Bucket) == 0 || len(r.Key) == 0 {
 		return "", alert.CannotEmptyError("Restore archive operation bucket or key", "")
@@ -26,6 +31,18 @@ func (r *RestoreArchiveApiInfo) ToOperation() (string, *data.CodeError) {
 	return storage.URIRestoreAr(r.Bucket, r.Key, r.FreezeAfterDays), nil
 }
 
+func (r *RestoreArchiveApiInfo) IsStatSkipEnable() bool {
+	return r.SkipRestored
+}
+
+// ShouldSkipByStat 已解冻的文件跳过
+func (r *RestoreArchiveApiInfo) ShouldSkipByStat(stat *batch.OperationResult) (skip bool, cause string) {
+	if stat != nil && stat.IsSuccess() && stat.RestoreStatus == 2 {
+		return true, "already restored"
+	}
+	return false, ""
+}
+
 func (r *RestoreArchiveApiInfo) WorkId() string {
 	return fmt.Sprintf("RestoreArchive|%s|%s|%d", r.Bucket, r.Key, r.FreezeAfterDays)
 }
@@ -6,9 +6,11 @@ import (
 
 	"github.com/qiniu/go-sdk/v7/auth"
 	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/qiniu/go-sdk/v7/storagev2/apis"
 
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 	"github.com/qiniu/qshell/v2/iqshell/storage/bucket"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
 )
@@ -27,6 +29,10 @@ func (s StatusApiInfo) GetBucket() string {
 	return s.Bucket
 }
 
+func (s StatusApiInfo) GetKey() string {
+	return s.Key
+}
+
 func (s StatusApiInfo) ToOperation() (string, *data.CodeError) {
 	if len(s.Bucket) == 0 || len(s.Key) == 0 {
 		return "", alert.CannotEmptyError("status operation bucket or key", "")
@@ -53,6 +59,8 @@ type StatusResult struct {
 	TransitionToArchiveIR int64 `json:"transitionToArchiveIR"`
 	// 文件生命周期中转为深度归档存储的日期，int64 类型，Unix 时间戳格式
 	TransitionToDeepArchive int64 `json:"transitionToDeepArchive"`
+	// 自定义元数据，key 不包含 x-qn-meta- 前缀；未设置时不返回该字段
+	MetaData map[string]string `json:"x-qn-meta"`
 }
 
 func Status(info StatusApiInfo) (res StatusResult, err *data.CodeError) {
@@ -81,6 +89,73 @@ func Status(info StatusApiInfo) (res StatusResult, err *data.CodeError) {
 	return res, nil
 }
 
+// batchStatusMaxCount 批量查询时单个请求包含的最大文件数
+const batchStatusMaxCount = 1000
+
+// BatchStatus 批量查询文件信息，返回结果与 infos 一一对应；
+// 与 batch 操作不同，返回结果包含文件的自定义元数据，infos 中的文件需在同一区域
+func BatchStatus(infos []StatusApiInfo) ([]*StatusResult, *data.CodeError) {
+	if len(infos) == 0 {
+		return nil, nil
+	}
+
+	storageClient, err := bucket.GetStorageV2()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*StatusResult, 0, len(infos))
+	for start := 0; start < len(infos); start += batchStatusMaxCount {
+		end := start + batchStatusMaxCount
+		if end > len(infos) {
+			end = len(infos)
+		}
+
+		operations := make([]string, 0, end-start)
+		for _, info := range infos[start:end] {
+			operation, oErr := info.ToOperation()
+			if oErr != nil {
+				return results, oErr
+			}
+			operations = append(operations, operation)
+		}
+
+		response, bErr := storageClient.BatchOps(workspace.GetContext(), &apis.BatchOpsRequest{
+			Operations: operations,
+		}, &apis.Options{
+			OverwrittenBucketName: infos[start].Bucket,
+		})
+		if bErr != nil {
+			return results, data.NewEmptyError().AppendDescF("batch status error:%v", bErr)
+		}
+		if len(response.OperationResponses) != len(operations) {
+			return results, data.NewEmptyError().AppendDescF("batch status error, expect %d results but got %d",
+				len(operations), len(response.OperationResponses))
+		}
+
+		for _, r := range response.OperationResponses {
+			results = append(results, &StatusResult{
+				OperationResult: batch.OperationResult{
+					Code:     int(r.Code),
+					Hash:     r.Data.Hash,
+					FSize:    r.Data.Size,
+					PutTime:  r.Data.PutTime,
+					MimeType: r.Data.MimeType,
+					Type:     int(r.Data.Type),
+					EndUser:  r.Data.EndUser,
+					Error:    r.Data.Error,
+				},
+				RestoreStatus: int(r.Data.RestoringStatus),
+				Status:        int(r.Data.Status),
+				MD5:           r.Data.Md5,
+				Expiration:    r.Data.ExpirationTime,
+				MetaData:      r.Data.Metadata,
+			})
+		}
+	}
+	return results, nil
+}
+
 // ChangeStatusApiInfo 修改 status
 type ChangeStatusApiInfo struct {
 	Bucket string
@@ -92,6 +167,10 @@ func (c *ChangeStatusApiInfo) GetBucket() string {
 	return c.Bucket
 }
 
+func (c *ChangeStatusApiInfo) GetKey() string {
+	return c.Key
+}
+
 func (c *ChangeStatusApiInfo) ToOperation() (string, *data.CodeError) {
 	if len(c.Bucket) == 0 || len(c.Key) == 0 {
 		return "", alert.CannotEmptyError("change status operation bucket or key", "")
@@ -18,6 +18,10 @@ func (c *ChangeTypeApiInfo) GetBucket() string {
 	return c.Bucket
 }
 
+func (c *ChangeTypeApiInfo) GetKey() string {
+	return c.Key
+}
+
 func (c *ChangeTypeApiInfo) ToOperation() (string, *data.CodeError) {
 	if len(c.Bucket) == 0 || len(c.Key) == 0 {
 		return "", alert.CannotEmptyError("change type operation bucket or key", "")
@@ -6,6 +6,7 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -17,7 +18,10 @@ type ProgressRecorder struct {
 	Offset       int64                    `json:"offset"`
 	TotalSize    int64                    `json:"total_size"`
 	LastModified int                      `json:"last_modified"` // 上传文件的modification time
+	ETag         string                   `json:"etag"`          // 上传文件的 ETag
 	FilePath     string                   `json:"-"`             // 断点续传记录保存文件
+
+	mu sync.Mutex
 }
 
 func NewProgressRecorder(filePath string) *ProgressRecorder {
@@ -47,95 +51,88 @@ func (p *ProgressRecorder) Recover() (err *data.CodeError) {
 }
 
 func (p *ProgressRecorder) Reset() {
-	p.Offset = 0
+	p.ResetParts()
 	p.TotalSize = 0
+}
+
+// ResetParts 仅清除已上传的块信息及 uploadId，保留源文件的大小、修改时间及 ETag，用于 uploadId 过期等源文件未变化的场景
+func (p *ProgressRecorder) ResetParts() {
+	p.Offset = 0
 	p.BlkCtxs = make([]storage.BlkputRet, 0)
 	p.Parts = make([]storage.UploadPartInfo, 0)
+	p.UploadId = ""
+	p.ExpireTime = 0
 }
 
-func (p *ProgressRecorder) CheckValid(fileSize int64, lastModified int, isResumableV2 bool) {
+// CheckValid 检查断点续传记录是否可用，不可用时重置记录；
+// blockSize 为每块的大小，lastModified 和 etag 为源文件的修改时间和 ETag，为空时不检查
+func (p *ProgressRecorder) CheckValid(fileSize, blockSize int64, lastModified int, etag string, isResumableV2 bool) {
+	defer func() {
+		p.TotalSize = fileSize
+		p.LastModified = lastModified
+		p.ETag = etag
+	}()
 
 	//check offset valid or not
-	if p.Offset%data.BLOCK_SIZE != 0 {
+	if blockSize <= 0 || (p.Offset != fileSize && p.Offset%blockSize != 0) {
 		log.Info("Invalid offset from progress file,", p.Offset)
 		p.Reset()
 		return
 	}
 
-	// 分片 V1
-	if !isResumableV2 {
-		//check offset and blk ctxs
-		if p.Offset != 0 && p.BlkCtxs != nil && int(p.Offset/data.BLOCK_SIZE) != len(p.BlkCtxs) {
-
+	//check offset and blk ctxs, when no progress found
+	blockCount := len(p.BlkCtxs) // 分片 V1
+	if isResumableV2 {
+		blockCount = len(p.Parts) // 分片 V2
+	}
+	if p.Offset == 0 || int((p.Offset+blockSize-1)/blockSize) != blockCount {
+		if p.Offset != 0 {
 			log.Info("Invalid offset and block info")
-			p.Reset()
-			return
 		}
-
-		//check blk ctxs, when no progress found
-		if p.Offset == 0 || p.BlkCtxs == nil {
-			p.Reset()
-			return
-		}
-
-		if fileSize != p.TotalSize {
-			if p.TotalSize != 0 {
-				log.Warning("Remote file length changed, progress file out of date")
-			}
-			p.Offset = 0
-			p.TotalSize = fileSize
-			p.BlkCtxs = make([]storage.BlkputRet, 0)
-			return
-		}
-
-		if len(p.BlkCtxs) > 0 {
-			if lastModified != 0 && p.LastModified != lastModified {
-				p.Reset()
-			}
-		}
-
+		p.Reset()
 		return
 	}
 
-	// 分片 V2
-	//check offset and blk ctxs
-	if p.Offset != 0 && p.Parts != nil && int(p.Offset/data.BLOCK_SIZE) != len(p.Parts) {
-
-		log.Info("Invalid offset and block info")
+	if fileSize != p.TotalSize {
+		log.Warning("Remote file length changed, progress file out of date")
 		p.Reset()
 		return
 	}
 
-	//check blk ctxs, when no progress found
-	if p.Offset == 0 || p.Parts == nil {
+	if (lastModified != 0 && p.LastModified != lastModified) || (len(etag) > 0 && p.ETag != etag) {
+		log.Warning("Remote file modified, progress file out of date")
 		p.Reset()
 		return
 	}
+}
 
-	if fileSize != p.TotalSize {
-		if p.TotalSize != 0 {
-			log.Warning("Remote file length changed, progress file out of date")
-		}
-		p.Offset = 0
-		p.TotalSize = fileSize
-		p.Parts = make([]storage.UploadPartInfo, 0)
-		return
+// setBlkCtx 记录第 index 个块的上传结果，块可能并发上传，需保证顺序
+func (p *ProgressRecorder) setBlkCtx(index int, blkCtx storage.BlkputRet, size int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.BlkCtxs) <= index {
+		p.BlkCtxs = append(p.BlkCtxs, storage.BlkputRet{})
 	}
+	p.BlkCtxs[index] = blkCtx
+	p.Offset += size
+}
 
-	if len(p.Parts) > 0 {
-		if lastModified != 0 && p.LastModified != lastModified {
-			p.Reset()
-		}
+// setPart 记录第 index 个分片的上传结果，分片可能并发上传，需保证顺序
+func (p *ProgressRecorder) setPart(index int, part storage.UploadPartInfo, size int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.Parts) <= index {
+		p.Parts = append(p.Parts, storage.UploadPartInfo{})
 	}
+	p.Parts[index] = part
+	p.Offset += size
 }
 
 func (p *ProgressRecorder) RecordProgress() (err *data.CodeError) {
-	fh, openErr := os.Create(p.FilePath)
-	if openErr != nil {
-		err = data.NewEmptyError().AppendDescF("Open progress file %s error, %s", p.FilePath, openErr.Error())
-		return
-	}
-	defer fh.Close()
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
 	jsonBytes, mErr := json.Marshal(p)
 	if mErr != nil {
@@ -143,9 +140,15 @@ func (p *ProgressRecorder) RecordProgress() (err *data.CodeError) {
 		return
 	}
 
-	_, wErr := fh.Write(jsonBytes)
-	if wErr != nil {
+	// 先写入临时文件再重命名，避免中断时留下不完整的记录
+	tempFilePath := p.FilePath + ".tmp"
+	if wErr := os.WriteFile(tempFilePath, jsonBytes, 0644); wErr != nil {
 		err = data.NewEmptyError().AppendDescF("Write sync progress error, %s", wErr.Error())
+		return
+	}
+
+	if rErr := os.Rename(tempFilePath, p.FilePath); rErr != nil {
+		err = data.NewEmptyError().AppendDescF("Save progress file %s error, %s", p.FilePath, rErr.Error())
 	}
 
 	return
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+func TestProgressRecorderCheckValid(t *testing.T) {
+	progressFile := filepath.Join(t.TempDir(), "sync.progress")
+	p := NewProgressRecorder(progressFile)
+	p.CheckValid(10, 4, 100, "etag", true)
+	p.UploadId = "upload-id"
+	p.setPart(0, storage.UploadPartInfo{Etag: "p1", PartNumber: 1}, 4)
+	p.setPart(1, storage.UploadPartInfo{Etag: "p2", PartNumber: 2}, 4)
+	if err := p.RecordProgress(); err != nil {
+		t.Fatal("record progress error:", err)
+	}
+
+	load := func() *ProgressRecorder {
+		r := NewProgressRecorder(progressFile)
+		if err := r.Recover(); err != nil {
+			t.Fatal("recover progress error:", err)
+		}
+		return r
+	}
+
+	r := load()
+	r.CheckValid(10, 4, 100, "etag", true)
+	if r.Offset != 8 || len(r.Parts) != 2 || r.UploadId != "upload-id" {
+		t.Fatalf("progress should be valid, offset:%d parts:%d", r.Offset, len(r.Parts))
+	}
+
+	r = load()
+	r.CheckValid(10, 4, 100, "etag-changed", true)
+	if r.Offset != 0 || len(r.Parts) != 0 {
+		t.Fatal("progress should be reset when etag changed")
+	}
+
+	r = load()
+	r.CheckValid(10, 4, 101, "etag", true)
+	if r.Offset != 0 || len(r.Parts) != 0 {
+		t.Fatal("progress should be reset when last modified changed")
+	}
+
+	r = load()
+	r.CheckValid(10, 8, 100, "etag", true)
+	if r.Offset != 0 || len(r.Parts) != 0 {
+		t.Fatal("progress should be reset when block size changed")
+	}
+}
+
+func TestProgressRecorderResetParts(t *testing.T) {
+	progressFile := filepath.Join(t.TempDir(), "sync.progress")
+	p := NewProgressRecorder(progressFile)
+	p.CheckValid(10, 4, 100, "etag", true)
+	p.UploadId = "expired-upload-id"
+	p.setPart(0, storage.UploadPartInfo{Etag: "p1", PartNumber: 1}, 4)
+
+	// uploadId 过期后重新上传，源文件信息需保留
+	p.ResetParts()
+	if p.Offset != 0 || len(p.Parts) != 0 || len(p.UploadId) != 0 {
+		t.Fatalf("parts should be reset, offset:%d parts:%d", p.Offset, len(p.Parts))
+	}
+	p.UploadId = "upload-id"
+	p.setPart(0, storage.UploadPartInfo{Etag: "p1", PartNumber: 1}, 4)
+	if err := p.RecordProgress(); err != nil {
+		t.Fatal("record progress error:", err)
+	}
+
+	r := NewProgressRecorder(progressFile)
+	if err := r.Recover(); err != nil {
+		t.Fatal("recover progress error:", err)
+	}
+	r.CheckValid(10, 4, 100, "etag", true)
+	if r.Offset != 4 || len(r.Parts) != 1 || r.UploadId != "upload-id" {
+		t.Fatalf("progress should be valid after parts reset, offset:%d parts:%d", r.Offset, len(r.Parts))
+	}
+}
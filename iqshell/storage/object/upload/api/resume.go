@@ -10,6 +10,7 @@ import (
 
 type Resume interface {
 	InitServer(ctx context.Context) *data.CodeError
+	// UploadBlock 上传第 index 个块（从 0 开始），不同 index 的块可并发上传
 	UploadBlock(ctx context.Context, index int, data []byte) *data.CodeError
 	Complete(ctx context.Context, ret interface{}) (err *data.CodeError)
 }
@@ -17,7 +17,7 @@ func (r *resumeV1) InitServer(ctx context.Context) *data.CodeError {
 	return nil
 }
 
-// UploadBlock size 必须是 4M 整数倍
+// UploadBlock size 必须是 4M 整数倍，index 为块在文件中的序号（从 0 开始）
 func (r *resumeV1) UploadBlock(ctx context.Context, index int, d []byte) *data.CodeError {
 	size := len(d)
 	var blkCtx storage.BlkputRet
@@ -25,8 +25,7 @@ func (r *resumeV1) UploadBlock(ctx context.Context, index int, d []byte) *data.C
 	if err != nil {
 		return data.NewEmptyError().AppendDesc("resume v1 upload block error:" + err.Error())
 	} else {
-		r.Recorder.BlkCtxs = append(r.Recorder.BlkCtxs, blkCtx)
-		r.Recorder.Offset += int64(size)
+		r.Recorder.setBlkCtx(index, blkCtx, int64(size))
 		return nil
 	}
 }
@@ -28,6 +28,10 @@ func (r *resumeV2) InitServer(ctx context.Context) *data.CodeError {
 	err := r.uploader.InitParts(ctx, r.TokenProvider(), r.UpHost, r.Bucket,
 		r.Key, hasKey, ret)
 	if err == nil {
+		// 新的 uploadId 下之前上传的分片均不可用，源文件未变化，保留源文件信息以便之后接续
+		if len(r.Recorder.UploadId) > 0 {
+			r.Recorder.ResetParts()
+		}
 		r.Recorder.UploadId = ret.UploadID
 		r.Recorder.ExpireTime = time.Now().Unix() + 3600*24*5
 	} else {
@@ -38,7 +42,7 @@ func (r *resumeV2) InitServer(ctx context.Context) *data.CodeError {
 
 func (r *resumeV2) UploadBlock(ctx context.Context, index int, d []byte) *data.CodeError {
 	hasKey := len(r.Key) != 0
-	partNumber := int64(len(r.Recorder.Parts)) + 1
+	partNumber := int64(index) + 1
 	size := len(d)
 	partMd5 := md5.Sum(d)
 	partMd5String := hex.EncodeToString(partMd5[:])
@@ -46,11 +50,10 @@ func (r *resumeV2) UploadBlock(ctx context.Context, index int, d []byte) *data.C
 	err := r.uploader.UploadParts(ctx, r.TokenProvider(), r.UpHost, r.Bucket,
 		r.Key, hasKey, r.Recorder.UploadId, partNumber, partMd5String, ret, bytes.NewReader(d), size)
 	if err == nil {
-		r.Recorder.Parts = append(r.Recorder.Parts, storage.UploadPartInfo{
+		r.Recorder.setPart(index, storage.UploadPartInfo{
 			Etag:       ret.Etag,
 			PartNumber: partNumber,
-		})
-		r.Recorder.Offset += int64(size)
+		}, int64(size))
 	} else {
 		err = errors.New("resume v2 upload block error:" + err.Error())
 	}
@@ -2,6 +2,7 @@ package upload
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/qiniu/go-sdk/v7/storage"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
@@ -10,18 +11,18 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 	"github.com/qiniu/qshell/v2/iqshell/storage/object/upload/api"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
-	resumeV2MinChunkSize = 1024 * 1024
-	resumeV2MaxPart      = 10000
-	httpTimeout          = time.Second * 60
+	httpTimeout = time.Second * 60
 )
 
 type conveyor struct {
@@ -53,7 +54,29 @@ func (c *conveyor) upload(info *ApiInfo) (ret *ApiResult, err *data.CodeError) {
 		info.UpHost = utils.Endpoint(c.cfg.UseHTTPS, info.UpHost)
 	}
 
-	ctx := workspace.GetContext()
+	var blockSize = info.ChunkSize
+	if !info.UseResumeV2 || blockSize < ResumeV2MinPartSize {
+		// 分片 v1 块大小固定为 4M
+		blockSize = int64(data.BLOCK_SIZE)
+	}
+
+	if info.UseResumeV2 {
+		// 检查块大小是否满足实际需求
+		maxParts := int64(ResumeV2MaxPartCount)
+		if blockSize*maxParts < info.LocalFileSize {
+			blockSize = (info.LocalFileSize + maxParts - 1) / maxParts
+		}
+	}
+
+	// 服务端支持 Range 时并发拉取并上传分片，否则使用单个数据流顺序拉取；
+	// 资源的修改时间和 ETag 用于判断断点续传记录是否过期
+	file, gErr := utils.GetNetworkFileInfoWithClient(newSyncClient(info.MaxRedirects, httpTimeout), info.FilePath)
+	if gErr != nil {
+		log.WarningF("sync get network file info error:%v", gErr)
+		file = &utils.NetworkFileInfo{}
+	}
+
+	ctx := info.context()
 	progressFile, fErr := ProgressFileFromUrl(info.FilePath, info.ToBucket, info.SaveKey)
 	if fErr != nil {
 		err = fErr
@@ -63,8 +86,10 @@ func (c *conveyor) upload(info *ApiInfo) (ret *ApiResult, err *data.CodeError) {
 	if rErr := recorder.Recover(); rErr != nil {
 		log.WarningF("sync progress recover error:%v", rErr)
 	}
-	recorder.CheckValid(info.LocalFileSize, 0, info.UseResumeV2)
-	recorder.TotalSize = info.LocalFileSize
+	recorder.CheckValid(info.LocalFileSize, blockSize, int(file.LastModified), file.Hash, info.UseResumeV2)
+	if recorder.Offset > 0 {
+		log.InfoF("sync resume from offset:%d", recorder.Offset)
+	}
 
 	if info.Progress != nil {
 		info.Progress.SetFileSize(info.LocalFileSize)
@@ -88,83 +113,153 @@ func (c *conveyor) upload(info *ApiInfo) (ret *ApiResult, err *data.CodeError) {
 	}
 
 	// 2. 上传文件分片
-	var blockSize = info.ChunkSize
-	if blockSize < resumeV2MinChunkSize {
-		blockSize = int64(data.BLOCK_SIZE)
+	if info.Progress != nil {
+		info.Progress.SendSize(recorder.Offset)
 	}
 
-	if info.UseResumeV2 {
-		// 检查块大小是否满足实际需求
-		maxParts := int64(resumeV2MaxPart)
-		if blockSize*maxParts < info.LocalFileSize {
-			blockSize = (info.LocalFileSize + maxParts - 1) / maxParts
+	if file.SupportRange {
+		err = syncBlocksByRange(ctx, info, uploader, recorder, blockSize)
+	} else {
+		log.Warning("sync remote server doesn't support range, sync with single stream")
+		err = syncBlocksByStream(ctx, info, uploader, recorder, blockSize)
+	}
+	if err != nil {
+		return
+	}
+
+	// 3. 合并文件
+	err = uploader.Complete(ctx, &ret)
+	if err != nil {
+		err = data.NewEmptyError().AppendDescF("sync complete error:%v", err)
+		return
+	} else {
+		if info.Progress != nil {
+			info.Progress.End()
 		}
 	}
 
-	totalBlkCnt := storage.BlockCount(info.LocalFileSize) //range get and mkblk upload
-	rangeStartOffset := recorder.Offset                   //init the range offset
-	fromBlkIndex := int(rangeStartOffset / data.BLOCK_SIZE)
+	//delete progress file
+	if rErr := os.Remove(progressFile); rErr != nil {
+		log.WarningF("sync remove record progress error:%v", rErr)
+	}
 
-	if info.Progress != nil {
-		info.Progress.SendSize(rangeStartOffset)
+	return
+}
+
+// syncBlocksByRange 以 ResumeWorkerCount 个块为一组，组内的块并发拉取并上传，一组全部成功后再记录进度，
+// 保证进度记录中已完成的块总是连续的
+func syncBlocksByRange(ctx context.Context, info *ApiInfo, uploader api.Resume, recorder *api.ProgressRecorder, blockSize int64) *data.CodeError {
+	workerCount := info.ResumeWorkerCount
+	if workerCount < 1 {
+		workerCount = 1
 	}
 
-	var bf *bytes.Buffer
-	for blkIndex := fromBlkIndex; blkIndex < totalBlkCnt; blkIndex++ {
-		log.DebugF("") // 此处仅为日志换行
-		log.DebugF("Syncing block %d ...", blkIndex)
-
-		// 2.1 获取上传数据
-		var retryTimes int
-		for {
-			bf, err = getRange(info.FilePath, info.LocalFileSize, rangeStartOffset, blockSize)
-			if err != nil && retryTimes >= info.TryTimes {
-				err = data.NewEmptyError().AppendDesc(strings.Join([]string{"sync Get range block data failed: ", err.Error()}, ""))
-				return
-			}
-			if err == nil {
-				break
-			}
-			time.Sleep(info.TryInterval)
-			log.DebugF("sync Retrying %d time get range for block [%d] for error:%v", retryTimes, blkIndex, err)
-			retryTimes++
+	totalBlkCnt := int((info.LocalFileSize + blockSize - 1) / blockSize)
+	fromBlkIndex := int(recorder.Offset / blockSize)
+	for groupStart := fromBlkIndex; groupStart < totalBlkCnt; groupStart += workerCount {
+		groupEnd := groupStart + workerCount
+		if groupEnd > totalBlkCnt {
+			groupEnd = totalBlkCnt
 		}
-		dataBytes := bf.Bytes()
 
-		// 2.2 上传数据到云存储
-		err = uploader.UploadBlock(ctx, 0, dataBytes)
-		if err != nil {
-			return
-		} else {
-			if info.Progress != nil {
-				info.Progress.SendSize(int64(len(dataBytes)))
+		errs := make([]*data.CodeError, groupEnd-groupStart)
+		wait := &sync.WaitGroup{}
+		for blkIndex := groupStart; blkIndex < groupEnd; blkIndex++ {
+			wait.Add(1)
+			go func(blkIndex int) {
+				defer wait.Done()
+				errs[blkIndex-groupStart] = syncRangeBlock(ctx, info, uploader, blkIndex, blockSize)
+			}(blkIndex)
+		}
+		wait.Wait()
+
+		for _, err := range errs {
+			if err != nil {
+				return err
 			}
 		}
 
-		//advance range offset
-		rangeStartOffset += data.BLOCK_SIZE
 		if sErr := recorder.RecordProgress(); sErr != nil {
 			log.WarningF("sync save record progress error:%v", sErr)
 		}
 	}
+	return nil
+}
 
-	// 3. 合并文件
-	err = uploader.Complete(ctx, &ret)
-	if err != nil {
-		err = data.NewEmptyError().AppendDescF("sync complete error:%v", err)
-		return
-	} else {
-		if info.Progress != nil {
-			info.Progress.End()
+func syncRangeBlock(ctx context.Context, info *ApiInfo, uploader api.Resume, blkIndex int, blockSize int64) *data.CodeError {
+	log.DebugF("Syncing block %d ...", blkIndex)
+
+	// 获取上传数据
+	var retryTimes int
+	var bf *bytes.Buffer
+	var err *data.CodeError
+	for {
+		bf, err = getRange(info.FilePath, info.MaxRedirects, info.LocalFileSize, int64(blkIndex)*blockSize, blockSize)
+		if err != nil && retryTimes >= info.TryTimes {
+			return data.NewEmptyError().AppendDesc(strings.Join([]string{"sync Get range block data failed: ", err.Error()}, ""))
+		}
+		if err == nil {
+			break
 		}
+		time.Sleep(info.TryInterval)
+		log.DebugF("sync Retrying %d time get range for block [%d] for error:%v", retryTimes, blkIndex, err)
+		retryTimes++
 	}
+	dataBytes := bf.Bytes()
 
-	//delete progress file
-	if rErr := os.Remove(progressFile); rErr != nil {
-		log.WarningF("sync remove record progress error:%v", rErr)
+	// 上传数据到云存储
+	if err = uploader.UploadBlock(ctx, blkIndex, dataBytes); err != nil {
+		return err
+	}
+	if info.Progress != nil {
+		info.Progress.SendSize(int64(len(dataBytes)))
+	}
+	return nil
+}
+
+// syncBlocksByStream 服务端不支持 Range 时使用，通过一个请求顺序读取整个资源，断点续传时跳过已上传的部分
+func syncBlocksByStream(ctx context.Context, info *ApiInfo, uploader api.Resume, recorder *api.ProgressRecorder, blockSize int64) *data.CodeError {
+	// 整个资源在一个请求中读取，不设置超时
+	dResp, dRespErr := newSyncClient(info.MaxRedirects, 0).Get(info.FilePath)
+	if dRespErr != nil {
+		return data.NewEmptyError().AppendDescF("Get response error, %s", dRespErr.Error())
 	}
+	defer dResp.Body.Close()
 
-	return
+	if dResp.StatusCode/100 != 2 {
+		return data.NewEmptyError().AppendDescF("Get resource error, %s", dResp.Status)
+	}
+
+	fromBlkIndex := int(recorder.Offset / blockSize)
+	if skipSize := int64(fromBlkIndex) * blockSize; skipSize > 0 {
+		if _, cErr := io.CopyN(ioutil.Discard, dResp.Body, skipSize); cErr != nil {
+			return data.NewEmptyError().AppendDescF("sync skip synced data error, %v", cErr)
+		}
+	}
+
+	buffer := make([]byte, blockSize)
+	for blkIndex := fromBlkIndex; ; blkIndex++ {
+		n, rErr := io.ReadFull(dResp.Body, buffer)
+		if n > 0 {
+			log.DebugF("Syncing block %d ...", blkIndex)
+			if err := uploader.UploadBlock(ctx, blkIndex, buffer[:n]); err != nil {
+				return err
+			}
+			if info.Progress != nil {
+				info.Progress.SendSize(int64(n))
+			}
+			if sErr := recorder.RecordProgress(); sErr != nil {
+				log.WarningF("sync save record progress error:%v", sErr)
+			}
+		}
+
+		if rErr == io.EOF || rErr == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if rErr != nil {
+			return data.NewEmptyError().AppendDescF("sync read block %d error, %v", blkIndex, rErr)
+		}
+	}
 }
 
 func ProgressFileFromUrl(srcResUrl, bucket, key string) (progressFile string, err *data.CodeError) {
@@ -188,7 +283,7 @@ func ProgressFileFromUrl(srcResUrl, bucket, key string) (progressFile string, er
 	return
 }
 
-func getRange(srcResUrl string, totalSize, rangeStartOffset, rangeBlockSize int64) (buffer *bytes.Buffer, err *data.CodeError) {
+func getRange(srcResUrl string, maxRedirects int, totalSize, rangeStartOffset, rangeBlockSize int64) (buffer *bytes.Buffer, err *data.CodeError) {
 	//range get
 	dReq, dReqErr := http.NewRequest("GET", srcResUrl, nil)
 	if dReqErr != nil {
@@ -204,20 +299,8 @@ func getRange(srcResUrl string, totalSize, rangeStartOffset, rangeBlockSize int6
 
 	dReq.Header.Add("Range", fmt.Sprintf("bytes=%d-%d", rangeStartOffset, rangeEndOffset))
 
-	//set client properties
-	client := http.DefaultClient
-	client.Timeout = httpTimeout
-	//client.Transport = &http.Transport{
-	//	Proxy: http.ProxyURL(proxyURL),
-	//}
-
-	client.CheckRedirect = func(rReq *http.Request, rVias []*http.Request) error {
-		rReq.Header.Add("Range", dReq.Header.Get("Range"))
-		return nil
-	}
-
 	//get response
-	dResp, dRespErr := client.Do(dReq)
+	dResp, dRespErr := newSyncClient(maxRedirects, httpTimeout).Do(dReq)
 	if dRespErr != nil {
 		err = data.NewEmptyError().AppendDescF("Get response error, %s", dRespErr.Error())
 		return
@@ -257,6 +340,28 @@ func getRange(srcResUrl string, totalSize, rangeStartOffset, rangeBlockSize int6
 	return buffer, nil
 }
 
+// newSyncClient 创建获取网络资源的 client，最多跟随 maxRedirects 次重定向，重定向时携带 Range 头；
+// 分片会并发获取，不能修改 http.DefaultClient
+func newSyncClient(maxRedirects int, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return data.NewEmptyError().AppendDescF("redirects exceed the limit of %d, stopped at %s", maxRedirects, req.URL)
+			}
+
+			if rangeHeader := via[0].Header.Get("Range"); len(rangeHeader) > 0 {
+				req.Header.Set("Range", rangeHeader)
+			}
+
+			if from := via[len(via)-1].URL; from.Host != req.URL.Host {
+				log.InfoF("sync redirect from host:%s to host:%s", from.Host, req.URL.Host)
+			}
+			return nil
+		},
+	}
+}
+
 // Content-Range: bytes 25538640-25538647/25538648
 func parseContentRange(contentRange string) (rangeSize, totalSize int64) {
 	contentRangeItems := strings.Split(contentRange, " ")
@@ -279,11 +384,9 @@ func getUpHost(cfg *storage.Config, ak, bucket string) (upHost string, err *data
 	if cfg.Zone != nil {
 		zone = cfg.Zone
 	} else {
-		if v, zoneErr := storage.GetZone(ak, bucket); zoneErr != nil {
-			err = data.ConvertError(zoneErr)
+		if zone = workspace.GetBucketRegion(bucket); zone == nil {
+			err = data.NewEmptyError().AppendDescF("can't get region of bucket:%s", bucket)
 			return
-		} else {
-			zone = v
 		}
 	}
 
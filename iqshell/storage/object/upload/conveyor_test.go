@@ -0,0 +1,96 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/upload/api"
+)
+
+type testResume struct {
+	mu     sync.Mutex
+	blocks map[int][]byte
+}
+
+func (t *testResume) InitServer(ctx context.Context) *data.CodeError {
+	return nil
+}
+
+func (t *testResume) UploadBlock(ctx context.Context, index int, d []byte) *data.CodeError {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.blocks[index] = append([]byte(nil), d...)
+	return nil
+}
+
+func (t *testResume) Complete(ctx context.Context, ret interface{}) *data.CodeError {
+	return nil
+}
+
+func (t *testResume) content() []byte {
+	buffer := &bytes.Buffer{}
+	for i := 0; i < len(t.blocks); i++ {
+		buffer.Write(t.blocks[i])
+	}
+	return buffer.Bytes()
+}
+
+func TestSyncBlocks(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file", time.Now(), bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	info := &ApiInfo{
+		FilePath:          server.URL,
+		LocalFileSize:     int64(len(content)),
+		ResumeWorkerCount: 3,
+	}
+
+	uploader := &testResume{blocks: make(map[int][]byte)}
+	recorder := api.NewProgressRecorder(filepath.Join(t.TempDir(), "range.progress"))
+	if err := syncBlocksByRange(context.Background(), info, uploader, recorder, 1024); err != nil {
+		t.Fatal("sync by range error:", err)
+	}
+	if len(uploader.blocks) != 10 || !bytes.Equal(uploader.content(), content) {
+		t.Fatal("sync by range: content not match")
+	}
+
+	uploader = &testResume{blocks: make(map[int][]byte)}
+	recorder = api.NewProgressRecorder(filepath.Join(t.TempDir(), "stream.progress"))
+	if err := syncBlocksByStream(context.Background(), info, uploader, recorder, 1024); err != nil {
+		t.Fatal("sync by stream error:", err)
+	}
+	if len(uploader.blocks) != 10 || !bytes.Equal(uploader.content(), content) {
+		t.Fatal("sync by stream: content not match")
+	}
+}
+
+func TestSyncRedirect(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file", time.Now(), bytes.NewReader(content))
+	}))
+	defer source.Close()
+
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, source.URL, http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	if _, err := getRange(redirect.URL, 1, int64(len(content)), 100, 100); err != nil {
+		t.Fatal("get range with redirect error:", err)
+	}
+
+	if _, err := getRange(redirect.URL, 0, int64(len(content)), 100, 100); err == nil {
+		t.Fatal("get range should fail when redirects exceed the limit")
+	}
+}
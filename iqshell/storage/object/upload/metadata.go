@@ -0,0 +1,98 @@
+package upload
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+const MetadataPrefix = "x-qn-meta-"
+
+// reservedMetadataNames 由服务端生成的响应头，不能通过元数据设置
+var reservedMetadataNames = map[string]bool{
+	"accept-ranges":     true,
+	"connection":        true,
+	"content-length":    true,
+	"content-md5":       true,
+	"content-range":     true,
+	"date":              true,
+	"etag":              true,
+	"host":              true,
+	"last-modified":     true,
+	"server":            true,
+	"transfer-encoding": true,
+}
+
+// NormalizeMetadata 校验元数据并转为上传接口的参数：Content-Type 作为文件的 MimeType，
+// 其他的作为自定义元数据，key 统一添加 x-qn-meta- 前缀（已有前缀的不重复添加）；
+// key 只能包含字母、数字、- 和 _，不区分大小写，value 不能为空且不能包含控制字符
+func NormalizeMetadata(metadata map[string]string) (mimeType string, params map[string]string, err *data.CodeError) {
+	params = make(map[string]string, len(metadata))
+	names := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		name, e := NormalizeMetadataName(key)
+		if e != nil {
+			return "", nil, e
+		}
+		lowerName := strings.ToLower(name)
+		if e := CheckMetadataValue(key, value); e != nil {
+			return "", nil, e
+		}
+		if other, ok := names[lowerName]; ok {
+			return "", nil, alert.Error(fmt.Sprintf("metadata %s and %s are duplicate", other, key), "metadata key is case-insensitive")
+		}
+		names[lowerName] = key
+
+		if lowerName == "content-type" {
+			mimeType = value
+		} else {
+			params[MetadataPrefix+name] = value
+		}
+	}
+	return mimeType, params, nil
+}
+
+// NormalizeMetadataName 校验元数据名，返回去掉 x-qn-meta- 前缀后的名称
+func NormalizeMetadataName(key string) (string, *data.CodeError) {
+	name := key
+	if strings.HasPrefix(strings.ToLower(name), MetadataPrefix) {
+		name = name[len(MetadataPrefix):]
+	}
+	if e := checkMetadataName(key, name); e != nil {
+		return "", e
+	}
+	if reservedMetadataNames[strings.ToLower(name)] {
+		return "", alert.Error(fmt.Sprintf("metadata %s is reserved and can't be set", key), "")
+	}
+	return name, nil
+}
+
+func checkMetadataName(key, name string) *data.CodeError {
+	if len(name) == 0 {
+		return alert.Error(fmt.Sprintf("metadata %s is invalid, name can't be empty", key), "")
+	}
+	if strings.HasPrefix(strings.ToLower(name), "x-qn-") {
+		return alert.Error(fmt.Sprintf("metadata %s is invalid, x-qn- is reserved", key), "custom metadata should be like x-qn-meta-<Name>")
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return alert.Error(fmt.Sprintf("metadata %s is invalid, name can only contain letters, digits, - and _", key), "")
+		}
+	}
+	return nil
+}
+
+// CheckMetadataValue 元数据的值不能为空且不能包含控制字符
+func CheckMetadataValue(key, value string) *data.CodeError {
+	if len(value) == 0 {
+		return alert.Error(fmt.Sprintf("value of metadata %s can't be empty", key), "")
+	}
+	for _, c := range value {
+		if c < 0x20 && c != '\t' || c == 0x7f {
+			return alert.Error(fmt.Sprintf("value of metadata %s can't contain control characters", key), "")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package upload
+
+import "testing"
+
+func TestNormalizeMetadata(t *testing.T) {
+	mimeType, params, err := NormalizeMetadata(map[string]string{
+		"Content-Type":          "text/html",
+		"Cache-Control":         "max-age=3600",
+		"x-qn-meta-Custom_Name": "value",
+	})
+	if err != nil {
+		t.Fatal("normalize metadata error:", err)
+	}
+	if mimeType != "text/html" {
+		t.Fatal("mime type should be text/html, but:", mimeType)
+	}
+	if len(params) != 2 || params["x-qn-meta-Cache-Control"] != "max-age=3600" || params["x-qn-meta-Custom_Name"] != "value" {
+		t.Fatal("metadata params error:", params)
+	}
+
+	for _, metadata := range []map[string]string{
+		{"Cache Control": "a"},
+		{"x-qn-meta-": "a"},
+		{"x-qn-fsize": "a"},
+		{"ETag": "a"},
+		{"Cache-Control": ""},
+		{"Cache-Control": "a\nb"},
+		{"Cache-Control": "a", "x-qn-meta-cache-control": "b"},
+	} {
+		if _, _, err := NormalizeMetadata(metadata); err == nil {
+			t.Fatalf("metadata %v should be invalid", metadata)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+// MimeTypeDetector 根据文件扩展名获取文件的 MimeType
+type MimeTypeDetector struct {
+	table map[string]string // 扩展名（小写，以 . 开头）和 MimeType 的映射，优先级高于系统的映射
+}
+
+// NewMimeTypeDetector tableFile 为扩展名和 MimeType 的映射表文件，JSON 格式，如：{".md": "text/markdown"}；为空则仅使用系统的映射
+func NewMimeTypeDetector(tableFile string) (*MimeTypeDetector, *data.CodeError) {
+	d := &MimeTypeDetector{
+		table: make(map[string]string),
+	}
+	if len(tableFile) == 0 {
+		return d, nil
+	}
+
+	table := make(map[string]string)
+	if err := utils.UnMarshalFromFile(tableFile, &table); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("load mime type table from %s error:%v", tableFile, err)
+	}
+	for ext, mimeType := range table {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if len(ext) == 0 || len(mimeType) == 0 {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		d.table[ext] = mimeType
+	}
+	return d, nil
+}
+
+// Detect 获取文件的 MimeType，无法获取时返回空字符串
+func (d *MimeTypeDetector) Detect(filePath string) string {
+	if d == nil {
+		return ""
+	}
+
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if len(ext) == 0 {
+		return ""
+	}
+	if mimeType, ok := d.table[ext]; ok {
+		return mimeType
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// CheckMimeType 校验 MimeType 的格式：type/subtype[;params]
+func CheckMimeType(mimeType string) *data.CodeError {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err == nil && !strings.Contains(mediaType, "/") {
+		err = errors.New("expected slash after media type")
+	}
+	if err != nil {
+		return alert.Error(fmt.Sprintf("invalid mime type:%s, %v", mimeType, err),
+			"mime type should be like type/subtype[;params], such as image/jpeg or text/plain; charset=utf-8")
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package upload
+
+import "testing"
+
+func TestCheckMimeType(t *testing.T) {
+	for _, mimeType := range []string{"image/jpeg", "text/plain; charset=utf-8", "application/vnd.ms-excel"} {
+		if err := CheckMimeType(mimeType); err != nil {
+			t.Fatalf("mime type:%s should be valid, error:%v", mimeType, err)
+		}
+	}
+	for _, mimeType := range []string{"", "jpeg", "image/", "/jpeg", "image/jpeg/x", "image jpeg", "text/plain; charset"} {
+		if err := CheckMimeType(mimeType); err == nil {
+			t.Fatalf("mime type:%q should be invalid", mimeType)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package operations
 
 import (
+	"bufio"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -10,6 +11,8 @@ import (
 	"github.com/qiniu/go-sdk/v7/storage"
 
 	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/bandwidth"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/export"
 	"github.com/qiniu/qshell/v2/iqshell/common/flow"
@@ -32,9 +35,18 @@ type BatchUploadInfo struct {
 	ItemSeparate string // 工作数据源：每行元素按分隔符分的分隔符
 	EnableStdin  bool   // 工作数据源：stdin, 当 InputFile 不存在时使用 stdin
 
-	UploadConfigFile string
-	CallbackHost     string
-	CallbackUrl      string
+	UploadConfigFile      string
+	CallbackHost          string
+	CallbackUrl           string
+	MimeTypeFromExtension bool   // 根据文件扩展名设置 MimeType，和配置文件中的 mimetype_from_extension 任一开启即生效
+	RateLimit             string // 上传总带宽限制，优先级高于配置文件中的 rate_limit
+	ForceRehash           bool   // 检测本地文件是否变化时总是计算 hash，和配置文件中的 force_rehash 任一开启即生效
+	NoHashCache           bool   // 不使用本地文件 etag 的缓存，和配置文件中的 no_hash_cache 任一开启即生效
+	ResumeRecordDir       string // 分片上传断点记录的共享目录，优先级高于配置文件中的 resume_record_dir
+	OnCollision           string // 多个本地文件对应同一个 key 时的处理方式，优先级高于配置文件中的 on_collision
+	PartConcurrency       int    // 单个文件分片上传的并发数，大于 0 时优先级高于配置文件中的 part_concurrency
+	PartSize              string // 分片大小，优先级高于配置文件中的 part_size
+	MultipartThreshold    string // 使用分片上传的文件大小阈值，优先级高于配置文件中的 multipart_threshold
 }
 
 func (info *BatchUploadInfo) Check() *data.CodeError {
@@ -90,6 +102,33 @@ func BatchUpload(cfg *iqshell.Config, info BatchUploadInfo) {
 		log.ErrorF("UnMarshal: read log setting error:%v config file:%s", err, info.UploadConfigFile)
 		return
 	}
+	if info.MimeTypeFromExtension {
+		upload2Info.UploadConfig.MimeTypeFromExtension = true
+	}
+	if len(info.RateLimit) > 0 {
+		upload2Info.UploadConfig.RateLimit = info.RateLimit
+	}
+	if info.ForceRehash {
+		upload2Info.UploadConfig.ForceRehash = true
+	}
+	if info.NoHashCache {
+		upload2Info.UploadConfig.NoHashCache = true
+	}
+	if len(info.OnCollision) > 0 {
+		upload2Info.UploadConfig.OnCollision = info.OnCollision
+	}
+	if len(info.ResumeRecordDir) > 0 {
+		upload2Info.UploadConfig.ResumeRecordDir = info.ResumeRecordDir
+	}
+	if info.PartConcurrency > 0 {
+		upload2Info.UploadConfig.PartConcurrency = info.PartConcurrency
+	}
+	if len(info.PartSize) > 0 {
+		upload2Info.UploadConfig.PartSize = info.PartSize
+	}
+	if len(info.MultipartThreshold) > 0 {
+		upload2Info.UploadConfig.MultipartThreshold = info.MultipartThreshold
+	}
 
 	BatchUpload2(cfg, upload2Info)
 }
@@ -103,6 +142,8 @@ type BatchUpload2Info struct {
 	InputFile    string // 工作数据源：文件
 	ItemSeparate string // 工作数据源：每行元素按分隔符分的分隔符
 	EnableStdin  bool   // 工作数据源：stdin, 当 InputFile 不存在时使用 stdin
+
+	MetadataItems []string // 命令行指定的元数据，格式：<Name>:<Value>，会合并到 UploadConfig 的 Metadata 中
 }
 
 func (info *BatchUpload2Info) Check() *data.CodeError {
@@ -110,14 +151,27 @@ func (info *BatchUpload2Info) Check() *data.CodeError {
 		log.WarningF("Tip: %d is out of range, you can set <ThreadCount> value between 1 and 200 to improve speed, and now ThreadCount change to: 5", info.Info.WorkerCount)
 		info.Info.WorkerCount = 5
 	}
+	if info.UploadConfig.PartConcurrency > 0 {
+		info.UploadConfig.WorkerCount = info.UploadConfig.PartConcurrency
+	}
 	if info.UploadConfig.WorkerCount < 1 || info.UploadConfig.WorkerCount > 2000 {
-		log.WarningF("Tip: %d is out of range, you can set <WorkerCount> value between 1 and 200 to improve speed, and now WorkerCount change to: 3", info.UploadConfig.WorkerCount)
+		log.WarningF("Tip: %d is out of range, you can set <PartConcurrency> value between 1 and 200 to improve speed, and now PartConcurrency change to: 3", info.UploadConfig.WorkerCount)
 		info.UploadConfig.WorkerCount = 3
 	}
 
 	if err := info.Info.Check(); err != nil {
 		return err
 	}
+	for _, item := range info.MetadataItems {
+		name, value, found := strings.Cut(item, ":")
+		if !found {
+			return alert.Error("invalid metadata: "+item, "metadata should be <Name>:<Value>")
+		}
+		if info.UploadConfig.Metadata == nil {
+			info.UploadConfig.Metadata = make(map[string]string)
+		}
+		info.UploadConfig.Metadata[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
 	if err := info.UploadConfig.Check(); err != nil {
 		return err
 	}
@@ -169,7 +223,10 @@ func batchUpload(info BatchUpload2Info) {
 
 	// 扫描本地文件
 	needScanLocal := false
-	if data.Empty(info.FileList) {
+	if len(info.SrcArchive) > 0 {
+		// 压缩包中的文件在上传时逐个读取，无需扫描
+		needScanLocal = false
+	} else if data.Empty(info.FileList) {
 		needScanLocal = true
 	} else {
 		if _, err := os.Stat(info.FileList); err == nil {
@@ -198,7 +255,12 @@ func batchUpload(info BatchUpload2Info) {
 			info.InputFile = filepath.Join(workspace.GetJobDir(), ".cache")
 		}
 
-		_, err := utils.DirCache(info.SrcDir, info.InputFile)
+		var err *data.CodeError
+		if len(info.SrcGlobs) > 0 {
+			_, err = utils.DirCacheWithGlobs(info.SrcDir, info.SrcGlobs, info.InputFile)
+		} else {
+			_, err = utils.DirCache(info.SrcDir, info.InputFile)
+		}
 		if err != nil {
 			data.SetCmdStatusError()
 			log.ErrorF("create dir files cache error:%v", err)
@@ -224,97 +286,198 @@ func batchUploadFlow(info BatchUpload2Info, uploadConfig UploadConfig, dbPath st
 		return
 	}
 
+	var mimeTypeDetector *upload.MimeTypeDetector
+	if uploadConfig.MimeTypeFromExtension {
+		if mimeTypeDetector, err = upload.NewMimeTypeDetector(uploadConfig.MimeTypeTableFile); err != nil {
+			data.SetCmdStatusError()
+			log.Error(err)
+			return
+		}
+	}
+
+	if len(uploadConfig.RateLimit) > 0 {
+		rateLimit, pErr := utils.ParseFileSize(uploadConfig.RateLimit)
+		if pErr != nil {
+			data.SetCmdStatusError()
+			log.ErrorF("invalid rate limit:%v", pErr)
+			return
+		}
+		bandwidth.SetGlobalLimit(rateLimit)
+		log.InfoF("upload rate limit:%s/s", utils.FormatFileSize(rateLimit))
+	}
+
+	var collisionSkippedPaths map[string]string
+	if len(uploadConfig.OnCollision) > 0 {
+		if len(uploadConfig.SrcArchive) > 0 {
+			log.Warning("check key collisions is not supported for src_archive, ignore on_collision")
+		} else {
+			filter, fErr := info.Info.FilterSkipper()
+			if fErr != nil {
+				data.SetCmdStatusError()
+				log.Error(fErr)
+				return
+			}
+			skippedPaths, shouldContinue := checkKeyCollisions(info.InputFile, info.ItemSeparate, &uploadConfig, filter)
+			if !shouldContinue {
+				data.SetCmdStatusError()
+				return
+			}
+			collisionSkippedPaths = skippedPaths
+		}
+	}
+
 	metric := &Metric{}
+	var totalSize int64
+	if info.ShowProgress {
+		metric.DisablePrintProgress()
+		totalSize = fileListTotalSize(info.InputFile, info.ItemSeparate)
+	}
 	metric.Start()
+	if !uploadConfig.NoHashCache {
+		// 中断时也保存已计算的 etag，下次执行时无需重新计算
+		workspace.AddCancelObserver(func(s os.Signal) {
+			object.FlushEtagCache()
+		})
+		defer object.FlushEtagCache()
+	}
 
-	flow.New(info.Info).
-		WorkProviderWithFile(info.InputFile,
+	newUploadInfo := func(fileRelativePath string, localFilePath string, fileSize int64, modifyTime int64) (*UploadInfo, *data.CodeError) {
+		key := uploadConfig.SaveKey(fileRelativePath)
+		log.DebugF("Key:%s FileSize:%d ModifyTime:%d", key, fileSize, modifyTime)
+
+		uploadInfo := &UploadInfo{
+			ApiInfo: upload.ApiInfo{
+				FilePath:            localFilePath,
+				ToBucket:            uploadConfig.Bucket,
+				SaveKey:             key,
+				MimeType:            "",
+				FileType:            uploadConfig.FileType,
+				CheckExist:          uploadConfig.CheckExists,
+				CheckHash:           uploadConfig.CheckHash,
+				CheckSize:           uploadConfig.CheckSize,
+				Overwrite:           uploadConfig.Overwrite,
+				UpHost:              uploadConfig.UpHost,
+				TokenProvider:       nil,
+				UseEtagCache:        !uploadConfig.NoHashCache,
+				TryTimes:            3,
+				TryInterval:         500 * time.Millisecond,
+				LocalFileSize:       fileSize,
+				LocalFileModifyTime: modifyTime,
+				DisableForm:         uploadConfig.DisableForm,
+				DisableResume:       uploadConfig.DisableResume,
+				UseResumeV2:         uploadConfig.ResumableAPIV2,
+				ChunkSize:           uploadConfig.ResumableAPIV2PartSize,
+				PutThreshold:        uploadConfig.PutThreshold,
+				ResumeWorkerCount:   partWorkerCount(uploadConfig.WorkerCount, info.Info), // go SDK 分片并发量是全局的需要做转化
+				SequentialReadFile:  uploadConfig.SequentialReadFile,
+				ResumeRecordDir:     uploadConfig.ResumeRecordDir,
+				Progress:            nil,
+			},
+			RelativePathToSrcPath: fileRelativePath,
+			Policy: storage.PutPolicy{
+				Scope:               "",
+				IsPrefixalScope:     0,
+				Expires:             0,
+				InsertOnly:          0,
+				EndUser:             uploadConfig.EndUser,
+				ReturnURL:           "",
+				ReturnBody:          "",
+				CallbackURL:         uploadConfig.CallbackURL,
+				CallbackHost:        uploadConfig.CallbackHost,
+				CallbackBody:        uploadConfig.CallbackBody,
+				CallbackBodyType:    uploadConfig.CallbackBodyType,
+				PersistentOps:       uploadConfig.PersistentOps,
+				PersistentNotifyURL: uploadConfig.PersistentNotifyURL,
+				PersistentPipeline:  uploadConfig.PersistentPipeline,
+				ForceSaveKey:        false,
+				SaveKey:             "",
+				FsizeMin:            0,
+				FsizeLimit:          0,
+				DetectMime:          uploadConfig.DetectMime,
+				MimeLimit:           "",
+				FileType:            uploadConfig.FileType,
+				CallbackFetchKey:    uploadConfig.CallbackFetchKey,
+				DeleteAfterDays:     uploadConfig.DeleteAfterDays,
+				TrafficLimit:        uploadConfig.TrafficLimit,
+			},
+			DeleteOnSuccess: uploadConfig.DeleteOnSuccess,
+		}
+		if metadata := uploadConfig.MetadataOf(fileRelativePath); len(metadata) > 0 {
+			// 元数据已在 Check 中校验
+			uploadInfo.MimeType, uploadInfo.Metadata, _ = upload.NormalizeMetadata(metadata)
+		}
+		if len(uploadInfo.MimeType) == 0 {
+			uploadInfo.MimeType = mimeTypeDetector.Detect(localFilePath)
+		}
+		if endUser, rErr := resolveEndUser(uploadConfig.EndUser, fileRelativePath); rErr != nil {
+			return nil, rErr
+		} else {
+			uploadInfo.Policy.EndUser = endUser
+		}
+		uploadInfo.TokenProvider = createTokenProviderWithMac(mac, uploadInfo)
+		return uploadInfo, nil
+	}
+
+	var workProvider flow.WorkProvider
+	if len(uploadConfig.SrcArchive) > 0 {
+		archiveProvider, pErr := newArchiveWorkProvider(uploadConfig.SrcArchive, func(entry *archiveEntry) (flow.Work, *data.CodeError) {
+			uploadInfo, cErr := newUploadInfo(entry.Name, filepath.Join(uploadConfig.SrcArchive, entry.Name), entry.Size, entry.ModifyTime)
+			if cErr != nil {
+				return nil, cErr
+			}
+			uploadInfo.FromArchive = uploadConfig.SrcArchive
+			uploadInfo.DeleteOnSuccess = false
+			uploadInfo.archiveEntry = entry
+			return uploadInfo, nil
+		})
+		if pErr != nil {
+			data.SetCmdStatusError()
+			log.Error(pErr)
+			return
+		}
+		defer archiveProvider.Close()
+		if archiveProvider.sequential {
+			log.Info("entries in tar archive can only be read in order, they will be uploaded one by one")
+		}
+		workProvider = archiveProvider
+	} else {
+		fileProvider, pErr := flow.NewWorkProviderOfFile(info.InputFile,
 			false,
 			flow.NewItemsWorkCreator(info.ItemSeparate,
 				3,
 				func(items []string) (work flow.Work, err *data.CodeError) {
 					fileRelativePath := items[0]
-					//pack the upload file key
 					fileSize, _ := strconv.ParseInt(items[1], 10, 64)
 					modifyTime, _ := strconv.ParseInt(items[2], 10, 64)
-					key := fileRelativePath
-					//check ignore dir
-					if uploadConfig.IsIgnoreDir() {
-						key = filepath.Base(key)
-					}
-					//check prefix
-					if data.NotEmpty(uploadConfig.KeyPrefix) {
-						key = strings.Join([]string{uploadConfig.KeyPrefix, key}, "")
-					}
-					//convert \ to / under windows
-					if utils.IsWindowsOS() {
-						key = strings.Replace(key, "\\", "/", -1)
+					localFilePath := filepath.Join(uploadConfig.SrcDir, fileRelativePath)
+					uploadInfo, cErr := newUploadInfo(fileRelativePath, localFilePath, fileSize, modifyTime)
+					if cErr != nil {
+						return nil, cErr
 					}
-					//check file encoding
-					if data.NotEmpty(uploadConfig.FileEncoding) && utils.IsGBKEncoding(uploadConfig.FileEncoding) {
-						key, _ = utils.Gbk2Utf8(key)
+					// 第 4 列为可选的服务端复制源文件
+					if len(items) > 3 && len(items[3]) > 0 {
+						if _, _, pErr := parseCopySource(items[3]); pErr != nil {
+							return nil, pErr
+						}
+						uploadInfo.CopySource = items[3]
 					}
-					log.DebugF("Key:%s FileSize:%d ModifyTime:%d", key, fileSize, modifyTime)
-
-					localFilePath := filepath.Join(uploadConfig.SrcDir, fileRelativePath)
-					uploadInfo := &UploadInfo{
-						ApiInfo: upload.ApiInfo{
-							FilePath:            localFilePath,
-							ToBucket:            uploadConfig.Bucket,
-							SaveKey:             key,
-							MimeType:            "",
-							FileType:            uploadConfig.FileType,
-							CheckExist:          uploadConfig.CheckExists,
-							CheckHash:           uploadConfig.CheckHash,
-							CheckSize:           uploadConfig.CheckSize,
-							Overwrite:           uploadConfig.Overwrite,
-							UpHost:              uploadConfig.UpHost,
-							TokenProvider:       nil,
-							TryTimes:            3,
-							TryInterval:         500 * time.Millisecond,
-							LocalFileSize:       fileSize,
-							LocalFileModifyTime: modifyTime,
-							DisableForm:         uploadConfig.DisableForm,
-							DisableResume:       uploadConfig.DisableResume,
-							UseResumeV2:         uploadConfig.ResumableAPIV2,
-							ChunkSize:           uploadConfig.ResumableAPIV2PartSize,
-							PutThreshold:        uploadConfig.PutThreshold,
-							ResumeWorkerCount:   uploadConfig.WorkerCount * info.Info.WorkerCount, // go SDK 分片并发量是全局的需要做转化
-							SequentialReadFile:  uploadConfig.SequentialReadFile,
-							Progress:            nil,
-						},
-						RelativePathToSrcPath: fileRelativePath,
-						Policy: storage.PutPolicy{
-							Scope:               "",
-							IsPrefixalScope:     0,
-							Expires:             0,
-							InsertOnly:          0,
-							EndUser:             uploadConfig.EndUser,
-							ReturnURL:           "",
-							ReturnBody:          "",
-							CallbackURL:         uploadConfig.CallbackURL,
-							CallbackHost:        uploadConfig.CallbackHost,
-							CallbackBody:        uploadConfig.CallbackBody,
-							CallbackBodyType:    uploadConfig.CallbackBodyType,
-							PersistentOps:       uploadConfig.PersistentOps,
-							PersistentNotifyURL: uploadConfig.PersistentNotifyURL,
-							PersistentPipeline:  uploadConfig.PersistentPipeline,
-							ForceSaveKey:        false,
-							SaveKey:             "",
-							FsizeMin:            0,
-							FsizeLimit:          0,
-							DetectMime:          uploadConfig.DetectMime,
-							MimeLimit:           "",
-							FileType:            uploadConfig.FileType,
-							CallbackFetchKey:    uploadConfig.CallbackFetchKey,
-							DeleteAfterDays:     uploadConfig.DeleteAfterDays,
-							TrafficLimit:        uploadConfig.TrafficLimit,
-						},
-						DeleteOnSuccess: uploadConfig.DeleteOnSuccess,
+					// 第 5 列为可选的 endUser，优先级高于配置中的 end_user
+					if len(items) > 4 && len(items[4]) > 0 {
+						uploadInfo.Policy.EndUser = items[4]
+						uploadInfo.TokenProvider = createTokenProviderWithMac(mac, uploadInfo)
 					}
-					uploadInfo.TokenProvider = createTokenProviderWithMac(mac, uploadInfo)
 					return uploadInfo, nil
-				})).
+				}))
+		if pErr != nil {
+			data.SetCmdStatusError()
+			log.Error(pErr)
+			return
+		}
+		workProvider = fileProvider
+	}
+
+	flow.New(info.Info).
+		WorkProvider(workProvider).
 		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
 			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
 				apiInfo, _ := workInfo.Work.(*UploadInfo)
@@ -322,6 +485,23 @@ func batchUploadFlow(info BatchUpload2Info, uploadConfig UploadConfig, dbPath st
 				metric.AddCurrentCount(1)
 				metric.PrintProgress("Uploading: " + apiInfo.FilePath)
 
+				if apiInfo.archiveEntry != nil {
+					reader, oErr := apiInfo.archiveEntry.open()
+					if oErr != nil {
+						return nil, oErr
+					}
+					apiInfo.Reader = reader
+				}
+
+				if len(apiInfo.CopySource) > 0 {
+					res, fallback, e := copyFromSource(apiInfo)
+					if e != nil {
+						return nil, e
+					} else if !fallback {
+						return res, nil
+					}
+				}
+
 				if res, e := uploadFile(apiInfo); e != nil {
 					return nil, e
 				} else {
@@ -377,8 +557,7 @@ func batchUploadFlow(info BatchUpload2Info, uploadConfig UploadConfig, dbPath st
 				}
 			}
 
-			// LocalFileModifyTime 单位是 100ns
-			isLocalFileNotChange, mErr := utils.IsLocalFileMatchFileModifyTime(uploadInfo.FilePath, recordUploadInfo.LocalFileModifyTime/10000000)
+			isLocalFileNotChange, mErr := isLocalFileNotChangeSinceLastUpload(uploadInfo, recordUploadInfo, result, uploadConfig.ForceRehash)
 			// 本地文件没有变化，服务端文件没有变化，则不需要再重新上传
 			if isLocalFileNotChange && isServerFileNotChange {
 				return false, nil
@@ -390,12 +569,17 @@ func batchUploadFlow(info BatchUpload2Info, uploadConfig UploadConfig, dbPath st
 				return true, data.NewEmptyError().AppendDesc("server file has change, hash don't match")
 			}
 		}).
+		SetWorkTotalSize(totalSize).
 		FlowWillStartFunc(func(flow *flow.Flow) (err *data.CodeError) {
 			metric.AddTotalCount(flow.WorkProvider.WorkTotalCount())
 			return nil
 		}).
 		ShouldSkip(func(workInfo *flow.WorkInfo) (skip bool, cause *data.CodeError) {
 			uploadInfo := workInfo.Work.(*UploadInfo)
+			if key, ok := collisionSkippedPaths[uploadInfo.RelativePathToSrcPath]; ok {
+				return true, data.NewEmptyError().AppendDescF("Skip by key collision, key `%s` is used by another local file", key)
+			}
+
 			if hit, prefix := uploadConfig.HitByPathPrefixes(uploadInfo.RelativePathToSrcPath); hit {
 				return true, data.NewEmptyError().AppendDescF("Skip by path prefix `%s` for local file path `%s`", prefix, uploadInfo.RelativePathToSrcPath)
 			}
@@ -413,7 +597,9 @@ func batchUploadFlow(info BatchUpload2Info, uploadConfig UploadConfig, dbPath st
 			}
 			return
 		}).
+		Deadletter(exporter.Deadletter()).
 		OnWorkSkip(func(workInfo *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			releaseArchiveEntryOfWork(workInfo)
 			metric.AddCurrentCount(1)
 			metric.PrintProgress("Uploading: " + workInfo.Data)
 
@@ -432,7 +618,8 @@ func batchUploadFlow(info BatchUpload2Info, uploadConfig UploadConfig, dbPath st
 				exporter.Skip().Export(workInfo.Data)
 			}
 		}).
-		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result) {
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
+			releaseArchiveEntryOfWork(workInfo)
 			res, _ := result.(*upload.ApiResult)
 			if res.IsNotOverwrite {
 				metric.AddNotOverwriteCount(1)
@@ -444,9 +631,10 @@ func batchUploadFlow(info BatchUpload2Info, uploadConfig UploadConfig, dbPath st
 				exporter.Success().Export(workInfo.Data)
 			}
 		}).
-		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError) {
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
+			releaseArchiveEntryOfWork(workInfo)
 			metric.AddFailureCount(1)
-			exporter.Fail().ExportF("%s%s%%s", workInfo.Data, flow.ErrorSeparate, err)
+			exporter.Fail().ExportF("%s%s%v", workInfo.Data, flow.ErrorSeparate, err)
 			log.ErrorF("Upload Failed, %s error:%s", workInfo.Data, err)
 		}).Build().Start()
 
@@ -462,22 +650,26 @@ func batchUploadFlow(info BatchUpload2Info, uploadConfig UploadConfig, dbPath st
 		log.DebugF("save download result to path:%s", resultPath)
 	}
 
-	log.Info("--------------- Upload Result ---------------")
-	log.InfoF("%20s%10d", "Total:", metric.TotalCount)
-	log.InfoF("%20s%10d", "Success:", metric.SuccessCount)
-	log.InfoF("%20s%10d", "Failure:", metric.FailureCount)
-	log.InfoF("%20s%10d", "Overwrite:", metric.OverwriteCount)
-	log.InfoF("%20s%10d", "NotOverwrite:", metric.NotOverwriteCount)
-	log.InfoF("%20s%10d", "Skipped:", metric.SkippedCount)
-	log.InfoF("%20s%10ds", "Duration:", metric.Duration)
-	log.InfoF("---------------------------------------------")
+	log.InfoF("Overwrite:%d, NotOverwrite:%d", metric.OverwriteCount, metric.NotOverwriteCount)
 	if workspace.GetConfig().Log.Enable() {
 		log.InfoF("See upload log at path:%s \n\n", workspace.GetConfig().Log.LogFile.Value())
 	}
+}
 
-	if !metric.IsCompletedSuccessfully() {
-		data.SetCmdStatusError()
+// partWorkerCount go SDK 的分片上传协程池是全局的，由所有上传线程共享，大小为 单个文件分片并发数 × 线程数；
+// 开启线程数动态调整时按最大线程数计算，线程数减少时分片上传的总并发也不会超过此值
+func partWorkerCount(partConcurrency int, info flow.Info) int {
+	threadCount := info.WorkerCount
+	if info.MaxWorkerCount > threadCount {
+		threadCount = info.MaxWorkerCount
+	}
+	if partConcurrency < 1 {
+		partConcurrency = 1
 	}
+	if threadCount < 1 {
+		threadCount = 1
+	}
+	return partConcurrency * threadCount
 }
 
 type BatchUploadConfigMouldInfo struct {
@@ -486,3 +678,78 @@ type BatchUploadConfigMouldInfo struct {
 func BatchUploadConfigMould(cfg *iqshell.Config, info BatchUploadConfigMouldInfo) {
 	log.Alert(uploadConfigMouldJsonString)
 }
+
+// isLocalFileNotChangeSinceLastUpload 对比本地文件和上次上传时的记录，检测本地文件是否有变化
+// 1. 大小不同则有变化；
+// 2. 修改时间和大小均相同则没有变化，无需计算 hash；
+// 3. 大小相同但修改时间较新（如：时钟偏差或仅修改了文件时间），计算 hash 与上次上传的结果对比，hash 相同则没有变化；
+// forceRehash 为 true 时不信任修改时间，大小相同时总是计算 hash 对比。
+func isLocalFileNotChangeSinceLastUpload(uploadInfo, recordUploadInfo *UploadInfo, result *upload.ApiResult, forceRehash bool) (bool, *data.CodeError) {
+	if len(uploadInfo.FromArchive) > 0 {
+		// 压缩包中的文件无法单独计算 hash，仅对比大小和修改时间
+		if uploadInfo.LocalFileSize != recordUploadInfo.LocalFileSize || uploadInfo.LocalFileModifyTime != recordUploadInfo.LocalFileModifyTime {
+			return false, data.NewEmptyError().AppendDescF("archive entry size or modifyTime don't match, except:%d/%d but:%d/%d",
+				recordUploadInfo.LocalFileSize, recordUploadInfo.LocalFileModifyTime, uploadInfo.LocalFileSize, uploadInfo.LocalFileModifyTime)
+		}
+		return true, nil
+	}
+
+	stat, sErr := os.Stat(uploadInfo.FilePath)
+	if sErr != nil {
+		return false, data.NewEmptyError().AppendDesc("get local file stat").AppendError(sErr)
+	}
+
+	if recordUploadInfo.LocalFileSize > 0 && stat.Size() != recordUploadInfo.LocalFileSize {
+		return false, data.NewEmptyError().AppendDescF("size don't match, except:%d but:%d", recordUploadInfo.LocalFileSize, stat.Size())
+	}
+
+	// LocalFileModifyTime 单位是 100ns
+	recordModifyTime := recordUploadInfo.LocalFileModifyTime / 10000000
+	modifyTime := stat.ModTime().Unix()
+	if modifyTime < recordModifyTime {
+		return false, data.NewEmptyError().AppendDescF("modifyTime don't match, except:%d but:%d", recordModifyTime, modifyTime)
+	}
+	if modifyTime == recordModifyTime && !forceRehash {
+		return true, nil
+	}
+
+	// 修改时间较新或强制计算 hash，对比本地文件 hash 与上次上传的 hash
+	match, mErr := object.Match(object.MatchApiInfo{
+		Bucket:         uploadInfo.ToBucket,
+		Key:            uploadInfo.SaveKey,
+		LocalFile:      uploadInfo.FilePath,
+		CheckMode:      object.MatchCheckModeFileHash,
+		ServerFileHash: result.ServerFileHash,
+		UseEtagCache:   uploadInfo.UseEtagCache && !forceRehash,
+	})
+	if mErr != nil {
+		return false, mErr
+	}
+	if match == nil || !match.Match {
+		return false, data.NewEmptyError().AppendDesc("hash don't match with last upload")
+	}
+	log.DebugF("local file modifyTime changed but hash not change, %s", uploadInfo.FilePath)
+	return true, nil
+}
+
+// fileListTotalSize 统计上传文件列表中所有文件的总大小，文件列表每行的第二项为文件大小，无法统计时返回 0
+func fileListTotalSize(fileList string, itemSeparate string) int64 {
+	f, err := os.Open(fileList)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	totalSize := int64(0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		items := strings.Split(scanner.Text(), itemSeparate)
+		if len(items) < 2 {
+			continue
+		}
+		if size, pErr := strconv.ParseInt(items[1], 10, 64); pErr == nil {
+			totalSize += size
+		}
+	}
+	return totalSize
+}
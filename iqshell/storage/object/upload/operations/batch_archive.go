@@ -0,0 +1,289 @@
+package operations
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+const (
+	archiveTypeTar   = "tar"
+	archiveTypeTarGz = "tar.gz"
+	archiveTypeZip   = "zip"
+)
+
+// archiveTypeOfPath 根据扩展名获取压缩包的类型，支持 .tar、.tar.gz、.tgz、.zip
+func archiveTypeOfPath(archivePath string) (string, *data.CodeError) {
+	name := strings.ToLower(archivePath)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz"):
+		return archiveTypeTarGz, nil
+	case strings.HasSuffix(name, ".tar"):
+		return archiveTypeTar, nil
+	case strings.HasSuffix(name, ".zip"):
+		return archiveTypeZip, nil
+	default:
+		return "", alert.Error("unsupported archive: "+archivePath, "archive should be .tar, .tar.gz, .tgz or .zip")
+	}
+}
+
+// archiveEntry 压缩包中的文件，数据流只能读取一次
+type archiveEntry struct {
+	Name       string // 文件在压缩包中的路径
+	Size       int64
+	ModifyTime int64 // 单位：100ns
+
+	skipReason  string // 不为空时此文件不上传，如：目录、符号链接
+	opener      func() (io.ReadCloser, error)
+	reader      io.ReadCloser
+	opened      bool
+	done        chan struct{}
+	releaseOnce sync.Once
+}
+
+func newArchiveEntry(name string, size int64, modifyTime int64, opener func() (io.ReadCloser, error)) *archiveEntry {
+	return &archiveEntry{
+		Name:       strings.TrimLeft(path.Clean("/"+name), "/"),
+		Size:       size,
+		ModifyTime: modifyTime,
+		opener:     opener,
+		done:       make(chan struct{}),
+	}
+}
+
+// open 打开文件的数据流；数据流被读取后无法回退，因此不支持重试
+func (e *archiveEntry) open() (io.Reader, *data.CodeError) {
+	if e.opened {
+		return nil, data.NewEmptyError().AppendDescF("archive entry %s has been read, can't upload it again", e.Name)
+	}
+	e.opened = true
+
+	reader, err := e.opener()
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("open archive entry %s error:%v", e.Name, err)
+	}
+	e.reader = reader
+	return reader, nil
+}
+
+// release 文件处理完毕（成功、失败或跳过）后调用，关闭数据流；tar 包需等待上一个文件释放后才能读取下一个文件
+func (e *archiveEntry) release() {
+	e.releaseOnce.Do(func() {
+		if e.reader != nil {
+			_ = e.reader.Close()
+		}
+		close(e.done)
+	})
+}
+
+// releaseArchiveEntryOfWork 释放 work 对应的压缩包文件，非压缩包中的文件不做处理
+func releaseArchiveEntryOfWork(workInfo *flow.WorkInfo) {
+	if workInfo == nil {
+		return
+	}
+	if info, ok := workInfo.Work.(*UploadInfo); ok && info.archiveEntry != nil {
+		info.archiveEntry.release()
+	}
+}
+
+// archiveWorkProvider 逐个提供压缩包中的文件，不解压到本地：
+// zip 包中的文件可以随机读取，可并发上传；tar 包只能顺序读取，上一个文件释放后才会提供下一个文件。
+type archiveWorkProvider struct {
+	archivePath string
+	workCount   int64
+	sequential  bool
+	next        func() (*archiveEntry, error) // 没有更多文件时返回 io.EOF
+	closer      io.Closer
+	creator     func(entry *archiveEntry) (flow.Work, *data.CodeError)
+
+	last     *archiveEntry
+	finished bool
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+var _ flow.StoppableWorkProvider = (*archiveWorkProvider)(nil)
+
+func newArchiveWorkProvider(archivePath string, creator func(entry *archiveEntry) (flow.Work, *data.CodeError)) (*archiveWorkProvider, *data.CodeError) {
+	archiveType, err := archiveTypeOfPath(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &archiveWorkProvider{
+		archivePath: archivePath,
+		workCount:   flow.UnknownWorkCount,
+		creator:     creator,
+		stopChan:    make(chan struct{}),
+	}
+	var oErr error
+	if archiveType == archiveTypeZip {
+		oErr = p.openZip()
+	} else {
+		oErr = p.openTar(archiveType == archiveTypeTarGz)
+	}
+	if oErr != nil {
+		return nil, data.NewEmptyError().AppendDescF("open archive %s error:%v", archivePath, oErr)
+	}
+	return p, nil
+}
+
+func (p *archiveWorkProvider) openZip() error {
+	reader, err := zip.OpenReader(p.archivePath)
+	if err != nil {
+		return err
+	}
+
+	p.closer = reader
+	p.workCount = 0
+	index := 0
+	for _, f := range reader.File {
+		if zipEntrySkipReason(f) == "" {
+			p.workCount++
+		}
+	}
+	p.next = func() (*archiveEntry, error) {
+		if index >= len(reader.File) {
+			return nil, io.EOF
+		}
+		f := reader.File[index]
+		index++
+		entry := newArchiveEntry(f.Name, int64(f.UncompressedSize64), f.Modified.UnixNano()/100, f.Open)
+		entry.skipReason = zipEntrySkipReason(f)
+		return entry, nil
+	}
+	return nil
+}
+
+func zipEntrySkipReason(f *zip.File) string {
+	mode := f.Mode()
+	switch {
+	case mode.IsDir() || strings.HasSuffix(f.Name, "/"):
+		return "it is a directory"
+	case mode&os.ModeSymlink != 0:
+		return "it is a symlink"
+	case !mode.IsRegular():
+		return "it is not a regular file"
+	default:
+		return ""
+	}
+}
+
+func (p *archiveWorkProvider) openTar(isGzip bool) error {
+	file, err := os.Open(p.archivePath)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader = file
+	if isGzip {
+		gzipReader, gErr := gzip.NewReader(file)
+		if gErr != nil {
+			_ = file.Close()
+			return gErr
+		}
+		reader = gzipReader
+	}
+
+	p.closer = file
+	p.sequential = true
+	tarReader := tar.NewReader(reader)
+	p.next = func() (*archiveEntry, error) {
+		header, nErr := tarReader.Next()
+		if nErr != nil {
+			return nil, nErr
+		}
+		entry := newArchiveEntry(header.Name, header.Size, header.ModTime.UnixNano()/100, func() (io.ReadCloser, error) {
+			return io.NopCloser(tarReader), nil
+		})
+		entry.skipReason = tarEntrySkipReason(header)
+		return entry, nil
+	}
+	return nil
+}
+
+func tarEntrySkipReason(header *tar.Header) string {
+	switch header.Typeflag {
+	case tar.TypeReg:
+		return ""
+	case tar.TypeDir:
+		return "it is a directory"
+	case tar.TypeSymlink:
+		return "it is a symlink"
+	case tar.TypeLink:
+		return "it is a hard link"
+	default:
+		return "it is not a regular file"
+	}
+}
+
+func (p *archiveWorkProvider) WorkTotalCount() int64 {
+	return p.workCount
+}
+
+func (p *archiveWorkProvider) Provide() (hasMore bool, work *flow.WorkInfo, err *data.CodeError) {
+	for {
+		if p.finished {
+			return false, nil, nil
+		}
+
+		// tar 包只能顺序读取，需等待上一个文件处理完毕
+		if p.sequential && p.last != nil {
+			select {
+			case <-p.last.done:
+			case <-p.stopChan:
+				p.finished = true
+				return false, nil, nil
+			}
+		}
+		p.last = nil
+
+		entry, nErr := p.next()
+		if nErr == io.EOF {
+			p.finished = true
+			return false, nil, nil
+		} else if nErr != nil {
+			p.finished = true
+			return false, &flow.WorkInfo{Data: p.archivePath}, data.NewEmptyError().AppendDescF("read archive %s error:%v", p.archivePath, nErr)
+		}
+
+		if len(entry.Name) == 0 {
+			entry.skipReason = "it is a directory"
+		}
+		if len(entry.skipReason) > 0 {
+			log.InfoF("Skip archive entry `%s` because %s", entry.Name, entry.skipReason)
+			continue
+		}
+
+		w, cErr := p.creator(entry)
+		if cErr != nil {
+			entry.release()
+			return true, &flow.WorkInfo{Data: entry.Name}, cErr
+		}
+		p.last = entry
+		return true, &flow.WorkInfo{Data: entry.Name, Work: w}, nil
+	}
+}
+
+func (p *archiveWorkProvider) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopChan)
+	})
+}
+
+// Close 所有的文件均处理完毕后关闭压缩包
+func (p *archiveWorkProvider) Close() {
+	if p.closer != nil {
+		_ = p.closer.Close()
+	}
+}
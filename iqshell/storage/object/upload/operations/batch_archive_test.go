@@ -0,0 +1,185 @@
+package operations
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/upload"
+)
+
+func writeTestTarGz(t *testing.T, archivePath string) {
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal("create archive error:", err)
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	defer gw.Close()
+	tw := tar.NewWriter(gw)
+	defer tw.Close()
+
+	headers := []*tar.Header{
+		{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "./dir/a.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 3},
+		{Name: "dir/link", Typeflag: tar.TypeSymlink, Linkname: "a.txt"},
+		{Name: "b.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 5},
+	}
+	contents := map[string]string{"./dir/a.txt": "aaa", "b.txt": "bbbbb"}
+	for _, h := range headers {
+		h.ModTime = time.Now()
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal("write tar header error:", err)
+		}
+		if c, ok := contents[h.Name]; ok {
+			if _, err := tw.Write([]byte(c)); err != nil {
+				t.Fatal("write tar content error:", err)
+			}
+		}
+	}
+}
+
+func writeTestZip(t *testing.T, archivePath string) {
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal("create archive error:", err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	defer zw.Close()
+
+	if _, err := zw.Create("dir/"); err != nil {
+		t.Fatal("create zip dir error:", err)
+	}
+	for name, content := range map[string]string{"dir/a.txt": "aaa", "b.txt": "bbbbb"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal("create zip entry error:", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal("write zip entry error:", err)
+		}
+	}
+	link := &zip.FileHeader{Name: "dir/link"}
+	link.SetMode(os.ModeSymlink | 0777)
+	if w, err := zw.CreateHeader(link); err != nil {
+		t.Fatal("create zip symlink error:", err)
+	} else {
+		_, _ = w.Write([]byte("a.txt"))
+	}
+}
+
+func readArchiveEntries(t *testing.T, archivePath string) map[string]string {
+	provider, err := newArchiveWorkProvider(archivePath, func(entry *archiveEntry) (flow.Work, *data.CodeError) {
+		return &UploadInfo{
+			ApiInfo:      upload.ApiInfo{FilePath: filepath.Join(archivePath, entry.Name), SaveKey: entry.Name},
+			archiveEntry: entry,
+		}, nil
+	})
+	if err != nil {
+		t.Fatal("new archive work provider error:", err)
+	}
+	defer provider.Close()
+
+	contents := make(map[string]string)
+	for {
+		hasMore, workInfo, pErr := provider.Provide()
+		if pErr != nil {
+			t.Fatal("provide error:", pErr)
+		}
+		if !hasMore {
+			break
+		}
+		info := workInfo.Work.(*UploadInfo)
+		reader, oErr := info.archiveEntry.open()
+		if oErr != nil {
+			t.Fatal("open entry error:", oErr)
+		}
+		content, rErr := io.ReadAll(reader)
+		if rErr != nil {
+			t.Fatal("read entry error:", rErr)
+		}
+		if int64(len(content)) != info.archiveEntry.Size {
+			t.Fatalf("entry %s size should be %d but %d", info.SaveKey, info.archiveEntry.Size, len(content))
+		}
+		if _, oErr := info.archiveEntry.open(); oErr == nil {
+			t.Fatal("entry should not be opened twice")
+		}
+		contents[info.SaveKey] = string(content)
+		releaseArchiveEntryOfWork(workInfo)
+	}
+	return contents
+}
+
+func TestArchiveWorkProvider(t *testing.T) {
+	dir := t.TempDir()
+	tarPath := filepath.Join(dir, "test.tar.gz")
+	writeTestTarGz(t, tarPath)
+	zipPath := filepath.Join(dir, "test.zip")
+	writeTestZip(t, zipPath)
+
+	for _, archivePath := range []string{tarPath, zipPath} {
+		contents := readArchiveEntries(t, archivePath)
+		if len(contents) != 2 || contents["dir/a.txt"] != "aaa" || contents["b.txt"] != "bbbbb" {
+			t.Fatalf("%s entries error:%v", archivePath, contents)
+		}
+	}
+
+	if _, err := newArchiveWorkProvider(filepath.Join(dir, "test.rar"), nil); err == nil {
+		t.Fatal("rar should not be supported")
+	}
+}
+
+func TestArchiveWorkProviderStop(t *testing.T) {
+	tarPath := filepath.Join(t.TempDir(), "test.tar.gz")
+	writeTestTarGz(t, tarPath)
+	provider, err := newArchiveWorkProvider(tarPath, func(entry *archiveEntry) (flow.Work, *data.CodeError) {
+		return &UploadInfo{archiveEntry: entry}, nil
+	})
+	if err != nil {
+		t.Fatal("new archive work provider error:", err)
+	}
+	defer provider.Close()
+
+	if hasMore, _, _ := provider.Provide(); !hasMore {
+		t.Fatal("should have entry")
+	}
+
+	// 上一个文件未释放时 tar 包的 Provide 会阻塞，Stop 后返回
+	done := make(chan bool)
+	go func() {
+		hasMore, _, _ := provider.Provide()
+		done <- hasMore
+	}()
+	select {
+	case <-done:
+		t.Fatal("provide should wait for the last entry")
+	case <-time.After(100 * time.Millisecond):
+	}
+	provider.Stop()
+	if hasMore := <-done; hasMore {
+		t.Fatal("provide should end after stop")
+	}
+}
+
+func TestCheckSrcArchiveDisableResume(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "test.tar.gz")
+	writeTestTarGz(t, archivePath)
+
+	up := &UploadConfig{SrcArchive: archivePath}
+	if err := up.checkSrcArchive(); err != nil {
+		t.Fatal("archive should be valid, err:", err)
+	}
+
+	up.DisableResume = true
+	if err := up.checkSrcArchive(); err == nil {
+		t.Fatal("archive should not be uploaded with disable resume")
+	}
+}
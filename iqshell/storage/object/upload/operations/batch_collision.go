@@ -0,0 +1,143 @@
+package operations
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/upload"
+)
+
+const (
+	KeyCollisionModeError     = "error"     // 存在冲突时报错，不上传任何文件
+	KeyCollisionModeSkip      = "skip"      // 冲突的文件只上传输入中的第一个，其余的跳过
+	KeyCollisionModeOverwrite = "overwrite" // 仅输出警告，所有文件都上传，后上传的文件覆盖先上传的文件
+)
+
+// keyCollision 对应同一个 key 的多个本地文件
+type keyCollision struct {
+	Key   string
+	Paths []string // 本地文件的相对路径，按输入中的顺序排列
+}
+
+// findKeyCollisions 上传前读取整个待上传文件列表，按 UploadConfig.SaveKey 计算每个文件的 key，返回对应多个文件的 key；
+// 会被跳过的文件（包括被 filter 过滤的，如：--min-size）不参与检测，同一文件在列表中出现多次时不算冲突；内存占用和文件数成正比
+func findKeyCollisions(inputFile string, itemSeparate string, uploadConfig *UploadConfig, filter flow.Skipper) ([]*keyCollision, *data.CodeError) {
+	f, err := os.Open(inputFile)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("open file list:%s error:%v", inputFile, err)
+	}
+	defer f.Close()
+
+	paths := make(map[string]bool)
+	firstPaths := make(map[string]string)
+	collisionIndexes := make(map[string]int)
+	collisions := make([]*keyCollision, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		items := strings.Split(scanner.Text(), itemSeparate)
+		if len(items) < 3 || len(items[0]) == 0 {
+			continue
+		}
+		relativePath := items[0]
+		if paths[relativePath] || uploadConfig.isSkippedPath(relativePath) {
+			continue
+		}
+
+		key := uploadConfig.SaveKey(relativePath)
+		if filter != nil {
+			fileSize, _ := strconv.ParseInt(items[1], 10, 64)
+			if skip, _ := filter.ShouldSkip(&flow.WorkInfo{
+				Data: scanner.Text(),
+				Work: &UploadInfo{
+					ApiInfo: upload.ApiInfo{
+						FilePath:      filepath.Join(uploadConfig.SrcDir, relativePath),
+						ToBucket:      uploadConfig.Bucket,
+						SaveKey:       key,
+						LocalFileSize: fileSize,
+					},
+					RelativePathToSrcPath: relativePath,
+				},
+			}); skip {
+				continue
+			}
+		}
+		paths[relativePath] = true
+
+		firstPath, exist := firstPaths[key]
+		if !exist {
+			firstPaths[key] = relativePath
+			continue
+		}
+		if index, ok := collisionIndexes[key]; ok {
+			collisions[index].Paths = append(collisions[index].Paths, relativePath)
+		} else {
+			collisionIndexes[key] = len(collisions)
+			collisions = append(collisions, &keyCollision{
+				Key:   key,
+				Paths: []string{firstPath, relativePath},
+			})
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("read file list:%s error:%v", inputFile, err)
+	}
+	return collisions, nil
+}
+
+// isSkippedPath 文件是否会因 skip_path_prefixes 等配置被跳过
+func (up *UploadConfig) isSkippedPath(relativePath string) bool {
+	if hit, _ := up.HitByPathPrefixes(relativePath); hit {
+		return true
+	}
+	if hit, _ := up.HitByFilePrefixes(relativePath); hit {
+		return true
+	}
+	if hit, _ := up.HitByFixesString(relativePath); hit {
+		return true
+	}
+	hit, _ := up.HitBySuffixes(relativePath)
+	return hit
+}
+
+// checkKeyCollisions 按 OnCollision 处理 key 冲突，返回需要跳过的本地文件相对路径；
+// shouldContinue 为 false 时不应开始上传
+func checkKeyCollisions(inputFile string, itemSeparate string, uploadConfig *UploadConfig, filter flow.Skipper) (skippedPaths map[string]string, shouldContinue bool) {
+	collisions, err := findKeyCollisions(inputFile, itemSeparate, uploadConfig, filter)
+	if err != nil {
+		log.ErrorF("check key collisions error:%v", err)
+		return nil, false
+	}
+	if len(collisions) == 0 {
+		log.Info("check key collisions, no collision found")
+		return nil, true
+	}
+
+	skippedPaths = make(map[string]string)
+	for _, collision := range collisions {
+		log.WarningF("key collision, %d local files will be uploaded to key:%s, files:%s",
+			len(collision.Paths), collision.Key, strings.Join(collision.Paths, ", "))
+		for _, path := range collision.Paths[1:] {
+			skippedPaths[path] = collision.Key
+		}
+	}
+
+	switch uploadConfig.OnCollision {
+	case KeyCollisionModeError:
+		log.ErrorF("check key collisions, %d keys collide, nothing is uploaded, please fix the key prefix or ignore_dir config", len(collisions))
+		return nil, false
+	case KeyCollisionModeSkip:
+		log.WarningF("check key collisions, %d keys collide, only the first file of each key is uploaded, %d files will be skipped",
+			len(collisions), len(skippedPaths))
+		return skippedPaths, true
+	default:
+		log.WarningF("check key collisions, %d keys collide, the later uploaded file may overwrite the earlier one", len(collisions))
+		return nil, true
+	}
+}
@@ -0,0 +1,91 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+)
+
+func TestFindKeyCollisions(t *testing.T) {
+	inputFile := filepath.Join(t.TempDir(), "input.txt")
+	content := "a/x.jpg\t1\t1\nb/x.jpg\t1\t1\nc/y.jpg\t1\t1\ntmp/x.jpg\t1\t1\nd/x.jpg\t1\t1\n"
+	if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	uploadConfig := &UploadConfig{IgnoreDir: true, KeyPrefix: "img/", SkipPathPrefixes: "tmp/"}
+	collisions, err := findKeyCollisions(inputFile, "\t", uploadConfig, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collisions) != 1 {
+		t.Fatalf("collisions should be 1, but:%d", len(collisions))
+	}
+	if collisions[0].Key != "img/x.jpg" || len(collisions[0].Paths) != 3 ||
+		collisions[0].Paths[0] != "a/x.jpg" || collisions[0].Paths[2] != "d/x.jpg" {
+		t.Fatalf("collision error:%+v", collisions[0])
+	}
+
+	uploadConfig = &UploadConfig{KeyPrefix: "img/"}
+	if collisions, err = findKeyCollisions(inputFile, "\t", uploadConfig, nil); err != nil {
+		t.Fatal(err)
+	} else if len(collisions) != 0 {
+		t.Fatalf("collisions should be empty, but:%d", len(collisions))
+	}
+}
+
+func TestFindKeyCollisionsDuplicateAndFilter(t *testing.T) {
+	inputFile := filepath.Join(t.TempDir(), "input.txt")
+	content := "a/x.jpg\t10\t1\na/x.jpg\t10\t1\nb/x.jpg\t1\t1\nc/y.jpg\t10\t1\n"
+	if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一文件出现多次不算冲突
+	uploadConfig := &UploadConfig{KeyPrefix: "img/"}
+	collisions, err := findKeyCollisions(inputFile, "\t", uploadConfig, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(collisions) != 0 {
+		t.Fatalf("collisions should be empty, but:%+v", collisions[0])
+	}
+
+	uploadConfig = &UploadConfig{IgnoreDir: true, KeyPrefix: "img/"}
+	if collisions, err = findKeyCollisions(inputFile, "\t", uploadConfig, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(collisions) != 1 || len(collisions[0].Paths) != 2 ||
+		collisions[0].Paths[0] != "a/x.jpg" || collisions[0].Paths[1] != "b/x.jpg" {
+		t.Fatalf("collision error:%+v", collisions)
+	}
+
+	// 被 --min-size 过滤的文件不参与检测
+	filter, fErr := (&flow.Info{MinSize: "5"}).FilterSkipper()
+	if fErr != nil {
+		t.Fatal(fErr)
+	}
+	if collisions, err = findKeyCollisions(inputFile, "\t", uploadConfig, filter); err != nil {
+		t.Fatal(err)
+	} else if len(collisions) != 0 {
+		t.Fatalf("collisions should be empty, but:%+v", collisions[0])
+	}
+
+	// 第一个文件被 --max-size 过滤时，后面的文件作为第一个文件
+	filter, fErr = (&flow.Info{MaxSize: "5"}).FilterSkipper()
+	if fErr != nil {
+		t.Fatal(fErr)
+	}
+	content += "d/x.jpg\t1\t1\n"
+	if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if collisions, err = findKeyCollisions(inputFile, "\t", uploadConfig, filter); err != nil {
+		t.Fatal(err)
+	}
+	if len(collisions) != 1 || len(collisions[0].Paths) != 2 ||
+		collisions[0].Paths[0] != "b/x.jpg" || collisions[0].Paths[1] != "d/x.jpg" {
+		t.Fatalf("collision error:%+v", collisions)
+	}
+}
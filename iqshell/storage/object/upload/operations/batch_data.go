@@ -8,7 +8,9 @@ import (
 
 	"github.com/qiniu/qshell/v2/iqshell/common/alert"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
 	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/upload"
 )
 
 type UploadConfig struct {
@@ -17,32 +19,37 @@ type UploadConfig struct {
 	BindRsIp  string `json:"bind_rs_ip,omitempty"`
 	BindNicIp string `json:"bind_nic_ip,omitempty"` //local network interface card config
 
-	SrcDir                 string `json:"src_dir,omitempty"`
-	FileList               string `json:"file_list,omitempty"`
-	IgnoreDir              bool   `json:"ignore_dir,omitempty"`
-	SkipFilePrefixes       string `json:"skip_file_prefixes,omitempty"`
-	SkipPathPrefixes       string `json:"skip_path_prefixes,omitempty"`
-	SkipFixedStrings       string `json:"skip_fixed_strings,omitempty"`
-	SkipSuffixes           string `json:"skip_suffixes,omitempty"`
-	FileEncoding           string `json:"file_encoding,omitempty"`
-	Bucket                 string `json:"bucket,omitempty"`
-	ResumableAPIV2         bool   `json:"resumable_api_v2,omitempty"`
-	ResumableAPIV2PartSize int64  `json:"resumable_api_v2_part_size,omitempty"`
-	PutThreshold           int64  `json:"put_threshold,omitempty"`
-	KeyPrefix              string `json:"key_prefix,omitempty"`
-	Overwrite              bool   `json:"overwrite,omitempty"`
-	CheckExists            bool   `json:"check_exists,omitempty"`
-	CheckHash              bool   `json:"check_hash,omitempty"`
-	CheckSize              bool   `json:"check_size,omitempty"`
-	RescanLocal            bool   `json:"rescan_local,omitempty"`
-	FileType               int    `json:"file_type,omitempty"`
-	DeleteOnSuccess        bool   `json:"delete_on_success,omitempty"`
-	DisableResume          bool   `json:"disable_resume,omitempty"`
-	DisableForm            bool   `json:"disable_form,omitempty"`
-	WorkerCount            int    `json:"work_count,omitempty"` // 分片上传并发数
-	RecordRoot             string `json:"record_root,omitempty"`
-	SequentialReadFile     bool   `json:"sequential_read_file"`   // 文件顺序读
-	Accelerate             bool   `json:"uploading_acceleration"` // 开启上传加速
+	SrcDir                 string   `json:"src_dir,omitempty"`
+	SrcGlobs               []string `json:"src_globs,omitempty"` // 只上传 SrcDir 下匹配这些 glob 的文件，glob 相对于 SrcDir，支持 `**`
+	FileList               string   `json:"file_list,omitempty"`
+	SrcArchive             string   `json:"src_archive,omitempty"` // 上传压缩包中的文件，不解压到本地，支持 .tar、.tar.gz、.tgz、.zip；和 SrcDir 不能同时设置
+	IgnoreDir              bool     `json:"ignore_dir,omitempty"`
+	SkipFilePrefixes       string   `json:"skip_file_prefixes,omitempty"`
+	SkipPathPrefixes       string   `json:"skip_path_prefixes,omitempty"`
+	SkipFixedStrings       string   `json:"skip_fixed_strings,omitempty"`
+	SkipSuffixes           string   `json:"skip_suffixes,omitempty"`
+	FileEncoding           string   `json:"file_encoding,omitempty"`
+	Bucket                 string   `json:"bucket,omitempty"`
+	ResumableAPIV2         bool     `json:"resumable_api_v2,omitempty"`
+	ResumableAPIV2PartSize int64    `json:"resumable_api_v2_part_size,omitempty"`
+	PutThreshold           int64    `json:"put_threshold,omitempty"`
+	PartSize               string   `json:"part_size,omitempty"`           // 分片大小，如 16m，设置时使用分片 v2 上传，优先级高于 resumable_api_v2_part_size
+	MultipartThreshold     string   `json:"multipart_threshold,omitempty"` // 使用分片上传的文件大小阈值，如 32m，优先级高于 put_threshold
+	KeyPrefix              string   `json:"key_prefix,omitempty"`
+	Overwrite              bool     `json:"overwrite,omitempty"`
+	CheckExists            bool     `json:"check_exists,omitempty"`
+	CheckHash              bool     `json:"check_hash,omitempty"`
+	CheckSize              bool     `json:"check_size,omitempty"`
+	RescanLocal            bool     `json:"rescan_local,omitempty"`
+	FileType               int      `json:"file_type,omitempty"`
+	DeleteOnSuccess        bool     `json:"delete_on_success,omitempty"`
+	DisableResume          bool     `json:"disable_resume,omitempty"`
+	DisableForm            bool     `json:"disable_form,omitempty"`
+	WorkerCount            int      `json:"work_count,omitempty"`       // 分片上传并发数，同 part_concurrency
+	PartConcurrency        int      `json:"part_concurrency,omitempty"` // 单个文件分片上传的并发数，优先级高于 work_count
+	RecordRoot             string   `json:"record_root,omitempty"`
+	SequentialReadFile     bool     `json:"sequential_read_file"`   // 文件顺序读
+	Accelerate             bool     `json:"uploading_acceleration"` // 开启上传加速
 
 	// 唯一属主标识。特殊场景下非常有用，例如根据 App-Client 标识给图片或视频打水印。
 	EndUser string `json:"end_user,omitempty"`
@@ -97,12 +104,47 @@ type UploadConfig struct {
 	// 设为 -1 时：无论上传端指定了何值直接使用该值。
 	DetectMime int `json:"detect_mime,omitempty"`
 
+	// 根据文件扩展名设置文件的 MimeType，已指定 MimeType 的文件不受影响
+	MimeTypeFromExtension bool `json:"mimetype_from_extension,omitempty"`
+
+	// 扩展名和 MimeType 的映射表文件，JSON 格式，如：{".md": "text/markdown"}；优先级高于系统的映射，开启 mimetype_from_extension 时有效
+	MimeTypeTableFile string `json:"mimetype_table_file,omitempty"`
+
 	CallbackFetchKey uint8 `json:"callback_fetch_key,omitempty"`
 
 	DeleteAfterDays int `json:"delete_after_days,omitempty"`
 
 	// 上传单链接限速，单位：bit/s；范围：819200 - 838860800（即800Kb/s - 800Mb/s），如果超出该范围将返回 400 错误
 	TrafficLimit uint64 `json:"traffic_limit,omitempty"`
+
+	// 本地上传总带宽限制，所有线程共享，如：512k、5m，单位：B/s；为空时不限制
+	RateLimit string `json:"rate_limit,omitempty"`
+
+	// 文件的元数据，如：{"Content-Type": "text/html", "Cache-Control": "max-age=3600"}；Content-Type 作为文件的 MimeType，
+	// 其他的作为自定义元数据（x-qn-meta-<Name>）保存，key 只能包含字母、数字、- 和 _
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// 按文件相对路径匹配的元数据，匹配的规则中的元数据会覆盖 Metadata 中同名的元数据，后面的规则优先级更高
+	MetadataRules []UploadMetadataRule `json:"metadata_rules,omitempty"`
+
+	// 再次上传时，即使本地文件的修改时间和大小与上次上传时一致，也重新计算 hash 与上次上传结果对比，以检测文件是否有变化
+	ForceRehash bool `json:"force_rehash,omitempty"`
+
+	// 不使用本地文件 etag 的缓存；默认会在工作目录下缓存本地文件的 etag，文件的大小和修改时间未变化时不再重新计算
+	NoHashCache bool `json:"no_hash_cache,omitempty"`
+
+	// 分片上传断点记录的共享目录，如多台机器挂载的同一网络存储；设置后断点记录按 bucket、key 及文件 hash 保存在此目录，
+	// 任意机器上传同一文件时都可以续传；为空时不记录单个文件的分片上传进度
+	ResumeRecordDir string `json:"resume_record_dir,omitempty"`
+
+	// 上传前检测多个本地文件是否对应同一个 key（如开启 ignore_dir 后不同目录下的同名文件），参考 KeyCollisionMode；
+	// 为空时不检测
+	OnCollision string `json:"on_collision,omitempty"`
+}
+
+type UploadMetadataRule struct {
+	Glob     string            `json:"glob"`     // 匹配文件相对路径的 glob，规则同 src_globs，如：**/*.html
+	Metadata map[string]string `json:"metadata"` // 匹配的文件的元数据
 }
 
 func DefaultUploadConfig() UploadConfig {
@@ -138,6 +180,28 @@ func DefaultUploadConfig() UploadConfig {
 	}
 }
 
+// SaveKey 本地文件上传后的 key，由文件相对路径及 ignore_dir、key_prefix、file_encoding 确定
+func (up *UploadConfig) SaveKey(fileRelativePath string) string {
+	key := fileRelativePath
+	//check ignore dir
+	if up.IsIgnoreDir() {
+		key = filepath.Base(key)
+	}
+	//check prefix
+	if data.NotEmpty(up.KeyPrefix) {
+		key = strings.Join([]string{up.KeyPrefix, key}, "")
+	}
+	//convert \ to / under windows
+	if utils.IsWindowsOS() {
+		key = strings.Replace(key, "\\", "/", -1)
+	}
+	//check file encoding
+	if data.NotEmpty(up.FileEncoding) && utils.IsGBKEncoding(up.FileEncoding) {
+		key, _ = utils.Gbk2Utf8(key)
+	}
+	return key
+}
+
 func (up *UploadConfig) IsIgnoreDir() bool {
 	return up.IgnoreDir
 }
@@ -179,10 +243,17 @@ func (up *UploadConfig) IsDisableForm() bool {
 }
 
 func (up *UploadConfig) JobId() string {
+	if len(up.SrcArchive) > 0 {
+		return utils.Md5Hex(fmt.Sprintf("archive:%s:%s", up.SrcArchive, up.Bucket))
+	}
 	return utils.Md5Hex(fmt.Sprintf("%s:%s:%s", up.SrcDir, up.Bucket, up.FileList))
 }
 
 func (up *UploadConfig) Check() *data.CodeError {
+	if err := up.checkPartSize(); err != nil {
+		return err
+	}
+
 	// 验证大小
 	if up.ResumableAPIV2PartSize == 0 {
 		up.ResumableAPIV2PartSize = data.BLOCK_SIZE
@@ -192,10 +263,23 @@ func (up *UploadConfig) Check() *data.CodeError {
 		up.ResumableAPIV2PartSize = utils.GB
 	}
 
+	if len(up.OnCollision) > 0 && up.OnCollision != KeyCollisionModeError &&
+		up.OnCollision != KeyCollisionModeSkip && up.OnCollision != KeyCollisionModeOverwrite {
+		return alert.Error("on_collision should be one of error, skip and overwrite", "")
+	}
+
 	if len(up.Bucket) == 0 {
 		return alert.CannotEmptyError("Bucket", "")
 	}
 
+	if len(up.SrcArchive) > 0 {
+		if err := up.checkSrcArchive(); err != nil {
+			return err
+		}
+		up.checkCallback()
+		return up.checkMetadata()
+	}
+
 	if len(up.SrcDir) == 0 {
 		return alert.CannotEmptyError("SrcDir", "")
 	}
@@ -209,6 +293,10 @@ func (up *UploadConfig) Check() *data.CodeError {
 		return data.NewEmptyError().AppendDescF("SrcDir should be a directory: %s", up.SrcDir)
 	}
 
+	if len(up.FileList) > 0 && len(up.SrcGlobs) > 0 {
+		return alert.Error("FileList and SrcGlobs can't be set at the same time", "")
+	}
+
 	if len(up.FileList) > 0 {
 		fileListInfo, err := os.Stat(up.FileList)
 		if err != nil {
@@ -220,6 +308,108 @@ func (up *UploadConfig) Check() *data.CodeError {
 		}
 	}
 
+	up.checkCallback()
+	return up.checkMetadata()
+}
+
+// checkPartSize 解析 part_size 和 multipart_threshold；分片 v1 的块大小固定为 4M，因此设置分片大小时使用分片 v2 上传
+func (up *UploadConfig) checkPartSize() *data.CodeError {
+	if len(up.PartSize) > 0 {
+		partSize, err := utils.ParseFileSize(up.PartSize)
+		if err != nil {
+			return alert.Error("invalid part size: "+up.PartSize, "part size should be like 4m, 16m")
+		}
+		if err = upload.CheckPartSize(partSize); err != nil {
+			return err
+		}
+		up.ResumableAPIV2 = true
+		up.ResumableAPIV2PartSize = partSize
+	}
+
+	if len(up.MultipartThreshold) > 0 {
+		threshold, err := utils.ParseFileSize(up.MultipartThreshold)
+		if err != nil {
+			return alert.Error("invalid multipart threshold: "+up.MultipartThreshold, "multipart threshold should be like 8m, 32m")
+		}
+		up.PutThreshold = threshold
+	}
+	return nil
+}
+
+func (up *UploadConfig) checkMetadata() *data.CodeError {
+	if _, _, err := upload.NormalizeMetadata(up.Metadata); err != nil {
+		return err
+	}
+	for _, rule := range up.MetadataRules {
+		if len(rule.Glob) == 0 {
+			return alert.CannotEmptyError("glob of metadata rule", "")
+		}
+		if _, err := filepath.Match(rule.Glob, ""); err != nil {
+			return data.NewEmptyError().AppendDescF("invalid glob of metadata rule:%s", rule.Glob).AppendError(err)
+		}
+		if _, _, err := upload.NormalizeMetadata(rule.Metadata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MetadataOf 获取文件的元数据，Metadata 和匹配的 MetadataRules 合并后的结果
+func (up *UploadConfig) MetadataOf(relativePath string) map[string]string {
+	if len(up.Metadata) == 0 && len(up.MetadataRules) == 0 {
+		return nil
+	}
+
+	// 元数据的 key 不区分大小写，且可以省略 x-qn-meta- 前缀
+	metadata := make(map[string]string)
+	keys := make(map[string]string)
+	merge := func(m map[string]string) {
+		for key, value := range m {
+			name := strings.TrimPrefix(strings.ToLower(key), upload.MetadataPrefix)
+			if old, ok := keys[name]; ok {
+				delete(metadata, old)
+			}
+			keys[name] = key
+			metadata[key] = value
+		}
+	}
+	merge(up.Metadata)
+	for _, rule := range up.MetadataRules {
+		if utils.GlobMatch(rule.Glob, relativePath) {
+			merge(rule.Metadata)
+		}
+	}
+	return metadata
+}
+
+func (up *UploadConfig) checkSrcArchive() *data.CodeError {
+	if len(up.SrcDir) > 0 || len(up.FileList) > 0 || len(up.SrcGlobs) > 0 {
+		return alert.Error("SrcArchive can't be set with SrcDir, FileList or SrcGlobs", "")
+	}
+
+	archiveInfo, err := os.Stat(up.SrcArchive)
+	if err != nil {
+		return data.NewEmptyError().AppendDesc("invalid SrcArchive:" + err.Error())
+	}
+	if archiveInfo.IsDir() {
+		return data.NewEmptyError().AppendDescF("SrcArchive should be a file: %s", up.SrcArchive)
+	}
+	if _, tErr := archiveTypeOfPath(up.SrcArchive); tErr != nil {
+		return tErr
+	}
+	if up.DisableResume {
+		// 压缩包中的文件以数据流的方式上传，表单上传需要把整个文件读入内存
+		return alert.Error("SrcArchive can't be set with DisableResume", "files in archive are always uploaded by resumable upload")
+	}
+
+	if up.DeleteOnSuccess {
+		up.DeleteOnSuccess = false
+		log.Warning("delete_on_success is ignored when uploading from archive")
+	}
+	return nil
+}
+
+func (up *UploadConfig) checkCallback() {
 	if up.CallbackURL != "" {
 		callbackUrls := strings.Replace(up.CallbackURL, ",", ";", -1)
 		up.CallbackURL = callbackUrls
@@ -230,8 +420,6 @@ func (up *UploadConfig) Check() *data.CodeError {
 			up.CallbackBodyType = "application/x-www-form-urlencoded"
 		}
 	}
-
-	return nil
 }
 
 func (up *UploadConfig) HitByPathPrefixes(localFileRelativePath string) (hit bool, pathPrefix string) {
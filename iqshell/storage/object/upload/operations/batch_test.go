@@ -0,0 +1,104 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/upload"
+)
+
+func TestIsLocalFileNotChangeSinceLastUpload(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(filePath, []byte("0123456789"), 0644); err != nil {
+		t.Fatal("write file error:", err)
+	}
+	modifyTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(filePath, modifyTime, modifyTime); err != nil {
+		t.Fatal("change file time error:", err)
+	}
+	hash, err := utils.GetEtag(filePath)
+	if err != nil {
+		t.Fatal("get etag error:", err)
+	}
+
+	uploadInfo := &UploadInfo{ApiInfo: upload.ApiInfo{FilePath: filePath}}
+	record := &UploadInfo{ApiInfo: upload.ApiInfo{
+		FilePath:            filePath,
+		LocalFileSize:       10,
+		LocalFileModifyTime: modifyTime.Unix() * 10000000,
+	}}
+	result := &upload.ApiResult{ServerFileHash: hash}
+
+	if notChange, _ := isLocalFileNotChangeSinceLastUpload(uploadInfo, record, result, false); !notChange {
+		t.Fatal("file should not change when size and modify time are same")
+	}
+
+	// 修改时间较新但 hash 一致，视为未变化
+	newer := modifyTime.Add(time.Minute)
+	if err := os.Chtimes(filePath, newer, newer); err != nil {
+		t.Fatal("change file time error:", err)
+	}
+	if notChange, e := isLocalFileNotChangeSinceLastUpload(uploadInfo, record, result, false); !notChange {
+		t.Fatal("file should not change when hash is same:", e)
+	}
+
+	// hash 不一致，视为有变化
+	if notChange, _ := isLocalFileNotChangeSinceLastUpload(uploadInfo, record, &upload.ApiResult{ServerFileHash: "FmDZwqadA4-ib_15hYfQ68i_bq6G"}, true); notChange {
+		t.Fatal("file should change when hash is different")
+	}
+
+	// 大小不一致，视为有变化
+	record.LocalFileSize = 11
+	if notChange, _ := isLocalFileNotChangeSinceLastUpload(uploadInfo, record, result, false); notChange {
+		t.Fatal("file should change when size is different")
+	}
+}
+
+func TestUploadConfigMetadataOf(t *testing.T) {
+	cfg := UploadConfig{
+		Metadata: map[string]string{"Cache-Control": "max-age=3600", "x-qn-meta-Owner": "a"},
+		MetadataRules: []UploadMetadataRule{
+			{Glob: "**/*.html", Metadata: map[string]string{"cache-control": "no-cache"}},
+			{Glob: "static/*", Metadata: map[string]string{"Owner": "b"}},
+		},
+	}
+	if err := cfg.checkMetadata(); err != nil {
+		t.Fatal("check metadata error:", err)
+	}
+
+	metadata := cfg.MetadataOf("static/index.html")
+	if len(metadata) != 2 || metadata["cache-control"] != "no-cache" || metadata["Owner"] != "b" {
+		t.Fatal("metadata of static/index.html error:", metadata)
+	}
+	metadata = cfg.MetadataOf("img/a.png")
+	if len(metadata) != 2 || metadata["Cache-Control"] != "max-age=3600" || metadata["x-qn-meta-Owner"] != "a" {
+		t.Fatal("metadata of img/a.png error:", metadata)
+	}
+
+	cfg.MetadataRules = append(cfg.MetadataRules, UploadMetadataRule{Glob: "[", Metadata: map[string]string{"a": "b"}})
+	if err := cfg.checkMetadata(); err == nil {
+		t.Fatal("invalid glob should be rejected")
+	}
+}
+
+func TestPartWorkerCount(t *testing.T) {
+	cases := []struct {
+		partConcurrency int
+		info            flow.Info
+		want            int
+	}{
+		{partConcurrency: 3, info: flow.Info{WorkerCount: 2}, want: 6},
+		{partConcurrency: 3, info: flow.Info{WorkerCount: 2, MaxWorkerCount: 5}, want: 15},
+		{partConcurrency: 0, info: flow.Info{WorkerCount: 4}, want: 4},
+		{partConcurrency: 2, info: flow.Info{}, want: 2},
+	}
+	for _, c := range cases {
+		if got := partWorkerCount(c.partConcurrency, c.info); got != c.want {
+			t.Fatalf("partWorkerCount(%d, %+v) should be %d but %d", c.partConcurrency, c.info, c.want, got)
+		}
+	}
+}
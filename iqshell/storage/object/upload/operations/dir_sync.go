@@ -0,0 +1,295 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qiniu/qshell/v2/iqshell"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/export"
+	"github.com/qiniu/qshell/v2/iqshell/common/flow"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/batch"
+	objectOperations "github.com/qiniu/qshell/v2/iqshell/storage/object/operations"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/upload"
+)
+
+const (
+	DirSyncActionUpload = "upload" // 上传仅本地存在或与空间中不一致的文件
+	DirSyncActionDelete = "delete" // 删除仅空间中存在的文件
+	DirSyncActionSkip   = "skip"   // 两端一致，或未开启删除时仅空间中存在的文件
+)
+
+type DirSyncInfo struct {
+	flow.Info
+	export.FileExporterConfig
+	objectOperations.DiffSource
+
+	Delete       bool // 是否删除空间中存在但本地不存在的文件
+	DryRun       bool // 仅输出将要执行的操作，不实际执行
+	EnableRecord bool // 是否记录执行进度，再次执行时跳过已成功的操作
+}
+
+func (info *DirSyncInfo) Check() *data.CodeError {
+	if err := info.DiffSource.Check(); err != nil {
+		return err
+	}
+
+	if info.DryRun || !info.Delete {
+		info.Force = true
+	}
+	return info.Info.Check()
+}
+
+// DirSyncWork 对比结果相同的 work 的 id 相同，本地文件或空间文件变化后 id 随之变化，以便通过执行记录跳过已完成的操作
+type DirSyncWork struct {
+	objectOperations.DiffWork
+}
+
+func (w *DirSyncWork) WorkId() string {
+	remoteHash := ""
+	if w.Remote != nil {
+		remoteHash = w.Remote.Hash
+	}
+	return utils.Md5Hex(fmt.Sprintf("%s:%s:%d:%d:%s", w.Key, w.LocalFile, w.LocalSize, w.LocalModifyTime, remoteHash))
+}
+
+type DirSyncResult struct {
+	Action string `json:"action"`
+	Key    string `json:"key"`
+	Flag   string `json:"flag"` // 对比的结果，参考 bucketdiff
+}
+
+func (r *DirSyncResult) IsValid() bool {
+	return r != nil && len(r.Action) > 0
+}
+
+// DirSync 将本地目录同步到空间的指定前缀下：上传仅本地存在或与空间中不一致的文件，开启删除时删除仅空间中存在的文件
+func DirSync(cfg *iqshell.Config, info DirSyncInfo) {
+	cfg.JobPathBuilder = func(cmdPath string) string {
+		localDir, _ := filepath.Abs(info.LocalDir)
+		jobId := utils.Md5Hex(fmt.Sprintf("%s:%s:%s:%s", cfg.CmdCfg.CmdId, localDir, info.Bucket, info.Prefix))
+		return filepath.Join(cmdPath, jobId)
+	}
+	if shouldContinue := iqshell.CheckAndLoad(cfg, iqshell.CheckAndLoadInfo{
+		Checker: &info,
+	}); !shouldContinue {
+		return
+	}
+
+	exporter, err := export.NewFileExport(info.FileExporterConfig)
+	if err != nil {
+		log.Error(err)
+		data.SetCmdStatusError()
+		return
+	}
+	defer exporter.Close()
+
+	mac, err := workspace.GetMac()
+	if err != nil {
+		data.SetCmdStatusError()
+		log.Error("get mac error:" + err.Error())
+		return
+	}
+
+	workspace.AddCancelObserver(func(s os.Signal) {
+		object.FlushEtagCache()
+	})
+	defer object.FlushEtagCache()
+
+	dbPath := filepath.Join(workspace.GetJobDir(), ".recorder")
+	if info.EnableRecord {
+		log.DebugF("dir sync recorder:%s", dbPath)
+	} else {
+		log.Debug("dir sync recorder:Not Enable")
+	}
+
+	uploadConfig := DefaultUploadConfig()
+	metric := &batch.Metric{}
+	metric.Start()
+	// 本地无法读取的文件及目录，空间中对应的文件不能删除
+	var localFailedKeysLock sync.Mutex
+	var localFailedKeys []string
+	isLocalFailed := func(key string) bool {
+		localFailedKeysLock.Lock()
+		defer localFailedKeysLock.Unlock()
+		for _, failedKey := range localFailedKeys {
+			if strings.HasPrefix(key, failedKey) {
+				return true
+			}
+		}
+		return false
+	}
+	diffWorks, listError := objectOperations.DiffWorks(info.DiffSource, func(key string, err *data.CodeError) {
+		localFailedKeysLock.Lock()
+		localFailedKeys = append(localFailedKeys, key)
+		localFailedKeysLock.Unlock()
+		metric.AddFailureCount(1)
+		exporter.Fail().ExportF("%s%s%v", key, flow.ErrorSeparate, err)
+		log.ErrorF("Dir Sync Failed, %s, Error: %v", key, err)
+	})
+	works := make(chan flow.Work)
+	go func() {
+		defer close(works)
+		for work := range diffWorks {
+			works <- &DirSyncWork{DiffWork: *work.(*objectOperations.DiffWork)}
+		}
+	}()
+
+	flow.New(info.Info).
+		WorkProviderWithChan(works).
+		WorkerProvider(flow.NewWorkerProvider(func() (flow.Worker, *data.CodeError) {
+			return flow.NewSimpleWorker(func(workInfo *flow.WorkInfo) (flow.Result, *data.CodeError) {
+				work := workInfo.Work.(*DirSyncWork)
+				diffResult, cErr := objectOperations.CompareDiffWork(info.Bucket, &work.DiffWork, false)
+				if cErr != nil {
+					return nil, cErr
+				}
+
+				result := &DirSyncResult{
+					Action: DirSyncActionSkip,
+					Key:    work.Key,
+					Flag:   diffResult.Flag,
+				}
+				switch diffResult.Flag {
+				case objectOperations.DiffFlagLocalOnly, objectOperations.DiffFlagModified:
+					result.Action = DirSyncActionUpload
+				case objectOperations.DiffFlagRemoteOnly:
+					if info.Delete {
+						result.Action = DirSyncActionDelete
+					}
+				}
+				if info.DryRun || result.Action == DirSyncActionSkip {
+					return result, nil
+				}
+
+				if result.Action == DirSyncActionDelete {
+					if isLocalFailed(work.Key) {
+						return nil, data.NewEmptyError().AppendDesc("local file or directory can't be read, skip deleting")
+					}
+					deleteResult, dErr := object.Delete(&object.DeleteApiInfo{
+						Bucket: info.Bucket,
+						Key:    work.Key,
+					})
+					if dErr != nil {
+						return nil, dErr
+					}
+					if !deleteResult.IsSuccess() {
+						return nil, data.NewError(deleteResult.Code, deleteResult.ErrorDescription())
+					}
+					return result, nil
+				}
+
+				uploadInfo := &UploadInfo{
+					ApiInfo: upload.ApiInfo{
+						FilePath:            work.LocalFile,
+						ToBucket:            info.Bucket,
+						SaveKey:             work.Key,
+						Overwrite:           true,
+						UseEtagCache:        true,
+						TryTimes:            3,
+						TryInterval:         500 * time.Millisecond,
+						LocalFileSize:       work.LocalSize,
+						LocalFileModifyTime: work.LocalModifyTime / 100, // LocalFileModifyTime 单位是 100ns
+						UseResumeV2:         uploadConfig.ResumableAPIV2,
+						ChunkSize:           uploadConfig.ResumableAPIV2PartSize,
+						PutThreshold:        uploadConfig.PutThreshold,
+						ResumeWorkerCount:   partWorkerCount(uploadConfig.WorkerCount, info.Info),
+						CacheDir:            workspace.GetJobDir(),
+					},
+				}
+				uploadInfo.TokenProvider = createTokenProviderWithMac(mac, uploadInfo)
+				if _, uErr := uploadFile(uploadInfo); uErr != nil {
+					return nil, uErr
+				}
+				return result, nil
+			}), nil
+		})).
+		SetOverseerEnable(info.EnableRecord).
+		SetUserConfirm(func(workTotalCount int64) bool {
+			// 删除文件需要用户输入空间名确认
+			return flow.UserNameVerification(info.Bucket)
+		}).
+		SetOverseerReadOnly(info.DryRun).
+		SetDBOverseer(dbPath, func() *flow.WorkRecord {
+			return &flow.WorkRecord{
+				WorkInfo: &flow.WorkInfo{
+					Data: "",
+					Work: &DirSyncWork{},
+				},
+				Result: &DirSyncResult{},
+				Err:    nil,
+			}
+		}).
+		ShouldRedo(func(workInfo *flow.WorkInfo, workRecord *flow.WorkRecord) (shouldRedo bool, cause *data.CodeError) {
+			if workRecord.Err == nil {
+				return false, nil
+			}
+			return true, workRecord.Err
+		}).
+		OnWorkSkip(func(workInfo *flow.WorkInfo, result flow.Result, err *data.CodeError) {
+			metric.AddSkippedCount(1)
+			key := workInfo.Data
+			if work, ok := workInfo.Work.(*DirSyncWork); ok {
+				key = work.Key
+			}
+			if err != nil && err.Code == data.ErrorCodeAlreadyDone {
+				log.InfoF("Skip key:%s because have done and success", key)
+			} else {
+				log.InfoF("Skip key:%s because:%v", key, err)
+			}
+		}).
+		OnWorkSuccess(func(workInfo *flow.WorkInfo, result flow.Result, stat *flow.WorkStat) {
+			r, _ := result.(*DirSyncResult)
+			if r == nil {
+				return
+			}
+			if r.Action == DirSyncActionSkip {
+				metric.AddSkippedCount(1)
+				if r.Flag == objectOperations.DiffFlagRemoteOnly {
+					log.InfoF("Skip key:%s because it only exists in bucket and delete is not enabled", r.Key)
+				} else {
+					log.DebugF("Skip key:%s because it is not changed", r.Key)
+				}
+				return
+			}
+
+			metric.AddSuccessCount(1)
+			if info.DryRun {
+				log.AlertF("DryRun\t%s\t%s", r.Action, r.Key)
+				return
+			}
+			exporter.Success().ExportF("%s\t%s", r.Action, r.Key)
+			if r.Action == DirSyncActionDelete {
+				log.AlertF("Delete success, [%s:%s]", info.Bucket, r.Key)
+			}
+		}).
+		OnWorkFail(func(workInfo *flow.WorkInfo, err *data.CodeError, stat *flow.WorkStat) {
+			metric.AddFailureCount(1)
+			key := workInfo.Data
+			if work, ok := workInfo.Work.(*DirSyncWork); ok {
+				key = work.Key
+			}
+			exporter.Fail().ExportF("%s%s%v", key, flow.ErrorSeparate, err)
+			log.ErrorF("Dir Sync Failed, %s, Error: %v", key, err)
+		}).Build().Start()
+
+	metric.End()
+	log.InfoF("Dir sync result, success:%d skipped:%d failure:%d, duration:%ds",
+		metric.SuccessCount, metric.SkippedCount, metric.FailureCount, metric.Duration)
+	if listErr := listError(); listErr != nil {
+		data.SetCmdStatusError()
+		log.ErrorF("list bucket:%s error, the dir sync is incomplete, error:%v", info.Bucket, listErr)
+	}
+	if len(localFailedKeys) > 0 {
+		// 无法读取的本地文件不在 flow 中，需和 flow 中失败的 work 一起设置命令状态
+		data.SetCmdStatusByWorkCount(metric.SuccessCount+metric.FailureCount, metric.FailureCount)
+	}
+}
@@ -27,6 +27,9 @@ func (info *SyncInfo) Check() *data.CodeError {
 	if info.Overwrite && len(info.SaveKey) == 0 {
 		return alert.CannotEmptyError("Overwrite mode and Key", "")
 	}
+	if err := (*UploadInfo)(info).checkPartSize(); err != nil {
+		return err
+	}
 	return checkPolicy(&info.Policy)
 }
 
@@ -48,7 +51,10 @@ func SyncFile(cfg *iqshell.Config, info SyncInfo) {
 	log.DebugF("upload config:%+v", info)
 
 	info.CacheDir = workspace.GetJobDir()
-	info.Progress = progress.NewPrintProgress(" 进度")
+	if info.Progress == nil {
+		// 未指定进度回调时打印进度
+		info.Progress = progress.NewPrintProgress(" 进度")
+	}
 	ret, err := uploadFile((*UploadInfo)(&info))
 	if err != nil {
 		data.SetCmdStatusError()
@@ -26,6 +26,11 @@ type UploadInfo struct {
 	RelativePathToSrcPath string // 相对与上传文件夹的路径信息
 	Policy                storage.PutPolicy
 	DeleteOnSuccess       bool
+	FromArchive           string `json:"from_archive,omitempty"` // 文件所在的压缩包，为空表示本地文件
+	CopySource            string `json:"copy_source,omitempty"`  // 七牛空间中内容相同的源文件，格式：<SrcBucket>:<SrcKey>；设置时使用服务端复制代替上传，源文件不存在时上传本地文件
+	PartSize              string `json:"-"`                      // 分片大小，如 16m，设置时使用分片 v2 上传，优先级高于 ChunkSize
+
+	archiveEntry *archiveEntry
 }
 
 func (info *UploadInfo) Check() *data.CodeError {
@@ -41,14 +46,48 @@ func (info *UploadInfo) Check() *data.CodeError {
 	if utils.IsNetworkSource(info.FilePath) {
 		return alert.Error("file can't be network source", "")
 	}
+	if err := info.checkPartSize(); err != nil {
+		return err
+	}
 
 	return checkPolicy(&info.Policy)
 }
 
+// checkPartSize 解析 PartSize；分片 v1 的块大小固定为 4M，因此设置分片大小时使用分片 v2 上传
+func (info *UploadInfo) checkPartSize() *data.CodeError {
+	if len(info.PartSize) == 0 {
+		return nil
+	}
+
+	partSize, err := utils.ParseFileSize(info.PartSize)
+	if err != nil {
+		return alert.Error("invalid part size: "+info.PartSize, "part size should be like 4m, 16m")
+	}
+	if err = upload.CheckPartSize(partSize); err != nil {
+		return err
+	}
+	info.UseResumeV2 = true
+	info.ChunkSize = partSize
+	return nil
+}
+
 func (info *UploadInfo) WorkId() string {
 	return fmt.Sprintf("%s:%s:%s", info.FilePath, info.ToBucket, info.SaveKey)
 }
 
+// GetSize 本地文件大小，未知时读取本地文件信息
+func (info *UploadInfo) GetSize() (int64, *data.CodeError) {
+	if info.LocalFileSize > 0 {
+		return info.LocalFileSize, nil
+	}
+
+	stat, err := os.Stat(info.FilePath)
+	if err != nil {
+		return 0, data.NewEmptyError().AppendDescF("get local file:%s stat", info.FilePath).AppendError(err)
+	}
+	return stat.Size(), nil
+}
+
 func checkPolicy(policy *storage.PutPolicy) *data.CodeError {
 	if policy.CallbackURL == "" {
 		return nil
@@ -83,7 +122,10 @@ func UploadFile(cfg *iqshell.Config, info UploadInfo) {
 	log.DebugF("upload config:%+v", info)
 
 	info.CacheDir = workspace.GetJobDir()
-	info.Progress = progress.NewPrintProgress(" 进度")
+	if info.Progress == nil {
+		// 未指定进度回调时打印进度
+		info.Progress = progress.NewPrintProgress(" 进度")
+	}
 	ret, err := uploadFile(&info)
 	if err != nil {
 		data.SetCmdStatusError()
@@ -95,6 +137,9 @@ func UploadFile(cfg *iqshell.Config, info UploadInfo) {
 		log.AlertF("%10s%s", "Hash: ", ret.ServerFileHash)
 		log.AlertF("%10s%d%s", "FileSize: ", ret.ServerFileSize, "("+utils.FormatFileSize(ret.ServerFileSize)+")")
 		log.AlertF("%10s%s", "MimeType: ", ret.MimeType)
+		if len(ret.EndUser) > 0 {
+			log.AlertF("%10s%s", "EndUser: ", ret.EndUser)
+		}
 	}
 }
 
@@ -120,7 +165,11 @@ func uploadFile(info *UploadInfo) (res *upload.ApiResult, err *data.CodeError) {
 	if res.IsSkip {
 		log.AlertF("Upload skip because file exist:%s => [%s:%s]", info.FilePath, info.ToBucket, info.SaveKey)
 	} else {
-		log.AlertF("Upload File success %s => [%s:%s] duration:%.2fs Speed:%s", info.FilePath, info.ToBucket, info.SaveKey, duration, speed)
+		if len(res.EndUser) > 0 {
+			log.AlertF("Upload File success %s => [%s:%s] hash:%s endUser:%s duration:%.2fs Speed:%s", info.FilePath, info.ToBucket, info.SaveKey, res.ServerFileHash, res.EndUser, duration, speed)
+		} else {
+			log.AlertF("Upload File success %s => [%s:%s] duration:%.2fs Speed:%s", info.FilePath, info.ToBucket, info.SaveKey, duration, speed)
+		}
 
 		//delete on success
 		if info.DeleteOnSuccess {
@@ -0,0 +1,110 @@
+package operations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object/upload"
+)
+
+// parseCopySource 解析服务端复制的源文件，格式：<SrcBucket>:<SrcKey>，key 中可以包含 :
+func parseCopySource(source string) (bucket string, key string, err *data.CodeError) {
+	items := strings.SplitN(source, ":", 2)
+	if len(items) != 2 || len(items[0]) == 0 || len(items[1]) == 0 {
+		return "", "", alert.Error("invalid copy source: "+source, "copy source should be <SrcBucket>:<SrcKey>")
+	}
+	return items[0], items[1], nil
+}
+
+// copyUnsupportedPolicyFields 服务端复制不经过上传，上传策略中依赖上传的字段无法生效，返回已设置的这些字段的配置名
+func copyUnsupportedPolicyFields(policy *storage.PutPolicy) []string {
+	fields := make([]string, 0)
+	if len(policy.EndUser) > 0 {
+		fields = append(fields, "end_user")
+	}
+	if len(policy.CallbackURL) > 0 {
+		fields = append(fields, "callback_url")
+	}
+	if len(policy.PersistentOps) > 0 {
+		fields = append(fields, "persistent_ops")
+	}
+	if policy.DetectMime != 0 {
+		fields = append(fields, "detect_mime")
+	}
+	if policy.FileType != 0 {
+		fields = append(fields, "file_type")
+	}
+	if policy.DeleteAfterDays != 0 {
+		fields = append(fields, "delete_after_days")
+	}
+	return fields
+}
+
+// copyFromSource 源文件已存在于七牛空间时，使用服务端复制代替上传；
+// 源文件不存在时 fallback 为 true，需上传本地文件；目标文件已存在且不覆盖时不复制；
+// 上传策略中设置了复制无法生效的字段时报错，不复制也不上传
+func copyFromSource(info *UploadInfo) (res *upload.ApiResult, fallback bool, err *data.CodeError) {
+	srcBucket, srcKey, err := parseCopySource(info.CopySource)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if fields := copyUnsupportedPolicyFields(&info.Policy); len(fields) > 0 {
+		return nil, false, alert.Error(fmt.Sprintf("copy source %s is not supported with %s", info.CopySource, strings.Join(fields, ", ")),
+			"remove the copy source from file list or remove these fields from upload config")
+	}
+
+	result, err := object.Copy(&object.CopyApiInfo{
+		SourceBucket: srcBucket,
+		SourceKey:    srcKey,
+		DestBucket:   info.ToBucket,
+		DestKey:      info.SaveKey,
+		Force:        info.Overwrite,
+	})
+	if err == nil && result == nil {
+		err = data.NewEmptyError().AppendDesc("copy: no result")
+	}
+	if err == nil && !result.IsSuccess() {
+		err = data.NewError(result.Code, result.Error)
+	}
+
+	if err.IsNotFound() {
+		log.WarningF("Copy source [%s:%s] doesn't exist, upload local file `%s` instead", srcBucket, srcKey, info.FilePath)
+		return nil, true, nil
+	}
+	if err.IsAlreadyExists() {
+		log.WarningF("Skip copy of [%s:%s] => [%s:%s] because `overwrite` is false",
+			srcBucket, srcKey, info.ToBucket, info.SaveKey)
+		return &upload.ApiResult{
+			IsNotOverwrite: true,
+		}, false, nil
+	}
+	if err != nil {
+		return nil, false, data.NewEmptyError().AppendDescF("copy [%s:%s] => [%s:%s]", srcBucket, srcKey, info.ToBucket, info.SaveKey).AppendError(err)
+	}
+
+	// 复制结果不包含文件信息，查询目标文件的信息作为上传结果
+	stat, sErr := object.Status(object.StatusApiInfo{
+		Bucket:   info.ToBucket,
+		Key:      info.SaveKey,
+		NeedPart: false,
+	})
+	if sErr != nil {
+		return nil, false, data.NewEmptyError().AppendDesc("get stat after copy").AppendError(sErr)
+	}
+	log.AlertF("Copy success [%s:%s] => [%s:%s]", srcBucket, srcKey, info.ToBucket, info.SaveKey)
+	return &upload.ApiResult{
+		Key:            info.SaveKey,
+		MimeType:       stat.MimeType,
+		ServerFileSize: stat.FSize,
+		ServerFileHash: stat.Hash,
+		ServerPutTime:  stat.PutTime,
+		EndUser:        stat.EndUser,
+	}, false, nil
+}
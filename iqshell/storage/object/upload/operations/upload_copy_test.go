@@ -0,0 +1,37 @@
+package operations
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+)
+
+func TestParseCopySource(t *testing.T) {
+	bucket, key, err := parseCopySource("bucket:dir/a:b.txt")
+	if err != nil || bucket != "bucket" || key != "dir/a:b.txt" {
+		t.Fatalf("parse copy source error, bucket:%s key:%s err:%v", bucket, key, err)
+	}
+
+	for _, source := range []string{"bucket", "bucket:", ":key", ""} {
+		if _, _, err := parseCopySource(source); err == nil {
+			t.Fatalf("copy source %s should be invalid", source)
+		}
+	}
+}
+
+func TestCopyUnsupportedPolicyFields(t *testing.T) {
+	if fields := copyUnsupportedPolicyFields(&storage.PutPolicy{TrafficLimit: 1024}); len(fields) > 0 {
+		t.Fatalf("policy should be supported, but:%v", fields)
+	}
+
+	fields := copyUnsupportedPolicyFields(&storage.PutPolicy{
+		EndUser:         "user",
+		PersistentOps:   "avthumb/mp4",
+		FileType:        1,
+		DeleteAfterDays: 7,
+	})
+	if !reflect.DeepEqual(fields, []string{"end_user", "persistent_ops", "file_type", "delete_after_days"}) {
+		t.Fatalf("unsupported fields error:%v", fields)
+	}
+}
@@ -0,0 +1,35 @@
+package operations
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+var endUserDirPattern = regexp.MustCompile(`\{dir(\d+)\}`)
+
+// resolveEndUser 解析 endUser 模版，{dirN} 为文件相对路径中的第 N 级目录（从 1 开始），
+// 如：文件 a/b/c.txt 的 {dir1} 为 a，{dir2} 为 b；目录不存在时返回错误
+func resolveEndUser(template string, relativePath string) (string, *data.CodeError) {
+	if !strings.Contains(template, "{dir") {
+		return template, nil
+	}
+
+	dirs := strings.Split(filepath.ToSlash(relativePath), "/")
+	dirs = dirs[:len(dirs)-1]
+	var err *data.CodeError
+	endUser := endUserDirPattern.ReplaceAllStringFunc(template, func(s string) string {
+		index, _ := strconv.Atoi(endUserDirPattern.FindStringSubmatch(s)[1])
+		if index < 1 || index > len(dirs) {
+			if err == nil {
+				err = data.NewEmptyError().AppendDescF("resolve endUser %s for %s: dir%d doesn't exist", template, relativePath, index)
+			}
+			return ""
+		}
+		return dirs[index-1]
+	})
+	return endUser, err
+}
@@ -0,0 +1,25 @@
+package operations
+
+import "testing"
+
+func TestResolveEndUser(t *testing.T) {
+	cases := []struct {
+		template string
+		path     string
+		endUser  string
+		hasErr   bool
+	}{
+		{"owner", "a/b/c.txt", "owner", false},
+		{"{dir1}", "a/b/c.txt", "a", false},
+		{"{dir1}-{dir2}", "a/b/c.txt", "a-b", false},
+		{"{dir3}", "a/b/c.txt", "", true},
+		{"{dir0}", "a/b/c.txt", "", true},
+		{"{dir1}", "c.txt", "", true},
+	}
+	for _, c := range cases {
+		endUser, err := resolveEndUser(c.template, c.path)
+		if (err != nil) != c.hasErr || (!c.hasErr && endUser != c.endUser) {
+			t.Fatalf("resolve %s of %s, except:%s hasErr:%v but:%s err:%v", c.template, c.path, c.endUser, c.hasErr, endUser, err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package upload
+
+import (
+	"fmt"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+const (
+	ResumeV2MinPartSize  = 1 * utils.MB
+	ResumeV2MaxPartSize  = 1 * utils.GB
+	ResumeV2MaxPartCount = 10000
+)
+
+// CheckPartSize 检查分片 v2 的分片大小，范围为 [1M, 1G]
+func CheckPartSize(partSize int64) *data.CodeError {
+	if partSize < ResumeV2MinPartSize || partSize > ResumeV2MaxPartSize {
+		return alert.Error(fmt.Sprintf("part size %s is out of range", utils.FormatFileSize(partSize)),
+			"part size should be between 1M and 1G")
+	}
+	return nil
+}
+
+// CheckPartCount 检查分片 v2 上传文件时的分片数，不能超过 10000
+func CheckPartCount(fileSize, partSize int64) *data.CodeError {
+	if partSize <= 0 {
+		return alert.Error("part size should be greater than 0", "")
+	}
+
+	partCount := (fileSize + partSize - 1) / partSize
+	if partCount > ResumeV2MaxPartCount {
+		minPartSize := (fileSize + ResumeV2MaxPartCount - 1) / ResumeV2MaxPartCount
+		return alert.Error(fmt.Sprintf("file size %s needs %d parts with part size %s, exceeds the limit of %d parts",
+			utils.FormatFileSize(fileSize), partCount, utils.FormatFileSize(partSize), ResumeV2MaxPartCount),
+			fmt.Sprintf("part size should be at least %s", utils.FormatFileSize(minPartSize)))
+	}
+	return nil
+}
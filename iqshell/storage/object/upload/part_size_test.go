@@ -0,0 +1,32 @@
+package upload
+
+import (
+	"testing"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+func TestCheckPartSize(t *testing.T) {
+	for _, size := range []int64{utils.MB, 4 * utils.MB, utils.GB} {
+		if err := CheckPartSize(size); err != nil {
+			t.Fatalf("part size %d should be valid, err:%v", size, err)
+		}
+	}
+	for _, size := range []int64{0, utils.MB - 1, utils.GB + 1} {
+		if err := CheckPartSize(size); err == nil {
+			t.Fatalf("part size %d should be invalid", size)
+		}
+	}
+}
+
+func TestCheckPartCount(t *testing.T) {
+	if err := CheckPartCount(10000*4*utils.MB, 4*utils.MB); err != nil {
+		t.Fatal("10000 parts should be valid, err:", err)
+	}
+	if err := CheckPartCount(10000*4*utils.MB+1, 4*utils.MB); err == nil {
+		t.Fatal("10001 parts should be invalid")
+	}
+	if err := CheckPartCount(0, 4*utils.MB); err != nil {
+		t.Fatal("empty file should be valid, err:", err)
+	}
+}
@@ -0,0 +1,159 @@
+package upload
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+	"github.com/qiniu/qshell/v2/iqshell/storage/object"
+)
+
+const (
+	resumeVersionV1 = "v1"
+	resumeVersionV2 = "v2"
+
+	// sharedRecordExpireMargin 和 go SDK 一致，上传上下文在过期前 2 小时即视为过期
+	sharedRecordExpireMargin = 2 * time.Hour
+)
+
+// sharedRecorder 将分片上传的断点记录（v1 为各块的 ctx，v2 为 upload id 及各分片的 etag）保存在多台机器共享的目录中，
+// 记录名由 bucket、key、文件 hash、分片上传版本及分片大小确定，与本地文件的路径及修改时间无关，
+// 任意机器上传同一文件到同一位置时都可以续传；记录中的上传上下文已过期时丢弃记录，重新上传。
+// 注：同一文件不能同时在多台机器上上传
+type sharedRecorder struct {
+	dir           string
+	recordName    string
+	resumeVersion string
+	modTime       int64 // 本地文件的修改时间，单位：ns；go SDK 恢复记录时会校验此值
+}
+
+func newSharedRecorder(info *ApiInfo, resumeVersion string) (storage.Recorder, *data.CodeError) {
+	if err := os.MkdirAll(info.ResumeRecordDir, 0700); err != nil {
+		return nil, data.NewEmptyError().AppendDescF("create resume record dir:%s error:%v", info.ResumeRecordDir, err)
+	}
+
+	stat, err := os.Stat(info.FilePath)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDescF("get local file:%s status error:%v", info.FilePath, err)
+	}
+
+	hash, hErr := object.LocalFileEtag(info.FilePath, info.UseEtagCache)
+	if hErr != nil {
+		return nil, hErr
+	}
+
+	return &sharedRecorder{
+		dir:           info.ResumeRecordDir,
+		recordName:    sharedRecordName(info.ToBucket, info.SaveKey, hash, resumeVersion, info.ChunkSize),
+		resumeVersion: resumeVersion,
+		modTime:       stat.ModTime().UnixNano(),
+	}, nil
+}
+
+func sharedRecordName(bucket, key, fileHash, resumeVersion string, chunkSize int64) string {
+	return utils.Md5Hex(fmt.Sprintf("%s:%s:%s:%s:%d", bucket, key, fileHash, resumeVersion, chunkSize))
+}
+
+func (r *sharedRecorder) path() string {
+	return filepath.Join(r.dir, r.recordName)
+}
+
+// Set 先写入临时文件再重命名，避免其他机器读到写了一半的记录
+func (r *sharedRecorder) Set(key string, data []byte) error {
+	tempPath := fmt.Sprintf("%s.%d.tmp", r.path(), os.Getpid())
+	if err := os.WriteFile(tempPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, r.path())
+}
+
+func (r *sharedRecorder) Get(key string) ([]byte, error) {
+	content, err := os.ReadFile(r.path())
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := r.localRecord(content, time.Now())
+	if err != nil {
+		log.InfoF("discard shared resume record:%s, %v", r.path(), err)
+		_ = r.Delete(key)
+		return nil, err
+	}
+	log.DebugF("resume from shared record:%s", r.path())
+	return record, nil
+}
+
+func (r *sharedRecorder) Delete(key string) error {
+	if err := os.Remove(r.path()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// GenerateRecorderKey 记录名在创建时已确定，和 go SDK 生成 key 使用的文件路径等信息无关
+func (r *sharedRecorder) GenerateRecorderKey(keyInfos []string, sourceFileInfo os.FileInfo) string {
+	return r.recordName
+}
+
+// localRecord 检查记录中的上传上下文是否过期，然后将记录中的文件修改时间替换为本地文件的修改时间，
+// 记录名中包含文件 hash，文件内容相同时其他机器的记录也可以使用；
+// 记录为 go SDK 内部的格式（e：过期时间，c：分片信息，m：修改时间），升级 SDK 时由 TestSharedRecorderWithSDK 校验
+func (r *sharedRecorder) localRecord(content []byte, now time.Time) ([]byte, error) {
+	record := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(content, &record); err != nil {
+		return nil, fmt.Errorf("record format error:%v", err)
+	}
+
+	expiredAt := make([]int64, 0)
+	if r.resumeVersion == resumeVersionV2 {
+		// v2 上传上下文的过期时间为 upload id 的过期时间
+		var e int64
+		if err := json.Unmarshal(record["e"], &e); err != nil {
+			return nil, fmt.Errorf("record expire time error:%v", err)
+		}
+		expiredAt = append(expiredAt, e)
+	} else {
+		// v1 每个块的 ctx 都有过期时间，有一个过期记录即无效
+		var contexts []struct {
+			ExpiredAt int64 `json:"e"`
+		}
+		if err := json.Unmarshal(record["c"], &contexts); err != nil {
+			return nil, fmt.Errorf("record contexts error:%v", err)
+		}
+		for _, c := range contexts {
+			expiredAt = append(expiredAt, c.ExpiredAt)
+		}
+	}
+	for _, e := range expiredAt {
+		if now.After(time.Unix(e, 0).Add(-sharedRecordExpireMargin)) {
+			return nil, errors.New("upload context has expired")
+		}
+	}
+
+	modTime, _ := json.Marshal(r.modTime)
+	record["m"] = modTime
+	return json.Marshal(record)
+}
+
+// newResumeRecorder 分片上传的断点记录，设置了 ResumeRecordDir 时保存在共享目录，否则保存在 CacheDir，都未设置时不记录
+func newResumeRecorder(info *ApiInfo, resumeVersion string) (storage.Recorder, *data.CodeError) {
+	if len(info.ResumeRecordDir) > 0 {
+		return newSharedRecorder(info, resumeVersion)
+	}
+	if len(info.CacheDir) == 0 {
+		return nil, nil
+	}
+	recorder, err := storage.NewFileRecorder(info.CacheDir)
+	if err != nil {
+		return nil, data.NewEmptyError().AppendDesc("new recorder error:" + err.Error())
+	}
+	return recorder, nil
+}
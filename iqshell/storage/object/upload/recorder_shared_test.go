@@ -0,0 +1,226 @@
+package upload
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qiniu/go-sdk/v7/auth/qbox"
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/utils"
+)
+
+func TestSharedRecorderLocalRecord(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	valid := now.Add(24 * time.Hour).Unix()
+	expired := now.Add(time.Hour).Unix()
+
+	r := &sharedRecorder{resumeVersion: resumeVersionV2, modTime: 123}
+	content := []byte(`{"v":"1.0.2","s":1024,"m":456,"e":` + strconv.FormatInt(valid, 10) + `,"i":"id","c":[{"o":0,"e":"etag","s":1024,"p":1}]}`)
+	record, err := r.localRecord(content, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]interface{})
+	if e := json.Unmarshal(record, &values); e != nil {
+		t.Fatal(e)
+	}
+	if values["m"] != float64(123) || values["i"] != "id" {
+		t.Fatalf("local record error:%s", string(record))
+	}
+
+	content = []byte(`{"v":"1.0.2","s":1024,"m":456,"e":` + strconv.FormatInt(expired, 10) + `,"i":"id","c":[]}`)
+	if _, err = r.localRecord(content, now); err == nil {
+		t.Fatal("expired v2 record should be discarded")
+	}
+
+	r = &sharedRecorder{resumeVersion: resumeVersionV1, modTime: 123}
+	content = []byte(`{"v":"1.0.2","s":1024,"m":456,"c":[{"c":"ctx1","e":` + strconv.FormatInt(valid, 10) + `},{"c":"ctx2","e":` + strconv.FormatInt(expired, 10) + `}]}`)
+	if _, err = r.localRecord(content, now); err == nil {
+		t.Fatal("v1 record with an expired block should be discarded")
+	}
+}
+
+func TestSharedRecordName(t *testing.T) {
+	name := sharedRecordName("bucket", "key", "hash", resumeVersionV2, 4*1024*1024)
+	if name != sharedRecordName("bucket", "key", "hash", resumeVersionV2, 4*1024*1024) {
+		t.Fatal("record name should be deterministic")
+	}
+	if name == sharedRecordName("bucket", "key", "hash2", resumeVersionV2, 4*1024*1024) {
+		t.Fatal("record name should depend on file hash")
+	}
+}
+
+// testUpServer 模拟分片上传服务，failUpload 为 true 时只有第一个分片（块）上传成功，其余返回错误；
+// uploadCount 为上传成功的分片（块）数
+type testUpServer struct {
+	*httptest.Server
+	failUpload  int32
+	uploadCount int64
+}
+
+func newTestUpServer() *testUpServer {
+	s := &testUpServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Reqid", "reqid")
+
+		var ret interface{}
+		switch {
+		case path[0] == "mkblk":
+			// v1 创建块
+			if !s.uploadPart() {
+				w.WriteHeader(http.StatusBadRequest)
+				ret = map[string]string{"error": "upload block error"}
+				break
+			}
+			ret = map[string]interface{}{
+				"ctx":        fmt.Sprintf("ctx_%d", len(body)),
+				"checksum":   "checksum",
+				"crc32":      crc32.ChecksumIEEE(body),
+				"offset":     len(body),
+				"host":       s.URL,
+				"expired_at": time.Now().Add(7 * 24 * time.Hour).Unix(),
+			}
+		case path[0] == "mkfile":
+			ret = map[string]string{"hash": "hash", "key": "key"}
+		case len(path) == 5:
+			// v2 初始化
+			ret = map[string]interface{}{
+				"uploadId": "upload_id",
+				"expireAt": time.Now().Add(7 * 24 * time.Hour).Unix(),
+			}
+		case len(path) == 7:
+			// v2 上传分片
+			if path[6] != "1" && atomic.LoadInt32(&s.failUpload) == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				ret = map[string]string{"error": "upload part error"}
+				break
+			}
+			atomic.AddInt64(&s.uploadCount, 1)
+			md5Value := md5.Sum(body)
+			ret = map[string]string{"etag": "etag_" + path[6], "md5": hex.EncodeToString(md5Value[:])}
+		default:
+			// v2 完成上传
+			ret = map[string]string{"hash": "hash", "key": "key"}
+		}
+		_ = json.NewEncoder(w).Encode(ret)
+	}))
+	return s
+}
+
+// uploadPart v1 无法区分块的序号，失败时只有第一个请求成功
+func (s *testUpServer) uploadPart() bool {
+	if atomic.LoadInt32(&s.failUpload) == 1 && atomic.LoadInt64(&s.uploadCount) > 0 {
+		return false
+	}
+	atomic.AddInt64(&s.uploadCount, 1)
+	return true
+}
+
+// TestSharedRecorderWithSDK 使用 go SDK 实际生成的断点记录，校验 localRecord 解析的字段及替换修改时间后 SDK 可以续传，
+// SDK 升级后记录格式变化时此测试会失败
+func TestSharedRecorderWithSDK(t *testing.T) {
+	for _, resumeVersion := range []string{resumeVersionV1, resumeVersionV2} {
+		t.Run(resumeVersion, func(t *testing.T) {
+			testSharedRecorderWithSDK(t, resumeVersion)
+		})
+	}
+}
+
+func testSharedRecorderWithSDK(t *testing.T, resumeVersion string) {
+	server := newTestUpServer()
+	defer server.Close()
+
+	// v1 块大小固定为 4M，v2 分片大小为 1M，都分为 2 个分片（块）上传
+	partSize := utils.MB
+	if resumeVersion == resumeVersionV1 {
+		partSize = 4 * utils.MB
+	}
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "file")
+	if err := os.WriteFile(filePath, []byte(strings.Repeat("a", int(2*partSize))), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	token := (&storage.PutPolicy{Scope: "bucket"}).UploadToken(qbox.NewMac("ak", "sk"))
+	info := &ApiInfo{
+		FilePath:        filePath,
+		ToBucket:        "bucket",
+		SaveKey:         "key",
+		UpHost:          server.URL,
+		TokenProvider:   func() string { return token },
+		TryTimes:        1,
+		LocalFileSize:   int64(2 * partSize),
+		ChunkSize:       utils.MB,
+		ResumeRecordDir: filepath.Join(dir, "record"),
+		ctx:             context.Background(),
+	}
+	var uploader Uploader
+	if resumeVersion == resumeVersionV1 {
+		uploader = newResumeV1Uploader(&storage.Config{})
+	} else {
+		uploader = newResumeV2Uploader(&storage.Config{})
+	}
+
+	// 第二个分片（块）上传失败，保留断点记录
+	atomic.StoreInt32(&server.failUpload, 1)
+	if _, err := uploader.upload(info); err == nil {
+		t.Fatal("upload should fail")
+	}
+	recorder, rErr := newSharedRecorder(info, resumeVersion)
+	if rErr != nil {
+		t.Fatal(rErr)
+	}
+	content, err := os.ReadFile(recorder.(*sharedRecorder).path())
+	if err != nil {
+		t.Fatal("shared record should be saved, err:", err)
+	}
+
+	// SDK 生成的记录可以解析，且未过期
+	r := &sharedRecorder{resumeVersion: resumeVersion, modTime: 123}
+	record, err := r.localRecord(content, time.Now())
+	if err != nil {
+		t.Fatalf("parse sdk record error:%v, record:%s", err, string(content))
+	}
+	values := make(map[string]interface{})
+	if e := json.Unmarshal(record, &values); e != nil {
+		t.Fatal(e)
+	}
+	if values["m"] != float64(123) {
+		t.Fatalf("record modify time should be replaced, record:%s", string(record))
+	}
+	if _, err = r.localRecord(content, time.Now().Add(8*24*time.Hour)); err == nil {
+		t.Fatalf("record should expire, record:%s", string(content))
+	}
+
+	// 修改本地文件的修改时间（相当于其他机器上的同一文件），仍可续传，只需上传剩余的分片（块）
+	modTime := time.Now().Add(-time.Hour)
+	if e := os.Chtimes(filePath, modTime, modTime); e != nil {
+		t.Fatal(e)
+	}
+	atomic.StoreInt32(&server.failUpload, 0)
+	atomic.StoreInt64(&server.uploadCount, 0)
+	if _, err := uploader.upload(info); err != nil {
+		t.Fatal("upload error:", err)
+	}
+	if c := atomic.LoadInt64(&server.uploadCount); c != 1 {
+		t.Fatal("should resume from the shared record and upload 1 part, but uploaded:", c)
+	}
+}
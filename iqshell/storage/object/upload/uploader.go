@@ -1,7 +1,9 @@
 package upload
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"sync"
@@ -32,6 +34,7 @@ type ApiInfo struct {
 	UpHost              string            `json:"up_host"`                // 上传使用的域名
 	Accelerate          bool              `json:"upload_acceleration"`    // 启用上传加速
 	TokenProvider       func() string     `json:"-"`                      // token provider
+	UseEtagCache        bool              `json:"-"`                      // 检查 hash 时使用本地文件 etag 的缓存，文件的大小和修改时间未变化时不再重新计算 【可选】
 	TryTimes            int               `json:"-"`                      // 失败时，最多重试次数【可选】
 	TryInterval         time.Duration     `json:"-"`                      // 重试间隔时间 【可选】
 	LocalFileSize       int64             `json:"local_file_size"`        // 待上传文件的大小, 如果不配置会动态读取 【可选】
@@ -39,31 +42,48 @@ type ApiInfo struct {
 	DisableForm         bool              `json:"-"`                      // 不使用 form 上传 【可选】
 	DisableResume       bool              `json:"-"`                      // 不使用分片上传 【可选】
 	UseResumeV2         bool              `json:"-"`                      // 分片上传时是否使用分片 v2 上传 【可选】
-	ResumeWorkerCount   int               `json:"-"`                      // 分片上传 worker 数量
+	ResumeWorkerCount   int               `json:"-"`                      // 分片上传 worker 数量，本地文件上传时为所有文件共享的分片并发数，网络资源同步时为单个文件的分片并发数
 	ChunkSize           int64             `json:"-"`                      // 分片上传时的分片大小
 	PutThreshold        int64             `json:"-"`                      // 分片上传时上传阈值
 	CacheDir            string            `json:"-"`                      // 临时数据保存路径
+	ResumeRecordDir     string            `json:"-"`                      // 分片上传断点记录的共享目录，设置后多台机器可以续传同一文件，参考 sharedRecorder 【可选】
 	SequentialReadFile  bool              `json:"-"`                      // 文件是否使用顺序读
 	Progress            progress.Progress `json:"-"`                      // 上传进度回调
+	MaxRedirects        int               `json:"-"`                      // 网络资源最多跟随重定向的次数，为 0 时不跟随重定向 【可选】
+	Metadata            map[string]string `json:"metadata,omitempty"`     // 自定义元数据，key 以 x-qn-meta- 开头，参考 NormalizeMetadata 【可选】
+	Reader              io.Reader         `json:"-"`                      // 待上传的数据流，设置时从数据流读取数据上传，FilePath 仅作为标识，需同时设置 LocalFileSize 【可选】
+
+	ctx context.Context // 上传请求使用的 context，超过 overall timeout 时取消 【内部使用】
+}
+
+func (a *ApiInfo) context() context.Context {
+	if a.ctx == nil {
+		return workspace.GetContext()
+	}
+	return a.ctx
 }
 
 func (a *ApiInfo) WorkId() string {
 	return fmt.Sprintf("%s:%s:%s", a.ToBucket, a.SaveKey, a.FilePath)
 }
 
+func (a *ApiInfo) GetKey() string {
+	return a.SaveKey
+}
+
 func (a *ApiInfo) Check() *data.CodeError {
 	if len(a.FilePath) == 0 {
 		return alert.CannotEmptyError("upload file path", "")
 	}
 
 	// 获取文件信息
-	if a.LocalFileSize == 0 || a.LocalFileModifyTime == 0 {
+	if a.Reader == nil && (a.LocalFileSize == 0 || a.LocalFileModifyTime == 0) {
 		if utils.IsNetworkSource(a.FilePath) {
-			localFileSize, nErr := utils.NetworkFileLength(a.FilePath)
+			file, nErr := utils.GetNetworkFileInfoWithClient(newSyncClient(a.MaxRedirects, httpTimeout), a.FilePath)
 			if nErr != nil {
 				return data.NewEmptyError().AppendDescF("get network file:%s size error:%v", a.FilePath, nErr)
 			}
-			a.LocalFileSize = localFileSize
+			a.LocalFileSize = file.Size
 		} else {
 			localFileStatus, sErr := os.Stat(a.FilePath)
 			if sErr != nil {
@@ -95,6 +115,7 @@ type ApiResult struct {
 	ServerFileSize int64  `json:"file_size"` // 文件大小
 	ServerFileHash string `json:"hash"`      // 文件 etag
 	ServerPutTime  int64  `json:"put_time"`  // 文件上传时间
+	EndUser        string `json:"end_user"`  // 文件的属主标识
 	IsSkip         bool   `json:"-"`         // 是否被 skip
 	IsNotOverwrite bool   `json:"-"`         // 是否因未开启 overwrite 而未覆盖之前的上传
 	IsOverwrite    bool   `json:"-"`         // 覆盖之前的上传
@@ -107,7 +128,7 @@ func (a *ApiResult) IsValid() bool {
 }
 
 func ApiResultFormat() string {
-	return `{"key":"$(key)","hash":"$(etag)","file_size":$(fsize),"mime_type":"$(mimeType)"}`
+	return `{"key":"$(key)","hash":"$(etag)","file_size":$(fsize),"mime_type":"$(mimeType)","end_user":"$(endUser)"}`
 }
 
 type Uploader interface {
@@ -122,16 +143,30 @@ func Upload(info *ApiInfo) (res *ApiResult, err *data.CodeError) {
 
 	exist := false
 	match := false
-	if info.CheckExist {
+	if info.CheckExist && info.Reader != nil {
+		// 数据流无法预先计算 hash，仅对比大小
+		stat, sErr := object.Status(object.StatusApiInfo{
+			Bucket:   info.ToBucket,
+			Key:      info.SaveKey,
+			NeedPart: false,
+		})
+		if sErr == nil {
+			exist = true
+			match = stat.FSize == info.LocalFileSize
+		} else if !sErr.IsNotFound() {
+			log.DebugF("check before upload error:%v", sErr)
+		}
+	} else if info.CheckExist {
 		checkMode := object.MatchCheckModeFileSize
 		if info.CheckHash {
 			checkMode = object.MatchCheckModeFileHash
 		}
 		checkResult, mErr := object.Match(object.MatchApiInfo{
-			Bucket:    info.ToBucket,
-			Key:       info.SaveKey,
-			LocalFile: info.FilePath,
-			CheckMode: checkMode,
+			Bucket:       info.ToBucket,
+			Key:          info.SaveKey,
+			LocalFile:    info.FilePath,
+			CheckMode:    checkMode,
+			UseEtagCache: info.UseEtagCache,
 		})
 		if checkResult != nil {
 			exist = checkResult.Exist
@@ -174,7 +209,7 @@ func Upload(info *ApiInfo) (res *ApiResult, err *data.CodeError) {
 		return
 	}
 
-	if info.CheckHash {
+	if info.CheckHash && info.Reader == nil {
 		if _, mErr := object.Match(object.MatchApiInfo{
 			Bucket:         info.ToBucket,
 			Key:            info.SaveKey,
@@ -182,6 +217,7 @@ func Upload(info *ApiInfo) (res *ApiResult, err *data.CodeError) {
 			CheckMode:      object.MatchCheckModeFileHash,
 			ServerFileHash: res.ServerFileHash,
 			ServerFileSize: res.ServerFileSize,
+			UseEtagCache:   info.UseEtagCache,
 		}); mErr != nil {
 			return res, data.NewEmptyError().AppendDesc("check after upload").AppendError(mErr)
 		}
@@ -192,6 +228,8 @@ func Upload(info *ApiInfo) (res *ApiResult, err *data.CodeError) {
 
 var once sync.Once
 
+// uploadSource 分片上传时各分片由 go SDK 全局的协程池并发上传，协程池的大小为第一个上传文件的 ResumeWorkerCount，
+// 所有文件共享，以限制分片上传的总并发；单个分片失败时按 TryTimes 单独重试，不会重新上传整个文件
 func uploadSource(info *ApiInfo) (*ApiResult, *data.CodeError) {
 	once.Do(func() {
 		storage.SetSettings(&storage.Settings{
@@ -204,8 +242,18 @@ func uploadSource(info *ApiInfo) (*ApiResult, *data.CodeError) {
 	})
 	storageCfg := workspace.GetStorageConfig()
 	storageCfg.AccelerateUploading = info.Accelerate
+	if storageCfg.Region == nil {
+		storageCfg.Region = workspace.GetBucketRegion(info.ToBucket)
+		storageCfg.Zone = storageCfg.Region
+	}
+	ctx, cancel := workspace.NewTransferContext()
+	defer cancel()
+	info.ctx = ctx
+
 	var up Uploader
-	if utils.IsNetworkSource(info.FilePath) {
+	if info.Reader != nil {
+		up = newReaderUploader(storageCfg)
+	} else if utils.IsNetworkSource(info.FilePath) {
 		up = networkSourceUploader(info, storageCfg)
 	} else {
 		up = localSourceUploader(info, storageCfg)
@@ -216,7 +264,7 @@ func uploadSource(info *ApiInfo) (*ApiResult, *data.CodeError) {
 func localSourceUploader(info *ApiInfo, storageCfg *storage.Config) (up Uploader) {
 	if info.DisableResume || (!info.DisableForm && info.LocalFileSize < info.PutThreshold) {
 		up = newFromUploader(storageCfg, &storage.PutExtra{
-			Params:             nil,
+			Params:             info.Metadata,
 			UpHost:             info.UpHost,
 			MimeType:           info.MimeType,
 			HostFreezeDuration: time.Minute * 10,
@@ -8,7 +8,6 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/client"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
-	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 )
 
 type formUploader struct {
@@ -52,7 +51,7 @@ func (f *formUploader) upload(info *ApiInfo) (ret *ApiResult, err *data.CodeErro
 
 	c := client.DefaultStorageClient()
 	up := storage.NewFormUploaderEx(f.cfg, &c)
-	if e := up.Put(workspace.GetContext(), &ret, token, info.SaveKey, file, fileStatus.Size(), f.ext); e != nil {
+	if e := up.Put(info.context(), &ret, token, info.SaveKey, file, fileStatus.Size(), f.ext); e != nil {
 		err = data.NewEmptyError().AppendDesc("form upload").AppendError(e)
 	} else {
 		if info.Progress != nil {
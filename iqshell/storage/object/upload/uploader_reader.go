@@ -0,0 +1,74 @@
+package upload
+
+import (
+	"github.com/qiniu/go-sdk/v7/storage"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/client"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+	"github.com/qiniu/qshell/v2/iqshell/common/log"
+)
+
+// readerUploader 上传 ApiInfo.Reader 中的数据，数据流只能读取一次，因此上传失败时不会重试；
+// 数据流总是使用分片上传并按分片读取，内存占用与分片大小及分片上传并发数有关，与数据流的大小无关；
+// 表单上传会把整个数据流读入内存，因此仅空文件使用表单上传，也不支持 DisableResume
+type readerUploader struct {
+	cfg *storage.Config
+}
+
+func newReaderUploader(cfg *storage.Config) Uploader {
+	return &readerUploader{
+		cfg: cfg,
+	}
+}
+
+func (r *readerUploader) upload(info *ApiInfo) (ret *ApiResult, err *data.CodeError) {
+	log.DebugF("reader upload:%s => [%s:%s]", info.FilePath, info.ToBucket, info.SaveKey)
+
+	token := info.TokenProvider()
+	log.DebugF("upload token:%s", token)
+
+	if info.Progress != nil {
+		info.Progress.SetFileSize(info.LocalFileSize)
+		info.Progress.Start()
+	}
+
+	c := client.DefaultStorageClient()
+	ctx := info.context()
+	var pErr error
+	if info.DisableResume {
+		return nil, data.NewEmptyError().AppendDesc("reader upload doesn't support disable resume")
+	}
+	if info.LocalFileSize == 0 {
+		up := storage.NewFormUploaderEx(r.cfg, &c)
+		pErr = up.Put(ctx, &ret, token, info.SaveKey, info.Reader, 0, &storage.PutExtra{
+			Params:   info.Metadata,
+			UpHost:   info.UpHost,
+			MimeType: info.MimeType,
+		})
+	} else if info.UseResumeV2 {
+		if cErr := CheckPartCount(info.LocalFileSize, info.ChunkSize); cErr != nil {
+			return nil, data.NewEmptyError().AppendDesc("reader upload").AppendError(cErr)
+		}
+		up := storage.NewResumeUploaderV2Ex(r.cfg, &c)
+		pErr = up.PutWithoutSize(ctx, &ret, token, info.SaveKey, info.Reader, &storage.RputV2Extra{
+			Metadata: info.Metadata,
+			UpHost:   info.UpHost,
+			MimeType: info.MimeType,
+			PartSize: info.ChunkSize,
+		})
+	} else {
+		up := storage.NewResumeUploaderEx(r.cfg, &c)
+		pErr = up.PutWithoutSize(ctx, &ret, token, info.SaveKey, info.Reader, &storage.RputExtra{
+			Params:   info.Metadata,
+			UpHost:   info.UpHost,
+			MimeType: info.MimeType,
+		})
+	}
+
+	if pErr != nil {
+		err = data.NewEmptyError().AppendDesc("reader upload").AppendError(pErr)
+	} else if info.Progress != nil {
+		info.Progress.End()
+	}
+	return
+}
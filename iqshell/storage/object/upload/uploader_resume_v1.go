@@ -8,7 +8,6 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/client"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
-	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 )
 
 type resumeV1Uploader struct {
@@ -36,13 +35,9 @@ func (r *resumeV1Uploader) upload(info *ApiInfo) (*ApiResult, *data.CodeError) {
 		info.Progress.Start()
 	}
 
-	var recorder storage.Recorder = nil
-	if len(info.CacheDir) > 0 {
-		if re, nErr := storage.NewFileRecorder(info.CacheDir); nErr != nil {
-			return nil, data.NewEmptyError().AppendDesc("resume v1 upload: new recorder error:" + nErr.Error())
-		} else {
-			recorder = re
-		}
+	recorder, rErr := newResumeRecorder(info, resumeVersionV1)
+	if rErr != nil {
+		return nil, data.NewEmptyError().AppendDesc("resume v1 upload").AppendError(rErr)
 	}
 
 	var progress int64 = 0
@@ -51,7 +46,7 @@ func (r *resumeV1Uploader) upload(info *ApiInfo) (*ApiResult, *data.CodeError) {
 	up := storage.NewResumeUploaderEx(r.cfg, &c)
 	extra := &storage.RputExtra{
 		Recorder:   recorder,
-		Params:     nil,
+		Params:     info.Metadata,
 		UpHost:     info.UpHost,
 		MimeType:   info.MimeType,
 		TryTimes:   info.TryTimes,
@@ -80,10 +75,10 @@ func (r *resumeV1Uploader) upload(info *ApiInfo) (*ApiResult, *data.CodeError) {
 		defer file.Close()
 
 		log.Debug("resume v1 upload: put with reader")
-		pErr = up.PutWithoutSize(workspace.GetContext(), &ret, token, info.SaveKey, file, extra)
+		pErr = up.PutWithoutSize(info.context(), &ret, token, info.SaveKey, file, extra)
 	} else {
 		log.Debug("resume v1 upload: put with file path")
-		pErr = up.PutFile(workspace.GetContext(), &ret, token, info.SaveKey, info.FilePath, extra)
+		pErr = up.PutFile(info.context(), &ret, token, info.SaveKey, info.FilePath, extra)
 	}
 
 	if pErr != nil {
@@ -8,7 +8,6 @@ import (
 	"github.com/qiniu/qshell/v2/iqshell/common/client"
 	"github.com/qiniu/qshell/v2/iqshell/common/data"
 	"github.com/qiniu/qshell/v2/iqshell/common/log"
-	"github.com/qiniu/qshell/v2/iqshell/common/workspace"
 )
 
 type resumeV2Uploader struct {
@@ -28,6 +27,10 @@ func (r *resumeV2Uploader) upload(info *ApiInfo) (*ApiResult, *data.CodeError) {
 		return nil, data.NewEmptyError().AppendDesc("resume v2 upload: get file status error:" + sErr.Error())
 	}
 
+	if err := CheckPartCount(info.LocalFileSize, info.ChunkSize); err != nil {
+		return nil, data.NewEmptyError().AppendDesc("resume v2 upload").AppendError(err)
+	}
+
 	token := info.TokenProvider()
 	log.DebugF("upload token:%s", token)
 
@@ -36,13 +39,9 @@ func (r *resumeV2Uploader) upload(info *ApiInfo) (*ApiResult, *data.CodeError) {
 		info.Progress.Start()
 	}
 
-	var recorder storage.Recorder = nil
-	if len(info.CacheDir) > 0 {
-		if re, nErr := storage.NewFileRecorder(info.CacheDir); nErr != nil {
-			return nil, data.NewEmptyError().AppendDesc("resume v2 upload: new recorder error:" + nErr.Error())
-		} else {
-			recorder = re
-		}
+	recorder, rErr := newResumeRecorder(info, resumeVersionV2)
+	if rErr != nil {
+		return nil, data.NewEmptyError().AppendDesc("resume v2 upload").AppendError(rErr)
 	}
 
 	var progress int64 = 0
@@ -51,7 +50,7 @@ func (r *resumeV2Uploader) upload(info *ApiInfo) (*ApiResult, *data.CodeError) {
 	up := storage.NewResumeUploaderV2Ex(r.cfg, &c)
 	extra := &storage.RputV2Extra{
 		Recorder:   recorder,
-		Metadata:   nil,
+		Metadata:   info.Metadata,
 		CustomVars: nil,
 		UpHost:     info.UpHost,
 		MimeType:   info.MimeType,
@@ -81,10 +80,10 @@ func (r *resumeV2Uploader) upload(info *ApiInfo) (*ApiResult, *data.CodeError) {
 		defer file.Close()
 
 		log.Debug("resume v2 upload: put with reader")
-		pErr = up.PutWithoutSize(workspace.GetContext(), &ret, token, info.SaveKey, file, extra)
+		pErr = up.PutWithoutSize(info.context(), &ret, token, info.SaveKey, file, extra)
 	} else {
 		log.Debug("resume v2 upload: put with file path")
-		pErr = up.PutFile(workspace.GetContext(), &ret, token, info.SaveKey, info.FilePath, extra)
+		pErr = up.PutFile(info.context(), &ret, token, info.SaveKey, info.FilePath, extra)
 	}
 
 	if pErr != nil {
@@ -0,0 +1,70 @@
+package object
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/qiniu/qshell/v2/iqshell/common/alert"
+	"github.com/qiniu/qshell/v2/iqshell/common/data"
+)
+
+var watermarkGravities = []string{"NorthWest", "North", "NorthEast", "West", "Center", "East", "SouthWest", "South", "SouthEast"}
+
+// WatermarkInfo 水印处理的参数，图片水印和文字水印二选一
+type WatermarkInfo struct {
+	Image    string // 水印图片的链接
+	Text     string // 水印文字
+	Font     string // 文字水印的字体
+	FontSize int    // 文字水印的字号，单位：缇，0 表示默认字号
+	Fill     string // 文字水印的颜色，如：#FFFFFF
+	Dissolve int    // 透明度，1 ~ 100
+	Gravity  string // 水印位置，如：SouthEast
+	Dx       int    // 横向边距，单位：像素
+	Dy       int    // 纵向边距，单位：像素
+}
+
+func (w *WatermarkInfo) Check() *data.CodeError {
+	if len(w.Image) == 0 && len(w.Text) == 0 {
+		return alert.CannotEmptyError("watermark image or text", "")
+	}
+	if len(w.Image) > 0 && len(w.Text) > 0 {
+		return alert.Error("watermark image and text can't be set at the same time", "")
+	}
+	if w.Dissolve < 1 || w.Dissolve > 100 {
+		return alert.Error(fmt.Sprintf("invalid dissolve: %d", w.Dissolve), "dissolve should be between 1 and 100")
+	}
+	if w.FontSize < 0 {
+		return alert.Error(fmt.Sprintf("invalid font size: %d", w.FontSize), "")
+	}
+	for _, gravity := range watermarkGravities {
+		if gravity == w.Gravity {
+			return nil
+		}
+	}
+	return alert.Error("invalid gravity: "+w.Gravity, "gravity should be one of "+strings.Join(watermarkGravities, ", "))
+}
+
+// Fop 水印处理指令，图片水印为 watermark/1，文字水印为 watermark/2
+func (w *WatermarkInfo) Fop() string {
+	encode := func(value string) string {
+		return base64.URLEncoding.EncodeToString([]byte(value))
+	}
+
+	var fop string
+	if len(w.Image) > 0 {
+		fop = "watermark/1/image/" + encode(w.Image)
+	} else {
+		fop = "watermark/2/text/" + encode(w.Text)
+		if len(w.Font) > 0 {
+			fop += "/font/" + encode(w.Font)
+		}
+		if w.FontSize > 0 {
+			fop += fmt.Sprintf("/fontsize/%d", w.FontSize)
+		}
+		if len(w.Fill) > 0 {
+			fop += "/fill/" + encode(w.Fill)
+		}
+	}
+	return fop + fmt.Sprintf("/dissolve/%d/gravity/%s/dx/%d/dy/%d", w.Dissolve, w.Gravity, w.Dx, w.Dy)
+}